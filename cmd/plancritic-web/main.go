@@ -25,8 +25,10 @@ import (
 	"time"
 	"unicode/utf8"
 
+	"github.com/dshills/plancritic/internal/config"
 	"github.com/dshills/plancritic/internal/llm"
 	"github.com/dshills/plancritic/internal/profile"
+	"github.com/dshills/plancritic/internal/render"
 	"github.com/dshills/plancritic/internal/review"
 	"github.com/dshills/plancritic/internal/reviewer"
 	"github.com/spf13/cobra"
@@ -42,6 +44,7 @@ type reviewRunner func(context.Context, string, reviewer.Options, string) (revie
 type webServer struct {
 	base         reviewer.Options
 	runner       reviewRunner
+	theme        render.Theme
 	nonceMu      sync.Mutex
 	issuedNonces map[string]time.Time
 	lastPrune    time.Time
@@ -60,7 +63,7 @@ func newServeCmd() *cobra.Command {
 	f.ProfileName = serveEnvStr("PLANCRITIC_PROFILE", "general")
 	f.ProviderName = serveEnvStr("PLANCRITIC_PROVIDER", "")
 	f.Model = serveEnvStr("PLANCRITIC_MODEL", "")
-	f.MaxTokens = serveEnvInt("PLANCRITIC_MAX_TOKENS", 4096)
+	f.MaxTokens = serveEnvInt("PLANCRITIC_MAX_TOKENS", 0)
 	f.MaxIssues = serveEnvInt("PLANCRITIC_MAX_ISSUES", 50)
 	f.MaxQuestions = serveEnvInt("PLANCRITIC_MAX_QUESTIONS", 20)
 	f.MaxInputTokens = serveEnvInt("PLANCRITIC_MAX_INPUT_TOKENS", 0)
@@ -70,12 +73,25 @@ func newServeCmd() *cobra.Command {
 	f.RedactEnabled = serveEnvBool("PLANCRITIC_REDACT", true)
 	f.NoCache = serveEnvBool("PLANCRITIC_NO_CACHE", false)
 	f.CacheTTL = serveEnvStr("PLANCRITIC_CACHE_TTL", "1h")
+	f.ReadOnly = serveEnvBool("PLANCRITIC_READ_ONLY", false)
 
 	cmd := &cobra.Command{
 		Use:   "serve",
 		Short: "Run the PlanCritic HTMX web UI",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			srv := &webServer{base: f.Options, runner: reviewer.Run}
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+			theme, err := loadTheme(cfg)
+			if err != nil {
+				return err
+			}
+			f.Classifications = cfg.Classifications()
+			f.Aliases = cfg.Aliases()
+			// Concurrent requests share one limit on provider calls.
+			f.Limiter = reviewer.NewLimiter(f.Concurrency)
+			srv := &webServer{base: f.Options, runner: reviewer.Run, theme: theme}
 			mux := srv.routes()
 			writeTimeout := reviewWriteTimeout(f.Timeout)
 			log.Printf("plancritic web UI listening on http://%s", f.addr)
@@ -98,7 +114,7 @@ func newServeCmd() *cobra.Command {
 	flags.StringVar(&f.ProfileName, "profile", f.ProfileName, "Default profile name")
 	flags.StringVar(&f.SeverityThreshold, "severity-threshold", f.SeverityThreshold, "Default minimum severity: info, warn, or critical")
 	flags.BoolVar(&f.Strict, "strict", f.Strict, "Enable strict grounding mode by default")
-	flags.IntVar(&f.MaxTokens, "max-tokens", f.MaxTokens, "Max response tokens")
+	flags.IntVar(&f.MaxTokens, "max-tokens", f.MaxTokens, "Max response tokens (0 scales with the plan size)")
 	flags.IntVar(&f.MaxIssues, "max-issues", f.MaxIssues, "Max issues to return")
 	flags.IntVar(&f.MaxQuestions, "max-questions", f.MaxQuestions, "Max questions to return")
 	flags.IntVar(&f.MaxInputTokens, "max-input-tokens", f.MaxInputTokens, "Max estimated input tokens (0=unlimited)")
@@ -108,6 +124,7 @@ func newServeCmd() *cobra.Command {
 	flags.BoolVar(&f.NoCache, "no-cache", f.NoCache, "Disable prompt caching")
 	flags.StringVar(&f.CacheTTL, "cache-ttl", f.CacheTTL, "TTL for provider-side context caches")
 	flags.BoolVar(&f.Verbose, "verbose", false, "Print review progress to stderr")
+	flags.BoolVar(&f.ReadOnly, "read-only", f.ReadOnly, "Never write to the filesystem: keep uploads in memory and skip the on-disk Gemini context cache")
 
 	return cmd
 }
@@ -185,7 +202,7 @@ func (s *webServer) index(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	profiles, err := profile.List()
+	profiles, err := profile.Names()
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -234,7 +251,12 @@ func (s *webServer) check(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	r.Body = http.MaxBytesReader(w, r.Body, maxUploadBytes)
-	if err := r.ParseMultipartForm(maxUploadMemory); err != nil {
+	maxMemory := int64(maxUploadMemory)
+	if s.base.ReadOnly {
+		// Uploads past maxMemory would be spooled to temp files.
+		maxMemory = maxUploadBytes
+	}
+	if err := r.ParseMultipartForm(maxMemory); err != nil {
 		renderError(w, fmt.Errorf("failed to parse form: %w", err))
 		return
 	}
@@ -258,37 +280,65 @@ func (s *webServer) check(w http.ResponseWriter, r *http.Request) {
 		renderErrorWithNonce(w, err, nextNonce)
 	}
 
-	dir, err := os.MkdirTemp("", "plancritic-web-*")
-	if err != nil {
-		fail(err)
-		return
-	}
-	defer func() { _ = os.RemoveAll(dir) }()
+	var planPath, planName string
+	var contextPaths []string
+	var docs []reviewer.ContextDocument
+	if s.base.ReadOnly {
+		var doc reviewer.ContextDocument
+		doc, planName, err = readUploadedFile(form, "plan")
+		if err != nil {
+			fail(err)
+			return
+		}
+		planPath = doc.Name
+		docs = []reviewer.ContextDocument{doc}
+		contextDocs, err := readUploadedFiles(form, "context")
+		if err != nil {
+			fail(err)
+			return
+		}
+		docs = append(docs, contextDocs...)
+	} else {
+		dir, err := os.MkdirTemp("", "plancritic-web-*")
+		if err != nil {
+			fail(err)
+			return
+		}
+		defer func() { _ = os.RemoveAll(dir) }()
 
-	planPath, planName, err := saveUploadedFile(form, "plan", dir)
-	if err != nil {
-		fail(err)
-		return
-	}
-	contextPaths, err := saveUploadedFiles(form, "context", dir)
-	if err != nil {
-		fail(err)
-		return
+		planPath, planName, err = saveUploadedFile(form, "plan", dir)
+		if err != nil {
+			fail(err)
+			return
+		}
+		contextPaths, err = saveUploadedFiles(form, "context", dir)
+		if err != nil {
+			fail(err)
+			return
+		}
 	}
 
 	f := s.flagsFromForm(r, contextPaths)
+	if s.base.ReadOnly {
+		f.PlanText, f.ContextDocuments = docs[0].Text, docs[1:]
+	}
 	rev, err := s.runner(r.Context(), planPath, f, version)
 	if err != nil {
 		fail(err)
 		return
 	}
 
-	planLines, err := displayPlanLines(planPath)
+	var planLines []numberedLine
+	if s.base.ReadOnly {
+		planLines, err = previewPlanLines(strings.NewReader(f.PlanText))
+	} else {
+		planLines, err = displayPlanLines(planPath)
+	}
 	if err != nil {
 		fail(err)
 		return
 	}
-	findings := findingsFromReview(rev, f.SeverityThreshold)
+	findings := findingsFromReview(rev, f.SeverityThreshold, s.theme)
 	addPlanLineBadges(planLines, findings, planName, filepath.Base(planPath))
 	data := resultData{
 		Review:     rev,
@@ -347,7 +397,7 @@ func formInt(r *http.Request, key string, fallback int) int {
 }
 
 func builtinProfileExists(name string) bool {
-	profiles, err := profile.List()
+	profiles, err := profile.Names()
 	if err != nil {
 		return false
 	}
@@ -576,6 +626,55 @@ func saveUploadedFiles(form *multipart.Form, field, dir string) ([]string, error
 	return paths, nil
 }
 
+// readUploadedFile reads the upload in field into memory, for
+// read-only mode, naming it as saveUploadedFile would.
+func readUploadedFile(form *multipart.Form, field string) (reviewer.ContextDocument, string, error) {
+	files := form.File[field]
+	if len(files) == 0 || files[0].Filename == "" {
+		return reviewer.ContextDocument{}, "", fmt.Errorf("%w: %s file", errMissingUpload, field)
+	}
+	doc, err := readFileHeader(files[0], "plan")
+	if err != nil {
+		return reviewer.ContextDocument{}, "", err
+	}
+	return doc, files[0].Filename, nil
+}
+
+func readUploadedFiles(form *multipart.Form, field string) ([]reviewer.ContextDocument, error) {
+	if len(form.File[field]) > maxContextFiles {
+		return nil, fmt.Errorf("too many context files: max %d", maxContextFiles)
+	}
+	var docs []reviewer.ContextDocument
+	for i, fh := range form.File[field] {
+		if fh.Filename == "" {
+			continue
+		}
+		doc, err := readFileHeader(fh, fmt.Sprintf("%s-%d", field, i+1))
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+func readFileHeader(fh *multipart.FileHeader, prefix string) (reviewer.ContextDocument, error) {
+	name := sanitizeUploadName(fh.Filename)
+	if name == "." || name == string(filepath.Separator) {
+		return reviewer.ContextDocument{}, fmt.Errorf("invalid upload filename %q", fh.Filename)
+	}
+	src, err := fh.Open()
+	if err != nil {
+		return reviewer.ContextDocument{}, err
+	}
+	defer func() { _ = src.Close() }()
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return reviewer.ContextDocument{}, err
+	}
+	return reviewer.ContextDocument{Name: prefix + "-" + name, Text: string(data)}, nil
+}
+
 func saveFileHeader(fh *multipart.FileHeader, dir, prefix string) (path string, err error) {
 	src, err := fh.Open()
 	if err != nil {
@@ -638,6 +737,7 @@ type lineBadge struct {
 	DOMID         string
 	Label         string
 	SeverityClass string
+	Color         string
 }
 
 type findingRow struct {
@@ -646,6 +746,8 @@ type findingRow struct {
 	DOMID         string
 	Severity      review.Severity
 	SeverityClass string
+	SeverityLabel string
+	Color         string
 	Category      string
 	Title         string
 	Detail        []string
@@ -658,8 +760,13 @@ func displayPlanLines(path string) ([]numberedLine, error) {
 		return nil, err
 	}
 	defer func() { _ = file.Close() }()
+	return previewPlanLines(file)
+}
 
-	scanner := bufio.NewScanner(file)
+// previewPlanLines numbers the plan lines read from r for display, up
+// to the preview limits.
+func previewPlanLines(r io.Reader) ([]numberedLine, error) {
+	scanner := bufio.NewScanner(r)
 	scanner.Buffer(make([]byte, 0, 4096), maxPreviewLineBytes)
 	lines := make([]numberedLine, 0, 128)
 	bytesRead := 0
@@ -694,7 +801,14 @@ func displayPlanLines(path string) ([]numberedLine, error) {
 	return lines, nil
 }
 
-func findingsFromReview(rev review.Review, threshold string) []findingRow {
+// loadTheme reads the severity theme from cfg, the PlanCritic config files
+// shared with the CLI.
+func loadTheme(cfg *config.Set) (render.Theme, error) {
+	t := cfg.Theme()
+	return render.NewTheme(t.Labels, t.Icons, t.Colors)
+}
+
+func findingsFromReview(rev review.Review, threshold string, theme render.Theme) []findingRow {
 	rows := make([]findingRow, 0, len(rev.Issues)+len(rev.Questions))
 	normalizedThreshold := strings.ToLower(threshold)
 	for _, issue := range rev.Issues {
@@ -705,6 +819,8 @@ func findingsFromReview(rev review.Review, threshold string) []findingRow {
 				DOMID:         domID("issue", issue.ID),
 				Severity:      issue.Severity,
 				SeverityClass: strings.ToUpper(string(issue.Severity)),
+				SeverityLabel: theme.Tag(issue.Severity),
+				Color:         theme.Color(issue.Severity),
 				Category:      string(issue.Category),
 				Title:         issue.Title,
 				Detail:        nonEmptyStrings(issue.Description, issue.Impact, issue.Recommendation),
@@ -720,6 +836,8 @@ func findingsFromReview(rev review.Review, threshold string) []findingRow {
 				DOMID:         domID("question", question.ID),
 				Severity:      question.Severity,
 				SeverityClass: strings.ToUpper(string(question.Severity)),
+				SeverityLabel: theme.Tag(question.Severity),
+				Color:         theme.Color(question.Severity),
 				Category:      "QUESTION",
 				Title:         question.Question,
 				Detail:        questionDetail(question),
@@ -776,8 +894,9 @@ func addPlanLineBadges(lines []numberedLine, findings []findingRow, planNames ..
 				seen[n][finding.DOMID] = true
 				byLine[n] = append(byLine[n], lineBadge{
 					DOMID:         finding.DOMID,
-					Label:         strings.TrimSpace(string(finding.Severity) + " " + finding.ID),
+					Label:         strings.TrimSpace(finding.SeverityLabel + " " + finding.ID),
 					SeverityClass: finding.SeverityClass,
+					Color:         finding.Color,
 				})
 			}
 		}
@@ -877,6 +996,8 @@ func publicErrorMessage(err error) string {
 			return "Input error. Check the uploaded files and selected options."
 		case 4:
 			return "Model/provider error. Check the server logs."
+		case 7:
+			return "The model provider is rate limiting or unavailable. Try again in a few minutes."
 		}
 	}
 	return "Unexpected server error. Check the server logs."
@@ -893,6 +1014,8 @@ func statusForError(err error) int {
 			return http.StatusBadRequest
 		case 4:
 			return http.StatusBadGateway
+		case 7:
+			return http.StatusServiceUnavailable
 		default:
 			return http.StatusInternalServerError
 		}
@@ -1392,16 +1515,16 @@ const resultTemplate = `<div class="status">Completed in {{.Elapsed}}.</div>
 </section>
 <section class="card">
   <h2>Findings</h2>
-  {{if .Findings}}{{range .Findings}}<button type="button" class="finding {{.SeverityClass}}" data-modal-target="modal-{{.DOMID}}"><span class="badge {{.SeverityClass}}">{{.Severity}}</span><span class="id">{{.ID}}</span><span class="title">{{.Title}}</span></button>{{end}}{{else}}<div class="placeholder">No findings at the selected severity.</div>{{end}}
+  {{if .Findings}}{{range .Findings}}<button type="button" class="finding {{.SeverityClass}}" data-modal-target="modal-{{.DOMID}}"><span class="badge {{.SeverityClass}}"{{with .Color}} style="color:#fff;background:{{.}}"{{end}}>{{.SeverityLabel}}</span><span class="id">{{.ID}}</span><span class="title">{{.Title}}</span></button>{{end}}{{else}}<div class="placeholder">No findings at the selected severity.</div>{{end}}
 </section>
 <section class="card">
   <h2>Plan Source</h2>
-  <div class="source">{{range .PlanLines}}<div class="line {{if .Badges}}with-badges{{end}}"><div class="num">{{.Number}}</div><div class="code"><span class="line-text">{{.Text}}</span>{{if .Badges}}<span class="line-badges">{{range .Badges}}<button type="button" class="line-badge {{.SeverityClass}}" data-modal-target="modal-{{.DOMID}}">{{.Label}}</button>{{end}}</span>{{end}}</div></div>{{end}}</div>
+  <div class="source">{{range .PlanLines}}<div class="line {{if .Badges}}with-badges{{end}}"><div class="num">{{.Number}}</div><div class="code"><span class="line-text">{{.Text}}</span>{{if .Badges}}<span class="line-badges">{{range .Badges}}<button type="button" class="line-badge {{.SeverityClass}}"{{with .Color}} style="color:#fff;background:{{.}}"{{end}} data-modal-target="modal-{{.DOMID}}">{{.Label}}</button>{{end}}</span>{{end}}</div></div>{{end}}</div>
 </section>
 {{range .Findings}}<div id="modal-{{.DOMID}}" class="modal" hidden>
   <div class="modal-box" role="dialog" aria-modal="true" aria-labelledby="title-{{.DOMID}}">
     <div class="modal-head"><h3 id="title-{{.DOMID}}">{{.ID}} {{.Title}}</h3><button type="button" class="modal-close" data-modal-close>Close</button></div>
-    <div class="modal-meta"><strong>{{.Severity}}</strong>{{if .Category}} {{.Category}}{{end}}</div>
+    <div class="modal-meta"><strong>{{.SeverityLabel}}</strong>{{if .Category}} {{.Category}}{{end}}</div>
     <div class="modal-body">{{if .Detail}}{{range .Detail}}<p>{{.}}</p>{{end}}{{else}}<p>No additional detail was returned for this finding.</p>{{end}}</div>
     {{if .Evidence}}<div class="modal-evidence"><h4>Evidence</h4>{{range .Evidence}}<div>{{.Source}} {{.Path}}:{{.LineStart}}{{if ne .LineStart .LineEnd}}-{{.LineEnd}}{{end}}{{if .Quote}} - {{.Quote}}{{end}}</div>{{end}}</div>{{end}}
   </div>
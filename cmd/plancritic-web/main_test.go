@@ -8,10 +8,12 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/dshills/plancritic/internal/llm"
+	"github.com/dshills/plancritic/internal/render"
 	"github.com/dshills/plancritic/internal/review"
 	"github.com/dshills/plancritic/internal/reviewer"
 )
@@ -190,6 +192,43 @@ func TestServeCheckRunsReviewAndRendersResult(t *testing.T) {
 	}
 }
 
+func TestServeCheckReadOnlyKeepsUploadsInMemory(t *testing.T) {
+	t.Setenv("TMPDIR", filepath.Join(t.TempDir(), "missing"))
+	var gotPlan string
+	var gotFlags reviewer.Options
+	srv := &webServer{
+		base: reviewer.Options{ProfileName: "general", SeverityThreshold: "info", ReadOnly: true},
+		runner: func(_ context.Context, planPath string, f reviewer.Options, _ string) (review.Review, error) {
+			gotPlan, gotFlags = planPath, f
+			return review.Review{Tool: "plancritic", Meta: review.Meta{Model: "mock/test"}}, nil
+		},
+	}
+
+	body, contentType := multipartBody(t, map[string]string{"form_nonce": issueNonce(t, srv)}, map[string]string{
+		"plan":    "# Plan\nDo the migration\n",
+		"context": "Postgres 16\n",
+	})
+	req := httptest.NewRequest(http.MethodPost, "http://127.0.0.1/check", body)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Origin", "http://127.0.0.1")
+	rec := httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, req)
+
+	// With TMPDIR missing, any temp file would have failed the request.
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if gotPlan != "plan-plan.md" || gotFlags.PlanText != "# Plan\nDo the migration\n" {
+		t.Errorf("plan = %q with text %q, want the upload in memory", gotPlan, gotFlags.PlanText)
+	}
+	if len(gotFlags.ContextPaths) != 0 || len(gotFlags.ContextDocuments) != 1 || gotFlags.ContextDocuments[0].Name != "context-1-context.md" {
+		t.Errorf("context = %v / %+v, want one in-memory document", gotFlags.ContextPaths, gotFlags.ContextDocuments)
+	}
+	if !strings.Contains(rec.Body.String(), "Do the migration") {
+		t.Error("result body missing the plan preview")
+	}
+}
+
 func TestServeCheckRequiresPlanUpload(t *testing.T) {
 	srv := &webServer{
 		base:   reviewer.Options{ProfileName: "general"},
@@ -327,3 +366,15 @@ func issueNonce(t *testing.T, srv *webServer) string {
 	}
 	return nonce
 }
+
+func TestFindingsFromReviewTheme(t *testing.T) {
+	theme, err := render.NewTheme(map[string]string{"critical": "Blocker"}, map[string]string{"critical": "🛑"}, map[string]string{"critical": "#b91c1c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rev := review.Review{Issues: []review.Issue{{ID: "ISSUE-0001", Severity: review.SeverityCritical, Title: "x"}}}
+	rows := findingsFromReview(rev, "info", theme)
+	if len(rows) != 1 || rows[0].SeverityLabel != "🛑 Blocker" || rows[0].Color != "#b91c1c" {
+		t.Fatalf("unexpected rows: %+v", rows)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dshills/plancritic/internal/keyring"
+	"github.com/spf13/cobra"
+)
+
+// authProviders are the providers whose keys `auth` can store.
+var authProviders = []string{"anthropic", "openai", "gemini", "local"}
+
+// The keychain calls, replaced in tests.
+var (
+	keyringSet    = keyring.Set
+	keyringDelete = keyring.Delete
+)
+
+func newAuthCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Store provider API keys in the OS keychain",
+		Long: "Store provider API keys in the OS keychain (macOS Keychain, the Secret Service via secret-tool, or the\n" +
+			"Windows Credential Manager) instead of the environment. A stored key is used when the provider's\n" +
+			"environment variable is unset; set PLANCRITIC_NO_KEYRING=1 to skip the keychain.",
+	}
+	cmd.AddCommand(newAuthSetCmd(), newAuthRemoveCmd())
+	return cmd
+}
+
+func newAuthSetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <provider>",
+		Short: "Store an API key, read from stdin, in the OS keychain",
+		Long: "Read an API key from the first line of stdin and store it in the OS keychain for provider\n" +
+			"(anthropic, openai, gemini, or local), replacing any stored key. The key is never passed on the command line.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAuthSet(cmd, args[0])
+		},
+	}
+}
+
+func newAuthRemoveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <provider>",
+		Short: "Remove a stored API key from the OS keychain",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAuthRemove(cmd, args[0])
+		},
+	}
+}
+
+func runAuthSet(cmd *cobra.Command, provider string) error {
+	if err := checkAuthProvider(provider); err != nil {
+		return err
+	}
+	in := cmd.InOrStdin()
+	if in == os.Stdin && isTerminal(os.Stdin) {
+		fmt.Fprintf(cmd.ErrOrStderr(), "API key for %s: ", provider)
+	}
+	key, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return exitError(3, "failed to read the API key: %v", err)
+	}
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return exitError(3, "no API key on stdin")
+	}
+	if err := keyringSet(provider, key); err != nil {
+		return keyringError(err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Stored the %s API key in the OS keychain\n", provider)
+	return nil
+}
+
+func runAuthRemove(cmd *cobra.Command, provider string) error {
+	if err := checkAuthProvider(provider); err != nil {
+		return err
+	}
+	if err := keyringDelete(provider); err != nil {
+		if errors.Is(err, keyring.ErrNotFound) {
+			return exitError(3, "no %s API key is stored in the OS keychain", provider)
+		}
+		return keyringError(err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Removed the %s API key from the OS keychain\n", provider)
+	return nil
+}
+
+func checkAuthProvider(provider string) error {
+	for _, p := range authProviders {
+		if p == provider {
+			return nil
+		}
+	}
+	return exitError(3, "unknown provider %q: want one of %s", provider, strings.Join(authProviders, ", "))
+}
+
+func keyringError(err error) error {
+	if errors.Is(err, keyring.ErrUnsupported) {
+		return exitError(3, "%v; use the provider's environment variable or api_key_cmd instead", err)
+	}
+	return fmt.Errorf("OS keychain: %w", err)
+}
+
+// isTerminal reports whether f is an interactive terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/dshills/plancritic/internal/keyring"
+)
+
+func stubKeyring(t *testing.T) map[string]string {
+	t.Helper()
+	store := map[string]string{}
+	set, del := keyringSet, keyringDelete
+	t.Cleanup(func() { keyringSet, keyringDelete = set, del })
+	keyringSet = func(account, secret string) error {
+		store[account] = secret
+		return nil
+	}
+	keyringDelete = func(account string) error {
+		if _, ok := store[account]; !ok {
+			return keyring.ErrNotFound
+		}
+		delete(store, account)
+		return nil
+	}
+	return store
+}
+
+func TestAuthSetRemove(t *testing.T) {
+	store := stubKeyring(t)
+
+	cmd := newAuthCmd()
+	cmd.SetArgs([]string{"set", "anthropic"})
+	cmd.SetIn(strings.NewReader("  sk-ant-test\n"))
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if store["anthropic"] != "sk-ant-test" {
+		t.Errorf("stored %q, want the trimmed key", store["anthropic"])
+	}
+
+	cmd = newAuthCmd()
+	cmd.SetArgs([]string{"remove", "anthropic"})
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store["anthropic"]; ok {
+		t.Error("key still stored after remove")
+	}
+
+	cmd = newAuthCmd()
+	cmd.SetArgs([]string{"remove", "anthropic"})
+	cmd.SetOut(&out)
+	cmd.SilenceUsage = true
+	assertExitCode(t, cmd.Execute(), 3)
+}
+
+func TestAuthSetErrors(t *testing.T) {
+	stubKeyring(t)
+	for name, tc := range map[string]struct {
+		args  []string
+		input string
+	}{
+		"unknown provider": {[]string{"set", "mistral"}, "sk\n"},
+		"empty key":        {[]string{"set", "openai"}, "\n"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cmd := newAuthCmd()
+			cmd.SetArgs(tc.args)
+			cmd.SetIn(strings.NewReader(tc.input))
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SilenceUsage = true
+			assertExitCode(t, cmd.Execute(), 3)
+		})
+	}
+}
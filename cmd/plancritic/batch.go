@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dshills/plancritic/internal/fetch"
+	"github.com/dshills/plancritic/internal/reviewer"
+)
+
+// runBatchSubmit submits every plan in one provider batch and writes
+// the ticket that --batch-collect reads.
+func runBatchSubmit(ctx context.Context, planPaths []string, f *checkFlags) error {
+	// Collected reviews are named after their plans, so two plans with
+	// the same name would overwrite each other's output.
+	seen := map[string]string{}
+	for _, path := range planPaths {
+		name := batchOutputName(path, f.format)
+		if other, dup := seen[name]; dup {
+			return exitError(3, "%s and %s would both be collected as %s; batch them separately", other, path, name)
+		}
+		seen[name] = path
+	}
+
+	opts, err := reviewOptions(f)
+	if err != nil {
+		return err
+	}
+	ticket, err := reviewer.SubmitBatch(ctx, planPaths, opts)
+	if err != nil {
+		return reviewError(err)
+	}
+	data, err := json.MarshalIndent(ticket, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch ticket: %w", err)
+	}
+	if err := os.WriteFile(f.batchSubmit, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write batch ticket: %w", err)
+	}
+	fmt.Printf("Submitted batch %s (%d plans) to %s. Collect with: plancritic check --batch-collect %s\n",
+		ticket.BatchID, len(ticket.Plans), ticket.Provider, f.batchSubmit)
+	return nil
+}
+
+// runBatchCollect finishes the reviews of a submitted batch and writes
+// one output file per plan into the --out directory. The exit code is
+// the first failed plan's, else 2 when any verdict meets --fail-on.
+func runBatchCollect(ctx context.Context, f *checkFlags) error {
+	start := time.Now()
+	data, err := os.ReadFile(f.batchCollect)
+	if err != nil {
+		return exitError(3, "failed to read batch ticket: %v", err)
+	}
+	var ticket reviewer.BatchTicket
+	if err := json.Unmarshal(data, &ticket); err != nil {
+		return exitError(3, "failed to parse batch ticket %s: %v", f.batchCollect, err)
+	}
+
+	opts, err := reviewOptions(f)
+	if err != nil {
+		return err
+	}
+	reviews, state, err := reviewer.CollectBatch(ctx, &ticket, opts, version)
+	if err != nil {
+		return reviewError(err)
+	}
+	if reviews == nil {
+		return exitError(6, "batch %s is still %s; collect again later", ticket.BatchID, state)
+	}
+
+	dir := f.out
+	if dir == "" {
+		dir = "."
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	var firstErr, failOnErr error
+	for _, br := range reviews {
+		if br.Err != nil {
+			err := reviewError(br.Err)
+			fmt.Fprintf(os.Stderr, "plancritic: %s: %v\n", br.Plan.Path, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		output, err := renderReview(&br.Review, br.Plan.Path, f)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(dir, batchOutputName(br.Plan.Path, f.format))
+		if _, err := writeIfChanged(dest, []byte(output), f.force); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+		var planErr error
+		if f.failOn != "" {
+			meets, err := verdictMeetsThreshold(br.Review.Summary.Verdict, f.failOn)
+			if err != nil {
+				return exitError(3, "%v", err)
+			}
+			if meets {
+				planErr = exitError(2, "%s: verdict %s meets fail threshold %s", br.Plan.Path, br.Review.Summary.Verdict, f.failOn)
+				if failOnErr == nil {
+					failOnErr = planErr
+				}
+			}
+		}
+		fmt.Fprintln(os.Stderr, runSummary(&br.Review, dest, time.Since(start), planErr))
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+	return failOnErr
+}
+
+// batchOutputName is the file a collected plan's review is written to:
+// the plan's name with a .review.json, .review.md, or .review.html
+// extension.
+func batchOutputName(planPath, format string) string {
+	base := filepath.Base(fetch.Name(planPath))
+	return strings.TrimSuffix(base, filepath.Ext(base)) + ".review." + format
+}
@@ -1,35 +1,62 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/dshills/plancritic/internal/fetch"
+	"github.com/dshills/plancritic/internal/history"
+	"github.com/dshills/plancritic/internal/hook"
 	"github.com/dshills/plancritic/internal/llm"
 	"github.com/dshills/plancritic/internal/patch"
+	"github.com/dshills/plancritic/internal/plan"
+	"github.com/dshills/plancritic/internal/publish"
 	"github.com/dshills/plancritic/internal/render"
 	"github.com/dshills/plancritic/internal/review"
 	"github.com/dshills/plancritic/internal/reviewer"
+	"github.com/dshills/plancritic/internal/suppress"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 type checkFlags struct {
 	format            string
 	out               string
 	contextPaths      []string
+	contextDirs       []string
+	urlHeaders        []string
+	githubLinked      bool
+	joint             bool
+	jointPlans        []string
+	since             string
+	baseline          string
 	profileName       string
+	constraints       []string
+	disableCategories []string
 	strict            bool
+	apiBase           string
+	endpoint          string
+	providerProfile   string
+	proxy             string
+	caCert            string
+	apiKeyFile        string
+	apiKeys           llm.KeySources
 	providerName      string
 	model             string
 	maxTokens         int
 	maxIssues         int
 	maxQuestions      int
 	maxInputTokens    int
+	chunk             bool
 	timeout           string
 	temperature       float64
 	seed              int
@@ -40,92 +67,333 @@ type checkFlags struct {
 	redactEnabled     bool
 	noCache           bool
 	cacheTTL          string
+	responseCache     bool
+	responseCacheTTL  string
+	noPrefill         bool
+	logLLM            string
+	stamp             bool
+	force             bool
+	dryRun            bool
+	storage           string
+	storageURL        string
+	reasoningEffort   string
+	minFindingsSanity int
+	forceReview       bool
+	groundedVerdict   bool
+	mode              string
+	embedInputs       bool
+	batchSubmit       string
+	batchCollect      string
+	maxDuration       string
+	maxRepairAttempts int
 	verbose           bool
 	debug             bool
-	provider          llm.Provider // if non-nil, used instead of ResolveProvider (for testing)
+	language          string
+	upload            []string
+	suppressions      string
+	expireQuestions   int
+	questionHistory   string
+	trainingDataDir   string
+	postProcess       []string
+	ensemble          []string
+	minAgreement      int
+	concurrency       int
+	maxInputBytes     int
+	theme             render.Theme
+	classifications   map[string][]string
+	aliases           map[string]string
+	limiter           *reviewer.Limiter // shared by the plans of a multi-plan check
+	provider          llm.Provider      // if non-nil, used instead of ResolveProvider (for testing)
 }
 
 func newCheckCmd() *cobra.Command {
 	f := &checkFlags{}
+	d := loadDefaults()
 
 	cmd := &cobra.Command{
-		Use:   "check <plan-file>",
-		Short: "Analyze a plan and produce a review",
-		Args:  cobra.ExactArgs(1),
+		Use:   "check <plan-file|glob>...",
+		Short: "Analyze plans and produce reviews",
+		Long: "Analyze a plan and produce a review.\n\n" +
+			"Several plans, or quoted glob patterns such as \"plans/*.md\", are reviewed in turn; each review is written to\n" +
+			"<plan>.review.json (or .md) in the --out directory, and the exit code is the first failed plan's, else 2 when\n" +
+			"any verdict meets --fail-on. With --joint they are instead reviewed together as one plan, the first taking the\n" +
+			"plan's role for the stamp and patches, and the model reports where they contradict each other.\n\n" +
+			"A plan may also be an http(s) URL, a GitHub issue or pull request given by URL or as gh:owner/repo#123\n" +
+			"(GITHUB_TOKEN for private repositories; --github-linked adds the issues it closes), a Jira issue given as\n" +
+			"jira:PROJ-123 (JIRA_URL and JIRA_TOKEN; JIRA_EMAIL for Jira Cloud), a Confluence page given by URL or as\n" +
+			"confluence:123456 (CONFLUENCE_URL and CONFLUENCE_TOKEN; CONFLUENCE_EMAIL for Confluence Cloud), or a Notion\n" +
+			"page given by URL or as notion:<page-id> (NOTION_TOKEN).",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if f.batchCollect != "" && f.batchSubmit == "" {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if d.err != nil {
+				return exitError(3, "%v", d.err)
+			}
+			theme, err := d.theme()
+			if err != nil {
+				return exitError(3, "%v", err)
+			}
+			f.theme = theme
+			f.classifications = d.cfg.Classifications()
+			if f.trainingDataDir != "" && !d.cfg.TrainingDataConsent() {
+				return exitError(3, "--collect-training-data requires training_data_consent: true in a config file")
+			}
+			if len(f.ensemble) > 0 && (cmd.Flags().Changed("provider") || cmd.Flags().Changed("model")) {
+				return exitError(3, "--ensemble cannot be combined with --provider or --model")
+			}
+			if len(f.ensemble) > 0 && f.apiKeyFile != "" {
+				return exitError(3, "--api-key-file cannot be combined with --ensemble; set api_key_file per provider in the user config")
+			}
+			if len(f.ensemble) > 0 && f.providerProfile != "" {
+				return exitError(3, "--provider-profile cannot be combined with --ensemble")
+			}
+			keyCmd := ""
+			if f.providerProfile != "" {
+				if keyCmd, err = d.applyProviderProfile(cmd.Flags(), f); err != nil {
+					return err
+				}
+			}
+			f.apiKeys = d.apiKeys(f.apiKeyFile)
+			f.apiKeys.Command = keyCmd
+			f.aliases = d.cfg.Aliases()
+			// The flag defaults to 1, so a parsed 0 was asked for:
+			// reviewer.Options spells "no repair" as a negative count.
+			if f.maxRepairAttempts == 0 {
+				f.maxRepairAttempts = -1
+			}
 			// Check if seed was explicitly set
 			f.hasSeed = cmd.Flags().Changed("seed")
-			return runCheck(cmd.Context(), args[0], f)
+			if f.dryRun && (f.batchSubmit != "" || f.batchCollect != "") {
+				return exitError(3, "--dry-run cannot be combined with --batch-submit or --batch-collect")
+			}
+			if f.joint && (f.dryRun || f.batchSubmit != "" || f.batchCollect != "") {
+				return exitError(3, "--joint cannot be combined with --dry-run or batch mode")
+			}
+			if f.batchSubmit != "" || f.batchCollect != "" {
+				if f.batchSubmit != "" && f.batchCollect != "" {
+					return exitError(3, "--batch-submit and --batch-collect are mutually exclusive")
+				}
+				if len(f.upload) > 0 || f.stamp || f.patchOut != "" {
+					return exitError(3, "--upload, --stamp, and --patch-out are not supported in batch mode")
+				}
+				if f.format != "json" && f.format != "md" && f.format != "html" {
+					return exitError(3, "unknown format: %s", f.format)
+				}
+				if f.batchSubmit != "" {
+					plans, err := expandPlanArgs(args)
+					if err != nil {
+						return err
+					}
+					return runBatchSubmit(cmd.Context(), plans, f)
+				}
+				return runBatchCollect(cmd.Context(), f)
+			}
+			plans, err := expandPlanArgs(args)
+			if err != nil {
+				return err
+			}
+			if f.dryRun {
+				return runDryRun(os.Stdout, plans, f)
+			}
+			if f.joint {
+				if len(plans) < 2 {
+					return exitError(3, "--joint needs at least two plans")
+				}
+				f.jointPlans = plans[1:]
+				return runCheck(cmd.Context(), plans[0], f)
+			}
+			if len(plans) > 1 {
+				return runCheckMany(cmd.Context(), plans, f)
+			}
+			return runCheck(cmd.Context(), plans[0], f)
 		},
 	}
 
-	flags := cmd.Flags()
-	flags.StringVar(&f.format, "format", envStr("PLANCRITIC_FORMAT", "json"), "Output format: json or md")
+	addCheckFlags(cmd.Flags(), f, d)
+	return cmd
+}
+
+// addCheckFlags registers the check flags on flags. Defaults come from
+// d, which layers environment variables over config files.
+func addCheckFlags(flags *pflag.FlagSet, f *checkFlags, d *defaults) {
+	flags.StringVar(&f.format, "format", d.str("format", "PLANCRITIC_FORMAT", "json"), "Output format: json, md, or html")
 	flags.StringVar(&f.out, "out", "", "Output file path (default: stdout)")
-	flags.StringSliceVar(&f.contextPaths, "context", nil, "Context file paths (may be repeated)")
-	flags.StringVar(&f.profileName, "profile", envStr("PLANCRITIC_PROFILE", "general"), "Profile name")
-	flags.BoolVar(&f.strict, "strict", envBool("PLANCRITIC_STRICT", false), "Enable strict grounding mode")
-	flags.StringVar(&f.providerName, "provider", envStr("PLANCRITIC_PROVIDER", ""), "LLM provider: anthropic, openai, or gemini")
-	flags.StringVar(&f.model, "model", envStr("PLANCRITIC_MODEL", ""), "Model ID (e.g., claude-sonnet-4-6, gpt-5.2)")
-	flags.IntVar(&f.maxTokens, "max-tokens", envInt("PLANCRITIC_MAX_TOKENS", 4096), "Max response tokens")
-	flags.IntVar(&f.maxIssues, "max-issues", envInt("PLANCRITIC_MAX_ISSUES", 50), "Max issues to return")
-	flags.IntVar(&f.maxQuestions, "max-questions", envInt("PLANCRITIC_MAX_QUESTIONS", 20), "Max questions to return")
-	flags.IntVar(&f.maxInputTokens, "max-input-tokens", envInt("PLANCRITIC_MAX_INPUT_TOKENS", 0), "Max estimated input tokens (0=unlimited)")
-	flags.StringVar(&f.timeout, "timeout", envStr("PLANCRITIC_TIMEOUT", "5m"), "HTTP timeout for LLM requests (e.g., 5m, 10m)")
-	flags.Float64Var(&f.temperature, "temperature", envFloat("PLANCRITIC_TEMPERATURE", 0.2), "Model temperature")
+	flags.StringSliceVar(&f.contextPaths, "context", nil, "Context file paths, http(s) URLs, gh:owner/repo#N and jira:KEY issues, or confluence:ID and notion:ID pages (may be repeated)")
+	flags.StringSliceVar(&f.contextDirs, "context-dir", nil, "Directory to load Markdown and text context files from, honoring its .plancriticignore (may be repeated)")
+	flags.BoolVar(&f.joint, "joint", false, "Review all the plans together as one, reporting contradictions between them")
+	flags.StringVar(&f.since, "since", "", "Review incrementally: focus on what changed in the plan file since this git revision")
+	flags.StringVar(&f.baseline, "baseline", "", "With --since, the review JSON of the plan at that revision; its findings on unchanged lines are carried forward")
+	flags.BoolVar(&f.githubLinked, "github-linked", d.bool("github-linked", "PLANCRITIC_GITHUB_LINKED", false), "With a gh:owner/repo#N plan or context, also read the issues it closes")
+	flags.StringArrayVar(&f.urlHeaders, "url-header", nil, "Header sent when fetching a plan or context URL, as 'Name: value'; $VARS are expanded (repeatable)")
+	flags.StringVar(&f.profileName, "profile", d.str("profile", "PLANCRITIC_PROFILE", "general"), "Profile name, path to a profile YAML file, or pinned https URL (URL@sha256:HEX)")
+	flags.StringArrayVar(&f.constraints, "constraint", nil, "Override a profile constraint, as key=value; the key may be a dotted path such as database.preferred (repeatable)")
+	flags.StringSliceVar(&f.disableCategories, "disable-category", nil, "Drop findings in this issue category, e.g. SCOPE_CREEP_RISK, besides those the profile disables (repeatable)")
+	flags.BoolVar(&f.strict, "strict", d.bool("strict", "PLANCRITIC_STRICT", false), "Enable strict grounding mode")
+	flags.StringVar(&f.providerName, "provider", d.str("provider", "PLANCRITIC_PROVIDER", ""), "LLM provider: anthropic, openai, gemini, or local")
+	flags.StringSliceVar(&f.ensemble, "ensemble", nil, "Review with several models concurrently and merge findings, e.g. anthropic:claude-sonnet-4-6,openai:gpt-5.2")
+	flags.IntVar(&f.minAgreement, "min-agreement", d.int("min-agreement", "PLANCRITIC_MIN_AGREEMENT", 1), "With --ensemble, drop findings reported by fewer models")
+	flags.IntVar(&f.concurrency, "concurrency", d.int("concurrency", "PLANCRITIC_CONCURRENCY", 0), "Max plans reviewed and provider requests in flight at once, across plans and ensemble models (0: 1 for a local server, else 4 per provider)")
+	flags.IntVar(&f.maxInputBytes, "max-input-bytes", d.int("max-input-bytes", "PLANCRITIC_MAX_INPUT_BYTES", 0), "Max size of each plan and context file or URL (0: 2 MiB)")
+	flags.StringVar(&f.apiBase, "api-base", d.str("api-base", "PLANCRITIC_API_BASE", ""), "Server URL for the local provider (OpenAI-compatible, e.g. http://127.0.0.1:8080)")
+	flags.StringVar(&f.proxy, "proxy", d.str("proxy", "PLANCRITIC_PROXY", ""), "Proxy URL for provider requests (default: HTTPS_PROXY/HTTP_PROXY from the environment)")
+	flags.StringVar(&f.caCert, "ca-cert", d.str("ca-cert", "PLANCRITIC_CA_CERT", ""), "PEM CA bundle to trust for provider TLS, in addition to the system roots")
+	flags.StringVar(&f.apiKeyFile, "api-key-file", envStr("PLANCRITIC_API_KEY_FILE", ""), "Read the provider's API key from this file instead of its environment variable")
+	flags.StringVar(&f.providerProfile, "provider-profile", d.str("provider-profile", "PLANCRITIC_PROVIDER_PROFILE", ""), "Use the named provider profile (provider, endpoint, key source, model, max tokens) from the config")
+	flags.StringVar(&f.model, "model", d.str("model", "PLANCRITIC_MODEL", ""), "Model ID (e.g., claude-sonnet-4-6, gpt-5.2)")
+	flags.IntVar(&f.maxTokens, "max-tokens", d.int("max-tokens", "PLANCRITIC_MAX_TOKENS", 0), "Max response tokens (0 scales with the plan size and --max-issues)")
+	flags.IntVar(&f.maxIssues, "max-issues", d.int("max-issues", "PLANCRITIC_MAX_ISSUES", 50), "Max issues to return")
+	flags.IntVar(&f.maxQuestions, "max-questions", d.int("max-questions", "PLANCRITIC_MAX_QUESTIONS", 20), "Max questions to return")
+	flags.IntVar(&f.maxInputTokens, "max-input-tokens", d.int("max-input-tokens", "PLANCRITIC_MAX_INPUT_TOKENS", 0), "Max estimated input tokens (0=unlimited)")
+	flags.BoolVar(&f.chunk, "chunk", d.bool("chunk", "PLANCRITIC_CHUNK", false), "Review a plan whose prompt exceeds --max-input-tokens in step-aligned parts that fit, and merge their findings")
+	flags.StringVar(&f.timeout, "timeout", d.str("timeout", "PLANCRITIC_TIMEOUT", "5m"), "Timeout for each LLM request, including the repair call (e.g., 90s, 10m)")
+	flags.StringVar(&f.maxDuration, "max-duration", d.str("max-duration", "PLANCRITIC_MAX_DURATION", ""), "Time budget for all LLM calls in the run, including repairs (e.g., 2m); on expiry, output the findings validated so far and exit 6")
+	flags.IntVar(&f.maxRepairAttempts, "max-repair-attempts", d.int("max-repair-attempts", "PLANCRITIC_MAX_REPAIR_ATTEMPTS", 1), "Repair rounds when the model's output fails schema validation (0 disables repair)")
+	flags.StringVar(&f.reasoningEffort, "reasoning-effort", d.str("reasoning-effort", "PLANCRITIC_REASONING_EFFORT", ""), "Reasoning effort for OpenAI reasoning models (o-series, gpt-5): none, minimal, low, medium, high, or xhigh")
+	flags.IntVar(&f.minFindingsSanity, "min-findings-sanity", d.int("min-findings-sanity", "PLANCRITIC_MIN_FINDINGS_SANITY", 0), "Retry once, then flag the review, when it has fewer findings than this for a plan with gaps (0 disables)")
+	flags.StringVar(&f.mode, "mode", d.str("mode", "PLANCRITIC_MODE", reviewer.ModeFull), "Review mode: full (findings and questions) or checklist (only the profile checklists, PASS/FAIL/N/A; cheaper and faster)")
+	flags.BoolVar(&f.groundedVerdict, "grounded-verdict", d.bool("grounded-verdict", "PLANCRITIC_GROUNDED_VERDICT", false), "Also report the verdict with UNVERIFIED and assumption findings left out")
+	flags.BoolVar(&f.forceReview, "force-review", d.bool("force-review", "PLANCRITIC_FORCE_REVIEW", false), "Send empty or stub plans to the model instead of failing them locally as NOT_EXECUTABLE")
+	flags.StringVar(&f.batchSubmit, "batch-submit", "", "Submit the plans (one or more) through the provider's batch API at half price and write a ticket to FILE")
+	flags.StringVar(&f.batchCollect, "batch-collect", "", "Collect the reviews of a batch submitted with --batch-submit; --out names the output directory")
+	flags.BoolVar(&f.embedInputs, "embed-inputs", d.bool("embed-inputs", "PLANCRITIC_EMBED_INPUTS", false), "Store the (redacted) plan and context contents in the review; recover them with plancritic extract")
+	flags.Float64Var(&f.temperature, "temperature", d.float("temperature", "PLANCRITIC_TEMPERATURE", 0.2), "Model temperature")
 	flags.IntVar(&f.seed, "seed", 0, "Random seed (if supported)")
-	flags.StringVar(&f.severityThreshold, "severity-threshold", envStr("PLANCRITIC_SEVERITY_THRESHOLD", "info"), "Minimum severity: info, warn, or critical")
+	flags.StringVar(&f.severityThreshold, "severity-threshold", d.str("severity-threshold", "PLANCRITIC_SEVERITY_THRESHOLD", "info"), "Minimum severity: info, warn, or critical")
+	flags.StringArrayVar(&f.upload, "upload", nil, "Upload the review JSON and Markdown report to s3://bucket/prefix/ or gs://bucket/prefix/ (repeatable)")
+	flags.StringVar(&f.suppressions, "suppressions", d.str("suppressions", "PLANCRITIC_SUPPRESSIONS", suppress.DefaultPath), "Suppression file (empty to disable)")
+	flags.IntVar(&f.expireQuestions, "expire-questions", d.int("expire-questions", "PLANCRITIC_EXPIRE_QUESTIONS", 0), "Turn CRITICAL questions still unanswered this many plan revisions after first asked into CRITICAL issues (0 = off)")
+	flags.StringVar(&f.questionHistory, "question-history", d.str("question-history", "PLANCRITIC_QUESTION_HISTORY", history.DefaultPath), "Question history file used by --expire-questions")
+	flags.StringVar(&f.trainingDataDir, "collect-training-data", "", "Append redacted prompt/response/triage records to DIR (requires training_data_consent: true in config)")
+	flags.StringArrayVar(&f.postProcess, "post-process", nil, "Command that receives the review JSON on stdin and prints the modified review (repeatable, run in order)")
 	flags.StringVar(&f.patchOut, "patch-out", "", "Write suggested patches as unified diff")
-	flags.StringVar(&f.failOn, "fail-on", envStr("PLANCRITIC_FAIL_ON", ""), "Exit non-zero if verdict meets this level")
-	flags.BoolVar(&f.redactEnabled, "redact", envBool("PLANCRITIC_REDACT", true), "Redact secrets before sending to model")
-	flags.BoolVar(&f.noCache, "no-cache", envBool("PLANCRITIC_NO_CACHE", false), "Disable prompt caching (Anthropic cache_control markers / Gemini context cache)")
-	flags.StringVar(&f.cacheTTL, "cache-ttl", envStr("PLANCRITIC_CACHE_TTL", "1h"), "TTL for provider-side context caches (Gemini only)")
+	flags.StringVar(&f.failOn, "fail-on", d.str("fail-on", "PLANCRITIC_FAIL_ON", ""), "Exit non-zero if verdict meets this level")
+	flags.BoolVar(&f.redactEnabled, "redact", d.bool("redact", "PLANCRITIC_REDACT", true), "Redact secrets before sending to model")
+	flags.BoolVar(&f.noCache, "no-cache", d.bool("no-cache", "PLANCRITIC_NO_CACHE", false), "Disable prompt caching (Anthropic cache_control markers / Gemini context cache) and the response cache")
+	flags.StringVar(&f.cacheTTL, "cache-ttl", d.str("cache-ttl", "PLANCRITIC_CACHE_TTL", "1h"), "TTL for provider-side context caches (Gemini only)")
+	flags.BoolVar(&f.responseCache, "cache", d.bool("cache", "PLANCRITIC_CACHE", false), "Reuse validated responses from the on-disk response cache (~/.cache/plancritic/responses); --no-cache overrides")
+	flags.StringVar(&f.responseCacheTTL, "response-cache-ttl", d.str("response-cache-ttl", "PLANCRITIC_RESPONSE_CACHE_TTL", "24h"), "Maximum age of a cached response")
+	flags.StringVar(&f.storage, "storage", d.str("storage", "PLANCRITIC_STORAGE", "fs"), "Backend for persistent state (the response cache and question history): fs, redis, s3, or sqlite")
+	flags.StringVar(&f.storageURL, "storage-url", d.str("storage-url", "PLANCRITIC_STORAGE_URL", ""), "Location of the redis (redis://[:password@]host:port[/db]) or s3 (s3://bucket/prefix/) storage backend, or the sqlite database file")
+	flags.BoolVar(&f.noPrefill, "no-prefill", d.bool("no-prefill", "PLANCRITIC_NO_PREFILL", false), "Do not prefill the model's response with \"{\" (Anthropic; for models that reject prefill)")
+	flags.StringVar(&f.language, "language", d.str("language", "PLANCRITIC_LANGUAGE", ""), "Language for findings: auto (match the plan) or an ISO 639-1 code (default: English)")
+	flags.StringVar(&f.logLLM, "log-llm", d.str("log-llm", "PLANCRITIC_LOG_LLM", ""), "Write every LLM request and raw response as timestamped JSON files to DIR")
+	flags.BoolVar(&f.stamp, "stamp", d.bool("stamp", "PLANCRITIC_STAMP", false), "Append or update a review status comment (date, verdict, score, review hash) at the bottom of the plan file")
+	flags.BoolVar(&f.force, "force", false, "Rewrite --out, --patch-out, and the --stamp block even when their content is unchanged")
+	flags.BoolVar(&f.dryRun, "dry-run", false, "Build the prompt and print its estimated tokens and cost per model without calling the LLM")
 	flags.BoolVar(&f.verbose, "verbose", false, "Print processing steps to stderr")
 	flags.BoolVar(&f.debug, "debug", false, "Save prompt to debug file")
-
-	return cmd
 }
 
-func runCheck(ctx context.Context, planPath string, f *checkFlags) error {
-	if f.format != "json" && f.format != "md" {
+func runCheck(ctx context.Context, planPath string, f *checkFlags) (err error) {
+	// Always end with a one-line summary on stderr so CI logs carry the
+	// headline whatever --format and --out say.
+	start := time.Now()
+	var summary *review.Review
+	defer func() {
+		fmt.Fprintln(os.Stderr, runSummary(summary, f.out, time.Since(start), err))
+	}()
+
+	if f.format != "json" && f.format != "md" && f.format != "html" {
 		return exitError(3, "unknown format: %s", f.format)
 	}
+	for _, dest := range f.upload {
+		if _, err := uploadTarget(dest); err != nil {
+			return err
+		}
+	}
+	if f.stamp && fetch.IsRemote(planPath) {
+		return exitError(3, "--stamp cannot write to a plan fetched from a URL, GitHub, Jira, Confluence, or Notion")
+	}
 
 	rev, err := runReview(ctx, planPath, f)
 	if err != nil {
+		if f.format == "json" {
+			writeErrorJSON(err, f.out)
+		}
 		return err
 	}
+	summary = &rev
 
 	verbose := verboseLogger(f.verbose)
 
 	// 12. Output
-	var output string
-	switch f.format {
-	case "json":
-		data, err := json.MarshalIndent(rev, "", "  ")
-		if err != nil {
-			return fmt.Errorf("failed to marshal output: %w", err)
-		}
-		output = string(data) + "\n"
-	case "md":
-		output = render.Markdown(&rev)
+	output, err := renderReview(&rev, planPath, f)
+	if err != nil {
+		return err
 	}
 
 	if f.out != "" {
-		verbose("Writing output to %s", f.out)
-		if err := os.WriteFile(f.out, []byte(output), 0644); err != nil {
+		wrote, err := writeIfChanged(f.out, []byte(output), f.force)
+		if err != nil {
 			return fmt.Errorf("failed to write output: %w", err)
 		}
+		if wrote {
+			verbose("Wrote output to %s", f.out)
+		} else {
+			verbose("Output %s is unchanged", f.out)
+		}
 	} else {
 		fmt.Print(output)
 	}
 
 	// 13. Patch output
-	if f.patchOut != "" {
-		verbose("Writing patches to %s", f.patchOut)
-		if err := patch.WritePatchFile(rev.Patches, f.patchOut); err != nil {
+	if f.patchOut != "" && len(rev.Patches) > 0 {
+		wrote, err := writeIfChanged(f.patchOut, []byte(patch.Unified(rev.Patches)), f.force)
+		if err != nil {
 			return fmt.Errorf("failed to write patches: %w", err)
 		}
+		if wrote {
+			verbose("Wrote patches to %s", f.patchOut)
+		} else {
+			verbose("Patches %s are unchanged", f.patchOut)
+		}
+	}
+
+	// 13b. Artifact upload. An interrupted run writes local files only.
+	uploads := f.upload
+	if rev.Meta.Interrupted && len(uploads) > 0 {
+		fmt.Fprintln(os.Stderr, "plancritic: warning: interrupted; skipping --upload")
+		uploads = nil
+	}
+	for _, dest := range uploads {
+		verbose("Uploading review to %s", dest)
+		if err := uploadReview(ctx, &rev, dest); err != nil {
+			return err
+		}
+	}
+
+	// 13c. Plan stamp. An incomplete review is not a review status.
+	if f.stamp && rev.Status != review.StatusIncomplete {
+		hash, err := review.ArtifactHash(&rev)
+		if err != nil {
+			return err
+		}
+		wrote, err := plan.WriteStamp(planPath, plan.Stamp{
+			Reviewed:   time.Now(),
+			Verdict:    string(rev.Summary.Verdict),
+			Score:      rev.Summary.Score,
+			ReviewHash: hash,
+		}, f.force)
+		if err != nil {
+			return fmt.Errorf("failed to stamp plan: %w", err)
+		}
+		if wrote {
+			verbose("Stamped review status into %s", planPath)
+		} else {
+			verbose("Review stamp in %s is unchanged", planPath)
+		}
+	}
+
+	if rev.Meta.Interrupted {
+		return exitError(130, "interrupted; the review had already been received and was written before exiting")
+	}
+
+	if rev.Status == review.StatusIncomplete {
+		return exitError(6, "review incomplete: --max-duration %s expired before every model call finished", f.maxDuration)
 	}
 
 	// 14. Exit code based on --fail-on
@@ -142,43 +410,287 @@ func runCheck(ctx context.Context, planPath string, f *checkFlags) error {
 	return nil
 }
 
+// renderReview formats rev as --format asks. The HTML page shows the
+// plan at planPath beside the findings.
+func renderReview(rev *review.Review, planPath string, f *checkFlags) (string, error) {
+	switch f.format {
+	case "md":
+		return render.MarkdownTheme(rev, f.theme), nil
+	case "html":
+		headers, err := parseURLHeaders(f.urlHeaders)
+		if err != nil {
+			return "", err
+		}
+		p, err := plan.LoadWith(planPath, fetch.Options{Headers: headers, GitHubLinked: f.githubLinked, MaxBytes: int64(f.maxInputBytes)})
+		if err != nil {
+			return "", fmt.Errorf("failed to read plan for HTML output: %w", err)
+		}
+		return render.HTML(rev, p.Raw, f.theme)
+	}
+	data, err := json.MarshalIndent(rev, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal output: %w", err)
+	}
+	return string(data) + "\n", nil
+}
+
 func runReview(parentCtx context.Context, planPath string, f *checkFlags) (review.Review, error) {
-	rev, err := reviewer.Run(parentCtx, planPath, reviewer.Options{
-		ContextPaths:      f.contextPaths,
-		ProfileName:       f.profileName,
-		Strict:            f.strict,
-		ProviderName:      f.providerName,
-		Model:             f.model,
-		MaxTokens:         f.maxTokens,
-		MaxIssues:         f.maxIssues,
-		MaxQuestions:      f.maxQuestions,
-		MaxInputTokens:    f.maxInputTokens,
-		Timeout:           f.timeout,
-		Temperature:       f.temperature,
-		Seed:              f.seed,
-		HasSeed:           f.hasSeed,
-		SeverityThreshold: f.severityThreshold,
-		RedactEnabled:     f.redactEnabled,
-		NoCache:           f.noCache,
-		CacheTTL:          f.cacheTTL,
-		Verbose:           f.verbose,
-		Debug:             f.debug,
-		DebugDir:          ".",
-		Provider:          f.provider,
-	}, version)
+	opts, err := reviewOptions(f)
 	if err != nil {
-		var re *reviewer.Error
-		if errors.As(err, &re) {
-			return review.Review{}, exitError(re.Code, "%s", re.Msg)
-		}
-		return review.Review{}, exitError(4, "%v", err)
+		return review.Review{}, err
+	}
+	rev, err := reviewer.Run(parentCtx, planPath, opts, version)
+	if err != nil {
+		return review.Review{}, reviewError(err)
 	}
 	return rev, nil
 }
 
+// reviewOptions maps the check flags to reviewer options.
+func reviewOptions(f *checkFlags) (reviewer.Options, error) {
+	var hooks []hook.PostProcessor
+	for _, command := range f.postProcess {
+		h, err := hook.NewExec(command)
+		if err != nil {
+			return reviewer.Options{}, exitError(3, "invalid --post-process: %v", err)
+		}
+		hooks = append(hooks, h)
+	}
+	headers, err := parseURLHeaders(f.urlHeaders)
+	if err != nil {
+		return reviewer.Options{}, err
+	}
+
+	return reviewer.Options{
+		ContextPaths:         f.contextPaths,
+		ContextDirs:          f.contextDirs,
+		URLHeaders:           headers,
+		GitHubLinked:         f.githubLinked,
+		JointPlans:           f.jointPlans,
+		Since:                f.since,
+		BaselinePath:         f.baseline,
+		ProfileName:          f.profileName,
+		Constraints:          f.constraints,
+		DisableCategories:    f.disableCategories,
+		Strict:               f.strict,
+		ProviderName:         f.providerName,
+		APIBase:              f.apiBase,
+		Endpoint:             f.endpoint,
+		Proxy:                f.proxy,
+		CACertFile:           f.caCert,
+		APIKeys:              f.apiKeys,
+		SuppressionsPath:     f.suppressions,
+		ExpireQuestionsAfter: f.expireQuestions,
+		QuestionHistoryPath:  f.questionHistory,
+		TrainingDataDir:      f.trainingDataDir,
+		Model:                f.model,
+		MaxTokens:            f.maxTokens,
+		MaxIssues:            f.maxIssues,
+		MaxQuestions:         f.maxQuestions,
+		MaxInputTokens:       f.maxInputTokens,
+		Chunk:                f.chunk,
+		Timeout:              f.timeout,
+		Temperature:          f.temperature,
+		Seed:                 f.seed,
+		HasSeed:              f.hasSeed,
+		SeverityThreshold:    f.severityThreshold,
+		RedactEnabled:        f.redactEnabled,
+		NoCache:              f.noCache,
+		CacheTTL:             f.cacheTTL,
+		Verbose:              f.verbose,
+		Debug:                f.debug,
+		DebugDir:             ".",
+		Provider:             f.provider,
+		Language:             f.language,
+		PostProcessors:       hooks,
+		Ensemble:             f.ensemble,
+		Aliases:              f.aliases,
+		MinAgreement:         f.minAgreement,
+		Concurrency:          f.concurrency,
+		Limiter:              f.limiter,
+		MaxInputBytes:        int64(f.maxInputBytes),
+		ResponseCache:        f.responseCache,
+		ResponseCacheTTL:     f.responseCacheTTL,
+		NoPrefill:            f.noPrefill,
+		LogLLMDir:            f.logLLM,
+		MaxDuration:          f.maxDuration,
+		MaxRepairAttempts:    f.maxRepairAttempts,
+		StorageBackend:       f.storage,
+		StorageURL:           f.storageURL,
+		ReasoningEffort:      f.reasoningEffort,
+		MinFindingsSanity:    f.minFindingsSanity,
+		ForceReview:          f.forceReview,
+		GroundedVerdict:      f.groundedVerdict,
+		Mode:                 f.mode,
+		EmbedInputs:          f.embedInputs,
+		Classifications:      f.classifications,
+	}, nil
+}
+
+// parseURLHeaders parses --url-header values. Environment variables in
+// a value are expanded, so a token can be passed as '$WIKI_TOKEN'
+// without appearing in the process list.
+func parseURLHeaders(specs []string) (http.Header, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	h := http.Header{}
+	for _, spec := range specs {
+		name, value, err := fetch.ParseHeader(spec)
+		if err != nil {
+			return nil, exitError(3, "invalid --url-header: %v", err)
+		}
+		h.Add(name, os.ExpandEnv(value))
+	}
+	return h, nil
+}
+
+// reviewError maps a reviewer error to the CLI exit code it carries;
+// anything else is a provider error.
+func reviewError(err error) error {
+	var re *reviewer.Error
+	if errors.As(err, &re) {
+		return &exitErr{code: re.Code, msg: re.Msg, class: re.Class}
+	}
+	return exitError(4, "%v", err)
+}
+
+// errorJSON is written in place of the review when a --format json run
+// fails, so tools reading the output can tell a retryable provider
+// outage from an error that needs fixing without parsing stderr.
+type errorJSON struct {
+	Error struct {
+		ExitCode  int            `json:"exit_code"`
+		Class     llm.ErrorClass `json:"class,omitempty"`
+		Retryable bool           `json:"retryable"`
+		Message   string         `json:"message"`
+	} `json:"error"`
+}
+
+// writeErrorJSON writes the errorJSON for err to out, or stdout when out
+// is empty. It is best effort: the exit code still reports the failure.
+func writeErrorJSON(err error, out string) {
+	var doc errorJSON
+	doc.Error.ExitCode = 1
+	doc.Error.Message = err.Error()
+	var ee *exitErr
+	if errors.As(err, &ee) {
+		doc.Error.ExitCode = ee.code
+		doc.Error.Class = ee.class
+		doc.Error.Retryable = ee.class.Retryable()
+	}
+	data, mErr := json.MarshalIndent(doc, "", "  ")
+	if mErr != nil {
+		return
+	}
+	data = append(data, '\n')
+	if out != "" {
+		_ = os.WriteFile(out, data, 0644)
+		return
+	}
+	os.Stdout.Write(data)
+}
+
+// writeIfChanged writes data to path unless the file already holds
+// exactly data and force is false, so re-running a check on unchanged
+// inputs leaves modification times alone and make targets built on the
+// output do not rebuild. It reports whether the file was written.
+func writeIfChanged(path string, data []byte, force bool) (bool, error) {
+	if !force {
+		if old, err := os.ReadFile(path); err == nil && bytes.Equal(old, data) {
+			return false, nil
+		}
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// uploadTarget maps an --upload URL to its publisher.
+func uploadTarget(dest string) (publish.Publisher, error) {
+	var name string
+	switch {
+	case strings.HasPrefix(dest, "s3://"):
+		name = "s3"
+	case strings.HasPrefix(dest, "gs://"):
+		name = "gcs"
+	default:
+		return nil, exitError(3, "unsupported --upload URL %q: want s3://bucket/prefix/ or gs://bucket/prefix/", dest)
+	}
+	p, _ := publish.Lookup(name)
+	return p, nil
+}
+
+// uploadReview stores the review JSON and Markdown report at an s3://
+// or gs:// URL under content-addressed keys.
+func uploadReview(ctx context.Context, rev *review.Review, dest string) error {
+	p, err := uploadTarget(dest)
+	if err != nil {
+		return err
+	}
+	err = publish.Publish(ctx, p, rev, publish.PublishOpts{
+		Retries: 2,
+		Options: map[string]string{"url": dest},
+		Log:     os.Stderr,
+	})
+	if err != nil {
+		return fmt.Errorf("upload to %s failed: %w", dest, err)
+	}
+	return nil
+}
+
+// runSummary formats the stderr summary line for a check run. rev is
+// nil when the run failed before a review was produced.
+func runSummary(rev *review.Review, out string, elapsed time.Duration, err error) string {
+	code := 0
+	var class llm.ErrorClass
+	if err != nil {
+		code = 1
+		var ee *exitErr
+		if errors.As(err, &ee) {
+			code, class = ee.code, ee.class
+		}
+	}
+	elapsed = elapsed.Round(time.Millisecond)
+	if rev == nil {
+		if class != "" {
+			return fmt.Sprintf("plancritic: status=error class=%s duration=%s exit=%d", class, elapsed, code)
+		}
+		return fmt.Sprintf("plancritic: status=error duration=%s exit=%d", elapsed, code)
+	}
+
+	var b strings.Builder
+	b.WriteString("plancritic:")
+	if rev.Status != "" {
+		fmt.Fprintf(&b, " status=%s", rev.Status)
+	}
+	fmt.Fprintf(&b, " verdict=%s score=%d critical=%d warn=%d info=%d questions=%d duration=%s",
+		rev.Summary.Verdict, rev.Summary.Score, rev.Summary.CriticalCount,
+		rev.Summary.WarnCount, rev.Summary.InfoCount, len(rev.Questions), elapsed)
+	if g := rev.Summary.Grounded; g != nil {
+		fmt.Fprintf(&b, " grounded_verdict=%s", g.Verdict)
+	}
+	switch u := rev.Meta.Usage; {
+	case u == nil:
+		// No tokens reported: a cached or free run.
+		b.WriteString(" cost=$0.0000")
+	case u.EstimatedCostUSD == nil:
+		b.WriteString(" cost=n/a")
+	default:
+		fmt.Fprintf(&b, " cost=$%.4f", *u.EstimatedCostUSD)
+	}
+	if out == "" {
+		out = "stdout"
+	}
+	fmt.Fprintf(&b, " out=%s exit=%d", out, code)
+	return b.String()
+}
+
 type exitErr struct {
-	code int
-	msg  string
+	code  int
+	msg   string
+	class llm.ErrorClass
 }
 
 func (e *exitErr) Error() string { return e.msg }
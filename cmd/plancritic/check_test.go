@@ -1,16 +1,23 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/dshills/plancritic/internal/llm"
 	"github.com/dshills/plancritic/internal/review"
+	"github.com/dshills/plancritic/internal/reviewer"
 )
 
 // --- Pure function tests ---
@@ -167,7 +174,7 @@ func TestVerdictMeetsThreshold(t *testing.T) {
 }
 
 func TestRunCheckFailOnUnrecognized(t *testing.T) {
-	planPath := writeTempPlan(t, "# Plan\n")
+	planPath := writeTempPlan(t, "# Plan\n\n1. Ship it\n")
 	f := &checkFlags{
 		format:            "json",
 		profileName:       "general",
@@ -262,7 +269,7 @@ func assertExitCode(t *testing.T, err error, wantCode int) {
 }
 
 func TestRunCheckHappyPath(t *testing.T) {
-	planPath := writeTempPlan(t, "# Step 1\nDo something\n")
+	planPath := writeTempPlan(t, "# Step 1\n- Do something\n")
 	f := &checkFlags{
 		format:            "json",
 		profileName:       "general",
@@ -289,7 +296,7 @@ func TestRunCheckMissingPlanFile(t *testing.T) {
 }
 
 func TestRunCheckBadContextPath(t *testing.T) {
-	planPath := writeTempPlan(t, "# Plan\n")
+	planPath := writeTempPlan(t, "# Plan\n\n1. Ship it\n")
 	f := &checkFlags{
 		format:            "json",
 		profileName:       "general",
@@ -303,7 +310,7 @@ func TestRunCheckBadContextPath(t *testing.T) {
 }
 
 func TestRunCheckUnknownProfile(t *testing.T) {
-	planPath := writeTempPlan(t, "# Plan\n")
+	planPath := writeTempPlan(t, "# Plan\n\n1. Ship it\n")
 	f := &checkFlags{
 		format:            "json",
 		profileName:       "nonexistent-profile-xyz",
@@ -316,7 +323,7 @@ func TestRunCheckUnknownProfile(t *testing.T) {
 }
 
 func TestRunCheckLLMError(t *testing.T) {
-	planPath := writeTempPlan(t, "# Plan\n")
+	planPath := writeTempPlan(t, "# Plan\n\n1. Ship it\n")
 	f := &checkFlags{
 		format:            "json",
 		profileName:       "general",
@@ -329,7 +336,7 @@ func TestRunCheckLLMError(t *testing.T) {
 }
 
 func TestRunCheckLLMReturnsNonJSON(t *testing.T) {
-	planPath := writeTempPlan(t, "# Plan\n")
+	planPath := writeTempPlan(t, "# Plan\n\n1. Ship it\n")
 	f := &checkFlags{
 		format:            "json",
 		profileName:       "general",
@@ -345,11 +352,12 @@ func TestRunCheckSchemaValidationFailsRepairSucceeds(t *testing.T) {
 	// First response: issue with invalid severity (structural error)
 	badResp := `{"summary":{"verdict":"EXECUTABLE_AS_IS"},"issues":[{"id":"I1","severity":"BOGUS","category":"CONTRADICTION","title":"t","description":"d","evidence":[{"source":"plan","path":"p","line_start":1,"line_end":1,"quote":"q"}]}],"questions":[]}`
 
-	mock := &callCountMockProvider{
-		responses: []string{badResp, validMockResponse()},
-	}
+	mock := &llm.MockProvider{Steps: []llm.MockStep{
+		{Response: badResp, Times: 1},
+		{Response: validMockResponse(), Times: 1},
+	}}
 
-	planPath := writeTempPlan(t, "# Plan\n")
+	planPath := writeTempPlan(t, "# Plan\n\n1. Ship it\n")
 	f := &checkFlags{
 		format:            "json",
 		profileName:       "general",
@@ -367,11 +375,11 @@ func TestRunCheckSchemaValidationFailsBothAttempts(t *testing.T) {
 	// Both responses have structural errors (invalid severity)
 	badResp := `{"summary":{"verdict":"EXECUTABLE_AS_IS"},"issues":[{"id":"I1","severity":"BOGUS","category":"CONTRADICTION","title":"t","description":"d","evidence":[{"source":"plan","path":"p","line_start":1,"line_end":1,"quote":"q"}]}],"questions":[]}`
 
-	mock := &callCountMockProvider{
-		responses: []string{badResp, badResp},
-	}
+	mock := &llm.MockProvider{Steps: []llm.MockStep{
+		{Response: badResp, Times: 2},
+	}}
 
-	planPath := writeTempPlan(t, "# Plan\n")
+	planPath := writeTempPlan(t, "# Plan\n\n1. Ship it\n")
 	f := &checkFlags{
 		format:            "json",
 		profileName:       "general",
@@ -384,7 +392,7 @@ func TestRunCheckSchemaValidationFailsBothAttempts(t *testing.T) {
 }
 
 func TestRunCheckFormatMarkdown(t *testing.T) {
-	planPath := writeTempPlan(t, "# Plan\n")
+	planPath := writeTempPlan(t, "# Plan\n\n1. Ship it\n")
 	dir := t.TempDir()
 	outPath := filepath.Join(dir, "out.md")
 
@@ -408,8 +416,76 @@ func TestRunCheckFormatMarkdown(t *testing.T) {
 	}
 }
 
+func TestRunCheckFormatHTML(t *testing.T) {
+	planPath := writeTempPlan(t, "# Plan\n\n1. Ship <it>\n")
+	outPath := filepath.Join(t.TempDir(), "out.html")
+
+	f := &checkFlags{
+		format:            "html",
+		out:               outPath,
+		profileName:       "general",
+		redactEnabled:     true,
+		severityThreshold: "info",
+		provider:          &llm.MockProvider{Response: validMockResponse()},
+	}
+	err := runCheck(context.Background(), planPath, f)
+	assertExitCode(t, err, 0)
+
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	if !strings.HasPrefix(out, "<!DOCTYPE html>") || !strings.Contains(out, "1. Ship &lt;it&gt;") {
+		t.Errorf("expected an HTML page with the plan text, got:\n%s", out)
+	}
+}
+
+func TestRunCheckPlanURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer wiki-token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("# Plan\n\n1. Ship it\n"))
+	}))
+	defer srv.Close()
+	t.Setenv("WIKI_TOKEN", "wiki-token")
+	outPath := filepath.Join(t.TempDir(), "out.json")
+
+	f := &checkFlags{
+		format:            "json",
+		out:               outPath,
+		urlHeaders:        []string{"Authorization: Bearer $WIKI_TOKEN"},
+		profileName:       "general",
+		redactEnabled:     true,
+		severityThreshold: "info",
+		provider:          &llm.MockProvider{Response: validMockResponse()},
+	}
+	err := runCheck(context.Background(), srv.URL+"/plans/plan.md?raw=1", f)
+	assertExitCode(t, err, 0)
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rev review.Review
+	if err := json.Unmarshal(data, &rev); err != nil {
+		t.Fatal(err)
+	}
+	if rev.Input.PlanFile != "plan.md" {
+		t.Errorf("plan_file = %q, want plan.md", rev.Input.PlanFile)
+	}
+
+	f.urlHeaders = nil
+	assertExitCode(t, runCheck(context.Background(), srv.URL+"/plans/plan.md", f), 3)
+	f.urlHeaders = []string{"no colon"}
+	assertExitCode(t, runCheck(context.Background(), srv.URL+"/plans/plan.md", f), 3)
+	f.urlHeaders, f.stamp = []string{"Authorization: Bearer $WIKI_TOKEN"}, true
+	assertExitCode(t, runCheck(context.Background(), srv.URL+"/plans/plan.md", f), 3)
+}
+
 func TestRunCheckFormatUnknown(t *testing.T) {
-	planPath := writeTempPlan(t, "# Plan\n")
+	planPath := writeTempPlan(t, "# Plan\n\n1. Ship it\n")
 	f := &checkFlags{
 		format:            "xml",
 		profileName:       "general",
@@ -422,7 +498,7 @@ func TestRunCheckFormatUnknown(t *testing.T) {
 }
 
 func TestRunCheckOutFile(t *testing.T) {
-	planPath := writeTempPlan(t, "# Plan\n")
+	planPath := writeTempPlan(t, "# Plan\n\n1. Ship it\n")
 	dir := t.TempDir()
 	outPath := filepath.Join(dir, "result.json")
 
@@ -452,7 +528,7 @@ func TestRunCheckOutFile(t *testing.T) {
 }
 
 func TestRunCheckFailOn(t *testing.T) {
-	planPath := writeTempPlan(t, "# Plan\n")
+	planPath := writeTempPlan(t, "# Plan\n\n1. Ship it\n")
 	f := &checkFlags{
 		format:            "json",
 		profileName:       "general",
@@ -465,8 +541,41 @@ func TestRunCheckFailOn(t *testing.T) {
 	assertExitCode(t, err, 2)
 }
 
+func TestRunCheckInvalidTimeout(t *testing.T) {
+	planPath := writeTempPlan(t, "# Plan\n\n1. Ship it\n")
+	for _, timeout := range []string{"soon", "0s", "-1m"} {
+		f := &checkFlags{
+			format:            "json",
+			profileName:       "general",
+			redactEnabled:     true,
+			severityThreshold: "info",
+			timeout:           timeout,
+			provider:          &llm.MockProvider{Response: validMockResponse()},
+		}
+		assertExitCode(t, runCheck(context.Background(), planPath, f), 3)
+	}
+}
+
+func TestRunCheckUploadBadScheme(t *testing.T) {
+	planPath := writeTempPlan(t, "# Plan\n\n1. Ship it\n")
+	mock := &llm.MockProvider{Response: validMockResponse()}
+	f := &checkFlags{
+		format:            "json",
+		profileName:       "general",
+		redactEnabled:     true,
+		severityThreshold: "info",
+		upload:            []string{"ftp://archive/plans/"},
+		provider:          mock,
+	}
+	err := runCheck(context.Background(), planPath, f)
+	assertExitCode(t, err, 3)
+	if len(mock.Prompts()) != 0 {
+		t.Error("an invalid --upload URL should fail before the LLM call")
+	}
+}
+
 func TestRunCheckDebugWritesPromptFile(t *testing.T) {
-	planPath := writeTempPlan(t, "# Plan\n")
+	planPath := writeTempPlan(t, "# Plan\n\n1. Ship it\n")
 
 	// Change to temp dir so debug file goes there
 	tmpDir := t.TempDir()
@@ -494,7 +603,7 @@ func TestRunCheckDebugWritesPromptFile(t *testing.T) {
 
 func TestRunCheckRedactDisabled(t *testing.T) {
 	// Plan with a secret pattern
-	planPath := writeTempPlan(t, "# Plan\nAPI_KEY=sk-abc123secret\n")
+	planPath := writeTempPlan(t, "# Plan\n- API_KEY=sk-abc123secret\n")
 	dir := t.TempDir()
 	outPath := filepath.Join(dir, "result.json")
 
@@ -538,7 +647,7 @@ func TestRunCheckRedactDisabled(t *testing.T) {
 }
 
 func TestRunCheckSeverityThresholdCritical(t *testing.T) {
-	planPath := writeTempPlan(t, "# Plan\n")
+	planPath := writeTempPlan(t, "# Plan\n\n1. Ship it\n")
 	dir := t.TempDir()
 	outPath := filepath.Join(dir, "result.json")
 
@@ -575,7 +684,7 @@ func TestRunCheckSeverityThresholdCritical(t *testing.T) {
 }
 
 func TestRunCheckStrict(t *testing.T) {
-	planPath := writeTempPlan(t, "# Plan\nDo something\n")
+	planPath := writeTempPlan(t, "# Plan\n- Do something\n")
 	f := &checkFlags{
 		format:            "json",
 		profileName:       "general",
@@ -589,7 +698,7 @@ func TestRunCheckStrict(t *testing.T) {
 }
 
 func TestRunCheckWithContext(t *testing.T) {
-	planPath := writeTempPlan(t, "# Plan\nDo something\n")
+	planPath := writeTempPlan(t, "# Plan\n- Do something\n")
 	dir := t.TempDir()
 	ctxPath := writeTempFile(t, dir, "context.md", "# Context\nSome context info\n")
 
@@ -663,19 +772,998 @@ func TestEnvFloat(t *testing.T) {
 	}
 }
 
-// callCountMockProvider returns different responses on successive calls.
-type callCountMockProvider struct {
-	responses []string
-	callIdx   int
+func TestCheckMockModelEndToEnd(t *testing.T) {
+	dir := t.TempDir()
+	planPath := writeTempPlan(t, "# Plan\n\n1. Ship it\n")
+	scenario := writeTempFile(t, dir, "scenario.yaml", "steps:\n  - response_file: review.json\n")
+	writeTempFile(t, dir, "review.json", validMockResponse())
+	outPath := filepath.Join(dir, "out.json")
+
+	cmd := newCheckCmd()
+	cmd.SetArgs([]string{planPath, "--model", "mock:" + scenario, "--out", outPath, "--fail-on", "not_executable"})
+	assertExitCode(t, cmd.Execute(), 2)
+
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rev review.Review
+	if err := json.Unmarshal(data, &rev); err != nil {
+		t.Fatal(err)
+	}
+	if rev.Meta.Model != "mock/mock:"+scenario {
+		t.Errorf("meta model = %q", rev.Meta.Model)
+	}
+}
+
+func TestCheckJoint(t *testing.T) {
+	dir := t.TempDir()
+	planPath := writeTempPlan(t, "# Design\n\n1. Ship to half of users\n")
+	rollout := writeTempFile(t, dir, "rollout.md", "# Rollout\n\n1. Ship to 10% of users\n")
+	outPath := filepath.Join(dir, "out.json")
+
+	cmd := newCheckCmd()
+	cmd.SetArgs([]string{planPath, rollout, "--joint", "--model", "mock:", "--out", outPath})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rev review.Review
+	if err := json.Unmarshal(data, &rev); err != nil {
+		t.Fatal(err)
+	}
+	if rev.Input.PlanFile != filepath.Base(planPath) || len(rev.Input.Plans) != 1 || rev.Input.Plans[0].Path != "rollout.md" {
+		t.Errorf("input = %+v, want one review of both plans", rev.Input)
+	}
+
+	for _, args := range [][]string{
+		{planPath, "--joint", "--model", "mock:"},
+		{planPath, rollout, "--joint", "--dry-run"},
+		{planPath, writeTempFile(t, dir, filepath.Base(planPath), "# Other\n\n1. Ship it\n"), "--joint", "--model", "mock:"},
+	} {
+		cmd := newCheckCmd()
+		cmd.SetArgs(args)
+		assertExitCode(t, cmd.Execute(), 3)
+	}
+}
+
+func TestRunCheckPostProcessHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not executable on windows")
+	}
+	dir := t.TempDir()
+	script := writeTempFile(t, dir, "downgrade.sh", "#!/bin/sh\nsed 's/\"severity\":\"CRITICAL\"/\"severity\":\"INFO\"/g'\n")
+	if err := os.Chmod(script, 0755); err != nil {
+		t.Fatal(err)
+	}
+	outPath := filepath.Join(dir, "out.json")
+	f := &checkFlags{
+		format:            "json",
+		out:               outPath,
+		profileName:       "general",
+		redactEnabled:     true,
+		severityThreshold: "info",
+		failOn:            "not_executable",
+		postProcess:       []string{script},
+		provider:          &llm.MockProvider{Response: validMockResponse()},
+	}
+	assertExitCode(t, runCheck(context.Background(), writeTempPlan(t, "# Plan\n\n1. Ship it\n"), f), 0)
+
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rev review.Review
+	if err := json.Unmarshal(data, &rev); err != nil {
+		t.Fatal(err)
+	}
+	if rev.Summary.CriticalCount != 0 || rev.Summary.InfoCount != 1 {
+		t.Errorf("summary not recomputed after hook: %+v", rev.Summary)
+	}
 }
 
-func (m *callCountMockProvider) Name() string { return "mock" }
+func TestRunCheckPostProcessHookFails(t *testing.T) {
+	f := &checkFlags{
+		format:            "json",
+		profileName:       "general",
+		redactEnabled:     true,
+		severityThreshold: "info",
+		postProcess:       []string{filepath.Join(t.TempDir(), "missing-hook")},
+		provider:          &llm.MockProvider{Response: validMockResponse()},
+	}
+	assertExitCode(t, runCheck(context.Background(), writeTempPlan(t, "# Plan\n\n1. Ship it\n"), f), 3)
+}
 
-func (m *callCountMockProvider) Generate(_ context.Context, _ string, _ llm.Settings) (string, llm.Usage, error) {
-	if m.callIdx >= len(m.responses) {
-		return "", llm.Usage{}, errors.New("no more mock responses")
+func TestCheckEnsembleMergesMembers(t *testing.T) {
+	dir := t.TempDir()
+	planPath := writeTempPlan(t, "# Plan\n\n1. Ship it\n")
+	writeTempFile(t, dir, "a.json", validMockResponse())
+	writeTempFile(t, dir, "b.json", `{"tool":"plancritic","version":"1.0","summary":{"verdict":"NOT_EXECUTABLE","score":80,"critical_count":1,"warn_count":0,"info_count":0},"issues":[{"id":"ISSUE-0001","severity":"CRITICAL","category":"CONTRADICTION","title":"Test issue","description":"d","evidence":[{"source":"plan","path":"plan.md","line_start":1,"line_end":1}]},{"id":"ISSUE-0002","severity":"INFO","category":"TEST_GAP","title":"Only b","description":"d","evidence":[{"source":"plan","path":"plan.md","line_start":1,"line_end":1}]}],"questions":[]}`)
+	a := writeTempFile(t, dir, "a.yaml", "response_file: a.json\n")
+	b := writeTempFile(t, dir, "b.yaml", "response_file: b.json\n")
+	outPath := filepath.Join(dir, "out.json")
+
+	cmd := newCheckCmd()
+	cmd.SetArgs([]string{planPath, "--ensemble", "mock:" + a + ",mock:" + b, "--out", outPath})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
 	}
-	resp := m.responses[m.callIdx]
-	m.callIdx++
-	return resp, llm.Usage{}, nil
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rev review.Review
+	if err := json.Unmarshal(data, &rev); err != nil {
+		t.Fatal(err)
+	}
+	if len(rev.Issues) != 2 {
+		t.Fatalf("issues = %d, want 2", len(rev.Issues))
+	}
+	if ag := rev.Issues[0].Agreement; ag == nil || ag.Count != 2 || ag.Total != 2 {
+		t.Errorf("shared issue agreement = %+v", ag)
+	}
+	if !strings.HasPrefix(rev.Meta.Model, "ensemble(") {
+		t.Errorf("meta model = %q", rev.Meta.Model)
+	}
+
+	// --min-agreement 2 keeps only the consensus finding.
+	cmd = newCheckCmd()
+	cmd.SetArgs([]string{planPath, "--ensemble", "mock:" + a + ",mock:" + b, "--min-agreement", "2", "--out", outPath})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(outPath)
+	rev = review.Review{}
+	if err := json.Unmarshal(data, &rev); err != nil {
+		t.Fatal(err)
+	}
+	if len(rev.Issues) != 1 || rev.Issues[0].Title != "Test issue" {
+		t.Errorf("issues after --min-agreement 2 = %+v", rev.Issues)
+	}
+}
+
+func TestCheckEnsembleInvalid(t *testing.T) {
+	planPath := writeTempPlan(t, "# Plan\n\n1. Ship it\n")
+	for _, args := range [][]string{
+		{planPath, "--ensemble", "mock:,mock:x.yaml", "--model", "gpt-5.2"},
+		{planPath, "--ensemble", "mock:"},
+		{planPath, "--ensemble", "mock:,openai:gpt-5.2", "--min-agreement", "3"},
+	} {
+		cmd := newCheckCmd()
+		cmd.SetArgs(args)
+		assertExitCode(t, cmd.Execute(), 3)
+	}
+}
+
+func TestRunCheckResponseCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	planPath := writeTempPlan(t, "# Plan\n\n1. Ship it\n")
+	mock := &llm.MockProvider{Steps: []llm.MockStep{{Response: validMockResponse(), Times: 1}}}
+	newFlags := func() *checkFlags {
+		return &checkFlags{
+			format:            "json",
+			out:               filepath.Join(t.TempDir(), "out.json"),
+			profileName:       "general",
+			redactEnabled:     true,
+			severityThreshold: "info",
+			responseCache:     true,
+			responseCacheTTL:  "1h",
+			provider:          mock,
+		}
+	}
+	assertExitCode(t, runCheck(context.Background(), planPath, newFlags()), 0)
+	// The mock's only step is spent; a second provider call would fail.
+	assertExitCode(t, runCheck(context.Background(), planPath, newFlags()), 0)
+	if n := len(mock.Prompts()); n != 1 {
+		t.Errorf("provider calls = %d, want 1", n)
+	}
+
+	// --no-cache bypasses the response cache.
+	f := newFlags()
+	f.noCache = true
+	assertExitCode(t, runCheck(context.Background(), planPath, f), 4)
+}
+
+func TestRunCheckInvalidResponseCacheTTL(t *testing.T) {
+	f := &checkFlags{
+		format:            "json",
+		profileName:       "general",
+		severityThreshold: "info",
+		responseCache:     true,
+		responseCacheTTL:  "soon",
+		provider:          &llm.MockProvider{Response: validMockResponse()},
+	}
+	assertExitCode(t, runCheck(context.Background(), writeTempPlan(t, "# Plan\n\n1. Ship it\n"), f), 3)
+}
+
+func TestRunCheckUnknownStorageBackend(t *testing.T) {
+	f := &checkFlags{
+		format:            "json",
+		profileName:       "general",
+		severityThreshold: "info",
+		responseCache:     true,
+		storage:           "sqlite",
+		provider:          &llm.MockProvider{Response: validMockResponse()},
+	}
+	assertExitCode(t, runCheck(context.Background(), writeTempPlan(t, "# Plan\n\n1. Ship it\n"), f), 3)
+}
+
+func TestRunCheckInvalidReasoningEffort(t *testing.T) {
+	f := &checkFlags{
+		format:            "json",
+		profileName:       "general",
+		severityThreshold: "info",
+		reasoningEffort:   "extreme",
+		provider:          &llm.MockProvider{Response: validMockResponse()},
+	}
+	assertExitCode(t, runCheck(context.Background(), writeTempPlan(t, "# Plan\n\n1. Ship it\n"), f), 3)
+}
+
+func TestRunCheckEvidenceMinimumTriggersRepair(t *testing.T) {
+	var rev review.Review
+	if err := json.Unmarshal([]byte(validMockResponse()), &rev); err != nil {
+		t.Fatal(err)
+	}
+	rev.Issues[0].Evidence = append(rev.Issues[0].Evidence, review.Evidence{Source: "plan", Path: "plan.md", LineStart: 2, LineEnd: 2})
+	repaired, err := json.Marshal(rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := &llm.MockProvider{Steps: []llm.MockStep{
+		{Response: validMockResponse(), Times: 1},
+		{Response: string(repaired), Times: 1},
+	}}
+	f := &checkFlags{
+		format:            "json",
+		out:               filepath.Join(t.TempDir(), "out.json"),
+		profileName:       "go-backend",
+		redactEnabled:     true,
+		severityThreshold: "info",
+		provider:          mock,
+	}
+	assertExitCode(t, runCheck(context.Background(), writeTempPlan(t, "# Plan\n1. Step one\n"), f), 0)
+	prompts := mock.Prompts()
+	if len(prompts) != 2 {
+		t.Fatalf("provider calls = %d, want initial + repair", len(prompts))
+	}
+	if !strings.Contains(prompts[1], "CRITICAL issues need at least 2 evidence entries") {
+		t.Errorf("repair prompt missing evidence error:\n%s", prompts[1])
+	}
+}
+
+func TestRunCheckEvidenceMinimumDowngradesAfterRepairs(t *testing.T) {
+	mock := &llm.MockProvider{Response: validMockResponse()}
+	out := filepath.Join(t.TempDir(), "out.json")
+	f := &checkFlags{
+		format:            "json",
+		out:               out,
+		profileName:       "go-backend",
+		redactEnabled:     true,
+		severityThreshold: "info",
+		provider:          mock,
+	}
+	assertExitCode(t, runCheck(context.Background(), writeTempPlan(t, "# Plan\n1. Step one\n"), f), 0)
+	if n := len(mock.Prompts()); n != 2 {
+		t.Errorf("provider calls = %d, want initial + repair", n)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rev review.Review
+	if err := json.Unmarshal(data, &rev); err != nil {
+		t.Fatal(err)
+	}
+	if len(rev.Issues) != 1 || rev.Issues[0].Severity != review.SeverityWarn || rev.Issues[0].Blocking {
+		t.Errorf("issues = %+v, want the single-citation CRITICAL kept as a non-blocking WARN", rev.Issues)
+	}
+}
+
+func TestRunCheckLogLLM(t *testing.T) {
+	logDir := filepath.Join(t.TempDir(), "llm")
+	mock := &llm.MockProvider{Steps: []llm.MockStep{
+		{Response: `{"summary":{"verdict":"MAYBE"},"issues":[],"questions":[]}`, Times: 1},
+		{Response: validMockResponse(), Times: 1},
+	}}
+	f := &checkFlags{
+		format:            "json",
+		out:               filepath.Join(t.TempDir(), "out.json"),
+		profileName:       "general",
+		redactEnabled:     true,
+		severityThreshold: "info",
+		logLLM:            logDir,
+		provider:          mock,
+	}
+	assertExitCode(t, runCheck(context.Background(), writeTempPlan(t, "# Plan\n\n1. Ship it\n"), f), 0)
+
+	entries, err := os.ReadDir(logDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("transcript files = %d, want review + repair", len(entries))
+	}
+	var kinds []string
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(logDir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var entry struct {
+			Kind     string `json:"kind"`
+			Model    string `json:"model"`
+			System   string `json:"system"`
+			Prompt   string `json:"prompt"`
+			Response string `json:"response"`
+			Status   string `json:"status"`
+		}
+		if err := json.Unmarshal(data, &entry); err != nil {
+			t.Fatal(err)
+		}
+		if entry.Status != "ok" || entry.Model != "mock/" || entry.System == "" || entry.Prompt == "" || entry.Response == "" {
+			t.Errorf("entry %s = %+v", e.Name(), entry)
+		}
+		kinds = append(kinds, entry.Kind)
+	}
+	if kinds[0] != "review" || kinds[1] != "repair" {
+		t.Errorf("kinds = %v, want [review repair]", kinds)
+	}
+}
+
+func TestRunCheckMaxDurationIncomplete(t *testing.T) {
+	dir := t.TempDir()
+	outPath := filepath.Join(dir, "out.json")
+	var rev review.Review
+	if err := json.Unmarshal([]byte(validMockResponse()), &rev); err != nil {
+		t.Fatal(err)
+	}
+	// The second issue fails validation, so the run waits on a repair
+	// call that outlasts the budget.
+	bad := rev.Issues[0]
+	bad.ID, bad.Title = "ISSUE-0002", ""
+	rev.Issues = append(rev.Issues, bad)
+	first, err := json.Marshal(rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &checkFlags{
+		format:            "json",
+		out:               outPath,
+		profileName:       "general",
+		redactEnabled:     true,
+		severityThreshold: "info",
+		maxDuration:       "200ms",
+		provider: &llm.MockProvider{Steps: []llm.MockStep{
+			{Response: string(first), Times: 1},
+			{Response: validMockResponse(), Latency: 5 * time.Second},
+		}},
+	}
+	assertExitCode(t, runCheck(context.Background(), writeTempPlan(t, "# Plan\n\n1. Ship it\n"), f), 6)
+
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got review.Review
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != review.StatusIncomplete {
+		t.Errorf("status = %q, want INCOMPLETE", got.Status)
+	}
+	if len(got.Issues) != 1 || got.Issues[0].Title != "Test issue" {
+		t.Errorf("issues = %+v, want only the validated issue", got.Issues)
+	}
+
+	// Nothing validated in time: an empty incomplete review.
+	f.provider = &llm.MockProvider{Response: validMockResponse(), Latency: 5 * time.Second}
+	assertExitCode(t, runCheck(context.Background(), writeTempPlan(t, "# Plan\n\n1. Ship it\n"), f), 6)
+
+	f.maxDuration = "soon"
+	assertExitCode(t, runCheck(context.Background(), writeTempPlan(t, "# Plan\n\n1. Ship it\n"), f), 3)
+}
+
+func TestRunCheckMaxRepairAttempts(t *testing.T) {
+	var rev review.Review
+	if err := json.Unmarshal([]byte(validMockResponse()), &rev); err != nil {
+		t.Fatal(err)
+	}
+	rev.Issues[0].Title = ""
+	untitled, err := json.Marshal(rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := func() *llm.MockProvider {
+		return &llm.MockProvider{Steps: []llm.MockStep{
+			{Response: `{"summary":{"verdict":"MAYBE"},"issues":[],"questions":[]}`, Times: 1},
+			{Response: string(untitled), Times: 1},
+			{Response: validMockResponse(), Times: 1},
+		}}
+	}
+	newFlags := func(attempts int, mock *llm.MockProvider) *checkFlags {
+		return &checkFlags{
+			format:            "json",
+			out:               filepath.Join(t.TempDir(), "out.json"),
+			profileName:       "general",
+			redactEnabled:     true,
+			severityThreshold: "info",
+			maxRepairAttempts: attempts,
+			provider:          mock,
+		}
+	}
+
+	mock := script()
+	assertExitCode(t, runCheck(context.Background(), writeTempPlan(t, "# Plan\n\n1. Ship it\n"), newFlags(2, mock)), 0)
+	prompts := mock.Prompts()
+	if len(prompts) != 3 {
+		t.Fatalf("provider calls = %d, want initial + 2 repairs", len(prompts))
+	}
+	if !strings.Contains(prompts[2], "## Errors Fixed In Earlier Attempts") || !strings.Contains(prompts[2], "summary.verdict") {
+		t.Errorf("second repair prompt should carry the earlier error:\n%s", prompts[2])
+	}
+
+	// The default single repair round is not enough for this script.
+	mock = script()
+	assertExitCode(t, runCheck(context.Background(), writeTempPlan(t, "# Plan\n\n1. Ship it\n"), newFlags(0, mock)), 5)
+	if n := len(mock.Prompts()); n != 2 {
+		t.Errorf("provider calls = %d, want 2", n)
+	}
+
+	// Repair disabled.
+	mock = script()
+	assertExitCode(t, runCheck(context.Background(), writeTempPlan(t, "# Plan\n\n1. Ship it\n"), newFlags(-1, mock)), 5)
+	if n := len(mock.Prompts()); n != 1 {
+		t.Errorf("provider calls = %d, want 1", n)
+	}
+}
+
+func TestRunSummary(t *testing.T) {
+	cost := 0.01234
+	rev := &review.Review{
+		Summary:   review.Summary{Verdict: review.VerdictNotExecutable, Score: 40, CriticalCount: 1, WarnCount: 2},
+		Questions: []review.Question{{ID: "Q-0001"}},
+		Meta:      review.Meta{Usage: &review.Usage{InputTokens: 10, EstimatedCostUSD: &cost}},
+	}
+	got := runSummary(rev, "review.json", 1500*time.Millisecond, exitError(2, "fail"))
+	want := "plancritic: verdict=NOT_EXECUTABLE score=40 critical=1 warn=2 info=0 questions=1 duration=1.5s cost=$0.0123 out=review.json exit=2"
+	if got != want {
+		t.Errorf("runSummary =\n%s\nwant\n%s", got, want)
+	}
+
+	rev.Meta.Usage.EstimatedCostUSD = nil
+	rev.Status = review.StatusIncomplete
+	got = runSummary(rev, "", time.Second, exitError(6, "incomplete"))
+	if !strings.HasPrefix(got, "plancritic: status=INCOMPLETE verdict=") || !strings.Contains(got, "cost=n/a out=stdout exit=6") {
+		t.Errorf("runSummary = %s", got)
+	}
+
+	got = runSummary(nil, "", 20*time.Millisecond, exitError(3, "bad input"))
+	if got != "plancritic: status=error duration=20ms exit=3" {
+		t.Errorf("runSummary = %s", got)
+	}
+}
+
+func TestRunCheckLocalJSONRepairSkipsRepairCall(t *testing.T) {
+	valid := strings.TrimSpace(validMockResponse())
+	for name, resp := range map[string]string{
+		"trailing comma": strings.TrimSuffix(valid, "}") + ",}",
+		"truncated":      strings.TrimSuffix(valid, "}"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			mock := &llm.MockProvider{Steps: []llm.MockStep{{Response: resp}}}
+			f := &checkFlags{
+				format:            "json",
+				out:               filepath.Join(t.TempDir(), "out.json"),
+				profileName:       "general",
+				maxTokens:         4096,
+				maxIssues:         50,
+				maxQuestions:      20,
+				maxInputTokens:    180000,
+				severityThreshold: "info",
+				provider:          mock,
+			}
+			assertExitCode(t, runCheck(context.Background(), writeTempPlan(t, "# Plan\n\n1. Ship it\n"), f), 0)
+			if n := len(mock.Prompts()); n != 1 {
+				t.Errorf("provider calls = %d, want 1 (no repair call)", n)
+			}
+		})
+	}
+}
+
+func TestRunCheckStamp(t *testing.T) {
+	planPath := writeTempPlan(t, "# Plan\n1. Step one\n")
+	run := func() review.Review {
+		t.Helper()
+		out := filepath.Join(t.TempDir(), "out.json")
+		f := &checkFlags{
+			format:            "json",
+			out:               out,
+			profileName:       "general",
+			maxTokens:         4096,
+			maxIssues:         50,
+			maxQuestions:      20,
+			maxInputTokens:    180000,
+			severityThreshold: "info",
+			stamp:             true,
+			provider:          &llm.MockProvider{Steps: []llm.MockStep{{Response: validMockResponse()}}},
+		}
+		assertExitCode(t, runCheck(context.Background(), planPath, f), 0)
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rev review.Review
+		if err := json.Unmarshal(data, &rev); err != nil {
+			t.Fatal(err)
+		}
+		return rev
+	}
+
+	first := run()
+	second := run()
+	data, err := os.ReadFile(planPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "<!-- plancritic:review"); n != 1 {
+		t.Fatalf("plan has %d stamps, want 1:\n%s", n, data)
+	}
+	hash, err := review.ArtifactHash(&second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "verdict: NOT_EXECUTABLE\n") || !strings.Contains(string(data), "review: "+hash) {
+		t.Errorf("stamp does not describe the latest review:\n%s", data)
+	}
+	if first.Input.PlanHash != second.Input.PlanHash {
+		t.Error("stamping changed the plan hash")
+	}
+}
+
+func TestRunCheckUnchangedLeavesFilesAlone(t *testing.T) {
+	planPath := writeTempPlan(t, "# Plan\n1. Step one\n")
+	out := filepath.Join(t.TempDir(), "out.json")
+	run := func(force bool) {
+		t.Helper()
+		f := &checkFlags{
+			format:            "json",
+			out:               out,
+			profileName:       "general",
+			maxTokens:         4096,
+			maxIssues:         50,
+			maxQuestions:      20,
+			maxInputTokens:    180000,
+			severityThreshold: "info",
+			stamp:             true,
+			force:             force,
+			provider:          &llm.MockProvider{Response: validMockResponse()},
+		}
+		assertExitCode(t, runCheck(context.Background(), planPath, f), 0)
+	}
+	// Backdate the files so a rewrite is visible whatever the
+	// filesystem's timestamp resolution.
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	backdate := func() {
+		t.Helper()
+		for _, p := range []string{out, planPath} {
+			if err := os.Chtimes(p, old, old); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	touched := func(p string) bool {
+		t.Helper()
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return !info.ModTime().Equal(old)
+	}
+
+	run(false)
+	before, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backdate()
+	run(false)
+	if touched(out) || touched(planPath) {
+		t.Error("unchanged re-run rewrote its output or the plan stamp")
+	}
+	after, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Errorf("re-run output differs:\n%s\nvs\n%s", before, after)
+	}
+
+	run(true)
+	if !touched(out) || !touched(planPath) {
+		t.Error("--force did not rewrite the output and plan stamp")
+	}
+}
+
+func TestRunCheckContinuesTruncatedResponse(t *testing.T) {
+	full := validMockResponse()
+	cut := len(full) / 2
+	mock := &llm.MockProvider{Steps: []llm.MockStep{
+		{Response: full[:cut], Err: fmt.Errorf("mock: %w (hit max_tokens=10)", llm.ErrTruncated), Times: 1},
+		{Response: full[cut:], Times: 1},
+	}}
+	out := filepath.Join(t.TempDir(), "out.json")
+	f := &checkFlags{
+		format:            "json",
+		out:               out,
+		profileName:       "general",
+		maxTokens:         4096,
+		maxIssues:         50,
+		maxQuestions:      20,
+		maxInputTokens:    180000,
+		severityThreshold: "info",
+		provider:          mock,
+	}
+	assertExitCode(t, runCheck(context.Background(), writeTempPlan(t, "# Plan\n\n1. Ship it\n"), f), 0)
+
+	prompts := mock.Prompts()
+	if len(prompts) != 2 {
+		t.Fatalf("provider calls = %d, want review + continuation", len(prompts))
+	}
+	if !strings.Contains(prompts[1], "## Partial Response") || !strings.Contains(prompts[1], full[:cut]) {
+		t.Errorf("continuation prompt should carry the partial response:\n%s", prompts[1])
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "Test issue") {
+		t.Errorf("stitched review missing the issue:\n%s", data)
+	}
+}
+
+func TestRunCheckRefusalIsProviderError(t *testing.T) {
+	mock := &llm.MockProvider{Response: "I'm sorry, but I can't help with reviewing this plan."}
+	f := &checkFlags{
+		format:            "json",
+		profileName:       "general",
+		severityThreshold: "info",
+		provider:          mock,
+	}
+	assertExitCode(t, runCheck(context.Background(), writeTempPlan(t, "# Plan\n\n1. Ship it\n"), f), 4)
+	if n := len(mock.Prompts()); n != 1 {
+		t.Errorf("provider calls = %d, want 1 (a refusal is not repaired)", n)
+	}
+}
+
+func TestRunCheckMinFindingsSanity(t *testing.T) {
+	empty, err := json.Marshal(review.Review{
+		Tool:      "plancritic",
+		Version:   "1.0",
+		Summary:   review.ComputeSummary(nil),
+		Issues:    []review.Issue{},
+		Questions: []review.Question{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name           string
+		retry          string
+		wantIssues     int
+		wantSuspicious bool
+	}{
+		{"retry finds issues", validMockResponse(), 1, false},
+		{"retry still empty", string(empty), 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &llm.MockProvider{Steps: []llm.MockStep{
+				{Response: string(empty), Times: 1},
+				{Response: tt.retry, Times: 1},
+			}}
+			outPath := filepath.Join(t.TempDir(), "out.json")
+			f := &checkFlags{
+				format:            "json",
+				out:               outPath,
+				profileName:       "general",
+				severityThreshold: "info",
+				minFindingsSanity: 1,
+				provider:          mock,
+			}
+			assertExitCode(t, runCheck(context.Background(), writeTempPlan(t, "# Plan\n\n1. Deploy it\n"), f), 0)
+
+			prompts := mock.Prompts()
+			if len(prompts) != 2 || !strings.Contains(prompts[1], "## Second Look") {
+				t.Fatalf("expected one second-look retry, got %d prompts", len(prompts))
+			}
+			data, err := os.ReadFile(outPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var rev review.Review
+			if err := json.Unmarshal(data, &rev); err != nil {
+				t.Fatal(err)
+			}
+			if len(rev.Issues) != tt.wantIssues {
+				t.Errorf("issues = %d, want %d", len(rev.Issues), tt.wantIssues)
+			}
+			if !rev.Meta.SanityRetry || rev.Meta.SuspiciouslyEmpty != tt.wantSuspicious {
+				t.Errorf("meta = %+v, want sanity_retry and suspiciously_empty=%v", rev.Meta, tt.wantSuspicious)
+			}
+		})
+	}
+}
+
+func TestCheckBatchSubmitAndCollect(t *testing.T) {
+	dir := t.TempDir()
+	planA := writeTempFile(t, dir, "a.md", "# Plan A\n")
+	planB := writeTempFile(t, dir, "b.md", "# Plan B\n")
+	ticket := filepath.Join(dir, "batch.json")
+	outDir := filepath.Join(dir, "reviews")
+	mock := &llm.MockProvider{Response: validMockResponse()}
+	newFlags := func() *checkFlags {
+		return &checkFlags{
+			format:            "json",
+			profileName:       "general",
+			severityThreshold: "info",
+			failOn:            "not_executable",
+			provider:          mock,
+		}
+	}
+
+	f := newFlags()
+	f.batchSubmit = ticket
+	assertExitCode(t, runBatchSubmit(context.Background(), []string{planA, planB}, f), 0)
+	data, err := os.ReadFile(ticket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tk reviewer.BatchTicket
+	if err := json.Unmarshal(data, &tk); err != nil {
+		t.Fatal(err)
+	}
+	if tk.BatchID == "" || tk.Provider != "mock" || len(tk.Plans) != 2 {
+		t.Fatalf("ticket = %+v", tk)
+	}
+
+	f = newFlags()
+	f.batchCollect = ticket
+	f.out = outDir
+	// Both reviews are NOT_EXECUTABLE, so --fail-on trips after writing them.
+	assertExitCode(t, runBatchCollect(context.Background(), f), 2)
+	for _, name := range []string{"a.review.json", "b.review.json"} {
+		data, err := os.ReadFile(filepath.Join(outDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rev review.Review
+		if err := json.Unmarshal(data, &rev); err != nil {
+			t.Fatal(err)
+		}
+		if rev.Summary.Verdict != review.VerdictNotExecutable || len(rev.Issues) != 1 {
+			t.Errorf("%s: summary = %+v", name, rev.Summary)
+		}
+	}
+	if n := len(mock.Prompts()); n != 2 {
+		t.Errorf("provider calls = %d, want 2 (one per plan, none at collection)", n)
+	}
+
+	// A plan edited after submission cannot be matched to its findings.
+	if err := os.WriteFile(planB, []byte("# Plan B, revised\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f = newFlags()
+	f.batchCollect = ticket
+	f.out = outDir
+	assertExitCode(t, runBatchCollect(context.Background(), f), 3)
+}
+
+func TestCheckBatchInvalid(t *testing.T) {
+	dir := t.TempDir()
+	sameName := writeTempFile(t, t.TempDir(), "plan.md", "# Other\n")
+	f := &checkFlags{
+		format:      "json",
+		profileName: "general",
+		batchSubmit: filepath.Join(dir, "batch.json"),
+		provider:    &llm.MockProvider{Response: validMockResponse()},
+	}
+	assertExitCode(t, runBatchSubmit(context.Background(), []string{writeTempPlan(t, "# Plan\n\n1. Ship it\n"), sameName}, f), 3)
+
+	planPath := writeTempPlan(t, "# Plan\n\n1. Ship it\n")
+	for _, args := range [][]string{
+		{planPath, "--batch-submit", filepath.Join(dir, "t.json"), "--batch-collect", filepath.Join(dir, "t.json")},
+		{planPath, "--batch-submit", filepath.Join(dir, "t.json"), "--stamp"},
+		{planPath, "--batch-submit", filepath.Join(dir, "t.json"), "--ensemble", "mock:,mock:"},
+		{"--batch-collect", filepath.Join(dir, "missing.json")},
+	} {
+		cmd := newCheckCmd()
+		cmd.SetArgs(args)
+		assertExitCode(t, cmd.Execute(), 3)
+	}
+}
+
+func TestRunCheckDropsInvalidPatches(t *testing.T) {
+	var rev review.Review
+	if err := json.Unmarshal([]byte(validMockResponse()), &rev); err != nil {
+		t.Fatal(err)
+	}
+	rev.Patches = []review.Patch{
+		{ID: "PATCH-0001", Type: review.PatchTypePlanTextEdit, Title: "Applies", DiffUnified: "--- plan.md\n+++ plan.md\n@@ -3 +3 @@\n-1. Deploy it\n+1. Deploy it behind a flag\n"},
+		{ID: "PATCH-0002", Type: review.PatchTypePlanTextEdit, Title: "Wrong context", DiffUnified: "--- plan.md\n+++ plan.md\n@@ -3 +3 @@\n-1. Ship it\n+1. Ship it carefully\n"},
+		{ID: "PATCH-0003", Type: review.PatchTypePlanTextEdit, Title: "Bad counts", DiffUnified: "--- plan.md\n+++ plan.md\n@@ -3,2 +3,2 @@\n-1. Deploy it\n+1. Deploy\n"},
+	}
+	data, err := json.Marshal(rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	outPath := filepath.Join(dir, "out.json")
+	patchPath := filepath.Join(dir, "plan.diff")
+	f := &checkFlags{
+		format:            "json",
+		out:               outPath,
+		patchOut:          patchPath,
+		profileName:       "general",
+		severityThreshold: "info",
+		provider:          &llm.MockProvider{Response: string(data)},
+	}
+	assertExitCode(t, runCheck(context.Background(), writeTempPlan(t, "# Plan\n\n1. Deploy it\n"), f), 0)
+
+	out, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got review.Review
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Patches) != 1 || got.Patches[0].ID != "PATCH-0001" {
+		t.Fatalf("patches = %+v, want only PATCH-0001", got.Patches)
+	}
+	diff, err := os.ReadFile(patchPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(diff), "Ship it") || strings.Contains(string(diff), "+1. Deploy\n") {
+		t.Errorf("patch file contains a dropped diff:\n%s", diff)
+	}
+}
+
+func TestRunCheckErrorJSON(t *testing.T) {
+	planPath := writeTempPlan(t, "# Plan\n\n1. Ship it\n")
+	outPath := filepath.Join(t.TempDir(), "review.json")
+	f := &checkFlags{
+		format:            "json",
+		out:               outPath,
+		profileName:       "general",
+		redactEnabled:     true,
+		severityThreshold: "info",
+		provider:          &llm.MockProvider{Err: &llm.APIError{Provider: "mock", StatusCode: 401}},
+	}
+	err := runCheck(context.Background(), planPath, f)
+	assertExitCode(t, err, 4)
+
+	data, readErr := os.ReadFile(outPath)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	var doc errorJSON
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, data)
+	}
+	if doc.Error.ExitCode != 4 || doc.Error.Class != llm.ClassAuth || doc.Error.Retryable || doc.Error.Message == "" {
+		t.Errorf("error = %+v", doc.Error)
+	}
+}
+
+func TestRunCheckManyPlans(t *testing.T) {
+	plans := t.TempDir()
+	writeTempFile(t, plans, "a.md", "# Plan A\n\n1. Ship it\n")
+	writeTempFile(t, plans, "b.md", "# Plan B\n\n1. Ship it\n")
+	writeTempFile(t, plans, "notes.txt", "not a plan\n")
+
+	paths, err := expandPlanArgs([]string{filepath.Join(plans, "*.md"), filepath.Join(plans, "a.md")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("paths = %v, want a.md and b.md once each", paths)
+	}
+	if _, err := expandPlanArgs([]string{filepath.Join(plans, "*.yaml")}); err == nil {
+		t.Error("expected an error for a pattern that matches nothing")
+	}
+
+	out := t.TempDir()
+	f := &checkFlags{
+		format:            "json",
+		out:               out,
+		profileName:       "general",
+		redactEnabled:     true,
+		severityThreshold: "info",
+		failOn:            "critical",
+		provider:          &llm.MockProvider{Response: validMockResponse()},
+	}
+	err = runCheckMany(context.Background(), append(paths, filepath.Join(plans, "missing.md")), f)
+	assertExitCode(t, err, 3)
+	for _, name := range []string{"a.review.json", "b.review.json"} {
+		data, err := os.ReadFile(filepath.Join(out, name))
+		if err != nil {
+			t.Fatalf("%s not written: %v", name, err)
+		}
+		var rev review.Review
+		if err := json.Unmarshal(data, &rev); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	err = runCheckMany(context.Background(), paths, f)
+	assertExitCode(t, err, 2)
+}
+
+func TestRunDryRun(t *testing.T) {
+	planPath := writeTempPlan(t, "# Plan\n1. Step one\n")
+	mock := &llm.MockProvider{Response: validMockResponse()}
+	f := &checkFlags{
+		format:            "json",
+		profileName:       "general",
+		maxTokens:         4096,
+		maxIssues:         50,
+		maxQuestions:      20,
+		severityThreshold: "info",
+		provider:          mock,
+	}
+	var out bytes.Buffer
+	assertExitCode(t, runDryRun(&out, []string{planPath}, f), 0)
+	if n := len(mock.Prompts()); n != 0 {
+		t.Fatalf("dry run sent %d prompts", n)
+	}
+	var ests []planEstimate
+	if err := json.Unmarshal(out.Bytes(), &ests); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out.String())
+	}
+	if len(ests) != 1 || ests[0].Plan != planPath || ests[0].PromptTokens == 0 || ests[0].Parts != 1 {
+		t.Fatalf("estimate = %+v", ests)
+	}
+	if m := ests[0].Models; len(m) != 1 || m[0].OutputTokens != 4096 || m[0].CostUSD == nil {
+		t.Errorf("models = %+v, want one priced model with the response budget", m)
+	}
+
+	f.format = "md"
+	out.Reset()
+	assertExitCode(t, runDryRun(&out, []string{planPath}, f), 0)
+	if !strings.Contains(out.String(), "prompt tokens") || !strings.Contains(out.String(), "mock") {
+		t.Errorf("text estimate = %q", out.String())
+	}
+}
+
+func TestCheckProfileFile(t *testing.T) {
+	dir := t.TempDir()
+	planPath := writeTempPlan(t, "# Plan\n\n1. Charge the card\n")
+	profPath := writeTempFile(t, dir, "team.yaml", "name: team\nchecklists:\n  - id: PAYMENTS\n    title: Payments\n    checks: [\"Is every charge idempotent?\"]\n")
+	outPath := filepath.Join(dir, "out.json")
+
+	cmd := newCheckCmd()
+	cmd.SetArgs([]string{planPath, "--profile", profPath, "--model", "mock:", "--out", outPath})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rev review.Review
+	if err := json.Unmarshal(data, &rev); err != nil {
+		t.Fatal(err)
+	}
+	if rev.Input.Profile != profPath {
+		t.Errorf("input.profile = %q, want %q", rev.Input.Profile, profPath)
+	}
+
+	bad := writeTempFile(t, dir, "bad.yaml", "checklist: []\n")
+	cmd = newCheckCmd()
+	cmd.SetArgs([]string{planPath, "--profile", bad, "--model", "mock:"})
+	assertExitCode(t, cmd.Execute(), 3)
 }
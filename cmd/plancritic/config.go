@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dshills/plancritic/internal/config"
+	"github.com/dshills/plancritic/internal/llm"
+	"github.com/dshills/plancritic/internal/render"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// defaults resolves flag defaults from environment variables and config
+// files (env wins), recording where each value came from so
+// `config show` can report provenance.
+type defaults struct {
+	cfg     *config.Set
+	err     error
+	sources map[string]string
+}
+
+func loadDefaults() *defaults {
+	cfg, err := config.Load()
+	return &defaults{cfg: cfg, err: err, sources: make(map[string]string)}
+}
+
+func (d *defaults) str(key, env, fallback string) string {
+	if v := os.Getenv(env); v != "" {
+		d.sources[key] = "env " + env
+		return v
+	}
+	if v, path, ok := d.cfg.Default(key); ok {
+		d.sources[key] = "config " + path
+		return v
+	}
+	d.sources[key] = "default"
+	return fallback
+}
+
+func (d *defaults) bool(key, env string, fallback bool) bool {
+	v := d.str(key, env, strconv.FormatBool(fallback))
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		d.sources[key] = "default"
+		return fallback
+	}
+	return b
+}
+
+func (d *defaults) int(key, env string, fallback int) int {
+	v := d.str(key, env, strconv.Itoa(fallback))
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		d.sources[key] = "default"
+		return fallback
+	}
+	return n
+}
+
+func (d *defaults) float(key, env string, fallback float64) float64 {
+	v := d.str(key, env, strconv.FormatFloat(fallback, 'g', -1, 64))
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		d.sources[key] = "default"
+		return fallback
+	}
+	return f
+}
+
+// theme builds the render theme from the config files.
+func (d *defaults) theme() (render.Theme, error) {
+	t := d.cfg.Theme()
+	return render.NewTheme(t.Labels, t.Icons, t.Colors)
+}
+
+// apiKeys combines --api-key-file with the key sources in the user
+// config.
+func (d *defaults) apiKeys(file string) llm.KeySources {
+	return llm.KeySources{File: file, Files: d.cfg.APIKeyFiles(), Commands: d.cfg.APIKeyCmds()}
+}
+
+// applyProviderProfile fills the provider settings of f from the
+// provider profile it names and returns the profile's key command.
+// Flags given on the command line win over the profile, which wins
+// over environment variables and config defaults.
+func (d *defaults) applyProviderProfile(flags *pflag.FlagSet, f *checkFlags) (string, error) {
+	p, _, ok := d.cfg.ProviderProfile(f.providerProfile)
+	if !ok {
+		names := d.cfg.ProviderProfileNames()
+		if len(names) == 0 {
+			return "", exitError(3, "unknown provider profile %q: no provider_profiles in the config", f.providerProfile)
+		}
+		return "", exitError(3, "unknown provider profile %q (defined: %s)", f.providerProfile, strings.Join(names, ", "))
+	}
+	set := func(flag string, dst *string, v string) {
+		if v != "" && !flags.Changed(flag) {
+			*dst = v
+		}
+	}
+	if p.Provider != "" && p.Model == "" && !flags.Changed("model") {
+		// A default model for another provider must not leak in.
+		f.model = ""
+	}
+	set("provider", &f.providerName, p.Provider)
+	set("model", &f.model, p.Model)
+	set("api-base", &f.apiBase, p.APIBase)
+	set("api-key-file", &f.apiKeyFile, p.APIKeyFile)
+	if p.MaxTokens > 0 && !flags.Changed("max-tokens") {
+		f.maxTokens = p.MaxTokens
+	}
+	f.endpoint = p.APIBase
+	keyCmd := p.APIKeyCmd
+	if flags.Changed("api-key-file") {
+		keyCmd = ""
+	}
+	return keyCmd, nil
+}
+
+func newConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect and edit PlanCritic configuration",
+	}
+	cmd.AddCommand(newConfigShowCmd(), newConfigSetCmd())
+	return cmd
+}
+
+// configEntry is one resolved setting in `config show` output.
+type configEntry struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+func newConfigShowCmd() *cobra.Command {
+	f := &checkFlags{}
+	d := loadDefaults()
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "show [check flags]",
+		Short: "Print the effective check configuration and where each value comes from",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if d.err != nil {
+				return exitError(3, "%v", d.err)
+			}
+			entries := resolvedConfig(cmd.Flags(), d)
+			if asJSON {
+				data, err := json.MarshalIndent(entries, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to marshal config: %w", err)
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), string(data))
+				return nil
+			}
+			tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE")
+			for _, e := range entries {
+				fmt.Fprintf(tw, "%s\t%s\t%s\n", e.Key, e.Value, e.Source)
+			}
+			return tw.Flush()
+		},
+	}
+
+	// Register the check flags so that `config show --model x` reports
+	// the value a check invocation with the same flags would use.
+	addCheckFlags(cmd.Flags(), f, d)
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print as JSON")
+	return cmd
+}
+
+// resolvedConfig lists every configurable check flag with its
+// effective value and provenance.
+func resolvedConfig(flags *pflag.FlagSet, d *defaults) []configEntry {
+	var entries []configEntry
+	for key, source := range d.sources {
+		fl := flags.Lookup(key)
+		if fl == nil {
+			continue
+		}
+		if fl.Changed {
+			source = "flag --" + key
+		}
+		entries = append(entries, configEntry{Key: key, Value: fl.Value.String(), Source: source})
+	}
+	// Config keys that don't correspond to a known setting are still
+	// listed so typos are visible rather than silently ignored.
+	for _, key := range d.cfg.Keys() {
+		if _, known := d.sources[key]; known {
+			continue
+		}
+		v, path, _ := d.cfg.Default(key)
+		entries = append(entries, configEntry{Key: key, Value: v, Source: "config " + path + " (unknown key, ignored)"})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries
+}
+
+func newConfigSetCmd() *cobra.Command {
+	var project bool
+
+	cmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a default in the user (or project) config file",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, value := args[0], args[1]
+
+			// Validate the key and value against the check flags so a
+			// bad config can't break every subsequent run.
+			probe := pflag.NewFlagSet("probe", pflag.ContinueOnError)
+			d := &defaults{sources: make(map[string]string)}
+			addCheckFlags(probe, &checkFlags{}, d)
+			if _, known := d.sources[key]; !known {
+				return exitError(3, "unknown config key %q", key)
+			}
+			if err := probe.Set(key, value); err != nil {
+				return exitError(3, "invalid value for %s: %v", key, err)
+			}
+
+			if project && config.UserOnly(key) {
+				return exitError(3, "%s can only be set in the user config", key)
+			}
+
+			path := config.ProjectPath
+			if !project {
+				p, err := config.UserPath()
+				if err != nil {
+					return exitError(3, "%v", err)
+				}
+				path = p
+			}
+			c, err := config.LoadFile(path)
+			if err != nil {
+				return exitError(3, "%v", err)
+			}
+			if c.Defaults == nil {
+				c.Defaults = make(map[string]string)
+			}
+			c.Defaults[key] = value
+			if err := config.Save(path, c); err != nil {
+				return fmt.Errorf("failed to save config: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Set %s = %s in %s\n", key, value, path)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&project, "project", false, "Write to the project config ("+config.ProjectPath+") instead of the user config")
+	return cmd
+}
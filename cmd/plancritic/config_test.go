@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dshills/plancritic/internal/config"
+	"github.com/dshills/plancritic/internal/review"
+)
+
+func TestConfigShowProvenance(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("PLANCRITIC_CONFIG", filepath.Join(dir, "config.yaml"))
+	t.Setenv("PLANCRITIC_MODEL", "gpt-5.2")
+
+	set := newConfigCmd()
+	set.SetArgs([]string{"set", "profile", "go-backend"})
+	set.SetOut(&bytes.Buffer{})
+	if err := set.Execute(); err != nil {
+		t.Fatalf("config set: %v", err)
+	}
+
+	show := newConfigCmd()
+	var out bytes.Buffer
+	show.SetOut(&out)
+	show.SetArgs([]string{"show", "--json", "--temperature", "0.5"})
+	if err := show.Execute(); err != nil {
+		t.Fatalf("config show: %v", err)
+	}
+
+	var entries []configEntry
+	if err := json.Unmarshal(out.Bytes(), &entries); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	got := make(map[string]configEntry)
+	for _, e := range entries {
+		got[e.Key] = e
+	}
+	checks := []struct {
+		key, value, sourcePrefix string
+	}{
+		{"profile", "go-backend", "config "},
+		{"model", "gpt-5.2", "env PLANCRITIC_MODEL"},
+		{"temperature", "0.5", "flag --temperature"},
+		{"format", "json", "default"},
+	}
+	for _, c := range checks {
+		e := got[c.key]
+		if e.Value != c.value || !strings.HasPrefix(e.Source, c.sourcePrefix) {
+			t.Errorf("%s = %q (%s), want %q (%s...)", c.key, e.Value, e.Source, c.value, c.sourcePrefix)
+		}
+	}
+}
+
+func TestConfigSetRejectsUnknownKeyAndBadValue(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PLANCRITIC_CONFIG", filepath.Join(dir, "config.yaml"))
+
+	for _, args := range [][]string{
+		{"set", "bogus", "1"},
+		{"set", "max-tokens", "lots"},
+		{"set", "--project", "provider-profile", "team"},
+		{"set", "--project", "api-base", "http://localhost:8080/v1"},
+		{"set", "--project", "proxy", "http://proxy.corp:3128"},
+		{"set", "--project", "storage-url", "redis://cache.example:6379"},
+	} {
+		cmd := newConfigCmd()
+		cmd.SetArgs(args)
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		assertExitCode(t, cmd.Execute(), 3)
+	}
+}
+
+func TestCheckModelAlias(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("PLANCRITIC_CONFIG", filepath.Join(dir, "config.yaml"))
+	planPath := writeTempPlan(t, "# Plan\n\n1. Ship it\n")
+	scenario := writeTempFile(t, dir, "scenario.yaml", "steps:\n  - response_file: review.json\n")
+	writeTempFile(t, dir, "review.json", validMockResponse())
+	if err := config.Save(config.ProjectPath, &config.Config{Aliases: map[string]string{"fast": "mock:" + scenario}}); err != nil {
+		t.Fatal(err)
+	}
+	outPath := filepath.Join(dir, "out.json")
+
+	cmd := newCheckCmd()
+	cmd.SetArgs([]string{planPath, "--model", "fast", "--out", outPath})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rev review.Review
+	if err := json.Unmarshal(data, &rev); err != nil {
+		t.Fatal(err)
+	}
+	if rev.Meta.Model != "mock/mock:"+scenario {
+		t.Errorf("meta model = %q, want the aliased mock model", rev.Meta.Model)
+	}
+}
+
+func TestCheckProviderProfile(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	userConfig := filepath.Join(dir, "config.yaml")
+	t.Setenv("PLANCRITIC_CONFIG", userConfig)
+	t.Setenv("LOCAL_API_KEY", "")
+
+	var auth string
+	var maxTokens int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		var req struct {
+			MaxTokens int `json:"max_tokens"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		maxTokens = req.MaxTokens
+		content, _ := json.Marshal(validMockResponse())
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":` + string(content) + `},"finish_reason":"stop"}]}`))
+	}))
+	defer srv.Close()
+
+	if err := config.Save(userConfig, &config.Config{
+		Defaults: map[string]string{"model": "gpt-5.2"},
+		ProviderProfiles: map[string]config.ProviderProfile{
+			"staging": {Provider: "local", APIBase: srv.URL, APIKeyCmd: "echo sk-staging", MaxTokens: 1234},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	planPath := writeTempPlan(t, "# Plan\n\n1. Ship it\n")
+
+	cmd := newCheckCmd()
+	cmd.SetArgs([]string{planPath, "--provider-profile", "staging", "--out", filepath.Join(dir, "out.json")})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if auth != "Bearer sk-staging" {
+		t.Errorf("Authorization = %q, want the profile's key command output", auth)
+	}
+	if maxTokens != 1234 {
+		t.Errorf("max_tokens = %d, want the profile's 1234", maxTokens)
+	}
+
+	cmd = newCheckCmd()
+	cmd.SetArgs([]string{planPath, "--provider-profile", "prod"})
+	cmd.SilenceUsage = true
+	err := cmd.Execute()
+	assertExitCode(t, err, 3)
+	if !strings.Contains(err.Error(), "staging") {
+		t.Errorf("error = %v, want it to list the defined profiles", err)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/dshills/plancritic/internal/reviewer"
+)
+
+// planEstimate is one plan's entry in --dry-run JSON output.
+type planEstimate struct {
+	Plan string `json:"plan"`
+	reviewer.Estimate
+}
+
+// runDryRun builds the prompt for each plan and prints its estimated
+// size and cost per configured model to w, without calling the LLM or
+// writing any output file. JSON output is an array with one entry per
+// plan; other formats print a short text report.
+func runDryRun(w io.Writer, planPaths []string, f *checkFlags) error {
+	if f.format != "json" && f.format != "md" && f.format != "html" {
+		return exitError(3, "unknown format: %s", f.format)
+	}
+	opts, err := reviewOptions(f)
+	if err != nil {
+		return err
+	}
+	var ests []planEstimate
+	for _, path := range planPaths {
+		est, err := reviewer.EstimateRun(path, opts)
+		if err != nil {
+			return reviewError(err)
+		}
+		ests = append(ests, planEstimate{Plan: path, Estimate: est})
+	}
+	if f.format == "json" {
+		data, err := json.MarshalIndent(ests, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal estimate: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	}
+	for _, e := range ests {
+		writeEstimate(w, e)
+	}
+	return nil
+}
+
+// writeEstimate prints e as a few lines of text.
+func writeEstimate(w io.Writer, e planEstimate) {
+	parts := ""
+	if e.Parts > 1 {
+		parts = fmt.Sprintf(" in %d parts", e.Parts)
+	}
+	fmt.Fprintf(w, "%s: ~%d prompt tokens (%d chars)%s, up to %d response tokens per request\n",
+		e.Plan, e.PromptTokens, e.PromptChars, parts, e.MaxOutputTokens)
+	for _, m := range e.Models {
+		cost := "cost unknown"
+		if m.CostUSD != nil {
+			cost = fmt.Sprintf("up to $%.4f", *m.CostUSD)
+		}
+		fmt.Fprintf(w, "  %s: ~%d input + %d output tokens, %s\n", m.Model, m.InputTokens, m.OutputTokens, cost)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dshills/plancritic/internal/escalate"
+	"github.com/dshills/plancritic/internal/review"
+	"github.com/spf13/cobra"
+)
+
+type escalateFlags struct {
+	format   string
+	out      string
+	deadline string
+	deciders []string
+}
+
+func newEscalateCmd() *cobra.Command {
+	f := &escalateFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "escalate <review.json>",
+		Short: "Extract findings that need a human decision into an escalation document",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runEscalate(cmd, args[0], f)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&f.format, "format", "md", "Output format: md or json")
+	flags.StringVar(&f.out, "out", "", "Output file path (default: stdout)")
+	flags.StringVar(&f.deadline, "deadline", "", "Decision deadline (YYYY-MM-DD; default: "+escalate.DeadlinePlaceholder+")")
+	flags.StringArrayVar(&f.deciders, "decider", nil, "Override who decides a category, as CATEGORY=role (repeatable)")
+
+	return cmd
+}
+
+func runEscalate(cmd *cobra.Command, path string, f *escalateFlags) error {
+	if f.format != "md" && f.format != "json" {
+		return exitError(3, "unknown format: %s", f.format)
+	}
+	if f.deadline != "" {
+		if _, err := time.Parse("2006-01-02", f.deadline); err != nil {
+			return exitError(3, "invalid --deadline %q: want YYYY-MM-DD", f.deadline)
+		}
+	}
+	deciders := map[review.Category]string{}
+	for _, kv := range f.deciders {
+		cat, role, ok := strings.Cut(kv, "=")
+		cat = strings.ToUpper(strings.TrimSpace(cat))
+		if !ok || strings.TrimSpace(role) == "" || !review.Category(cat).Valid() {
+			return exitError(3, "invalid --decider %q: want CATEGORY=role", kv)
+		}
+		deciders[review.Category(cat)] = strings.TrimSpace(role)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return exitError(3, "failed to read review: %v", err)
+	}
+	var rev review.Review
+	if err := json.Unmarshal(data, &rev); err != nil {
+		return exitError(3, "failed to parse review %s: %v", path, err)
+	}
+
+	doc := escalate.Build(&rev, escalate.Options{Deadline: f.deadline, Deciders: deciders})
+	var output string
+	switch f.format {
+	case "json":
+		b, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal output: %w", err)
+		}
+		output = string(b) + "\n"
+	default:
+		output = escalate.Markdown(doc)
+	}
+
+	if f.out != "" {
+		if err := os.WriteFile(f.out, []byte(output), 0644); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+		return nil
+	}
+	fmt.Fprint(cmd.OutOrStdout(), output)
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dshills/plancritic/internal/escalate"
+)
+
+func TestEscalateJSON(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTempFile(t, dir, "review.json", validMockResponse())
+
+	var out bytes.Buffer
+	cmd := newEscalateCmd()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{path, "--format", "json", "--deadline", "2026-11-01", "--decider", "contradiction=Architect"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	var doc escalate.Document
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Decisions) == 0 {
+		t.Fatal("expected decisions for a NOT_EXECUTABLE review")
+	}
+	last := doc.Decisions[len(doc.Decisions)-1]
+	if last.ID != "ISSUE-0001" || last.Decider != "Architect" || last.Deadline != "2026-11-01" {
+		t.Errorf("issue decision = %+v", last)
+	}
+}
+
+func TestEscalateMarkdown(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTempFile(t, dir, "review.json", validMockResponse())
+
+	var out bytes.Buffer
+	cmd := newEscalateCmd()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{path})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.String(), "# Decisions Needed") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestEscalateInvalidFlags(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTempFile(t, dir, "review.json", validMockResponse())
+	for _, args := range [][]string{
+		{path, "--deadline", "next week"},
+		{path, "--decider", "NOT_A_CATEGORY=someone"},
+		{path, "--format", "html"},
+		{filepath.Join(dir, "missing.json")},
+	} {
+		cmd := newEscalateCmd()
+		cmd.SetArgs(args)
+		assertExitCode(t, cmd.Execute(), 3)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dshills/plancritic/internal/review"
+	"github.com/spf13/cobra"
+)
+
+type extractFlags struct {
+	dir   string
+	force bool
+}
+
+func newExtractCmd() *cobra.Command {
+	f := &extractFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "extract <review.json>",
+		Short: "Write the plan and context files embedded with --embed-inputs back to disk",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runExtract(cmd, args[0], f)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&f.dir, "dir", ".", "Directory to write the files to")
+	flags.BoolVar(&f.force, "force", false, "Overwrite existing files")
+
+	return cmd
+}
+
+func runExtract(cmd *cobra.Command, path string, f *extractFlags) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return exitError(3, "failed to read review: %v", err)
+	}
+	var rev review.Review
+	if err := json.Unmarshal(data, &rev); err != nil {
+		return exitError(3, "failed to parse review %s: %v", path, err)
+	}
+	if len(rev.Embedded) == 0 {
+		return exitError(3, "review %s has no embedded inputs (run check with --embed-inputs)", path)
+	}
+
+	// Decode and check everything before writing anything, so a
+	// damaged artifact leaves no partial output behind.
+	type output struct {
+		entry   review.EmbeddedFile
+		dest    string
+		content string
+	}
+	var outputs []output
+	hashes := map[string]string{}
+entries:
+	for _, e := range rev.Embedded {
+		base, err := extractPath(e.Path)
+		if err != nil {
+			return exitError(3, "%v", err)
+		}
+		content, err := e.Content()
+		if err != nil {
+			return exitError(3, "%v", err)
+		}
+		// A context pinned to several sections is embedded once per
+		// pin; it is written once. Names that only collide once made
+		// safe are told apart as pctx.AssignNames does.
+		rel := base
+		for n := 2; ; n++ {
+			h, ok := hashes[rel]
+			if !ok {
+				break
+			}
+			if h == e.Hash {
+				continue entries
+			}
+			rel = fmt.Sprintf("%s~%d", base, n)
+		}
+		hashes[rel] = e.Hash
+		outputs = append(outputs, output{entry: e, dest: filepath.Join(f.dir, rel), content: content})
+	}
+	if !f.force {
+		for _, o := range outputs {
+			if _, err := os.Stat(o.dest); err == nil {
+				return exitError(3, "%s already exists (use --force to overwrite)", o.dest)
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("failed to check %s: %w", o.dest, err)
+			}
+		}
+	}
+
+	for _, o := range outputs {
+		if err := os.MkdirAll(filepath.Dir(o.dest), 0o755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(o.dest), err)
+		}
+		if err := os.WriteFile(o.dest, []byte(o.content), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", o.dest, err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\t%s\n", o.entry.Role, o.dest, o.entry.Hash)
+	}
+	return nil
+}
+
+// extractPath maps the name check gave an embedded file (a base name, a
+// context's disambiguated relative path such as "api/constraints.md",
+// or a remote plan such as "gh:owner/repo#12") to a relative path under
+// the output directory. Characters that are not portable in file names
+// become "_"; absolute paths and ".." are rejected.
+func extractPath(name string) (string, error) {
+	if strings.HasPrefix(name, "/") || strings.HasPrefix(name, "\\") {
+		return "", fmt.Errorf("invalid embedded file path %q", name)
+	}
+	var parts []string
+	for _, p := range strings.Split(strings.ReplaceAll(name, "\\", "/"), "/") {
+		switch p {
+		case "", ".":
+			continue
+		case "..":
+			return "", fmt.Errorf("invalid embedded file path %q", name)
+		}
+		parts = append(parts, strings.Map(func(r rune) rune {
+			if r < ' ' || strings.ContainsRune(`:#?*"<>|`, r) {
+				return '_'
+			}
+			return r
+		}, p))
+	}
+	if len(parts) == 0 {
+		return "", fmt.Errorf("invalid embedded file path %q", name)
+	}
+	return filepath.Join(parts...), nil
+}
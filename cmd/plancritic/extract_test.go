@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dshills/plancritic/internal/llm"
+	"github.com/dshills/plancritic/internal/review"
+)
+
+func TestEmbedInputsAndExtract(t *testing.T) {
+	dir := t.TempDir()
+	planPath := writeTempPlan(t, "# Plan\n\n1. Set api_key = \"sk-abcdefghijklmnopqrstuvwxyz123456\"\n")
+	ctxPath := writeTempFile(t, dir, "spec.md", "# Spec\n\nMust be fast.\n")
+	reviewPath := filepath.Join(dir, "review.json")
+	f := &checkFlags{
+		format:            "json",
+		out:               reviewPath,
+		contextPaths:      []string{ctxPath},
+		profileName:       "general",
+		redactEnabled:     true,
+		severityThreshold: "info",
+		embedInputs:       true,
+		provider:          &llm.MockProvider{Response: validMockResponse()},
+	}
+	assertExitCode(t, runCheck(context.Background(), planPath, f), 0)
+
+	// The originals can change or vanish; the artifact still has them.
+	if err := os.Remove(planPath); err != nil {
+		t.Fatal(err)
+	}
+	outDir := filepath.Join(dir, "restored")
+	cmd := newExtractCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{reviewPath, "--dir", outDir})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	plan, err := os.ReadFile(filepath.Join(outDir, "plan.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(plan, []byte("sk-abcdefghijklmnopqrstuvwxyz123456")) {
+		t.Error("embedded plan should be redacted")
+	}
+	spec, err := os.ReadFile(filepath.Join(outDir, "spec.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(spec) != "# Spec\n\nMust be fast.\n" {
+		t.Errorf("spec = %q", spec)
+	}
+
+	// A second extract refuses to overwrite without --force.
+	cmd = newExtractCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{reviewPath, "--dir", outDir})
+	assertExitCode(t, cmd.Execute(), 3)
+	cmd = newExtractCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{reviewPath, "--dir", outDir, "--force"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtractRejectsBadArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	good, err := review.EmbedFile(review.EmbedRolePlan, "plan.md", "# Plan\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	escape := good
+	escape.Path = "../plan.md"
+	nested := good
+	nested.Path = "docs/../../plan.md"
+	absolute := good
+	absolute.Path = "/tmp/plan.md"
+	tampered := good
+	tampered.Hash = "sha256:0000"
+
+	for name, embedded := range map[string][]review.EmbeddedFile{
+		"none":     nil,
+		"escape":   {escape},
+		"nested":   {nested},
+		"absolute": {absolute},
+		"tampered": {tampered},
+	} {
+		data, err := json.Marshal(review.Review{Embedded: embedded})
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := writeTempFile(t, dir, name+".json", string(data))
+		cmd := newExtractCmd()
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetArgs([]string{path, "--dir", filepath.Join(dir, name)})
+		assertExitCode(t, cmd.Execute(), 3)
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			t.Errorf("%s: extract created output for a bad artifact", name)
+		}
+	}
+}
+
+func TestExtractMapsCheckNames(t *testing.T) {
+	dir := t.TempDir()
+	embed := func(role, path, content string) review.EmbeddedFile {
+		t.Helper()
+		e, err := review.EmbedFile(role, path, content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e
+	}
+	spec := embed(review.EmbedRoleContext, "spec.md", "# Spec\n")
+	data, err := json.Marshal(review.Review{Embedded: []review.EmbeddedFile{
+		embed(review.EmbedRolePlan, "gh:owner/repo#12", "# Plan\n"),
+		embed(review.EmbedRoleContext, "api/constraints.md", "api\n"),
+		embed(review.EmbedRoleContext, "web/constraints.md", "web\n"),
+		// One file pinned to two sections is embedded twice.
+		spec,
+		spec,
+		embed(review.EmbedRoleContext, "notes:v2.md", "colon\n"),
+		embed(review.EmbedRoleContext, "notes_v2.md", "underscore\n"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := writeTempFile(t, dir, "review.json", string(data))
+	outDir := filepath.Join(dir, "out")
+
+	// An existing file stops the extract before anything is written.
+	if err := os.MkdirAll(filepath.Join(outDir, "web"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeTempFile(t, filepath.Join(outDir, "web"), "constraints.md", "mine\n")
+	cmd := newExtractCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{path, "--dir", outDir})
+	assertExitCode(t, cmd.Execute(), 3)
+	if _, err := os.Stat(filepath.Join(outDir, "api")); err == nil {
+		t.Error("extract wrote files before finding the conflict")
+	}
+
+	cmd = newExtractCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{path, "--dir", outDir, "--force"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"gh_owner/repo_12":   "# Plan\n",
+		"api/constraints.md": "api\n",
+		"web/constraints.md": "web\n",
+		"spec.md":            "# Spec\n",
+		"notes_v2.md":        "colon\n",
+		"notes_v2.md~2":      "underscore\n",
+	} {
+		got, err := os.ReadFile(filepath.Join(outDir, filepath.FromSlash(name)))
+		if err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "spec.md~2")); err == nil {
+		t.Error("a context embedded twice was written twice")
+	}
+}
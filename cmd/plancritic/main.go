@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
 )
@@ -19,9 +22,16 @@ func main() {
 		SilenceUsage:  true,
 	}
 
-	root.AddCommand(newCheckCmd())
+	root.AddCommand(newCheckCmd(), newConfigCmd(), newSignoffCmd(), newPublishCmd(), newSuppressCmd(), newEscalateCmd(), newExtractCmd(), newProvidersCmd(), newAuthCmd(), newPlanCmd(), newNewCmd(), newProfilesCmd())
 
-	if err := root.Execute(); err != nil {
+	// The first SIGINT or SIGTERM cancels the command's context, so a
+	// review whose response has arrived is still finished and written
+	// (see reviewer.Run); a second one exits at once.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	context.AfterFunc(ctx, stop)
+	err := root.ExecuteContext(ctx)
+	stop()
+	if err != nil {
 		var ee *exitErr
 		if errors.As(err, &ee) {
 			fmt.Fprintln(os.Stderr, ee.msg)
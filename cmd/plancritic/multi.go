@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dshills/plancritic/internal/fetch"
+	"github.com/dshills/plancritic/internal/reviewer"
+)
+
+// expandPlanArgs expands glob patterns among the plan arguments, for
+// patterns quoted so the shell leaves them alone (e.g. "plans/*.md"),
+// and drops repeated paths. URLs and arguments without glob characters
+// are kept as given so a missing plan is reported when it is loaded.
+func expandPlanArgs(args []string) ([]string, error) {
+	var paths []string
+	seen := map[string]bool{}
+	add := func(p string) {
+		if !seen[filepath.Clean(p)] {
+			seen[filepath.Clean(p)] = true
+			paths = append(paths, p)
+		}
+	}
+	for _, arg := range args {
+		if fetch.IsRemote(arg) || !strings.ContainsAny(arg, "*?[") {
+			add(arg)
+			continue
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, exitError(3, "invalid plan pattern %q: %v", arg, err)
+		}
+		if len(matches) == 0 {
+			return nil, exitError(3, "no plans match %q", arg)
+		}
+		for _, m := range matches {
+			if info, err := os.Stat(m); err == nil && info.IsDir() {
+				continue
+			}
+			add(m)
+		}
+	}
+	if len(paths) == 0 {
+		return nil, exitError(3, "no plan files to check")
+	}
+	return paths, nil
+}
+
+// runCheckMany reviews several plans, --concurrency at a time, writing each review to
+// <plan>.review.<format> in the --out directory (default: the working
+// directory), as --batch-collect does. Every plan is reviewed even
+// after one fails. The exit code is the first failed plan's, else 2
+// when any verdict meets --fail-on.
+func runCheckMany(ctx context.Context, planPaths []string, f *checkFlags) error {
+	if f.patchOut != "" {
+		return exitError(3, "--patch-out takes a single plan")
+	}
+	seen := map[string]string{}
+	for _, path := range planPaths {
+		name := batchOutputName(path, f.format)
+		if other, dup := seen[name]; dup {
+			return exitError(3, "%s and %s would both be written to %s; check them separately", other, path, name)
+		}
+		seen[name] = path
+	}
+	dir := f.out
+	if dir == "" {
+		dir = "."
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	start := time.Now()
+	workers := reviewer.Concurrency(reviewer.Options{
+		Concurrency:  f.concurrency,
+		ProviderName: f.providerName,
+		Model:        f.model,
+		Ensemble:     f.ensemble,
+		Aliases:      f.aliases,
+	})
+	// One limiter bounds the provider requests of every plan, so
+	// concurrent plans with ensembles stay within --concurrency.
+	lim := reviewer.NewLimiter(f.concurrency)
+	errs := make([]error, len(planPaths))
+	started := make([]bool, len(planPaths))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, path := range planPaths {
+		sem <- struct{}{}
+		if ctx.Err() != nil {
+			// Interrupted: the plans under review are finished, but no
+			// new one is started.
+			<-sem
+			break
+		}
+		started[i] = true
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			pf := *f
+			pf.out = filepath.Join(dir, batchOutputName(path, f.format))
+			pf.limiter = lim
+			errs[i] = runCheck(ctx, path, &pf)
+		}()
+	}
+	wg.Wait()
+
+	var firstErr, failOnErr error
+	failed, failing, rest := 0, 0, 0
+	for i, path := range planPaths {
+		err := errs[i]
+		var ee *exitErr
+		switch {
+		case !started[i]:
+			rest++
+		case err == nil:
+		case errors.As(err, &ee) && ee.code == 2:
+			failing++
+			if failOnErr == nil {
+				failOnErr = err
+			}
+		default:
+			failed++
+			fmt.Fprintf(os.Stderr, "plancritic: %s: %v\n", path, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	if rest > 0 {
+		failed += rest
+		if firstErr == nil {
+			firstErr = exitError(130, "interrupted; %d plans not reviewed", rest)
+		}
+		fmt.Fprintf(os.Stderr, "plancritic: interrupted; %d plans not reviewed\n", rest)
+	}
+
+	err := firstErr
+	if err == nil {
+		err = failOnErr
+	}
+	code := 0
+	if err != nil {
+		code = 1
+		var ee *exitErr
+		if errors.As(err, &ee) {
+			code = ee.code
+		}
+	}
+	fmt.Fprintf(os.Stderr, "plancritic: plans=%d passed=%d fail_on=%d errors=%d duration=%s out=%s exit=%d\n",
+		len(planPaths), len(planPaths)-failed-failing, failing, failed, time.Since(start).Round(time.Millisecond), dir, code)
+	return err
+}
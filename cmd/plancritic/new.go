@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dshills/plancritic/internal/plan"
+	"github.com/dshills/plancritic/internal/profile"
+	"github.com/spf13/cobra"
+)
+
+type newFlags struct {
+	profileName string
+	title       string
+	force       bool
+}
+
+func newNewCmd() *cobra.Command {
+	f := &newFlags{}
+	d := loadDefaults()
+
+	cmd := &cobra.Command{
+		Use:   "new [plan.md]",
+		Short: "Scaffold a plan skeleton tailored to a profile",
+		Long: "Write a plan skeleton with goals, out-of-scope, steps with estimates and acceptance criteria, a test plan,\n" +
+			"rollback, and risks sections. The profile's checklists become prompts under the sections that answer them,\n" +
+			"so a filled-in plan covers what the review checks. Placeholders are TODO markers.\n\n" +
+			"Without a path the skeleton is printed; an existing file is not overwritten without --force.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if d.err != nil {
+				return exitError(3, "%v", d.err)
+			}
+			path := ""
+			if len(args) == 1 {
+				path = args[0]
+			}
+			return runNew(cmd, path, f)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&f.profileName, "profile", d.str("profile", "PLANCRITIC_PROFILE", "general"), "Profile the plan will be reviewed with")
+	flags.StringVar(&f.title, "title", "", "Plan title (default: from the file name)")
+	flags.BoolVar(&f.force, "force", false, "Overwrite an existing file")
+
+	return cmd
+}
+
+func runNew(cmd *cobra.Command, path string, f *newFlags) error {
+	prof, err := profile.Load(f.profileName)
+	if err != nil {
+		return exitError(3, "failed to load profile: %v", err)
+	}
+	title := f.title
+	if title == "" {
+		title = titleFromPath(path)
+	}
+	text := plan.Scaffold(title, prof)
+
+	if path == "" {
+		_, err := fmt.Fprint(cmd.OutOrStdout(), text)
+		return err
+	}
+	if !f.force {
+		if _, err := os.Stat(path); err == nil {
+			return exitError(3, "%s already exists (use --force to overwrite)", path)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return exitError(3, "%v", err)
+		}
+	}
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "Wrote %s (profile %s)\n", path, prof.Name)
+	return nil
+}
+
+// titleFromPath turns a plan file name such as billing-migration.md
+// into a title, "Billing migration".
+func titleFromPath(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	name = strings.Join(strings.Fields(strings.NewReplacer("-", " ", "_", " ").Replace(name)), " ")
+	if path == "" || name == "" {
+		return "Implementation Plan"
+	}
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[size:]
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/dshills/plancritic/internal/fetch"
+	"github.com/dshills/plancritic/internal/plan"
+	"github.com/dshills/plancritic/internal/profile"
+	"github.com/spf13/cobra"
+)
+
+type planMetricsFlags struct {
+	profileName string
+	asJSON      bool
+}
+
+type planNormalizeFlags struct {
+	out   string
+	write bool
+}
+
+func newPlanCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Work with plan files",
+	}
+	cmd.AddCommand(newPlanNormalizeCmd(), newPlanMetricsCmd())
+	return cmd
+}
+
+func newPlanNormalizeCmd() *cobra.Command {
+	f := &planNormalizeFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "normalize <plan>",
+		Short: "Rewrite a plan in canonical form: numbered steps, consistent heading levels, and acceptance criteria sections",
+		Long: "Print the plan in a canonical form that the model cites more accurately and that diffs cleanly between revisions:\n" +
+			"heading levels start at 1 without gaps, ordered lists count up from their first number with \"N.\" markers\n" +
+			"(and \"step N\" references follow renumbered steps), bullets use \"-\", inline acceptance criteria\n" +
+			"(\"Done when: a; b\") become an \"**Acceptance criteria:**\" list, and blank lines and trailing space are tidied.\n\n" +
+			"Front matter and fenced code are left alone. A review stamp is dropped, since the normalized plan has not been reviewed.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPlanNormalize(cmd, args[0], f)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&f.out, "out", "", "Output file path (default: stdout)")
+	flags.BoolVarP(&f.write, "write", "w", false, "Rewrite the plan file in place")
+
+	return cmd
+}
+
+func runPlanNormalize(cmd *cobra.Command, path string, f *planNormalizeFlags) error {
+	if f.write && f.out != "" {
+		return exitError(3, "--write and --out are mutually exclusive")
+	}
+	if f.write && fetch.IsRemote(path) {
+		return exitError(3, "--write needs a local plan file, not %s", path)
+	}
+	p, err := plan.Load(path)
+	if err != nil {
+		return exitError(3, "failed to load plan: %v", err)
+	}
+	out := plan.Normalize(p)
+
+	dest := f.out
+	if f.write {
+		dest = path
+	}
+	if dest == "" {
+		_, err := fmt.Fprint(cmd.OutOrStdout(), out)
+		return err
+	}
+	if err := os.WriteFile(dest, []byte(out), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	return nil
+}
+
+func newPlanMetricsCmd() *cobra.Command {
+	f := &planMetricsFlags{}
+	d := loadDefaults()
+
+	cmd := &cobra.Command{
+		Use:   "metrics <plan>",
+		Short: "Print the plan's quality metrics, computed locally without a model",
+		Long: "Print the deterministic metrics a review reports under summary.plan_metrics: size, average step length,\n" +
+			"estimate and acceptance-criteria coverage, steps without acceptance criteria, TODO/TBD markers, and the\n" +
+			"density of the profile's vague phrases. No model is called, so no provider needs to be configured.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if d.err != nil {
+				return exitError(3, "%v", d.err)
+			}
+			return runPlanMetrics(cmd, args[0], f)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&f.profileName, "profile", d.str("profile", "PLANCRITIC_PROFILE", "general"), "Profile whose ambiguity triggers count as vague phrases")
+	flags.BoolVar(&f.asJSON, "json", false, "Print as JSON")
+
+	return cmd
+}
+
+func runPlanMetrics(cmd *cobra.Command, path string, f *planMetricsFlags) error {
+	p, err := plan.Load(path)
+	if err != nil {
+		return exitError(3, "failed to load plan: %v", err)
+	}
+	prof, err := profile.Load(f.profileName)
+	if err != nil {
+		return exitError(3, "failed to load profile: %v", err)
+	}
+	m := plan.ComputeMetrics(p, plan.InferStepIDs(p), nil)
+	plan.MeasureVagueness(&m, p, profile.AmbiguityTriggers(prof, plan.DetectLanguage(p.Raw)))
+
+	w := cmd.OutOrStdout()
+	if f.asJSON {
+		data, err := json.MarshalIndent(m, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal metrics: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "lines\t%d\n", m.LineCount)
+	fmt.Fprintf(tw, "steps\t%d\n", m.StepCount)
+	fmt.Fprintf(tw, "avg step words\t%.1f\n", m.AvgStepWords)
+	fmt.Fprintf(tw, "estimate coverage\t%.0f%%\n", m.EstimateCoverage)
+	fmt.Fprintf(tw, "acceptance coverage\t%.0f%%\n", m.AcceptanceCoverage)
+	fmt.Fprintf(tw, "steps without acceptance criteria\t%d\n", m.StepsWithoutAcceptance)
+	fmt.Fprintf(tw, "TODO/TBD markers\t%d\n", m.Placeholders)
+	fmt.Fprintf(tw, "vague phrases\t%d (%.1f per 100 words)\n", m.VaguePhrases, m.VagueDensity)
+	return tw.Flush()
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dshills/plancritic/internal/review"
+)
+
+func TestPlanNormalize(t *testing.T) {
+	planPath := writeTempPlan(t, "## Steps\n1) Build\n1) Ship  \n")
+	const want = "# Steps\n\n1. Build\n2. Ship\n"
+
+	cmd := newPlanCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"normalize", planPath})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != want {
+		t.Errorf("stdout = %q, want %q", out.String(), want)
+	}
+
+	dest := filepath.Join(t.TempDir(), "normalized.md")
+	cmd = newPlanCmd()
+	cmd.SetArgs([]string{"normalize", planPath, "--out", dest})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(dest); string(data) != want {
+		t.Errorf("--out wrote %q", data)
+	}
+
+	cmd = newPlanCmd()
+	cmd.SetArgs([]string{"normalize", planPath, "-w", "--out", dest})
+	assertExitCode(t, cmd.Execute(), 3)
+
+	cmd = newPlanCmd()
+	cmd.SetArgs([]string{"normalize", planPath, "-w"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(planPath); string(data) != want {
+		t.Errorf("--write left %q", data)
+	}
+}
+
+func TestPlanMetrics(t *testing.T) {
+	planPath := writeTempPlan(t, "# Plan\n\n1. Make it fast\n2. Deploy (TBD)\n   Done when: green.\n")
+	cmd := newPlanCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"metrics", planPath, "--json", "--profile", "general"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	var m review.PlanMetrics
+	if err := json.Unmarshal(out.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.StepCount != 3 || m.StepsWithoutAcceptance != 2 || m.Placeholders != 1 || m.VaguePhrases != 1 {
+		t.Errorf("metrics = %+v", m)
+	}
+
+	cmd = newPlanCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{"metrics", planPath, "--profile", "nope"})
+	assertExitCode(t, cmd.Execute(), 3)
+}
+
+func TestNew(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "billing-migration.md")
+	cmd := newNewCmd()
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{dest, "--profile", "go-backend"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# Billing migration\n", "--profile go-backend", "## Test Plan\n"} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Errorf("scaffold lacks %q:\n%s", want, data)
+		}
+	}
+
+	cmd = newNewCmd()
+	cmd.SetArgs([]string{dest})
+	assertExitCode(t, cmd.Execute(), 3)
+
+	cmd = newNewCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--title", "Search revamp", "--profile", "general"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(out.Bytes(), []byte("# Search revamp\n")) {
+		t.Errorf("stdout = %s", out.String())
+	}
+
+	cmd = newNewCmd()
+	cmd.SetArgs([]string{"--profile", "no-such-profile"})
+	assertExitCode(t, cmd.Execute(), 3)
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"text/tabwriter"
+
+	"github.com/dshills/plancritic/internal/profile"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// profileNamePattern matches a name profiles init accepts: one that
+// --profile resolves in a profile directory.
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+type profilesListFlags struct {
+	asJSON bool
+}
+
+type profilesShowFlags struct {
+	rendered bool
+	language string
+}
+
+type profilesValidateFlags struct {
+	asJSON bool
+}
+
+type profilesInitFlags struct {
+	project bool
+	force   bool
+}
+
+func newProfilesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profiles",
+		Short: "Inspect the review profiles",
+	}
+	cmd.AddCommand(newProfilesListCmd(), newProfilesShowCmd(), newProfilesValidateCmd(), newProfilesInitCmd())
+	return cmd
+}
+
+func newProfilesListCmd() *cobra.Command {
+	f := &profilesListFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the available profiles with their version, source, and description",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runProfilesList(cmd, f)
+		},
+	}
+
+	cmd.Flags().BoolVar(&f.asJSON, "json", false, "Print as JSON")
+
+	return cmd
+}
+
+func runProfilesList(cmd *cobra.Command, f *profilesListFlags) error {
+	infos, err := profile.List()
+	if err != nil {
+		return exitError(3, "failed to list profiles: %v", err)
+	}
+	w := cmd.OutOrStdout()
+	if f.asJSON {
+		data, err := json.MarshalIndent(infos, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal profiles: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tVERSION\tSOURCE\tDESCRIPTION")
+	for _, info := range infos {
+		if info.Error != "" {
+			fmt.Fprintf(tw, "%s\t-\t%s\terror: %s\n", info.Name, info.Source, info.Error)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", info.Name, info.Version, info.Source, info.Description)
+	}
+	return tw.Flush()
+}
+
+func newProfilesShowCmd() *cobra.Command {
+	f := &profilesShowFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "show <profile>",
+		Short: "Print a profile as loaded, or with --rendered as the model is given it",
+		Long: "Print the profile as plancritic loads it, in YAML; a strict variant is shown with everything it adds to its base.\n" +
+			"With --rendered, print instead the exact profile section of the review prompt, for a plan in --language.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runProfilesShow(cmd, args[0], f)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVar(&f.rendered, "rendered", false, "Print the profile's section of the review prompt")
+	flags.StringVar(&f.language, "language", "", "With --rendered, the plan language (ISO 639-1) whose localized vague phrases are included")
+
+	return cmd
+}
+
+func runProfilesShow(cmd *cobra.Command, name string, f *profilesShowFlags) error {
+	prof, err := profile.Load(name)
+	if err != nil {
+		return exitError(3, "failed to load profile: %v", err)
+	}
+	w := cmd.OutOrStdout()
+	if f.rendered {
+		_, err := fmt.Fprint(w, profile.FormatForPromptLanguage(prof, f.language))
+		return err
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(prof); err != nil {
+		return fmt.Errorf("failed to marshal profile: %w", err)
+	}
+	return enc.Close()
+}
+
+func newProfilesValidateCmd() *cobra.Command {
+	f := &profilesValidateFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "validate <file>",
+		Short: "Check a profile file and report each problem at its line",
+		Long: "Check a profile YAML file: its syntax and keys, checklist IDs and checks, constraint types,\n" +
+			"contradiction pairs and their severities, evidence rules, and the profile it extends.\n" +
+			"Every problem is printed as file:line:column: message; any problem exits 3.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runProfilesValidate(cmd, args[0], f)
+		},
+	}
+
+	cmd.Flags().BoolVar(&f.asJSON, "json", false, "Print the problems as JSON")
+
+	return cmd
+}
+
+func runProfilesValidate(cmd *cobra.Command, path string, f *profilesValidateFlags) error {
+	problems, err := profile.LintFile(path)
+	if err != nil {
+		return exitError(3, "failed to read profile: %v", err)
+	}
+	w := cmd.OutOrStdout()
+	if f.asJSON {
+		if problems == nil {
+			problems = []profile.Problem{}
+		}
+		data, err := json.MarshalIndent(problems, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal problems: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+	} else {
+		for _, p := range problems {
+			fmt.Fprintf(w, "%s:%s\n", path, p)
+		}
+	}
+	switch len(problems) {
+	case 0:
+		if !f.asJSON {
+			fmt.Fprintf(w, "%s: ok\n", path)
+		}
+		return nil
+	case 1:
+		return exitError(3, "%s: 1 problem", path)
+	}
+	return exitError(3, "%s: %d problems", path, len(problems))
+}
+
+func newProfilesInitCmd() *cobra.Command {
+	f := &profilesInitFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "init <name>",
+		Short: "Write a commented starter profile to the user profile directory",
+		Long: "Write NAME.yaml, a commented starter profile with example constraints, a checklist, and heuristics, to the\n" +
+			"user profile directory (or with --project to " + profile.ProjectDir + "), so --profile NAME uses it.\n" +
+			"The starter extends the general profile. An existing file is not overwritten without --force.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runProfilesInit(cmd, args[0], f)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVar(&f.project, "project", false, "Write to the project profile directory, "+profile.ProjectDir)
+	flags.BoolVar(&f.force, "force", false, "Overwrite an existing profile file")
+
+	return cmd
+}
+
+func runProfilesInit(cmd *cobra.Command, name string, f *profilesInitFlags) error {
+	if !profileNamePattern.MatchString(name) {
+		return exitError(3, "invalid profile name %q: use letters, digits, '-', and '_'", name)
+	}
+	dir := profile.ProjectDir
+	if !f.project {
+		var err error
+		if dir, err = profile.UserDir(); err != nil {
+			return exitError(3, "failed to find the user profile directory: %v", err)
+		}
+	}
+	path := filepath.Join(dir, name+".yaml")
+	if !f.force {
+		for _, ext := range []string{".yaml", ".yml"} {
+			existing := filepath.Join(dir, name+ext)
+			if _, err := os.Stat(existing); err == nil {
+				return exitError(3, "%s already exists (use --force to overwrite)", existing)
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return exitError(3, "%v", err)
+			}
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	if err := os.WriteFile(path, []byte(profile.Starter(name)), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Wrote %s\nEdit it, then review with --profile %s\n", path, name)
+	return nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dshills/plancritic/internal/profile"
+	"gopkg.in/yaml.v3"
+)
+
+func TestProfilesList(t *testing.T) {
+	cmd := newProfilesCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"list"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(out.String(), "\n")
+	if !strings.HasPrefix(lines[0], "NAME") || !strings.Contains(out.String(), "go-backend-strict") {
+		t.Errorf("list =\n%s", out.String())
+	}
+
+	cmd = newProfilesCmd()
+	out.Reset()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"list", "--json"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	var infos []profile.Info
+	if err := json.Unmarshal(out.Bytes(), &infos); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if len(infos) == 0 || infos[0].Source != profile.SourceBuiltin || infos[0].Version == 0 {
+		t.Errorf("infos = %+v", infos)
+	}
+}
+
+func TestProfilesShow(t *testing.T) {
+	cmd := newProfilesCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"show", "go-backend-strict"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	var p profile.Profile
+	if err := yaml.Unmarshal(out.Bytes(), &p); err != nil {
+		t.Fatalf("invalid YAML: %v\n%s", err, out.String())
+	}
+	want, err := profile.LoadBuiltin("go-backend-strict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "go-backend-strict" || len(p.Checklists) != len(want.Checklists) {
+		t.Errorf("show printed %+v", p)
+	}
+
+	cmd = newProfilesCmd()
+	out.Reset()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"show", "go-backend-strict", "--rendered"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != profile.FormatForPrompt(want) {
+		t.Errorf("--rendered =\n%s", out.String())
+	}
+
+	cmd = newProfilesCmd()
+	cmd.SetArgs([]string{"show", "no-such-profile"})
+	assertExitCode(t, cmd.Execute(), 3)
+}
+
+func TestProfilesValidate(t *testing.T) {
+	dir := t.TempDir()
+	good := writeTempFile(t, dir, "good.yaml", "extends: go-backend\nchecklists:\n  - id: TEAM\n    checks: [\"Is the team named?\"]\n")
+	bad := writeTempFile(t, dir, "bad.yaml", "checklists:\n  - id: TEAM\n    checks: [\"\"]\n  - id: TEAM\n    checks: [\"Again?\"]\n")
+	orphan := writeTempFile(t, dir, "orphan.yaml", "extends: no-such-profile\n")
+
+	cmd := newProfilesCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"validate", good})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("validate good: %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "ok") {
+		t.Errorf("validate good =\n%s", out.String())
+	}
+
+	cmd = newProfilesCmd()
+	out.Reset()
+	cmd.SetOut(&out)
+	cmd.SilenceUsage = true
+	cmd.SetArgs([]string{"validate", bad})
+	assertExitCode(t, cmd.Execute(), 3)
+	for _, want := range []string{bad + ":3:14: checklist TEAM: checks[0] is empty", bad + ":4:9: checklists[1]: duplicate id"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("validate bad missing %q:\n%s", want, out.String())
+		}
+	}
+
+	cmd = newProfilesCmd()
+	out.Reset()
+	cmd.SetOut(&out)
+	cmd.SilenceUsage = true
+	cmd.SetArgs([]string{"validate", "--json", orphan})
+	assertExitCode(t, cmd.Execute(), 3)
+	var problems []profile.Problem
+	if err := json.Unmarshal(out.Bytes(), &problems); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if len(problems) != 1 || problems[0].Line != 1 {
+		t.Errorf("problems = %+v", problems)
+	}
+}
+
+func TestProfilesInit(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	run := func(args ...string) (string, error) {
+		cmd := newProfilesCmd()
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SilenceUsage = true
+		cmd.SetArgs(append([]string{"init"}, args...))
+		err := cmd.Execute()
+		return out.String(), err
+	}
+	if out, err := run("my-team"); err != nil {
+		t.Fatalf("init: %v\n%s", err, out)
+	}
+	p, err := profile.Load("my-team")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	infos, err := profile.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, info := range infos {
+		found = found || (info.Name == p.Name && info.Source == profile.SourceUser)
+	}
+	if !found {
+		t.Errorf("my-team not listed as a user profile: %+v", infos)
+	}
+
+	_, err = run("my-team")
+	assertExitCode(t, err, 3)
+	if _, err := run("my-team", "--force"); err != nil {
+		t.Errorf("init --force: %v", err)
+	}
+	if _, err := run("my-team", "--project"); err != nil {
+		t.Errorf("init --project: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(profile.ProjectDir, "my-team.yaml")); err != nil {
+		t.Error(err)
+	}
+	_, err = run("../escape")
+	assertExitCode(t, err, 3)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dshills/plancritic/internal/llm"
+	"github.com/spf13/cobra"
+)
+
+type providersTestFlags struct {
+	providerName string
+	model        string
+	apiBase      string
+	proxy        string
+	caCert       string
+	apiKeyFile   string
+	keys         llm.KeySources
+	aliases      map[string]string
+	timeout      string
+	asJSON       bool
+}
+
+func newProvidersCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "providers",
+		Short: "Inspect the configured LLM providers",
+	}
+	cmd.AddCommand(newProvidersTestCmd())
+	return cmd
+}
+
+func newProvidersTestCmd() *cobra.Command {
+	f := &providersTestFlags{}
+	d := loadDefaults()
+
+	cmd := &cobra.Command{
+		Use:   "test",
+		Short: "Send a tiny request to each configured provider and report reachability, model availability, and latency",
+		Long: "Send a few-token request to each provider with an API key in the environment (and to the local server when --api-base is set)\n" +
+			"and report whether it is reachable, accepts the credentials, and serves the model, with the round-trip latency.\n\n" +
+			"With --provider or --model only that provider is tested. Exits 4 if any provider fails.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if d.err != nil {
+				return exitError(3, "%v", d.err)
+			}
+			f.aliases = d.cfg.Aliases()
+			f.keys = d.apiKeys(f.apiKeyFile)
+			return runProvidersTest(cmd, f)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&f.providerName, "provider", d.str("provider", "PLANCRITIC_PROVIDER", ""), "Test only this provider: anthropic, openai, gemini, or local")
+	flags.StringVar(&f.model, "model", d.str("model", "PLANCRITIC_MODEL", ""), "Test only the provider serving this model, with this model")
+	flags.StringVar(&f.apiBase, "api-base", d.str("api-base", "PLANCRITIC_API_BASE", ""), "Server URL for the local provider")
+	flags.StringVar(&f.proxy, "proxy", d.str("proxy", "PLANCRITIC_PROXY", ""), "Proxy URL for provider requests")
+	flags.StringVar(&f.caCert, "ca-cert", d.str("ca-cert", "PLANCRITIC_CA_CERT", ""), "PEM CA bundle to trust for provider TLS")
+	flags.StringVar(&f.apiKeyFile, "api-key-file", envStr("PLANCRITIC_API_KEY_FILE", ""), "Read the API key from this file (needs --provider or --model)")
+	flags.StringVar(&f.timeout, "timeout", "30s", "Timeout for each request")
+	flags.BoolVar(&f.asJSON, "json", false, "Print as JSON")
+
+	return cmd
+}
+
+// providerTestResult is one row of `providers test` output.
+type providerTestResult struct {
+	Provider  string `json:"provider"`
+	Model     string `json:"model,omitempty"`
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+func runProvidersTest(cmd *cobra.Command, f *providersTestFlags) error {
+	timeout, err := time.ParseDuration(f.timeout)
+	if err != nil || timeout <= 0 {
+		return exitError(3, "invalid --timeout %q: want a positive duration such as 30s", f.timeout)
+	}
+	transport, err := llm.NewTransport(llm.TransportOptions{Proxy: f.proxy, CACertFile: f.caCert})
+	if err != nil {
+		return exitError(3, "invalid network settings: %v", err)
+	}
+
+	names := []string{f.providerName}
+	if f.providerName == "" && f.model == "" {
+		if f.apiKeyFile != "" {
+			return exitError(3, "--api-key-file needs --provider or --model to say which provider it is for")
+		}
+		names = llm.ConfiguredProviders(f.apiBase, f.keys)
+		if len(names) == 0 {
+			return exitError(3, "no providers configured: set ANTHROPIC_API_KEY, OPENAI_API_KEY, or GEMINI_API_KEY, or pass --api-base for a local server")
+		}
+	}
+
+	var results []providerTestResult
+	failed := false
+	for _, name := range names {
+		p, err := llm.ResolveProviderWith(name, f.model, llm.ProviderOptions{APIBase: f.apiBase, Transport: transport, Keys: f.keys, Aliases: f.aliases})
+		if err != nil {
+			results = append(results, providerTestResult{Provider: name, Status: "not_configured", Error: err.Error()})
+			failed = true
+			continue
+		}
+		r := llm.Probe(cmd.Context(), p, timeout)
+		res := providerTestResult{
+			Provider:  r.Provider,
+			Model:     r.Model,
+			Status:    string(r.Status),
+			LatencyMS: r.Latency.Milliseconds(),
+		}
+		if r.Err != nil {
+			res.Error = r.Err.Error()
+			failed = true
+		}
+		results = append(results, res)
+	}
+
+	if f.asJSON {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal results: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+	} else {
+		tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "PROVIDER\tMODEL\tSTATUS\tLATENCY\tERROR")
+		for _, r := range results {
+			model := r.Model
+			if model == "" {
+				model = "(default)"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%dms\t%s\n", r.Provider, model, r.Status, r.LatencyMS, r.Error)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	if failed {
+		return exitError(4, "one or more providers failed the test")
+	}
+	return nil
+}
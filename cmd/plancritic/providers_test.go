@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/dshills/plancritic/internal/config"
+)
+
+func TestProvidersTest(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("PLANCRITIC_CONFIG", filepath.Join(dir, "config.yaml"))
+
+	cmd := newProvidersCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"test", "--provider", "mock", "--json"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("providers test: %v", err)
+	}
+	var results []providerTestResult
+	if err := json.Unmarshal(out.Bytes(), &results); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if len(results) != 1 || results[0].Provider != "mock" || results[0].Status != "ok" {
+		t.Errorf("results = %+v", results)
+	}
+}
+
+func TestProvidersTestFailures(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("PLANCRITIC_CONFIG", filepath.Join(dir, "config.yaml"))
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("GEMINI_API_KEY", "")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":"bad key"}`))
+	}))
+	defer srv.Close()
+
+	cmd := newProvidersCmd()
+	cmd.SilenceUsage = true
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"test", "--api-base", srv.URL, "--json"})
+	assertExitCode(t, cmd.Execute(), 4)
+
+	var results []providerTestResult
+	if err := json.Unmarshal(out.Bytes(), &results); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if len(results) != 1 || results[0].Provider != "local" || results[0].Status != "auth_failed" || results[0].Error == "" {
+		t.Errorf("results = %+v", results)
+	}
+
+	none := newProvidersCmd()
+	none.SetOut(&bytes.Buffer{})
+	none.SetArgs([]string{"test"})
+	assertExitCode(t, none.Execute(), 3)
+}
+
+func TestProvidersTestAPIKeyCmd(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	userConfig := filepath.Join(dir, "config.yaml")
+	t.Setenv("PLANCRITIC_CONFIG", userConfig)
+	t.Setenv("LOCAL_API_KEY", "")
+	if err := config.Save(userConfig, &config.Config{APIKeyCmds: map[string]string{"local": "echo sk-from-cmd"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"OK"},"finish_reason":"stop"}]}`))
+	}))
+	defer srv.Close()
+
+	cmd := newProvidersCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{"test", "--provider", "local", "--api-base", srv.URL})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("providers test: %v", err)
+	}
+	if auth != "Bearer sk-from-cmd" {
+		t.Errorf("Authorization = %q, want the key printed by api_key_cmd", auth)
+	}
+}
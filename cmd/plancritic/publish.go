@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dshills/plancritic/internal/publish"
+	"github.com/dshills/plancritic/internal/review"
+	"github.com/spf13/cobra"
+)
+
+type publishFlags struct {
+	to      []string
+	options []string
+	dryRun  bool
+	retries int
+}
+
+func newPublishCmd() *cobra.Command {
+	f := &publishFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "publish <review.json>",
+		Short: "Send a review artifact to one or more publish targets",
+		Long: "Send a review artifact to one or more publish targets.\n\nTargets: " +
+			strings.Join(publish.Names(), ", ") +
+			"\n\nTarget options are passed as --opt key=value (or --opt target.key=value to scope an option to one target).",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPublish(cmd, args[0], f)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringSliceVar(&f.to, "to", nil, "Publish target (repeatable or comma-separated)")
+	flags.StringArrayVar(&f.options, "opt", nil, "Target option as key=value (repeatable)")
+	flags.BoolVar(&f.dryRun, "dry-run", false, "Print what would be published without sending anything")
+	flags.IntVar(&f.retries, "retries", envInt("PLANCRITIC_PUBLISH_RETRIES", 2), "Retries after a transient publish failure")
+
+	return cmd
+}
+
+func runPublish(cmd *cobra.Command, path string, f *publishFlags) error {
+	if len(f.to) == 0 {
+		return exitError(3, "--to is required (targets: %s)", strings.Join(publish.Names(), ", "))
+	}
+	if f.retries < 0 {
+		return exitError(3, "--retries must be >= 0")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return exitError(3, "failed to read review: %v", err)
+	}
+	var rev review.Review
+	if err := json.Unmarshal(data, &rev); err != nil {
+		return exitError(3, "failed to parse review %s: %v", path, err)
+	}
+
+	var targets []publish.Publisher
+	for _, name := range f.to {
+		p, ok := publish.Lookup(strings.TrimSpace(name))
+		if !ok {
+			return exitError(3, "unknown publish target %q (targets: %s)", name, strings.Join(publish.Names(), ", "))
+		}
+		targets = append(targets, p)
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for _, p := range targets {
+		opts, err := targetOptions(p.Name(), f.options)
+		if err != nil {
+			return exitError(3, "%v", err)
+		}
+		err = publish.Publish(ctx, p, &rev, publish.PublishOpts{
+			DryRun:  f.dryRun,
+			Retries: f.retries,
+			Options: opts,
+			Log:     cmd.ErrOrStderr(),
+		})
+		if err != nil {
+			return fmt.Errorf("publish to %s failed: %w", p.Name(), err)
+		}
+	}
+	return nil
+}
+
+// targetOptions parses --opt values for one target. Unscoped keys apply
+// to every target; "target.key" applies only to the named target and
+// wins over an unscoped key of the same name.
+func targetOptions(target string, raw []string) (map[string]string, error) {
+	opts := make(map[string]string)
+	scoped := make(map[string]string)
+	for _, kv := range raw {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --opt %q: want key=value", kv)
+		}
+		if t, k, isScoped := strings.Cut(key, "."); isScoped {
+			if strings.EqualFold(t, target) {
+				scoped[k] = value
+			}
+			continue
+		}
+		opts[key] = value
+	}
+	for k, v := range scoped {
+		opts[k] = v
+	}
+	return opts, nil
+}
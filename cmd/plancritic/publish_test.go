@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPublishDryRun(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTempFile(t, dir, "review.json", `{"tool":"plancritic"}`)
+
+	var stderr bytes.Buffer
+	cmd := newPublishCmd()
+	cmd.SetErr(&stderr)
+	cmd.SetArgs([]string{path, "--to", "webhook", "--opt", "webhook.url=http://127.0.0.1:1/hook", "--dry-run"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stderr.String(), "would POST") {
+		t.Errorf("expected dry-run output, got %q", stderr.String())
+	}
+}
+
+func TestPublishUnknownTarget(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTempFile(t, dir, "review.json", `{"tool":"plancritic"}`)
+
+	cmd := newPublishCmd()
+	cmd.SetArgs([]string{path, "--to", "carrier-pigeon"})
+	assertExitCode(t, cmd.Execute(), 3)
+}
+
+func TestTargetOptionsScoping(t *testing.T) {
+	opts, err := targetOptions("webhook", []string{"url=a", "webhook.url=b", "slack.url=c", "retry=x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts["url"] != "b" || opts["retry"] != "x" {
+		t.Errorf("unexpected options: %v", opts)
+	}
+	if _, err := targetOptions("webhook", []string{"novalue"}); err == nil {
+		t.Error("expected error for option without '='")
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dshills/plancritic/internal/review"
+	"github.com/spf13/cobra"
+)
+
+type signoffFlags struct {
+	approve  bool
+	reject   bool
+	reviewer string
+	notes    string
+	out      string
+	verify   bool
+}
+
+func newSignoffCmd() *cobra.Command {
+	f := &signoffFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "signoff <review.json>",
+		Short: "Record a human approval or rejection in a review artifact",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSignoff(cmd, args[0], f)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVar(&f.approve, "approve", false, "Approve the plan")
+	flags.BoolVar(&f.reject, "reject", false, "Reject the plan")
+	flags.StringVar(&f.reviewer, "reviewer", envStr("PLANCRITIC_REVIEWER", os.Getenv("USER")), "Reviewer name")
+	flags.StringVar(&f.notes, "notes", "", "Reviewer notes")
+	flags.StringVar(&f.out, "out", "", "Output file path (default: update the review in place)")
+	flags.BoolVar(&f.verify, "verify", false, "Verify an existing signoff against the artifact hash instead of signing")
+
+	return cmd
+}
+
+func runSignoff(cmd *cobra.Command, path string, f *signoffFlags) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return exitError(3, "failed to read review: %v", err)
+	}
+	var rev review.Review
+	if err := json.Unmarshal(data, &rev); err != nil {
+		return exitError(3, "failed to parse review %s: %v", path, err)
+	}
+
+	if f.verify {
+		if err := review.VerifySignoff(&rev); err != nil {
+			return exitError(2, "signoff verification failed: %v", err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Signoff OK: %s by %s at %s\n",
+			rev.Signoff.Decision, rev.Signoff.Reviewer, rev.Signoff.Timestamp.Format(time.RFC3339))
+		return nil
+	}
+
+	var decision review.SignoffDecision
+	switch {
+	case f.approve && f.reject:
+		return exitError(3, "--approve and --reject are mutually exclusive")
+	case f.approve:
+		decision = review.SignoffApproved
+	case f.reject:
+		decision = review.SignoffRejected
+	default:
+		return exitError(3, "one of --approve or --reject is required")
+	}
+	if err := review.Sign(&rev, f.reviewer, decision, f.notes, time.Now()); err != nil {
+		return exitError(3, "%v", err)
+	}
+
+	out, err := json.MarshalIndent(&rev, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal output: %w", err)
+	}
+	dest := f.out
+	if dest == "" {
+		dest = path
+	}
+	if err := os.WriteFile(dest, append(out, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
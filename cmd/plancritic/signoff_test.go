@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/dshills/plancritic/internal/review"
+)
+
+func TestSignoffApproveAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	rev := review.Review{Tool: "plancritic", Summary: review.Summary{Verdict: review.VerdictExecutable, Score: 100}}
+	data, _ := json.Marshal(rev)
+	path := writeTempFile(t, dir, "review.json", string(data))
+
+	sign := newSignoffCmd()
+	sign.SetArgs([]string{path, "--approve", "--reviewer", "alice", "--notes", "ok"})
+	if err := sign.Execute(); err != nil {
+		t.Fatalf("signoff: %v", err)
+	}
+
+	out, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var signed review.Review
+	if err := json.Unmarshal(out, &signed); err != nil {
+		t.Fatal(err)
+	}
+	if signed.Signoff == nil || signed.Signoff.Decision != review.SignoffApproved || signed.Signoff.Reviewer != "alice" {
+		t.Fatalf("unexpected signoff: %+v", signed.Signoff)
+	}
+
+	verify := newSignoffCmd()
+	verify.SetOut(&bytes.Buffer{})
+	verify.SetArgs([]string{path, "--verify"})
+	if err := verify.Execute(); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	// Tamper with the critique after sign-off.
+	signed.Summary.Score = 50
+	tampered, _ := json.Marshal(signed)
+	if err := os.WriteFile(path, tampered, 0644); err != nil {
+		t.Fatal(err)
+	}
+	verify = newSignoffCmd()
+	verify.SetArgs([]string{path, "--verify"})
+	assertExitCode(t, verify.Execute(), 2)
+}
+
+func TestSignoffRequiresDecision(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTempFile(t, dir, "review.json", `{"tool":"plancritic"}`)
+
+	for _, args := range [][]string{
+		{path},
+		{path, "--approve", "--reject"},
+	} {
+		cmd := newSignoffCmd()
+		cmd.SetArgs(args)
+		assertExitCode(t, cmd.Execute(), 3)
+	}
+}
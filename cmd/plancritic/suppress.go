@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dshills/plancritic/internal/review"
+	"github.com/dshills/plancritic/internal/suppress"
+	"github.com/spf13/cobra"
+)
+
+type suppressFlags struct {
+	file   string
+	reason string
+	until  string
+	review string
+}
+
+func newSuppressCmd() *cobra.Command {
+	f := &suppressFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "suppress <fingerprint|issue-id>",
+		Short: "Suppress a finding, permanently or until a date",
+		Long: "Add a finding to the suppression file so it no longer appears in reviews or fails CI.\n\n" +
+			"Identify the finding by its fingerprint, or by its ID together with --review to look the fingerprint up.\n" +
+			"With --until the suppression is a snooze: from that date the finding is reported again.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSuppress(cmd, args[0], f)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&f.file, "file", envStr("PLANCRITIC_SUPPRESSIONS", suppress.DefaultPath), "Suppression file")
+	flags.StringVar(&f.reason, "reason", "", "Why the finding is acceptable for now (required)")
+	flags.StringVar(&f.until, "until", "", "Snooze until this date (YYYY-MM-DD); omit for a permanent suppression")
+	flags.StringVar(&f.review, "review", "", "Review JSON used to resolve an issue or question ID to its fingerprint")
+
+	return cmd
+}
+
+func runSuppress(cmd *cobra.Command, target string, f *suppressFlags) error {
+	if strings.TrimSpace(f.reason) == "" {
+		return exitError(3, "--reason is required")
+	}
+	entry := suppress.Entry{Fingerprint: target, Reason: f.reason, Until: f.until}
+	if exp, err := entry.Expiry(); err != nil {
+		return exitError(3, "%v", err)
+	} else if !exp.IsZero() && !time.Now().Before(exp) {
+		return exitError(3, "--until %s is already in the past", f.until)
+	}
+
+	if f.review != "" {
+		fp, title, err := lookupFingerprint(f.review, target)
+		if err != nil {
+			return err
+		}
+		entry.Fingerprint, entry.Title = fp, title
+	}
+
+	file, err := suppress.Load(f.file)
+	if err != nil {
+		return exitError(3, "%v", err)
+	}
+	file.Add(entry)
+	if err := suppress.Save(f.file, file); err != nil {
+		return err
+	}
+	if entry.Until != "" {
+		fmt.Fprintf(cmd.OutOrStdout(), "Snoozed %s until %s in %s\n", entry.Fingerprint, entry.Until, f.file)
+	} else {
+		fmt.Fprintf(cmd.OutOrStdout(), "Suppressed %s in %s\n", entry.Fingerprint, f.file)
+	}
+	return nil
+}
+
+// lookupFingerprint finds the finding with the given ID or fingerprint
+// in a review file.
+func lookupFingerprint(path, target string) (fingerprint, title string, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", exitError(3, "failed to read review: %v", err)
+	}
+	var rev review.Review
+	if err := json.Unmarshal(data, &rev); err != nil {
+		return "", "", exitError(3, "failed to parse review %s: %v", path, err)
+	}
+	review.AssignFingerprints(&rev)
+	for _, iss := range rev.Issues {
+		if iss.ID == target || iss.Fingerprint == target {
+			return iss.Fingerprint, iss.Title, nil
+		}
+	}
+	for _, q := range rev.Questions {
+		if q.ID == target || q.Fingerprint == target {
+			return q.Fingerprint, q.Question, nil
+		}
+	}
+	return "", "", exitError(3, "no issue or question %q in %s", target, path)
+}
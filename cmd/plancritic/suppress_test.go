@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dshills/plancritic/internal/llm"
+	"github.com/dshills/plancritic/internal/review"
+)
+
+func TestSuppressThenCheck(t *testing.T) {
+	planPath := writeTempPlan(t, "# Plan\n\n1. Ship it\n")
+	dir := t.TempDir()
+	outPath := filepath.Join(dir, "review.json")
+	supPath := filepath.Join(dir, "suppressions.yaml")
+
+	f := &checkFlags{
+		format:            "json",
+		out:               outPath,
+		profileName:       "general",
+		redactEnabled:     true,
+		severityThreshold: "info",
+		failOn:            "not_executable",
+		suppressions:      supPath,
+		provider:          &llm.MockProvider{Response: validMockResponse()},
+	}
+	assertExitCode(t, runCheck(context.Background(), planPath, f), 2)
+
+	cmd := newSuppressCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{"ISSUE-0001", "--review", outPath, "--file", supPath, "--reason", "accepted risk", "--until", "2999-01-01"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	// With the critical issue snoozed the verdict no longer fails CI.
+	assertExitCode(t, runCheck(context.Background(), planPath, f), 0)
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rev review.Review
+	if err := json.Unmarshal(data, &rev); err != nil {
+		t.Fatal(err)
+	}
+	if len(rev.Issues) != 0 || rev.Summary.CriticalCount != 0 {
+		t.Errorf("issue should be suppressed: %+v", rev.Issues)
+	}
+	if len(rev.Suppressed) != 1 || rev.Suppressed[0].Until != "2999-01-01" || rev.Suppressed[0].Reason != "accepted risk" {
+		t.Errorf("unexpected suppressed list: %+v", rev.Suppressed)
+	}
+}
+
+func TestSuppressValidation(t *testing.T) {
+	dir := t.TempDir()
+	supPath := filepath.Join(dir, "s.yaml")
+	for _, args := range [][]string{
+		{"abc123", "--file", supPath},
+		{"abc123", "--file", supPath, "--reason", "x", "--until", "soon"},
+		{"abc123", "--file", supPath, "--reason", "x", "--until", "2001-01-01"},
+	} {
+		cmd := newSuppressCmd()
+		cmd.SetArgs(args)
+		assertExitCode(t, cmd.Execute(), 3)
+	}
+}
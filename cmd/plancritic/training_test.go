@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dshills/plancritic/internal/llm"
+	"github.com/dshills/plancritic/internal/training"
+)
+
+func TestCollectTrainingDataRequiresConsent(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("PLANCRITIC_CONFIG", filepath.Join(dir, "config.yaml"))
+	planPath := writeTempPlan(t, "# Plan\n\n1. Ship it\n")
+
+	cmd := newCheckCmd()
+	cmd.SetArgs([]string{planPath, "--collect-training-data", filepath.Join(dir, "data")})
+	assertExitCode(t, cmd.Execute(), 3)
+}
+
+func TestRunCheckCollectsTrainingData(t *testing.T) {
+	planPath := writeTempPlan(t, "# Plan\n\n1. Ship it\n")
+	dataDir := filepath.Join(t.TempDir(), "data")
+	f := &checkFlags{
+		format:            "json",
+		out:               filepath.Join(t.TempDir(), "review.json"),
+		profileName:       "general",
+		redactEnabled:     true,
+		severityThreshold: "info",
+		trainingDataDir:   dataDir,
+		provider:          &llm.MockProvider{Response: validMockResponse()},
+	}
+	assertExitCode(t, runCheck(context.Background(), planPath, f), 0)
+
+	data, err := os.ReadFile(filepath.Join(dataDir, training.FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) == 0 || data[len(data)-1] != '\n' {
+		t.Errorf("expected a JSONL record, got %q", data)
+	}
+}
+
+func TestRunCheckTrainingDataRequiresRedaction(t *testing.T) {
+	planPath := writeTempPlan(t, "# Plan\n\n1. Ship it\n")
+	dataDir := filepath.Join(t.TempDir(), "data")
+	mock := &llm.MockProvider{Response: validMockResponse()}
+	f := &checkFlags{
+		format:            "json",
+		out:               filepath.Join(t.TempDir(), "review.json"),
+		profileName:       "general",
+		severityThreshold: "info",
+		trainingDataDir:   dataDir,
+		provider:          mock,
+	}
+	assertExitCode(t, runCheck(context.Background(), planPath, f), 3)
+	if len(mock.Prompts()) != 0 {
+		t.Error("plan was sent to the model")
+	}
+	if _, err := os.Stat(dataDir); !os.IsNotExist(err) {
+		t.Errorf("training data directory was created: %v", err)
+	}
+}
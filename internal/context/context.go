@@ -4,8 +4,13 @@ package context
 import (
 	"crypto/sha256"
 	"fmt"
+	"net/url"
 	"os"
+	"regexp"
 	"strings"
+
+	"github.com/dshills/plancritic/internal/fetch"
+	"github.com/dshills/plancritic/internal/textnorm"
 )
 
 // File holds a loaded context file with its content and metadata.
@@ -14,31 +19,158 @@ type File struct {
 	Raw      string
 	Lines    []string
 	Hash     string
+	// Section is the heading named by a "path#Heading" pin, empty when
+	// the whole file is included. Start and End are the section's
+	// first and last line numbers (1-based, inclusive) in the original
+	// file; Lines and Raw always hold the whole file so citations keep
+	// their original line numbers.
+	Section    string
+	Start, End int
+	// Name is the unique path the prompt shows for the file and that
+	// citations use; see AssignNames and CitePath. Role says how the
+	// file was supplied (RoleFile, RoleURL, ...).
+	Name string
+	Role string
 }
 
-// Load reads a context file and computes its SHA-256 hash.
+// Load reads a context file and computes its SHA-256 hash. A path of
+// the form "file.md#Heading" that does not name an existing file pins
+// the context to that Markdown heading's section (see Pin). path may be
+// an http or https URL; see LoadWith.
 func Load(path string) (*File, error) {
-	data, err := os.ReadFile(path)
+	return LoadWith(path, fetch.Options{})
+}
+
+// LoadWith is Load with options for a context file fetched from a URL.
+// A URL's fragment pins the section, and the File's FilePath is the URL
+// without credentials, query, or fragment.
+func LoadWith(path string, o fetch.Options) (*File, error) {
+	file, anchor := path, ""
+	if fetch.IsURL(path) {
+		if u, err := url.Parse(path); err == nil {
+			anchor = u.Fragment
+		}
+	} else if i := strings.LastIndex(path, "#"); i > 0 {
+		if _, err := os.Stat(path); err != nil {
+			file, anchor = path[:i], path[i+1:]
+		}
+	}
+	data, err := fetch.Read(file, o)
 	if err != nil {
 		return nil, fmt.Errorf("context.Load: %w", err)
 	}
-	raw := string(data)
-	h := sha256.Sum256(data)
+	f := Parse(fetch.Name(file), string(data))
+	f.Role = RoleFile
+	if _, ok := fetch.JiraKey(file); ok {
+		f.Role = RoleJira
+	} else if _, ok := fetch.GitHubIssue(file); ok {
+		f.Role = RoleGitHub
+	} else if _, _, ok := fetch.ConfluencePage(file); ok {
+		f.Role = RoleConfluence
+	} else if _, ok := fetch.NotionPage(file); ok {
+		f.Role = RoleNotion
+	} else if fetch.IsURL(file) {
+		f.Role = RoleURL
+	}
+	if anchor != "" {
+		if err := f.Pin(anchor); err != nil {
+			return nil, fmt.Errorf("context.Load: %s: %w", f.FilePath, err)
+		}
+	}
+	return f, nil
+}
+
+// Parse builds a File from content already in memory, as Load does for
+// a file. path only names it; no section is pinned. The content is
+// normalized first (see textnorm.Normalize), so a CRLF or BOM file
+// numbers and quotes its lines as the model sees them.
+func Parse(path, content string) *File {
+	content = textnorm.Normalize(content)
+	h := sha256.Sum256([]byte(content))
 	return &File{
 		FilePath: path,
-		Raw:      raw,
-		Lines:    strings.Split(raw, "\n"),
+		Raw:      content,
+		Lines:    strings.Split(content, "\n"),
 		Hash:     fmt.Sprintf("sha256:%x", h),
-	}, nil
+	}
+}
+
+// headingPattern matches an ATX Markdown heading: its level marks and
+// text, without any closing #s.
+var headingPattern = regexp.MustCompile(`^ {0,3}(#{1,6})\s+(.*?)(?:\s+#+)?\s*$`)
+
+// Pin restricts f to the section under the heading named anchor. The
+// anchor matches a heading's text case-insensitively or its GitHub
+// style slug ("Deployment Notes" or "deployment-notes"). The section
+// runs to the next heading of the same or a higher level. Headings in
+// fenced code blocks are ignored.
+func (f *File) Pin(anchor string) error {
+	want := strings.TrimSpace(anchor)
+	start, level := 0, 0
+	var headings []string
+	inFence := false
+	for i, line := range f.Lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		m := headingPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if start > 0 {
+			if len(m[1]) <= level {
+				f.Section, f.Start, f.End = want, start, i
+				return nil
+			}
+			continue
+		}
+		headings = append(headings, m[2])
+		if strings.EqualFold(m[2], want) || slug(m[2]) == strings.ToLower(want) {
+			start, level = i+1, len(m[1])
+		}
+	}
+	if start == 0 {
+		if len(headings) == 0 {
+			return fmt.Errorf("no section %q: the file has no Markdown headings", want)
+		}
+		return fmt.Errorf("no section %q (headings: %s)", want, strings.Join(headings, ", "))
+	}
+	f.Section, f.Start, f.End = want, start, len(f.Lines)
+	return nil
 }
 
-// LineNumbered returns the context text with each line prefixed by L-padded numbers.
+// slug returns the GitHub-style anchor for heading text.
+func slug(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case r == ' ' || r == '-':
+			b.WriteRune('-')
+		case r == '_' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || r > 127:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// LineNumbered returns the context text with each line prefixed by
+// L-padded numbers. A pinned file yields only its section, numbered as
+// in the original file.
 func LineNumbered(f *File) string {
 	width := lineNumberWidth(len(f.Lines))
 	format := fmt.Sprintf("L%%0%dd: %%s\n", width)
+	first, last := 1, len(f.Lines)
+	if f.Start > 0 {
+		first, last = f.Start, f.End
+	}
 	var b strings.Builder
-	for i, line := range f.Lines {
-		fmt.Fprintf(&b, format, i+1, line)
+	for i := first; i <= last && i <= len(f.Lines); i++ {
+		fmt.Fprintf(&b, format, i, f.Lines[i-1])
 	}
 	return b.String()
 }
@@ -1,10 +1,14 @@
 package context
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/dshills/plancritic/internal/fetch"
 )
 
 func writeTempFile(t *testing.T, content string) string {
@@ -33,6 +37,16 @@ func TestLoad(t *testing.T) {
 	}
 }
 
+func TestLoadWindowsFile(t *testing.T) {
+	f, err := Load(writeTempFile(t, "\ufeffconstraint one\r\nconstraint two\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Raw != "constraint one\nconstraint two\n" {
+		t.Errorf("Raw = %q, want LF endings and no BOM", f.Raw)
+	}
+}
+
 func TestLoadMissingFile(t *testing.T) {
 	_, err := Load("/nonexistent/ctx.md")
 	if err == nil {
@@ -50,3 +64,113 @@ func TestLineNumbered(t *testing.T) {
 		t.Errorf("expected L002 prefix, got:\n%s", got)
 	}
 }
+
+const sectionDoc = `# Constraints
+
+Intro.
+
+## Deployment Notes
+
+Use blue/green.
+
+` + "```sh\n# not a heading\n```" + `
+
+### Rollback
+
+Keep the previous release.
+
+## Storage
+
+Postgres only.
+`
+
+func TestLoadPinnedSection(t *testing.T) {
+	path := writeTempFile(t, sectionDoc)
+	for _, anchor := range []string{"Deployment Notes", "deployment notes", "deployment-notes"} {
+		f, err := Load(path + "#" + anchor)
+		if err != nil {
+			t.Fatalf("%s: %v", anchor, err)
+		}
+		if f.FilePath != path || f.Start != 5 || f.End != 16 {
+			t.Errorf("%s: path=%s lines %d-%d, want 5-16", anchor, f.FilePath, f.Start, f.End)
+		}
+	}
+
+	f, err := Load(path + "#Storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := LineNumbered(f)
+	if !strings.HasPrefix(got, "L017: ## Storage\n") || strings.Contains(got, "Deployment") {
+		t.Errorf("section should keep original line numbers:\n%s", got)
+	}
+	if f.End != len(f.Lines) {
+		t.Errorf("last section should run to the end of the file, got end %d", f.End)
+	}
+}
+
+func TestLoadPinnedSectionMissing(t *testing.T) {
+	path := writeTempFile(t, sectionDoc)
+	_, err := Load(path + "#Networking")
+	if err == nil || !strings.Contains(err.Error(), "Deployment Notes") {
+		t.Errorf("err = %v, want the available headings listed", err)
+	}
+	if _, err := Load(path + "#not a heading"); err == nil {
+		t.Error("headings inside code fences should not match")
+	}
+}
+
+func TestLoadFileNameWithHash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes#1.md")
+	if err := os.WriteFile(path, []byte("one\ntwo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Section != "" || len(f.Lines) != 2 {
+		t.Errorf("existing file with # in its name should load whole: %+v", f)
+	}
+}
+
+func TestLoadWithURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Private-Token") != "abc" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(sectionDoc))
+	}))
+	defer srv.Close()
+
+	o := fetch.Options{Headers: http.Header{"Private-Token": {"abc"}}}
+	f, err := LoadWith(srv.URL+"/wiki/ctx.md?raw=1#deployment-notes", o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.FilePath != srv.URL+"/wiki/ctx.md" || f.Start != 5 || f.End != 16 {
+		t.Errorf("path=%s lines %d-%d, want the URL without query and lines 5-16", f.FilePath, f.Start, f.End)
+	}
+	if _, err := Load(srv.URL + "/wiki/ctx.md"); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("err = %v, want the 403 status without the header", err)
+	}
+}
+
+func TestAssignNames(t *testing.T) {
+	files := []*File{
+		{FilePath: "docs/api/constraints.md", Hash: "a"},
+		{FilePath: "docs/db/constraints.md", Hash: "b"},
+		{FilePath: "README.md", Hash: "c"},
+		{FilePath: "./docs/api/constraints.md", Hash: "a", Section: "Limits"},
+		{FilePath: "notes.md", Hash: "d"},
+		{FilePath: "notes.md", Hash: "e"},
+	}
+	AssignNames(files)
+	want := []string{"api/constraints.md", "db/constraints.md", "README.md", "api/constraints.md", "notes.md", "notes.md~2"}
+	for i, f := range files {
+		if f.Name != want[i] {
+			t.Errorf("files[%d].Name = %q, want %q", i, f.Name, want[i])
+		}
+	}
+}
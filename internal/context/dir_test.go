@@ -0,0 +1,67 @@
+package context
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDir(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"README.md":                 "readme",
+		"adr/0001-db.md":            "adr",
+		"adr/0002-cache.markdown":   "adr",
+		"adr/draft/0003-wip.md":     "draft",
+		"notes.txt":                 "notes",
+		"notes.tmp.txt":             "tmp",
+		"diagram.png":               "png",
+		"generated/api.md":          "gen",
+		"generated/keep.md":         "keep",
+		"vendor/lib/README.md":      "vendor",
+		".hidden/secret.md":         "hidden",
+		"sub/.env.md":               "hidden",
+		".plancriticignore":         "# drafts and scratch\n*.tmp.txt\ndraft/\n/vendor\ngenerated/**\n!generated/keep.md\n",
+		"docs/vendor/kept.md":       "anchored pattern only matches the root",
+		"docs/deep/nested/guide.md": "guide",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paths, err := Dir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range paths {
+		rel, _ := filepath.Rel(root, p)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	want := []string{
+		"README.md",
+		"adr/0001-db.md",
+		"adr/0002-cache.markdown",
+		"docs/deep/nested/guide.md",
+		"docs/vendor/kept.md",
+		"generated/keep.md",
+		"notes.txt",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Dir = %v\nwant %v", got, want)
+	}
+
+	if _, err := Dir(filepath.Join(root, "README.md")); err == nil {
+		t.Error("expected error for a file")
+	}
+	if _, err := Dir(filepath.Join(root, "missing")); err == nil {
+		t.Error("expected error for a missing directory")
+	}
+}
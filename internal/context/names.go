@@ -0,0 +1,104 @@
+package context
+
+import (
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Roles record how a context file was supplied. The prompt shows the
+// role next to each file so the model can weigh, say, a document
+// pasted into a form against a file the user named.
+const (
+	RoleFile       = "file"
+	RoleDirectory  = "directory"
+	RoleURL        = "url"
+	RoleJira       = "jira"
+	RoleGitHub     = "github"
+	RoleConfluence = "confluence"
+	RoleNotion     = "notion"
+	RoleDocument   = "document"
+)
+
+// CitePath returns the path the prompt shows for f and that evidence
+// cites it by: f.Name once AssignNames has run, else the base name of
+// f.FilePath.
+func (f *File) CitePath() string {
+	if f.Name != "" {
+		return f.Name
+	}
+	return path.Base(strings.ReplaceAll(f.FilePath, "\\", "/"))
+}
+
+// AssignNames gives each file a Name that no other file shares: its
+// base name, or when base names collide, the shortest trailing part of
+// its path that tells them apart ("api/constraints.md" and
+// "db/constraints.md"). The same file given twice, such as two sections
+// pinned from it, keeps one name since its line numbers agree. Files
+// whose whole paths collide but whose content differs, like two
+// uploaded documents both called "notes.md", get a "~2", "~3" suffix
+// in order.
+func AssignNames(files []*File) {
+	parts := make([][]string, len(files))
+	depth := make([]int, len(files))
+	ids := make([]string, len(files))
+	for i, f := range files {
+		for _, p := range strings.Split(strings.ReplaceAll(f.FilePath, "\\", "/"), "/") {
+			if p != "" && p != "." {
+				parts[i] = append(parts[i], p)
+			}
+		}
+		depth[i] = 1
+		ids[i] = strings.Join(parts[i], "/") + "\x00" + f.Hash
+	}
+	name := func(i int) string {
+		d := min(depth[i], len(parts[i]))
+		return strings.Join(parts[i][len(parts[i])-d:], "/")
+	}
+	for {
+		byName := make(map[string][]int, len(files))
+		for i := range files {
+			byName[name(i)] = append(byName[name(i)], i)
+		}
+		grew := false
+		for _, group := range byName {
+			if !distinct(ids, group) {
+				continue
+			}
+			for _, i := range group {
+				if depth[i] < len(parts[i]) {
+					depth[i]++
+					grew = true
+				}
+			}
+		}
+		if !grew {
+			break
+		}
+	}
+	seen := make(map[string]int, len(files))
+	byID := make(map[string]string, len(files))
+	for i, f := range files {
+		if n, ok := byID[ids[i]]; ok {
+			f.Name = n
+			continue
+		}
+		n := name(i)
+		if seen[n]++; seen[n] > 1 {
+			n += "~" + strconv.Itoa(seen[n])
+		}
+		byID[ids[i]] = n
+		f.Name = n
+	}
+}
+
+// distinct reports whether the files at the indexes in group are more
+// than one file.
+func distinct(ids []string, group []int) bool {
+	for _, i := range group[1:] {
+		if ids[i] != ids[group[0]] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+// Package ensemble merges reviews of the same plan from several models
+// into one review, recording how many models reported each finding.
+package ensemble
+
+import (
+	"fmt"
+
+	"github.com/dshills/plancritic/internal/review"
+)
+
+// Member is one model's validated review.
+type Member struct {
+	Name   string
+	Review review.Review
+}
+
+// Merge combines member reviews. Issues and questions are matched by
+// fingerprint; each merged finding takes the text and evidence of the
+// member that rated it most severe (the earliest member on ties), is
+// blocking if any member marked it blocking, and carries an Agreement
+// naming the members that reported it. IDs are renumbered in sorted
+// order. Patches and checklists come from the first member.
+func Merge(members []Member) review.Review {
+	if len(members) == 0 {
+		return review.Review{}
+	}
+	total := len(members)
+	out := review.Review{
+		Tool:       members[0].Review.Tool,
+		Version:    members[0].Review.Version,
+		Patches:    members[0].Review.Patches,
+		Checklists: members[0].Review.Checklists,
+	}
+
+	issueIdx := map[string]int{}
+	questionIdx := map[string]int{}
+	for _, m := range members {
+		// A member that repeats a finding counts once.
+		seen := map[string]bool{}
+		for _, iss := range m.Review.Issues {
+			fp := review.Fingerprint(iss)
+			if seen[fp] {
+				continue
+			}
+			seen[fp] = true
+			i, ok := issueIdx[fp]
+			if !ok {
+				iss.Agreement = &review.Agreement{Total: total}
+				issueIdx[fp] = len(out.Issues)
+				out.Issues = append(out.Issues, iss)
+				i = issueIdx[fp]
+			} else if iss.Severity.Order() < out.Issues[i].Severity.Order() {
+				agreement, blocking := out.Issues[i].Agreement, out.Issues[i].Blocking
+				iss.Agreement = agreement
+				iss.Blocking = iss.Blocking || blocking
+				out.Issues[i] = iss
+			} else {
+				out.Issues[i].Blocking = out.Issues[i].Blocking || iss.Blocking
+			}
+			a := out.Issues[i].Agreement
+			a.Count++
+			a.Models = append(a.Models, m.Name)
+		}
+
+		seen = map[string]bool{}
+		for _, q := range m.Review.Questions {
+			fp := review.QuestionFingerprint(q)
+			if seen[fp] {
+				continue
+			}
+			seen[fp] = true
+			i, ok := questionIdx[fp]
+			if !ok {
+				q.Agreement = &review.Agreement{Total: total}
+				questionIdx[fp] = len(out.Questions)
+				out.Questions = append(out.Questions, q)
+				i = questionIdx[fp]
+			} else if q.Severity.Order() < out.Questions[i].Severity.Order() {
+				q.Agreement = out.Questions[i].Agreement
+				out.Questions[i] = q
+			}
+			a := out.Questions[i].Agreement
+			a.Count++
+			a.Models = append(a.Models, m.Name)
+		}
+	}
+
+	review.SortIssues(out.Issues)
+	review.SortQuestions(out.Questions)
+	for i := range out.Issues {
+		out.Issues[i].ID = fmt.Sprintf("ISSUE-%04d", i+1)
+	}
+	for i := range out.Questions {
+		out.Questions[i].ID = fmt.Sprintf("Q-%04d", i+1)
+	}
+	if out.Issues == nil {
+		out.Issues = []review.Issue{}
+	}
+	if out.Questions == nil {
+		out.Questions = []review.Question{}
+	}
+	return out
+}
+
+// FilterByAgreement drops findings reported by fewer than min members.
+// Findings without an Agreement are kept.
+func FilterByAgreement(r *review.Review, min int) {
+	if min <= 1 {
+		return
+	}
+	issues := r.Issues[:0]
+	for _, iss := range r.Issues {
+		if iss.Agreement == nil || iss.Agreement.Count >= min {
+			issues = append(issues, iss)
+		}
+	}
+	r.Issues = issues
+	questions := r.Questions[:0]
+	for _, q := range r.Questions {
+		if q.Agreement == nil || q.Agreement.Count >= min {
+			questions = append(questions, q)
+		}
+	}
+	r.Questions = questions
+}
@@ -0,0 +1,78 @@
+package ensemble
+
+import (
+	"testing"
+
+	"github.com/dshills/plancritic/internal/review"
+)
+
+func issue(id string, sev review.Severity, title string, blocking bool) review.Issue {
+	return review.Issue{
+		ID:       id,
+		Severity: sev,
+		Category: review.CategoryRiskData,
+		Title:    title,
+		Blocking: blocking,
+		Evidence: []review.Evidence{{Source: "plan", Path: "plan.md", LineStart: 1, LineEnd: 1}},
+	}
+}
+
+func TestMergeAgreement(t *testing.T) {
+	a := review.Review{
+		Issues: []review.Issue{
+			issue("ISSUE-0001", review.SeverityWarn, "No backup before migration", false),
+			issue("ISSUE-0002", review.SeverityInfo, "Only A saw this", false),
+		},
+		Questions: []review.Question{{ID: "Q-0001", Severity: review.SeverityInfo, Question: "Which DB?"}},
+	}
+	b := review.Review{
+		Issues: []review.Issue{
+			issue("ISSUE-0001", review.SeverityCritical, "No backup before migration.", true),
+		},
+		Questions: []review.Question{{ID: "Q-0001", Severity: review.SeverityWarn, Question: "which db"}},
+	}
+
+	got := Merge([]Member{{Name: "a", Review: a}, {Name: "b", Review: b}})
+	if len(got.Issues) != 2 {
+		t.Fatalf("issues = %d, want 2", len(got.Issues))
+	}
+	shared := got.Issues[0]
+	if shared.ID != "ISSUE-0001" || shared.Severity != review.SeverityCritical || !shared.Blocking {
+		t.Errorf("shared issue = %+v, want the most severe, blocking version first", shared)
+	}
+	if ag := shared.Agreement; ag == nil || ag.Count != 2 || ag.Total != 2 || len(ag.Models) != 2 {
+		t.Errorf("shared agreement = %+v", shared.Agreement)
+	}
+	if ag := got.Issues[1].Agreement; ag == nil || ag.Count != 1 || ag.Models[0] != "a" {
+		t.Errorf("single agreement = %+v", ag)
+	}
+	if len(got.Questions) != 1 || got.Questions[0].Severity != review.SeverityWarn || got.Questions[0].Agreement.Count != 2 {
+		t.Errorf("questions = %+v", got.Questions)
+	}
+}
+
+func TestMergeCountsMemberOnce(t *testing.T) {
+	a := review.Review{Issues: []review.Issue{
+		issue("ISSUE-0001", review.SeverityWarn, "Dup", false),
+		issue("ISSUE-0002", review.SeverityWarn, "dup", false),
+	}}
+	got := Merge([]Member{{Name: "a", Review: a}, {Name: "b", Review: review.Review{}}})
+	if len(got.Issues) != 1 || got.Issues[0].Agreement.Count != 1 || got.Issues[0].Agreement.Total != 2 {
+		t.Errorf("issues = %+v", got.Issues)
+	}
+	if got.Questions == nil {
+		t.Error("questions should be an empty slice, not nil")
+	}
+}
+
+func TestFilterByAgreement(t *testing.T) {
+	r := review.Review{Issues: []review.Issue{
+		{ID: "ISSUE-0001", Agreement: &review.Agreement{Count: 2, Total: 3}},
+		{ID: "ISSUE-0002", Agreement: &review.Agreement{Count: 1, Total: 3}},
+		{ID: "ISSUE-0003"},
+	}}
+	FilterByAgreement(&r, 2)
+	if len(r.Issues) != 2 || r.Issues[0].ID != "ISSUE-0001" || r.Issues[1].ID != "ISSUE-0003" {
+		t.Errorf("issues = %+v", r.Issues)
+	}
+}
@@ -0,0 +1,225 @@
+// Package escalate extracts the findings in a review that need a human
+// decision and formats them as a compact document for a decision
+// meeting or forum.
+package escalate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dshills/plancritic/internal/review"
+)
+
+// DeadlinePlaceholder stands in for the decision deadline when none is
+// given.
+const DeadlinePlaceholder = "TBD"
+
+// DefaultDeciders maps issue categories to the role that usually owns
+// the decision. Categories not listed, and questions, go to the plan
+// owner.
+var DefaultDeciders = map[review.Category]string{
+	review.CategoryRiskSecurity:              "Security lead",
+	review.CategoryRiskData:                  "Data owner",
+	review.CategoryRiskOperations:            "Operations / SRE",
+	review.CategoryScopeCreepRisk:            "Product owner",
+	review.CategoryMissingAcceptanceCriteria: "Product owner",
+	review.CategoryUnspecifiedInterface:      "Tech lead",
+	review.CategoryOrderingDependency:        "Tech lead",
+	review.CategoryContradiction:             "Tech lead",
+}
+
+// PlanOwner is the decider for findings with no category mapping.
+const PlanOwner = "Plan owner"
+
+// Options configures Build.
+type Options struct {
+	// Deadline is printed on every decision; empty means
+	// DeadlinePlaceholder.
+	Deadline string
+	// Deciders overrides entries of DefaultDeciders.
+	Deciders map[review.Category]string
+}
+
+// Document is the escalation output.
+type Document struct {
+	PlanFile  string         `json:"plan_file"`
+	PlanHash  string         `json:"plan_hash"`
+	Verdict   review.Verdict `json:"verdict"`
+	Score     int            `json:"score"`
+	Deadline  string         `json:"deadline"`
+	Decisions []Decision     `json:"decisions"`
+}
+
+// Decision is one question put to a decider.
+type Decision struct {
+	// ID is the issue or question ID the decision comes from, or
+	// "VERDICT" for the go/no-go decision on a NOT_EXECUTABLE plan.
+	ID          string `json:"id"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// Permalink is the finding's ID across output formats (see
+	// review.Permalink), so a decision can link back to the report.
+	Permalink string          `json:"permalink,omitempty"`
+	Severity  review.Severity `json:"severity"`
+	Decider   string          `json:"decider"`
+	Question  string          `json:"question"`
+	Context   string          `json:"context,omitempty"`
+	Options   []string        `json:"options"`
+	Lines     []string        `json:"lines,omitempty"`
+	Deadline  string          `json:"deadline"`
+}
+
+// Build selects the findings that need a human decision: blocking or
+// CRITICAL issues, questions that block steps or are CRITICAL, and, for
+// a NOT_EXECUTABLE verdict, the go/no-go decision itself.
+func Build(r *review.Review, opts Options) Document {
+	deadline := opts.Deadline
+	if deadline == "" {
+		deadline = DeadlinePlaceholder
+	}
+	doc := Document{
+		PlanFile:  r.Input.PlanFile,
+		PlanHash:  r.Input.PlanHash,
+		Verdict:   r.Summary.Verdict,
+		Score:     r.Summary.Score,
+		Deadline:  deadline,
+		Decisions: []Decision{},
+	}
+
+	if r.Summary.Verdict == review.VerdictNotExecutable {
+		doc.Decisions = append(doc.Decisions, Decision{
+			ID:       "VERDICT",
+			Severity: review.SeverityCritical,
+			Decider:  PlanOwner,
+			Question: "The plan was judged not executable as written. Revise it before work starts, or proceed and accept the blockers below?",
+			Options: []string{
+				"Revise the plan and re-run the review",
+				"Proceed, accepting the blocking findings below",
+				"Stop or re-scope the work",
+			},
+			Deadline: deadline,
+		})
+	}
+
+	issueLinks, questionLinks := review.Permalinks(r)
+	for i, q := range r.Questions {
+		if len(q.Blocks) == 0 && q.Severity != review.SeverityCritical {
+			continue
+		}
+		options := append([]string(nil), q.SuggestedAnswers...)
+		options = append(options, "Other (record the answer in the plan)")
+		ctx := q.WhyNeeded
+		if len(q.Blocks) > 0 {
+			ctx = strings.TrimSpace(ctx + " Blocks: " + strings.Join(q.Blocks, ", ") + ".")
+		}
+		doc.Decisions = append(doc.Decisions, Decision{
+			ID:          q.ID,
+			Fingerprint: q.Fingerprint,
+			Permalink:   questionLinks[i],
+			Severity:    q.Severity,
+			Decider:     PlanOwner,
+			Question:    q.Question,
+			Context:     ctx,
+			Options:     options,
+			Lines:       evidenceLines(q.Evidence),
+			Deadline:    deadline,
+		})
+	}
+
+	for i, iss := range r.Issues {
+		if !iss.Blocking && iss.Severity != review.SeverityCritical {
+			continue
+		}
+		options := []string{}
+		if iss.Recommendation != "" {
+			options = append(options, "Adopt the recommendation: "+iss.Recommendation)
+		}
+		options = append(options, "Accept the risk and proceed as planned", "Defer: track as follow-up work")
+		doc.Decisions = append(doc.Decisions, Decision{
+			ID:          iss.ID,
+			Fingerprint: iss.Fingerprint,
+			Permalink:   issueLinks[i],
+			Severity:    iss.Severity,
+			Decider:     decider(iss.Category, opts.Deciders),
+			Question:    iss.Title,
+			Context:     strings.TrimSpace(iss.Description + " " + iss.Impact),
+			Options:     options,
+			Lines:       evidenceLines(iss.Evidence),
+			Deadline:    deadline,
+		})
+	}
+	return doc
+}
+
+func decider(c review.Category, overrides map[review.Category]string) string {
+	if d, ok := overrides[c]; ok && d != "" {
+		return d
+	}
+	if d, ok := DefaultDeciders[c]; ok {
+		return d
+	}
+	return PlanOwner
+}
+
+func evidenceLines(ev []review.Evidence) []string {
+	var out []string
+	for _, e := range ev {
+		if e.LineStart == e.LineEnd {
+			out = append(out, fmt.Sprintf("%s:%d", e.Path, e.LineStart))
+		} else {
+			out = append(out, fmt.Sprintf("%s:%d-%d", e.Path, e.LineStart, e.LineEnd))
+		}
+	}
+	return out
+}
+
+// Markdown renders the document for posting to a decision forum,
+// grouped by decider.
+func Markdown(doc Document) string {
+	var b strings.Builder
+	b.WriteString("# Decisions Needed\n\n")
+	fmt.Fprintf(&b, "**Plan:** %s (%s)\n", doc.PlanFile, doc.PlanHash)
+	fmt.Fprintf(&b, "**Verdict:** %s, score %d / 100\n", doc.Verdict, doc.Score)
+	fmt.Fprintf(&b, "**Decide by:** %s\n\n", doc.Deadline)
+
+	if len(doc.Decisions) == 0 {
+		b.WriteString("No decisions needed.\n")
+		return b.String()
+	}
+
+	var deciders []string
+	byDecider := map[string][]Decision{}
+	for _, d := range doc.Decisions {
+		if _, ok := byDecider[d.Decider]; !ok {
+			deciders = append(deciders, d.Decider)
+		}
+		byDecider[d.Decider] = append(byDecider[d.Decider], d)
+	}
+	// The plan owner leads because the go/no-go decision is theirs.
+	sort.SliceStable(deciders, func(i, j int) bool {
+		return deciders[i] == PlanOwner && deciders[j] != PlanOwner
+	})
+
+	for _, who := range deciders {
+		fmt.Fprintf(&b, "## %s\n\n", who)
+		for _, d := range byDecider[who] {
+			id := d.ID
+			if d.Permalink != "" {
+				id += ", " + d.Permalink
+			}
+			fmt.Fprintf(&b, "### [%s] %s (%s)\n\n", d.Severity, d.Question, id)
+			if d.Context != "" {
+				fmt.Fprintf(&b, "%s\n\n", d.Context)
+			}
+			if len(d.Lines) > 0 {
+				fmt.Fprintf(&b, "Evidence: %s\n\n", strings.Join(d.Lines, ", "))
+			}
+			b.WriteString("Options:\n")
+			for _, o := range d.Options {
+				fmt.Fprintf(&b, "- [ ] %s\n", o)
+			}
+			fmt.Fprintf(&b, "\nDecision: ______  Owner: %s  Due: %s\n\n", d.Decider, d.Deadline)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,84 @@
+package escalate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dshills/plancritic/internal/review"
+)
+
+func testReview() *review.Review {
+	ev := []review.Evidence{{Source: "plan", Path: "plan.md", LineStart: 3, LineEnd: 4}}
+	return &review.Review{
+		Input:   review.Input{PlanFile: "plan.md", PlanHash: "sha256:abc"},
+		Summary: review.Summary{Verdict: review.VerdictNotExecutable, Score: 60},
+		Questions: []review.Question{
+			{ID: "Q-0001", Severity: review.SeverityWarn, Question: "Which region?", WhyNeeded: "Latency budget depends on it.", Blocks: []string{"STEP-2"}, Evidence: ev, SuggestedAnswers: []string{"us-east-1", "eu-west-1"}},
+			{ID: "Q-0002", Severity: review.SeverityInfo, Question: "Naming?"},
+		},
+		Issues: []review.Issue{
+			{ID: "ISSUE-0001", Severity: review.SeverityCritical, Category: review.CategoryRiskSecurity, Title: "Secrets in repo", Recommendation: "Use a vault", Evidence: ev},
+			{ID: "ISSUE-0002", Severity: review.SeverityWarn, Category: review.CategoryTestGap, Title: "No load test", Blocking: true},
+			{ID: "ISSUE-0003", Severity: review.SeverityWarn, Category: review.CategoryAmbiguity, Title: "Vague step"},
+		},
+	}
+}
+
+func TestBuildSelectsDecisions(t *testing.T) {
+	doc := Build(testReview(), Options{})
+	var ids []string
+	for _, d := range doc.Decisions {
+		ids = append(ids, d.ID)
+	}
+	if got := strings.Join(ids, ","); got != "VERDICT,Q-0001,ISSUE-0001,ISSUE-0002" {
+		t.Fatalf("decisions = %s", got)
+	}
+	if doc.Deadline != DeadlinePlaceholder {
+		t.Errorf("deadline = %q", doc.Deadline)
+	}
+	q := doc.Decisions[1]
+	if len(q.Options) != 3 || q.Options[0] != "us-east-1" || !strings.Contains(q.Context, "STEP-2") {
+		t.Errorf("question decision = %+v", q)
+	}
+	if got := doc.Decisions[2].Decider; got != "Security lead" {
+		t.Errorf("security decider = %q", got)
+	}
+	if got := doc.Decisions[3].Decider; got != PlanOwner {
+		t.Errorf("unmapped decider = %q", got)
+	}
+	if got := doc.Decisions[2].Lines; len(got) != 1 || got[0] != "plan.md:3-4" {
+		t.Errorf("lines = %v", got)
+	}
+}
+
+func TestBuildDeciderOverride(t *testing.T) {
+	doc := Build(testReview(), Options{
+		Deadline: "2026-11-01",
+		Deciders: map[review.Category]string{review.CategoryRiskSecurity: "CISO"},
+	})
+	d := doc.Decisions[2]
+	if d.Decider != "CISO" || d.Deadline != "2026-11-01" {
+		t.Errorf("decision = %+v", d)
+	}
+}
+
+func TestMarkdownGroupsByDecider(t *testing.T) {
+	md := Markdown(Build(testReview(), Options{}))
+	owner := strings.Index(md, "## Plan owner")
+	sec := strings.Index(md, "## Security lead")
+	if owner < 0 || sec < 0 || owner > sec {
+		t.Fatalf("expected plan owner section before security section:\n%s", md)
+	}
+	for _, want := range []string{"**Decide by:** TBD", "- [ ] Adopt the recommendation: Use a vault", "Evidence: plan.md:3-4"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q", want)
+		}
+	}
+}
+
+func TestMarkdownNoDecisions(t *testing.T) {
+	md := Markdown(Build(&review.Review{Summary: review.Summary{Verdict: review.VerdictExecutable}}, Options{}))
+	if !strings.Contains(md, "No decisions needed.") {
+		t.Errorf("unexpected markdown:\n%s", md)
+	}
+}
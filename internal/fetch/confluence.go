@@ -0,0 +1,501 @@
+package fetch
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// confluencePrefix marks a plan or context path as a Confluence page
+// ID, as in "confluence:123456".
+const confluencePrefix = "confluence:"
+
+var (
+	// confluenceIDPattern matches a Confluence page ID.
+	confluenceIDPattern = regexp.MustCompile(`^[0-9]+$`)
+	// confluencePagePath matches the path of a page URL, capturing the
+	// base (the part before "/spaces/", e.g. "/wiki" on Confluence
+	// Cloud) and the page ID: /wiki/spaces/ENG/pages/123456/Title.
+	confluencePagePath = regexp.MustCompile(`^(.*?)/spaces/[^/]+/pages/([0-9]+)(?:/[^/]*)?/?$`)
+	// confluenceViewPath matches the path of a Confluence Data Center
+	// page URL that names the page in its pageId query parameter.
+	confluenceViewPath = regexp.MustCompile(`^(.*?)/pages/viewpage\.action$`)
+)
+
+// ConfluencePage reports whether path names a Confluence page, as
+// "confluence:ID" or as a page URL, and returns the page ID and the
+// base URL of its site. base is empty for "confluence:ID", which is
+// read from CONFLUENCE_URL.
+func ConfluencePage(path string) (base, id string, ok bool) {
+	if len(path) > len(confluencePrefix) && strings.EqualFold(path[:len(confluencePrefix)], confluencePrefix) {
+		id = path[len(confluencePrefix):]
+		return "", id, confluenceIDPattern.MatchString(id)
+	}
+	if !IsURL(path) {
+		return "", "", false
+	}
+	u, err := url.Parse(path)
+	if err != nil {
+		return "", "", false
+	}
+	site := func(prefix string) string {
+		return (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: prefix}).String()
+	}
+	if m := confluencePagePath.FindStringSubmatch(u.Path); m != nil {
+		return site(m[1]), m[2], true
+	}
+	if m := confluenceViewPath.FindStringSubmatch(u.Path); m != nil {
+		if id := u.Query().Get("pageId"); confluenceIDPattern.MatchString(id) {
+			return site(m[1]), id, true
+		}
+	}
+	return "", "", false
+}
+
+// confluencePage is the part of a Confluence REST API content response
+// a review reads.
+type confluencePage struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Body  struct {
+		Storage struct {
+			Value string `json:"value"`
+		} `json:"storage"`
+	} `json:"body"`
+}
+
+// readConfluence fetches page id from the Confluence site at base (or
+// CONFLUENCE_URL) and renders it as Markdown: the title as a heading,
+// then the body converted from Confluence storage format (see
+// storageToMarkdown). CONFLUENCE_TOKEN authenticates as a bearer token
+// (a Data Center personal access token), or with CONFLUENCE_EMAIL set,
+// as a Confluence Cloud API token. Without a token the request carries
+// the --url-header headers, as for any URL.
+func readConfluence(base, id string, o Options) ([]byte, error) {
+	name := confluencePrefix + id
+	if base == "" {
+		base = os.Getenv("CONFLUENCE_URL")
+		if base == "" {
+			return nil, fmt.Errorf("fetch %s: set CONFLUENCE_URL and CONFLUENCE_TOKEN to read Confluence pages by ID", name)
+		}
+	}
+	headers := http.Header{}
+	if token := os.Getenv("CONFLUENCE_TOKEN"); token != "" {
+		auth := "Bearer " + token
+		if email := os.Getenv("CONFLUENCE_EMAIL"); email != "" {
+			auth = "Basic " + base64.StdEncoding.EncodeToString([]byte(email+":"+token))
+		}
+		headers.Set("Authorization", auth)
+	} else {
+		headers = o.Headers.Clone()
+		if headers == nil {
+			headers = http.Header{}
+		}
+	}
+	headers.Set("Accept", "application/json")
+	u := strings.TrimRight(base, "/") + "/rest/api/content/" + id + "?expand=body.storage"
+	data, err := get(name, u, headers, o)
+	if err != nil {
+		return nil, err
+	}
+	var page confluencePage
+	if err := json.Unmarshal(data, &page); err != nil {
+		return nil, fmt.Errorf("fetch %s: invalid Confluence response: %w", name, err)
+	}
+	body, err := storageToMarkdown(page.Body.Storage.Value)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", name, err)
+	}
+	text := body
+	if page.Title != "" {
+		text = "# " + page.Title + "\n\n" + body
+	}
+	return []byte(text), nil
+}
+
+// storageAutoClose lists the void HTML elements that storage format may
+// leave unclosed. It is xml.HTMLAutoClose without "link", which in
+// storage format is ac:link, a container.
+var storageAutoClose = func() []string {
+	var out []string
+	for _, name := range xml.HTMLAutoClose {
+		if name != "link" {
+			out = append(out, name)
+		}
+	}
+	return out
+}()
+
+// storageList is an open list in storageWriter: ordered lists count
+// their items so numbering survives conversion.
+type storageList struct {
+	ordered bool
+	n       int
+}
+
+// storageWriter renders Confluence storage format as Markdown, one
+// token at a time.
+type storageWriter struct {
+	b       strings.Builder
+	lineLen int    // bytes written on the current line
+	prefix  string // written before the next text on a fresh line
+	lists   []storageList
+	skip    int // depth inside elements whose text is dropped
+	raw     int // depth inside preformatted text
+	// macros records, per open ac:structured-macro, whether it is a
+	// code block.
+	macros []bool
+	// table collects rows while a table is open; inCell marks an open
+	// cell.
+	table  [][]string
+	inCell bool
+	// status collects an ac:task-status; done marks the open task.
+	status  *strings.Builder
+	done    bool
+	linkRef string // title of the page an open ac:link points at
+	linkHas bool   // whether the open ac:link had a body
+}
+
+// storageToMarkdown converts a Confluence storage-format body (XHTML
+// with ac: and ri: elements) to Markdown. Headings, paragraphs, and
+// lists keep their structure; ordered lists are numbered from their
+// start attribute so plan step numbers survive; task lists become
+// checkboxes, tables become pipe tables, and code macros become fenced
+// blocks. Macro parameters and images are dropped.
+func storageToMarkdown(storage string) (string, error) {
+	d := xml.NewDecoder(strings.NewReader("<root>" + storage + "</root>"))
+	d.Strict = false
+	d.AutoClose = storageAutoClose
+	d.Entity = xml.HTMLEntity
+	w := &storageWriter{}
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("invalid storage format: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			w.start(t)
+		case xml.EndElement:
+			w.end(t)
+		case xml.CharData:
+			w.text(string(t))
+		}
+	}
+	out := strings.TrimSpace(w.b.String())
+	for strings.Contains(out, "\n\n\n") {
+		out = strings.ReplaceAll(out, "\n\n\n", "\n\n")
+	}
+	return out + "\n", nil
+}
+
+func (w *storageWriter) start(t xml.StartElement) {
+	if w.skip > 0 {
+		w.skip++
+		return
+	}
+	switch name := strings.ToLower(t.Name.Local); name {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		w.blank()
+		level, _ := strconv.Atoi(name[1:])
+		w.prefix = strings.Repeat("#", level) + " "
+	case "p", "div", "blockquote":
+		if len(w.lists) == 0 && w.table == nil {
+			w.blank()
+		} else {
+			w.newline()
+		}
+	case "br":
+		if w.inCell {
+			w.cellText(" ")
+		} else {
+			w.newline()
+		}
+	case "ul", "ol", "task-list":
+		w.newline()
+		l := storageList{ordered: name == "ol"}
+		if n, err := strconv.Atoi(attr(t, "start")); err == nil {
+			l.n = n - 1
+		}
+		w.lists = append(w.lists, l)
+	case "li":
+		w.newline()
+		if len(w.lists) == 0 {
+			w.prefix = "- "
+			return
+		}
+		l := &w.lists[len(w.lists)-1]
+		l.n++
+		marker := "- "
+		if l.ordered {
+			marker = strconv.Itoa(l.n) + ". "
+		}
+		w.prefix = w.indent() + marker
+	case "task":
+		w.newline()
+		w.done = false
+	case "task-status":
+		w.status = &strings.Builder{}
+	case "task-body":
+		box := "[ ] "
+		if w.done {
+			box = "[x] "
+		}
+		w.prefix = w.indent() + "- " + box
+	case "table":
+		w.blank()
+		w.table = [][]string{}
+	case "tr":
+		if w.table != nil {
+			w.table = append(w.table, nil)
+		}
+	case "td", "th":
+		if n := len(w.table); n > 0 {
+			w.table[n-1] = append(w.table[n-1], "")
+			w.inCell = true
+		}
+	case "pre":
+		w.fence()
+	case "structured-macro":
+		code := attr(t, "name") == "code" || attr(t, "name") == "noformat"
+		w.macros = append(w.macros, code)
+		if code {
+			w.fence()
+		}
+	case "code":
+		if w.raw == 0 {
+			w.text("`")
+		}
+	case "link":
+		w.linkRef, w.linkHas = "", false
+	case "page", "attachment", "space":
+		if title := attr(t, "content-title"); title != "" {
+			w.linkRef = title
+		} else if file := attr(t, "filename"); file != "" {
+			w.linkRef = file
+		}
+	case "link-body", "plain-text-link-body":
+		w.linkHas = true
+	case "parameter", "image", "placeholder", "script", "style":
+		w.skip = 1
+	}
+}
+
+func (w *storageWriter) end(t xml.EndElement) {
+	if w.skip > 0 {
+		w.skip--
+		return
+	}
+	switch name := strings.ToLower(t.Name.Local); name {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		w.prefix = ""
+		w.blank()
+	case "p", "div", "blockquote":
+		if len(w.lists) == 0 && w.table == nil {
+			w.blank()
+		} else {
+			w.newline()
+		}
+	case "ul", "ol", "task-list":
+		if len(w.lists) > 0 {
+			w.lists = w.lists[:len(w.lists)-1]
+		}
+		w.newline()
+		if len(w.lists) == 0 {
+			w.blank()
+		}
+	case "li", "task":
+		w.prefix = ""
+		w.newline()
+	case "task-status":
+		if w.status != nil {
+			w.done = strings.TrimSpace(w.status.String()) == "complete"
+			w.status = nil
+		}
+	case "td", "th":
+		w.inCell = false
+	case "table":
+		w.writeTable()
+	case "pre":
+		w.closeFence()
+	case "structured-macro":
+		if n := len(w.macros); n > 0 {
+			if w.macros[n-1] {
+				w.closeFence()
+			}
+			w.macros = w.macros[:n-1]
+		}
+	case "code":
+		if w.raw == 0 {
+			w.text("`")
+		}
+	case "link":
+		if !w.linkHas && w.linkRef != "" {
+			w.text(w.linkRef)
+		}
+		w.linkRef, w.linkHas = "", false
+	}
+}
+
+// text writes character data: verbatim inside preformatted text, else
+// with whitespace collapsed, and into the open cell inside a table.
+func (w *storageWriter) text(s string) {
+	if w.skip > 0 {
+		return
+	}
+	if w.status != nil {
+		w.status.WriteString(s)
+		return
+	}
+	if w.raw > 0 {
+		w.b.WriteString(s)
+		if strings.HasSuffix(s, "\n") {
+			w.lineLen = 0
+		} else {
+			w.lineLen += len(s)
+		}
+		return
+	}
+	s = collapseSpace(s)
+	if w.inCell {
+		w.cellText(s)
+		return
+	}
+	if w.table != nil {
+		return
+	}
+	if w.lineLen == 0 {
+		s = strings.TrimLeft(s, " ")
+		if s == "" {
+			return
+		}
+		if w.prefix == "" && len(w.lists) > 0 {
+			// A continuation paragraph inside a list item.
+			w.prefix = w.indent() + "  "
+		}
+		w.b.WriteString(w.prefix)
+		w.lineLen += len(w.prefix)
+		w.prefix = ""
+	}
+	w.b.WriteString(s)
+	w.lineLen += len(s)
+}
+
+// collapseSpace turns each run of whitespace in s into one space, as
+// HTML rendering does.
+func collapseSpace(s string) string {
+	words := strings.Fields(s)
+	if len(words) == 0 {
+		if s == "" {
+			return ""
+		}
+		return " "
+	}
+	out := strings.Join(words, " ")
+	if strings.TrimLeft(s, " \t\r\n") != s {
+		out = " " + out
+	}
+	if strings.TrimRight(s, " \t\r\n") != s {
+		out += " "
+	}
+	return out
+}
+
+func (w *storageWriter) cellText(s string) {
+	row := w.table[len(w.table)-1]
+	row[len(row)-1] += s
+}
+
+// newline ends the current line if anything is on it.
+func (w *storageWriter) newline() {
+	if w.lineLen != 0 {
+		w.b.WriteString("\n")
+		w.lineLen = 0
+	}
+}
+
+// blank leaves an empty line before the next block.
+func (w *storageWriter) blank() {
+	w.newline()
+	if s := w.b.String(); s != "" && !strings.HasSuffix(s, "\n\n") {
+		w.b.WriteString("\n")
+	}
+}
+
+// indent is the leading space for an item of the innermost open list.
+func (w *storageWriter) indent() string {
+	return strings.Repeat("   ", max(len(w.lists)-1, 0))
+}
+
+// fence opens a fenced code block; raw text is written verbatim until
+// closeFence.
+func (w *storageWriter) fence() {
+	w.blank()
+	w.b.WriteString("```\n")
+	w.raw++
+	w.lineLen = 0
+}
+
+func (w *storageWriter) closeFence() {
+	w.raw = max(w.raw-1, 0)
+	if w.lineLen != 0 {
+		w.b.WriteString("\n")
+	}
+	w.b.WriteString("```\n\n")
+	w.lineLen = 0
+}
+
+// writeTable renders the collected rows as a pipe table.
+func (w *storageWriter) writeTable() {
+	rows := w.table
+	w.table, w.inCell = nil, false
+	if len(rows) == 0 {
+		return
+	}
+	writePipeTable(&w.b, rows)
+	w.b.WriteString("\n")
+	w.lineLen = 0
+}
+
+// writePipeTable writes rows as a Markdown pipe table, the first row as
+// the header, padding short rows to the widest.
+func writePipeTable(b *strings.Builder, rows [][]string) {
+	cols := 0
+	for _, r := range rows {
+		cols = max(cols, len(r))
+	}
+	for i, r := range rows {
+		cells := make([]string, cols)
+		for j := range cells {
+			if j < len(r) {
+				cells[j] = strings.ReplaceAll(strings.TrimSpace(r[j]), "|", `\|`)
+			}
+		}
+		fmt.Fprintf(b, "| %s |\n", strings.Join(cells, " | "))
+		if i == 0 {
+			fmt.Fprintf(b, "|%s\n", strings.Repeat(" --- |", cols))
+		}
+	}
+}
+
+// attr returns the value of t's attribute local, whatever its prefix.
+func attr(t xml.StartElement, local string) string {
+	for _, a := range t.Attr {
+		if strings.EqualFold(a.Name.Local, local) {
+			return a.Value
+		}
+	}
+	return ""
+}
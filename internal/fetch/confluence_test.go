@@ -0,0 +1,93 @@
+package fetch
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestConfluencePage(t *testing.T) {
+	cases := []struct {
+		in, base, id string
+	}{
+		{"confluence:123456", "", "123456"},
+		{"https://acme.atlassian.net/wiki/spaces/ENG/pages/123456/Checkout+Plan", "https://acme.atlassian.net/wiki", "123456"},
+		{"https://wiki.acme.com/pages/viewpage.action?pageId=42", "https://wiki.acme.com", "42"},
+		{"https://wiki.acme.com/raw/plan.md", "", ""},
+		{"confluence:ENG", "", ""},
+	}
+	for _, c := range cases {
+		base, id, ok := ConfluencePage(c.in)
+		if base != c.base || id != c.id && ok || ok != (c.id != "") {
+			t.Errorf("ConfluencePage(%q) = %q, %q, %v; want %q, %q", c.in, base, id, ok, c.base, c.id)
+		}
+	}
+	if got := Name("https://acme.atlassian.net/wiki/spaces/ENG/pages/123456/Checkout+Plan"); got != "confluence:123456" {
+		t.Errorf("Name = %q", got)
+	}
+}
+
+func TestStorageToMarkdown(t *testing.T) {
+	storage := `<h2>Rollout&nbsp;steps</h2>
+<p>Run these <strong>in order</strong>.</p>
+<ol start="3"><li><p>Copy data</p><ol><li>Dump tables</li><li>Load them</li></ol></li><li>Switch <code>reads</code></li></ol>
+<ac:task-list><ac:task><ac:task-status>complete</ac:task-status><ac:task-body>Book window</ac:task-body></ac:task>
+<ac:task><ac:task-status>incomplete</ac:task-status><ac:task-body>Notify support</ac:task-body></ac:task></ac:task-list>
+<table><tbody><tr><th>Owner</th><th>Step</th></tr><tr><td>Ana</td><td>3</td></tr></tbody></table>
+<ac:structured-macro ac:name="code"><ac:parameter ac:name="language">bash</ac:parameter><ac:plain-text-body><![CDATA[pg_dump db | psql new
+echo done]]></ac:plain-text-body></ac:structured-macro>
+<p>See <ac:link><ri:page ri:content-title="Runbook" /></ac:link> first.<ac:image><ri:attachment ri:filename="x.png" /></ac:image></p>`
+	got, err := storageToMarkdown(storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "## Rollout steps\n\n" +
+		"Run these in order.\n\n" +
+		"3. Copy data\n   1. Dump tables\n   2. Load them\n4. Switch `reads`\n\n" +
+		"- [x] Book window\n- [ ] Notify support\n\n" +
+		"| Owner | Step |\n| --- | --- |\n| Ana | 3 |\n\n" +
+		"```\npg_dump db | psql new\necho done\n```\n\n" +
+		"See Runbook first.\n"
+	if got != want {
+		t.Errorf("storageToMarkdown =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestReadConfluence(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/wiki/rest/api/content/77" || r.URL.Query().Get("expand") != "body.storage" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"id":"77","title":"Checkout plan","body":{"storage":{"value":"<ol><li>Ship it</li></ol>"}}}`))
+	}))
+	defer srv.Close()
+
+	t.Setenv("CONFLUENCE_TOKEN", "secret")
+	t.Setenv("CONFLUENCE_EMAIL", "")
+	t.Setenv("CONFLUENCE_URL", srv.URL+"/wiki")
+	for _, path := range []string{"confluence:77", srv.URL + "/wiki/spaces/ENG/pages/77/Checkout+plan"} {
+		data, err := Read(path, Options{})
+		if err != nil {
+			t.Fatalf("Read(%q): %v", path, err)
+		}
+		if want := "# Checkout plan\n\n1. Ship it\n"; string(data) != want {
+			t.Errorf("Read(%q) = %q, want %q", path, data, want)
+		}
+	}
+
+	// Without a token the --url-header headers authenticate.
+	t.Setenv("CONFLUENCE_TOKEN", "")
+	o := Options{Headers: http.Header{"Authorization": {"Bearer secret"}}}
+	if _, err := Read("confluence:77", o); err != nil {
+		t.Errorf("Read with url headers: %v", err)
+	}
+	if _, err := Read("confluence:77", Options{}); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("error = %v, want 401", err)
+	}
+}
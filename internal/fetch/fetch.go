@@ -0,0 +1,255 @@
+// Package fetch reads plan and context inputs from local files, from
+// http(s) URLs, from Jira or GitHub issues, or from Confluence or
+// Notion pages, so documents kept in wikis, artifact stores, or
+// trackers can be reviewed without downloading them first.
+package fetch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxBytes bounds a document when Options.MaxBytes is zero. Plans and
+// context files are text; anything larger is almost certainly the
+// wrong file or URL.
+const MaxBytes = 16 << 20
+
+// binarySniffLen is how much of a document is checked for binary
+// content.
+const binarySniffLen = 8 << 10
+
+var (
+	// ErrTooLarge is returned, wrapped, for a document over the size
+	// limit.
+	ErrTooLarge = errors.New("document too large")
+	// ErrBinary is returned, wrapped, for a document that is not text.
+	ErrBinary = errors.New("not a text document")
+)
+
+// defaultTimeout bounds a URL request when Options.Timeout is zero.
+const defaultTimeout = 30 * time.Second
+
+// Options configures URL requests. The zero value sends no extra
+// headers and uses the environment's proxy settings.
+type Options struct {
+	// Headers are sent with every URL request, e.g. an Authorization
+	// header for a private wiki. Go's client drops Authorization and
+	// Cookie when a redirect leaves the original host.
+	Headers http.Header
+	// Transport replaces http.DefaultTransport.
+	Transport http.RoundTripper
+	// Timeout bounds each request; zero means 30s.
+	Timeout time.Duration
+	// GitHubLinked also reads the issues a GitHub issue or pull
+	// request closes (see GitHubIssue).
+	GitHubLinked bool
+	// MaxBytes bounds a file or URL document; zero means MaxBytes.
+	MaxBytes int64
+}
+
+// maxBytes returns the size limit o sets.
+func (o Options) maxBytes() int64 {
+	if o.MaxBytes > 0 {
+		return o.MaxBytes
+	}
+	return MaxBytes
+}
+
+// IsURL reports whether path is an http or https URL rather than a
+// file path.
+func IsURL(path string) bool {
+	lower := strings.ToLower(path)
+	return strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "http://")
+}
+
+// Name returns the name to show and record for path: the path itself
+// for a file, "jira:KEY" for a Jira issue, "gh:owner/repo#N" for a
+// GitHub issue or pull request, "confluence:ID" or "notion:ID" for a
+// Confluence or Notion page (by ID or URL), and for a URL the URL
+// without credentials, query, or fragment, so its base name is the
+// document's file name.
+func Name(path string) string {
+	if key, ok := JiraKey(path); ok {
+		return jiraPrefix + key
+	}
+	if ref, ok := GitHubIssue(path); ok {
+		return githubPrefix + ref.String()
+	}
+	if _, id, ok := ConfluencePage(path); ok {
+		return confluencePrefix + id
+	}
+	if id, ok := NotionPage(path); ok {
+		return notionPrefix + id
+	}
+	if !IsURL(path) {
+		return path
+	}
+	u, err := url.Parse(path)
+	if err != nil {
+		return path
+	}
+	u.User, u.RawQuery, u.Fragment, u.RawFragment = nil, "", "", ""
+	return u.String()
+}
+
+// Read returns the content of the file, URL, Jira issue (see JiraKey),
+// GitHub issue or pull request (see GitHubIssue), Confluence page (see
+// ConfluencePage), or Notion page (see NotionPage) at path. A URL's
+// fragment is not sent. A file or URL document larger than o's limit
+// fails with ErrTooLarge, and one that is not text (see CheckText)
+// with ErrBinary.
+func Read(path string, o Options) ([]byte, error) {
+	if key, ok := JiraKey(path); ok {
+		return readJira(key, o)
+	}
+	if ref, ok := GitHubIssue(path); ok {
+		return readGitHub(ref, o)
+	}
+	if base, id, ok := ConfluencePage(path); ok {
+		return readConfluence(base, id, o)
+	}
+	if id, ok := NotionPage(path); ok {
+		return readNotion(id, o)
+	}
+	if !IsURL(path) {
+		return readFile(path, o)
+	}
+	u, err := url.Parse(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	u.Fragment, u.RawFragment = "", ""
+	data, err := get(Name(path), u.String(), o.Headers, o)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckText(Name(path), data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// readFile reads a local file after checking its size, so a huge file
+// is refused without being read.
+func readFile(path string, o Options) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	limit := o.maxBytes()
+	if info, err := f.Stat(); err == nil && info.Mode().IsRegular() && info.Size() > limit {
+		return nil, fmt.Errorf("%s is %s, over the %s limit: %w", path, formatBytes(info.Size()), formatBytes(limit), ErrTooLarge)
+	}
+	data, err := io.ReadAll(io.LimitReader(f, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s is over the %s limit: %w", path, formatBytes(limit), ErrTooLarge)
+	}
+	if err := CheckText(path, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// CheckText returns an error wrapping ErrBinary when data does not look
+// like text: when its first 8 KiB hold a NUL byte, or are mostly not
+// UTF-8. UTF-16 text counts as binary, since its every other byte is
+// NUL.
+func CheckText(name string, data []byte) error {
+	head := data[:min(len(data), binarySniffLen)]
+	if bytes.IndexByte(head, 0) >= 0 {
+		return fmt.Errorf("%s contains NUL bytes (binary or UTF-16 file?): %w", name, ErrBinary)
+	}
+	invalid := 0
+	for len(head) > 0 {
+		r, size := utf8.DecodeRune(head)
+		if r == utf8.RuneError && size == 1 && len(head) >= utf8.UTFMax {
+			invalid++
+		}
+		head = head[size:]
+	}
+	// A few bad bytes are a Latin-1 accent or a truncated character;
+	// a tenth is a binary format.
+	if invalid*10 > min(len(data), binarySniffLen) {
+		return fmt.Errorf("%s is not UTF-8 text: %w", name, ErrBinary)
+	}
+	return nil
+}
+
+// formatBytes renders n in the largest binary unit it fills.
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
+
+// IsRemote reports whether path names a URL, a Jira or GitHub issue,
+// or a Confluence or Notion page rather than a local file.
+func IsRemote(path string) bool {
+	_, jira := JiraKey(path)
+	_, github := GitHubIssue(path)
+	_, _, confluence := ConfluencePage(path)
+	_, notion := NotionPage(path)
+	return jira || github || confluence || notion || IsURL(path)
+}
+
+// get fetches rawURL with headers, naming the document name in errors.
+func get(name, rawURL string, headers http.Header, o Options) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	for k, vs := range headers {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	timeout := o.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	client := &http.Client{Transport: o.Transport, Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("fetch %s: %s", name, resp.Status)
+	}
+	limit := o.maxBytes()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", name, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("fetch %s: document is over the %s limit: %w", name, formatBytes(limit), ErrTooLarge)
+	}
+	return data, nil
+}
+
+// ParseHeader parses a "Name: value" header as given on the command
+// line.
+func ParseHeader(s string) (string, string, error) {
+	name, value, ok := strings.Cut(s, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || strings.ContainsAny(name, " \t\r\n") || strings.ContainsAny(value, "\r\n") {
+		return "", "", fmt.Errorf("invalid header %q: want \"Name: value\"", s)
+	}
+	return http.CanonicalHeaderKey(name), strings.TrimSpace(value), nil
+}
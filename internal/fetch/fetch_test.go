@@ -0,0 +1,119 @@
+package fetch
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/docs/plan.md" || r.URL.Fragment != "" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("# Plan\n"))
+	}))
+	defer srv.Close()
+
+	o := Options{Headers: http.Header{"Authorization": {"Bearer secret"}}}
+	data, err := Read(srv.URL+"/docs/plan.md#Steps", o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "# Plan\n" {
+		t.Errorf("data = %q", data)
+	}
+
+	_, err = Read(srv.URL+"/docs/plan.md", Options{})
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("error = %v, want the 401 status", err)
+	}
+	if _, err := Read(srv.URL+"/missing.md", o); err == nil {
+		t.Error("expected error for 404")
+	}
+
+	path := filepath.Join(t.TempDir(), "plan.md")
+	if err := os.WriteFile(path, []byte("local"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := Read(path, o); err != nil || string(data) != "local" {
+		t.Errorf("Read(file) = %q, %v", data, err)
+	}
+}
+
+func TestReadLimits(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	big := write("big.md", []byte(strings.Repeat("word ", 100)))
+	if _, err := Read(big, Options{MaxBytes: 100}); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("oversized file: error = %v, want ErrTooLarge", err)
+	}
+	if _, err := Read(big, Options{MaxBytes: 1000}); err != nil {
+		t.Errorf("file under a raised limit: %v", err)
+	}
+
+	bin := write("plan.pdf", []byte("%PDF-1.7\n\x00\x01\x02stream"))
+	if _, err := Read(bin, Options{}); !errors.Is(err, ErrBinary) {
+		t.Errorf("binary file: error = %v, want ErrBinary", err)
+	}
+	latin1 := write("notes.md", []byte("Caf\xe9 menu rollout plan, with a few accented words\n"))
+	if _, err := Read(latin1, Options{}); err != nil {
+		t.Errorf("mostly-text file: %v", err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/image.png" {
+			w.Write([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"))
+			return
+		}
+		w.Write([]byte(strings.Repeat("x", 200)))
+	}))
+	defer srv.Close()
+	if _, err := Read(srv.URL+"/plan.md", Options{MaxBytes: 100}); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("oversized URL: error = %v, want ErrTooLarge", err)
+	}
+	if _, err := Read(srv.URL+"/image.png", Options{}); !errors.Is(err, ErrBinary) {
+		t.Errorf("binary URL: error = %v, want ErrBinary", err)
+	}
+}
+
+func TestName(t *testing.T) {
+	cases := map[string]string{
+		"plans/plan.md": "plans/plan.md",
+		"https://user:pw@wiki.example.com/a/plan.md?raw=1#Steps": "https://wiki.example.com/a/plan.md",
+		"HTTP://example.com/plan.md":                             "http://example.com/plan.md",
+	}
+	for in, want := range cases {
+		if got := Name(in); got != want {
+			t.Errorf("Name(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	name, value, err := ParseHeader("private-token:  abc ")
+	if err != nil || name != "Private-Token" || value != "abc" {
+		t.Errorf("ParseHeader = %q, %q, %v", name, value, err)
+	}
+	for _, bad := range []string{"no colon", ": value", "Bad Name: x", "X: a\r\nY: b"} {
+		if _, _, err := ParseHeader(bad); err == nil {
+			t.Errorf("ParseHeader(%q): expected error", bad)
+		}
+	}
+}
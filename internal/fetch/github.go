@@ -0,0 +1,177 @@
+package fetch
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// githubPrefix marks a plan or context path as a GitHub issue or pull
+// request, as in "gh:owner/repo#123".
+const githubPrefix = "gh:"
+
+// maxGitHubLinked bounds how many linked issues are fetched, one
+// request each.
+const maxGitHubLinked = 10
+
+var (
+	// githubRefPattern matches "owner/repo#123".
+	githubRefPattern = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9-]*)/([A-Za-z0-9._-]+)#([0-9]+)$`)
+	// githubURLPath matches the path of a github.com issue or pull
+	// request page, with or without a tab such as /files.
+	githubURLPath = regexp.MustCompile(`^/([A-Za-z0-9][A-Za-z0-9-]*)/([A-Za-z0-9._-]+)/(?:issues|pull)/([0-9]+)(?:/.*)?$`)
+	// githubClosingPattern matches a closing reference in an issue or
+	// pull request body, as in "Fixes #12" or "closes acme/api#7": the
+	// references GitHub lists as linked issues.
+	githubClosingPattern = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+([A-Za-z0-9][A-Za-z0-9-]*/[A-Za-z0-9._-]+)?#([0-9]+)\b`)
+)
+
+// GitHubRef names an issue or pull request. GitHub numbers both in one
+// sequence per repository, and its issues API serves either.
+type GitHubRef struct {
+	Owner  string
+	Repo   string
+	Number int
+}
+
+func (r GitHubRef) String() string {
+	return fmt.Sprintf("%s/%s#%d", r.Owner, r.Repo, r.Number)
+}
+
+// GitHubIssue reports whether path names a GitHub issue or pull
+// request, as "gh:owner/repo#123" or as a github.com issue or pull
+// request URL.
+func GitHubIssue(path string) (GitHubRef, bool) {
+	var m []string
+	switch {
+	case len(path) > len(githubPrefix) && strings.EqualFold(path[:len(githubPrefix)], githubPrefix):
+		m = githubRefPattern.FindStringSubmatch(path[len(githubPrefix):])
+	case IsURL(path):
+		u, err := url.Parse(path)
+		if err != nil {
+			return GitHubRef{}, false
+		}
+		if host := strings.ToLower(u.Hostname()); host != "github.com" && host != "www.github.com" {
+			return GitHubRef{}, false
+		}
+		m = githubURLPath.FindStringSubmatch(u.Path)
+	}
+	if m == nil {
+		return GitHubRef{}, false
+	}
+	n, err := strconv.Atoi(m[3])
+	if err != nil || n <= 0 {
+		return GitHubRef{}, false
+	}
+	return GitHubRef{Owner: m[1], Repo: m[2], Number: n}, true
+}
+
+// githubIssue is the part of a GitHub REST API issue a review reads.
+type githubIssue struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	State string `json:"state"`
+}
+
+// readGitHub fetches the issue or pull request ref from the GitHub API
+// at GITHUB_API_URL (https://api.github.com by default) and renders it
+// as Markdown: the title as a heading, then the body. With
+// o.GitHubLinked, the issues its body closes ("Fixes #12") follow in a
+// section each; past the first 10 they are listed but not fetched.
+// GITHUB_TOKEN, when set, authenticates; public repositories need none.
+func readGitHub(ref GitHubRef, o Options) ([]byte, error) {
+	api := strings.TrimRight(os.Getenv("GITHUB_API_URL"), "/")
+	if api == "" {
+		api = "https://api.github.com"
+	}
+	token := os.Getenv("GITHUB_TOKEN")
+	headers := http.Header{
+		"Accept":               {"application/vnd.github+json"},
+		"X-Github-Api-Version": {"2022-11-28"},
+	}
+	if token != "" {
+		headers.Set("Authorization", "Bearer "+token)
+	}
+	fetchIssue := func(ref GitHubRef) (*githubIssue, error) {
+		u := fmt.Sprintf("%s/repos/%s/%s/issues/%d", api, url.PathEscape(ref.Owner), url.PathEscape(ref.Repo), ref.Number)
+		data, err := get(githubPrefix+ref.String(), u, headers, o)
+		if err != nil {
+			if token == "" {
+				return nil, fmt.Errorf("%w (set GITHUB_TOKEN to read private repositories)", err)
+			}
+			return nil, err
+		}
+		var issue githubIssue
+		if err := json.Unmarshal(data, &issue); err != nil {
+			return nil, fmt.Errorf("fetch %s%s: invalid GitHub response: %w", githubPrefix, ref, err)
+		}
+		return &issue, nil
+	}
+
+	issue, err := fetchIssue(ref)
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s: %s\n", ref, issue.Title)
+	writeGitHubBody(&b, issue)
+	if !o.GitHubLinked {
+		return []byte(b.String()), nil
+	}
+	linked := githubClosed(ref, issue.Body)
+	if len(linked) > 0 {
+		b.WriteString("\n## Linked issues\n")
+	}
+	for i, l := range linked {
+		if i >= maxGitHubLinked {
+			fmt.Fprintf(&b, "\n### %s\n\n(not fetched: more than %d linked issues)\n", l, maxGitHubLinked)
+			continue
+		}
+		li, err := fetchIssue(l)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&b, "\n### %s: %s", l, li.Title)
+		if li.State != "" {
+			fmt.Fprintf(&b, " (%s)", li.State)
+		}
+		b.WriteString("\n")
+		writeGitHubBody(&b, li)
+	}
+	return []byte(b.String()), nil
+}
+
+// githubClosed returns the issues body closes, in order and without
+// repeats; a bare "#12" is in ref's repository.
+func githubClosed(ref GitHubRef, body string) []GitHubRef {
+	var out []GitHubRef
+	seen := map[string]bool{ref.String(): true}
+	for _, m := range githubClosingPattern.FindAllStringSubmatch(body, -1) {
+		l := GitHubRef{Owner: ref.Owner, Repo: ref.Repo}
+		if owner, repo, ok := strings.Cut(m[1], "/"); ok {
+			l.Owner, l.Repo = owner, repo
+		}
+		n, err := strconv.Atoi(m[2])
+		if err != nil || n <= 0 {
+			continue
+		}
+		l.Number = n
+		if !seen[l.String()] {
+			seen[l.String()] = true
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// writeGitHubBody writes the issue's Markdown body after a blank line.
+func writeGitHubBody(b *strings.Builder, issue *githubIssue) {
+	if body := strings.TrimSpace(strings.ReplaceAll(issue.Body, "\r\n", "\n")); body != "" {
+		fmt.Fprintf(b, "\n%s\n", body)
+	}
+}
@@ -0,0 +1,94 @@
+package fetch
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGitHubIssue(t *testing.T) {
+	cases := map[string]string{
+		"gh:acme/api#123":                               "acme/api#123",
+		"GH:acme/web.app#7":                             "acme/web.app#7",
+		"https://github.com/acme/api/pull/45":           "acme/api#45",
+		"https://github.com/acme/api/pull/45/files":     "acme/api#45",
+		"https://github.com/acme/api/issues/9":          "acme/api#9",
+		"gh:acme/api":                                   "",
+		"gh:acme#1":                                     "",
+		"https://github.com/acme/api/blob/main/plan.md": "",
+		"https://example.com/acme/api/pull/45":          "",
+		"plans/plan.md":                                 "",
+	}
+	for in, want := range cases {
+		got, ok := GitHubIssue(in)
+		if ok != (want != "") || (ok && got.String() != want) {
+			t.Errorf("GitHubIssue(%q) = %v, %v; want %q", in, got, ok, want)
+		}
+	}
+	if got := Name("https://github.com/acme/api/pull/45"); got != "gh:acme/api#45" {
+		t.Errorf("Name = %q", got)
+	}
+}
+
+func TestGitHubClosed(t *testing.T) {
+	ref := GitHubRef{Owner: "acme", Repo: "api", Number: 45}
+	body := "Fixes #12 and closes acme/web#3.\nResolves: #12. Mentions #8. Refs #45, fixes #45."
+	var got []string
+	for _, l := range githubClosed(ref, body) {
+		got = append(got, l.String())
+	}
+	if strings.Join(got, " ") != "acme/api#12 acme/web#3" {
+		t.Errorf("closed = %v", got)
+	}
+}
+
+func TestReadGitHub(t *testing.T) {
+	issues := map[string]string{
+		"/repos/acme/api/issues/45": `{"title":"Migrate billing","body":"## Steps\r\n1. Copy data\r\n2. Switch reads\r\n\r\nCloses #12","state":"open","pull_request":{}}`,
+		"/repos/acme/api/issues/12": `{"title":"Billing is slow","body":"Reads time out.","state":"open"}`,
+	}
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		body, ok := issues[strings.TrimPrefix(r.URL.Path, "/api/v3")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	t.Setenv("GITHUB_API_URL", srv.URL+"/api/v3/")
+	t.Setenv("GITHUB_TOKEN", "")
+	data, err := Read("gh:acme/api#45", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# acme/api#45: Migrate billing\n\n## Steps\n1. Copy data\n2. Switch reads\n\nCloses #12\n"
+	if string(data) != want {
+		t.Errorf("data = %q, want %q", data, want)
+	}
+	if auth != "" {
+		t.Errorf("Authorization = %q without GITHUB_TOKEN", auth)
+	}
+
+	t.Setenv("GITHUB_TOKEN", "secret")
+	data, err = Read("https://github.com/acme/api/pull/45", Options{GitHubLinked: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want += "\n## Linked issues\n\n### acme/api#12: Billing is slow (open)\n\nReads time out.\n"
+	if string(data) != want {
+		t.Errorf("data = %q, want %q", data, want)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("Authorization = %q", auth)
+	}
+
+	t.Setenv("GITHUB_TOKEN", "")
+	if _, err := Read("gh:acme/api#9", Options{}); err == nil || !strings.Contains(err.Error(), "gh:acme/api#9: 404") || !strings.Contains(err.Error(), "GITHUB_TOKEN") {
+		t.Errorf("error = %v, want a 404 naming the issue and the token", err)
+	}
+}
@@ -0,0 +1,121 @@
+package fetch
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// jiraPrefix marks a plan or context path as a Jira issue key, as in
+// "jira:PROJ-123".
+const jiraPrefix = "jira:"
+
+// maxJiraSubtasks bounds how many subtasks of an issue are fetched, one
+// request each.
+const maxJiraSubtasks = 50
+
+// jiraKeyPattern matches a Jira issue key: a project key and a number.
+var jiraKeyPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*-[0-9]+$`)
+
+// JiraKey returns the issue key path names when it has the form
+// "jira:PROJ-123", upper-cased as Jira shows it.
+func JiraKey(path string) (string, bool) {
+	if len(path) < len(jiraPrefix) || !strings.EqualFold(path[:len(jiraPrefix)], jiraPrefix) {
+		return "", false
+	}
+	key := path[len(jiraPrefix):]
+	if !jiraKeyPattern.MatchString(key) {
+		return "", false
+	}
+	return strings.ToUpper(key), true
+}
+
+// jiraIssue is the part of a Jira REST API v2 issue a review reads.
+type jiraIssue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Summary     string `json:"summary"`
+		Description string `json:"description"`
+		Status      struct {
+			Name string `json:"name"`
+		} `json:"status"`
+		Subtasks []struct {
+			Key string `json:"key"`
+		} `json:"subtasks"`
+	} `json:"fields"`
+}
+
+// readJira fetches the issue key and its subtasks from the Jira server
+// at JIRA_URL and renders them as one Markdown document: the issue's
+// summary and description, then a section per subtask. JIRA_TOKEN
+// authenticates as a bearer token (a Jira Data Center personal access
+// token), or with JIRA_EMAIL set, as a Jira Cloud API token. Subtasks
+// past the first 50 are listed but not fetched.
+func readJira(key string, o Options) ([]byte, error) {
+	base := strings.TrimRight(os.Getenv("JIRA_URL"), "/")
+	token := os.Getenv("JIRA_TOKEN")
+	if base == "" || token == "" {
+		return nil, fmt.Errorf("fetch %s%s: set JIRA_URL and JIRA_TOKEN to read Jira issues", jiraPrefix, key)
+	}
+	auth := "Bearer " + token
+	if email := os.Getenv("JIRA_EMAIL"); email != "" {
+		auth = "Basic " + base64.StdEncoding.EncodeToString([]byte(email+":"+token))
+	}
+	headers := http.Header{"Authorization": {auth}, "Accept": {"application/json"}}
+	fetchIssue := func(key string) (*jiraIssue, error) {
+		u := base + "/rest/api/2/issue/" + url.PathEscape(key) + "?fields=summary,description,status,subtasks"
+		data, err := get(jiraPrefix+key, u, headers, o)
+		if err != nil {
+			return nil, err
+		}
+		var issue jiraIssue
+		if err := json.Unmarshal(data, &issue); err != nil {
+			return nil, fmt.Errorf("fetch %s%s: invalid Jira response: %w", jiraPrefix, key, err)
+		}
+		if issue.Key == "" {
+			issue.Key = key
+		}
+		return &issue, nil
+	}
+
+	issue, err := fetchIssue(key)
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s: %s\n", issue.Key, issue.Fields.Summary)
+	writeJiraDescription(&b, issue)
+	if len(issue.Fields.Subtasks) > 0 {
+		b.WriteString("\n## Subtasks\n")
+	}
+	for i, st := range issue.Fields.Subtasks {
+		if i >= maxJiraSubtasks {
+			fmt.Fprintf(&b, "\n### %s\n\n(not fetched: more than %d subtasks)\n", st.Key, maxJiraSubtasks)
+			continue
+		}
+		sub, err := fetchIssue(st.Key)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&b, "\n### %s: %s", sub.Key, sub.Fields.Summary)
+		if sub.Fields.Status.Name != "" {
+			fmt.Fprintf(&b, " (%s)", sub.Fields.Status.Name)
+		}
+		b.WriteString("\n")
+		writeJiraDescription(&b, sub)
+	}
+	return []byte(b.String()), nil
+}
+
+// writeJiraDescription writes the issue's description, in Jira's own
+// markup, after a blank line.
+func writeJiraDescription(b *strings.Builder, issue *jiraIssue) {
+	if d := strings.TrimSpace(strings.ReplaceAll(issue.Fields.Description, "\r\n", "\n")); d != "" {
+		fmt.Fprintf(b, "\n%s\n", d)
+	}
+}
@@ -0,0 +1,71 @@
+package fetch
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestJiraKey(t *testing.T) {
+	cases := map[string]string{
+		"jira:PROJ-123": "PROJ-123",
+		"JIRA:proj-7":   "PROJ-7",
+		"jira:PROJ":     "",
+		"jira:123":      "",
+		"plans/plan.md": "",
+	}
+	for in, want := range cases {
+		got, ok := JiraKey(in)
+		if got != want || ok != (want != "") {
+			t.Errorf("JiraKey(%q) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+	if got := Name("jira:proj-7"); got != "jira:PROJ-7" {
+		t.Errorf("Name = %q", got)
+	}
+}
+
+func TestReadJira(t *testing.T) {
+	issues := map[string]string{
+		"PROJ-1": `{"key":"PROJ-1","fields":{"summary":"Migrate billing","description":"h2. Steps\r\n# Copy data\r\n# Switch reads","subtasks":[{"key":"PROJ-2"}]}}`,
+		"PROJ-2": `{"key":"PROJ-2","fields":{"summary":"Copy data","description":"Use pg_dump.","status":{"name":"In Progress"}}}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		body, ok := issues[strings.TrimPrefix(r.URL.Path, "/jira/rest/api/2/issue/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	t.Setenv("JIRA_URL", srv.URL+"/jira/")
+	t.Setenv("JIRA_TOKEN", "secret")
+	t.Setenv("JIRA_EMAIL", "")
+	data, err := Read("jira:proj-1", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# PROJ-1: Migrate billing\n\nh2. Steps\n# Copy data\n# Switch reads\n\n## Subtasks\n\n### PROJ-2: Copy data (In Progress)\n\nUse pg_dump.\n"
+	if string(data) != want {
+		t.Errorf("data = %q, want %q", data, want)
+	}
+
+	if _, err := Read("jira:PROJ-9", Options{}); err == nil || !strings.Contains(err.Error(), "jira:PROJ-9: 404") {
+		t.Errorf("error = %v, want a 404 naming the issue", err)
+	}
+	t.Setenv("JIRA_EMAIL", "me@example.com")
+	if _, err := Read("jira:PROJ-1", Options{}); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("error = %v, want basic auth rejected", err)
+	}
+	t.Setenv("JIRA_TOKEN", "")
+	if _, err := Read("jira:PROJ-1", Options{}); err == nil || !strings.Contains(err.Error(), "JIRA_TOKEN") {
+		t.Errorf("error = %v, want missing credentials", err)
+	}
+}
@@ -0,0 +1,352 @@
+package fetch
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// notionPrefix marks a plan or context path as a Notion page ID, as in
+// "notion:0123456789abcdef0123456789abcdef".
+const notionPrefix = "notion:"
+
+// notionVersion is the Notion API version the block types below follow.
+const notionVersion = "2022-06-28"
+
+// maxNotionRequests bounds the API requests one page may take: one for
+// the page, then one per 100 blocks and per block with children.
+const maxNotionRequests = 500
+
+// notionAPI is the Notion API base URL; tests point it at a fake.
+var notionAPI = "https://api.notion.com"
+
+// notionIDPattern matches a Notion page ID, with or without the dashes
+// of its UUID form, at the end of a string.
+var notionIDPattern = regexp.MustCompile(`(?i)([0-9a-f]{8})-?([0-9a-f]{4})-?([0-9a-f]{4})-?([0-9a-f]{4})-?([0-9a-f]{12})$`)
+
+// NotionPage reports whether path names a Notion page, as "notion:ID"
+// or as a notion.so or notion.site page URL, whose last path segment
+// ends in the page ID ("Rollout-plan-0123...cdef"). It returns the ID
+// in its dashed UUID form.
+func NotionPage(path string) (string, bool) {
+	var ref string
+	titled := false
+	switch {
+	case len(path) > len(notionPrefix) && strings.EqualFold(path[:len(notionPrefix)], notionPrefix):
+		ref = path[len(notionPrefix):]
+	case IsURL(path):
+		titled = true
+		u, err := url.Parse(path)
+		if err != nil {
+			return "", false
+		}
+		host := strings.ToLower(u.Hostname())
+		if host != "notion.so" && !strings.HasSuffix(host, ".notion.so") && !strings.HasSuffix(host, ".notion.site") {
+			return "", false
+		}
+		ref = u.Path[strings.LastIndex(u.Path, "/")+1:]
+	default:
+		return "", false
+	}
+	m := notionIDPattern.FindStringSubmatch(ref)
+	if m == nil {
+		return "", false
+	}
+	// The ID is the whole reference, or in a URL follows the dash after
+	// the page title.
+	if rest := ref[:len(ref)-len(m[0])]; rest != "" && !(titled && strings.HasSuffix(rest, "-")) {
+		return "", false
+	}
+	return strings.ToLower(strings.Join(m[1:], "-")), true
+}
+
+// notionText is one run of Notion rich text.
+type notionText struct {
+	PlainText   string `json:"plain_text"`
+	Annotations struct {
+		Code bool `json:"code"`
+	} `json:"annotations"`
+}
+
+// notionBlock is the part of a Notion block a review reads. The API
+// nests a block's content under its type's name; UnmarshalJSON lifts
+// it into Content.
+type notionBlock struct {
+	ID          string
+	Type        string
+	HasChildren bool
+	Content     struct {
+		RichText []notionText   `json:"rich_text"`
+		Checked  bool           `json:"checked"`
+		Language string         `json:"language"`
+		Title    string         `json:"title"`
+		Cells    [][]notionText `json:"cells"`
+		URL      string         `json:"url"`
+	}
+}
+
+func (b *notionBlock) UnmarshalJSON(data []byte) error {
+	var head struct {
+		ID          string `json:"id"`
+		Type        string `json:"type"`
+		HasChildren bool   `json:"has_children"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return err
+	}
+	b.ID, b.Type, b.HasChildren = head.ID, head.Type, head.HasChildren
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if raw, ok := fields[head.Type]; ok {
+		return json.Unmarshal(raw, &b.Content)
+	}
+	return nil
+}
+
+// notionListItems are the block types rendered as list items: they sit
+// on consecutive lines and indent their children.
+var notionListItems = map[string]bool{
+	"bulleted_list_item": true,
+	"numbered_list_item": true,
+	"to_do":              true,
+	"toggle":             true,
+}
+
+// notionReader fetches one page's blocks, counting requests against
+// maxNotionRequests.
+type notionReader struct {
+	name     string
+	headers  http.Header
+	o        Options
+	requests int
+}
+
+// readNotion fetches page id and its child blocks from the Notion API
+// and renders them as Markdown: the page title as a heading, then the
+// blocks in order. Headings, lists (numbered, bulleted, to-do, and
+// toggles), quotes, callouts, code, and tables keep their structure,
+// and nested blocks are indented under their parent. Child pages and
+// databases are named but not fetched. NOTION_TOKEN is an integration
+// token the page has been shared with.
+func readNotion(id string, o Options) ([]byte, error) {
+	name := notionPrefix + id
+	token := os.Getenv("NOTION_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("fetch %s: set NOTION_TOKEN to read Notion pages", name)
+	}
+	r := &notionReader{
+		name: name,
+		headers: http.Header{
+			"Authorization":  {"Bearer " + token},
+			"Notion-Version": {notionVersion},
+			"Accept":         {"application/json"},
+		},
+		o: o,
+	}
+
+	var page struct {
+		Properties map[string]struct {
+			Type  string       `json:"type"`
+			Title []notionText `json:"title"`
+		} `json:"properties"`
+	}
+	if err := r.get("/v1/pages/"+id, &page); err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	for _, p := range page.Properties {
+		if p.Type == "title" {
+			if title := notionPlain(p.Title); title != "" {
+				fmt.Fprintf(&b, "# %s\n\n", title)
+			}
+			break
+		}
+	}
+	if err := r.render(&b, id, ""); err != nil {
+		return nil, err
+	}
+	out := strings.TrimSpace(b.String())
+	for strings.Contains(out, "\n\n\n") {
+		out = strings.ReplaceAll(out, "\n\n\n", "\n\n")
+	}
+	return []byte(out + "\n"), nil
+}
+
+// get decodes the JSON response to the API path into v.
+func (r *notionReader) get(path string, v any) error {
+	if r.requests >= maxNotionRequests {
+		return fmt.Errorf("fetch %s: page needs more than %d Notion API requests", r.name, maxNotionRequests)
+	}
+	r.requests++
+	data, err := get(r.name, strings.TrimRight(notionAPI, "/")+path, r.headers, r.o)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("fetch %s: invalid Notion response: %w", r.name, err)
+	}
+	return nil
+}
+
+// children returns every child block of parent, following pagination.
+func (r *notionReader) children(parent string) ([]notionBlock, error) {
+	var blocks []notionBlock
+	cursor := ""
+	for {
+		path := "/v1/blocks/" + parent + "/children?page_size=100"
+		if cursor != "" {
+			path += "&start_cursor=" + url.QueryEscape(cursor)
+		}
+		var resp struct {
+			Results    []notionBlock `json:"results"`
+			HasMore    bool          `json:"has_more"`
+			NextCursor string        `json:"next_cursor"`
+		}
+		if err := r.get(path, &resp); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, resp.Results...)
+		if !resp.HasMore || resp.NextCursor == "" {
+			return blocks, nil
+		}
+		cursor = resp.NextCursor
+	}
+}
+
+// render writes the child blocks of parent, each line after indent.
+// Blocks are separated by a blank line, except consecutive list items.
+func (r *notionReader) render(b *strings.Builder, parent, indent string) error {
+	blocks, err := r.children(parent)
+	if err != nil {
+		return err
+	}
+	n, prevItem := 0, false
+	for i, blk := range blocks {
+		item := notionListItems[blk.Type]
+		if i > 0 && !(item && prevItem) {
+			b.WriteString("\n")
+		}
+		prevItem = item
+		if blk.Type == "numbered_list_item" {
+			n++
+		} else {
+			n = 0
+		}
+
+		text := notionPlain(blk.Content.RichText)
+		line := func(s string) {
+			fmt.Fprintf(b, "%s%s\n", indent, s)
+		}
+		switch blk.Type {
+		case "heading_1", "heading_2", "heading_3":
+			// The page title is the only level-one heading.
+			level := int(blk.Type[len(blk.Type)-1]-'0') + 1
+			line(strings.Repeat("#", level) + " " + text)
+		case "bulleted_list_item", "toggle":
+			line("- " + text)
+		case "numbered_list_item":
+			line(fmt.Sprintf("%d. %s", n, text))
+		case "to_do":
+			box := "[ ] "
+			if blk.Content.Checked {
+				box = "[x] "
+			}
+			line("- " + box + text)
+		case "quote", "callout":
+			for _, l := range strings.Split(text, "\n") {
+				line("> " + l)
+			}
+		case "code":
+			var raw strings.Builder
+			for _, t := range blk.Content.RichText {
+				raw.WriteString(t.PlainText)
+			}
+			line("```" + strings.ReplaceAll(blk.Content.Language, " ", "-"))
+			for _, l := range strings.Split(raw.String(), "\n") {
+				line(l)
+			}
+			line("```")
+		case "divider":
+			line("---")
+		case "child_page", "child_database":
+			line(fmt.Sprintf("(%s: %s)", strings.ReplaceAll(blk.Type, "_", " "), blk.Content.Title))
+			continue
+		case "bookmark", "embed", "link_preview":
+			if blk.Content.URL != "" {
+				line(blk.Content.URL)
+			}
+		case "table":
+			if err := r.table(b, blk.ID, indent); err != nil {
+				return err
+			}
+			continue
+		default:
+			// Paragraphs, and any type with text this reader does not
+			// know, as plain text.
+			if text != "" {
+				for _, l := range strings.Split(text, "\n") {
+					line(l)
+				}
+			}
+		}
+		if blk.HasChildren {
+			child := indent
+			if item {
+				child += "   "
+			}
+			if err := r.render(b, blk.ID, child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// table writes the rows of table block id as a pipe table.
+func (r *notionReader) table(b *strings.Builder, id, indent string) error {
+	blocks, err := r.children(id)
+	if err != nil {
+		return err
+	}
+	var rows [][]string
+	for _, blk := range blocks {
+		if blk.Type != "table_row" {
+			continue
+		}
+		var row []string
+		for _, cell := range blk.Content.Cells {
+			row = append(row, strings.ReplaceAll(notionPlain(cell), "\n", " "))
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	var t strings.Builder
+	writePipeTable(&t, rows)
+	for _, l := range strings.SplitAfter(strings.TrimSuffix(t.String(), "\n"), "\n") {
+		b.WriteString(indent + l)
+	}
+	b.WriteString("\n")
+	return nil
+}
+
+// notionPlain joins rich text as plain text, with inline code in
+// backticks.
+func notionPlain(texts []notionText) string {
+	var b strings.Builder
+	for _, t := range texts {
+		if t.Annotations.Code {
+			b.WriteString("`" + t.PlainText + "`")
+		} else {
+			b.WriteString(t.PlainText)
+		}
+	}
+	return b.String()
+}
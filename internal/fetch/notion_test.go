@@ -0,0 +1,95 @@
+package fetch
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNotionPage(t *testing.T) {
+	const id = "0123abcd-4567-89ab-cdef-0123456789ab"
+	cases := map[string]string{
+		"notion:0123abcd456789abcdef0123456789ab": id,
+		"Notion:" + id: id,
+		"https://www.notion.so/acme/Rollout-plan-0123abcd456789abcdef0123456789ab": id,
+		"https://acme.notion.site/0123ABCD456789ABCDEF0123456789AB?pvs=4":          id,
+		"notion:Rollout-0123abcd456789abcdef0123456789ab":                          "",
+		"https://example.com/Rollout-0123abcd456789abcdef0123456789ab":             "",
+		"https://www.notion.so/acme/Rollout-plan":                                  "",
+		"plans/plan.md": "",
+	}
+	for in, want := range cases {
+		got, ok := NotionPage(in)
+		if got != want || ok != (want != "") {
+			t.Errorf("NotionPage(%q) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+	if got := Name("https://www.notion.so/acme/Rollout-0123abcd456789abcdef0123456789ab"); got != "notion:"+id {
+		t.Errorf("Name = %q", got)
+	}
+}
+
+func TestReadNotion(t *testing.T) {
+	const id = "0123abcd-4567-89ab-cdef-0123456789ab"
+	responses := map[string]string{
+		"/v1/pages/" + id: `{"properties":{"Tags":{"type":"multi_select"},"Name":{"type":"title","title":[{"plain_text":"Rollout plan"}]}}}`,
+		"/v1/blocks/" + id + "/children": `{"results":[
+			{"id":"h","type":"heading_1","heading_1":{"rich_text":[{"plain_text":"Steps"}]}},
+			{"id":"s1","type":"numbered_list_item","has_children":true,"numbered_list_item":{"rich_text":[{"plain_text":"Copy data"}]}},
+			{"id":"s2","type":"numbered_list_item","numbered_list_item":{"rich_text":[{"plain_text":"Switch "},{"plain_text":"reads","annotations":{"code":true}}]}}
+		],"has_more":true,"next_cursor":"c2"}`,
+		"/v1/blocks/" + id + "/children?c2": `{"results":[
+			{"id":"d","type":"to_do","to_do":{"rich_text":[{"plain_text":"Book window"}],"checked":true}},
+			{"id":"t","type":"table","has_children":true,"table":{}},
+			{"id":"c","type":"code","code":{"rich_text":[{"plain_text":"pg_dump db\necho done"}],"language":"shell"}},
+			{"id":"p","type":"child_page","has_children":true,"child_page":{"title":"Runbook"}},
+			{"id":"x","type":"paragraph","paragraph":{"rich_text":[{"plain_text":"Ask #ops first."}]}}
+		]}`,
+		"/v1/blocks/s1/children": `{"results":[{"id":"s1a","type":"bulleted_list_item","bulleted_list_item":{"rich_text":[{"plain_text":"Dump tables"}]}}]}`,
+		"/v1/blocks/t/children": `{"results":[
+			{"id":"r1","type":"table_row","table_row":{"cells":[[{"plain_text":"Owner"}],[{"plain_text":"Step"}]]}},
+			{"id":"r2","type":"table_row","table_row":{"cells":[[{"plain_text":"Ana"}],[{"plain_text":"1"}]]}}
+		]}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("Notion-Version") == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		key := r.URL.Path
+		if c := r.URL.Query().Get("start_cursor"); c != "" {
+			key += "?" + c
+		}
+		body, ok := responses[key]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+	defer func(api string) { notionAPI = api }(notionAPI)
+	notionAPI = srv.URL
+
+	t.Setenv("NOTION_TOKEN", "secret")
+	data, err := Read("notion:"+strings.ReplaceAll(id, "-", ""), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Rollout plan\n\n" +
+		"## Steps\n\n" +
+		"1. Copy data\n   - Dump tables\n2. Switch `reads`\n- [x] Book window\n\n" +
+		"| Owner | Step |\n| --- | --- |\n| Ana | 1 |\n\n" +
+		"```shell\npg_dump db\necho done\n```\n\n" +
+		"(child page: Runbook)\n\n" +
+		"Ask #ops first.\n"
+	if string(data) != want {
+		t.Errorf("data =\n%s\nwant\n%s", data, want)
+	}
+
+	t.Setenv("NOTION_TOKEN", "")
+	if _, err := Read("notion:"+id, Options{}); err == nil || !strings.Contains(err.Error(), "NOTION_TOKEN") {
+		t.Errorf("error = %v, want missing credentials", err)
+	}
+}
@@ -0,0 +1,133 @@
+// Package git reads earlier revisions and the provenance of a plan from
+// the git repository it lives in, by running the git command.
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotRepo is returned for a file outside any git work tree.
+var ErrNotRepo = errors.New("not in a git repository")
+
+// Show returns the content of the file at path as of revision rev
+// ("HEAD~1", a branch, a tag, or a commit).
+func Show(path, rev string) (string, error) {
+	if rev == "" || strings.HasPrefix(rev, "-") {
+		return "", fmt.Errorf("invalid revision %q", rev)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	// "rev:./name" resolves name against the directory git runs in,
+	// wherever that is in the work tree.
+	return run(filepath.Dir(abs), "show", rev+":./"+filepath.Base(abs))
+}
+
+// Provenance is the git revision a file was read at.
+type Provenance struct {
+	// Commit is the full SHA of HEAD; empty before the first commit.
+	Commit string
+	// Branch is the checked-out branch; empty for a detached HEAD.
+	Branch string
+	// Dirty reports that the file differs from Commit: it has
+	// uncommitted (staged or unstaged) changes or is untracked.
+	Dirty bool
+}
+
+// Describe returns the provenance of the file at path. It returns an
+// error wrapping ErrNotRepo for a file outside any git work tree.
+func Describe(path string) (*Provenance, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	dir, name := filepath.Dir(abs), filepath.Base(abs)
+	if _, err := run(dir, "rev-parse", "--git-dir"); err != nil {
+		return nil, err
+	}
+	var pv Provenance
+	// Both fail harmlessly in a repository without commits or on a
+	// detached HEAD, leaving the field empty.
+	if out, err := run(dir, "rev-parse", "--verify", "-q", "HEAD"); err == nil {
+		pv.Commit = strings.TrimSpace(out)
+	}
+	if out, err := run(dir, "symbolic-ref", "-q", "--short", "HEAD"); err == nil {
+		pv.Branch = strings.TrimSpace(out)
+	}
+	out, err := run(dir, "status", "--porcelain", "--untracked-files=all", "--", name)
+	if err != nil {
+		return nil, err
+	}
+	pv.Dirty = strings.TrimSpace(out) != ""
+	return &pv, nil
+}
+
+// Location is where a file sits in its repository, named the same way
+// in every clone.
+type Location struct {
+	// Repo is the URL of the origin remote without credentials, or the
+	// work tree's absolute path when there is no origin.
+	Repo string
+	// Path is the file's slash-separated path from the work tree root.
+	Path string
+}
+
+// Locate returns the location of the file at path, which need not
+// exist yet. It returns an error wrapping ErrNotRepo for a file outside
+// any git work tree.
+func Locate(path string) (*Location, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(abs)
+	// The prefix is the directory's path from the root, so symlinks in
+	// abs do not matter.
+	prefix, err := run(dir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, err
+	}
+	loc := &Location{Path: strings.TrimSpace(prefix) + filepath.Base(abs)}
+	if out, err := run(dir, "remote", "get-url", "origin"); err == nil {
+		loc.Repo = strings.TrimSpace(out)
+		if u, err := url.Parse(loc.Repo); err == nil && u.User != nil {
+			u.User = nil
+			loc.Repo = u.String()
+		}
+		return loc, nil
+	}
+	top, err := run(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	loc.Repo = strings.TrimSpace(top)
+	return loc, nil
+}
+
+// run runs git in dir and returns its standard output.
+func run(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", fmt.Errorf("git is not installed: %w", err)
+		}
+		msg := strings.TrimSpace(stderr.String())
+		if strings.Contains(msg, "not a git repository") {
+			return "", fmt.Errorf("%s: %w", dir, ErrNotRepo)
+		}
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return stdout.String(), nil
+}
@@ -18,11 +18,15 @@ func projectRoot() string {
 	return filepath.Dir(filepath.Dir(filename))
 }
 
-func TestGoldenSimpleReview(t *testing.T) {
-	root := projectRoot()
+// fixturePath returns the path of a file in the pkg/plancritic/corpus
+// fixtures.
+func fixturePath(elem ...string) string {
+	return filepath.Join(append([]string{projectRoot(), "pkg", "plancritic", "corpus", "fixtures"}, elem...)...)
+}
 
+func TestGoldenSimpleReview(t *testing.T) {
 	// Load the golden JSON (simulated LLM response)
-	goldenPath := filepath.Join(root, "testdata", "golden", "simple-review.json")
+	goldenPath := fixturePath("golden", "simple-review.json")
 	goldenData, err := os.ReadFile(goldenPath)
 	if err != nil {
 		t.Fatalf("failed to read golden file: %v", err)
@@ -35,14 +39,14 @@ func TestGoldenSimpleReview(t *testing.T) {
 	}
 
 	// Load plan to get line count
-	planPath := filepath.Join(root, "testdata", "plans", "simple.md")
+	planPath := fixturePath("plans", "simple.md")
 	p, err := plan.Load(planPath)
 	if err != nil {
 		t.Fatalf("failed to load plan: %v", err)
 	}
 
 	// Verify context file loads correctly
-	ctxPath := filepath.Join(root, "testdata", "contexts", "constraints.md")
+	ctxPath := fixturePath("contexts", "constraints.md")
 	ctx, err := pctx.Load(ctxPath)
 	if err != nil {
 		t.Fatalf("failed to load context: %v", err)
@@ -0,0 +1,234 @@
+// Package history tracks CRITICAL questions across revisions of a plan
+// so that questions left unanswered revision after revision can be
+// turned into blocking issues instead of lingering forever.
+package history
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/dshills/plancritic/internal/review"
+	"github.com/dshills/plancritic/internal/storage"
+)
+
+// DefaultPath is the project question history file, relative to the
+// working directory.
+const DefaultPath = ".plancritic/question-history.json"
+
+// ExpiredTag marks an issue converted from an expired question.
+const ExpiredTag = "expired-question"
+
+// File is the local question history file, keyed by plan path.
+type File struct {
+	Plans map[string]*Plan `json:"plans"`
+}
+
+// Plan is the history of one plan. A revision is a distinct plan hash;
+// re-reviewing an unchanged plan does not start a new one.
+type Plan struct {
+	// ID identifies the plan in a shared storage backend; see PlanKey.
+	ID        string           `json:"id,omitempty"`
+	LastHash  string           `json:"last_hash"`
+	Revision  int              `json:"revision"`
+	Questions map[string]Entry `json:"questions"`
+}
+
+// Entry is an open CRITICAL question, keyed by fingerprint.
+type Entry struct {
+	Question string `json:"question"`
+	// FirstRevision is the revision that first asked the question; it
+	// has been asked in every revision since.
+	FirstRevision int `json:"first_revision"`
+}
+
+// PlanKey returns the key of the history of the plan identified by id
+// in a shared storage backend (redis, s3, or sqlite). Each plan is its
+// own object, so clients reviewing different plans never overwrite
+// each other's history. id must name the plan the same way for every
+// client, e.g. by its repository and path there.
+func PlanKey(id string) string {
+	return fmt.Sprintf("history/plans/%x.json", sha256.Sum256([]byte(id)))
+}
+
+// Local returns an fs backend and key for the history file at path.
+func Local(path string) (storage.Backend, string) {
+	return storage.NewFS(filepath.Dir(path)), filepath.Base(path)
+}
+
+// Load reads the history file stored under key. A missing history
+// yields an empty File.
+func Load(ctx context.Context, b storage.Backend, key string) (*File, error) {
+	f := &File{}
+	if err := load(ctx, b, key, f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Save stores f under key. A history that already holds f is left
+// untouched.
+func Save(ctx context.Context, b storage.Backend, key string, f *File) error {
+	return save(ctx, b, key, f)
+}
+
+// LoadPlan reads the history of one plan stored under key (see
+// PlanKey). A missing history yields an empty Plan.
+func LoadPlan(ctx context.Context, b storage.Backend, key string) (*Plan, error) {
+	p := &Plan{}
+	if err := load(ctx, b, key, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// SavePlan stores p under key, leaving a history that already holds p
+// untouched.
+func SavePlan(ctx context.Context, b storage.Backend, key string, p *Plan) error {
+	return save(ctx, b, key, p)
+}
+
+func load(ctx context.Context, b storage.Backend, key string, v any) error {
+	data, err := b.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("history: read %s: %w", key, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("history: parse %s: %w", key, err)
+	}
+	return nil
+}
+
+func save(ctx context.Context, b storage.Backend, key string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("history: marshal: %w", err)
+	}
+	data = append(data, '\n')
+	if old, err := b.Get(ctx, key); err == nil && bytes.Equal(old, data) {
+		return nil
+	}
+	if err := b.Put(ctx, key, data); err != nil {
+		return fmt.Errorf("history: write %s: %w", key, err)
+	}
+	return nil
+}
+
+// Record updates the history of the plan named plan with the CRITICAL
+// questions in r (see Plan.Record).
+func (f *File) Record(plan, hash string, r *review.Review) *Plan {
+	if f.Plans == nil {
+		f.Plans = map[string]*Plan{}
+	}
+	p := f.Plans[plan]
+	if p == nil {
+		p = &Plan{}
+		f.Plans[plan] = p
+	}
+	p.Record(hash, r)
+	return p
+}
+
+// Record updates the plan's history with the CRITICAL questions in r.
+// When hash differs from the last recorded one it is a new revision,
+// and questions it no longer asks are forgotten as answered.
+// Fingerprints must already be assigned.
+func (p *Plan) Record(hash string, r *review.Review) {
+	if p.Questions == nil {
+		p.Questions = map[string]Entry{}
+	}
+	asked := map[string]review.Question{}
+	for _, q := range r.Questions {
+		if q.Severity == review.SeverityCritical {
+			asked[q.Fingerprint] = q
+		}
+	}
+	if hash != p.LastHash || p.Revision == 0 {
+		p.Revision++
+		p.LastHash = hash
+		for fp := range p.Questions {
+			if _, ok := asked[fp]; !ok {
+				delete(p.Questions, fp)
+			}
+		}
+	}
+	for fp, q := range asked {
+		if _, ok := p.Questions[fp]; !ok {
+			p.Questions[fp] = Entry{Question: strings.TrimSpace(q.Question), FirstRevision: p.Revision}
+		}
+	}
+}
+
+// Age returns how many revisions after the one that first asked it the
+// question with fingerprint fp has stayed unanswered, or -1 when it is
+// not open.
+func (p *Plan) Age(fp string) int {
+	e, ok := p.Questions[fp]
+	if !ok {
+		return -1
+	}
+	return p.Revision - e.FirstRevision
+}
+
+// Expire converts each CRITICAL question in r whose Age is at least
+// after into a CRITICAL AMBIGUITY issue, so it counts against the score
+// and verdict. It returns the converted questions. The caller
+// recomputes the summary.
+func (p *Plan) Expire(r *review.Review, after int) []review.Question {
+	if after <= 0 {
+		return nil
+	}
+	var expired []review.Question
+	questions := r.Questions[:0]
+	for _, q := range r.Questions {
+		age := p.Age(q.Fingerprint)
+		if q.Severity != review.SeverityCritical || age < after {
+			questions = append(questions, q)
+			continue
+		}
+		expired = append(expired, q)
+		r.Issues = append(r.Issues, issueFor(q, age))
+	}
+	r.Questions = questions
+	review.SortIssues(r.Issues)
+	return expired
+}
+
+// issueFor builds the issue an expired question becomes.
+func issueFor(q review.Question, age int) review.Issue {
+	revisions := "revision"
+	if age != 1 {
+		revisions = "revisions"
+	}
+	desc := fmt.Sprintf("This question has gone unanswered for %d plan %s since it was first asked.", age, revisions)
+	if why := strings.TrimSpace(q.WhyNeeded); why != "" {
+		desc = why + " " + desc
+	}
+	rec := "Answer the question in the plan."
+	if len(q.SuggestedAnswers) > 0 {
+		rec += " Candidate answers: " + strings.Join(q.SuggestedAnswers, "; ") + "."
+	}
+	iss := review.Issue{
+		ID:             "ISSUE-" + q.ID,
+		Severity:       review.SeverityCritical,
+		Category:       review.CategoryAmbiguity,
+		Title:          "Unanswered question: " + strings.TrimSpace(q.Question),
+		Description:    desc,
+		Evidence:       q.Evidence,
+		Impact:         "Execution stays blocked until the plan answers this question.",
+		Recommendation: rec,
+		Blocking:       true,
+		Tags:           []string{ExpiredTag},
+		Agreement:      q.Agreement,
+	}
+	iss.Fingerprint = review.Fingerprint(iss)
+	return iss
+}
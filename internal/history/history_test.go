@@ -0,0 +1,130 @@
+package history
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dshills/plancritic/internal/review"
+	"github.com/dshills/plancritic/internal/storage"
+)
+
+func reviewWith(questions ...review.Question) *review.Review {
+	r := &review.Review{Questions: questions}
+	review.AssignFingerprints(r)
+	return r
+}
+
+var (
+	dbQuestion = review.Question{
+		ID: "Q-0001", Severity: review.SeverityCritical,
+		Question: "Which database?", WhyNeeded: "The migration depends on it.",
+		SuggestedAnswers: []string{"PostgreSQL", "MySQL"},
+		Evidence:         []review.Evidence{{Source: "plan", Path: "plan.md", LineStart: 3, LineEnd: 3, Quote: "use a database"}},
+	}
+	minorQuestion = review.Question{ID: "Q-0002", Severity: review.SeverityWarn, Question: "Which region?"}
+)
+
+func TestRecordAndExpire(t *testing.T) {
+	f := &File{}
+
+	// Revision 1 asks; re-running it does not age the question.
+	for range 2 {
+		r := reviewWith(dbQuestion, minorQuestion)
+		p := f.Record("plans/plan.md", "sha256:1", r)
+		if p.Revision != 1 || p.Age(r.Questions[0].Fingerprint) != 0 {
+			t.Fatalf("revision %d, age %d", p.Revision, p.Age(r.Questions[0].Fingerprint))
+		}
+		if got := p.Expire(r, 1); len(got) != 0 {
+			t.Errorf("expired on first revision: %v", got)
+		}
+	}
+
+	// Revision 2 still asks: one revision old.
+	r := reviewWith(dbQuestion, minorQuestion)
+	p := f.Record("plans/plan.md", "sha256:2", r)
+	if got := p.Expire(r, 2); len(got) != 0 {
+		t.Errorf("expired before the limit: %v", got)
+	}
+	expired := p.Expire(r, 1)
+	if len(expired) != 1 || len(r.Questions) != 1 || r.Questions[0].ID != "Q-0002" {
+		t.Fatalf("expired %d, questions left %+v", len(expired), r.Questions)
+	}
+	iss := r.Issues[0]
+	if iss.ID != "ISSUE-Q-0001" || iss.Severity != review.SeverityCritical || iss.Category != review.CategoryAmbiguity || !iss.Blocking {
+		t.Errorf("issue = %+v", iss)
+	}
+	if len(iss.Evidence) != 1 || iss.Tags[0] != ExpiredTag || iss.Fingerprint == "" {
+		t.Errorf("issue evidence/tags = %+v", iss)
+	}
+	if s := review.ComputeSummary(r.Issues); s.CriticalCount != 1 {
+		t.Errorf("critical count = %d, want the converted issue scored", s.CriticalCount)
+	}
+
+	// Revision 3 answers it; revision 4 asking again starts over.
+	p = f.Record("plans/plan.md", "sha256:3", reviewWith(minorQuestion))
+	if len(p.Questions) != 0 {
+		t.Errorf("answered question still open: %+v", p.Questions)
+	}
+	r = reviewWith(dbQuestion)
+	p = f.Record("plans/plan.md", "sha256:4", r)
+	if age := p.Age(r.Questions[0].Fingerprint); age != 0 {
+		t.Errorf("age after re-asking = %d, want 0", age)
+	}
+}
+
+func TestLoadSave(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), ".plancritic", "question-history.json")
+	b, key := Local(path)
+	f, err := Load(ctx, b, key)
+	if err != nil || len(f.Plans) != 0 {
+		t.Fatalf("Load(missing) = %+v, %v", f, err)
+	}
+	f.Record("plan.md", "sha256:1", reviewWith(dbQuestion))
+	if err := Save(ctx, b, key, f); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("history file not written: %v", err)
+	}
+	got, err := Load(ctx, b, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := got.Plans["plan.md"]; p == nil || p.Revision != 1 || len(p.Questions) != 1 {
+		t.Errorf("round trip = %+v", got.Plans)
+	}
+}
+
+func TestLoadSavePlanSQLite(t *testing.T) {
+	ctx := context.Background()
+	b, err := storage.NewSQLite(filepath.Join(t.TempDir(), "plancritic.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	// Two repositories with a plan.md each keep separate histories.
+	ours, theirs := PlanKey("https://github.com/acme/api.git#plan.md"), PlanKey("https://github.com/acme/web.git#plan.md")
+	if ours == theirs {
+		t.Fatal("plans in different repositories share a key")
+	}
+	p := &Plan{ID: "https://github.com/acme/api.git#plan.md"}
+	p.Record("sha256:1", reviewWith(dbQuestion))
+	if err := SavePlan(ctx, b, ours, p); err != nil {
+		t.Fatal(err)
+	}
+	other, err := LoadPlan(ctx, b, theirs)
+	if err != nil || other.Revision != 0 || len(other.Questions) != 0 {
+		t.Fatalf("LoadPlan(other repository) = %+v, %v; want an empty history", other, err)
+	}
+	got, err := LoadPlan(ctx, b, ours)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != p.ID || got.Revision != 1 || len(got.Questions) != 1 {
+		t.Errorf("round trip = %+v", got)
+	}
+}
@@ -0,0 +1,93 @@
+// Package hook runs user-supplied post-processors over a validated
+// review before it is rendered, so teams can filter or enrich findings
+// without changing the pipeline.
+package hook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/dshills/plancritic/internal/review"
+)
+
+// PostProcessor modifies a review in place. It runs after validation,
+// suppressions, and the severity filter, with Input and Meta filled in.
+// The summary is recomputed from the issues afterwards.
+type PostProcessor interface {
+	PostProcess(ctx context.Context, r *review.Review) error
+}
+
+// Func adapts an ordinary function to PostProcessor.
+type Func func(ctx context.Context, r *review.Review) error
+
+func (f Func) PostProcess(ctx context.Context, r *review.Review) error { return f(ctx, r) }
+
+// Exec is a PostProcessor that runs an external command. The review
+// JSON is written to the command's stdin and the modified review is
+// read from its stdout; empty output leaves the review unchanged. The
+// command's stderr is passed through. A non-zero exit is an error.
+type Exec struct {
+	// Command is the program followed by whitespace-separated
+	// arguments. It is executed directly, not through a shell.
+	Command string
+	Stderr  io.Writer
+}
+
+// NewExec returns an Exec hook for command, writing the command's
+// stderr to os.Stderr.
+func NewExec(command string) (*Exec, error) {
+	if len(strings.Fields(command)) == 0 {
+		return nil, errors.New("post-process command is empty")
+	}
+	return &Exec{Command: command, Stderr: os.Stderr}, nil
+}
+
+func (e *Exec) PostProcess(ctx context.Context, r *review.Review) error {
+	args := strings.Fields(e.Command)
+	if len(args) == 0 {
+		return errors.New("post-process command is empty")
+	}
+	in, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("post-process %s: marshal review: %w", args[0], err)
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = &stdout
+	if e.Stderr != nil {
+		cmd.Stderr = e.Stderr
+	}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("post-process %s: %w", args[0], err)
+	}
+
+	out := bytes.TrimSpace(stdout.Bytes())
+	if len(out) == 0 {
+		return nil
+	}
+	var modified review.Review
+	if err := json.Unmarshal(out, &modified); err != nil {
+		return fmt.Errorf("post-process %s: output is not a review: %w", args[0], err)
+	}
+	*r = modified
+	return nil
+}
+
+// Run applies hooks in order, stopping at the first error.
+func Run(ctx context.Context, r *review.Review, hooks []PostProcessor) error {
+	for _, h := range hooks {
+		if err := h.PostProcess(ctx, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package hook
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/dshills/plancritic/internal/review"
+)
+
+func writeScript(t *testing.T, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not executable on windows")
+	}
+	path := filepath.Join(t.TempDir(), "hook.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunFuncOrder(t *testing.T) {
+	r := &review.Review{}
+	var order []string
+	hooks := []PostProcessor{
+		Func(func(_ context.Context, r *review.Review) error {
+			order = append(order, "first")
+			r.Issues = append(r.Issues, review.Issue{ID: "ISSUE-0001"})
+			return nil
+		}),
+		Func(func(_ context.Context, r *review.Review) error {
+			order = append(order, "second")
+			return errors.New("stop")
+		}),
+		Func(func(_ context.Context, r *review.Review) error {
+			order = append(order, "third")
+			return nil
+		}),
+	}
+	if err := Run(context.Background(), r, hooks); err == nil || err.Error() != "stop" {
+		t.Fatalf("err = %v, want stop", err)
+	}
+	if len(order) != 2 || len(r.Issues) != 1 {
+		t.Errorf("order = %v, issues = %d", order, len(r.Issues))
+	}
+}
+
+func TestExecReplacesReview(t *testing.T) {
+	script := writeScript(t, `cat >/dev/null
+echo '{"tool":"plancritic","issues":[],"questions":[{"id":"Q-0001","severity":"INFO","question":"added?"}]}'
+`)
+	h, err := NewExec(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &review.Review{Issues: []review.Issue{{ID: "ISSUE-0001"}}}
+	if err := h.PostProcess(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Issues) != 0 || len(r.Questions) != 1 || r.Questions[0].Question != "added?" {
+		t.Errorf("review not replaced: %+v", r)
+	}
+}
+
+func TestExecEmptyOutputKeepsReview(t *testing.T) {
+	h, err := NewExec(writeScript(t, "cat >/dev/null\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &review.Review{Issues: []review.Issue{{ID: "ISSUE-0001"}}}
+	if err := h.PostProcess(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Issues) != 1 {
+		t.Errorf("issues = %d, want 1", len(r.Issues))
+	}
+}
+
+func TestExecErrors(t *testing.T) {
+	for name, body := range map[string]string{
+		"exit":    "exit 3\n",
+		"garbage": "echo not json\n",
+	} {
+		h, err := NewExec(writeScript(t, body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.Stderr = nil
+		if err := h.PostProcess(context.Background(), &review.Review{}); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if _, err := NewExec("  "); err == nil {
+		t.Error("expected error for empty command")
+	}
+}
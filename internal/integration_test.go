@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"os"
-	"path/filepath"
 	"testing"
 	"time"
 
@@ -25,20 +24,20 @@ func skipUnlessIntegration(t *testing.T) {
 	}
 }
 
-// loadTestPlan loads the test plan from testdata.
+// loadTestPlan loads the test plan from the corpus fixtures.
 func loadTestPlan(t *testing.T) *plan.Plan {
 	t.Helper()
-	p, err := plan.Load(filepath.Join(projectRoot(), "testdata", "plans", "simple.md"))
+	p, err := plan.Load(fixturePath("plans", "simple.md"))
 	if err != nil {
 		t.Fatalf("load plan: %v", err)
 	}
 	return p
 }
 
-// loadTestContext loads the test constraints context from testdata.
+// loadTestContext loads the test constraints context from the corpus fixtures.
 func loadTestContext(t *testing.T) *pctx.File {
 	t.Helper()
-	c, err := pctx.Load(filepath.Join(projectRoot(), "testdata", "contexts", "constraints.md"))
+	c, err := pctx.Load(fixturePath("contexts", "constraints.md"))
 	if err != nil {
 		t.Fatalf("load context: %v", err)
 	}
@@ -0,0 +1,82 @@
+// Package keyring stores secrets, such as provider API keys, in the OS
+// keychain: the macOS Keychain, the Secret Service (GNOME Keyring,
+// KWallet) through secret-tool, or the Windows Credential Manager.
+package keyring
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Service is the name entries are stored under.
+const Service = "plancritic"
+
+var (
+	// ErrNotFound is returned when no entry exists for the account.
+	ErrNotFound = errors.New("not found in the OS keychain")
+	// ErrUnsupported is returned when no keychain is available, e.g.
+	// secret-tool is not installed.
+	ErrUnsupported = errors.New("no supported OS keychain")
+)
+
+// Get returns the secret stored for account.
+func Get(account string) (string, error) { return get(account) }
+
+// Set stores secret for account, replacing any existing entry.
+func Set(account, secret string) error {
+	if secret == "" {
+		return fmt.Errorf("empty secret")
+	}
+	return set(account, secret)
+}
+
+// Delete removes the entry for account.
+func Delete(account string) error { return remove(account) }
+
+// run runs a keychain tool with stdin and returns its trimmed stdout.
+// A missing tool is ErrUnsupported.
+func run(stdin, name string, args ...string) (string, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s not found", ErrUnsupported, name)
+	}
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", &toolError{err: err, msg: msg}
+		}
+		return "", &toolError{err: err}
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// toolError is a failed keychain tool run.
+type toolError struct {
+	err error
+	msg string
+}
+
+func (e *toolError) Error() string {
+	if e.msg != "" {
+		return fmt.Sprintf("%v: %s", e.err, e.msg)
+	}
+	return e.err.Error()
+}
+
+func (e *toolError) Unwrap() error { return e.err }
+
+// exitCode returns the exit status of a failed tool run, or -1.
+func exitCode(err error) int {
+	var ee *exec.ExitError
+	if errors.As(err, &ee) {
+		return ee.ExitCode()
+	}
+	return -1
+}
@@ -0,0 +1,35 @@
+package keyring
+
+import "encoding/hex"
+
+// errSecItemNotFound is the exit status of security(1) for a missing
+// item.
+const errSecItemNotFound = 44
+
+func get(account string) (string, error) {
+	out, err := run("", "security", "find-generic-password", "-s", Service, "-a", account, "-w")
+	if exitCode(err) == errSecItemNotFound {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+func set(account, secret string) error {
+	// Commands are read from stdin (security -i) so the secret never
+	// appears in the process list; -X takes it hex-encoded, avoiding
+	// any quoting.
+	cmd := "add-generic-password -U -s " + Service + " -a " + account + " -X " + hex.EncodeToString([]byte(secret)) + "\n"
+	_, err := run(cmd, "security", "-i")
+	return err
+}
+
+func remove(account string) error {
+	_, err := run("", "security", "delete-generic-password", "-s", Service, "-a", account)
+	if exitCode(err) == errSecItemNotFound {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,30 @@
+//go:build !darwin && !windows
+
+package keyring
+
+func get(account string) (string, error) {
+	out, err := run("", "secret-tool", "lookup", "service", Service, "account", account)
+	// secret-tool exits 1 with no output when nothing matches.
+	if out == "" && (err == nil || exitCode(err) == 1) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+func set(account, secret string) error {
+	// secret-tool reads the secret from stdin.
+	_, err := run(secret, "secret-tool", "store", "--label", Service+" API key ("+account+")", "service", Service, "account", account)
+	return err
+}
+
+func remove(account string) error {
+	// secret-tool clear succeeds whether or not anything matched.
+	if _, err := get(account); err != nil {
+		return err
+	}
+	_, err := run("", "secret-tool", "clear", "service", Service, "account", account)
+	return err
+}
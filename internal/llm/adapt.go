@@ -0,0 +1,67 @@
+package llm
+
+// PromptAdapter is implemented by providers whose models follow a
+// provider-specific prompt layout better than the generic one. The
+// pipeline builds one prompt and each provider adapts it before
+// sending. Adapt must be deterministic so cache-marked segments stay
+// byte-identical across runs, and must keep System segments first.
+type PromptAdapter interface {
+	AdaptPrompt(segs []Segment) []Segment
+}
+
+// AdaptPrompt returns segs as adapted by p (looking through model
+// overrides), or segs unchanged when p has no adaptation.
+func AdaptPrompt(p Provider, segs []Segment) []Segment {
+	if a, ok := Unwrap(p).(PromptAdapter); ok {
+		return a.AdaptPrompt(segs)
+	}
+	return segs
+}
+
+// jsonReminder closes the user message for Chat Completions models,
+// which adhere to the output schema more reliably when it is restated
+// after long input.
+const jsonReminder = "\nRespond with a single JSON object that matches the Output JSON Schema in the system message exactly: the same keys, enum values, and nesting. Start with { and end with }. No prose, no code fences.\n"
+
+// AdaptPrompt restates the JSON output requirement at the end of the
+// user message.
+func (o *OpenAIProvider) AdaptPrompt(segs []Segment) []Segment {
+	return appendReminder(segs)
+}
+
+// AdaptPrompt restates the JSON output requirement at the end of the
+// user message; local models drift from the schema more than hosted
+// ones.
+func (l *LocalProvider) AdaptPrompt(segs []Segment) []Segment {
+	return appendReminder(segs)
+}
+
+func appendReminder(segs []Segment) []Segment {
+	out := make([]Segment, 0, len(segs)+1)
+	out = append(out, segs...)
+	return append(out, Segment{Text: jsonReminder})
+}
+
+// AdaptPrompt wraps the instructions in <instructions> tags and the
+// plan and context in <input> tags, the document structure Claude
+// models are trained to attend to.
+func (a *AnthropicProvider) AdaptPrompt(segs []Segment) []Segment {
+	out := make([]Segment, len(segs))
+	copy(out, segs)
+	first, last := -1, -1
+	for i, s := range out {
+		if s.System {
+			out[i].Text = "<instructions>\n" + s.Text + "</instructions>\n"
+			continue
+		}
+		if first < 0 {
+			first = i
+		}
+		last = i
+	}
+	if first >= 0 {
+		out[first].Text = "<input>\n" + out[first].Text
+		out[last].Text += "</input>\n"
+	}
+	return out
+}
@@ -0,0 +1,52 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+)
+
+func testSegments() []Segment {
+	return []Segment{
+		{Text: "rules\n", System: true, CacheMark: true},
+		{Text: "context\n", CacheMark: true},
+		{Text: "plan\n"},
+	}
+}
+
+func TestAnthropicAdaptPrompt(t *testing.T) {
+	in := testSegments()
+	out := (&AnthropicProvider{}).AdaptPrompt(in)
+	if len(out) != 3 {
+		t.Fatalf("segments = %d, want 3", len(out))
+	}
+	if out[0].Text != "<instructions>\nrules\n</instructions>\n" || !out[0].System || !out[0].CacheMark {
+		t.Errorf("system segment = %+v", out[0])
+	}
+	if !strings.HasPrefix(out[1].Text, "<input>\n") || !out[1].CacheMark {
+		t.Errorf("first user segment = %+v", out[1])
+	}
+	if !strings.HasSuffix(out[2].Text, "</input>\n") {
+		t.Errorf("last user segment = %+v", out[2])
+	}
+	if in[0].Text != "rules\n" || in[1].Text != "context\n" {
+		t.Error("AdaptPrompt must not modify its input")
+	}
+}
+
+func TestOpenAIAdaptPromptAppendsReminder(t *testing.T) {
+	out := (&OpenAIProvider{}).AdaptPrompt(testSegments())
+	if len(out) != 4 || out[3].Text != jsonReminder || out[3].System || out[3].CacheMark {
+		t.Fatalf("segments = %+v", out)
+	}
+}
+
+func TestAdaptPromptThroughOverride(t *testing.T) {
+	p := &modelOverride{Provider: &AnthropicProvider{}, model: "x"}
+	if out := AdaptPrompt(p, testSegments()); !strings.HasPrefix(out[0].Text, "<instructions>") {
+		t.Error("AdaptPrompt should look through model overrides")
+	}
+	segs := testSegments()
+	if out := AdaptPrompt(&GeminiProvider{}, segs); len(out) != 3 || out[0].Text != "rules\n" {
+		t.Error("providers without an adapter should get the prompt unchanged")
+	}
+}
@@ -5,11 +5,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
-	"os"
 	"strings"
-	"time"
 )
 
 const (
@@ -27,11 +24,16 @@ type AnthropicProvider struct {
 
 // NewAnthropic creates an Anthropic provider using the ANTHROPIC_API_KEY env var.
 func NewAnthropic() (*AnthropicProvider, error) {
-	key := os.Getenv("ANTHROPIC_API_KEY")
-	if key == "" {
-		return nil, fmt.Errorf("ANTHROPIC_API_KEY environment variable not set")
+	return newAnthropic(KeySources{})
+}
+
+// newAnthropic is NewAnthropic with the key read from keys before the env var.
+func newAnthropic(keys KeySources) (*AnthropicProvider, error) {
+	key, err := keys.lookup("anthropic", "ANTHROPIC_API_KEY")
+	if err != nil {
+		return nil, err
 	}
-	return &AnthropicProvider{apiKey: key, apiURL: anthropicAPIURL, client: &http.Client{Timeout: 5 * time.Minute}}, nil
+	return &AnthropicProvider{apiKey: key, apiURL: anthropicAPIURL, client: &http.Client{}}, nil
 }
 
 func (a *AnthropicProvider) Name() string { return "anthropic" }
@@ -42,39 +44,13 @@ func (a *AnthropicProvider) Generate(ctx context.Context, prompt string, s Setti
 
 // GenerateSegments sends a prompt composed of ordered segments, placing a
 // cache_control breakpoint on any segment whose CacheMark is true.
+// System segments (and Settings.System) go in the request's system
+// field; the rest form the user message. Settings.Prefill is sent as the
+// start of the assistant turn.
 func (a *AnthropicProvider) GenerateSegments(ctx context.Context, segments []Segment, s Settings) (string, Usage, error) {
-	model := s.Model
-	if model == "" {
-		model = anthropicDefaultModel
-	}
-
-	maxTokens := s.MaxTokens
-	if maxTokens <= 0 {
-		maxTokens = 16384
-	}
-
-	blocks := make([]anthropicContentBlock, 0, len(segments))
-	for _, seg := range segments {
-		if seg.Text == "" {
-			continue
-		}
-		block := anthropicContentBlock{Type: "text", Text: seg.Text}
-		if seg.CacheMark {
-			block.CacheControl = &anthropicCacheControl{Type: "ephemeral"}
-		}
-		blocks = append(blocks, block)
-	}
-	if len(blocks) == 0 {
-		return "", Usage{}, fmt.Errorf("anthropic: empty prompt")
-	}
-
-	reqBody := anthropicRequest{
-		Model:       model,
-		MaxTokens:   maxTokens,
-		Temperature: &s.Temperature,
-		Messages: []anthropicMessage{
-			{Role: "user", Content: blocks},
-		},
+	reqBody, err := anthropicBuildRequest(segments, s)
+	if err != nil {
+		return "", Usage{}, err
 	}
 
 	body, err := json.Marshal(reqBody)
@@ -82,14 +58,14 @@ func (a *AnthropicProvider) GenerateSegments(ctx context.Context, segments []Seg
 		return "", Usage{}, fmt.Errorf("anthropic: marshal request: %w", err)
 	}
 
+	ctx, cancel := requestContext(ctx, s)
+	defer cancel()
+
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.apiURL, bytes.NewReader(body))
 	if err != nil {
 		return "", Usage{}, fmt.Errorf("anthropic: create request: %w", err)
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-API-Key", a.apiKey)
-	req.Header.Set("Anthropic-Version", anthropicAPIVersion)
-	req.Header.Set("Anthropic-Beta", "prompt-caching-2024-07-31")
+	a.setHeaders(req)
 
 	resp, err := a.client.Do(req)
 	if err != nil {
@@ -97,20 +73,83 @@ func (a *AnthropicProvider) GenerateSegments(ctx context.Context, segments []Seg
 	}
 	defer func() { _ = resp.Body.Close() }()
 
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err := readResponseBody(resp)
 	if err != nil {
 		return "", Usage{}, fmt.Errorf("anthropic: read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", Usage{}, fmt.Errorf("anthropic: API returned %d: %s", resp.StatusCode, string(respBody))
+		return "", Usage{}, apiError("anthropic", resp.StatusCode, respBody)
 	}
 
 	var result anthropicResponse
 	if err := json.Unmarshal(respBody, &result); err != nil {
 		return "", Usage{}, fmt.Errorf("anthropic: parse response: %w", err)
 	}
+	return anthropicResult(result, reqBody.MaxTokens, s)
+}
+
+// setHeaders adds authentication and API version headers to req.
+func (a *AnthropicProvider) setHeaders(req *http.Request) {
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", a.apiKey)
+	req.Header.Set("Anthropic-Version", anthropicAPIVersion)
+	req.Header.Set("Anthropic-Beta", "prompt-caching-2024-07-31")
+}
+
+// anthropicBuildRequest builds the Messages API request body for
+// segments and s.
+func anthropicBuildRequest(segments []Segment, s Settings) (anthropicRequest, error) {
+	model := s.Model
+	if model == "" {
+		model = anthropicDefaultModel
+	}
+
+	maxTokens := s.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = 16384
+	}
+
+	var system []anthropicContentBlock
+	blocks := make([]anthropicContentBlock, 0, len(segments))
+	for _, seg := range withSystem(segments, s) {
+		if seg.Text == "" {
+			continue
+		}
+		block := anthropicContentBlock{Type: "text", Text: seg.Text}
+		if seg.CacheMark {
+			block.CacheControl = &anthropicCacheControl{Type: "ephemeral"}
+		}
+		if seg.System {
+			system = append(system, block)
+		} else {
+			blocks = append(blocks, block)
+		}
+	}
+	if len(blocks) == 0 {
+		return anthropicRequest{}, fmt.Errorf("anthropic: empty prompt")
+	}
 
+	messages := []anthropicMessage{{Role: "user", Content: blocks}}
+	if s.Prefill != "" {
+		messages = append(messages, anthropicMessage{
+			Role:    "assistant",
+			Content: []anthropicContentBlock{{Type: "text", Text: s.Prefill}},
+		})
+	}
+	return anthropicRequest{
+		Model:       model,
+		MaxTokens:   maxTokens,
+		Temperature: &s.Temperature,
+		System:      system,
+		Messages:    messages,
+	}, nil
+}
+
+// anthropicResult extracts the text and usage from a Messages API
+// response, mapping refusals and truncation to ErrRefused and
+// ErrTruncated.
+func anthropicResult(result anthropicResponse, maxTokens int, s Settings) (string, Usage, error) {
 	usage := Usage{
 		InputTokens:              result.Usage.InputTokens,
 		OutputTokens:             result.Usage.OutputTokens,
@@ -125,20 +164,35 @@ func (a *AnthropicProvider) GenerateSegments(ctx context.Context, segments []Seg
 		}
 	}
 
-	if result.StopReason == "max_tokens" {
-		return out.String(), usage, fmt.Errorf("anthropic: response truncated (hit max_tokens=%d)", maxTokens)
+	if result.StopReason == "refusal" {
+		return out.String(), usage, fmt.Errorf("anthropic: %w (stop_reason=refusal)", ErrRefused)
 	}
-	if out.Len() == 0 {
+	if out.Len() == 0 && result.StopReason != "max_tokens" {
 		return "", usage, fmt.Errorf("anthropic: no text content in response")
 	}
-	return out.String(), usage, nil
+	text := withPrefill(out.String(), s.Prefill)
+	if result.StopReason == "max_tokens" {
+		return text, usage, fmt.Errorf("anthropic: %w (hit max_tokens=%d)", ErrTruncated, maxTokens)
+	}
+	return text, usage, nil
+}
+
+// withPrefill restores the prefilled start of the assistant turn, which
+// the API does not echo. A model that repeats the prefill anyway is
+// left as is.
+func withPrefill(text, prefill string) string {
+	if prefill == "" || strings.HasPrefix(strings.TrimLeft(text, " \t\r\n"), prefill) {
+		return text
+	}
+	return prefill + text
 }
 
 type anthropicRequest struct {
-	Model       string             `json:"model"`
-	MaxTokens   int                `json:"max_tokens"`
-	Temperature *float64           `json:"temperature,omitempty"`
-	Messages    []anthropicMessage `json:"messages"`
+	Model       string                  `json:"model"`
+	MaxTokens   int                     `json:"max_tokens"`
+	Temperature *float64                `json:"temperature,omitempty"`
+	System      []anthropicContentBlock `json:"system,omitempty"`
+	Messages    []anthropicMessage      `json:"messages"`
 }
 
 type anthropicMessage struct {
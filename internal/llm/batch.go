@@ -0,0 +1,393 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// BatchDiscount is the fraction of the list price the Anthropic and
+// OpenAI batch APIs bill.
+const BatchDiscount = 0.5
+
+// BatchRequest is one prompt in a batch. ID must be unique within the
+// batch and match [a-zA-Z0-9_-]{1,64}.
+type BatchRequest struct {
+	ID       string
+	Segments []Segment
+	Settings Settings
+}
+
+// BatchResult is the outcome of one batch request. Err wraps the same
+// sentinels Generate would (ErrTruncated, ErrRefused); Text is set with
+// ErrTruncated as it is there. Text lacks the request's
+// Settings.Prefill: the results do not say what it was, so callers
+// restore it with RestorePrefill.
+type BatchResult struct {
+	Text  string
+	Usage Usage
+	Err   error
+}
+
+// BatchStatus reports the progress of a submitted batch. Results is
+// keyed by request ID and filled in once Done.
+type BatchStatus struct {
+	Done    bool
+	State   string
+	Results map[string]BatchResult
+}
+
+// BatchProvider is implemented by providers with an asynchronous batch
+// API: requests are billed at BatchDiscount and answered within 24
+// hours.
+type BatchProvider interface {
+	Provider
+	// SubmitBatch submits reqs and returns the provider's batch ID.
+	SubmitBatch(ctx context.Context, reqs []BatchRequest) (string, error)
+	// CollectBatch reports on a batch, with its results once it ended.
+	CollectBatch(ctx context.Context, id string) (BatchStatus, error)
+}
+
+// AsBatchProvider returns the batch-capable provider behind p, if any,
+// and the model a --model override selects ("" when there is none).
+func AsBatchProvider(p Provider) (BatchProvider, string, bool) {
+	bp, ok := Unwrap(p).(BatchProvider)
+	return bp, OverrideModel(p), ok
+}
+
+// doJSON sends a request and decodes a 2xx JSON response into out.
+func doJSON(client *http.Client, req *http.Request, name string, out any) error {
+	body, err := doRaw(client, req, name)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("%s: parse response: %w", name, err)
+	}
+	return nil
+}
+
+// doRaw sends a request and returns the body of a 2xx response.
+func doRaw(client *http.Client, req *http.Request, name string) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: request failed: %w", name, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := readResponseBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("%s: read response: %w", name, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, apiError(name, resp.StatusCode, body)
+	}
+	return body, nil
+}
+
+// jsonLines calls fn for each non-blank line of a JSONL body.
+func jsonLines(body []byte, fn func(line []byte) error) error {
+	sc := bufio.NewScanner(bytes.NewReader(body))
+	sc.Buffer(make([]byte, 0, 64*1024), maxResponseBytes)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// Anthropic Message Batches API.
+
+type anthropicBatchRequest struct {
+	CustomID string           `json:"custom_id"`
+	Params   anthropicRequest `json:"params"`
+}
+
+type anthropicBatch struct {
+	ID               string `json:"id"`
+	ProcessingStatus string `json:"processing_status"`
+	ResultsURL       string `json:"results_url"`
+}
+
+type anthropicBatchResult struct {
+	CustomID string `json:"custom_id"`
+	Result   struct {
+		Type    string            `json:"type"`
+		Message anthropicResponse `json:"message"`
+		Error   json.RawMessage   `json:"error"`
+	} `json:"result"`
+}
+
+// SubmitBatch creates a Message Batch with one request per prompt.
+func (a *AnthropicProvider) SubmitBatch(ctx context.Context, reqs []BatchRequest) (string, error) {
+	payload := struct {
+		Requests []anthropicBatchRequest `json:"requests"`
+	}{}
+	for _, r := range reqs {
+		params, err := anthropicBuildRequest(r.Segments, r.Settings)
+		if err != nil {
+			return "", err
+		}
+		payload.Requests = append(payload.Requests, anthropicBatchRequest{CustomID: r.ID, Params: params})
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("anthropic: marshal batch: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.apiURL+"/batches", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("anthropic: create request: %w", err)
+	}
+	a.setHeaders(req)
+	var batch anthropicBatch
+	if err := doJSON(a.client, req, "anthropic", &batch); err != nil {
+		return "", err
+	}
+	if batch.ID == "" {
+		return "", fmt.Errorf("anthropic: batch response has no id")
+	}
+	return batch.ID, nil
+}
+
+// CollectBatch reports on a Message Batch and, once it has ended,
+// downloads its results.
+func (a *AnthropicProvider) CollectBatch(ctx context.Context, id string) (BatchStatus, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.apiURL+"/batches/"+id, nil)
+	if err != nil {
+		return BatchStatus{}, fmt.Errorf("anthropic: create request: %w", err)
+	}
+	a.setHeaders(req)
+	var batch anthropicBatch
+	if err := doJSON(a.client, req, "anthropic", &batch); err != nil {
+		return BatchStatus{}, err
+	}
+	st := BatchStatus{State: batch.ProcessingStatus}
+	if batch.ProcessingStatus != "ended" {
+		return st, nil
+	}
+	if batch.ResultsURL == "" {
+		return st, fmt.Errorf("anthropic: ended batch %s has no results_url", id)
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, batch.ResultsURL, nil)
+	if err != nil {
+		return st, fmt.Errorf("anthropic: create request: %w", err)
+	}
+	a.setHeaders(req)
+	body, err := doRaw(a.client, req, "anthropic")
+	if err != nil {
+		return st, err
+	}
+	st.Done = true
+	st.Results = map[string]BatchResult{}
+	err = jsonLines(body, func(line []byte) error {
+		var r anthropicBatchResult
+		if err := json.Unmarshal(line, &r); err != nil {
+			return fmt.Errorf("anthropic: parse batch result: %w", err)
+		}
+		var res BatchResult
+		switch r.Result.Type {
+		case "succeeded":
+			res.Text, res.Usage, res.Err = anthropicResult(r.Result.Message, 0, Settings{})
+		case "errored":
+			res.Err = fmt.Errorf("anthropic: batch request errored: %s", errorBody(r.Result.Error))
+		default:
+			res.Err = fmt.Errorf("anthropic: batch request %s", r.Result.Type)
+		}
+		st.Results[r.CustomID] = res
+		return nil
+	})
+	return st, err
+}
+
+// RestorePrefill adds back the prefill a batch request carried, which
+// batch results omit just as the synchronous API does (see
+// BatchResult).
+func RestorePrefill(text, prefill string) string {
+	return withPrefill(text, prefill)
+}
+
+// OpenAI Batch API.
+
+type openaiBatchLine struct {
+	CustomID string        `json:"custom_id"`
+	Method   string        `json:"method"`
+	URL      string        `json:"url"`
+	Body     openaiRequest `json:"body"`
+}
+
+type openaiBatch struct {
+	ID           string `json:"id"`
+	Status       string `json:"status"`
+	OutputFileID string `json:"output_file_id"`
+	ErrorFileID  string `json:"error_file_id"`
+}
+
+type openaiBatchOutput struct {
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int             `json:"status_code"`
+		Body       json.RawMessage `json:"body"`
+	} `json:"response"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// openaiBaseURL is the API root that the chat completions URL lives
+// under (".../v1").
+func (o *OpenAIProvider) openaiBaseURL() string {
+	return strings.TrimSuffix(o.apiURL, "/chat/completions")
+}
+
+// SubmitBatch uploads the requests as a JSONL file and creates a batch
+// against the chat completions endpoint.
+func (o *OpenAIProvider) SubmitBatch(ctx context.Context, reqs []BatchRequest) (string, error) {
+	var lines bytes.Buffer
+	enc := json.NewEncoder(&lines)
+	for _, r := range reqs {
+		s := r.Settings
+		system, user := SplitSystem(r.Segments)
+		if system != "" {
+			s.System = system
+		}
+		line := openaiBatchLine{CustomID: r.ID, Method: http.MethodPost, URL: "/v1/chat/completions", Body: openaiBuildRequest(user, s)}
+		if err := enc.Encode(line); err != nil {
+			return "", fmt.Errorf("openai: marshal batch: %w", err)
+		}
+	}
+
+	var form bytes.Buffer
+	mw := multipart.NewWriter(&form)
+	if err := mw.WriteField("purpose", "batch"); err != nil {
+		return "", fmt.Errorf("openai: build upload: %w", err)
+	}
+	fw, err := mw.CreateFormFile("file", "plancritic-batch.jsonl")
+	if err != nil {
+		return "", fmt.Errorf("openai: build upload: %w", err)
+	}
+	if _, err := io.Copy(fw, &lines); err != nil {
+		return "", fmt.Errorf("openai: build upload: %w", err)
+	}
+	if err := mw.Close(); err != nil {
+		return "", fmt.Errorf("openai: build upload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.openaiBaseURL()+"/files", &form)
+	if err != nil {
+		return "", fmt.Errorf("openai: create request: %w", err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	o.setAuth(req)
+	var file struct {
+		ID string `json:"id"`
+	}
+	if err := doJSON(o.client, req, "openai", &file); err != nil {
+		return "", err
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"input_file_id":     file.ID,
+		"endpoint":          "/v1/chat/completions",
+		"completion_window": "24h",
+	})
+	if err != nil {
+		return "", fmt.Errorf("openai: marshal batch: %w", err)
+	}
+	req, err = http.NewRequestWithContext(ctx, http.MethodPost, o.openaiBaseURL()+"/batches", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("openai: create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	o.setAuth(req)
+	var batch openaiBatch
+	if err := doJSON(o.client, req, "openai", &batch); err != nil {
+		return "", err
+	}
+	if batch.ID == "" {
+		return "", fmt.Errorf("openai: batch response has no id")
+	}
+	return batch.ID, nil
+}
+
+// CollectBatch reports on a batch and, once it has reached a terminal
+// state, downloads its output and error files.
+func (o *OpenAIProvider) CollectBatch(ctx context.Context, id string) (BatchStatus, error) {
+	var batch openaiBatch
+	if err := o.get(ctx, "/batches/"+id, &batch); err != nil {
+		return BatchStatus{}, err
+	}
+	st := BatchStatus{State: batch.Status}
+	switch batch.Status {
+	case "completed", "expired", "cancelled":
+	case "failed":
+		return st, fmt.Errorf("openai: batch %s failed", id)
+	default:
+		return st, nil
+	}
+
+	st.Done = true
+	st.Results = map[string]BatchResult{}
+	for _, fileID := range []string{batch.OutputFileID, batch.ErrorFileID} {
+		if fileID == "" {
+			continue
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.openaiBaseURL()+"/files/"+fileID+"/content", nil)
+		if err != nil {
+			return st, fmt.Errorf("openai: create request: %w", err)
+		}
+		o.setAuth(req)
+		body, err := doRaw(o.client, req, "openai")
+		if err != nil {
+			return st, err
+		}
+		err = jsonLines(body, func(line []byte) error {
+			var out openaiBatchOutput
+			if err := json.Unmarshal(line, &out); err != nil {
+				return fmt.Errorf("openai: parse batch result: %w", err)
+			}
+			var res BatchResult
+			switch {
+			case out.Error != nil:
+				res.Err = fmt.Errorf("openai: batch request failed: %s: %s", out.Error.Code, out.Error.Message)
+			case out.Response == nil:
+				res.Err = fmt.Errorf("openai: batch request has no response")
+			case out.Response.StatusCode != http.StatusOK:
+				res.Err = apiError("openai", out.Response.StatusCode, out.Response.Body)
+			default:
+				var result openaiResponse
+				if err := json.Unmarshal(out.Response.Body, &result); err != nil {
+					res.Err = fmt.Errorf("openai: parse response: %w", err)
+				} else {
+					res.Text, res.Usage, res.Err = openaiResult(result, 0)
+				}
+			}
+			st.Results[out.CustomID] = res
+			return nil
+		})
+		if err != nil {
+			return st, err
+		}
+	}
+	return st, nil
+}
+
+// get fetches path under the API root and decodes the JSON response.
+func (o *OpenAIProvider) get(ctx context.Context, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.openaiBaseURL()+path, nil)
+	if err != nil {
+		return fmt.Errorf("openai: create request: %w", err)
+	}
+	o.setAuth(req)
+	return doJSON(o.client, req, "openai", out)
+}
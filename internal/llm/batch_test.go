@@ -0,0 +1,168 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAnthropicBatch(t *testing.T) {
+	var submitted struct {
+		Requests []anthropicBatchRequest `json:"requests"`
+	}
+	status := "in_progress"
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "test-key" {
+			t.Errorf("%s %s: missing API key", r.Method, r.URL.Path)
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/messages/batches":
+			_ = json.NewDecoder(r.Body).Decode(&submitted)
+			_ = json.NewEncoder(w).Encode(anthropicBatch{ID: "msgbatch_1", ProcessingStatus: "in_progress"})
+		case r.URL.Path == "/v1/messages/batches/msgbatch_1":
+			_ = json.NewEncoder(w).Encode(anthropicBatch{ID: "msgbatch_1", ProcessingStatus: status, ResultsURL: srv.URL + "/results/msgbatch_1"})
+		case r.URL.Path == "/results/msgbatch_1":
+			_, _ = io.WriteString(w, `{"custom_id":"plan-1","result":{"type":"succeeded","message":{"content":[{"type":"text","text":"\"ok\": true}"}],"stop_reason":"end_turn","usage":{"input_tokens":10,"output_tokens":5}}}}
+{"custom_id":"plan-2","result":{"type":"succeeded","message":{"content":[{"type":"text","text":"{\"part"}],"stop_reason":"max_tokens","usage":{}}}}
+{"custom_id":"plan-3","result":{"type":"errored","error":{"type":"error","error":{"type":"invalid_request_error","message":"bad"}}}}
+{"custom_id":"plan-4","result":{"type":"expired"}}
+`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	p := &AnthropicProvider{apiKey: "test-key", apiURL: srv.URL + "/v1/messages", client: srv.Client()}
+	id, err := p.SubmitBatch(context.Background(), []BatchRequest{
+		{ID: "plan-1", Segments: []Segment{{Text: "rules", System: true}, {Text: "plan"}}, Settings: Settings{Model: "claude-haiku-4-5", Prefill: "{"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "msgbatch_1" {
+		t.Errorf("id = %q", id)
+	}
+	if len(submitted.Requests) != 1 || submitted.Requests[0].CustomID != "plan-1" {
+		t.Fatalf("submitted = %+v", submitted)
+	}
+	params := submitted.Requests[0].Params
+	if params.Model != "claude-haiku-4-5" || len(params.System) != 1 || len(params.Messages) != 2 {
+		t.Errorf("params = %+v, want the model, system prompt, and prefill", params)
+	}
+
+	st, err := p.CollectBatch(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Done || st.State != "in_progress" {
+		t.Errorf("status = %+v, want in progress", st)
+	}
+
+	status = "ended"
+	st, err = p.CollectBatch(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !st.Done || len(st.Results) != 4 {
+		t.Fatalf("status = %+v, want 4 results", st)
+	}
+	if r := st.Results["plan-1"]; r.Err != nil || RestorePrefill(r.Text, "{") != `{"ok": true}` || r.Usage.InputTokens != 10 {
+		t.Errorf("plan-1 = %+v", r)
+	}
+	if r := st.Results["plan-2"]; !errors.Is(r.Err, ErrTruncated) {
+		t.Errorf("plan-2 err = %v, want ErrTruncated", r.Err)
+	}
+	for _, id := range []string{"plan-3", "plan-4"} {
+		if st.Results[id].Err == nil {
+			t.Errorf("%s: expected an error", id)
+		}
+	}
+}
+
+func TestOpenAIBatch(t *testing.T) {
+	var uploaded string
+	var created map[string]string
+	status := "in_progress"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("%s %s: missing API key", r.Method, r.URL.Path)
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/files":
+			if r.FormValue("purpose") != "batch" {
+				t.Errorf("purpose = %q", r.FormValue("purpose"))
+			}
+			f, _, err := r.FormFile("file")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			data, _ := io.ReadAll(f)
+			uploaded = string(data)
+			_, _ = io.WriteString(w, `{"id":"file-in"}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/batches":
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			_, _ = io.WriteString(w, `{"id":"batch_1","status":"validating"}`)
+		case r.URL.Path == "/v1/batches/batch_1":
+			_ = json.NewEncoder(w).Encode(openaiBatch{ID: "batch_1", Status: status, OutputFileID: "file-out", ErrorFileID: "file-err"})
+		case r.URL.Path == "/v1/files/file-out/content":
+			_, _ = io.WriteString(w, `{"custom_id":"plan-1","response":{"status_code":200,"body":{"choices":[{"message":{"role":"assistant","content":"{\"ok\":true}"},"finish_reason":"stop"}],"usage":{"prompt_tokens":7,"completion_tokens":3}}},"error":null}`+"\n")
+		case r.URL.Path == "/v1/files/file-err/content":
+			_, _ = io.WriteString(w, `{"custom_id":"plan-2","response":null,"error":{"code":"batch_expired","message":"not completed in time"}}`+"\n")
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	p := &OpenAIProvider{apiKey: "test-key", apiURL: srv.URL + "/v1/chat/completions", client: srv.Client()}
+	id, err := p.SubmitBatch(context.Background(), []BatchRequest{
+		{ID: "plan-1", Segments: []Segment{{Text: "rules", System: true}, {Text: "plan"}}, Settings: Settings{Model: "gpt-4o-mini"}},
+		{ID: "plan-2", Segments: []Segment{{Text: "plan 2"}}, Settings: Settings{Model: "gpt-4o-mini"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "batch_1" {
+		t.Errorf("id = %q", id)
+	}
+	if created["input_file_id"] != "file-in" || created["endpoint"] != "/v1/chat/completions" || created["completion_window"] != "24h" {
+		t.Errorf("batch create = %v", created)
+	}
+	lines := strings.Split(strings.TrimSpace(uploaded), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("uploaded %d lines, want 2", len(lines))
+	}
+	var line openaiBatchLine
+	if err := json.Unmarshal([]byte(lines[0]), &line); err != nil {
+		t.Fatal(err)
+	}
+	if line.CustomID != "plan-1" || line.URL != "/v1/chat/completions" || line.Body.Model != "gpt-4o-mini" || len(line.Body.Messages) != 2 {
+		t.Errorf("line = %+v", line)
+	}
+
+	st, err := p.CollectBatch(context.Background(), id)
+	if err != nil || st.Done {
+		t.Fatalf("status = %+v, %v; want in progress", st, err)
+	}
+	status = "completed"
+	st, err = p.CollectBatch(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := st.Results["plan-1"]; r.Err != nil || r.Text != `{"ok":true}` || r.Usage.OutputTokens != 3 {
+		t.Errorf("plan-1 = %+v", r)
+	}
+	if r := st.Results["plan-2"]; r.Err == nil || !strings.Contains(r.Err.Error(), "batch_expired") {
+		t.Errorf("plan-2 err = %v", r.Err)
+	}
+}
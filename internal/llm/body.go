@@ -0,0 +1,82 @@
+package llm
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxResponseBytes caps how much of a provider response body is read,
+// measured after decompression. Generous for any completion the CLI
+// requests, small enough that a misbehaving gateway can't exhaust
+// memory.
+const maxResponseBytes = 16 << 20
+
+// maxErrorBodyChars caps how much of a non-2xx response body is echoed
+// into error messages.
+const maxErrorBodyChars = 2048
+
+// readResponseBody reads resp.Body up to maxResponseBytes. The Go
+// transport transparently decompresses gzip only when it negotiated the
+// encoding itself; a proxy that gzips anyway is handled here, and the
+// size limit applies to the decompressed stream so a compression bomb
+// is cut off rather than expanded in memory.
+func readResponseBody(resp *http.Response) ([]byte, error) {
+	var r io.Reader = resp.Body
+	if !resp.Uncompressed && strings.EqualFold(strings.TrimSpace(resp.Header.Get("Content-Encoding")), "gzip") {
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("decompress response: %w", err)
+		}
+		defer func() { _ = gz.Close() }()
+		r = gz
+	}
+	data, err := io.ReadAll(io.LimitReader(r, maxResponseBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxResponseBytes {
+		return nil, fmt.Errorf("response exceeded %d bytes", maxResponseBytes)
+	}
+	return data, nil
+}
+
+// errorBody renders a non-2xx response body for an error message,
+// truncated so a huge HTML error page doesn't flood the terminal.
+func errorBody(b []byte) string {
+	return truncateString(string(b), maxErrorBodyChars)
+}
+
+// APIError is a non-2xx response from a provider API. The body is kept
+// so callers can explain well-known failures; the message truncates it.
+type APIError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+	// Code, Param, and Message are parsed from the body when the
+	// provider returns a structured error (OpenAI).
+	Code    string
+	Param   string
+	Message string
+	// Hint, when set, tells the user what to do about the error and
+	// replaces the raw body in the message.
+	Hint string
+}
+
+// apiError builds the error for a non-2xx response.
+func apiError(provider string, status int, body []byte) *APIError {
+	e := &APIError{Provider: provider, StatusCode: status, Body: string(body)}
+	if provider == "openai" {
+		explainOpenAIError(e)
+	}
+	return e
+}
+
+func (e *APIError) Error() string {
+	if e.Hint != "" {
+		return fmt.Sprintf("%s: %s (%s, HTTP %d: %s)", e.Provider, e.Hint, e.Code, e.StatusCode, truncateString(e.Message, maxErrorBodyChars))
+	}
+	return fmt.Sprintf("%s: API returned %d: %s", e.Provider, e.StatusCode, errorBody([]byte(e.Body)))
+}
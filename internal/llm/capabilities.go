@@ -0,0 +1,81 @@
+package llm
+
+// Capabilities describes what a provider's implementation honors for a
+// model, so the pipeline can branch on features instead of on provider
+// names. A false field means the feature is ignored, not that the
+// request fails: a Seed sent to a provider without Seed is dropped.
+type Capabilities struct {
+	// Seed is true when Settings.Seed reaches the model.
+	Seed bool `json:"seed"`
+	// SystemRole is true when Settings.System and System segments are
+	// sent in a system role rather than prepended to the user message.
+	SystemRole bool `json:"system_role"`
+	// Prefill is true when Settings.Prefill starts the model's turn and
+	// is included in the returned text, so a truncated response can be
+	// continued by prefilling it.
+	Prefill bool `json:"prefill"`
+	// JSONMode is true when the request asks the API for a JSON object
+	// response.
+	JSONMode bool `json:"json_mode"`
+	// JSONSchema is true when the response can be constrained to a
+	// supplied JSON schema. No built-in provider requests one yet.
+	JSONSchema bool `json:"json_schema"`
+	// Streaming is true when responses are read as a stream. No
+	// built-in provider streams yet.
+	Streaming bool `json:"streaming"`
+	// PromptCache is true when Segment.CacheMark places provider-side
+	// prompt cache breakpoints.
+	PromptCache bool `json:"prompt_cache"`
+	// ContextCache is true for a CachingProvider.
+	ContextCache bool `json:"context_cache"`
+	// Batch is true for a BatchProvider.
+	Batch bool `json:"batch"`
+	// MaxContextTokens is the model's context window, input and output
+	// together; 0 when unknown, as for local models.
+	MaxContextTokens int `json:"max_context_tokens,omitempty"`
+	// MaxOutputTokens is the most output tokens a request may ask for;
+	// 0 when unknown.
+	MaxOutputTokens int `json:"max_output_tokens,omitempty"`
+}
+
+// Capabilities reports the features of the Anthropic Messages API
+// client for model (the default model when empty).
+func (a *AnthropicProvider) Capabilities(model string) Capabilities {
+	c := Capabilities{SystemRole: true, Prefill: true, PromptCache: true, Batch: true}
+	c.MaxContextTokens, c.MaxOutputTokens = modelLimits(a, model)
+	return c
+}
+
+// Capabilities reports the features of the OpenAI client for model
+// (the default model when empty).
+func (o *OpenAIProvider) Capabilities(model string) Capabilities {
+	c := Capabilities{Seed: true, SystemRole: true, JSONMode: true, Batch: true}
+	c.MaxContextTokens, c.MaxOutputTokens = modelLimits(o, model)
+	return c
+}
+
+// Capabilities reports the features of the Gemini client for model
+// (the default model when empty).
+func (g *GeminiProvider) Capabilities(model string) Capabilities {
+	c := Capabilities{Seed: true, SystemRole: true, JSONMode: true, ContextCache: true}
+	c.MaxContextTokens, c.MaxOutputTokens = modelLimits(g, model)
+	return c
+}
+
+// Capabilities reports the features of the local server client. Limits
+// depend on the server's model and are unknown.
+func (l *LocalProvider) Capabilities(string) Capabilities {
+	return Capabilities{Seed: true, SystemRole: true}
+}
+
+// Capabilities reports the mock's features: it answers batches and
+// ignores everything else.
+func (m *MockProvider) Capabilities(string) Capabilities {
+	return Capabilities{Batch: true}
+}
+
+// Capabilities reports the wrapped provider's capabilities for the
+// override model.
+func (m *modelOverride) Capabilities(string) Capabilities {
+	return m.Provider.Capabilities(m.model)
+}
@@ -0,0 +1,27 @@
+package llm
+
+import "testing"
+
+func TestCapabilities(t *testing.T) {
+	if !(&AnthropicProvider{}).Capabilities("").Prefill {
+		t.Error("anthropic should support prefill")
+	}
+	if (&OpenAIProvider{}).Capabilities("").Prefill || (&MockProvider{}).Capabilities("").Prefill {
+		t.Error("openai and mock do not support prefill")
+	}
+	if (&AnthropicProvider{}).Capabilities("").Seed || !(&OpenAIProvider{}).Capabilities("").Seed {
+		t.Error("only openai of the two sends a seed")
+	}
+
+	override := &modelOverride{Provider: &OpenAIProvider{}, model: "gpt-4o-mini"}
+	c := override.Capabilities("gpt-5.2")
+	if c.MaxContextTokens != 128000 || c.MaxOutputTokens != 16384 {
+		t.Errorf("override capabilities = %+v, want gpt-4o limits", c)
+	}
+	if c := (&GeminiProvider{}).Capabilities("gemini:gemini-2.5-pro"); c.MaxContextTokens != 1048576 || !c.ContextCache {
+		t.Errorf("gemini capabilities = %+v", c)
+	}
+	if c := (&LocalProvider{}).Capabilities("llama3"); c.MaxContextTokens != 0 || c.MaxOutputTokens != 0 {
+		t.Errorf("local limits should be unknown: %+v", c)
+	}
+}
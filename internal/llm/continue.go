@@ -0,0 +1,30 @@
+package llm
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrTruncated is wrapped by provider errors for a response cut off at
+// the output token limit. The partial text is returned with the error.
+var ErrTruncated = errors.New("response truncated")
+
+// Bounds on the text a continuation may repeat from the end of the
+// partial response. Shorter matches are likely coincidence (a quote or
+// digit that really does recur) and are kept.
+const (
+	minContinuationOverlap = 16
+	maxContinuationOverlap = 500
+)
+
+// StitchContinuation joins a truncated response and its continuation.
+// Models asked to carry on sometimes restart a little early; the
+// longest suffix of partial that opens cont is kept only once.
+func StitchContinuation(partial, cont string) string {
+	for n := min(len(partial), len(cont), maxContinuationOverlap); n >= minContinuationOverlap; n-- {
+		if strings.HasPrefix(cont, partial[len(partial)-n:]) {
+			return partial + cont[n:]
+		}
+	}
+	return partial + cont
+}
@@ -0,0 +1,20 @@
+package llm
+
+import "testing"
+
+func TestStitchContinuation(t *testing.T) {
+	tests := []struct {
+		name, partial, cont, want string
+	}{
+		{"clean resume", `{"issues": [{"title": "Miss`, `ing rollback"}]}`, `{"issues": [{"title": "Missing rollback"}]}`},
+		{"repeated tail dropped", `{"issues": [{"title": "Missing rollback plan`, `"title": "Missing rollback plan"}]}`, `{"issues": [{"title": "Missing rollback plan"}]}`},
+		{"short overlap kept", `[1, 2`, `2]`, `[1, 22]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StitchContinuation(tt.partial, tt.cont); got != tt.want {
+				t.Errorf("StitchContinuation = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrFiltered is wrapped by provider errors for a response blocked by
+// the provider's content filter rather than declined by the model.
+var ErrFiltered = errors.New("response blocked by content filter")
+
+// ErrorClass is a coarse category of provider error. It tells callers
+// whether to retry and how to report a failure without matching on
+// status codes or messages.
+type ErrorClass string
+
+const (
+	// ClassAuth: the API key is missing, invalid, or lacks permission.
+	ClassAuth ErrorClass = "auth"
+	// ClassQuota: the account is out of credit or over a spending
+	// limit. Retrying does not help until billing changes.
+	ClassQuota ErrorClass = "quota"
+	// ClassRateLimit: too many requests; retrying later succeeds.
+	ClassRateLimit ErrorClass = "rate_limit"
+	// ClassTransient: a network failure, timeout, or server-side
+	// error (5xx, overloaded) that may not recur.
+	ClassTransient ErrorClass = "transient"
+	// ClassInvalidRequest: the request itself was rejected, e.g. an
+	// unknown model or a prompt over the context window.
+	ClassInvalidRequest ErrorClass = "invalid_request"
+	// ClassContentFilter: the model refused or a safety filter blocked
+	// the content.
+	ClassContentFilter ErrorClass = "content_filter"
+	// ClassUnknown: anything else, such as an unparseable response.
+	ClassUnknown ErrorClass = "unknown"
+)
+
+// Retryable reports whether a request that failed with this class may
+// succeed if sent again unchanged.
+func (c ErrorClass) Retryable() bool {
+	return c == ClassRateLimit || c == ClassTransient
+}
+
+// Classify returns the class of an error from a provider call, or ""
+// for nil.
+func Classify(err error) ErrorClass {
+	if err == nil {
+		return ""
+	}
+	if errors.Is(err, ErrRefused) || errors.Is(err, ErrFiltered) {
+		return ClassContentFilter
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Class()
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) || errors.Is(err, context.DeadlineExceeded) {
+		// No response: DNS, TLS, connection, or timeout failures.
+		return ClassTransient
+	}
+	return ClassUnknown
+}
+
+// Class returns the class of a non-2xx response from its status and,
+// where the provider sends one, its error code.
+func (e *APIError) Class() ErrorClass {
+	switch e.Code {
+	case "insufficient_quota":
+		return ClassQuota
+	case "invalid_api_key":
+		return ClassAuth
+	case "content_filter", "content_policy_violation":
+		return ClassContentFilter
+	}
+	switch status := e.StatusCode; {
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return ClassAuth
+	case status == http.StatusBadRequest && strings.Contains(e.Body, "API_KEY_INVALID"):
+		// Gemini reports a bad key as a 400.
+		return ClassAuth
+	case status == http.StatusPaymentRequired:
+		return ClassQuota
+	case status == http.StatusTooManyRequests:
+		return ClassRateLimit
+	case status == http.StatusRequestTimeout, status >= 500:
+		// Includes Anthropic's 529 "overloaded".
+		return ClassTransient
+	case status >= 400:
+		return ClassInvalidRequest
+	}
+	return ClassUnknown
+}
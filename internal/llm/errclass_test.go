@@ -0,0 +1,52 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		{"nil", nil, ""},
+		{"unauthorized", &APIError{StatusCode: 401}, ClassAuth},
+		{"gemini bad key", &APIError{StatusCode: 400, Body: `{"error":{"status":"INVALID_ARGUMENT","details":[{"reason":"API_KEY_INVALID"}]}}`}, ClassAuth},
+		{"openai quota", &APIError{StatusCode: 429, Code: "insufficient_quota"}, ClassQuota},
+		{"rate limited", &APIError{StatusCode: 429}, ClassRateLimit},
+		{"overloaded", &APIError{StatusCode: 529}, ClassTransient},
+		{"server error", fmt.Errorf("wrapped: %w", &APIError{StatusCode: 502}), ClassTransient},
+		{"bad request", &APIError{StatusCode: 400}, ClassInvalidRequest},
+		{"unknown model", &APIError{StatusCode: 404, Code: "model_not_found"}, ClassInvalidRequest},
+		{"refused", fmt.Errorf("anthropic: %w", ErrRefused), ClassContentFilter},
+		{"filtered", fmt.Errorf("gemini: %w", ErrFiltered), ClassContentFilter},
+		{"network", &url.Error{Op: "Post", URL: "https://api.example.com", Err: errors.New("connection refused")}, ClassTransient},
+		{"timeout", context.DeadlineExceeded, ClassTransient},
+		{"other", errors.New("unexpected response"), ClassUnknown},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Classify(tc.err); got != tc.want {
+				t.Errorf("Classify = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	for _, c := range []ErrorClass{ClassRateLimit, ClassTransient} {
+		if !c.Retryable() {
+			t.Errorf("%s not retryable", c)
+		}
+	}
+	for _, c := range []ErrorClass{ClassAuth, ClassQuota, ClassInvalidRequest, ClassContentFilter, ClassUnknown, ""} {
+		if c.Retryable() {
+			t.Errorf("%q retryable", c)
+		}
+	}
+}
@@ -5,9 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 )
@@ -40,11 +38,16 @@ type GeminiProvider struct {
 
 // NewGemini creates a Gemini provider using the GEMINI_API_KEY env var.
 func NewGemini() (*GeminiProvider, error) {
-	key := os.Getenv("GEMINI_API_KEY")
-	if key == "" {
-		return nil, fmt.Errorf("GEMINI_API_KEY environment variable not set")
+	return newGemini(KeySources{})
+}
+
+// newGemini is NewGemini with the key read from keys before the env var.
+func newGemini(keys KeySources) (*GeminiProvider, error) {
+	key, err := keys.lookup("gemini", "GEMINI_API_KEY")
+	if err != nil {
+		return nil, err
 	}
-	return &GeminiProvider{apiKey: key, apiURL: geminiAPIBaseURL, client: &http.Client{Timeout: 5 * time.Minute}}, nil
+	return &GeminiProvider{apiKey: key, apiURL: geminiAPIBaseURL, client: &http.Client{}}, nil
 }
 
 func (g *GeminiProvider) Name() string { return "gemini" }
@@ -60,7 +63,9 @@ func (g *GeminiProvider) Generate(ctx context.Context, prompt string, s Settings
 // cachedContent field instead. Gemini's cache model prepends cached
 // content to the request contents, so cache-marked segments MUST form
 // a contiguous prefix — otherwise the model would see the prompt in
-// reordered form. Interleaved marks return an error.
+// reordered form. Interleaved marks return an error. System segments
+// (and Settings.System) are sent as the systemInstruction unless they
+// are part of the cached prefix.
 func (g *GeminiProvider) GenerateSegments(ctx context.Context, segments []Segment, s Settings) (string, Usage, error) {
 	model := s.Model
 	if model == "" {
@@ -72,23 +77,25 @@ func (g *GeminiProvider) GenerateSegments(ctx context.Context, segments []Segmen
 		maxTokens = 16384
 	}
 
-	tail := segments
+	var tail []Segment
 	if s.CachedContentName != "" {
 		end, ok := contiguousCachePrefixEnd(segments)
 		if !ok {
 			return "", Usage{}, fmt.Errorf("gemini: cache-marked segments must form a contiguous prefix")
 		}
+		// The cache holds the system instruction; Gemini rejects a
+		// request that sets both.
 		tail = segments[end:]
+	} else {
+		tail = withSystem(segments, s)
 	}
 
-	var body strings.Builder
-	for _, seg := range tail {
-		body.WriteString(seg.Text)
-	}
+	system, body := SplitSystem(tail)
 
 	reqBody := geminiRequest{
+		SystemInstruction: geminiSystemInstruction(system),
 		Contents: []geminiContent{
-			{Parts: []geminiPart{{Text: body.String()}}},
+			{Parts: []geminiPart{{Text: body}}},
 		},
 		GenerationConfig: geminiGenerationConfig{
 			Temperature:      s.Temperature,
@@ -109,6 +116,10 @@ func (g *GeminiProvider) GenerateSegments(ctx context.Context, segments []Segmen
 	}
 
 	url := fmt.Sprintf("%s/models/%s:generateContent", g.apiURL, strings.TrimPrefix(model, "models/"))
+
+	ctx, cancel := requestContext(ctx, s)
+	defer cancel()
+
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(raw))
 	if err != nil {
 		return "", Usage{}, fmt.Errorf("gemini: create request: %w", err)
@@ -122,13 +133,13 @@ func (g *GeminiProvider) GenerateSegments(ctx context.Context, segments []Segmen
 	}
 	defer func() { _ = resp.Body.Close() }()
 
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err := readResponseBody(resp)
 	if err != nil {
 		return "", Usage{}, fmt.Errorf("gemini: read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", Usage{}, fmt.Errorf("gemini: API returned %d: %s", resp.StatusCode, string(respBody))
+		return "", Usage{}, apiError("gemini", resp.StatusCode, respBody)
 	}
 
 	var result geminiResponse
@@ -143,6 +154,9 @@ func (g *GeminiProvider) GenerateSegments(ctx context.Context, segments []Segmen
 	}
 
 	if len(result.Candidates) == 0 {
+		if r := result.PromptFeedback.BlockReason; r != "" {
+			return "", usage, fmt.Errorf("gemini: %w (blockReason=%s)", ErrFiltered, r)
+		}
 		return "", usage, fmt.Errorf("gemini: no candidates in response")
 	}
 
@@ -151,8 +165,12 @@ func (g *GeminiProvider) GenerateSegments(ctx context.Context, segments []Segmen
 	for _, part := range candidate.Content.Parts {
 		out.WriteString(part.Text)
 	}
+	switch candidate.FinishReason {
+	case "SAFETY", "PROHIBITED_CONTENT", "BLOCKLIST", "SPII":
+		return "", usage, fmt.Errorf("gemini: %w (finishReason=%s)", ErrFiltered, candidate.FinishReason)
+	}
 	if candidate.FinishReason == "MAX_TOKENS" {
-		return out.String(), usage, fmt.Errorf("gemini: response truncated (hit maxOutputTokens=%d)", maxTokens)
+		return out.String(), usage, fmt.Errorf("gemini: %w (hit maxOutputTokens=%d)", ErrTruncated, maxTokens)
 	}
 	if out.Len() == 0 {
 		return "", usage, fmt.Errorf("gemini: no text content in response")
@@ -175,23 +193,24 @@ func (g *GeminiProvider) CreateCache(ctx context.Context, segments []Segment, mo
 		return CacheHandle{}, fmt.Errorf("gemini: cache-marked segments must form a contiguous prefix")
 	}
 
-	var body strings.Builder
-	for _, seg := range segments[:end] {
-		body.WriteString(seg.Text)
-	}
-	if body.Len() == 0 {
+	system, body := SplitSystem(segments[:end])
+	size := len(system) + len(body)
+	if size == 0 {
 		return CacheHandle{}, fmt.Errorf("gemini: no cacheable segments provided")
 	}
-	if body.Len() < GeminiMinCacheChars {
-		return CacheHandle{}, fmt.Errorf("gemini: cacheable prefix too small (%d chars, need ≥%d)", body.Len(), GeminiMinCacheChars)
+	if size < GeminiMinCacheChars {
+		return CacheHandle{}, fmt.Errorf("gemini: cacheable prefix too small (%d chars, need ≥%d)", size, GeminiMinCacheChars)
 	}
 
 	reqBody := geminiCacheCreateRequest{
-		Model: "models/" + strings.TrimPrefix(model, "models/"),
-		Contents: []geminiContent{
-			{Role: "user", Parts: []geminiPart{{Text: body.String()}}},
-		},
-		TTL: fmt.Sprintf("%ds", int(ttl.Seconds())),
+		Model:             "models/" + strings.TrimPrefix(model, "models/"),
+		SystemInstruction: geminiSystemInstruction(system),
+		TTL:               fmt.Sprintf("%ds", int(ttl.Seconds())),
+	}
+	if body != "" {
+		reqBody.Contents = []geminiContent{
+			{Role: "user", Parts: []geminiPart{{Text: body}}},
+		}
 	}
 
 	raw, err := json.Marshal(reqBody)
@@ -213,13 +232,13 @@ func (g *GeminiProvider) CreateCache(ctx context.Context, segments []Segment, mo
 	}
 	defer func() { _ = resp.Body.Close() }()
 
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err := readResponseBody(resp)
 	if err != nil {
 		return CacheHandle{}, fmt.Errorf("gemini: read cache response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return CacheHandle{}, fmt.Errorf("gemini: cache API returned %d: %s", resp.StatusCode, string(respBody))
+		return CacheHandle{}, fmt.Errorf("gemini: cache API returned %d: %s", resp.StatusCode, errorBody(respBody))
 	}
 
 	var result geminiCacheCreateResponse
@@ -257,10 +276,20 @@ func contiguousCachePrefixEnd(segments []Segment) (int, bool) {
 	return end, true
 }
 
+// geminiSystemInstruction wraps system text for the systemInstruction
+// field, or returns nil when there is none.
+func geminiSystemInstruction(system string) *geminiContent {
+	if system == "" {
+		return nil
+	}
+	return &geminiContent{Parts: []geminiPart{{Text: system}}}
+}
+
 type geminiRequest struct {
-	Contents         []geminiContent        `json:"contents"`
-	GenerationConfig geminiGenerationConfig `json:"generationConfig"`
-	CachedContent    string                 `json:"cachedContent,omitempty"`
+	SystemInstruction *geminiContent         `json:"systemInstruction,omitempty"`
+	Contents          []geminiContent        `json:"contents"`
+	GenerationConfig  geminiGenerationConfig `json:"generationConfig"`
+	CachedContent     string                 `json:"cachedContent,omitempty"`
 }
 
 type geminiContent struct {
@@ -280,8 +309,11 @@ type geminiGenerationConfig struct {
 }
 
 type geminiResponse struct {
-	Candidates    []geminiCandidate   `json:"candidates"`
-	UsageMetadata geminiUsageMetadata `json:"usageMetadata"`
+	Candidates     []geminiCandidate   `json:"candidates"`
+	UsageMetadata  geminiUsageMetadata `json:"usageMetadata"`
+	PromptFeedback struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
 }
 
 type geminiUsageMetadata struct {
@@ -296,9 +328,10 @@ type geminiCandidate struct {
 }
 
 type geminiCacheCreateRequest struct {
-	Model    string          `json:"model"`
-	Contents []geminiContent `json:"contents"`
-	TTL      string          `json:"ttl"`
+	Model             string          `json:"model"`
+	SystemInstruction *geminiContent  `json:"systemInstruction,omitempty"`
+	Contents          []geminiContent `json:"contents,omitempty"`
+	TTL               string          `json:"ttl"`
 }
 
 type geminiCacheCreateResponse struct {
@@ -0,0 +1,120 @@
+package llm
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// RepairJSON applies lenient local fixes for syntax slips LLMs make in
+// otherwise well-formed JSON: trailing commas before a closing bracket,
+// single-quoted strings, raw newlines and tabs inside strings, and
+// output cut off before its closing brackets. A cut-off document is
+// closed at the last point where it is still valid, dropping a dangling
+// key or half-written literal. The result is not guaranteed to be valid
+// JSON; callers still unmarshal it and fall back to an LLM repair.
+func RepairJSON(s string) string {
+	var out []byte
+	var stack []byte // open containers: '{' or '['
+	type cut struct {
+		pos   int
+		stack string
+	}
+	var cuts []cut
+	var quote byte // current string delimiter, or 0 outside strings
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if quote != 0 {
+			switch {
+			case c == '\\' && i+1 < len(s):
+				next := s[i+1]
+				i++
+				if next == '\'' {
+					// \' is not a JSON escape.
+					out = append(out, '\'')
+					continue
+				}
+				out = append(out, '\\', next)
+			case c == quote:
+				out = append(out, '"')
+				quote = 0
+			case c == '"':
+				// A double quote inside a single-quoted string.
+				out = append(out, '\\', '"')
+			case c == '\n':
+				out = append(out, '\\', 'n')
+			case c == '\r':
+				out = append(out, '\\', 'r')
+			case c == '\t':
+				out = append(out, '\\', 't')
+			default:
+				out = append(out, c)
+			}
+			continue
+		}
+
+		switch c {
+		case '"', '\'':
+			quote = c
+			out = append(out, '"')
+		case '{', '[':
+			stack = append(stack, c)
+			out = append(out, c)
+			cuts = append(cuts, cut{len(out), string(stack)})
+		case '}', ']':
+			out = trimTrailingComma(out)
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			out = append(out, c)
+		case ',':
+			cuts = append(cuts, cut{len(out), string(stack)})
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+
+	if quote == 0 && len(stack) == 0 {
+		return string(out)
+	}
+
+	// Truncated: close the open string and containers, and if that does
+	// not parse, back off to the latest comma or opening bracket.
+	if quote != 0 {
+		out = append(out, '"')
+	}
+	if closed := closeJSON(out, string(stack)); json.Valid(closed) {
+		return string(closed)
+	}
+	for i := len(cuts) - 1; i >= 0; i-- {
+		if closed := closeJSON(out[:cuts[i].pos], cuts[i].stack); json.Valid(closed) {
+			return string(closed)
+		}
+	}
+	return string(closeJSON(out, string(stack)))
+}
+
+// trimTrailingComma drops a comma (and the whitespace after it) at the
+// end of b.
+func trimTrailingComma(b []byte) []byte {
+	trimmed := strings.TrimRight(string(b), " \t\r\n")
+	if strings.HasSuffix(trimmed, ",") {
+		return b[:len(trimmed)-1]
+	}
+	return b
+}
+
+// closeJSON appends the closing brackets for the open containers in
+// stack to a copy of b.
+func closeJSON(b []byte, stack string) []byte {
+	out := trimTrailingComma(append([]byte(nil), b...))
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i] == '{' {
+			out = append(out, '}')
+		} else {
+			out = append(out, ']')
+		}
+	}
+	return out
+}
@@ -0,0 +1,36 @@
+package llm
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRepairJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"valid unchanged", `{"a": [1, 2], "b": "x"}`, `{"a": [1, 2], "b": "x"}`},
+		{"trailing commas", "{\"a\": [1, 2,], \"b\": 3,\n}", `{"a": [1, 2], "b": 3}`},
+		{"single quotes", `{'a': 'it\'s "ok"'}`, `{"a": "it's \"ok\""}`},
+		{"raw newline in string", "{\"a\": \"line1\nline2\"}", `{"a": "line1\nline2"}`},
+		{"comma inside string kept", `{"a": "x,]"}`, `{"a": "x,]"}`},
+		{"truncated in string", `{"issues": [{"title": "Missing rollb`, `{"issues": [{"title": "Missing rollb"}]}`},
+		{"truncated after comma", `{"a": 1, "b": [true,`, `{"a": 1, "b": [true]}`},
+		{"truncated key", `{"a": 1, "b`, `{"a": 1}`},
+		{"truncated after colon", `{"a": 1, "b": `, `{"a": 1}`},
+		{"truncated literal", `{"a": [1, tr`, `{"a": [1]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RepairJSON(tt.in)
+			if got != tt.want {
+				t.Errorf("RepairJSON(%q)\n got %s\nwant %s", tt.in, got, tt.want)
+			}
+			if !json.Valid([]byte(got)) {
+				t.Errorf("result is not valid JSON: %s", got)
+			}
+		})
+	}
+}
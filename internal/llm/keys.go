@@ -0,0 +1,125 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/dshills/plancritic/internal/keyring"
+)
+
+// keyCommandTimeout bounds an API key command. Password managers may
+// wait for the user to unlock them, so it is generous.
+const keyCommandTimeout = 2 * time.Minute
+
+// KeySources says where to read provider API keys from before falling
+// back to the provider's environment variable, so keys need not live
+// in the environment.
+type KeySources struct {
+	// File is read for the key of whichever provider is resolved
+	// (--api-key-file). It takes priority over Files and Commands.
+	File string
+	// Command is run for the key of whichever provider is resolved,
+	// after File and before Files and Commands.
+	Command string
+	// Files maps a provider name to a file holding its key.
+	Files map[string]string
+	// Commands maps a provider name to a command that prints its key,
+	// e.g. "op read op://Private/OpenAI/credential". The command is
+	// split on whitespace and run without a shell.
+	Commands map[string]string
+}
+
+// key returns the key for provider from the configured sources, or ""
+// when none is configured for it.
+func (k KeySources) key(provider string) (string, error) {
+	switch {
+	case k.File != "":
+		return readKeyFile(k.File)
+	case k.Command != "":
+		return runKeyCommand(k.Command)
+	case k.Files[provider] != "":
+		return readKeyFile(k.Files[provider])
+	case k.Commands[provider] != "":
+		return runKeyCommand(k.Commands[provider])
+	}
+	return "", nil
+}
+
+// has reports whether a source other than the environment is
+// configured for provider, without reading it.
+func (k KeySources) has(provider string) bool {
+	return k.File != "" || k.Command != "" || k.Files[provider] != "" || k.Commands[provider] != ""
+}
+
+// lookup returns the key for provider from the configured sources,
+// then from the environment variable env, then from the OS keychain.
+func (k KeySources) lookup(provider, env string) (string, error) {
+	key, err := k.key(provider)
+	if err != nil || key != "" {
+		return key, err
+	}
+	if key := os.Getenv(env); key != "" {
+		return key, nil
+	}
+	if key := keychainKey(provider); key != "" {
+		return key, nil
+	}
+	return "", fmt.Errorf("%s environment variable not set (or store a key with `plancritic auth set %s`)", env, provider)
+}
+
+// available reports whether a key for provider can be found by lookup.
+func (k KeySources) available(provider, env string) bool {
+	return k.has(provider) || os.Getenv(env) != "" || keychainKey(provider) != ""
+}
+
+// keychainKey returns the key stored for provider with `plancritic auth
+// set`, or "". A missing or locked keychain counts as no key so it
+// never stops the other sources from working; PLANCRITIC_NO_KEYRING
+// skips the keychain entirely. Tests replace it.
+var keychainKey = func(provider string) string {
+	if os.Getenv("PLANCRITIC_NO_KEYRING") != "" {
+		return ""
+	}
+	key, err := keyring.Get(provider)
+	if err != nil {
+		return ""
+	}
+	return key
+}
+
+func readKeyFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read API key file: %w", err)
+	}
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return "", fmt.Errorf("API key file %s is empty", path)
+	}
+	return key, nil
+}
+
+func runKeyCommand(command string) (string, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return "", fmt.Errorf("empty API key command")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), keyCommandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	// Password managers prompt on stderr.
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("API key command %q: %w", args[0], err)
+	}
+	key := strings.TrimSpace(string(out))
+	if key == "" {
+		return "", fmt.Errorf("API key command %q printed nothing", args[0])
+	}
+	return key, nil
+}
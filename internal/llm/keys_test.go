@@ -0,0 +1,130 @@
+package llm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKeySourcesBeforeEnv(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "sk-env")
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("GEMINI_API_KEY", "")
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	if err := os.WriteFile(keyFile, []byte("sk-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		provider string
+		keys     KeySources
+		want     string
+	}{
+		{"env fallback", "anthropic", KeySources{}, "sk-env"},
+		{"flag file", "anthropic", KeySources{File: keyFile, Commands: map[string]string{"anthropic": "echo sk-cmd"}}, "sk-file"},
+		{"config file", "anthropic", KeySources{Files: map[string]string{"anthropic": keyFile}}, "sk-file"},
+		{"command", "anthropic", KeySources{Commands: map[string]string{"anthropic": "echo sk-cmd"}}, "sk-cmd"},
+		{"other provider's command", "anthropic", KeySources{Commands: map[string]string{"openai": "echo sk-cmd"}}, "sk-env"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ResolveProviderWith(tt.provider, "", ProviderOptions{Keys: tt.keys})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := Unwrap(p).(*AnthropicProvider).apiKey; got != tt.want {
+				t.Errorf("key = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeySourcesAutoDetect(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("GEMINI_API_KEY", "")
+
+	p, err := ResolveProviderWith("", "", ProviderOptions{Keys: KeySources{Commands: map[string]string{"gemini": "echo g-cmd"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, ok := p.(*GeminiProvider); !ok || g.apiKey != "g-cmd" {
+		t.Errorf("provider = %#v, want gemini with the command's key", p)
+	}
+
+	_, err = ResolveProviderWith("", "", ProviderOptions{Keys: KeySources{File: "key"}})
+	if err == nil || !strings.Contains(err.Error(), "--provider or --model") {
+		t.Errorf("key file without a provider: err = %v", err)
+	}
+}
+
+func TestKeySourcesErrors(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty")
+	if err := os.WriteFile(empty, []byte("\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]KeySources{
+		"missing file":   {File: filepath.Join(dir, "missing")},
+		"empty file":     {File: empty},
+		"failing cmd":    {Commands: map[string]string{"openai": "false"}},
+		"silent cmd":     {Commands: map[string]string{"openai": "true"}},
+		"unknown binary": {Commands: map[string]string{"openai": "plancritic-no-such-binary"}},
+	}
+	for name, keys := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ResolveProviderWith("openai", "", ProviderOptions{Keys: keys}); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestKeySourcesLocal(t *testing.T) {
+	t.Setenv("LOCAL_API_KEY", "local-env")
+	p, err := ResolveProviderWith("local", "", ProviderOptions{Keys: KeySources{Commands: map[string]string{"local": "echo local-cmd"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Unwrap(p).(*LocalProvider).apiKey; got != "local-cmd" {
+		t.Errorf("key = %q, want local-cmd", got)
+	}
+}
+
+func TestKeychainAfterEnv(t *testing.T) {
+	defer func(f func(string) string) { keychainKey = f }(keychainKey)
+	keychainKey = func(provider string) string {
+		if provider == "openai" {
+			return "sk-keychain"
+		}
+		return ""
+	}
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("GEMINI_API_KEY", "")
+
+	p, err := ResolveProviderWith("", "", ProviderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	op, ok := Unwrap(p).(*OpenAIProvider)
+	if !ok || op.apiKey != "sk-keychain" {
+		t.Fatalf("auto-detected %T, want the OpenAI provider with the keychain key", Unwrap(p))
+	}
+
+	t.Setenv("OPENAI_API_KEY", "sk-env")
+	p, err = ResolveProviderWith("openai", "", ProviderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Unwrap(p).(*OpenAIProvider).apiKey; got != "sk-env" {
+		t.Errorf("key = %q, want the environment to take priority", got)
+	}
+
+	if _, err := ResolveProviderWith("gemini", "", ProviderOptions{}); err == nil || !strings.Contains(err.Error(), "plancritic auth set gemini") {
+		t.Errorf("error = %v, want a hint to store a key", err)
+	}
+}
@@ -0,0 +1,80 @@
+package llm
+
+import "strings"
+
+// outputLimits maps model name prefixes to the most output tokens a
+// request may ask for. Longer prefixes are listed first so the most
+// specific entry wins. Like prices, the table is maintained by hand.
+var outputLimits = []struct {
+	prefix string
+	limit  int
+}{
+	{"claude-opus-4-6", 128000},
+	{"claude-opus-4-5", 64000},
+	{"claude-opus-4", 32000},
+	{"claude-sonnet-4", 64000},
+	{"claude-haiku-4", 64000},
+	{"claude-3-7-sonnet", 64000},
+	{"claude-3-5", 8192},
+	{"claude-3", 4096},
+	{"gpt-5", 128000},
+	{"gpt-4.1", 32768},
+	{"gpt-4o", 16384},
+	{"gpt-4-turbo", 4096},
+	{"gpt-4", 8192},
+	{"o1", 100000},
+	{"o3", 100000},
+	{"o4", 100000},
+	{"gemini-2.5", 65536},
+	{"gemini-2.0", 8192},
+	{"gemini-1.5", 8192},
+}
+
+// contextWindows maps model name prefixes to context window sizes, in
+// the same order and kept the same way as outputLimits.
+var contextWindows = []struct {
+	prefix string
+	limit  int
+}{
+	{"claude-", 200000},
+	{"gpt-5", 400000},
+	{"gpt-4.1", 1047576},
+	{"gpt-4o", 128000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4", 8192},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4", 200000},
+	{"gemini-2.5", 1048576},
+	{"gemini-2.0", 1048576},
+	{"gemini-1.5", 1048576},
+}
+
+// MaxOutputTokens returns the output token limit of the model p serves:
+// the override set on p, else model, else the provider's default. It
+// returns 0 when the limit is unknown, as for local models.
+func MaxOutputTokens(p Provider, model string) int {
+	return p.Capabilities(model).MaxOutputTokens
+}
+
+// modelLimits returns the context window and output limit of model, or
+// of p's default model when model is empty; 0 for an unknown model.
+func modelLimits(p Provider, model string) (context, output int) {
+	if model == "" {
+		model = defaultModel(p)
+	}
+	model = strings.ToLower(stripProviderPrefix(model))
+	for _, e := range contextWindows {
+		if strings.HasPrefix(model, e.prefix) {
+			context = e.limit
+			break
+		}
+	}
+	for _, e := range outputLimits {
+		if strings.HasPrefix(model, e.prefix) {
+			output = e.limit
+			break
+		}
+	}
+	return context, output
+}
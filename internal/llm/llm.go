@@ -18,6 +18,37 @@ type Settings struct {
 	// "cachedContents/abc123" for Gemini). Only honored by providers
 	// that implement CachingProvider.
 	CachedContentName string
+	// Timeout bounds a single Generate call, including reading the
+	// response. Zero means DefaultTimeout. A deadline already on the
+	// caller's context still applies if it is sooner.
+	Timeout time.Duration
+	// System is the system prompt for the request: instructions kept
+	// apart from the user content. Segmented calls may carry it as
+	// leading System segments instead (see Segment.System).
+	System string
+	// Prefill starts the model's turn with this text, e.g. "{" to force
+	// the response to open with JSON. Providers that support prefilling
+	// (Anthropic) include it in the returned text, so a truncated
+	// response is still a prefix of the full output. Others ignore it.
+	Prefill string
+	// ReasoningEffort sets how much an OpenAI reasoning model thinks
+	// before answering (e.g. "minimal", "low", "medium", "high"); empty
+	// leaves the model's default. Other models and providers ignore it.
+	ReasoningEffort string
+}
+
+// DefaultTimeout is the per-request timeout used when Settings.Timeout
+// is zero.
+const DefaultTimeout = 5 * time.Minute
+
+// requestContext derives the context for one provider request from
+// the caller's context and s.Timeout.
+func requestContext(ctx context.Context, s Settings) (context.Context, context.CancelFunc) {
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return context.WithTimeout(ctx, timeout)
 }
 
 // Usage reports token counts for a single request. Cache-related fields
@@ -31,10 +62,13 @@ type Usage struct {
 
 // Provider generates text from a prompt using an LLM. Usage reports
 // token counts for the returned response and is tied to that specific
-// call (no shared state on the provider).
+// call (no shared state on the provider). Capabilities reports what the
+// provider honors for a model, the provider's default (or a wrapper's
+// override) when model is empty.
 type Provider interface {
 	Generate(ctx context.Context, prompt string, settings Settings) (string, Usage, error)
 	Name() string
+	Capabilities(model string) Capabilities
 }
 
 // Segment is a piece of prompt text that may optionally mark a cache
@@ -47,6 +81,10 @@ type Segment struct {
 	// checkpoint at the end of this segment. The provider is free to
 	// ignore the mark if the cumulative prefix is too small to cache.
 	CacheMark bool
+	// System marks the segment as instructions to send as the system
+	// prompt rather than as user content. System segments lead the
+	// prompt; providers without a system role send them first.
+	System bool
 }
 
 // SegmentedProvider is an optional extension interface implemented by
@@ -91,6 +129,29 @@ func ConcatSegments(segs []Segment) string {
 	return b.String()
 }
 
+// SplitSystem returns the concatenated text of the System segments and
+// of the remaining (user) segments.
+func SplitSystem(segs []Segment) (system, user string) {
+	var sys, usr strings.Builder
+	for _, s := range segs {
+		if s.System {
+			sys.WriteString(s.Text)
+		} else {
+			usr.WriteString(s.Text)
+		}
+	}
+	return sys.String(), usr.String()
+}
+
+// withSystem prepends s.System as a System segment unless segs already
+// begin with one.
+func withSystem(segs []Segment, s Settings) []Segment {
+	if s.System == "" || (len(segs) > 0 && segs[0].System) {
+		return segs
+	}
+	return append([]Segment{{Text: s.System, System: true}}, segs...)
+}
+
 // ExtractJSON strips markdown code fences from LLM responses that wrap JSON.
 // It handles cases where the LLM adds prose before or after a code fence block.
 func ExtractJSON(s string) string {
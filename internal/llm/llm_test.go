@@ -1,8 +1,11 @@
 package llm
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -43,6 +46,18 @@ func TestResolveProviderOpenAIPrefix(t *testing.T) {
 	}
 }
 
+func TestResolveProviderAlias(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+	t.Setenv("OPENAI_API_KEY", "test-key")
+	p, err := ResolveProviderWith("", "fast", ProviderOptions{Aliases: map[string]string{"fast": "openai:gpt-5.2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name() != "openai" {
+		t.Errorf("expected the aliased openai provider, got %s", p.Name())
+	}
+}
+
 func TestResolveProviderGPTPrefix(t *testing.T) {
 	t.Setenv("OPENAI_API_KEY", "test-key")
 	p, err := ResolveProvider("", "gpt-5.2")
@@ -313,6 +328,47 @@ func TestAnthropicGenerateSegmentsCacheControl(t *testing.T) {
 	}
 }
 
+func TestAnthropicGenerateSegmentsSystem(t *testing.T) {
+	var captured anthropicRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&captured)
+		resp := map[string]any{
+			"content":     []map[string]string{{"type": "text", "text": `{"ok": true}`}},
+			"stop_reason": "end_turn",
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	p := &AnthropicProvider{apiKey: "test-key", apiURL: srv.URL, client: srv.Client()}
+	segs := []Segment{
+		{Text: "instructions\n", CacheMark: true, System: true},
+		{Text: "plan content\n"},
+	}
+	if _, _, err := p.GenerateSegments(context.Background(), segs, Settings{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(captured.System) != 1 || captured.System[0].Text != "instructions\n" {
+		t.Fatalf("system = %+v, want the instruction segment", captured.System)
+	}
+	if captured.System[0].CacheControl == nil {
+		t.Error("cache-marked system block should keep cache_control")
+	}
+	blocks := captured.Messages[0].Content
+	if len(blocks) != 1 || blocks[0].Text != "plan content\n" {
+		t.Errorf("user blocks = %+v, want only the plan", blocks)
+	}
+
+	// Settings.System is used by plain Generate calls such as repair.
+	if _, _, err := p.Generate(context.Background(), "fix it", Settings{System: "rules"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(captured.System) != 1 || captured.System[0].Text != "rules" {
+		t.Errorf("system = %+v, want Settings.System", captured.System)
+	}
+}
+
 func TestAnthropicGenerateSegmentsOmitsEmpty(t *testing.T) {
 	var captured anthropicRequest
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -543,6 +599,69 @@ func TestGeminiGenerateSegmentsWithoutCachedContent(t *testing.T) {
 	}
 }
 
+func TestGeminiSystemInstruction(t *testing.T) {
+	var captured geminiRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		captured = geminiRequest{}
+		_ = json.NewDecoder(r.Body).Decode(&captured)
+		resp := geminiResponse{
+			Candidates: []geminiCandidate{
+				{Content: geminiContent{Parts: []geminiPart{{Text: "ok"}}}},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	p := &GeminiProvider{apiKey: "test-key", apiURL: srv.URL, client: srv.Client()}
+	segs := []Segment{
+		{Text: "instructions", CacheMark: true, System: true},
+		{Text: "plan"},
+	}
+	if _, _, err := p.GenerateSegments(context.Background(), segs, Settings{}); err != nil {
+		t.Fatal(err)
+	}
+	if captured.SystemInstruction == nil || captured.SystemInstruction.Parts[0].Text != "instructions" {
+		t.Errorf("systemInstruction = %+v", captured.SystemInstruction)
+	}
+	if sent := captured.Contents[0].Parts[0].Text; sent != "plan" {
+		t.Errorf("contents = %q, want only the plan", sent)
+	}
+
+	// With a cache the system instruction lives in the cache resource.
+	if _, _, err := p.GenerateSegments(context.Background(), segs, Settings{CachedContentName: "cachedContents/x"}); err != nil {
+		t.Fatal(err)
+	}
+	if captured.SystemInstruction != nil {
+		t.Errorf("systemInstruction must be omitted with cachedContent, got %+v", captured.SystemInstruction)
+	}
+}
+
+func TestOpenAISystemMessage(t *testing.T) {
+	var captured openaiRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&captured)
+		resp := openaiResponse{
+			Choices: []openaiChoice{{Message: openaiMessage{Content: "{}"}}},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	p := &OpenAIProvider{apiKey: "test-key", apiURL: srv.URL, client: srv.Client()}
+	wrapped := &modelOverride{Provider: p, model: "gpt-test"}
+	segs := []Segment{{Text: "rules", System: true}, {Text: "plan"}}
+	if _, _, err := wrapped.GenerateSegments(context.Background(), segs, Settings{}); err != nil {
+		t.Fatal(err)
+	}
+	want := []openaiMessage{{Role: "system", Content: "rules"}, {Role: "user", Content: "plan"}}
+	if len(captured.Messages) != 2 || captured.Messages[0] != want[0] || captured.Messages[1] != want[1] {
+		t.Errorf("messages = %+v, want %+v", captured.Messages, want)
+	}
+}
+
 func TestOpenAIProviderGenerate(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Header.Get("Authorization") != "Bearer test-key" {
@@ -691,6 +810,9 @@ func TestGeminiTruncation(t *testing.T) {
 	if !strings.Contains(err.Error(), "truncated") {
 		t.Errorf("error should mention 'truncated', got: %s", err.Error())
 	}
+	if !errors.Is(err, ErrTruncated) {
+		t.Errorf("error should wrap ErrTruncated, got: %v", err)
+	}
 }
 
 func TestGeminiSeedPassthrough(t *testing.T) {
@@ -887,6 +1009,60 @@ func TestAnthropicTruncation(t *testing.T) {
 	if !strings.Contains(err.Error(), "truncated") {
 		t.Errorf("error should mention 'truncated', got: %s", err.Error())
 	}
+	if !errors.Is(err, ErrTruncated) {
+		t.Errorf("error should wrap ErrTruncated, got: %v", err)
+	}
+}
+
+func TestAnthropicPrefill(t *testing.T) {
+	var captured anthropicRequest
+	reply := `"ok": true}`
+	stop := "end_turn"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&captured)
+		resp := anthropicResponse{Content: []anthropicContentBlock{{Type: "text", Text: reply}}, StopReason: stop}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	p := &AnthropicProvider{apiKey: "test-key", apiURL: srv.URL, client: srv.Client()}
+	out, _, err := p.Generate(context.Background(), "prompt", Settings{Prefill: "{"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(captured.Messages) != 2 || captured.Messages[1].Role != "assistant" || captured.Messages[1].Content[0].Text != "{" {
+		t.Fatalf("messages = %+v, want a trailing assistant prefill", captured.Messages)
+	}
+	if out != `{"ok": true}` {
+		t.Errorf("output = %q, want the prefill restored", out)
+	}
+
+	// A model that repeats the prefill is not doubled.
+	reply = ` {"ok": true}`
+	if out, _, _ = p.Generate(context.Background(), "prompt", Settings{Prefill: "{"}); out != reply {
+		t.Errorf("output = %q, want %q", out, reply)
+	}
+
+	// A truncated response keeps the prefill so it is still a prefix
+	// of the full output.
+	reply, stop = `"partial": tr`, "max_tokens"
+	out, _, err = p.Generate(context.Background(), "prompt", Settings{Prefill: "{"})
+	if err == nil || !strings.Contains(err.Error(), "truncated") {
+		t.Fatalf("err = %v, want truncation error", err)
+	}
+	if out != `{"partial": tr` {
+		t.Errorf("truncated output = %q", out)
+	}
+
+	// Without a prefill the request has only the user turn.
+	reply, stop = `{}`, "end_turn"
+	if _, _, err := p.Generate(context.Background(), "prompt", Settings{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(captured.Messages) != 1 {
+		t.Errorf("messages = %d, want 1", len(captured.Messages))
+	}
 }
 
 func TestAnthropicNoTextContent(t *testing.T) {
@@ -1008,6 +1184,9 @@ func TestOpenAITruncation(t *testing.T) {
 	if !strings.Contains(err.Error(), "truncated") {
 		t.Errorf("error should mention 'truncated', got: %s", err.Error())
 	}
+	if !errors.Is(err, ErrTruncated) {
+		t.Errorf("error should wrap ErrTruncated, got: %v", err)
+	}
 }
 
 func TestOpenAISeedPassthrough(t *testing.T) {
@@ -1064,3 +1243,394 @@ func TestOpenAISeedOmittedWhenNil(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestOpenAIGzipResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Simulate a proxy that compresses even though the client
+		// didn't negotiate it.
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		_ = json.NewEncoder(gz).Encode(openaiResponse{
+			Choices: []openaiChoice{{Message: openaiMessage{Content: `{"result": "ok"}`}}},
+		})
+		_ = gz.Close()
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+	p := &OpenAIProvider{apiKey: "test-key", apiURL: srv.URL, client: client}
+	got, _, err := p.Generate(context.Background(), "prompt", Settings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != `{"result": "ok"}` {
+		t.Errorf("unexpected response: %s", got)
+	}
+}
+
+func TestResponseBodyLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		chunk := bytes.Repeat([]byte{' '}, 1<<20)
+		for i := 0; i <= maxResponseBytes>>20; i++ {
+			_, _ = gz.Write(chunk)
+		}
+		_ = gz.Close()
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+	p := &AnthropicProvider{apiKey: "test-key", apiURL: srv.URL, client: client}
+	_, _, err := p.Generate(context.Background(), "prompt", Settings{})
+	if err == nil || !strings.Contains(err.Error(), "exceeded") {
+		t.Fatalf("expected size limit error, got %v", err)
+	}
+}
+
+func TestErrorBodyTruncated(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte(strings.Repeat("x", 10*maxErrorBodyChars)))
+	}))
+	defer srv.Close()
+
+	p := &OpenAIProvider{apiKey: "test-key", apiURL: srv.URL, client: srv.Client()}
+	_, _, err := p.Generate(context.Background(), "prompt", Settings{})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if len(err.Error()) > maxErrorBodyChars+100 {
+		t.Errorf("error message not truncated: %d chars", len(err.Error()))
+	}
+}
+
+func TestResolveProviderLocalPrefix(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("GEMINI_API_KEY", "")
+	p, err := ResolveProviderWith("", "local:qwen2.5-coder", ProviderOptions{APIBase: "http://127.0.0.1:1234"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name() != "local" {
+		t.Errorf("expected local, got %s", p.Name())
+	}
+	if OverrideModel(p) != "qwen2.5-coder" {
+		t.Errorf("expected model qwen2.5-coder, got %q", OverrideModel(p))
+	}
+}
+
+func TestResolveProviderAPIBaseSelectsLocal(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+	p, err := ResolveProviderWith("", "", ProviderOptions{APIBase: "http://127.0.0.1:8080"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name() != "local" {
+		t.Errorf("expected local, got %s", p.Name())
+	}
+}
+
+func TestResolveProviderFlagLocal(t *testing.T) {
+	p, err := ResolveProviderWith("local", "", ProviderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Unwrap(p).(*LocalProvider).apiURL != LocalDefaultAPIBase+"/v1/chat/completions" {
+		t.Errorf("unexpected default URL: %s", Unwrap(p).(*LocalProvider).apiURL)
+	}
+}
+
+func TestLocalChatURL(t *testing.T) {
+	tests := map[string]string{
+		"http://127.0.0.1:8080":                   "http://127.0.0.1:8080/v1/chat/completions",
+		"http://localhost:1234/v1/":               "http://localhost:1234/v1/chat/completions",
+		"https://gpu.lan/llm/v1/chat/completions": "https://gpu.lan/llm/v1/chat/completions",
+		"http://127.0.0.1:8080/proxy":             "http://127.0.0.1:8080/proxy/v1/chat/completions",
+	}
+	for in, want := range tests {
+		got, err := localChatURL(in)
+		if err != nil {
+			t.Errorf("%s: %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("localChatURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if _, err := localChatURL("127.0.0.1:8080"); err == nil {
+		t.Error("expected error for URL without scheme")
+	}
+}
+
+func TestLocalProviderGenerate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "" {
+			t.Error("local provider should not send Authorization without LOCAL_API_KEY")
+		}
+		var raw map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&raw)
+		if _, ok := raw["max_tokens"]; !ok {
+			t.Error("expected max_tokens in request")
+		}
+		if _, ok := raw["response_format"]; ok {
+			t.Error("local provider should not request a response format")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(openaiResponse{
+			Choices: []openaiChoice{{Message: openaiMessage{Content: `{"result": "ok"}`}}},
+		})
+	}))
+	defer srv.Close()
+
+	t.Setenv("LOCAL_API_KEY", "")
+	p, err := NewLocal(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _, err := p.Generate(context.Background(), "prompt", Settings{Model: "llama"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != `{"result": "ok"}` {
+		t.Errorf("unexpected response: %s", got)
+	}
+}
+
+func TestSettingsTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	p := &OpenAIProvider{apiKey: "test-key", apiURL: srv.URL, client: srv.Client()}
+	start := time.Now()
+	_, _, err := p.Generate(context.Background(), "prompt", Settings{Timeout: 50 * time.Millisecond})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("timeout not applied, call took %s", elapsed)
+	}
+}
+
+func TestIsReasoningModel(t *testing.T) {
+	for model, want := range map[string]bool{
+		"gpt-5.2":           true,
+		"gpt-5-mini":        true,
+		"openai:o3":         true,
+		"o4-mini":           true,
+		"o1":                true,
+		"gpt-5-chat-latest": false,
+		"gpt-4.1":           false,
+		"gpt-4o":            false,
+		"omni-moderation":   false,
+	} {
+		if got := IsReasoningModel(model); got != want {
+			t.Errorf("IsReasoningModel(%q) = %v, want %v", model, got, want)
+		}
+	}
+}
+
+func TestOpenAIReasoningModelParameters(t *testing.T) {
+	var raw map[string]json.RawMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw = nil
+		_ = json.NewDecoder(r.Body).Decode(&raw)
+		resp := openaiResponse{Choices: []openaiChoice{{Message: openaiMessage{Content: `{"ok": true}`}}}}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+	p := &OpenAIProvider{apiKey: "test-key", apiURL: srv.URL, client: srv.Client()}
+
+	if _, _, err := p.Generate(context.Background(), "prompt", Settings{Model: "o4-mini", Temperature: 0.2, ReasoningEffort: "high"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := raw["temperature"]; ok {
+		t.Error("reasoning model request should omit temperature")
+	}
+	if string(raw["reasoning_effort"]) != `"high"` {
+		t.Errorf("reasoning_effort = %s, want \"high\"", raw["reasoning_effort"])
+	}
+	if _, ok := raw["max_completion_tokens"]; !ok {
+		t.Error("request should set max_completion_tokens")
+	}
+
+	if _, _, err := p.Generate(context.Background(), "prompt", Settings{Model: "gpt-4.1", Temperature: 0.2, ReasoningEffort: "high"}); err != nil {
+		t.Fatal(err)
+	}
+	if string(raw["temperature"]) != "0.2" {
+		t.Errorf("temperature = %s, want 0.2", raw["temperature"])
+	}
+	if _, ok := raw["reasoning_effort"]; ok {
+		t.Error("non-reasoning model request should omit reasoning_effort")
+	}
+}
+
+func TestOpenAIErrorHints(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{"quota", http.StatusTooManyRequests, `{"error":{"message":"You exceeded your current quota.","type":"insufficient_quota","param":null,"code":"insufficient_quota"}}`, "out of credit"},
+		{"quota in type", http.StatusTooManyRequests, `{"error":{"message":"You exceeded your current quota.","type":"insufficient_quota"}}`, "out of credit"},
+		{"model", http.StatusNotFound, `{"error":{"message":"The model gpt-9 does not exist or you do not have access to it.","type":"invalid_request_error","code":"model_not_found"}}`, "OPENAI_ORG_ID"},
+		{"unknown code", http.StatusBadRequest, `{"error":{"message":"Something odd.","type":"invalid_request_error","code":"odd"}}`, "API returned 400"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			p := &OpenAIProvider{apiKey: "test-key", apiURL: srv.URL, client: srv.Client()}
+			_, _, err := p.Generate(context.Background(), "prompt", Settings{})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want it to contain %q", err, tt.want)
+			}
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+				t.Errorf("err = %#v, want an APIError with status %d", err, tt.status)
+			}
+		})
+	}
+}
+
+func TestOpenAIResponseFormatFallback(t *testing.T) {
+	var formats []bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openaiRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+			return
+		}
+		formats = append(formats, req.ResponseFormat != nil)
+		if req.ResponseFormat != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"message":"Invalid parameter: 'response_format' of type 'json_object' is not supported with this model.","type":"invalid_request_error","param":"response_format","code":null}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"{}"},"finish_reason":"stop"}]}`))
+	}))
+	defer srv.Close()
+
+	p := &OpenAIProvider{apiKey: "test-key", apiURL: srv.URL, client: srv.Client()}
+	text, _, err := p.Generate(context.Background(), "prompt", Settings{Model: "gpt-4o-search-preview"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "{}" {
+		t.Errorf("text = %q", text)
+	}
+	if len(formats) != 2 || !formats[0] || formats[1] {
+		t.Errorf("response_format sent = %v, want [true false]", formats)
+	}
+}
+
+func TestOpenAIJSONModeModels(t *testing.T) {
+	for model, want := range map[string]bool{
+		"gpt-5.2":           true,
+		"gpt-4o":            true,
+		"o3-mini":           true,
+		"gpt-4":             false,
+		"gpt-4-0613":        false,
+		"gpt-4-32k":         false,
+		"o1-mini":           false,
+		"openai:o1-preview": false,
+	} {
+		req := openaiBuildRequest("prompt", Settings{Model: model})
+		if got := req.ResponseFormat != nil; got != want {
+			t.Errorf("%s: response_format sent = %v, want %v", model, got, want)
+		}
+		if req.MaxCompletionTokens == 0 {
+			t.Errorf("%s: max_completion_tokens not set", model)
+		}
+	}
+}
+
+func TestOpenAIOrganizationHeaders(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "test-key")
+	t.Setenv("OPENAI_ORG_ID", "org-123")
+	t.Setenv("OPENAI_PROJECT_ID", "proj_456")
+	var org, project string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		org, project = r.Header.Get("OpenAI-Organization"), r.Header.Get("OpenAI-Project")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"{}"},"finish_reason":"stop"}]}`))
+	}))
+	defer srv.Close()
+
+	p, err := NewOpenAI()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.apiURL, p.client = srv.URL, srv.Client()
+	if _, _, err := p.Generate(context.Background(), "prompt", Settings{}); err != nil {
+		t.Fatal(err)
+	}
+	if org != "org-123" || project != "proj_456" {
+		t.Errorf("headers = %q, %q", org, project)
+	}
+}
+
+func TestContentFilterErrors(t *testing.T) {
+	gemini := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"promptFeedback":{"blockReason":"SAFETY"}}`))
+	}))
+	defer gemini.Close()
+	openai := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":""},"finish_reason":"content_filter"}]}`))
+	}))
+	defer openai.Close()
+
+	providers := []Provider{
+		&GeminiProvider{apiKey: "test-key", apiURL: gemini.URL, client: gemini.Client()},
+		&OpenAIProvider{apiKey: "test-key", apiURL: openai.URL, client: openai.Client()},
+	}
+	for _, p := range providers {
+		_, _, err := p.Generate(context.Background(), "prompt", Settings{Model: "m"})
+		if !errors.Is(err, ErrFiltered) {
+			t.Errorf("%s: error = %v, want ErrFiltered", p.Name(), err)
+		}
+		if c := Classify(err); c != ClassContentFilter {
+			t.Errorf("%s: class = %q, want content_filter", p.Name(), c)
+		}
+	}
+}
+
+func TestMaxOutputTokens(t *testing.T) {
+	anthropic := &AnthropicProvider{}
+	cases := []struct {
+		name  string
+		p     Provider
+		model string
+		want  int
+	}{
+		{"default model", anthropic, "", 64000},
+		{"settings model", anthropic, "claude-opus-4-1", 32000},
+		{"override", &modelOverride{Provider: &OpenAIProvider{}, model: "gpt-4o-mini"}, "", 16384},
+		{"prefixed", &GeminiProvider{}, "gemini:gemini-2.5-pro", 65536},
+		{"local", &LocalProvider{}, "llama3", 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := MaxOutputTokens(tc.p, tc.model); got != tc.want {
+				t.Errorf("MaxOutputTokens = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// LocalDefaultAPIBase is the server used by the local provider when no
+// API base is given (llama.cpp server's default listen address).
+const LocalDefaultAPIBase = "http://127.0.0.1:8080"
+
+// LocalProvider implements Provider against a self-hosted server that
+// speaks the OpenAI Chat Completions wire format, such as llama.cpp
+// server or LM Studio. No API key is required; LOCAL_API_KEY is sent as
+// a bearer token when set, for servers started with one.
+//
+// Unlike OpenAIProvider it sends max_tokens rather than
+// max_completion_tokens and does not request a JSON response format,
+// since support for both varies between local servers. The prompt
+// already asks for JSON and the pipeline extracts it from the output.
+type LocalProvider struct {
+	apiKey string
+	apiURL string
+	client *http.Client
+}
+
+// NewLocal creates a local provider for the server at apiBase, which
+// may be a bare origin ("http://127.0.0.1:8080"), a /v1 base, or the
+// full chat completions URL.
+func NewLocal(apiBase string) (*LocalProvider, error) {
+	apiURL, err := localChatURL(apiBase)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalProvider{
+		apiKey: os.Getenv("LOCAL_API_KEY"),
+		apiURL: apiURL,
+		client: &http.Client{},
+	}, nil
+}
+
+func localChatURL(apiBase string) (string, error) {
+	if apiBase == "" {
+		apiBase = LocalDefaultAPIBase
+	}
+	u, err := url.Parse(apiBase)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid API base %q: want an http(s) URL such as %s", apiBase, LocalDefaultAPIBase)
+	}
+	path := strings.TrimRight(u.Path, "/")
+	switch {
+	case strings.HasSuffix(path, "/chat/completions"):
+	case strings.HasSuffix(path, "/v1"):
+		path += "/chat/completions"
+	default:
+		path += "/v1/chat/completions"
+	}
+	u.Path = path
+	return u.String(), nil
+}
+
+func (l *LocalProvider) Name() string { return "local" }
+
+func (l *LocalProvider) Generate(ctx context.Context, prompt string, s Settings) (string, Usage, error) {
+	maxTokens := s.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = 16384
+	}
+
+	reqBody := localRequest{
+		Model:       s.Model,
+		MaxTokens:   maxTokens,
+		Temperature: s.Temperature,
+		Seed:        s.Seed,
+		Messages:    chatMessages(s.System, prompt),
+	}
+
+	body, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", Usage{}, fmt.Errorf("local: marshal request: %w", err)
+	}
+
+	ctx, cancel := requestContext(ctx, s)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.apiURL, bytes.NewReader(body))
+	if err != nil {
+		return "", Usage{}, fmt.Errorf("local: create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if l.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+l.apiKey)
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return "", Usage{}, fmt.Errorf("local: request to %s failed (is the server running?): %w", l.apiURL, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := readResponseBody(resp)
+	if err != nil {
+		return "", Usage{}, fmt.Errorf("local: read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", Usage{}, apiError("local", resp.StatusCode, respBody)
+	}
+
+	var result openaiResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", Usage{}, fmt.Errorf("local: parse response: %w", err)
+	}
+
+	usage := Usage{
+		InputTokens:  result.Usage.PromptTokens,
+		OutputTokens: result.Usage.CompletionTokens,
+	}
+
+	if len(result.Choices) == 0 {
+		return "", usage, fmt.Errorf("local: no choices in response")
+	}
+
+	choice := result.Choices[0]
+	if choice.FinishReason == "length" {
+		return choice.Message.Content, usage, fmt.Errorf("local: %w (hit max_tokens=%d)", ErrTruncated, maxTokens)
+	}
+
+	return choice.Message.Content, usage, nil
+}
+
+type localRequest struct {
+	Model       string          `json:"model,omitempty"`
+	MaxTokens   int             `json:"max_tokens"`
+	Temperature float64         `json:"temperature"`
+	Seed        *int            `json:"seed,omitempty"`
+	Messages    []openaiMessage `json:"messages"`
+}
@@ -1,15 +1,243 @@
 package llm
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
 
-// MockProvider is a test double that returns canned responses.
+	"gopkg.in/yaml.v3"
+)
+
+// MockDemoResponse is the review returned by the mock provider when no
+// scenario file is given ("--model mock:"). It is a clean verdict so
+// demos and smoke tests pass for any plan.
+const MockDemoResponse = `{"tool":"plancritic","version":"1.0","summary":{"verdict":"EXECUTABLE_AS_IS","score":100,"critical_count":0,"warn_count":0,"info_count":0},"issues":[],"questions":[]}`
+
+// MockProvider is a deterministic provider for tests, demos, and
+// end-to-end CLI runs without API keys.
+//
+// With no Steps it returns Response and Err on every call. With Steps,
+// each call uses the first step whose Match accepts the prompt and
+// whose Times budget is not yet spent; when no step applies it falls
+// back to Response and Err, or fails if neither is set. A sequence of
+// responses is a list of steps with Times: 1.
 type MockProvider struct {
 	Response string
 	Err      error
+	// Latency delays every response that does not set its own. The
+	// delay honours the request timeout and context cancellation.
+	Latency time.Duration
+	Steps   []MockStep
+
+	mu      sync.Mutex
+	used    []int
+	prompts []string
+	batches map[string]map[string]BatchResult
+}
+
+// MockStep is one scripted reply.
+type MockStep struct {
+	// Match, when non-nil, restricts the step to prompts it matches.
+	Match    *regexp.Regexp
+	Response string
+	Err      error
+	Latency  time.Duration
+	// Times is how many calls the step answers; zero means unlimited.
+	Times int
 }
 
 func (m *MockProvider) Name() string { return "mock" }
 
-func (m *MockProvider) Generate(_ context.Context, _ string, _ Settings) (string, Usage, error) {
-	return m.Response, Usage{}, m.Err
+func (m *MockProvider) Generate(ctx context.Context, prompt string, s Settings) (string, Usage, error) {
+	resp, err, latency := m.next(prompt)
+	if latency > 0 {
+		ctx, cancel := requestContext(ctx, s)
+		defer cancel()
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return "", Usage{}, fmt.Errorf("mock: %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+	return resp, Usage{}, err
+}
+
+// Prompts returns the prompts received so far, in call order.
+func (m *MockProvider) Prompts() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.prompts...)
+}
+
+func (m *MockProvider) next(prompt string) (string, error, time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prompts = append(m.prompts, prompt)
+	if len(m.used) < len(m.Steps) {
+		m.used = append(m.used, make([]int, len(m.Steps)-len(m.used))...)
+	}
+	for i, step := range m.Steps {
+		if step.Times > 0 && m.used[i] >= step.Times {
+			continue
+		}
+		if step.Match != nil && !step.Match.MatchString(prompt) {
+			continue
+		}
+		m.used[i]++
+		latency := step.Latency
+		if latency == 0 {
+			latency = m.Latency
+		}
+		return step.Response, step.Err, latency
+	}
+	if len(m.Steps) > 0 && m.Response == "" && m.Err == nil {
+		return "", fmt.Errorf("mock: no scripted response for call %d", len(m.prompts)), m.Latency
+	}
+	return m.Response, m.Err, m.Latency
+}
+
+// NewMock creates the provider selected by a "mock:" model. An empty
+// scenario returns MockDemoResponse; otherwise scenario is the path of a
+// YAML scenario file (see LoadMockScenario).
+func NewMock(scenario string) (*MockProvider, error) {
+	if scenario == "" {
+		return &MockProvider{Response: MockDemoResponse}, nil
+	}
+	return LoadMockScenario(scenario)
+}
+
+type mockScenarioFile struct {
+	Response     string             `yaml:"response"`
+	ResponseFile string             `yaml:"response_file"`
+	Error        string             `yaml:"error"`
+	Latency      string             `yaml:"latency"`
+	Steps        []mockScenarioStep `yaml:"steps"`
+}
+
+type mockScenarioStep struct {
+	Match        string `yaml:"match"`
+	Response     string `yaml:"response"`
+	ResponseFile string `yaml:"response_file"`
+	Error        string `yaml:"error"`
+	Latency      string `yaml:"latency"`
+	Times        int    `yaml:"times"`
+}
+
+// LoadMockScenario reads a YAML scenario file. The top-level response,
+// response_file, error, and latency keys set the fallback reply; steps
+// lists scripted replies with the same keys plus match (a regular
+// expression tested against the prompt) and times. response_file paths
+// are relative to the scenario file.
+func LoadMockScenario(path string) (*MockProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("mock scenario: %w", err)
+	}
+	var sf mockScenarioFile
+	if err := yaml.Unmarshal(data, &sf); err != nil {
+		return nil, fmt.Errorf("mock scenario %s: %w", path, err)
+	}
+	dir := filepath.Dir(path)
+
+	m := &MockProvider{}
+	if m.Response, err = mockResponse(dir, sf.Response, sf.ResponseFile); err != nil {
+		return nil, fmt.Errorf("mock scenario %s: %w", path, err)
+	}
+	if sf.Error != "" {
+		m.Err = errors.New(sf.Error)
+	}
+	if m.Latency, err = mockLatency(sf.Latency); err != nil {
+		return nil, fmt.Errorf("mock scenario %s: %w", path, err)
+	}
+	for i, st := range sf.Steps {
+		step := MockStep{Times: st.Times}
+		if st.Times < 0 {
+			return nil, fmt.Errorf("mock scenario %s: step %d: times must not be negative", path, i+1)
+		}
+		if st.Match != "" {
+			if step.Match, err = regexp.Compile(st.Match); err != nil {
+				return nil, fmt.Errorf("mock scenario %s: step %d: match: %w", path, i+1, err)
+			}
+		}
+		if step.Response, err = mockResponse(dir, st.Response, st.ResponseFile); err != nil {
+			return nil, fmt.Errorf("mock scenario %s: step %d: %w", path, i+1, err)
+		}
+		if st.Error != "" {
+			step.Err = errors.New(st.Error)
+		}
+		if step.Latency, err = mockLatency(st.Latency); err != nil {
+			return nil, fmt.Errorf("mock scenario %s: step %d: %w", path, i+1, err)
+		}
+		m.Steps = append(m.Steps, step)
+	}
+	if m.Response == "" && m.Err == nil && len(m.Steps) == 0 {
+		return nil, fmt.Errorf("mock scenario %s: no response, error, or steps", path)
+	}
+	return m, nil
+}
+
+func mockResponse(dir, inline, file string) (string, error) {
+	if file == "" {
+		return inline, nil
+	}
+	if inline != "" {
+		return "", errors.New("response and response_file are mutually exclusive")
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func mockLatency(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid latency %q", s)
+	}
+	return d, nil
+}
+
+// SubmitBatch answers every request at once, as Generate would, and
+// keeps the results in memory for CollectBatch.
+func (m *MockProvider) SubmitBatch(ctx context.Context, reqs []BatchRequest) (string, error) {
+	results := make(map[string]BatchResult, len(reqs))
+	for _, r := range reqs {
+		_, user := SplitSystem(r.Segments)
+		text, usage, err := m.Generate(ctx, user, r.Settings)
+		results[r.ID] = BatchResult{Text: text, Usage: usage, Err: err}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.batches == nil {
+		m.batches = make(map[string]map[string]BatchResult)
+	}
+	id := fmt.Sprintf("mock-batch-%d", len(m.batches)+1)
+	m.batches[id] = results
+	return id, nil
+}
+
+// CollectBatch returns the results of a batch submitted to m. Batches
+// live in memory, so they cannot be collected by another process.
+func (m *MockProvider) CollectBatch(ctx context.Context, id string) (BatchStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	results, ok := m.batches[id]
+	if !ok {
+		return BatchStatus{}, fmt.Errorf("mock: unknown batch %q", id)
+	}
+	return BatchStatus{Done: true, State: "ended", Results: results}, nil
 }
@@ -0,0 +1,122 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMockProviderSteps(t *testing.T) {
+	m := &MockProvider{
+		Response: "fallback",
+		Steps: []MockStep{
+			{Match: regexp.MustCompile(`repair`), Response: "fixed"},
+			{Response: "first", Times: 1},
+			{Err: errors.New("boom"), Times: 1},
+		},
+	}
+	want := []struct{ prompt, resp, err string }{
+		{"review", "first", ""},
+		{"please repair", "fixed", ""},
+		{"review", "", "boom"},
+		{"review", "fallback", ""},
+	}
+	for i, w := range want {
+		got, _, err := m.Generate(context.Background(), w.prompt, Settings{})
+		if got != w.resp {
+			t.Errorf("call %d: response = %q, want %q", i+1, got, w.resp)
+		}
+		if (err == nil) != (w.err == "") || (err != nil && err.Error() != w.err) {
+			t.Errorf("call %d: err = %v, want %q", i+1, err, w.err)
+		}
+	}
+	if n := len(m.Prompts()); n != len(want) {
+		t.Errorf("recorded %d prompts, want %d", n, len(want))
+	}
+}
+
+func TestMockProviderExhausted(t *testing.T) {
+	m := &MockProvider{Steps: []MockStep{{Response: "only", Times: 1}}}
+	_, _, _ = m.Generate(context.Background(), "p", Settings{})
+	if _, _, err := m.Generate(context.Background(), "p", Settings{}); err == nil {
+		t.Fatal("expected error once steps are exhausted")
+	}
+}
+
+func TestMockProviderLatencyTimeout(t *testing.T) {
+	m := &MockProvider{Response: "late", Latency: time.Second}
+	_, _, err := m.Generate(context.Background(), "p", Settings{Timeout: 10 * time.Millisecond})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+}
+
+func TestLoadMockScenario(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ok.json"), []byte(`{"ok":true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	scenario := filepath.Join(dir, "scenario.yaml")
+	content := `latency: 1ms
+steps:
+  - error: rate limited
+    times: 1
+  - match: "(?i)schema"
+    response: '{"repaired":true}'
+  - response_file: ok.json
+`
+	if err := os.WriteFile(scenario, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := ResolveProvider("", "mock:"+scenario)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, ok := p.(*MockProvider)
+	if !ok {
+		t.Fatalf("expected *MockProvider, got %T", p)
+	}
+	if _, _, err := m.Generate(context.Background(), "x", Settings{}); err == nil || err.Error() != "rate limited" {
+		t.Errorf("first call err = %v, want rate limited", err)
+	}
+	if got, _, _ := m.Generate(context.Background(), "fix the SCHEMA errors", Settings{}); got != `{"repaired":true}` {
+		t.Errorf("matcher response = %q", got)
+	}
+	if got, _, _ := m.Generate(context.Background(), "x", Settings{}); got != `{"ok":true}` {
+		t.Errorf("file response = %q", got)
+	}
+}
+
+func TestLoadMockScenarioInvalid(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"empty":   "latency: 1s\n",
+		"regexp":  "steps:\n  - match: \"(\"\n    response: x\n",
+		"latency": "response: x\nlatency: soon\n",
+	} {
+		path := filepath.Join(dir, name+".yaml")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadMockScenario(path); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestResolveProviderMockDemo(t *testing.T) {
+	p, err := ResolveProvider("mock", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _, err := p.Generate(context.Background(), "p", Settings{})
+	if err != nil || !strings.Contains(got, "EXECUTABLE_AS_IS") {
+		t.Errorf("demo response = %q, %v", got, err)
+	}
+}
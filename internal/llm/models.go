@@ -150,16 +150,23 @@ func ListModels(ctx context.Context, provider string) ([]ModelInfo, error) {
 }
 
 func listOpenAIModels(ctx context.Context) ([]ModelInfo, error) {
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
-		return nil, fmt.Errorf("OPENAI_API_KEY environment variable not set")
+	apiKey, err := KeySources{}.lookup("openai", "OPENAI_API_KEY")
+	if err != nil {
+		return nil, err
 	}
 	var payload struct {
 		Data []struct {
 			ID string `json:"id"`
 		} `json:"data"`
 	}
-	err := getModelsJSON(ctx, modelsAPIURL("openai"), map[string]string{"Authorization": "Bearer " + apiKey}, &payload)
+	headers := map[string]string{"Authorization": "Bearer " + apiKey}
+	if org := os.Getenv("OPENAI_ORG_ID"); org != "" {
+		headers["OpenAI-Organization"] = org
+	}
+	if project := os.Getenv("OPENAI_PROJECT_ID"); project != "" {
+		headers["OpenAI-Project"] = project
+	}
+	err = getModelsJSON(ctx, modelsAPIURL("openai"), headers, &payload)
 	if err != nil {
 		return nil, err
 	}
@@ -173,9 +180,9 @@ func listOpenAIModels(ctx context.Context) ([]ModelInfo, error) {
 }
 
 func listAnthropicModels(ctx context.Context) ([]ModelInfo, error) {
-	apiKey := os.Getenv("ANTHROPIC_API_KEY")
-	if apiKey == "" {
-		return nil, fmt.Errorf("ANTHROPIC_API_KEY environment variable not set")
+	apiKey, err := KeySources{}.lookup("anthropic", "ANTHROPIC_API_KEY")
+	if err != nil {
+		return nil, err
 	}
 	var payload struct {
 		Data []struct {
@@ -184,7 +191,7 @@ func listAnthropicModels(ctx context.Context) ([]ModelInfo, error) {
 		} `json:"data"`
 	}
 	headers := map[string]string{"x-api-key": apiKey, "anthropic-version": anthropicModelsAPIVersion}
-	err := getModelsJSON(ctx, modelsAPIURL("anthropic"), headers, &payload)
+	err = getModelsJSON(ctx, modelsAPIURL("anthropic"), headers, &payload)
 	if err != nil {
 		return nil, err
 	}
@@ -198,9 +205,9 @@ func listAnthropicModels(ctx context.Context) ([]ModelInfo, error) {
 }
 
 func listGeminiModels(ctx context.Context) ([]ModelInfo, error) {
-	apiKey := os.Getenv("GEMINI_API_KEY")
-	if apiKey == "" {
-		return nil, fmt.Errorf("GEMINI_API_KEY environment variable not set")
+	apiKey, err := KeySources{}.lookup("gemini", "GEMINI_API_KEY")
+	if err != nil {
+		return nil, err
 	}
 	var payload struct {
 		Models []struct {
@@ -4,11 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
-	"time"
+	"strings"
 )
 
 const (
@@ -19,77 +19,144 @@ const (
 // OpenAIProvider implements Provider using the OpenAI Chat Completions API.
 type OpenAIProvider struct {
 	apiKey string
-	apiURL string
-	client *http.Client
+	// org and project scope requests to an organization or project
+	// (OPENAI_ORG_ID, OPENAI_PROJECT_ID), for keys that can reach
+	// several and models shared with only one of them.
+	org     string
+	project string
+	apiURL  string
+	client  *http.Client
 }
 
 // NewOpenAI creates an OpenAI provider using the OPENAI_API_KEY env var.
 func NewOpenAI() (*OpenAIProvider, error) {
-	key := os.Getenv("OPENAI_API_KEY")
-	if key == "" {
-		return nil, fmt.Errorf("OPENAI_API_KEY environment variable not set")
+	return newOpenAI(KeySources{})
+}
+
+// newOpenAI is NewOpenAI with the key read from keys before the env var.
+func newOpenAI(keys KeySources) (*OpenAIProvider, error) {
+	key, err := keys.lookup("openai", "OPENAI_API_KEY")
+	if err != nil {
+		return nil, err
 	}
-	return &OpenAIProvider{apiKey: key, apiURL: openaiAPIURL, client: &http.Client{Timeout: 5 * time.Minute}}, nil
+	return &OpenAIProvider{
+		apiKey:  key,
+		org:     os.Getenv("OPENAI_ORG_ID"),
+		project: os.Getenv("OPENAI_PROJECT_ID"),
+		apiURL:  openaiAPIURL,
+		client:  &http.Client{},
+	}, nil
 }
 
 func (o *OpenAIProvider) Name() string { return "openai" }
 
 func (o *OpenAIProvider) Generate(ctx context.Context, prompt string, s Settings) (string, Usage, error) {
-	model := s.Model
-	if model == "" {
-		model = openaiDefaultModel
-	}
-
-	maxTokens := s.MaxTokens
-	if maxTokens <= 0 {
-		maxTokens = 16384
-	}
+	ctx, cancel := requestContext(ctx, s)
+	defer cancel()
 
-	reqBody := openaiRequest{
-		Model:               model,
-		MaxCompletionTokens: maxTokens,
-		Temperature:         s.Temperature,
-		Messages: []openaiMessage{
-			{Role: "user", Content: prompt},
-		},
-		ResponseFormat: &openaiResponseFormat{Type: "json_object"},
+	reqBody := openaiBuildRequest(prompt, s)
+	result, err := o.send(ctx, reqBody)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && reqBody.ResponseFormat != nil && rejectsResponseFormat(apiErr) {
+		// A model missing from the static list that has no JSON mode.
+		// The prompt asks for JSON anyway, so retry without it.
+		reqBody.ResponseFormat = nil
+		result, err = o.send(ctx, reqBody)
 	}
-	if s.Seed != nil {
-		reqBody.Seed = s.Seed
+	if err != nil {
+		return "", Usage{}, err
 	}
+	return openaiResult(result, reqBody.MaxCompletionTokens)
+}
 
+// send posts a Chat Completions request and decodes the response.
+func (o *OpenAIProvider) send(ctx context.Context, reqBody openaiRequest) (openaiResponse, error) {
 	body, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", Usage{}, fmt.Errorf("openai: marshal request: %w", err)
+		return openaiResponse{}, fmt.Errorf("openai: marshal request: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.apiURL, bytes.NewReader(body))
 	if err != nil {
-		return "", Usage{}, fmt.Errorf("openai: create request: %w", err)
+		return openaiResponse{}, fmt.Errorf("openai: create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	o.setAuth(req)
 
 	resp, err := o.client.Do(req)
 	if err != nil {
-		return "", Usage{}, fmt.Errorf("openai: request failed: %w", err)
+		return openaiResponse{}, fmt.Errorf("openai: request failed: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err := readResponseBody(resp)
 	if err != nil {
-		return "", Usage{}, fmt.Errorf("openai: read response: %w", err)
+		return openaiResponse{}, fmt.Errorf("openai: read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", Usage{}, fmt.Errorf("openai: API returned %d: %s", resp.StatusCode, string(respBody))
+		return openaiResponse{}, apiError("openai", resp.StatusCode, respBody)
 	}
 
 	var result openaiResponse
 	if err := json.Unmarshal(respBody, &result); err != nil {
-		return "", Usage{}, fmt.Errorf("openai: parse response: %w", err)
+		return openaiResponse{}, fmt.Errorf("openai: parse response: %w", err)
+	}
+	return result, nil
+}
+
+// setAuth adds the API key and any organization and project headers
+// to req.
+func (o *OpenAIProvider) setAuth(req *http.Request) {
+	req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	if o.org != "" {
+		req.Header.Set("OpenAI-Organization", o.org)
+	}
+	if o.project != "" {
+		req.Header.Set("OpenAI-Project", o.project)
+	}
+}
+
+// openaiBuildRequest builds the Chat Completions request body for
+// prompt and s.
+func openaiBuildRequest(prompt string, s Settings) openaiRequest {
+	model := s.Model
+	if model == "" {
+		model = openaiDefaultModel
+	}
+
+	maxTokens := s.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = 16384
+	}
+
+	// max_completion_tokens replaces the deprecated max_tokens, which
+	// reasoning models reject; every current chat model accepts it.
+	reqBody := openaiRequest{
+		Model:               model,
+		MaxCompletionTokens: maxTokens,
+		Messages:            chatMessages(s.System, prompt),
+	}
+	if supportsJSONMode(model) {
+		reqBody.ResponseFormat = &openaiResponseFormat{Type: "json_object"}
+	}
+	// Reasoning models reject a temperature and take an effort level
+	// instead; other models ignore reasoning_effort at best.
+	if IsReasoningModel(model) {
+		reqBody.ReasoningEffort = s.ReasoningEffort
+	} else {
+		reqBody.Temperature = &s.Temperature
+	}
+	if s.Seed != nil {
+		reqBody.Seed = s.Seed
 	}
+	return reqBody
+}
 
+// openaiResult extracts the text and usage from a Chat Completions
+// response, mapping refusals and truncation to ErrRefused and
+// ErrTruncated.
+func openaiResult(result openaiResponse, maxTokens int) (string, Usage, error) {
 	usage := Usage{
 		InputTokens:  result.Usage.PromptTokens,
 		OutputTokens: result.Usage.CompletionTokens,
@@ -100,8 +167,14 @@ func (o *OpenAIProvider) Generate(ctx context.Context, prompt string, s Settings
 	}
 
 	choice := result.Choices[0]
+	if choice.Message.Refusal != "" {
+		return "", usage, fmt.Errorf("openai: %w: %s", ErrRefused, choice.Message.Refusal)
+	}
+	if choice.FinishReason == "content_filter" {
+		return "", usage, fmt.Errorf("openai: %w", ErrFiltered)
+	}
 	if choice.FinishReason == "length" {
-		return choice.Message.Content, usage, fmt.Errorf("openai: response truncated (hit max_completion_tokens=%d)", maxTokens)
+		return choice.Message.Content, usage, fmt.Errorf("openai: %w (hit max_completion_tokens=%d)", ErrTruncated, maxTokens)
 	}
 
 	return choice.Message.Content, usage, nil
@@ -110,15 +183,107 @@ func (o *OpenAIProvider) Generate(ctx context.Context, prompt string, s Settings
 type openaiRequest struct {
 	Model               string                `json:"model"`
 	MaxCompletionTokens int                   `json:"max_completion_tokens"`
-	Temperature         float64               `json:"temperature"`
+	Temperature         *float64              `json:"temperature,omitempty"`
+	ReasoningEffort     string                `json:"reasoning_effort,omitempty"`
 	Seed                *int                  `json:"seed,omitempty"`
 	Messages            []openaiMessage       `json:"messages"`
 	ResponseFormat      *openaiResponseFormat `json:"response_format,omitempty"`
 }
 
+// ReasoningEfforts lists the reasoning_effort values OpenAI accepts.
+// Not every model takes every level.
+var ReasoningEfforts = []string{"none", "minimal", "low", "medium", "high", "xhigh"}
+
+// IsReasoningModel reports whether an OpenAI model is a reasoning
+// model (the o-series and the GPT-5 family, except its -chat variants),
+// which rejects temperature and accepts reasoning_effort.
+func IsReasoningModel(model string) bool {
+	m := strings.ToLower(stripProviderPrefix(model))
+	switch {
+	case strings.Contains(m, "-chat"):
+		return false
+	case strings.HasPrefix(m, "gpt-5"):
+		return true
+	}
+	for _, prefix := range []string{"o1", "o3", "o4"} {
+		if m == prefix || strings.HasPrefix(m, prefix+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// supportsJSONMode reports whether an OpenAI model accepts
+// response_format json_object. The first o1 releases and the original
+// GPT-4 snapshots reject it; Generate also drops it for any other model
+// whose API says so.
+func supportsJSONMode(model string) bool {
+	m := strings.ToLower(stripProviderPrefix(model))
+	switch {
+	case strings.HasPrefix(m, "o1-preview"), strings.HasPrefix(m, "o1-mini"):
+		return false
+	case m == "gpt-4", m == "gpt-4-0314", m == "gpt-4-0613", strings.HasPrefix(m, "gpt-4-32k"):
+		return false
+	}
+	return true
+}
+
+// rejectsResponseFormat reports whether e is a model refusing
+// response_format.
+func rejectsResponseFormat(e *APIError) bool {
+	return e.StatusCode == http.StatusBadRequest &&
+		(e.Param == "response_format" || strings.Contains(e.Message, "response_format"))
+}
+
+// openaiHints maps OpenAI error codes to what the user can do about
+// them.
+var openaiHints = map[string]string{
+	"insufficient_quota":      "the account is out of credit or over its spending limit; add credits or raise the limit at https://platform.openai.com/settings/organization/billing, or use another provider",
+	"model_not_found":         "the model does not exist or this key cannot use it; check the name, or set OPENAI_ORG_ID or OPENAI_PROJECT_ID to the organization or project that has access",
+	"invalid_api_key":         "the API key was rejected; check OPENAI_API_KEY or --api-key-file",
+	"rate_limit_exceeded":     "rate limited; wait and retry, or run fewer reviews at once",
+	"context_length_exceeded": "the prompt is longer than the model's context window; use fewer context files, pin a --context section, or pick a model with a larger window",
+}
+
+// explainOpenAIError fills in the structured fields of an OpenAI error
+// body and a hint for well-known codes.
+func explainOpenAIError(e *APIError) {
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+			Type    string `json:"type"`
+			Param   string `json:"param"`
+			Code    string `json:"code"`
+		} `json:"error"`
+	}
+	if json.Unmarshal([]byte(e.Body), &body) != nil {
+		return
+	}
+	e.Message, e.Param, e.Code = body.Error.Message, body.Error.Param, body.Error.Code
+	if e.Code == "" {
+		// Quota errors have carried the code only in the type.
+		e.Code = body.Error.Type
+	}
+	e.Hint = openaiHints[e.Code]
+}
+
+// chatMessages builds a Chat Completions message list: an optional
+// system message followed by the user prompt.
+func chatMessages(system, prompt string) []openaiMessage {
+	if system == "" {
+		return []openaiMessage{{Role: "user", Content: prompt}}
+	}
+	return []openaiMessage{
+		{Role: "system", Content: system},
+		{Role: "user", Content: prompt},
+	}
+}
+
 type openaiMessage struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
+	// Refusal is set, instead of Content, when the model declines.
+	Refusal string `json:"refusal,omitempty"`
 }
 
 type openaiResponseFormat struct {
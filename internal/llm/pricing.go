@@ -0,0 +1,97 @@
+package llm
+
+import "strings"
+
+// Price is a model's list price in USD per million tokens.
+type Price struct {
+	Input      float64
+	Output     float64
+	CacheRead  float64
+	CacheWrite float64
+}
+
+// prices maps model name prefixes to list prices. Longer prefixes are
+// listed first so the most specific entry wins. Estimates only:
+// providers change prices, and the table is not updated automatically.
+var prices = []struct {
+	prefix string
+	price  Price
+}{
+	{"claude-opus-4-6", Price{Input: 5, Output: 25, CacheRead: 0.5, CacheWrite: 6.25}},
+	{"claude-opus-4-5", Price{Input: 5, Output: 25, CacheRead: 0.5, CacheWrite: 6.25}},
+	{"claude-opus-4", Price{Input: 15, Output: 75, CacheRead: 1.5, CacheWrite: 18.75}},
+	{"claude-sonnet-4", Price{Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75}},
+	{"claude-haiku-4", Price{Input: 1, Output: 5, CacheRead: 0.1, CacheWrite: 1.25}},
+	{"gpt-5-mini", Price{Input: 0.25, Output: 2, CacheRead: 0.025}},
+	{"gpt-5-nano", Price{Input: 0.05, Output: 0.4, CacheRead: 0.005}},
+	{"gpt-5.2", Price{Input: 1.75, Output: 14, CacheRead: 0.175}},
+	{"gpt-5", Price{Input: 1.25, Output: 10, CacheRead: 0.125}},
+	{"gemini-2.5-flash", Price{Input: 0.3, Output: 2.5, CacheRead: 0.03}},
+	{"gemini-2.5-pro", Price{Input: 1.25, Output: 10, CacheRead: 0.125}},
+}
+
+// EstimateCost estimates the USD cost of usage on p. The model is the
+// override set on p, else s.Model, else the provider's default. Local
+// and mock models cost nothing. The second return is false when the
+// model has no known price.
+func EstimateCost(p Provider, s Settings, u Usage) (float64, bool) {
+	inner := Unwrap(p)
+	switch inner.(type) {
+	case *LocalProvider, *MockProvider:
+		return 0, true
+	}
+	model := EffectiveModel(p, s.Model)
+	if _, ok := inner.(*GeminiProvider); ok {
+		// Gemini's prompt count includes cached tokens.
+		u.InputTokens -= u.CacheReadInputTokens
+	}
+	model = strings.ToLower(stripProviderPrefix(model))
+	for _, e := range prices {
+		if strings.HasPrefix(model, e.prefix) {
+			pr := e.price
+			cost := float64(u.InputTokens)*pr.Input +
+				float64(u.OutputTokens)*pr.Output +
+				float64(u.CacheReadInputTokens)*pr.CacheRead +
+				float64(u.CacheCreationInputTokens)*pr.CacheWrite
+			return cost / 1e6, true
+		}
+	}
+	return 0, false
+}
+
+// Add returns the sum of u and v.
+func (u Usage) Add(v Usage) Usage {
+	return Usage{
+		InputTokens:              u.InputTokens + v.InputTokens,
+		OutputTokens:             u.OutputTokens + v.OutputTokens,
+		CacheCreationInputTokens: u.CacheCreationInputTokens + v.CacheCreationInputTokens,
+		CacheReadInputTokens:     u.CacheReadInputTokens + v.CacheReadInputTokens,
+	}
+}
+
+// EffectiveModel returns the model a request for model is served by on
+// p: the override set on p, else model, else the provider's default.
+// It is "" for a provider without a fixed default when model is empty.
+func EffectiveModel(p Provider, model string) string {
+	if m := OverrideModel(p); m != "" {
+		return m
+	}
+	if model != "" {
+		return model
+	}
+	return defaultModel(Unwrap(p))
+}
+
+// defaultModel returns the model p uses when Settings.Model is empty,
+// or "" for providers without a fixed default.
+func defaultModel(p Provider) string {
+	switch p.(type) {
+	case *AnthropicProvider:
+		return anthropicDefaultModel
+	case *OpenAIProvider:
+		return openaiDefaultModel
+	case *GeminiProvider:
+		return geminiDefaultModel
+	}
+	return ""
+}
@@ -0,0 +1,47 @@
+package llm
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEstimateCost(t *testing.T) {
+	u := Usage{InputTokens: 1_000_000, OutputTokens: 100_000}
+	tests := []struct {
+		name  string
+		p     Provider
+		model string
+		want  float64
+		ok    bool
+	}{
+		{"anthropic default", &AnthropicProvider{}, "", 3 + 1.5, true},
+		{"anthropic prefixed", &AnthropicProvider{}, "anthropic:claude-haiku-4-5", 1 + 0.5, true},
+		{"openai mini", &OpenAIProvider{}, "gpt-5-mini", 0.25 + 0.2, true},
+		{"unknown model", &OpenAIProvider{}, "gpt-99", 0, false},
+		{"local is free", &LocalProvider{}, "llama3", 0, true},
+		{"mock is free", &MockProvider{}, "", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := EstimateCost(tt.p, Settings{Model: tt.model}, u)
+			if ok != tt.ok || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("EstimateCost = %v, %v; want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestEstimateCostCacheTokens(t *testing.T) {
+	u := Usage{CacheReadInputTokens: 1_000_000, CacheCreationInputTokens: 1_000_000}
+	got, ok := EstimateCost(&AnthropicProvider{}, Settings{Model: "claude-sonnet-4-6"}, u)
+	if !ok || math.Abs(got-(0.3+3.75)) > 1e-9 {
+		t.Errorf("EstimateCost = %v, %v; want 4.05", got, ok)
+	}
+
+	// Gemini's prompt count already includes the cached tokens.
+	u = Usage{InputTokens: 2_000_000, CacheReadInputTokens: 1_000_000}
+	got, ok = EstimateCost(&GeminiProvider{}, Settings{Model: "gemini-2.5-pro"}, u)
+	if !ok || math.Abs(got-(1.25+0.125)) > 1e-9 {
+		t.Errorf("EstimateCost = %v, %v; want 1.375", got, ok)
+	}
+}
@@ -0,0 +1,108 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+)
+
+// ProbeStatus classifies the outcome of a Probe.
+type ProbeStatus string
+
+const (
+	ProbeOK          ProbeStatus = "ok"
+	ProbeUnreachable ProbeStatus = "unreachable"
+	ProbeAuthFailed  ProbeStatus = "auth_failed"
+	ProbeNoModel     ProbeStatus = "model_unavailable"
+	ProbeRateLimited ProbeStatus = "rate_limited"
+	ProbeNoQuota     ProbeStatus = "quota_exceeded"
+	ProbeFailed      ProbeStatus = "error"
+)
+
+const (
+	probePrompt       = "Reply with the single word OK."
+	probeMaxTokens    = 16
+	probeDefaultLimit = 30 * time.Second
+)
+
+// ProbeResult reports one Probe.
+type ProbeResult struct {
+	Provider string
+	Model    string
+	Status   ProbeStatus
+	Latency  time.Duration
+	Err      error
+}
+
+// ConfiguredProviders lists the providers that can be resolved without
+// flags: those with a key source in keys or an API key in the
+// environment, then local when apiBase is set.
+func ConfiguredProviders(apiBase string, keys KeySources) []string {
+	var names []string
+	for _, c := range []struct{ name, env string }{
+		{"anthropic", "ANTHROPIC_API_KEY"},
+		{"openai", "OPENAI_API_KEY"},
+		{"gemini", "GEMINI_API_KEY"},
+	} {
+		if keys.Files[c.name] != "" || keys.Commands[c.name] != "" || os.Getenv(c.env) != "" || keychainKey(c.name) != "" {
+			names = append(names, c.name)
+		}
+	}
+	if apiBase != "" {
+		names = append(names, "local")
+	}
+	return names
+}
+
+// Probe sends a tiny prompt to p to check that it is reachable, accepts
+// the credentials, and serves the model. A response cut off at the
+// small token limit still counts: the request was authenticated and
+// the model answered. timeout bounds the request; zero means 30s.
+func Probe(ctx context.Context, p Provider, timeout time.Duration) ProbeResult {
+	if timeout <= 0 {
+		timeout = probeDefaultLimit
+	}
+	r := ProbeResult{Provider: p.Name(), Model: OverrideModel(p)}
+	if r.Model == "" {
+		r.Model = defaultModel(Unwrap(p))
+	}
+	s := Settings{MaxTokens: probeMaxTokens, Timeout: timeout}
+	start := time.Now()
+	_, _, err := p.Generate(ctx, probePrompt, s)
+	r.Latency = time.Since(start)
+	if errors.Is(err, ErrTruncated) {
+		err = nil
+	}
+	r.Err = err
+	r.Status = probeStatus(err)
+	return r
+}
+
+// probeStatus maps a Generate error to a status, refining Classify
+// with whether a response arrived at all and whether the model exists.
+func probeStatus(err error) ProbeStatus {
+	if err == nil {
+		return ProbeOK
+	}
+	var apiErr *APIError
+	isAPI := errors.As(err, &apiErr)
+	switch Classify(err) {
+	case ClassTransient:
+		if !isAPI {
+			return ProbeUnreachable
+		}
+	case ClassAuth:
+		return ProbeAuthFailed
+	case ClassQuota:
+		return ProbeNoQuota
+	case ClassRateLimit:
+		return ProbeRateLimited
+	case ClassInvalidRequest:
+		if apiErr.Code == "model_not_found" || apiErr.StatusCode == http.StatusNotFound {
+			return ProbeNoModel
+		}
+	}
+	return ProbeFailed
+}
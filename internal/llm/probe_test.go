@@ -0,0 +1,77 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestProbe(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   ProbeStatus
+	}{
+		{"ok", http.StatusOK, `{"choices":[{"message":{"content":"OK"},"finish_reason":"stop"}]}`, ProbeOK},
+		{"truncated", http.StatusOK, `{"choices":[{"message":{"content":"O"},"finish_reason":"length"}]}`, ProbeOK},
+		{"bad key", http.StatusUnauthorized, `{"error":{"code":"invalid_api_key"}}`, ProbeAuthFailed},
+		{"no model", http.StatusNotFound, `{"error":{"code":"model_not_found"}}`, ProbeNoModel},
+		{"rate limited", http.StatusTooManyRequests, `{"error":{}}`, ProbeRateLimited},
+		{"server error", http.StatusInternalServerError, `oops`, ProbeFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+			p := &modelOverride{Provider: &OpenAIProvider{apiKey: "test-key", apiURL: srv.URL, client: srv.Client()}, model: "gpt-5-mini"}
+
+			r := Probe(context.Background(), p, time.Second)
+			if r.Status != tt.want {
+				t.Errorf("status = %s, want %s (err %v)", r.Status, tt.want, r.Err)
+			}
+			if (r.Err == nil) != (tt.want == ProbeOK) {
+				t.Errorf("err = %v", r.Err)
+			}
+			if r.Provider != "openai" || r.Model != "gpt-5-mini" {
+				t.Errorf("provider/model = %s/%s", r.Provider, r.Model)
+			}
+		})
+	}
+}
+
+func TestProbeUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	r := Probe(context.Background(), &AnthropicProvider{apiKey: "test-key", apiURL: url, client: &http.Client{}}, time.Second)
+	if r.Status != ProbeUnreachable {
+		t.Errorf("status = %s, want %s (err %v)", r.Status, ProbeUnreachable, r.Err)
+	}
+	if r.Model != anthropicDefaultModel {
+		t.Errorf("model = %q, want the default", r.Model)
+	}
+}
+
+func TestConfiguredProviders(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("GEMINI_API_KEY", "g-test")
+
+	got := ConfiguredProviders("http://127.0.0.1:8080", KeySources{Commands: map[string]string{"openai": "pass openai"}})
+	want := []string{"openai", "gemini", "local"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package llm
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrRefused is wrapped by provider errors for a response the model
+// declined to give (a safety refusal reported by the API).
+var ErrRefused = errors.New("model refused the request")
+
+// refusalPhrases open the prose replies models give instead of a
+// review when they decline.
+var refusalPhrases = []string{
+	"i can't", "i can’t", "i cannot", "i won't", "i won’t", "i will not",
+	"i'm sorry", "i’m sorry", "i am sorry", "i apologize",
+	"i'm unable", "i’m unable", "i am unable", "i'm not able", "i am not able",
+	"unable to comply", "unable to assist", "unable to help",
+}
+
+// LooksLikeRefusal reports whether a response that failed to parse is
+// a refusal or empty rather than malformed JSON worth repairing. A
+// response that mentions the review's fields is an attempt at the
+// review, however broken. The "{" the pipeline prefills is ignored.
+func LooksLikeRefusal(text string) bool {
+	t := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(text), "{"))
+	if t == "" {
+		return true
+	}
+	if strings.Contains(t, `"issues"`) || strings.Contains(t, `"summary"`) {
+		return false
+	}
+	head := strings.ToLower(t[:min(len(t), 300)])
+	for _, p := range refusalPhrases {
+		if strings.Contains(head, p) {
+			return true
+		}
+	}
+	return false
+}
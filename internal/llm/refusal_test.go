@@ -0,0 +1,61 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLooksLikeRefusal(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"", true},
+		{"  {  ", true},
+		{"I'm sorry, but I can't help with reviewing this plan.", true},
+		{"{I cannot assist with that request.", true},
+		{"As an AI, I am unable to comply.", true},
+		{`{"summary": {"verdict": "EXECUTABLE_AS_IS"}, "issues": [}`, false},
+		{`{"tool": "plancritic", "issues": [{"title": "I can't find a rollback step"}]`, false},
+		{"Here is the review: not json", false},
+	}
+	for _, tt := range tests {
+		if got := LooksLikeRefusal(tt.text); got != tt.want {
+			t.Errorf("LooksLikeRefusal(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestOpenAIRefusal(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := openaiResponse{Choices: []openaiChoice{{Message: openaiMessage{Refusal: "I can't help with that."}, FinishReason: "stop"}}}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	p := &OpenAIProvider{apiKey: "test-key", apiURL: srv.URL, client: srv.Client()}
+	_, _, err := p.Generate(context.Background(), "prompt", Settings{})
+	if !errors.Is(err, ErrRefused) {
+		t.Errorf("error should wrap ErrRefused, got: %v", err)
+	}
+}
+
+func TestAnthropicRefusal(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := anthropicResponse{StopReason: "refusal"}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	p := &AnthropicProvider{apiKey: "test-key", apiURL: srv.URL, client: srv.Client()}
+	_, _, err := p.Generate(context.Background(), "prompt", Settings{})
+	if !errors.Is(err, ErrRefused) {
+		t.Errorf("error should wrap ErrRefused, got: %v", err)
+	}
+}
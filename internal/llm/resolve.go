@@ -3,19 +3,87 @@ package llm
 import (
 	"context"
 	"fmt"
-	"os"
+	"net/http"
 	"strings"
 )
 
 // ResolveProvider selects an LLM provider based on the provider flag, model flag,
 // and available API keys (in that priority order).
 func ResolveProvider(providerFlag, modelFlag string) (Provider, error) {
+	return ResolveProviderWith(providerFlag, modelFlag, ProviderOptions{})
+}
+
+// ProviderOptions carries provider settings beyond the provider and
+// model flags.
+type ProviderOptions struct {
+	// APIBase is the server URL for the local provider. A non-empty
+	// APIBase also selects the local provider when neither flag names
+	// another one, so a local server works without any API keys in the
+	// environment.
+	APIBase string
+	// Endpoint replaces the API root of a hosted provider, e.g. an LLM
+	// gateway such as https://gateway.example.com/anthropic/v1. The
+	// local provider uses APIBase instead.
+	Endpoint string
+	// Transport, when non-nil, replaces the HTTP transport of the
+	// resolved provider (see NewTransport).
+	Transport http.RoundTripper
+	// Keys are consulted for the provider's API key before its
+	// environment variable.
+	Keys KeySources
+	// Aliases maps short model names (e.g. "fast") to model specs; the
+	// model is expanded with ExpandAlias before the provider is chosen.
+	Aliases map[string]string
+}
+
+// ExpandAlias returns the model spec aliases maps model to, or model
+// itself when it is not an alias. Expansion is not recursive.
+func ExpandAlias(aliases map[string]string, model string) string {
+	if spec, ok := aliases[model]; ok {
+		return spec
+	}
+	return model
+}
+
+// ResolveProviderWith is ResolveProvider with additional options.
+func ResolveProviderWith(providerFlag, modelFlag string, opts ProviderOptions) (Provider, error) {
+	modelFlag = ExpandAlias(opts.Aliases, modelFlag)
+	p, err := resolveProvider(providerFlag, modelFlag, opts.APIBase, opts.Keys)
+	if err != nil {
+		return nil, err
+	}
+	setTransport(p, opts.Transport)
+	if opts.Endpoint != "" {
+		setEndpoint(p, opts.Endpoint)
+	}
+	return p, nil
+}
+
+// setEndpoint points a hosted provider at base, the API root its
+// request paths are relative to.
+func setEndpoint(p Provider, base string) {
+	base = strings.TrimSuffix(base, "/")
+	switch v := Unwrap(p).(type) {
+	case *AnthropicProvider:
+		v.apiURL = base + "/messages"
+	case *OpenAIProvider:
+		v.apiURL = base + "/chat/completions"
+	case *GeminiProvider:
+		v.apiURL = base
+	}
+}
+
+func resolveProvider(providerFlag, modelFlag, apiBase string, keys KeySources) (Provider, error) {
 	// Explicit --provider flag takes highest priority
 	if providerFlag != "" {
 		model := stripProviderPrefix(modelFlag)
 		switch strings.ToLower(providerFlag) {
+		case "mock":
+			return NewMock(model)
+		case "local":
+			return newLocalWithModel(apiBase, keys, model)
 		case "anthropic":
-			p, err := NewAnthropic()
+			p, err := newAnthropic(keys)
 			if err != nil {
 				return nil, err
 			}
@@ -24,7 +92,7 @@ func ResolveProvider(providerFlag, modelFlag string) (Provider, error) {
 			}
 			return p, nil
 		case "openai":
-			p, err := NewOpenAI()
+			p, err := newOpenAI(keys)
 			if err != nil {
 				return nil, err
 			}
@@ -33,7 +101,7 @@ func ResolveProvider(providerFlag, modelFlag string) (Provider, error) {
 			}
 			return p, nil
 		case "gemini", "google":
-			p, err := NewGemini()
+			p, err := newGemini(keys)
 			if err != nil {
 				return nil, err
 			}
@@ -42,7 +110,7 @@ func ResolveProvider(providerFlag, modelFlag string) (Provider, error) {
 			}
 			return p, nil
 		default:
-			return nil, fmt.Errorf("unknown provider: %q (valid: anthropic, openai, gemini)", providerFlag)
+			return nil, fmt.Errorf("unknown provider: %q (valid: anthropic, openai, gemini, local, mock)", providerFlag)
 		}
 	}
 
@@ -50,43 +118,49 @@ func ResolveProvider(providerFlag, modelFlag string) (Provider, error) {
 	if modelFlag != "" {
 		lower := strings.ToLower(modelFlag)
 		switch {
+		case strings.HasPrefix(lower, "mock:"):
+			return NewMock(modelFlag[len("mock:"):])
+
+		case strings.HasPrefix(lower, "local:"):
+			return newLocalWithModel(apiBase, keys, modelFlag[len("local:"):])
+
 		case strings.HasPrefix(lower, "anthropic:"):
-			p, err := NewAnthropic()
+			p, err := newAnthropic(keys)
 			if err != nil {
 				return nil, err
 			}
 			return &modelOverride{Provider: p, model: strings.TrimPrefix(modelFlag, "anthropic:")}, nil
 
 		case strings.HasPrefix(lower, "claude"):
-			p, err := NewAnthropic()
+			p, err := newAnthropic(keys)
 			if err != nil {
 				return nil, err
 			}
 			return &modelOverride{Provider: p, model: modelFlag}, nil
 
 		case strings.HasPrefix(lower, "openai:"):
-			p, err := NewOpenAI()
+			p, err := newOpenAI(keys)
 			if err != nil {
 				return nil, err
 			}
 			return &modelOverride{Provider: p, model: strings.TrimPrefix(modelFlag, "openai:")}, nil
 
 		case strings.HasPrefix(lower, "gpt"):
-			p, err := NewOpenAI()
+			p, err := newOpenAI(keys)
 			if err != nil {
 				return nil, err
 			}
 			return &modelOverride{Provider: p, model: modelFlag}, nil
 
 		case strings.HasPrefix(lower, "gemini:"):
-			p, err := NewGemini()
+			p, err := newGemini(keys)
 			if err != nil {
 				return nil, err
 			}
 			return &modelOverride{Provider: p, model: strings.TrimPrefix(modelFlag, "gemini:")}, nil
 
 		case strings.HasPrefix(lower, "gemini"):
-			p, err := NewGemini()
+			p, err := newGemini(keys)
 			if err != nil {
 				return nil, err
 			}
@@ -94,18 +168,52 @@ func ResolveProvider(providerFlag, modelFlag string) (Provider, error) {
 		}
 	}
 
-	// Auto-detect from environment
-	if os.Getenv("ANTHROPIC_API_KEY") != "" {
-		return NewAnthropic()
+	// An API base with no recognised provider means a local server
+	if apiBase != "" {
+		return newLocalWithModel(apiBase, keys, modelFlag)
+	}
+
+	// A key file or command names no provider, so it cannot drive
+	// auto-detection
+	if keys.File != "" || keys.Command != "" {
+		return nil, fmt.Errorf("an API key file or command needs --provider or --model to say which provider it is for")
+	}
+
+	// Auto-detect from configured key sources, the environment, and
+	// the OS keychain
+	if keys.available("anthropic", "ANTHROPIC_API_KEY") {
+		return newAnthropic(keys)
 	}
-	if os.Getenv("OPENAI_API_KEY") != "" {
-		return NewOpenAI()
+	if keys.available("openai", "OPENAI_API_KEY") {
+		return newOpenAI(keys)
 	}
-	if os.Getenv("GEMINI_API_KEY") != "" {
-		return NewGemini()
+	if keys.available("gemini", "GEMINI_API_KEY") {
+		return newGemini(keys)
 	}
 
-	return nil, fmt.Errorf("no LLM provider configured: set ANTHROPIC_API_KEY, OPENAI_API_KEY, or GEMINI_API_KEY, or use --provider")
+	return nil, fmt.Errorf("no LLM provider configured: set ANTHROPIC_API_KEY, OPENAI_API_KEY, or GEMINI_API_KEY, or use --provider (--model local:<name> --api-base <url> for a local server)")
+}
+
+func newLocalWithModel(apiBase string, keys KeySources, model string) (Provider, error) {
+	p, err := NewLocal(apiBase)
+	if err != nil {
+		return nil, err
+	}
+	// The local key is optional, so only a configured source replaces
+	// LOCAL_API_KEY, and the keychain only fills in when it is unset.
+	key, err := keys.key("local")
+	if err != nil {
+		return nil, err
+	}
+	if key != "" {
+		p.apiKey = key
+	} else if p.apiKey == "" {
+		p.apiKey = keychainKey("local")
+	}
+	if model != "" {
+		return &modelOverride{Provider: p, model: model}, nil
+	}
+	return p, nil
 }
 
 // Unwrap returns the underlying provider if p is a wrapper (e.g. from
@@ -141,19 +249,24 @@ func (m *modelOverride) Generate(ctx context.Context, prompt string, s Settings)
 }
 
 // GenerateSegments forwards to the wrapped provider when it supports
-// segmented prompts. Otherwise it concatenates segments into a single
-// prompt string and calls Generate.
+// segmented prompts. Otherwise it concatenates the user segments into a
+// single prompt string, passes System segments as Settings.System, and
+// calls Generate.
 func (m *modelOverride) GenerateSegments(ctx context.Context, segments []Segment, s Settings) (string, Usage, error) {
 	s.Model = m.model
 	if sp, ok := m.Provider.(SegmentedProvider); ok {
 		return sp.GenerateSegments(ctx, segments, s)
 	}
-	return m.Provider.Generate(ctx, ConcatSegments(segments), s)
+	system, user := SplitSystem(segments)
+	if system != "" {
+		s.System = system
+	}
+	return m.Provider.Generate(ctx, user, s)
 }
 
 // stripProviderPrefix removes a leading "provider:" prefix from a model name.
 func stripProviderPrefix(model string) string {
-	for _, prefix := range []string{"anthropic:", "openai:", "gemini:"} {
+	for _, prefix := range []string{"anthropic:", "openai:", "gemini:", "local:", "mock:"} {
 		if strings.HasPrefix(strings.ToLower(model), prefix) {
 			return model[len(prefix):]
 		}
@@ -0,0 +1,56 @@
+package llm
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// CountTokens estimates how many tokens text encodes to. It splits text
+// the way BPE tokenizers pre-tokenize it — words with their leading
+// space, digits in groups of three, runs of punctuation, and runs of
+// whitespace — and counts tokens per piece from typical vocabularies:
+// common words are a single token, long words and punctuation runs
+// split into several, and non-ASCII characters take about one token
+// each. Exact counts differ by model, so the result is an estimate for
+// budgeting, not a billing figure.
+func CountTokens(text string) int {
+	n := 0
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		j := i + size
+		switch {
+		case r >= utf8.RuneSelf && !unicode.IsSpace(r):
+			n++
+		case unicode.IsLetter(r) || r == ' ' && j < len(text) && isASCIILetter(text[j]):
+			for j < len(text) && isASCIILetter(text[j]) {
+				j++
+			}
+			n += (j - i + 7) / 8
+		case unicode.IsDigit(r):
+			for j < len(text) && text[j] >= '0' && text[j] <= '9' {
+				j++
+			}
+			n += (j - i + 2) / 3
+		case unicode.IsSpace(r):
+			for j < len(text) && (text[j] == ' ' || text[j] == '\t' || text[j] == '\n' || text[j] == '\r') {
+				j++
+			}
+			n += (j - i + 3) / 4
+		default:
+			for j < len(text) && isASCIIPunct(text[j]) {
+				j++
+			}
+			n += (j - i + 1) / 2
+		}
+		i = j
+	}
+	return n
+}
+
+func isASCIILetter(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+func isASCIIPunct(b byte) bool {
+	return b > ' ' && b < utf8.RuneSelf && !isASCIILetter(b) && (b < '0' || b > '9')
+}
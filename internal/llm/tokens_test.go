@@ -0,0 +1,31 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCountTokens(t *testing.T) {
+	tests := []struct {
+		text     string
+		min, max int
+	}{
+		{"", 0, 0},
+		{"Hello, world!", 3, 5},
+		{"The migration runs before the deploy step.", 7, 10},
+		{"12345", 2, 2},
+		{"L0001: ## Rollout", 5, 9},
+		{"データベース", 6, 6},
+	}
+	for _, tt := range tests {
+		if got := CountTokens(tt.text); got < tt.min || got > tt.max {
+			t.Errorf("CountTokens(%q) = %d, want %d-%d", tt.text, got, tt.min, tt.max)
+		}
+	}
+
+	// Prose runs close to the usual four characters per token.
+	prose := strings.Repeat("Add a rollback step and a test for the cache invalidation path. ", 50)
+	if got, chars := CountTokens(prose), len(prose); got < chars/6 || got > chars/3 {
+		t.Errorf("CountTokens(prose) = %d for %d chars", got, chars)
+	}
+}
@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// TransportOptions configures outbound HTTP for provider clients.
+type TransportOptions struct {
+	// Proxy is an explicit proxy URL (http, https, or socks5). When
+	// empty, HTTPS_PROXY/HTTP_PROXY/NO_PROXY from the environment apply.
+	Proxy string
+	// CACertFile is a PEM bundle trusted in addition to the system
+	// roots, for TLS-intercepting corporate proxies.
+	CACertFile string
+}
+
+// NewTransport returns an http.Transport for the given options, or nil
+// when no options are set so callers keep http.DefaultTransport (which
+// already honors the proxy environment variables).
+func NewTransport(opts TransportOptions) (http.RoundTripper, error) {
+	if opts.Proxy == "" && opts.CACertFile == "" {
+		return nil, nil
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.Proxy != "" {
+		u, err := url.Parse(opts.Proxy)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", opts.Proxy)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme %q (want http, https, or socks5)", u.Scheme)
+		}
+		t.Proxy = http.ProxyURL(u)
+	}
+	if opts.CACertFile != "" {
+		pem, err := os.ReadFile(opts.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", opts.CACertFile)
+		}
+		t.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return t, nil
+}
+
+// setTransport installs rt on the HTTP client of a built-in provider.
+func setTransport(p Provider, rt http.RoundTripper) {
+	if rt == nil {
+		return
+	}
+	switch v := Unwrap(p).(type) {
+	case *AnthropicProvider:
+		v.client.Transport = rt
+	case *OpenAIProvider:
+		v.client.Transport = rt
+	case *GeminiProvider:
+		v.client.Transport = rt
+	case *LocalProvider:
+		v.client.Transport = rt
+	}
+}
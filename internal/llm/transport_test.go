@@ -0,0 +1,84 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewTransportNoOptions(t *testing.T) {
+	rt, err := NewTransport(TransportOptions{})
+	if err != nil || rt != nil {
+		t.Fatalf("expected nil transport without options, got %v, %v", rt, err)
+	}
+}
+
+func TestNewTransportInvalid(t *testing.T) {
+	for _, opts := range []TransportOptions{
+		{Proxy: "ftp://proxy:21"},
+		{Proxy: "not a url"},
+		{CACertFile: filepath.Join(t.TempDir(), "missing.pem")},
+	} {
+		if _, err := NewTransport(opts); err == nil {
+			t.Errorf("expected error for %+v", opts)
+		}
+	}
+}
+
+func TestResolveProviderWithProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		_ = json.NewEncoder(w).Encode(openaiResponse{
+			Choices: []openaiChoice{{Message: openaiMessage{Content: "{}"}}},
+		})
+	}))
+	defer proxy.Close()
+
+	rt, err := NewTransport(TransportOptions{Proxy: proxy.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := ResolveProviderWith("local", "", ProviderOptions{APIBase: "http://llm.internal.example", Transport: rt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := p.Generate(context.Background(), "prompt", Settings{}); err != nil {
+		t.Fatal(err)
+	}
+	if proxied != "http://llm.internal.example/v1/chat/completions" {
+		t.Errorf("request did not go through the proxy, got %q", proxied)
+	}
+}
+
+func TestNewTransportCABundle(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(bundle, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without the bundle the self-signed certificate is rejected.
+	if resp, err := (&http.Client{}).Get(srv.URL); err == nil {
+		_ = resp.Body.Close()
+		t.Fatal("expected TLS verification failure without CA bundle")
+	}
+
+	rt, err := NewTransport(TransportOptions{CACertFile: bundle})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: rt}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request with CA bundle failed: %v", err)
+	}
+	_ = resp.Body.Close()
+}
@@ -0,0 +1,223 @@
+package patch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FileDiff is the parsed unified diff of one file.
+type FileDiff struct {
+	OldName string
+	NewName string
+	Hunks   []Hunk
+}
+
+// Hunk is one @@ section of a unified diff. Lines keep their leading
+// ' ', '-' or '+' marker. Start lines are 1-based; a count of zero
+// means the hunk inserts after (or deletes before) line Start.
+type Hunk struct {
+	OldStart int
+	OldCount int
+	NewStart int
+	NewCount int
+	Lines    []string
+}
+
+// Parse parses a unified diff. It checks that every hunk body matches
+// the line counts in its @@ header and that hunks appear in order
+// without overlapping, but not that the diff applies to any content;
+// see Verify.
+func Parse(text string) ([]FileDiff, error) {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	var files []FileDiff
+	var cur *FileDiff
+	for i := 0; i < len(lines); {
+		line := strings.TrimSuffix(lines[i], "\r")
+		switch {
+		case strings.HasPrefix(line, "--- "):
+			if i+1 >= len(lines) || !strings.HasPrefix(lines[i+1], "+++ ") {
+				return nil, fmt.Errorf("line %d: \"---\" header without \"+++\"", i+1)
+			}
+			files = append(files, FileDiff{
+				OldName: headerName(line[4:]),
+				NewName: headerName(strings.TrimSuffix(lines[i+1], "\r")[4:]),
+			})
+			cur = &files[len(files)-1]
+			i += 2
+		case strings.HasPrefix(line, "@@"):
+			if cur == nil {
+				return nil, fmt.Errorf("line %d: hunk before any file header", i+1)
+			}
+			h, next, err := parseHunk(lines, i)
+			if err != nil {
+				return nil, err
+			}
+			if n := len(cur.Hunks); n > 0 {
+				prev := cur.Hunks[n-1]
+				if h.OldStart < prev.OldStart+prev.OldCount {
+					return nil, fmt.Errorf("line %d: hunk overlaps or precedes the previous hunk", i+1)
+				}
+			}
+			cur.Hunks = append(cur.Hunks, h)
+			i = next
+		case cur == nil || strings.TrimSpace(line) == "":
+			// Preamble (diff --git, index lines) and blank separators.
+			i++
+		default:
+			return nil, fmt.Errorf("line %d: unexpected line outside a hunk: %q", i+1, line)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no file headers found")
+	}
+	for _, f := range files {
+		if len(f.Hunks) == 0 {
+			return nil, fmt.Errorf("%s: no hunks", f.OldName)
+		}
+	}
+	return files, nil
+}
+
+// parseHunk parses the hunk whose header is lines[i] and returns it with
+// the index of the first line after its body.
+func parseHunk(lines []string, i int) (Hunk, int, error) {
+	header := strings.TrimSuffix(lines[i], "\r")
+	var h Hunk
+	if err := parseHunkHeader(header, &h); err != nil {
+		return Hunk{}, 0, fmt.Errorf("line %d: %v", i+1, err)
+	}
+	oldSeen, newSeen := 0, 0
+	j := i + 1
+	for ; j < len(lines) && (oldSeen < h.OldCount || newSeen < h.NewCount); j++ {
+		line := strings.TrimSuffix(lines[j], "\r")
+		if strings.HasPrefix(line, `\`) {
+			// "\ No newline at end of file" annotates the previous line.
+			continue
+		}
+		if line == "" {
+			// Some generators drop the space of an empty context line.
+			line = " "
+		}
+		switch line[0] {
+		case ' ':
+			oldSeen++
+			newSeen++
+		case '-':
+			oldSeen++
+		case '+':
+			newSeen++
+		default:
+			return Hunk{}, 0, fmt.Errorf("line %d: invalid hunk line %q", j+1, line)
+		}
+		h.Lines = append(h.Lines, line)
+	}
+	if oldSeen != h.OldCount || newSeen != h.NewCount {
+		return Hunk{}, 0, fmt.Errorf("line %d: hunk header says -%d +%d lines but the body has -%d +%d", i+1, h.OldCount, h.NewCount, oldSeen, newSeen)
+	}
+	for j < len(lines) && strings.HasPrefix(lines[j], `\`) {
+		j++
+	}
+	return h, j, nil
+}
+
+// parseHunkHeader parses "@@ -a,b +c,d @@ optional text" into h.
+func parseHunkHeader(header string, h *Hunk) error {
+	fields := strings.Fields(header)
+	if len(fields) < 4 || fields[0] != "@@" || fields[3] != "@@" {
+		return fmt.Errorf("malformed hunk header %q", header)
+	}
+	var err error
+	if h.OldStart, h.OldCount, err = parseRange(fields[1], '-'); err != nil {
+		return fmt.Errorf("hunk header %q: %v", header, err)
+	}
+	if h.NewStart, h.NewCount, err = parseRange(fields[2], '+'); err != nil {
+		return fmt.Errorf("hunk header %q: %v", header, err)
+	}
+	return nil
+}
+
+// parseRange parses "-a,b" or "-a" (count 1) with the given sign.
+func parseRange(s string, sign byte) (start, count int, err error) {
+	if len(s) < 2 || s[0] != sign {
+		return 0, 0, fmt.Errorf("range %q must start with %q", s, sign)
+	}
+	startStr, countStr, hasCount := strings.Cut(s[1:], ",")
+	start, err = strconv.Atoi(startStr)
+	if err != nil || start < 0 {
+		return 0, 0, fmt.Errorf("invalid start in range %q", s)
+	}
+	count = 1
+	if hasCount {
+		count, err = strconv.Atoi(countStr)
+		if err != nil || count < 0 {
+			return 0, 0, fmt.Errorf("invalid count in range %q", s)
+		}
+	}
+	if start == 0 && count != 0 {
+		return 0, 0, fmt.Errorf("range %q starts at line 0 but is not empty", s)
+	}
+	return start, count, nil
+}
+
+// headerName strips the timestamp that may follow a tab in a ---/+++
+// header.
+func headerName(s string) string {
+	name, _, _ := strings.Cut(s, "\t")
+	return strings.TrimSpace(name)
+}
+
+// Verify checks that the hunks of f apply to lines: every context and
+// removed line must match the content at the position the header
+// gives, and the new-side line numbers must agree with the old side
+// after the earlier hunks' changes. Trailing whitespace is ignored.
+func (f FileDiff) Verify(lines []string) error {
+	offset := 0
+	for n, h := range f.Hunks {
+		pos := h.OldStart
+		if h.OldCount > 0 {
+			// A non-empty range starts at the line itself rather than
+			// after it.
+			pos--
+		}
+		if want := pos + offset; h.NewStart-boolInt(h.NewCount > 0) != want {
+			return fmt.Errorf("hunk %d: new start %d does not follow from old start %d", n+1, h.NewStart, h.OldStart)
+		}
+		if pos+h.OldCount > len(lines) {
+			return fmt.Errorf("hunk %d: old range %d,%d is past the end of the %d-line file", n+1, h.OldStart, h.OldCount, len(lines))
+		}
+		for _, l := range h.Lines {
+			if l[0] == '+' {
+				continue
+			}
+			got := strings.TrimRight(lines[pos], " \t\r")
+			want := strings.TrimRight(l[1:], " \t\r")
+			if got != want {
+				return fmt.Errorf("hunk %d: line %d is %q, diff expects %q", n+1, pos+1, got, want)
+			}
+			pos++
+		}
+		offset += h.NewCount - h.OldCount
+	}
+	return nil
+}
+
+// Validate parses diff and verifies it against lines, the content of
+// the single file it must change.
+func Validate(diff string, lines []string) error {
+	files, err := Parse(diff)
+	if err != nil {
+		return err
+	}
+	if len(files) != 1 {
+		return fmt.Errorf("diff changes %d files, want 1", len(files))
+	}
+	return files[0].Verify(lines)
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
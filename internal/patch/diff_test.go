@@ -0,0 +1,97 @@
+package patch
+
+import (
+	"strings"
+	"testing"
+)
+
+var planLines = []string{
+	"# Plan",
+	"",
+	"1. Add the endpoint",
+	"2. Make it fast",
+	"3. Deploy",
+}
+
+func TestParse(t *testing.T) {
+	diff := "--- plan.md\t2024-01-01\n+++ plan.md\n@@ -3,2 +3,3 @@ Plan\n 1. Add the endpoint\n-2. Make it fast\n+2. Target p95 < 200ms\n+   Measure with the load test\n@@ -5 +6 @@\n-3. Deploy\n+3. Deploy behind a flag\n\\ No newline at end of file\n"
+	files, err := Parse(diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("files = %d, want 1", len(files))
+	}
+	f := files[0]
+	if f.OldName != "plan.md" || f.NewName != "plan.md" {
+		t.Errorf("names = %q, %q", f.OldName, f.NewName)
+	}
+	if len(f.Hunks) != 2 {
+		t.Fatalf("hunks = %d, want 2", len(f.Hunks))
+	}
+	h := f.Hunks[0]
+	if h.OldStart != 3 || h.OldCount != 2 || h.NewStart != 3 || h.NewCount != 3 || len(h.Lines) != 4 {
+		t.Errorf("hunk 1 = %+v", h)
+	}
+	if h := f.Hunks[1]; h.OldCount != 1 || h.NewCount != 1 {
+		t.Errorf("hunk 2 counts = %d,%d, want 1,1", h.OldCount, h.NewCount)
+	}
+	if err := f.Verify(planLines); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := map[string]string{
+		"no headers":     "@@ -1 +1 @@\n-a\n+b\n",
+		"missing +++":    "--- a\n@@ -1 +1 @@\n-a\n+b\n",
+		"no hunks":       "--- a\n+++ a\n",
+		"bad header":     "--- a\n+++ a\n@@ -x +1 @@\n-a\n+b\n",
+		"short body":     "--- a\n+++ a\n@@ -1,2 +1,2 @@\n-a\n+b\n",
+		"long body":      "--- a\n+++ a\n@@ -1 +1 @@\n-a\n-b\n+c\n",
+		"bad line":       "--- a\n+++ a\n@@ -1 +1 @@\n*a\n+b\n",
+		"trailing prose": "--- a\n+++ a\n@@ -1 +1 @@\n-a\n+b\nThis fixes the step.\n",
+		"overlap":        "--- a\n+++ a\n@@ -2,2 +2,2 @@\n-a\n-b\n+c\n+d\n@@ -3 +3 @@\n-b\n+e\n",
+		"zero start":     "--- a\n+++ a\n@@ -0,1 +1 @@\n-a\n+b\n",
+	}
+	for name, diff := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Parse(diff); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name    string
+		diff    string
+		wantErr string
+	}{
+		{"replace", "--- plan.md\n+++ plan.md\n@@ -4 +4 @@\n-2. Make it fast\n+2. Target p95 < 200ms\n", ""},
+		{"insert", "--- plan.md\n+++ plan.md\n@@ -5,0 +6 @@\n+4. Monitor\n", ""},
+		{"insert at start", "--- plan.md\n+++ plan.md\n@@ -0,0 +1 @@\n+Status: draft\n", ""},
+		{"delete", "--- plan.md\n+++ plan.md\n@@ -4 +3,0 @@\n-2. Make it fast\n", ""},
+		{"trailing space", "--- plan.md\n+++ plan.md\n@@ -4 +4 @@\n-2. Make it fast  \n+2. Fast\n", ""},
+		{"context mismatch", "--- plan.md\n+++ plan.md\n@@ -3,2 +3,2 @@\n 1. Add an endpoint\n-2. Make it fast\n+2. Fast\n", "diff expects"},
+		{"wrong line", "--- plan.md\n+++ plan.md\n@@ -3 +3 @@\n-2. Make it fast\n+2. Fast\n", "diff expects"},
+		{"past end", "--- plan.md\n+++ plan.md\n@@ -5,2 +5,2 @@\n 3. Deploy\n-4. Monitor\n+4. Alert\n", "past the end"},
+		{"new start", "--- plan.md\n+++ plan.md\n@@ -3 +4,2 @@\n-1. Add the endpoint\n+1. Add\n+   the endpoint\n@@ -5 +5 @@\n-3. Deploy\n+3. Ship\n", "new start"},
+		{"two files", "--- a\n+++ a\n@@ -1 +1 @@\n-# Plan\n+# Plan v2\n--- b\n+++ b\n@@ -1 +1 @@\n-x\n+y\n", "2 files"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(tc.diff, planLines)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tc.wantErr)
+			}
+		})
+	}
+}
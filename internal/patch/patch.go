@@ -9,13 +9,8 @@ import (
 	"github.com/dshills/plancritic/internal/review"
 )
 
-// WritePatchFile writes all patch diffs to the given path.
-// If there are no patches, no file is created.
-func WritePatchFile(patches []review.Patch, outPath string) error {
-	if len(patches) == 0 {
-		return nil
-	}
-
+// Unified concatenates the patch diffs, each ending in a newline.
+func Unified(patches []review.Patch) string {
 	var b strings.Builder
 	for _, p := range patches {
 		b.WriteString(p.DiffUnified)
@@ -23,8 +18,16 @@ func WritePatchFile(patches []review.Patch, outPath string) error {
 			b.WriteString("\n")
 		}
 	}
+	return b.String()
+}
 
-	if err := os.WriteFile(outPath, []byte(b.String()), 0644); err != nil {
+// WritePatchFile writes all patch diffs to the given path.
+// If there are no patches, no file is created.
+func WritePatchFile(patches []review.Patch, outPath string) error {
+	if len(patches) == 0 {
+		return nil
+	}
+	if err := os.WriteFile(outPath, []byte(Unified(patches)), 0644); err != nil {
 		return fmt.Errorf("patch.WritePatchFile: %w", err)
 	}
 	return nil
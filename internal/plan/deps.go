@@ -0,0 +1,315 @@
+package plan
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// numberedHeadingPattern matches a numbered heading, "## 3. Deploy"
+	// or "### Step 3: Deploy", capturing its number.
+	numberedHeadingPattern = regexp.MustCompile(`(?i)^ {0,3}#{1,6}\s+(?:step\s+(\d+)\b|(\d+)(?:[.):](?:\s|$)|\s+[-–—]|\s*$))`)
+	// numberedItemPattern matches a numbered list item, capturing its
+	// indentation and number.
+	numberedItemPattern = regexp.MustCompile(`^(\s*)(\d+)[.)]\s+`)
+	// dependencyPattern matches a dependency phrase naming one or more
+	// steps: "depends on step 2", "after steps 3 and 4", "blocked by
+	// step 5". The second group holds the step numbers.
+	dependencyPattern = regexp.MustCompile(`(?i)\b(depends\s+on|dependent\s+on|after|blocked\s+by)\s+steps?\s+(\d+(?:\s*(?:,|&|\band\b|\bor\b)\s*(?:steps?\s+)?\d+)*)`)
+	stepNumberPattern = regexp.MustCompile(`\d+`)
+)
+
+// NumberedStep is a step the plan numbers itself, so other steps can
+// refer to it as "step N".
+type NumberedStep struct {
+	Number int
+	// Line is the step's heading or list item line; its section runs
+	// to LineEnd.
+	Line    int
+	LineEnd int
+	Text    string
+}
+
+// Dependency is one "depends on step N" phrase: step Step depends on
+// step On, as written on Line.
+type Dependency struct {
+	Step   int
+	On     int
+	Line   int
+	Phrase string
+}
+
+// DependencyGraph is the plan's numbered steps, in plan order, and the
+// dependencies their sections state.
+type DependencyGraph struct {
+	Steps []NumberedStep
+	Deps  []Dependency
+}
+
+// Dependencies builds the plan's step dependency graph. The steps are
+// its numbered headings when it has at least two, else its outermost
+// numbered list items; a heading's section runs to the next heading of
+// the same or a higher level, a list item's to the next item or
+// heading. A plan whose step numbers repeat (two lists that each start
+// at 1) has no unambiguous "step N", and so no graph.
+func Dependencies(p *Plan) DependencyGraph {
+	steps := numberedHeadings(p)
+	if len(steps) < 2 {
+		steps = numberedItems(p)
+	}
+	seen := map[int]bool{}
+	for _, s := range steps {
+		if seen[s.Number] {
+			return DependencyGraph{}
+		}
+		seen[s.Number] = true
+	}
+
+	g := DependencyGraph{Steps: steps}
+	for _, s := range steps {
+		inFence := false
+		for ln := s.Line; ln <= s.LineEnd; ln++ {
+			line := p.Lines[ln-1]
+			trimmed := strings.TrimSpace(line)
+			if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+				inFence = !inFence
+				continue
+			}
+			if inFence {
+				continue
+			}
+			for _, m := range dependencyPattern.FindAllStringSubmatch(line, -1) {
+				for _, n := range stepNumberPattern.FindAllString(m[2], -1) {
+					on, err := strconv.Atoi(n)
+					if err != nil {
+						continue
+					}
+					g.Deps = append(g.Deps, Dependency{Step: s.Number, On: on, Line: ln, Phrase: strings.TrimSpace(m[0])})
+				}
+			}
+		}
+	}
+	return g
+}
+
+// numberedHeadings returns the plan's numbered headings as steps.
+func numberedHeadings(p *Plan) []NumberedStep {
+	var steps []NumberedStep
+	var levels []int
+	inFence := false
+	for i := p.bodyIndex(); i < len(p.Lines); i++ {
+		line := p.Lines[i]
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		m := scopeHeadingPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		level := len(m[1])
+		// A heading closes every open step at its level or deeper.
+		for j := range steps {
+			if steps[j].LineEnd == 0 && levels[j] >= level {
+				steps[j].LineEnd = i
+			}
+		}
+		if n := numberedHeadingPattern.FindStringSubmatch(line); n != nil {
+			num, _ := strconv.Atoi(n[1] + n[2])
+			steps = append(steps, NumberedStep{Number: num, Line: i + 1, Text: strings.TrimSpace(m[2])})
+			levels = append(levels, level)
+		}
+	}
+	for j := range steps {
+		if steps[j].LineEnd == 0 {
+			steps[j].LineEnd = len(p.Lines)
+		}
+	}
+	return steps
+}
+
+// numberedItems returns the plan's outermost numbered list items as
+// steps.
+func numberedItems(p *Plan) []NumberedStep {
+	type item struct {
+		indent int
+		step   NumberedStep
+	}
+	var items []item
+	var breaks []int // heading lines, which end a list item's section
+	inFence := false
+	for i := p.bodyIndex(); i < len(p.Lines); i++ {
+		line := p.Lines[i]
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		if scopeHeadingPattern.MatchString(line) {
+			breaks = append(breaks, i+1)
+			continue
+		}
+		if m := numberedItemPattern.FindStringSubmatch(line); m != nil {
+			num, _ := strconv.Atoi(m[2])
+			items = append(items, item{
+				indent: len(strings.ReplaceAll(m[1], "\t", "    ")),
+				step:   NumberedStep{Number: num, Line: i + 1, Text: strings.TrimSpace(line[len(m[0]):])},
+			})
+		}
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	outer := items[0].indent
+	for _, it := range items {
+		outer = min(outer, it.indent)
+	}
+	var steps []NumberedStep
+	for _, it := range items {
+		if it.indent == outer {
+			steps = append(steps, it.step)
+		}
+	}
+	for j := range steps {
+		end := len(p.Lines)
+		if j+1 < len(steps) {
+			end = steps[j+1].Line - 1
+		}
+		for _, b := range breaks {
+			if b > steps[j].Line && b-1 < end {
+				end = b - 1
+				break
+			}
+		}
+		steps[j].LineEnd = end
+	}
+	return steps
+}
+
+// Step returns the step numbered n.
+func (g DependencyGraph) Step(n int) (NumberedStep, bool) {
+	for _, s := range g.Steps {
+		if s.Number == n {
+			return s, true
+		}
+	}
+	return NumberedStep{}, false
+}
+
+// position returns each step number's index in plan order.
+func (g DependencyGraph) position() map[int]int {
+	pos := make(map[int]int, len(g.Steps))
+	for i, s := range g.Steps {
+		pos[s.Number] = i
+	}
+	return pos
+}
+
+// Cycles returns the sets of steps that depend on each other, each in
+// plan order, ordered by their first step. A step that depends on
+// itself is a cycle of one.
+func (g DependencyGraph) Cycles() [][]int {
+	pos := g.position()
+	edges := map[int][]int{}
+	self := map[int]bool{}
+	for _, d := range g.Deps {
+		if _, ok := pos[d.On]; !ok {
+			continue
+		}
+		if d.Step == d.On {
+			self[d.Step] = true
+		}
+		edges[d.Step] = append(edges[d.Step], d.On)
+	}
+
+	// Tarjan's strongly connected components.
+	index := map[int]int{}
+	low := map[int]int{}
+	onStack := map[int]bool{}
+	var stack []int
+	var cycles [][]int
+	var visit func(v int)
+	visit = func(v int) {
+		index[v], low[v] = len(index), len(index)
+		stack = append(stack, v)
+		onStack[v] = true
+		for _, w := range edges[v] {
+			if _, ok := index[w]; !ok {
+				visit(w)
+				low[v] = min(low[v], low[w])
+			} else if onStack[w] {
+				low[v] = min(low[v], index[w])
+			}
+		}
+		if low[v] != index[v] {
+			return
+		}
+		var scc []int
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			scc = append(scc, w)
+			if w == v {
+				break
+			}
+		}
+		if len(scc) > 1 || self[v] {
+			sort.Slice(scc, func(i, j int) bool { return pos[scc[i]] < pos[scc[j]] })
+			cycles = append(cycles, scc)
+		}
+	}
+	for _, s := range g.Steps {
+		if _, ok := index[s.Number]; !ok {
+			visit(s.Number)
+		}
+	}
+	sort.Slice(cycles, func(i, j int) bool { return pos[cycles[i][0]] < pos[cycles[j][0]] })
+	return cycles
+}
+
+// ForwardRefs returns the dependencies on a step that comes later in
+// the plan, leaving out those inside a cycle, which Cycles reports.
+func (g DependencyGraph) ForwardRefs() []Dependency {
+	pos := g.position()
+	inCycle := map[int]int{}
+	for i, c := range g.Cycles() {
+		for _, n := range c {
+			inCycle[n] = i + 1
+		}
+	}
+	var out []Dependency
+	for _, d := range g.Deps {
+		on, ok := pos[d.On]
+		if !ok || on <= pos[d.Step] {
+			continue
+		}
+		if c := inCycle[d.Step]; c != 0 && c == inCycle[d.On] {
+			continue
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+// MissingRefs returns the dependencies on a step number the plan does
+// not have.
+func (g DependencyGraph) MissingRefs() []Dependency {
+	pos := g.position()
+	var out []Dependency
+	for _, d := range g.Deps {
+		if _, ok := pos[d.On]; !ok {
+			out = append(out, d)
+		}
+	}
+	return out
+}
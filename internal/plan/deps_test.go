@@ -0,0 +1,75 @@
+package plan
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestDependenciesHeadings(t *testing.T) {
+	p := Parse("plan.md", `# Rollout
+
+## Step 1: Schema
+Blocked by step 3.
+
+## Step 2: Backfill
+Depends on steps 1 and 3.
+
+### Notes
+After step 1, run the script.
+
+## Step 3: Switch reads
+After step 2.
+
+`+"```"+`
+after step 9
+`+"```"+`
+
+## Step 4: Cleanup
+Depends on step 4. Blocked by step 7.
+`)
+	g := Dependencies(p)
+	var nums []int
+	for _, s := range g.Steps {
+		nums = append(nums, s.Number)
+	}
+	if !reflect.DeepEqual(nums, []int{1, 2, 3, 4}) {
+		t.Fatalf("steps = %v", nums)
+	}
+	var deps []string
+	for _, d := range g.Deps {
+		deps = append(deps, fmt.Sprintf("%d->%d@%d", d.Step, d.On, d.Line))
+	}
+	want := []string{"1->3@4", "2->1@7", "2->3@7", "2->1@10", "3->2@13", "4->4@20", "4->7@20"}
+	if !reflect.DeepEqual(deps, want) {
+		t.Errorf("deps = %v, want %v", deps, want)
+	}
+
+	if got := g.Cycles(); !reflect.DeepEqual(got, [][]int{{1, 2, 3}, {4}}) {
+		t.Errorf("cycles = %v", got)
+	}
+	if got := g.ForwardRefs(); len(got) != 0 {
+		t.Errorf("forward refs inside cycles = %v", got)
+	}
+	if got := g.MissingRefs(); len(got) != 1 || got[0].On != 7 {
+		t.Errorf("missing refs = %v", got)
+	}
+}
+
+func TestDependenciesListItems(t *testing.T) {
+	p := Parse("plan.md", "# Plan\n\n1. Deploy, after step 2\n   1. Nested, depends on step 3\n2. Build\n3. Test\n\n## Later\nAfter step 1.\n")
+	g := Dependencies(p)
+	if len(g.Steps) != 3 || g.Steps[0].LineEnd != 4 || g.Steps[2].LineEnd != 7 {
+		t.Fatalf("steps = %+v", g.Steps)
+	}
+	fwd := g.ForwardRefs()
+	if len(fwd) != 2 || fwd[0].On != 2 || fwd[1].On != 3 || len(g.Cycles()) != 0 {
+		t.Errorf("forward refs = %+v", fwd)
+	}
+
+	// Repeated numbers make "step N" ambiguous.
+	p = Parse("plan.md", "## A\n1. One\n2. Two, after step 1\n## B\n1. Uno, after step 2\n")
+	if g := Dependencies(p); len(g.Steps) != 0 || len(g.Deps) != 0 {
+		t.Errorf("ambiguous plan graph = %+v", g)
+	}
+}
@@ -0,0 +1,102 @@
+package plan
+
+// Change is a run of lines that differ between two revisions of a plan:
+// OldCount lines from line OldStart of the old revision became NewCount
+// lines from line NewStart of the new one. Lines are 1-based; when a
+// count is 0 (a pure insertion or deletion), its start is the line the
+// change comes before.
+type Change struct {
+	OldStart, OldCount int
+	NewStart, NewCount int
+}
+
+// maxDiffCells bounds the table Diff fills to match the lines between
+// the unchanged head and tail of two revisions. Past it the whole middle
+// is reported as one change, which is still correct, only coarser.
+const maxDiffCells = 4 << 20
+
+// Diff returns the changes that turn the lines old into the lines new,
+// in order, keeping the longest run of lines common to both.
+func Diff(old, new []string) []Change {
+	head := 0
+	for head < len(old) && head < len(new) && old[head] == new[head] {
+		head++
+	}
+	tail := 0
+	for tail < len(old)-head && tail < len(new)-head && old[len(old)-1-tail] == new[len(new)-1-tail] {
+		tail++
+	}
+	a, b := old[head:len(old)-tail], new[head:len(new)-tail]
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+	if len(a)*len(b) > maxDiffCells || len(a) == 0 || len(b) == 0 {
+		return []Change{{OldStart: head + 1, OldCount: len(a), NewStart: head + 1, NewCount: len(b)}}
+	}
+
+	// lcs[i*w+j] is the length of the longest common subsequence of
+	// a[i:] and b[j:].
+	w := len(b) + 1
+	lcs := make([]int32, (len(a)+1)*w)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i*w+j] = lcs[(i+1)*w+j+1] + 1
+			case lcs[(i+1)*w+j] >= lcs[i*w+j+1]:
+				lcs[i*w+j] = lcs[(i+1)*w+j]
+			default:
+				lcs[i*w+j] = lcs[i*w+j+1]
+			}
+		}
+	}
+
+	var changes []Change
+	var cur *Change
+	flush := func() {
+		if cur != nil {
+			changes = append(changes, *cur)
+			cur = nil
+		}
+	}
+	open := func(i, j int) {
+		if cur == nil {
+			cur = &Change{OldStart: head + i + 1, NewStart: head + j + 1}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			flush()
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[(i+1)*w+j] >= lcs[i*w+j+1]):
+			open(i, j)
+			cur.OldCount++
+			i++
+		default:
+			open(i, j)
+			cur.NewCount++
+			j++
+		}
+	}
+	flush()
+	return changes
+}
+
+// MapLine returns the line of the new revision that line of the old one
+// became, or false when the line was changed or deleted.
+func MapLine(changes []Change, line int) (int, bool) {
+	shift := 0
+	for _, c := range changes {
+		if line < c.OldStart {
+			break
+		}
+		if line < c.OldStart+c.OldCount {
+			return 0, false
+		}
+		shift += c.NewCount - c.OldCount
+	}
+	return line + shift, true
+}
@@ -0,0 +1,32 @@
+package plan
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	old := strings.Split("a\nb\nc\nd\ne\nf", "\n")
+	new := strings.Split("a\nB\nc\nx\ny\nd\nf\ng", "\n")
+	want := []Change{
+		{OldStart: 2, OldCount: 1, NewStart: 2, NewCount: 1},
+		{OldStart: 4, OldCount: 0, NewStart: 4, NewCount: 2},
+		{OldStart: 5, OldCount: 1, NewStart: 7, NewCount: 0},
+		{OldStart: 7, OldCount: 0, NewStart: 8, NewCount: 1},
+	}
+	got := Diff(old, new)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Diff = %+v, want %+v", got, want)
+	}
+	if Diff(old, old) != nil {
+		t.Error("Diff of equal revisions should be empty")
+	}
+
+	for line, wantNew := range map[int]int{1: 1, 2: 0, 3: 3, 4: 6, 5: 0, 6: 7} {
+		n, ok := MapLine(got, line)
+		if ok != (wantNew != 0) || n != wantNew {
+			t.Errorf("MapLine(%d) = %d, %v; want %d", line, n, ok, wantNew)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dshills/plancritic/internal/review"
+	"gopkg.in/yaml.v3"
+)
+
+// maxFrontMatterLines bounds the search for the closing delimiter, so a
+// plan that merely opens with a thematic break is not scanned in full.
+const maxFrontMatterLines = 200
+
+// ParseFrontMatter reads a YAML front matter block at the top of raw:
+// a "---" line, a YAML mapping, and a closing "---" or "..." line. It
+// returns the metadata and the 1-based line the plan body starts on,
+// or nil and 1 when raw has no front matter. A block that is not a
+// YAML mapping is not front matter and stays part of the body.
+func ParseFrontMatter(raw string) (*review.PlanMeta, int) {
+	lines := strings.Split(raw, "\n")
+	if len(lines) < 2 || strings.TrimRight(strings.TrimPrefix(lines[0], "\ufeff"), " \t\r") != "---" {
+		return nil, 1
+	}
+	end := -1
+	for i := 1; i < len(lines) && i <= maxFrontMatterLines; i++ {
+		if l := strings.TrimRight(lines[i], " \t\r"); l == "---" || l == "..." {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return nil, 1
+	}
+	var fields map[string]any
+	if err := yaml.Unmarshal([]byte(strings.Join(lines[1:end], "\n")), &fields); err != nil || len(fields) == 0 {
+		return nil, 1
+	}
+	meta := &review.PlanMeta{}
+	for key, v := range fields {
+		switch strings.ToLower(strings.ReplaceAll(key, "-", "_")) {
+		case "title":
+			meta.Title = scalar(v)
+		case "owner":
+			meta.Owner = scalar(v)
+		case "target_date", "target":
+			meta.TargetDate = scalar(v)
+		case "related", "related_docs":
+			meta.Related = list(v)
+		case "classification", "data_classification":
+			meta.Classification = scalar(v)
+		case "providers", "allowed_providers":
+			meta.Providers = list(v)
+		case "out_of_scope", "non_goals", "not_in_scope":
+			meta.OutOfScope = append(meta.OutOfScope, list(v)...)
+		default:
+			if meta.Extra == nil {
+				meta.Extra = map[string]any{}
+			}
+			meta.Extra[key] = v
+		}
+	}
+	return meta, end + 2
+}
+
+// scalar formats a front matter value as text. YAML dates decode as
+// times and are kept as dates.
+func scalar(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 {
+			return v.Format("2006-01-02")
+		}
+		return v.Format(time.RFC3339)
+	default:
+		return strings.TrimSpace(fmt.Sprint(v))
+	}
+}
+
+// list accepts a YAML sequence or a single value.
+func list(v any) []string {
+	items, ok := v.([]any)
+	if !ok {
+		if s := scalar(v); s != "" {
+			return []string{s}
+		}
+		return nil
+	}
+	var out []string
+	for _, item := range items {
+		if s := scalar(item); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,125 @@
+package plan
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dshills/plancritic/internal/review"
+)
+
+const frontMatterPlan = `---
+title: Checkout v2
+owner: payments-team
+target-date: 2026-03-01
+related:
+  - docs/adr/0042.md
+  - docs/runbook.md
+risk: high
+---
+# Plan
+
+1. Add the endpoint
+`
+
+func TestParseFrontMatter(t *testing.T) {
+	p := Parse("plan.md", frontMatterPlan)
+	if p.Meta == nil {
+		t.Fatal("front matter not parsed")
+	}
+	m := p.Meta
+	if m.Title != "Checkout v2" || m.Owner != "payments-team" || m.TargetDate != "2026-03-01" {
+		t.Errorf("meta = %+v", m)
+	}
+	if len(m.Related) != 2 || m.Related[1] != "docs/runbook.md" || m.Extra["risk"] != "high" {
+		t.Errorf("related/extra = %v, %v", m.Related, m.Extra)
+	}
+	if p.BodyStart != 10 {
+		t.Errorf("BodyStart = %d, want 10", p.BodyStart)
+	}
+
+	got := LineNumbered(p)
+	if !strings.HasPrefix(got, "L010: # Plan\n") || strings.Contains(got, "owner") {
+		t.Errorf("front matter should be left out with body lines keeping their numbers:\n%s", got)
+	}
+	steps := InferStepIDs(p)
+	if len(steps) != 2 || steps[0].LineStart != 10 || steps[1].Text != "Add the endpoint" {
+		t.Errorf("steps = %+v, want only body steps", steps)
+	}
+}
+
+func TestParseFrontMatterNone(t *testing.T) {
+	cases := map[string]string{
+		"plain":        "# Plan\n\n1. Do it\n",
+		"rule":         "---\n\nSome prose under a rule.\n\n---\n1. Do it\n",
+		"unclosed":     "---\ntitle: x\n# Plan\n",
+		"invalid yaml": "---\ntitle: [x\n---\n# Plan\n",
+	}
+	for name, raw := range cases {
+		t.Run(name, func(t *testing.T) {
+			p := Parse("plan.md", raw)
+			if p.Meta != nil || p.BodyStart != 1 {
+				t.Errorf("meta = %+v, body start %d; want none", p.Meta, p.BodyStart)
+			}
+			if !strings.HasPrefix(LineNumbered(p), "L001: ") {
+				t.Error("numbering should start at line 1")
+			}
+		})
+	}
+}
+
+func TestParseFrontMatterProviderPolicy(t *testing.T) {
+	p := Parse("plan.md", "---\ndata-classification: confidential\nallowed_providers: [local, \"anthropic:claude-haiku-*\"]\n---\n# Plan\n")
+	m := p.Meta
+	if m == nil || m.Classification != "confidential" {
+		t.Fatalf("meta = %+v, want the classification", m)
+	}
+	if len(m.Providers) != 2 || m.Providers[1] != "anthropic:claude-haiku-*" {
+		t.Errorf("providers = %v", m.Providers)
+	}
+	if len(m.Extra) != 0 {
+		t.Errorf("extra = %v, want the policy keys parsed", m.Extra)
+	}
+}
+
+func TestOutOfScope(t *testing.T) {
+	p := Parse("plan.md", `---
+non_goals: [Data migration]
+---
+# Plan
+
+## Goals
+
+- Ship search
+
+## Out of Scope
+
+- Mobile app (Q3)
+- [ ] Billing changes
+
+### Notes
+
+`+"```"+`
+## Not a heading
+`+"```"+`
+
+## Steps
+
+1. Build the index
+
+**Non-goals:**
+- Multi-region
+
+Trailing text
+`)
+	got := OutOfScope(p)
+	want := []review.Exclusion{
+		{Text: "Data migration"},
+		{Text: "Mobile app (Q3)", Line: 12},
+		{Text: "Billing changes", Line: 13},
+		{Text: "Multi-region", Line: 26},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("OutOfScope = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,126 @@
+package plan
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// stopwords are high-frequency function words used to tell Latin-script
+// languages apart. The lists are short on purpose: detection only has to
+// pick the dominant language of a plan, not classify arbitrary text.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "to", "of", "is", "with", "for", "that", "this", "will", "be", "on"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "mit", "für", "wird", "ein", "eine", "auf"},
+	"fr": {"le", "la", "les", "et", "est", "des", "une", "pour", "avec", "dans", "sur", "sera"},
+	"es": {"el", "los", "las", "y", "es", "una", "para", "con", "por", "del", "que", "será"},
+	"pt": {"o", "os", "as", "e", "é", "uma", "para", "com", "não", "do", "da", "que"},
+	"it": {"il", "gli", "e", "è", "una", "per", "con", "non", "della", "che", "sono", "sarà"},
+	"nl": {"de", "het", "en", "is", "een", "voor", "met", "niet", "van", "wordt", "op", "dat"},
+}
+
+var languageNames = map[string]string{
+	"en": "English",
+	"de": "German",
+	"fr": "French",
+	"es": "Spanish",
+	"pt": "Portuguese",
+	"it": "Italian",
+	"nl": "Dutch",
+	"ja": "Japanese",
+	"zh": "Chinese",
+	"ko": "Korean",
+	"ru": "Russian",
+	"ar": "Arabic",
+}
+
+// minLanguageSignal is the minimum number of stopword hits (or script
+// letters) required before DetectLanguage commits to an answer.
+const minLanguageSignal = 3
+
+// DetectLanguage returns the ISO 639-1 code of the dominant language in
+// text, or "" when there is too little signal to tell.
+func DetectLanguage(text string) string {
+	var letters, kana, hangul, han, cyrillic, arabic int
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Arabic, r):
+			arabic++
+		}
+	}
+	if letters == 0 {
+		return ""
+	}
+	// Non-Latin scripts win when they make up a meaningful share of the
+	// letters; plans commonly mix in English identifiers.
+	share := func(n int) bool { return n >= minLanguageSignal && n*10 >= letters*3 }
+	switch {
+	case share(kana) || (kana > 0 && share(kana+han)):
+		return "ja"
+	case share(hangul):
+		return "ko"
+	case share(han):
+		return "zh"
+	case share(cyrillic):
+		return "ru"
+	case share(arabic):
+		return "ar"
+	}
+
+	counts := make(map[string]int)
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		for lang, words := range stopwords {
+			for _, sw := range words {
+				if w == sw {
+					counts[lang]++
+				}
+			}
+		}
+	}
+	best, bestCount := "", 0
+	// Iterate in a fixed order so ties resolve deterministically, with
+	// English first.
+	for _, lang := range []string{"en", "de", "fr", "es", "pt", "it", "nl"} {
+		if counts[lang] > bestCount {
+			best, bestCount = lang, counts[lang]
+		}
+	}
+	if bestCount < minLanguageSignal {
+		return ""
+	}
+	return best
+}
+
+// LanguageName returns the English name for an ISO 639-1 code, or the
+// code itself when it is not known.
+func LanguageName(code string) string {
+	if name, ok := languageNames[strings.ToLower(code)]; ok {
+		return name
+	}
+	return code
+}
+
+// KnownLanguage reports whether code is an ISO 639-1 code LanguageName
+// knows.
+func KnownLanguage(code string) bool {
+	_, ok := languageNames[strings.ToLower(code)]
+	return ok
+}
+
+// LanguageCodes returns the known ISO 639-1 codes, sorted.
+func LanguageCodes() []string {
+	return slices.Sorted(maps.Keys(languageNames))
+}
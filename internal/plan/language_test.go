@@ -0,0 +1,46 @@
+package plan
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"english", "## Step 1\nAdd the table and run the migration for the service. This will be fast.", "en"},
+		{"german", "## Schritt 1\nDie Tabelle wird mit der Migration erstellt und das ist nicht optional für den Dienst.", "de"},
+		{"french", "## Étape 1\nLa table est créée avec la migration et les données sont copiées dans une file pour le service.", "fr"},
+		{"spanish", "## Paso 1\nLa tabla se crea con la migración y los datos se copian para el servicio del equipo.", "es"},
+		{"japanese", "## ステップ1\nテーブルを作成し、データを移行します。", "ja"},
+		{"chinese", "## 第一步\n创建数据表并迁移数据。", "zh"},
+		{"russian", "## Шаг 1\nСоздать таблицу и перенести данные.", "ru"},
+		{"too short", "ok", ""},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectLanguage(tt.text); got != tt.want {
+				t.Errorf("DetectLanguage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLanguageName(t *testing.T) {
+	if got := LanguageName("DE"); got != "German" {
+		t.Errorf("LanguageName(DE) = %q", got)
+	}
+	if got := LanguageName("xx"); got != "xx" {
+		t.Errorf("LanguageName(xx) = %q", got)
+	}
+}
+
+func TestKnownLanguage(t *testing.T) {
+	if !KnownLanguage("DE") || KnownLanguage("xx") {
+		t.Error("KnownLanguage disagrees with LanguageName")
+	}
+	if codes := LanguageCodes(); len(codes) != 12 || codes[0] != "ar" {
+		t.Errorf("codes = %v", codes)
+	}
+}
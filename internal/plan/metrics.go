@@ -0,0 +1,138 @@
+package plan
+
+import (
+	"math"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dshills/plancritic/internal/review"
+)
+
+var (
+	// Effort estimates: "2h", "3 days", "5 points", "Estimate: ..."
+	estimatePattern = regexp.MustCompile(`(?i)\b\d+(?:\.\d+)?\s*(?:h|hrs?|hours?|d|days?|w|wks?|weeks?|pts?|points?|sp)\b|\bestimate[sd]?\b`)
+	// Acceptance criteria markers within a step's section.
+	acceptancePattern = regexp.MustCompile(`(?i)acceptance criteri|done when|definition of done|success criteri|verified by|\bverify that\b`)
+	// Unfinished-text markers.
+	placeholderPattern = regexp.MustCompile(`\b(?:TODO|TBD|TBC|FIXME|XXX)\b|\?\?\?`)
+)
+
+// ComputeMetrics derives deterministic size and coverage metrics for a
+// plan. A step's section runs from its line to the line before the next
+// inferred step (or the end of the plan). contextPaths are the context
+// files supplied with the review; a reference is any plan line that
+// names one of them by base name.
+func ComputeMetrics(p *Plan, steps []StepID, contextPaths []string) review.PlanMetrics {
+	m := review.PlanMetrics{
+		LineCount: len(p.Lines),
+		StepCount: len(steps),
+	}
+
+	var totalWords, withEstimate, withAcceptance int
+	for i, s := range steps {
+		end := len(p.Lines)
+		if i+1 < len(steps) {
+			end = steps[i+1].LineStart - 1
+		}
+		section := strings.Join(p.Lines[s.LineStart-1:end], "\n")
+		totalWords += len(strings.Fields(section))
+		if estimatePattern.MatchString(section) {
+			withEstimate++
+		}
+		if acceptancePattern.MatchString(section) {
+			withAcceptance++
+		}
+	}
+	m.StepsWithoutAcceptance = len(steps) - withAcceptance
+	for _, line := range p.Lines[p.bodyIndex():] {
+		m.Placeholders += len(placeholderPattern.FindAllString(line, -1))
+	}
+	if len(steps) > 0 {
+		n := float64(len(steps))
+		m.AvgStepWords = round1(float64(totalWords) / n)
+		m.EstimateCoverage = round1(float64(withEstimate) * 100 / n)
+		m.AcceptanceCoverage = round1(float64(withAcceptance) * 100 / n)
+	}
+
+	for _, cp := range contextPaths {
+		base := filepath.Base(cp)
+		for _, line := range p.Lines {
+			if strings.Contains(line, base) {
+				m.ContextReferences++
+			}
+		}
+	}
+	return m
+}
+
+// MeasureVagueness counts the vague phrases in the plan body, matched
+// case-insensitively as whole words, and sets m's VaguePhrases and
+// VagueDensity. triggers are the profile's ambiguity triggers (see
+// profile.AmbiguityTriggers). Fenced code is skipped.
+func MeasureVagueness(m *review.PlanMetrics, p *Plan, triggers []string) {
+	m.VaguePhrases, m.VagueDensity = 0, 0
+	words := 0
+	inFence := false
+	for _, line := range p.Lines[p.bodyIndex():] {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		words += len(strings.Fields(line))
+		lower := strings.ToLower(line)
+		for _, t := range triggers {
+			m.VaguePhrases += countPhrase(lower, strings.ToLower(strings.TrimSpace(t)))
+		}
+	}
+	if words > 0 {
+		m.VagueDensity = round1(float64(m.VaguePhrases) * 100 / float64(words))
+	}
+}
+
+// countPhrase counts the occurrences of phrase in s that are not part
+// of a longer word. Scripts written without spaces, such as Chinese and
+// Japanese, match anywhere.
+func countPhrase(s, phrase string) int {
+	if phrase == "" {
+		return 0
+	}
+	first, _ := utf8.DecodeRuneInString(phrase)
+	last, _ := utf8.DecodeLastRuneInString(phrase)
+	n := 0
+	for i := 0; ; {
+		j := strings.Index(s[i:], phrase)
+		if j < 0 {
+			return n
+		}
+		start, end := i+j, i+j+len(phrase)
+		before, _ := utf8.DecodeLastRuneInString(s[:start])
+		after, _ := utf8.DecodeRuneInString(s[end:])
+		if !(joins(first) && start > 0 && joins(before)) && !(joins(last) && end < len(s) && joins(after)) {
+			n++
+		}
+		i = start + 1
+		for i < len(s) && !utf8.RuneStart(s[i]) {
+			i++
+		}
+	}
+}
+
+// joins reports whether r continues a word written with spaces between
+// words.
+func joins(r rune) bool {
+	if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) {
+		return false
+	}
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func round1(f float64) float64 {
+	return math.Round(f*10) / 10
+}
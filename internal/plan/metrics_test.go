@@ -0,0 +1,88 @@
+package plan
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestComputeMetrics(t *testing.T) {
+	content := strings.Join([]string{
+		"# Rollout plan",
+		"See constraints.md for limits.",
+		"1. Add the table (estimate: 2h)",
+		"   Acceptance criteria: migration applies cleanly.",
+		"2. Backfill rows",
+		"   Takes 3 days.",
+		"3. Switch reads",
+	}, "\n")
+	p, err := Load(writeTempFile(t, content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := InferStepIDs(p)
+	m := ComputeMetrics(p, steps, []string{"/tmp/docs/constraints.md"})
+
+	if m.LineCount != 7 {
+		t.Errorf("LineCount = %d, want 7", m.LineCount)
+	}
+	if m.StepCount != 4 {
+		t.Fatalf("StepCount = %d, want 4", m.StepCount)
+	}
+	// Steps 1 and 2 carry estimates; step 1 has acceptance criteria.
+	if m.EstimateCoverage != 50 {
+		t.Errorf("EstimateCoverage = %v, want 50", m.EstimateCoverage)
+	}
+	if m.AcceptanceCoverage != 25 {
+		t.Errorf("AcceptanceCoverage = %v, want 25", m.AcceptanceCoverage)
+	}
+	if m.ContextReferences != 1 {
+		t.Errorf("ContextReferences = %d, want 1", m.ContextReferences)
+	}
+	if m.AvgStepWords <= 0 {
+		t.Errorf("AvgStepWords = %v, want > 0", m.AvgStepWords)
+	}
+}
+
+func TestComputeMetricsNoSteps(t *testing.T) {
+	p, err := Load(writeTempFile(t, "just prose\nno structure"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := ComputeMetrics(p, InferStepIDs(p), nil)
+	if m.StepCount != 0 || m.AvgStepWords != 0 || m.EstimateCoverage != 0 {
+		t.Errorf("unexpected metrics for unstructured plan: %+v", m)
+	}
+}
+
+func TestQualityMetrics(t *testing.T) {
+	p := Parse("plan.md", strings.Join([]string{
+		"# Plan",
+		"1. Make the API fast and scalable, etc. TODO: pick a cache",
+		"   Done when: p99 < 50ms.",
+		"2. Handle edge cases (TBD). Not breakfast.",
+		"```",
+		"fast TODO",
+		"```",
+	}, "\n"))
+	m := ComputeMetrics(p, InferStepIDs(p), nil)
+	if m.StepsWithoutAcceptance != 2 {
+		t.Errorf("StepsWithoutAcceptance = %d, want 2 (the heading and step 2)", m.StepsWithoutAcceptance)
+	}
+	if m.Placeholders != 3 {
+		t.Errorf("Placeholders = %d, want 3", m.Placeholders)
+	}
+
+	MeasureVagueness(&m, p, []string{"fast", "Scalable", "etc.", "handle edge cases"})
+	if m.VaguePhrases != 4 {
+		t.Errorf("VaguePhrases = %d, want 4", m.VaguePhrases)
+	}
+	if m.VagueDensity != 15.4 {
+		t.Errorf("VagueDensity = %v, want 15.4 (4 in 26 words)", m.VagueDensity)
+	}
+
+	p = Parse("plan.md", "# 计划\n\n1. 尽快完成，之后再优化\n")
+	MeasureVagueness(&m, p, []string{"尽快"})
+	if m.VaguePhrases != 1 {
+		t.Errorf("VaguePhrases = %d, want 1 in unspaced text", m.VaguePhrases)
+	}
+}
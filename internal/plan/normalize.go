@@ -0,0 +1,222 @@
+package plan
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// bulletPattern matches an unordered list item, capturing its
+	// indentation and the text after the marker.
+	bulletPattern = regexp.MustCompile(`^(\s*)[*+-]\s+(.*)$`)
+	// orderedPattern matches an ordered list item, capturing its
+	// indentation, number, and text.
+	orderedPattern = regexp.MustCompile(`^(\s*)(\d+)[.)]\s+(.*)$`)
+	// acceptanceLabelPattern matches a line that opens acceptance
+	// criteria, optionally bold and inside a list item, capturing the
+	// criteria that follow the label on the same line.
+	acceptanceLabelPattern = regexp.MustCompile(`(?i)^(?:\*\*|__)?(?:acceptance criteria|acceptance|done when|definition of done|success criteria)(?:\*\*|__)?\s*:\s*(?:\*\*|__)?\s*(.*)$`)
+	// stepRefPattern matches a reference to one or more steps by
+	// number: "step 2", "steps 3 and 4".
+	stepRefPattern = regexp.MustCompile(`(?i)\bsteps?\s+\d+(?:\s*(?:,|&|\band\b|\bor\b)\s*(?:steps?\s+)?\d+)*`)
+)
+
+// Normalize returns the plan in a canonical form, so the model's line
+// citations land on predictable lines and two revisions diff only where
+// their content differs:
+//
+//   - Heading levels start at 1 and never skip a level.
+//   - Ordered list items use "N." markers and count up by one from the
+//     list's first number; "step N" references follow the plan's steps
+//     when they are renumbered.
+//   - Unordered list items use "-".
+//   - Inline acceptance criteria ("Done when: a; b") become an
+//     "**Acceptance criteria:**" label with one item per criterion.
+//   - Trailing whitespace is trimmed, runs of blank lines collapse to
+//     one, headings are set off by blank lines, and the text ends in a
+//     single newline.
+//
+// Front matter and fenced code blocks are kept as they are; a review
+// stamp is dropped, since the normalized text has not been reviewed.
+// Normalize is idempotent.
+func Normalize(p *Plan) string {
+	lines := p.Lines
+	body := p.bodyIndex()
+	var out []string
+	out = append(out, lines[:body]...)
+
+	numbers := listNumbers(p)
+	renumber := stepRenumbering(p, numbers)
+	var stack []int // original levels of the open headings
+	inFence := false
+	blank := func() {
+		if len(out) > body && out[len(out)-1] != "" {
+			out = append(out, "")
+		}
+	}
+	for i := body; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t\r")
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			out = append(out, line)
+			continue
+		}
+		if inFence {
+			out = append(out, lines[i])
+			continue
+		}
+		if trimmed == "" {
+			blank()
+			continue
+		}
+
+		if m := scopeHeadingPattern.FindStringSubmatch(line); m != nil {
+			level := len(m[1])
+			for len(stack) > 0 && stack[len(stack)-1] >= level {
+				stack = stack[:len(stack)-1]
+			}
+			stack = append(stack, level)
+			blank()
+			out = append(out, strings.Repeat("#", len(stack))+" "+renumberRefs(m[2], renumber))
+			out = append(out, "")
+			continue
+		}
+
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if m := orderedPattern.FindStringSubmatch(line); m != nil {
+			line = indent + strconv.Itoa(numbers[i]) + ". " + m[3]
+		} else if m := bulletPattern.FindStringSubmatch(line); m != nil && !isRule(trimmed) {
+			line = indent + "- " + m[2]
+		}
+		line = renumberRefs(line, renumber)
+
+		// Acceptance criteria on the label's line become items under
+		// the label, indented like the label's text.
+		text, prefix := strings.TrimLeft(line, " \t"), ""
+		if m := orderedPattern.FindStringSubmatch(line); m != nil {
+			prefix, text = m[1]+m[2]+". ", m[3]
+		} else if m := bulletPattern.FindStringSubmatch(line); m != nil && !isRule(trimmed) {
+			prefix, text = m[1]+"- ", m[2]
+		} else {
+			prefix = indent
+		}
+		if m := acceptanceLabelPattern.FindStringSubmatch(text); m != nil {
+			out = append(out, prefix+"**Acceptance criteria:**")
+			itemIndent := strings.Repeat(" ", len(prefix))
+			for _, c := range splitCriteria(m[1]) {
+				out = append(out, itemIndent+"- "+c)
+			}
+			continue
+		}
+		out = append(out, line)
+	}
+	for len(out) > body && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+	return strings.Join(out, "\n") + "\n"
+}
+
+// isRule reports whether a trimmed line is a thematic break ("---",
+// "* * *") rather than a list item.
+func isRule(trimmed string) bool {
+	s := strings.ReplaceAll(trimmed, " ", "")
+	return len(s) >= 3 && strings.Trim(s, string(s[0])) == "" && strings.ContainsRune("*-_", rune(s[0]))
+}
+
+// splitCriteria splits inline acceptance criteria on semicolons.
+func splitCriteria(s string) []string {
+	var out []string
+	for _, c := range strings.Split(s, ";") {
+		if c = strings.TrimSpace(c); c != "" {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// listNumbers returns the number Normalize gives each ordered list
+// item, by line index. A list keeps its first item's number, as
+// Markdown renders it, and counts up from there, so "1. 1. 1." becomes
+// "1. 2. 3." and a list continued after a heading at "4." still starts
+// at 4. A heading, or text indented no deeper than a list, ends it.
+func listNumbers(p *Plan) map[int]int {
+	numbers := map[int]int{}
+	counters := map[int]int{} // last number per list indent
+	inFence := false
+	for i := p.bodyIndex(); i < len(p.Lines); i++ {
+		line := p.Lines[i]
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence || trimmed == "" {
+			continue
+		}
+		if scopeHeadingPattern.MatchString(line) {
+			clear(counters)
+			continue
+		}
+		depth := len(strings.ReplaceAll(line[:len(line)-len(strings.TrimLeft(line, " \t"))], "\t", "    "))
+		m := orderedPattern.FindStringSubmatch(line)
+		for d := range counters {
+			if d > depth || (m == nil && d >= depth && !bulletPattern.MatchString(line)) {
+				delete(counters, d)
+			}
+		}
+		if m == nil {
+			continue
+		}
+		if n, ok := counters[depth]; ok {
+			counters[depth] = n + 1
+		} else {
+			counters[depth], _ = strconv.Atoi(m[2])
+		}
+		numbers[i] = counters[depth]
+	}
+	return numbers
+}
+
+// stepRenumbering maps the plan's list-item step numbers to the
+// numbers Normalize gives them, or is nil when no step moves or the old
+// numbers are ambiguous. Numbered headings keep their numbers, so
+// references to them are unchanged.
+func stepRenumbering(p *Plan, numbers map[int]int) map[int]int {
+	if len(numberedHeadings(p)) >= 2 {
+		return nil
+	}
+	m := map[int]int{}
+	moved := false
+	for _, s := range numberedItems(p) {
+		if _, dup := m[s.Number]; dup {
+			return nil
+		}
+		m[s.Number] = numbers[s.Line-1]
+		moved = moved || s.Number != m[s.Number]
+	}
+	if !moved {
+		return nil
+	}
+	return m
+}
+
+// renumberRefs rewrites the step numbers in "step N" references by m.
+func renumberRefs(s string, m map[int]int) string {
+	if m == nil {
+		return s
+	}
+	return stepRefPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		return stepNumberPattern.ReplaceAllStringFunc(ref, func(n string) string {
+			old, err := strconv.Atoi(n)
+			if err != nil {
+				return n
+			}
+			if v, ok := m[old]; ok {
+				return strconv.Itoa(v)
+			}
+			return n
+		})
+	})
+}
@@ -0,0 +1,27 @@
+package plan
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	in := "---\nowner: me\n---\nIntro text.  \n\n### Plan: Billing  ###\n\n\n#### Steps\n" +
+		"1) Copy data\n1) Switch reads after step 1\n   * check dashboards\n   Done when: reads served from new db; p99 < 50ms\n" +
+		"4. Cleanup, depends on step 4\n+ note\n\n```sh\n1) not a list\n```\n* * *\n## Rollback\n3. Revert\n4. Verify\n"
+	want := "---\nowner: me\n---\nIntro text.\n\n# Plan: Billing\n\n## Steps\n\n" +
+		"1. Copy data\n2. Switch reads after step 1\n   - check dashboards\n   **Acceptance criteria:**\n   - reads served from new db\n   - p99 < 50ms\n" +
+		"3. Cleanup, depends on step 4\n- note\n\n```sh\n1) not a list\n```\n* * *\n\n# Rollback\n\n3. Revert\n4. Verify\n"
+	got := Normalize(Parse("plan.md", in))
+	if got != want {
+		t.Errorf("Normalize =\n%s\nwant\n%s", got, want)
+	}
+	if again := Normalize(Parse("plan.md", got)); again != got {
+		t.Errorf("Normalize is not idempotent:\n%s", again)
+	}
+}
+
+func TestNormalizeRenumbersStepRefs(t *testing.T) {
+	in := "# Plan\n\n1. Build\n2. Test, after step 1\n5. Deploy, blocked by steps 2 and 5\n"
+	want := "# Plan\n\n1. Build\n2. Test, after step 1\n3. Deploy, blocked by steps 2 and 3\n"
+	if got := Normalize(Parse("plan.md", in)); got != want {
+		t.Errorf("Normalize =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -4,9 +4,12 @@ package plan
 import (
 	"crypto/sha256"
 	"fmt"
-	"os"
 	"regexp"
 	"strings"
+
+	"github.com/dshills/plancritic/internal/fetch"
+	"github.com/dshills/plancritic/internal/review"
+	"github.com/dshills/plancritic/internal/textnorm"
 )
 
 // Plan holds a loaded plan file with its content and metadata.
@@ -15,6 +18,12 @@ type Plan struct {
 	Raw      string
 	Lines    []string
 	Hash     string
+	// Meta is the plan's YAML front matter, nil when it has none.
+	// BodyStart is the 1-based line the plan body starts on, after the
+	// front matter; Lines and Raw always hold the whole file so
+	// citations keep their original line numbers.
+	Meta      *review.PlanMeta
+	BodyStart int
 }
 
 // StepID represents an inferred plan step identifier.
@@ -25,30 +34,56 @@ type StepID struct {
 	Text      string
 }
 
-// Load reads a plan file and computes its SHA-256 hash.
+// Load reads a plan file and computes its SHA-256 hash. A review stamp
+// at the bottom (see Stamp) is dropped first, so stamping a plan does
+// not change its hash or what the model reviews. path may be an http
+// or https URL; see LoadWith.
 func Load(path string) (*Plan, error) {
-	data, err := os.ReadFile(path)
+	return LoadWith(path, fetch.Options{})
+}
+
+// LoadWith is Load with options for a plan fetched from a URL. The
+// plan's FilePath is then the URL without credentials or query.
+func LoadWith(path string, o fetch.Options) (*Plan, error) {
+	data, err := fetch.Read(path, o)
 	if err != nil {
 		return nil, fmt.Errorf("plan.Load: %w", err)
 	}
-	raw := string(data)
-	h := sha256.Sum256(data)
+	return Parse(fetch.Name(path), string(data)), nil
+}
+
+// Parse builds a Plan from content already in memory, as Load does for
+// a file. path only names the plan. The content is normalized first
+// (see textnorm.Normalize), so CRLF line endings, a BOM, or decomposed
+// accents change neither the hash nor the lines the model cites.
+func Parse(path, content string) *Plan {
+	raw := StripStamp(textnorm.Normalize(content))
+	h := sha256.Sum256([]byte(raw))
+	meta, body := ParseFrontMatter(raw)
 	return &Plan{
-		FilePath: path,
-		Raw:      raw,
-		Lines:    strings.Split(raw, "\n"),
-		Hash:     fmt.Sprintf("sha256:%x", h),
-	}, nil
+		FilePath:  path,
+		Raw:       raw,
+		Lines:     strings.Split(raw, "\n"),
+		Hash:      fmt.Sprintf("sha256:%x", h),
+		Meta:      meta,
+		BodyStart: body,
+	}
+}
+
+// bodyIndex returns the index in Lines of the first body line.
+func (p *Plan) bodyIndex() int {
+	return min(max(p.BodyStart-1, 0), len(p.Lines))
 }
 
 // LineNumbered returns the plan text with each line prefixed by L-padded numbers.
-// The width adjusts based on total line count.
+// The width adjusts based on total line count. Front matter is left
+// out; body lines keep their numbers from the full file.
 func LineNumbered(p *Plan) string {
 	width := lineNumberWidth(len(p.Lines))
 	format := fmt.Sprintf("L%%0%dd: %%s\n", width)
 	var b strings.Builder
-	for i, line := range p.Lines {
-		fmt.Fprintf(&b, format, i+1, line)
+	for i := p.bodyIndex(); i < len(p.Lines); i++ {
+		fmt.Fprintf(&b, format, i+1, p.Lines[i])
 	}
 	return b.String()
 }
@@ -67,19 +102,19 @@ func lineNumberWidth(totalLines int) int {
 var (
 	// Markdown heading: ## Title or ## 1. Title
 	headingPattern = regexp.MustCompile(`^#{1,6}\s+(?:\d+[\.\)]\s*)?(.+)`)
-	// Numbered bullet: 1. Step text
-	numberedPattern = regexp.MustCompile(`^\d+[\.\)]\s+(.+)`)
-	// Dash bullet: - Step text
-	dashPattern = regexp.MustCompile(`^-\s+(.+)`)
+	// Numbered bullet: 1. Step text, or CJK-style 1、/１．Step text
+	numberedPattern = regexp.MustCompile(`^(?:\d+[\.\)]\s+|[0-9０-９]+[．、）]\s*)(.+)`)
+	// Dash bullet: - Step text, or • / ・ Step text
+	dashPattern = regexp.MustCompile(`^(?:-\s+|[•・]\s*)(.+)`)
 )
 
-// InferStepIDs scans the plan for numbered headings or bullets and assigns P-NNN IDs.
+// InferStepIDs scans the plan body for numbered headings or bullets and assigns P-NNN IDs.
 func InferStepIDs(p *Plan) []StepID {
 	var steps []StepID
 	seq := 1
 
-	for i, line := range p.Lines {
-		trimmed := strings.TrimSpace(line)
+	for i := p.bodyIndex(); i < len(p.Lines); i++ {
+		trimmed := strings.TrimSpace(p.Lines[i])
 		if trimmed == "" {
 			continue
 		}
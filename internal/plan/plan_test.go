@@ -36,6 +36,23 @@ func TestLoad(t *testing.T) {
 	}
 }
 
+func TestLoadWindowsFile(t *testing.T) {
+	unix, err := Load(writeTempFile(t, "# Caf\u00e9 rollout\n\n1. Ship it\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := Load(writeTempFile(t, "\ufeff# Cafe\u0301 rollout\r\n\r\n1. Ship it\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Raw != unix.Raw || p.Hash != unix.Hash {
+		t.Errorf("Raw = %q, want %q with the same hash", p.Raw, unix.Raw)
+	}
+	if p.Lines[2] != "1. Ship it" {
+		t.Errorf("Lines[2] = %q, want no carriage return", p.Lines[2])
+	}
+}
+
 func TestLoadMissingFile(t *testing.T) {
 	_, err := Load("/nonexistent/file.md")
 	if err == nil {
@@ -66,6 +83,9 @@ func TestInferStepIDs(t *testing.T) {
 		{"markdown headings", "## Overview\n## Implementation", 2},
 		{"empty", "", 0},
 		{"mixed", "# Intro\n1. Step one\n- Detail", 3},
+		{"cjk numbering", "1、テーブルを作成\n２．データを移行", 2},
+		{"unicode bullets", "• Erste Aufgabe\n・次の作業", 2},
+		{"decimal is not a step", "1.5 seconds of latency", 0},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
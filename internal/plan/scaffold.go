@@ -0,0 +1,65 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dshills/plancritic/internal/profile"
+)
+
+// Scaffold returns a plan skeleton titled title for review with prof:
+// goals, out-of-scope items, numbered steps with estimates and
+// acceptance criteria, a test plan, rollback, and risks. Each of the
+// profile's checklists is turned into prompts, as HTML comments, under
+// the section that answers it (a testing checklist under the test plan,
+// a rollback one under rollback) or a section of its own. Placeholders
+// are TODO markers, so the plan's metrics show what is left to fill in.
+func Scaffold(title string, prof *profile.Profile) string {
+	var testing, rollback, other []profile.Checklist
+	for _, cl := range prof.Checklists {
+		id := strings.ToUpper(cl.ID)
+		switch {
+		case strings.Contains(id, "TEST"):
+			testing = append(testing, cl)
+		case strings.Contains(id, "ROLLBACK"):
+			rollback = append(rollback, cl)
+		default:
+			other = append(other, cl)
+		}
+	}
+	checks := func(b *strings.Builder, lists []profile.Checklist) {
+		for _, cl := range lists {
+			fmt.Fprintf(b, "<!-- %s (%s):\n", cl.Title, cl.ID)
+			for _, c := range cl.Checks {
+				// Not list items, which would count as steps.
+				fmt.Fprintf(b, "     %s\n", c)
+			}
+			b.WriteString("-->\n\n")
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "---\ntitle: %q\nowner: \"\"\ntarget_date: \"\"\n---\n\n", title)
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	fmt.Fprintf(&b, "<!-- Scaffolded for the %s profile. Replace every TODO, then run:\n     plancritic check <this file> --profile %s -->\n\n", prof.Name, prof.Name)
+	b.WriteString("## Goals\n\n- TODO: what this change achieves, for whom, and how success is measured\n\n")
+	b.WriteString("## Out of Scope\n\n<!-- List what this plan deliberately leaves out; the review does not report it as missing. -->\n\n")
+	b.WriteString("## Steps\n\n")
+	b.WriteString("1. TODO: the first change: what is built or changed, and where. Estimate: TODO\n")
+	b.WriteString("   **Acceptance criteria:**\n   - TODO: a measurable, testable condition\n")
+	b.WriteString("2. TODO: the next change; say \"depends on step 1\" if it must wait for it. Estimate: TODO\n")
+	b.WriteString("   **Acceptance criteria:**\n   - TODO: a measurable, testable condition\n\n")
+	for _, cl := range other {
+		fmt.Fprintf(&b, "## %s\n\n", cl.Title)
+		checks(&b, []profile.Checklist{cl})
+		b.WriteString("- TODO\n\n")
+	}
+	b.WriteString("## Test Plan\n\n")
+	checks(&b, testing)
+	b.WriteString("- TODO: the unit, integration, and end-to-end tests, and the acceptance criteria each one proves\n\n")
+	b.WriteString("## Rollback\n\n")
+	checks(&b, rollback)
+	b.WriteString("- TODO: how each risky or irreversible step is undone, and what triggers the rollback\n\n")
+	b.WriteString("## Risks\n\n- TODO: what could go wrong, how likely it is, and how it is mitigated\n")
+	return b.String()
+}
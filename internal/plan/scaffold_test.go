@@ -0,0 +1,42 @@
+package plan
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dshills/plancritic/internal/profile"
+)
+
+func TestScaffold(t *testing.T) {
+	prof, err := profile.LoadBuiltin("general")
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := Scaffold("Billing migration", prof)
+	p := Parse("plan.md", text)
+	if p.Meta == nil || p.Meta.Title != "Billing migration" {
+		t.Errorf("front matter = %+v, want the title", p.Meta)
+	}
+	if got := OutOfScope(p); len(got) != 0 {
+		t.Errorf("OutOfScope = %+v, want the guidance comment ignored", got)
+	}
+	steps := InferStepIDs(p)
+	for _, s := range steps {
+		if strings.HasPrefix(s.Text, "Does the plan") {
+			t.Errorf("checklist prompt %q inferred as a step", s.Text)
+		}
+	}
+	if m := ComputeMetrics(p, steps, nil); m.Placeholders == 0 {
+		t.Error("scaffold has no TODO markers left to fill in")
+	}
+	for _, want := range []string{
+		"--profile general",
+		"## Security basics\n\n<!-- Security basics (SECURITY):\n",
+		"## Test Plan\n\n<!-- Test coverage (TESTING):\n     Does the plan specify what tests will be written?\n",
+		"## Rollback\n\n<!-- Rollback and safety (ROLLBACK):\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("scaffold lacks %q:\n%s", want, text)
+		}
+	}
+}
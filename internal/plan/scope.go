@@ -0,0 +1,94 @@
+package plan
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/dshills/plancritic/internal/review"
+)
+
+var (
+	// scopeHeadingPattern matches a Markdown heading, capturing its
+	// level marks and text.
+	scopeHeadingPattern = regexp.MustCompile(`^ {0,3}(#{1,6})\s+(.*?)(?:\s+#+)?\s*$`)
+	// scopeLabelPattern matches a bold or plain label line such as
+	// "**Out of scope:**" that opens a list without a heading.
+	scopeLabelPattern = regexp.MustCompile(`^\s*(?:(?:\*\*|__)([^*_]+?):?(?:\*\*|__):?|([^*_:]+):)\s*$`)
+	// listItemPattern strips a bullet or number from a list item.
+	listItemPattern = regexp.MustCompile(`^\s*(?:[-*+•]|\d+[.)])\s+(?:\[[ xX]\]\s+)?`)
+)
+
+// isScopeTitle reports whether a heading or label declares what the
+// plan leaves out: "Out of scope", "Non-goals", "Not in scope".
+func isScopeTitle(title string) bool {
+	t := strings.ToLower(strings.NewReplacer("-", " ", "_", " ").Replace(title))
+	t = strings.Join(strings.Fields(t), " ")
+	for _, s := range []string{"out of scope", "not in scope", "non goal", "nongoal", "scope exclusion"} {
+		if strings.Contains(t, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// OutOfScope returns what the plan explicitly excludes: the
+// out_of_scope (or non_goals) front matter entries, then each line of
+// every "Out of scope" or "Non-goals" section in the body. A section
+// under a heading runs to the next heading of the same or a higher
+// level; one under a label line such as "**Out of scope:**" runs to the
+// next blank line after its first item. HTML comments, such as the
+// guidance in a Scaffold, are not exclusions.
+func OutOfScope(p *Plan) []review.Exclusion {
+	var out []review.Exclusion
+	if p.Meta != nil {
+		for _, item := range p.Meta.OutOfScope {
+			out = append(out, review.Exclusion{Text: item})
+		}
+	}
+	level := 0 // heading level of the open section
+	label, labelItems := false, 0
+	inFence, inComment := false, false
+	for i := p.bodyIndex(); i < len(p.Lines); i++ {
+		line := p.Lines[i]
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		if inComment || strings.HasPrefix(trimmed, "<!--") {
+			inComment = !strings.HasSuffix(trimmed, "-->")
+			continue
+		}
+		if m := scopeHeadingPattern.FindStringSubmatch(line); m != nil {
+			if level > 0 && len(m[1]) > level {
+				continue
+			}
+			level, label = 0, false
+			if isScopeTitle(m[2]) {
+				level = len(m[1])
+			}
+			continue
+		}
+		if level == 0 && !label {
+			if m := scopeLabelPattern.FindStringSubmatch(line); m != nil && isScopeTitle(m[1]+m[2]) {
+				label, labelItems = true, 0
+			}
+			continue
+		}
+		if trimmed == "" {
+			if label && labelItems > 0 {
+				label = false
+			}
+			continue
+		}
+		text := strings.TrimSpace(listItemPattern.ReplaceAllString(line, ""))
+		if text != "" {
+			out = append(out, review.Exclusion{Text: text, Line: i + 1})
+			labelItems++
+		}
+	}
+	return out
+}
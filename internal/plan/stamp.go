@@ -0,0 +1,115 @@
+package plan
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	stampBegin = "<!-- plancritic:review"
+	stampEnd   = "-->"
+)
+
+// Stamp is the review status block `check --stamp` keeps at the bottom
+// of a plan, as an HTML comment so rendered Markdown hides it.
+type Stamp struct {
+	Reviewed   time.Time
+	Verdict    string
+	Score      int
+	ReviewHash string
+}
+
+// String renders s as the comment block, without a trailing newline.
+func (s Stamp) String() string {
+	return fmt.Sprintf("%s\nreviewed: %s\nverdict: %s\nscore: %d\nreview: %s\n%s",
+		stampBegin, s.Reviewed.UTC().Format(time.RFC3339), s.Verdict, s.Score, s.ReviewHash, stampEnd)
+}
+
+// StripStamp removes a trailing review stamp, and the blank line before
+// it, from raw. Text without a stamp is returned unchanged.
+func StripStamp(raw string) string {
+	i := strings.LastIndex(raw, stampBegin)
+	if i < 0 || (i > 0 && raw[i-1] != '\n') {
+		return raw
+	}
+	rest := strings.TrimRight(raw[i:], " \t\r\n")
+	if !strings.HasSuffix(rest, stampEnd) || strings.Count(rest, stampEnd) != 1 {
+		return raw
+	}
+	return strings.TrimRight(raw[:i], "\n") + "\n"
+}
+
+// ReadStamp returns the stamp at the end of raw, reporting false when
+// raw has none or it cannot be parsed.
+func ReadStamp(raw string) (Stamp, bool) {
+	body := StripStamp(raw)
+	if body == raw {
+		return Stamp{}, false
+	}
+	i := strings.LastIndex(raw, stampBegin)
+	block := strings.TrimSuffix(strings.TrimRight(raw[i+len(stampBegin):], " \t\r\n"), stampEnd)
+	var s Stamp
+	for _, line := range strings.Split(block, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		var err error
+		switch key {
+		case "reviewed":
+			s.Reviewed, err = time.Parse(time.RFC3339, value)
+		case "verdict":
+			s.Verdict = value
+		case "score":
+			s.Score, err = strconv.Atoi(value)
+		case "review":
+			s.ReviewHash = value
+		}
+		if err != nil {
+			return Stamp{}, false
+		}
+	}
+	return s, true
+}
+
+// Matches reports whether s and o record the same review outcome,
+// ignoring when the reviews ran.
+func (s Stamp) Matches(o Stamp) bool {
+	return s.Verdict == o.Verdict && s.Score == o.Score && s.ReviewHash == o.ReviewHash
+}
+
+// ApplyStamp returns raw with s as its stamp, replacing any existing
+// one, so repeated reviews never stack blocks.
+func ApplyStamp(raw string, s Stamp) string {
+	body := strings.TrimRight(StripStamp(raw), "\n")
+	if body == "" {
+		return s.String() + "\n"
+	}
+	return body + "\n\n" + s.String() + "\n"
+}
+
+// WriteStamp applies s to the plan file at path in place. Unless force
+// is set, a plan whose stamp already Matches s is left untouched, so
+// re-reviewing an unchanged plan does not change its modification time.
+// It reports whether the file was written.
+func WriteStamp(path string, s Stamp, force bool) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, fmt.Errorf("plan.WriteStamp: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("plan.WriteStamp: %w", err)
+	}
+	if old, ok := ReadStamp(string(data)); ok && !force && old.Matches(s) {
+		return false, nil
+	}
+	if err := os.WriteFile(path, []byte(ApplyStamp(string(data), s)), info.Mode().Perm()); err != nil {
+		return false, fmt.Errorf("plan.WriteStamp: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,107 @@
+package plan
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyStampIdempotent(t *testing.T) {
+	s := Stamp{
+		Reviewed:   time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		Verdict:    "NOT_EXECUTABLE",
+		Score:      40,
+		ReviewHash: "sha256:abc",
+	}
+	once := ApplyStamp("# Plan\n\n1. Step\n", s)
+	want := "# Plan\n\n1. Step\n\n<!-- plancritic:review\nreviewed: 2026-03-01T12:00:00Z\nverdict: NOT_EXECUTABLE\nscore: 40\nreview: sha256:abc\n-->\n"
+	if once != want {
+		t.Fatalf("ApplyStamp =\n%q\nwant\n%q", once, want)
+	}
+
+	s.Verdict, s.Score = "EXECUTABLE_AS_IS", 95
+	twice := ApplyStamp(once, s)
+	if strings.Count(twice, stampBegin) != 1 {
+		t.Fatalf("stamp duplicated:\n%s", twice)
+	}
+	if !strings.Contains(twice, "verdict: EXECUTABLE_AS_IS\nscore: 95") {
+		t.Errorf("stamp not updated:\n%s", twice)
+	}
+	if got := StripStamp(twice); got != "# Plan\n\n1. Step\n" {
+		t.Errorf("StripStamp = %q", got)
+	}
+}
+
+func TestStripStampLeavesOtherComments(t *testing.T) {
+	for _, raw := range []string{
+		"# Plan\n<!-- a note -->\n",
+		"# Plan\n<!-- plancritic:review\nunterminated\n",
+		"# Plan\n<!-- plancritic:review\n-->\nMore text after\n",
+	} {
+		if got := StripStamp(raw); got != raw {
+			t.Errorf("StripStamp(%q) = %q, want unchanged", raw, got)
+		}
+	}
+}
+
+func TestLoadIgnoresStamp(t *testing.T) {
+	path := writeTempFile(t, "# Plan\n1. Step\n")
+	before, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WriteStamp(path, Stamp{Reviewed: time.Now(), Verdict: "EXECUTABLE_AS_IS", Score: 100, ReviewHash: "sha256:x"}, false); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), stampBegin) {
+		t.Fatalf("stamp not written:\n%s", data)
+	}
+	after, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Hash != before.Hash || after.Raw != before.Raw {
+		t.Errorf("stamped plan loads differently: %q vs %q", after.Raw, before.Raw)
+	}
+}
+
+func TestWriteStampSkipsMatchingStamp(t *testing.T) {
+	path := writeTempFile(t, "# Plan\n1. Step\n")
+	first := Stamp{Reviewed: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), Verdict: "EXECUTABLE_AS_IS", Score: 100, ReviewHash: "sha256:x"}
+	if wrote, err := WriteStamp(path, first, false); err != nil || !wrote {
+		t.Fatalf("first WriteStamp = %v, %v", wrote, err)
+	}
+	got, ok := ReadStamp(mustRead(t, path))
+	if !ok || got != first {
+		t.Fatalf("ReadStamp = %+v, %v; want %+v", got, ok, first)
+	}
+
+	again := first
+	again.Reviewed = first.Reviewed.Add(time.Hour)
+	if wrote, err := WriteStamp(path, again, false); err != nil || wrote {
+		t.Fatalf("matching WriteStamp = %v, %v; want no write", wrote, err)
+	}
+	if !strings.Contains(mustRead(t, path), "reviewed: 2026-03-01T12:00:00Z") {
+		t.Error("matching stamp was rewritten")
+	}
+	if wrote, err := WriteStamp(path, again, true); err != nil || !wrote {
+		t.Fatalf("forced WriteStamp = %v, %v", wrote, err)
+	}
+
+	changed := again
+	changed.Score = 90
+	if wrote, err := WriteStamp(path, changed, false); err != nil || !wrote {
+		t.Fatalf("changed WriteStamp = %v, %v", wrote, err)
+	}
+}
+
+func mustRead(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
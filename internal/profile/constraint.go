@@ -0,0 +1,73 @@
+package profile
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ParseConstraint parses a constraint override given as key=value. The
+// key is a dotted path into the constraint sections, such as
+// database.preferred, and the value is read as YAML, so 0 is a number,
+// true a boolean, and [a, b] a list; anything else, including text
+// with a colon, is a string.
+func ParseConstraint(s string) (key string, value interface{}, err error) {
+	key, raw, ok := strings.Cut(s, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return "", nil, fmt.Errorf("invalid constraint %q: want key=value", s)
+	}
+	for _, part := range strings.Split(key, ".") {
+		if part == "" {
+			return "", nil, fmt.Errorf("invalid constraint key %q: empty path element", key)
+		}
+	}
+	raw = strings.TrimSpace(raw)
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(raw), &v); err != nil {
+		v = raw
+	}
+	switch v.(type) {
+	case nil, map[string]interface{}:
+		v = raw
+	}
+	return key, v, nil
+}
+
+// SetConstraint sets the constraint at a dotted key to value, adding
+// the sections on its path as needed and replacing a value that is in
+// the way of one. The maps on the path are copied, so profiles sharing
+// constraints with p (see Extend) are not changed.
+func (p *Profile) SetConstraint(key string, value interface{}) {
+	path := strings.Split(key, ".")
+	p.Constraints = setConstraint(p.Constraints, path, value)
+}
+
+// setConstraint returns a copy of m with value set at path.
+func setConstraint(m map[string]interface{}, path []string, value interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m)+1)
+	for k, v := range m {
+		out[k] = v
+	}
+	if len(path) == 1 {
+		out[path[0]] = value
+		return out
+	}
+	next, _ := out[path[0]].(map[string]interface{})
+	out[path[0]] = setConstraint(next, path[1:], value)
+	return out
+}
+
+// ApplyConstraints applies key=value constraint overrides to p in
+// order (see ParseConstraint and SetConstraint).
+func ApplyConstraints(p *Profile, overrides []string) error {
+	for _, s := range overrides {
+		key, value, err := ParseConstraint(s)
+		if err != nil {
+			return err
+		}
+		p.SetConstraint(key, value)
+	}
+	return nil
+}
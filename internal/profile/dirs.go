@@ -0,0 +1,113 @@
+package profile
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ProjectDir is the project's profile directory, relative to the
+// working directory.
+const ProjectDir = ".plancritic/profiles"
+
+// Dir is a directory searched for profile files.
+type Dir struct {
+	Path string
+	// Source is SourceProject or SourceUser.
+	Source string
+}
+
+// UserDir returns the user's profile directory, next to the user
+// config file: $XDG_CONFIG_HOME/plancritic/profiles on Linux.
+func UserDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "plancritic", "profiles"), nil
+}
+
+// Dirs returns the directories searched for a profile name before the
+// built-in profiles, highest priority first: ProjectDir, so a
+// repository can pin its profiles, then UserDir. A profile named NAME
+// is the file NAME.yaml or NAME.yml in one of them.
+func Dirs() []Dir {
+	dirs := []Dir{{Path: ProjectDir, Source: SourceProject}}
+	if user, err := UserDir(); err == nil {
+		dirs = append(dirs, Dir{Path: user, Source: SourceUser})
+	}
+	return dirs
+}
+
+// profileFile returns the file defining the profile name in dir.
+func profileFile(dir, name string) (string, bool) {
+	for _, ext := range []string{".yaml", ".yml"} {
+		path := filepath.Join(dir, name+ext)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// dirProfiles returns the names of the profiles defined in dir, sorted;
+// none when dir does not exist.
+func dirProfiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var names []string
+	for _, e := range entries {
+		n := e.Name()
+		ext := filepath.Ext(n)
+		if e.IsDir() || (ext != ".yaml" && ext != ".yml") || strings.HasPrefix(n, ".") {
+			continue
+		}
+		if name := strings.TrimSuffix(n, ext); !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// loadFromDirs loads name from the first search directory that defines
+// it, or its base profile there when name is a variant, reporting false
+// when none does. The file skip (an absolute path) is passed over, and
+// chain is passed on to loadUserFile.
+func loadFromDirs(name, skip string, chain []string) (*Profile, bool, error) {
+	find := func(dir, name string) (string, bool) {
+		path, ok := profileFile(dir, name)
+		if ok && skip != "" {
+			if abs, err := filepath.Abs(path); err == nil && abs == skip {
+				return "", false
+			}
+		}
+		return path, ok
+	}
+	for _, d := range Dirs() {
+		if path, ok := find(d.Path, name); ok {
+			p, err := loadUserFile(path, chain)
+			return p, true, err
+		}
+		if v, baseName, ok := splitVariant(name); ok {
+			if path, ok := find(d.Path, baseName); ok {
+				base, err := loadUserFile(path, chain)
+				if err != nil {
+					return nil, true, err
+				}
+				return v.derive(name, baseName, base), true, nil
+			}
+		}
+	}
+	return nil, false, nil
+}
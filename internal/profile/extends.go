@@ -0,0 +1,206 @@
+package profile
+
+import (
+	"slices"
+	"strings"
+)
+
+// maxExtends bounds a chain of profiles extending one another.
+const maxExtends = 16
+
+// Extend returns child merged onto parent, for a child that extends
+// parent:
+//
+//   - Name, Description, Version, and Classification are the child's
+//     when it sets them, else the parent's. AllowedProviders is the
+//     child's list when it has one.
+//   - Constraints merge key by key: maps merge recursively, lists are
+//     the parent's items followed by the child's new ones, and any
+//     other child value replaces the parent's.
+//   - A checklist with a parent checklist's ID adds its checks to that
+//     checklist, and its title replaces the parent's when set; other
+//     checklists follow the parent's. A check with a parent check's ID
+//     replaces it.
+//   - Contradiction pairs, ambiguity triggers, fabrication phrases,
+//     and focus and disabled categories are the parent's followed by
+//     the child's new ones; a child pair with the same triggers
+//     replaces the parent's.
+//   - A custom category with a parent category's ID replaces it;
+//     other categories follow the parent's.
+//   - Severity bounds are the child's for the categories it bounds.
+//   - Evidence minimums are the child's where it sets them, and
+//     contradiction pairs satisfy them when either profile allows it.
+//
+// The result is self-contained, so its Extends is empty. Neither
+// profile is modified.
+func Extend(parent, child *Profile) *Profile {
+	p := clone(parent)
+	p.Extends = ""
+	p.Base = ""
+	if child.Name != "" {
+		p.Name = child.Name
+	}
+	if child.Description != "" {
+		p.Description = child.Description
+	}
+	if child.Version != 0 {
+		p.Version = child.Version
+	}
+	if child.Classification != "" {
+		p.Classification = child.Classification
+	}
+	if len(child.AllowedProviders) > 0 {
+		p.AllowedProviders = append([]string(nil), child.AllowedProviders...)
+	}
+	p.Constraints = mergeConstraints(parent.Constraints, child.Constraints)
+	p.FocusCategories = appendNew(p.FocusCategories, child.FocusCategories)
+	p.DisabledCategories = appendNew(p.DisabledCategories, child.DisabledCategories)
+	for c, b := range child.CategorySeverity {
+		if p.CategorySeverity == nil {
+			p.CategorySeverity = map[string]SeverityBounds{}
+		}
+		p.CategorySeverity[c] = b
+	}
+	for _, c := range child.Categories {
+		i := slices.IndexFunc(p.Categories, func(pc Category) bool { return pc.ID == c.ID })
+		if i < 0 {
+			p.Categories = append(p.Categories, c)
+		} else {
+			p.Categories[i] = c
+		}
+	}
+
+	index := map[string]int{}
+	for i, cl := range p.Checklists {
+		index[cl.ID] = i
+	}
+	for _, cl := range child.Checklists {
+		i, ok := index[cl.ID]
+		if !ok {
+			index[cl.ID] = len(p.Checklists)
+			p.Checklists = append(p.Checklists, Checklist{ID: cl.ID, Title: cl.Title, Checks: append([]Check(nil), cl.Checks...)})
+			continue
+		}
+		if cl.Title != "" {
+			p.Checklists[i].Title = cl.Title
+		}
+		p.Checklists[i].Checks = appendChecks(p.Checklists[i].Checks, cl.Checks)
+	}
+
+	for _, c := range child.Heuristics.Contradictions {
+		replaced := false
+		for i, pc := range p.Heuristics.Contradictions {
+			if strings.EqualFold(pc.TriggerA, c.TriggerA) && strings.EqualFold(pc.TriggerB, c.TriggerB) {
+				p.Heuristics.Contradictions[i], replaced = c, true
+			}
+		}
+		if !replaced {
+			p.Heuristics.Contradictions = append(p.Heuristics.Contradictions, c)
+		}
+	}
+	p.Heuristics.AmbiguityTriggers = appendNew(p.Heuristics.AmbiguityTriggers, child.Heuristics.AmbiguityTriggers)
+	p.Heuristics.FabricationPhrases = appendNew(p.Heuristics.FabricationPhrases, child.Heuristics.FabricationPhrases)
+	if len(parent.Heuristics.LocalizedAmbiguityTriggers)+len(child.Heuristics.LocalizedAmbiguityTriggers) > 0 {
+		loc := map[string][]string{}
+		for lang, ts := range parent.Heuristics.LocalizedAmbiguityTriggers {
+			loc[lang] = append([]string(nil), ts...)
+		}
+		for lang, ts := range child.Heuristics.LocalizedAmbiguityTriggers {
+			loc[lang] = appendNew(loc[lang], ts)
+		}
+		p.Heuristics.LocalizedAmbiguityTriggers = loc
+	}
+
+	if len(parent.Evidence.MinCitations)+len(child.Evidence.MinCitations) > 0 {
+		min := map[string]int{}
+		for sev, n := range parent.Evidence.MinCitations {
+			min[sev] = n
+		}
+		for sev, n := range child.Evidence.MinCitations {
+			min[sev] = n
+		}
+		p.Evidence.MinCitations = min
+	}
+	p.Evidence.AllowContradictionPair = parent.Evidence.AllowContradictionPair || child.Evidence.AllowContradictionPair
+	return p
+}
+
+// appendNew appends the items of add not already in list, ignoring
+// case, to a copy of list.
+func appendNew(list, add []string) []string {
+	out := append([]string(nil), list...)
+	seen := map[string]bool{}
+	for _, s := range out {
+		seen[strings.ToLower(s)] = true
+	}
+	for _, s := range add {
+		if !seen[strings.ToLower(s)] {
+			seen[strings.ToLower(s)] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// appendChecks returns list followed by the checks in add it lacks. A
+// check with the ID of one in list replaces it; a check without an ID
+// is new unless list has one of the same text, ignoring case.
+func appendChecks(list, add []Check) []Check {
+	out := append([]Check(nil), list...)
+	for _, c := range add {
+		i := slices.IndexFunc(out, func(o Check) bool {
+			if c.ID != "" {
+				return o.ID == c.ID
+			}
+			return strings.EqualFold(o.Text, c.Text)
+		})
+		switch {
+		case i < 0:
+			out = append(out, c)
+		case c.ID != "":
+			out[i] = c
+		}
+	}
+	return out
+}
+
+// mergeConstraints merges child constraints onto parent's, as Extend
+// describes, without modifying either.
+func mergeConstraints(parent, child map[string]interface{}) map[string]interface{} {
+	if len(parent)+len(child) == 0 {
+		return nil
+	}
+	out := make(map[string]interface{}, len(parent)+len(child))
+	for k, v := range parent {
+		out[k] = v
+	}
+	for k, cv := range child {
+		switch c := cv.(type) {
+		case map[string]interface{}:
+			if pm, ok := out[k].(map[string]interface{}); ok {
+				out[k] = mergeConstraints(pm, c)
+				continue
+			}
+		case []interface{}:
+			if pl, ok := out[k].([]interface{}); ok {
+				merged := append([]interface{}(nil), pl...)
+				for _, item := range c {
+					dup := false
+					for _, have := range pl {
+						if have == item {
+							dup = true
+							break
+						}
+					}
+					if !dup {
+						merged = append(merged, item)
+					}
+				}
+				out[k] = merged
+				continue
+			}
+		}
+		out[k] = cv
+	}
+	return out
+}
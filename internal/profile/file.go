@@ -0,0 +1,249 @@
+package profile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/dshills/plancritic/internal/review"
+	"gopkg.in/yaml.v3"
+)
+
+// IsPath reports whether a --profile value names a profile file rather
+// than a built-in profile: it ends in .yaml or .yml, or has a directory.
+func IsPath(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".yaml" || ext == ".yml" || strings.ContainsRune(name, '/') || strings.ContainsRune(name, filepath.Separator)
+}
+
+// Load loads the profile name: a pinned remote profile when
+// IsRemote(name) (see LoadRemote), a profile file when IsPath(name),
+// else the profile of that name in the search directories (see Dirs)
+// or, failing those, the built-in one.
+func Load(name string) (*Profile, error) {
+	if IsRemote(name) {
+		return LoadRemote(name)
+	}
+	if IsPath(name) {
+		return LoadFile(name)
+	}
+	if p, ok, err := loadFromDirs(name, "", nil); ok {
+		return p, err
+	}
+	return LoadBuiltin(name)
+}
+
+// LoadFile loads a user-defined profile from a YAML file. Unknown keys
+// are errors, so a misspelled key is not silently ignored, and the
+// profile must pass Validate. A profile without a name is named after
+// its file. A profile that extends another is merged onto it (see
+// Extend).
+func LoadFile(path string) (*Profile, error) {
+	return loadUserFile(path, nil)
+}
+
+// loadUserFile is LoadFile for a profile reached through the extends
+// of the profile files in chain, by absolute path.
+func loadUserFile(path string, chain []string) (*Profile, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("profile.LoadFile: %w", err)
+	}
+	if slices.Contains(chain, abs) {
+		return nil, fmt.Errorf("profile.LoadFile: %s: extends cycle", path)
+	}
+	if len(chain) >= maxExtends {
+		return nil, fmt.Errorf("profile.LoadFile: %s: more than %d levels of extends", path, maxExtends)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("profile.LoadFile: %w", err)
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("profile.LoadFile: %s: %w", path, err)
+	}
+	if p.Name == "" {
+		p.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if p.Extends == "" {
+		return p, nil
+	}
+	parent, err := loadParent(p.Extends, abs, append(chain, abs))
+	if err != nil {
+		return nil, fmt.Errorf("profile.LoadFile: %s: extends %q: %w", path, p.Extends, err)
+	}
+	merged := Extend(parent, p)
+	if err := merged.Validate(); err != nil {
+		return nil, fmt.Errorf("profile.LoadFile: %s: %w", path, err)
+	}
+	return merged, nil
+}
+
+// loadParent loads the profile a profile file from extends: a path
+// relative to that file, or a name resolved like Load's except that
+// from itself is skipped, so a profile can extend the one it hides.
+func loadParent(name, from string, chain []string) (*Profile, error) {
+	if IsPath(name) {
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(filepath.Dir(from), name)
+		}
+		return loadUserFile(name, chain)
+	}
+	if p, ok, err := loadFromDirs(name, from, chain); ok {
+		return p, err
+	}
+	return LoadBuiltin(name)
+}
+
+// Parse decodes and validates profile YAML.
+func Parse(data []byte) (*Profile, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var p Profile
+	if err := dec.Decode(&p); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("empty profile")
+		}
+		return nil, err
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// severities are the severities a profile may name.
+var severities = map[string]bool{"CRITICAL": true, "WARN": true, "INFO": true}
+
+// isWebURL reports whether s is an absolute http or https URL.
+func isWebURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// categoryPattern matches the ID of a custom category.
+var categoryPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// Validate reports the first problem that would make the profile
+// mislead the model or the evidence checks: a checklist without an ID
+// or checks, a repeated checklist ID, a check without text, with a
+// repeated ID, an unknown category, or a reference that is not a web
+// URL, a custom category with a malformed, built-in, or repeated ID,
+// no description, or an unknown default severity, a contradiction pair
+// with an empty side or an unknown severity, an empty fabrication
+// phrase, an unknown focus or disabled category, a severity bound for
+// an unknown category or with an unknown or inverted range, or an
+// invalid evidence rule.
+func (p *Profile) Validate() error {
+	if p.Version < 0 {
+		return fmt.Errorf("version %d: want a positive number", p.Version)
+	}
+	custom := map[string]bool{}
+	for _, c := range p.Categories {
+		custom[c.ID] = true
+	}
+	ids, checkIDs := map[string]bool{}, map[string]bool{}
+	for i, cl := range p.Checklists {
+		switch {
+		case strings.TrimSpace(cl.ID) == "":
+			return fmt.Errorf("checklists[%d]: missing id", i)
+		case ids[cl.ID]:
+			return fmt.Errorf("checklists[%d]: duplicate id %q", i, cl.ID)
+		case len(cl.Checks) == 0:
+			return fmt.Errorf("checklist %s: no checks", cl.ID)
+		}
+		ids[cl.ID] = true
+		for j, c := range cl.Checks {
+			switch {
+			case strings.TrimSpace(c.Text) == "":
+				return fmt.Errorf("checklist %s: checks[%d] is empty", cl.ID, j)
+			case c.ID != "" && checkIDs[c.ID]:
+				return fmt.Errorf("checklist %s: checks[%d]: duplicate check id %q", cl.ID, j, c.ID)
+			case c.Category != "" && !review.Category(c.Category).Valid() && !custom[c.Category] && p.Extends == "":
+				return fmt.Errorf("checklist %s: checks[%d]: unknown category %q", cl.ID, j, c.Category)
+			case c.Reference != "" && !isWebURL(c.Reference):
+				return fmt.Errorf("checklist %s: checks[%d]: reference %q: want an http or https URL", cl.ID, j, c.Reference)
+			}
+			if c.ID != "" {
+				checkIDs[c.ID] = true
+			}
+		}
+	}
+	seen := map[string]bool{}
+	for i, c := range p.Categories {
+		switch {
+		case !categoryPattern.MatchString(c.ID):
+			return fmt.Errorf("categories[%d]: id %q: want upper case letters, digits, and underscores", i, c.ID)
+		case review.Category(c.ID).Valid():
+			return fmt.Errorf("categories[%d]: %s is a built-in category", i, c.ID)
+		case seen[c.ID]:
+			return fmt.Errorf("categories[%d]: duplicate id %q", i, c.ID)
+		case strings.TrimSpace(c.Description) == "":
+			return fmt.Errorf("category %s: missing description", c.ID)
+		case c.DefaultSeverity != "" && !severities[c.DefaultSeverity]:
+			return fmt.Errorf("category %s: invalid default_severity %q (valid: CRITICAL, WARN, INFO)", c.ID, c.DefaultSeverity)
+		}
+		seen[c.ID] = true
+	}
+	for i, c := range p.FocusCategories {
+		// A profile that extends another may focus on a category the
+		// other defines; the merged profile is validated again.
+		if !review.Category(c).Valid() && !custom[c] && p.Extends == "" {
+			return fmt.Errorf("focus_categories[%d]: unknown category %q", i, c)
+		}
+	}
+	for i, c := range p.DisabledCategories {
+		if !review.Category(c).Valid() && !custom[c] && p.Extends == "" {
+			return fmt.Errorf("disabled_categories[%d]: unknown category %q", i, c)
+		}
+	}
+	cats := make([]string, 0, len(p.CategorySeverity))
+	for c := range p.CategorySeverity {
+		cats = append(cats, c)
+	}
+	sort.Strings(cats)
+	for _, c := range cats {
+		b := p.CategorySeverity[c]
+		switch {
+		case !review.Category(c).Valid() && !custom[c] && p.Extends == "":
+			return fmt.Errorf("category_severity: unknown category %q", c)
+		case b.MinSeverity != "" && !severities[b.MinSeverity]:
+			return fmt.Errorf("category_severity.%s: invalid min_severity %q (valid: CRITICAL, WARN, INFO)", c, b.MinSeverity)
+		case b.MaxSeverity != "" && !severities[b.MaxSeverity]:
+			return fmt.Errorf("category_severity.%s: invalid max_severity %q (valid: CRITICAL, WARN, INFO)", c, b.MaxSeverity)
+		case b.MinSeverity != "" && b.MaxSeverity != "" && review.Severity(b.MinSeverity).Order() < review.Severity(b.MaxSeverity).Order():
+			return fmt.Errorf("category_severity.%s: min_severity %s is above max_severity %s", c, b.MinSeverity, b.MaxSeverity)
+		}
+	}
+	for i, c := range p.Heuristics.Contradictions {
+		if strings.TrimSpace(c.TriggerA) == "" || strings.TrimSpace(c.TriggerB) == "" {
+			return fmt.Errorf("heuristics.contradictions[%d]: trigger_a and trigger_b are required", i)
+		}
+		if c.Severity != "" && !severities[c.Severity] {
+			return fmt.Errorf("heuristics.contradictions[%d]: invalid severity %q (valid: CRITICAL, WARN, INFO)", i, c.Severity)
+		}
+	}
+	for i, f := range p.Heuristics.FabricationPhrases {
+		if strings.TrimSpace(f) == "" {
+			return fmt.Errorf("heuristics.fabrication_phrases[%d] is empty", i)
+		}
+	}
+	for sev, n := range p.Evidence.MinCitations {
+		if !severities[sev] {
+			return fmt.Errorf("evidence.min_citations: invalid severity %q (valid: CRITICAL, WARN, INFO)", sev)
+		}
+		if n < 0 {
+			return fmt.Errorf("evidence.min_citations.%s: %d is negative", sev, n)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,354 @@
+package profile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dshills/plancritic/internal/review"
+	"gopkg.in/yaml.v3"
+)
+
+// ConstraintTypes are the constraint sections the built-in profiles
+// use, and the ones Lint accepts.
+var ConstraintTypes = []string{
+	"api_design", "architecture", "database", "dependencies", "error_handling",
+	"infrastructure", "language", "observability", "operations", "platform",
+	"security", "testing",
+}
+
+// Problem is one thing wrong with a profile file, at a 1-based line
+// and column; Column is 0 when only the line is known.
+type Problem struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	if p.Column > 0 {
+		return fmt.Sprintf("%d:%d: %s", p.Line, p.Column, p.Message)
+	}
+	return fmt.Sprintf("%d: %s", p.Line, p.Message)
+}
+
+var (
+	// yamlLinePattern matches the line yaml.v3 puts in its errors.
+	yamlLinePattern = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+	// unknownFieldPattern matches yaml.v3's error for an unknown key.
+	unknownFieldPattern = regexp.MustCompile(`^field (\S+) not found in type \S+$`)
+)
+
+// Lint checks profile YAML more thoroughly than Parse, reporting every
+// problem rather than the first, each at its line: YAML syntax, unknown
+// keys and mistyped values, checklists without an ID or checks or with a
+// repeated ID, checks Validate rejects, custom categories it rejects,
+// focus categories, disabled categories, and severity bounds for
+// categories that are neither built in nor defined, inverted or unknown
+// severity bounds, constraint sections not in ConstraintTypes,
+// contradiction pairs with an empty side or an unknown severity, empty
+// fabrication phrases, and invalid evidence rules. A profile Lint passes
+// also passes Parse; the reverse does not hold, since Parse accepts any
+// constraint section.
+func Lint(data []byte) []Problem {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return yamlProblems(err)
+	}
+	if len(doc.Content) == 0 {
+		return []Problem{{Line: 1, Message: "empty profile"}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return []Problem{at(root, "want a mapping of profile keys")}
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var p Profile
+	var problems []Problem
+	if err := dec.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		problems = yamlProblems(err)
+	}
+
+	if _, v := lookup(root, "version"); v != nil {
+		if n, err := strconv.Atoi(v.Value); err == nil && n < 0 {
+			problems = append(problems, at(v, fmt.Sprintf("version %d: want a positive number", n)))
+		}
+	}
+	custom, categoryProblems := lintCategories(root)
+	problems = append(problems, categoryProblems...)
+	_, extends := lookup(root, "extends")
+	problems = append(problems, lintChecklists(root, custom, !blank(extends))...)
+	for _, key := range []string{"focus_categories", "disabled_categories"} {
+		if _, v := lookup(root, key); v != nil && v.Kind == yaml.SequenceNode {
+			for i, c := range v.Content {
+				if !review.Category(c.Value).Valid() && !custom[c.Value] && blank(extends) {
+					problems = append(problems, at(c, fmt.Sprintf("%s[%d]: unknown category %q", key, i, c.Value)))
+				}
+			}
+		}
+	}
+	if _, v := lookup(root, "category_severity"); v != nil && v.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(v.Content); i += 2 {
+			k, b := v.Content[i], v.Content[i+1]
+			if !review.Category(k.Value).Valid() && !custom[k.Value] && blank(extends) {
+				problems = append(problems, at(k, fmt.Sprintf("category_severity: unknown category %q", k.Value)))
+			}
+			bounds := map[string]string{}
+			for _, key := range []string{"min_severity", "max_severity"} {
+				if _, s := lookup(b, key); s != nil && s.Value != "" {
+					if !severities[s.Value] {
+						problems = append(problems, at(s, fmt.Sprintf("category_severity.%s: invalid %s %q (valid: CRITICAL, WARN, INFO)", k.Value, key, s.Value)))
+						continue
+					}
+					bounds[key] = s.Value
+				}
+			}
+			if lo, hi := bounds["min_severity"], bounds["max_severity"]; lo != "" && hi != "" && review.Severity(lo).Order() < review.Severity(hi).Order() {
+				problems = append(problems, at(k, fmt.Sprintf("category_severity.%s: min_severity %s is above max_severity %s", k.Value, lo, hi)))
+			}
+		}
+	}
+	if _, v := lookup(root, "constraints"); v != nil && v.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(v.Content); i += 2 {
+			k := v.Content[i]
+			if !slices.Contains(ConstraintTypes, k.Value) {
+				problems = append(problems, at(k, fmt.Sprintf("unknown constraint type %q (valid: %s)", k.Value, strings.Join(ConstraintTypes, ", "))))
+			}
+		}
+	}
+	if _, h := lookup(root, "heuristics"); h != nil {
+		if _, cs := lookup(h, "contradictions"); cs != nil && cs.Kind == yaml.SequenceNode {
+			for i, c := range cs.Content {
+				_, a := lookup(c, "trigger_a")
+				_, b := lookup(c, "trigger_b")
+				if blank(a) || blank(b) {
+					problems = append(problems, at(c, fmt.Sprintf("heuristics.contradictions[%d]: trigger_a and trigger_b are required", i)))
+				}
+				if _, s := lookup(c, "severity"); s != nil && s.Value != "" && !severities[s.Value] {
+					problems = append(problems, at(s, fmt.Sprintf("heuristics.contradictions[%d]: invalid severity %q (valid: CRITICAL, WARN, INFO)", i, s.Value)))
+				}
+			}
+		}
+		if _, fs := lookup(h, "fabrication_phrases"); fs != nil && fs.Kind == yaml.SequenceNode {
+			for i, f := range fs.Content {
+				if blank(f) {
+					problems = append(problems, at(f, fmt.Sprintf("heuristics.fabrication_phrases[%d] is empty", i)))
+				}
+			}
+		}
+	}
+	if _, e := lookup(root, "evidence"); e != nil {
+		if _, m := lookup(e, "min_citations"); m != nil && m.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(m.Content); i += 2 {
+				k, v := m.Content[i], m.Content[i+1]
+				if !severities[k.Value] {
+					problems = append(problems, at(k, fmt.Sprintf("evidence.min_citations: invalid severity %q (valid: CRITICAL, WARN, INFO)", k.Value)))
+				}
+				if n, err := strconv.Atoi(v.Value); err == nil && n < 0 {
+					problems = append(problems, at(v, fmt.Sprintf("evidence.min_citations.%s: %d is negative", k.Value, n)))
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].Line != problems[j].Line {
+			return problems[i].Line < problems[j].Line
+		}
+		return problems[i].Column < problems[j].Column
+	})
+	return problems
+}
+
+// LintFile lints the profile file at path. A profile that passes and
+// extends another is then loaded, so a missing parent or a merge that
+// fails validation is reported at its extends line.
+func LintFile(path string) ([]Problem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("profile.LintFile: %w", err)
+	}
+	problems := Lint(data)
+	if len(problems) > 0 {
+		return problems, nil
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return problems, nil
+	}
+	k, v := lookup(doc.Content[0], "extends")
+	if v == nil || v.Value == "" {
+		return problems, nil
+	}
+	if _, err := LoadFile(path); err != nil {
+		msg := err.Error()
+		if i := strings.Index(msg, path+": "); i >= 0 {
+			msg = msg[i+len(path)+2:]
+		}
+		problems = append(problems, at(k, msg))
+	}
+	return problems, nil
+}
+
+// lintCategories reports the custom category problems Lint describes,
+// and returns the IDs of the categories defined.
+func lintCategories(root *yaml.Node) (map[string]bool, []Problem) {
+	_, cats := lookup(root, "categories")
+	if cats == nil || cats.Kind != yaml.SequenceNode {
+		return nil, nil
+	}
+	var problems []Problem
+	first := map[string]int{} // category ID to the line it is first defined on
+	for i, c := range cats.Content {
+		if c.Kind != yaml.MappingNode {
+			continue
+		}
+		name := fmt.Sprintf("categories[%d]", i)
+		_, id := lookup(c, "id")
+		switch {
+		case blank(id):
+			problems = append(problems, at(c, name+": missing id"))
+		case !categoryPattern.MatchString(id.Value):
+			problems = append(problems, at(id, fmt.Sprintf("%s: id %q: want upper case letters, digits, and underscores", name, id.Value)))
+		case review.Category(id.Value).Valid():
+			problems = append(problems, at(id, fmt.Sprintf("%s: %s is a built-in category", name, id.Value)))
+		case first[id.Value] > 0:
+			problems = append(problems, at(id, fmt.Sprintf("%s: duplicate id %q (first defined on line %d)", name, id.Value, first[id.Value])))
+		default:
+			first[id.Value] = id.Line
+			name = "category " + id.Value
+		}
+		if _, d := lookup(c, "description"); blank(d) {
+			problems = append(problems, at(c, name+": missing description"))
+		}
+		if _, s := lookup(c, "default_severity"); s != nil && s.Value != "" && !severities[s.Value] {
+			problems = append(problems, at(s, fmt.Sprintf("%s: invalid default_severity %q (valid: CRITICAL, WARN, INFO)", name, s.Value)))
+		}
+	}
+	ids := make(map[string]bool, len(first))
+	for id := range first {
+		ids[id] = true
+	}
+	return ids, problems
+}
+
+// lintChecklists reports the checklist problems Lint describes. custom
+// are the profile's own categories; a profile that extends another may
+// also name the other's, so unknown check categories are not reported.
+func lintChecklists(root *yaml.Node, custom map[string]bool, extends bool) []Problem {
+	_, cls := lookup(root, "checklists")
+	if cls == nil || cls.Kind != yaml.SequenceNode {
+		return nil
+	}
+	var problems []Problem
+	first := map[string]int{}      // checklist ID to the line it is first defined on
+	firstCheck := map[string]int{} // check ID to the line it is first defined on
+	for i, cl := range cls.Content {
+		if cl.Kind != yaml.MappingNode {
+			continue
+		}
+		name := fmt.Sprintf("checklists[%d]", i)
+		_, id := lookup(cl, "id")
+		switch {
+		case blank(id):
+			problems = append(problems, at(cl, name+": missing id"))
+		case first[id.Value] > 0:
+			problems = append(problems, at(id, fmt.Sprintf("%s: duplicate id %q (first defined on line %d)", name, id.Value, first[id.Value])))
+		default:
+			first[id.Value] = id.Line
+			name = "checklist " + id.Value
+		}
+		k, checks := lookup(cl, "checks")
+		if blank(checks) || (checks.Kind == yaml.SequenceNode && len(checks.Content) == 0) {
+			n := cl
+			if k != nil {
+				n = k
+			}
+			problems = append(problems, at(n, name+": no checks"))
+			continue
+		}
+		if checks.Kind != yaml.SequenceNode {
+			continue
+		}
+		for j, c := range checks.Content {
+			if c.Kind != yaml.MappingNode {
+				if blank(c) {
+					problems = append(problems, at(c, fmt.Sprintf("%s: checks[%d] is empty", name, j)))
+				}
+				continue
+			}
+			if _, text := lookup(c, "text"); blank(text) {
+				problems = append(problems, at(c, fmt.Sprintf("%s: checks[%d] is empty", name, j)))
+			}
+			if _, cid := lookup(c, "id"); !blank(cid) {
+				if line := firstCheck[cid.Value]; line > 0 {
+					problems = append(problems, at(cid, fmt.Sprintf("%s: checks[%d]: duplicate check id %q (first defined on line %d)", name, j, cid.Value, line)))
+				} else {
+					firstCheck[cid.Value] = cid.Line
+				}
+			}
+			if _, cat := lookup(c, "category"); !blank(cat) && !review.Category(cat.Value).Valid() && !custom[cat.Value] && !extends {
+				problems = append(problems, at(cat, fmt.Sprintf("%s: checks[%d]: unknown category %q", name, j, cat.Value)))
+			}
+			if _, ref := lookup(c, "reference"); !blank(ref) && !isWebURL(ref.Value) {
+				problems = append(problems, at(ref, fmt.Sprintf("%s: checks[%d]: reference %q: want an http or https URL", name, j, ref.Value)))
+			}
+		}
+	}
+	return problems
+}
+
+// yamlProblems turns a yaml.v3 decoding error into problems at the
+// lines it names.
+func yamlProblems(err error) []Problem {
+	msgs := []string{err.Error()}
+	var te *yaml.TypeError
+	if errors.As(err, &te) {
+		msgs = te.Errors
+	}
+	var problems []Problem
+	for _, msg := range msgs {
+		line := 1
+		if m := yamlLinePattern.FindStringSubmatch(msg); m != nil {
+			line, _ = strconv.Atoi(m[1])
+			msg = m[2]
+		}
+		if m := unknownFieldPattern.FindStringSubmatch(msg); m != nil {
+			msg = fmt.Sprintf("unknown key %q", m[1])
+		}
+		problems = append(problems, Problem{Line: line, Message: strings.TrimPrefix(msg, "yaml: ")})
+	}
+	return problems
+}
+
+// lookup returns the key and value nodes of key in mapping n, or nils.
+func lookup(n *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i], n.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// blank reports whether n is missing or an empty scalar.
+func blank(n *yaml.Node) bool {
+	return n == nil || (n.Kind == yaml.ScalarNode && strings.TrimSpace(n.Value) == "")
+}
+
+// at returns a problem at n's position.
+func at(n *yaml.Node, msg string) Problem {
+	return Problem{Line: n.Line, Column: n.Column, Message: msg}
+}
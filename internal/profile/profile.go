@@ -4,9 +4,11 @@ package profile
 import (
 	"embed"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 
+	"github.com/dshills/plancritic/internal/review"
 	"gopkg.in/yaml.v3"
 )
 
@@ -21,19 +23,171 @@ type Profile struct {
 	Constraints map[string]interface{} `yaml:"constraints"`
 	Checklists  []Checklist            `yaml:"checklists"`
 	Heuristics  Heuristics             `yaml:"heuristics"`
+	Evidence    EvidenceRules          `yaml:"evidence"`
+	// Classification and AllowedProviders restrict which providers may
+	// review plans with this profile, like the plan front matter keys
+	// of the same names.
+	Classification   string   `yaml:"classification"`
+	AllowedProviders []string `yaml:"allowed_providers"`
+	// FocusCategories are the issue categories the profile is about:
+	// the model is asked to look for them first, and to file a finding
+	// that fits one of them and another category under the focus one.
+	FocusCategories []string `yaml:"focus_categories,omitempty"`
+	// Categories are issue categories the profile adds to the built-in
+	// ones, for findings specific to its domain. See Category.
+	Categories []Category `yaml:"categories,omitempty"`
+	// CategorySeverity bounds the severity of issues by category, as
+	// team policy: after the review, an issue below its category's
+	// min_severity is raised to it and one above its max_severity is
+	// lowered to it.
+	CategorySeverity map[string]SeverityBounds `yaml:"category_severity,omitempty"`
+	// DisabledCategories are issue categories the team does not want
+	// reported: the model is asked not to, and any such findings are
+	// dropped before scoring.
+	DisabledCategories []string `yaml:"disabled_categories,omitempty"`
+	// Base names the profile this one was generated from; empty for
+	// a profile defined in YAML. See Variants.
+	Base string `yaml:"-"`
+	// Extends names the profile a profile file builds on: a profile
+	// name, or a path relative to the file. It is empty once the file
+	// is loaded. See Extend.
+	Extends string `yaml:"extends,omitempty"`
+}
+
+// EvidenceRules raises the evidence bar for issues by severity.
+type EvidenceRules struct {
+	// MinCitations maps a severity (CRITICAL, WARN, INFO) to the fewest
+	// evidence entries an issue of that severity must cite.
+	MinCitations map[string]int `yaml:"min_citations"`
+	// AllowContradictionPair lets an issue meet its minimum with one
+	// citation whose lines contain both phrases of a contradiction
+	// heuristic.
+	AllowContradictionPair bool `yaml:"allow_contradiction_pair"`
+}
+
+// Category is an issue category a profile defines, such as
+// PRIVACY_RISK. The model is told about it alongside the built-in
+// categories, and a review may file issues under it.
+type Category struct {
+	// ID is the category's value in issues: upper case letters,
+	// digits, and underscores, not naming a built-in category.
+	ID          string `yaml:"id"`
+	Description string `yaml:"description"`
+	// DefaultSeverity is the severity the model should give findings
+	// in the category unless the plan warrants another; empty leaves
+	// it to the model.
+	DefaultSeverity string `yaml:"default_severity,omitempty"`
+}
+
+// SeverityBounds is the range of severities a category's issues may
+// have; an empty bound leaves that side open.
+type SeverityBounds struct {
+	MinSeverity string `yaml:"min_severity,omitempty"`
+	MaxSeverity string `yaml:"max_severity,omitempty"`
+}
+
+// CategoryIDs returns the IDs of the categories p defines.
+func (p *Profile) CategoryIDs() []review.Category {
+	if p == nil || len(p.Categories) == 0 {
+		return nil
+	}
+	ids := make([]review.Category, len(p.Categories))
+	for i, c := range p.Categories {
+		ids[i] = review.Category(c.ID)
+	}
+	return ids
+}
+
+// DisableCategories adds cats to the categories p disables, as
+// --disable-category gives them. Each must be a built-in category or
+// one p defines; case is ignored.
+func DisableCategories(p *Profile, cats []string) error {
+	for _, c := range cats {
+		c = strings.ToUpper(strings.TrimSpace(c))
+		if !review.Category(c).Valid() && !slices.Contains(p.CategoryIDs(), review.Category(c)) {
+			return fmt.Errorf("unknown category %q", c)
+		}
+		p.DisabledCategories = appendNew(p.DisabledCategories, []string{c})
+	}
+	return nil
 }
 
 // Checklist is a named group of checks.
 type Checklist struct {
-	ID     string   `yaml:"id"`
-	Title  string   `yaml:"title"`
-	Checks []string `yaml:"checks"`
+	ID     string  `yaml:"id"`
+	Title  string  `yaml:"title"`
+	Checks []Check `yaml:"checks"`
+}
+
+// Check is one item of a checklist. In YAML it is either the check's
+// text alone or a mapping of the fields below.
+type Check struct {
+	// ID identifies the check in review results, so a failure can be
+	// traced to the policy it enforces. It is unique in a profile.
+	ID   string `yaml:"id,omitempty"`
+	Text string `yaml:"text"`
+	// Category is the issue category a plan failing the check has a
+	// finding in.
+	Category string `yaml:"category,omitempty"`
+	// Reference is the URL of the policy behind the check.
+	Reference string `yaml:"reference,omitempty"`
+	// Required marks a check the plan must pass: in a checklist-mode
+	// review, failing it makes the plan NOT_EXECUTABLE.
+	Required bool `yaml:"required,omitempty"`
+}
+
+// checkKeys are the keys a check given as a mapping may have.
+var checkKeys = []string{"id", "text", "category", "reference", "required"}
+
+// UnmarshalYAML reads a check given as its text or as a mapping,
+// rejecting unknown keys in a mapping as a strict decoder would.
+func (c *Check) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		*c = Check{Text: n.Value}
+		return nil
+	}
+	if n.Kind == yaml.MappingNode {
+		var unknown []string
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if k := n.Content[i]; !slices.Contains(checkKeys, k.Value) {
+				unknown = append(unknown, fmt.Sprintf("line %d: field %s not found in type profile.Check", k.Line, k.Value))
+			}
+		}
+		if len(unknown) > 0 {
+			return &yaml.TypeError{Errors: unknown}
+		}
+	}
+	type plain Check
+	return n.Decode((*plain)(c))
+}
+
+// MarshalYAML writes a check that has only text as the text alone.
+func (c Check) MarshalYAML() (interface{}, error) {
+	if c == (Check{Text: c.Text}) {
+		return c.Text, nil
+	}
+	type plain Check
+	return plain(c), nil
+}
+
+// String returns the check's text.
+func (c Check) String() string {
+	return c.Text
 }
 
 // Heuristics defines pattern-based triggers.
 type Heuristics struct {
 	Contradictions    []Contradiction `yaml:"contradictions"`
 	AmbiguityTriggers []string        `yaml:"ambiguity_triggers"`
+	// LocalizedAmbiguityTriggers maps an ISO 639-1 language code to
+	// vague phrases in that language. They are added to the prompt
+	// alongside AmbiguityTriggers when the plan is in that language.
+	LocalizedAmbiguityTriggers map[string][]string `yaml:"localized_ambiguity_triggers"`
+	// FabricationPhrases are phrases suggesting the model invented
+	// knowledge of the team's systems, such as "our Kubernetes
+	// cluster". In strict mode they extend the phrases
+	// review.CheckGrounding looks for.
+	FabricationPhrases []string `yaml:"fabrication_phrases,omitempty"`
 }
 
 // Contradiction defines a pair of phrases that indicate a plan contradiction.
@@ -44,8 +198,22 @@ type Contradiction struct {
 	Note     string `yaml:"note"`
 }
 
-// LoadBuiltin loads a built-in profile by name.
+// LoadBuiltin loads a built-in profile by name. A name such as
+// go-backend-strict that is not defined in YAML is generated from its
+// base profile by the matching Variant.
 func LoadBuiltin(name string) (*Profile, error) {
+	p, err := loadFile(name)
+	if err == nil {
+		return p, nil
+	}
+	if v, ok, verr := loadVariant(name); ok {
+		return v, verr
+	}
+	return nil, err
+}
+
+// loadFile loads the built-in profile defined in YAML as name.
+func loadFile(name string) (*Profile, error) {
 	filename := name + ".yaml"
 	data, err := builtinFS.ReadFile("builtin/" + filename)
 	if err != nil {
@@ -58,20 +226,103 @@ func LoadBuiltin(name string) (*Profile, error) {
 	return &p, nil
 }
 
-// List returns the names of all available built-in profiles.
-func List() ([]string, error) {
+// Profile sources reported in Info.Source.
+const (
+	// SourceBuiltin is a profile embedded in the binary.
+	SourceBuiltin = "builtin"
+	// SourceProject is a profile in the project's ProjectDir.
+	SourceProject = "project"
+	// SourceUser is a profile in the user's UserDir.
+	SourceUser = "user"
+)
+
+// List returns all available profiles in the order Load resolves them:
+// those in the search directories (see Dirs), then the built-in ones.
+// Each profile defined in YAML is followed by its generated variants. A
+// profile hidden by one of the same name earlier in the order is left
+// out. A profile file that fails to load is listed with its Error set
+// and without variants, rather than failing the whole list.
+func List() ([]Info, error) {
+	var infos []Info
+	seen := map[string]bool{}
+	add := func(names []string, source string, load func(string) (*Profile, error)) {
+		defined := map[string]bool{}
+		for _, name := range names {
+			defined[name] = true
+		}
+		for _, base := range names {
+			if seen[base] {
+				continue
+			}
+			p, err := load(base)
+			seen[base] = true
+			if err != nil {
+				// A broken file still hides later profiles of its name,
+				// as it does in Load, so it is listed with its error.
+				infos = append(infos, Info{Name: base, Source: source, Error: err.Error()})
+				continue
+			}
+			infos = append(infos, Info{Name: base, Description: oneLine(p.Description), Version: p.Version, Source: source})
+			for _, v := range Variants {
+				name := base + "-" + v.Suffix
+				if defined[name] || seen[name] || strings.HasSuffix(base, "-"+v.Suffix) {
+					continue
+				}
+				seen[name] = true
+				infos = append(infos, Info{
+					Name:        name,
+					Description: oneLine(p.Description + " " + v.Description),
+					Version:     p.Version,
+					Source:      source,
+					Base:        base,
+					Variant:     v.Suffix,
+				})
+			}
+		}
+	}
+
+	for _, d := range Dirs() {
+		names, err := dirProfiles(d.Path)
+		if err != nil {
+			return nil, err
+		}
+		dir := d.Path
+		add(names, d.Source, func(name string) (*Profile, error) {
+			path, _ := profileFile(dir, name)
+			return LoadFile(path)
+		})
+	}
+
 	entries, err := builtinFS.ReadDir("builtin")
 	if err != nil {
 		return nil, err
 	}
-	var names []string
+	var bases []string
 	for _, e := range entries {
-		if e.IsDir() {
-			continue
+		if n := e.Name(); !e.IsDir() && strings.HasSuffix(n, ".yaml") {
+			bases = append(bases, strings.TrimSuffix(n, ".yaml"))
 		}
-		n := e.Name()
-		if strings.HasSuffix(n, ".yaml") {
-			names = append(names, strings.TrimSuffix(n, ".yaml"))
+	}
+	add(bases, SourceBuiltin, loadFile)
+	return infos, nil
+}
+
+// oneLine collapses the whitespace of a folded YAML description.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// Names returns the names of all available profiles in List order,
+// leaving out those that fail to load.
+func Names() ([]string, error) {
+	infos, err := List()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, info := range infos {
+		if info.Error == "" {
+			names = append(names, info.Name)
 		}
 	}
 	return names, nil
@@ -79,6 +330,24 @@ func List() ([]string, error) {
 
 // FormatForPrompt renders the profile into text suitable for inclusion in the LLM prompt.
 func FormatForPrompt(p *Profile) string {
+	return FormatForPromptLanguage(p, "")
+}
+
+// AmbiguityTriggers returns the profile's vague phrases for a plan
+// written in lang (ISO 639-1): the default triggers, then any localized
+// ones for lang.
+func AmbiguityTriggers(p *Profile, lang string) []string {
+	triggers := p.Heuristics.AmbiguityTriggers
+	if lang != "" {
+		triggers = append(append([]string(nil), triggers...), p.Heuristics.LocalizedAmbiguityTriggers[strings.ToLower(lang)]...)
+	}
+	return triggers
+}
+
+// FormatForPromptLanguage is FormatForPrompt for a plan written in
+// lang (ISO 639-1). Localized ambiguity triggers for lang are listed
+// after the profile's default triggers.
+func FormatForPromptLanguage(p *Profile, lang string) string {
 	var b strings.Builder
 
 	fmt.Fprintf(&b, "## Profile: %s\n\n", p.Name)
@@ -86,6 +355,53 @@ func FormatForPrompt(p *Profile) string {
 		fmt.Fprintf(&b, "%s\n\n", strings.TrimSpace(p.Description))
 	}
 
+	if len(p.FocusCategories) > 0 {
+		b.WriteString("### Focus Categories\n\n")
+		fmt.Fprintf(&b, "This profile focuses on %s findings. Look for these first, and when a finding fits one of them and another category, use the focus category.\n\n", strings.Join(p.FocusCategories, ", "))
+	}
+
+	if len(p.Categories) > 0 {
+		b.WriteString("### Custom Categories\n\n")
+		b.WriteString("Besides the categories in the schema, \"category\" may be one of these. Use one when a finding fits it better than a built-in category.\n")
+		for _, c := range p.Categories {
+			fmt.Fprintf(&b, "- %s: %s", c.ID, strings.TrimSpace(c.Description))
+			if c.DefaultSeverity != "" {
+				fmt.Fprintf(&b, " (usually %s)", c.DefaultSeverity)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	if len(p.DisabledCategories) > 0 {
+		b.WriteString("### Disabled Categories\n\n")
+		fmt.Fprintf(&b, "Do not report %s findings. This team does not want them, and they are dropped from the review.\n\n", strings.Join(p.DisabledCategories, ", "))
+	}
+
+	if len(p.CategorySeverity) > 0 {
+		b.WriteString("### Severity Policy\n\n")
+		b.WriteString("Issues in these categories are given a severity in this range, whatever the finding:\n")
+		cats := make([]string, 0, len(p.CategorySeverity))
+		for c := range p.CategorySeverity {
+			cats = append(cats, c)
+		}
+		sort.Strings(cats)
+		for _, c := range cats {
+			bounds := p.CategorySeverity[c]
+			var parts []string
+			if bounds.MinSeverity != "" {
+				parts = append(parts, "at least "+bounds.MinSeverity)
+			}
+			if bounds.MaxSeverity != "" {
+				parts = append(parts, "at most "+bounds.MaxSeverity)
+			}
+			if len(parts) > 0 {
+				fmt.Fprintf(&b, "- %s: %s\n", c, strings.Join(parts, ", "))
+			}
+		}
+		b.WriteString("\n")
+	}
+
 	// Render constraints as YAML-like text
 	if len(p.Constraints) > 0 {
 		b.WriteString("### Constraints\n\n")
@@ -99,14 +415,33 @@ func FormatForPrompt(p *Profile) string {
 		for _, cl := range p.Checklists {
 			fmt.Fprintf(&b, "**%s** (%s)\n", cl.Title, cl.ID)
 			for _, check := range cl.Checks {
-				fmt.Fprintf(&b, "- %s\n", check)
+				b.WriteString("- ")
+				if check.ID != "" {
+					fmt.Fprintf(&b, "[%s] ", check.ID)
+				}
+				b.WriteString(check.Text)
+				var notes []string
+				if check.Required {
+					notes = append(notes, "required")
+				}
+				if check.Category != "" {
+					notes = append(notes, "category "+check.Category)
+				}
+				if check.Reference != "" {
+					notes = append(notes, "see "+check.Reference)
+				}
+				if len(notes) > 0 {
+					fmt.Fprintf(&b, " (%s)", strings.Join(notes, "; "))
+				}
+				b.WriteString("\n")
 			}
 			b.WriteString("\n")
 		}
 	}
 
 	// Render heuristics
-	if len(p.Heuristics.Contradictions) > 0 || len(p.Heuristics.AmbiguityTriggers) > 0 {
+	triggers := AmbiguityTriggers(p, lang)
+	if len(p.Heuristics.Contradictions) > 0 || len(triggers) > 0 {
 		b.WriteString("### Heuristics\n\n")
 		if len(p.Heuristics.Contradictions) > 0 {
 			b.WriteString("Watch for these contradiction pairs:\n")
@@ -115,15 +450,31 @@ func FormatForPrompt(p *Profile) string {
 			}
 			b.WriteString("\n")
 		}
-		if len(p.Heuristics.AmbiguityTriggers) > 0 {
+		if len(triggers) > 0 {
 			b.WriteString("Flag these vague phrases as ambiguity:\n")
-			for _, trigger := range p.Heuristics.AmbiguityTriggers {
+			for _, trigger := range triggers {
 				fmt.Fprintf(&b, "- %q\n", trigger)
 			}
 			b.WriteString("\n")
 		}
 	}
 
+	if len(p.Evidence.MinCitations) > 0 {
+		b.WriteString("### Evidence Requirements\n\n")
+		for _, sev := range []string{"CRITICAL", "WARN", "INFO"} {
+			n := p.Evidence.MinCitations[sev]
+			if n <= 1 {
+				continue
+			}
+			fmt.Fprintf(&b, "- %s issues must cite at least %d evidence entries", sev, n)
+			if p.Evidence.AllowContradictionPair && len(p.Heuristics.Contradictions) > 0 {
+				b.WriteString(", or one entry whose lines contain both sides of a contradiction pair above")
+			}
+			b.WriteString(". Lower the severity of findings you cannot support this way.\n")
+		}
+		b.WriteString("\n")
+	}
+
 	return b.String()
 }
 
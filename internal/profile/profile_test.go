@@ -1,12 +1,17 @@
 package profile
 
 import (
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestLoadBuiltinAll(t *testing.T) {
-	names := []string{"general", "go-backend", "react-frontend", "aws-deploy", "davin-go"}
+	names := []string{"general", "go-backend", "python-backend", "react-frontend", "aws-deploy", "terraform", "data-migration", "security-review", "api-design", "davin-go"}
 	for _, name := range names {
 		t.Run(name, func(t *testing.T) {
 			p, err := LoadBuiltin(name)
@@ -39,16 +44,25 @@ func TestLoadBuiltinNotFound(t *testing.T) {
 }
 
 func TestList(t *testing.T) {
-	names, err := List()
+	infos, err := List()
 	if err != nil {
 		t.Fatal(err)
 	}
-	if len(names) < 4 {
-		t.Errorf("expected at least 4 profiles, got %d", len(names))
+	if len(infos) < 4 {
+		t.Errorf("expected at least 4 profiles, got %d", len(infos))
 	}
-	required := map[string]bool{"general": false, "go-backend": false, "react-frontend": false, "aws-deploy": false}
-	for _, n := range names {
-		required[n] = true
+	required := map[string]bool{"general": false, "go-backend": false, "react-frontend": false, "aws-deploy": false, "go-backend-strict": false}
+	for _, info := range infos {
+		required[info.Name] = true
+		if info.Name == "go-backend-strict" && (info.Base != "go-backend" || info.Variant != "strict") {
+			t.Errorf("go-backend-strict lineage = %+v", info)
+		}
+		if info.Name == "go-backend" && info.Base != "" {
+			t.Errorf("go-backend lineage = %+v", info)
+		}
+		if info.Source != SourceBuiltin || info.Version < 1 || info.Description == "" || strings.Contains(info.Description, "\n") {
+			t.Errorf("%s info = %+v, want a builtin with a version and a one-line description", info.Name, info)
+		}
 	}
 	for name, found := range required {
 		if !found {
@@ -79,3 +93,787 @@ func TestFormatForPrompt(t *testing.T) {
 		}
 	}
 }
+
+func TestFormatForPromptLanguage(t *testing.T) {
+	p, err := LoadBuiltin("general")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(FormatForPrompt(p), "skalierbar") {
+		t.Error("localized triggers should not appear without a language")
+	}
+	text := FormatForPromptLanguage(p, "DE")
+	for _, want := range []string{`"scalable"`, `"skalierbar"`} {
+		if !strings.Contains(text, want) {
+			t.Errorf("German prompt missing %s", want)
+		}
+	}
+	if strings.Contains(text, "évolutif") {
+		t.Error("French triggers leaked into German prompt")
+	}
+}
+
+func TestFormatForPromptEvidenceRequirements(t *testing.T) {
+	p, err := LoadBuiltin("go-backend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Evidence.MinCitations["CRITICAL"] != 2 || !p.Evidence.AllowContradictionPair {
+		t.Fatalf("evidence rules = %+v", p.Evidence)
+	}
+	out := FormatForPrompt(p)
+	if !strings.Contains(out, "CRITICAL issues must cite at least 2 evidence entries, or one entry whose lines contain both sides") {
+		t.Errorf("prompt missing evidence requirements:\n%s", out)
+	}
+
+	general, err := LoadBuiltin("general")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(FormatForPrompt(general), "Evidence Requirements") {
+		t.Error("general profile should not raise the evidence bar")
+	}
+}
+
+func TestStrictVariant(t *testing.T) {
+	base, err := LoadBuiltin("go-backend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := LoadBuiltin("go-backend-strict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "go-backend-strict" || p.Base != "go-backend" {
+		t.Errorf("name, base = %q, %q", p.Name, p.Base)
+	}
+	if len(p.Checklists) != len(base.Checklists)+1 || p.Checklists[len(p.Checklists)-1].ID != "STRICT_COMPLETENESS" {
+		t.Errorf("checklists = %d, want the base's plus STRICT_COMPLETENESS", len(p.Checklists))
+	}
+	if got, want := len(p.Checklists[0].Checks), len(base.Checklists[0].Checks)+1; got != want {
+		t.Errorf("checks in %s = %d, want %d", p.Checklists[0].ID, got, want)
+	}
+	for _, c := range p.Heuristics.Contradictions {
+		if c.Severity != "CRITICAL" {
+			t.Errorf("contradiction %q severity = %s", c.TriggerA, c.Severity)
+		}
+	}
+	if !strings.Contains(FormatForPrompt(p), `"TBD"`) {
+		t.Error("strict triggers missing from the prompt")
+	}
+
+	// Deriving must not change the base.
+	p = Strict(base)
+	if len(base.Checklists[0].Checks) == len(p.Checklists[0].Checks) {
+		t.Error("Strict modified the base checklist")
+	}
+	if strings.Contains(FormatForPrompt(base), "STRICT_COMPLETENESS") {
+		t.Error("Strict modified the base profile")
+	}
+
+	if _, err := LoadBuiltin("nonexistent-strict"); err == nil {
+		t.Error("expected error for a variant of an unknown profile")
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	path := write("team-profile.yaml", `version: 2
+description: Team rules.
+checklists:
+  - id: PAYMENTS
+    title: Payments
+    checks:
+      - "Is every charge idempotent?"
+evidence:
+  min_citations:
+    CRITICAL: 2
+`)
+	p, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if p.Name != "team-profile" || p.Version != 2 || len(p.Checklists) != 1 || p.Evidence.MinCitations["CRITICAL"] != 2 {
+		t.Errorf("profile = %+v", p)
+	}
+	if !strings.Contains(FormatForPrompt(p), "Is every charge idempotent?") {
+		t.Error("checklist not in the prompt")
+	}
+
+	for name, content := range map[string]string{
+		"unknown key":        "name: x\nchecklist:\n  - id: A\n",
+		"no checks":          "checklists:\n  - id: A\n    title: A\n",
+		"duplicate id":       "checklists:\n  - id: A\n    checks: [a]\n  - id: A\n    checks: [b]\n",
+		"bad severity":       "heuristics:\n  contradictions:\n    - trigger_a: x\n      trigger_b: y\n      severity: HIGH\n",
+		"bad evidence":       "evidence:\n  min_citations:\n    BLOCKER: 2\n",
+		"empty":              "",
+		"not a profile file": "- a\n- b\n",
+	} {
+		if _, err := Load(write("bad.yaml", content)); err == nil {
+			t.Errorf("%s: Load succeeded", name)
+		}
+	}
+	if _, err := Load(filepath.Join(dir, "missing.yml")); err == nil {
+		t.Error("Load of a missing file succeeded")
+	}
+}
+
+func TestBuiltinsValidate(t *testing.T) {
+	entries, err := builtinFS.ReadDir("builtin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		data, err := builtinFS.ReadFile("builtin/" + e.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Parse(data); err != nil {
+			t.Errorf("%s: %v", e.Name(), err)
+		}
+	}
+}
+
+func TestProfileDirs(t *testing.T) {
+	project := t.TempDir()
+	t.Chdir(project)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	user, err := UserDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(dir, name, content string) {
+		t.Helper()
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(user, "acme-payments.yaml", "description: User payments rules.\nchecklists:\n  - id: PAY\n    checks: [\"Is every charge idempotent?\"]\n")
+	write(user, "general.yml", "name: general\ndescription: User general.\n")
+	write(ProjectDir, "general.yaml", "name: general\ndescription: Project general.\n")
+
+	p, err := Load("acme-payments")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if p.Name != "acme-payments" || len(p.Checklists) != 1 {
+		t.Errorf("acme-payments = %+v", p)
+	}
+	if p, err := Load("acme-payments-strict"); err != nil || p.Base != "acme-payments" || len(p.Checklists) != 2 {
+		t.Errorf("acme-payments-strict = %+v, %v; want the strict variant of the user profile", p, err)
+	}
+	if p, err := Load("general"); err != nil || p.Description != "Project general." {
+		t.Errorf("general = %+v, %v; want the project profile", p, err)
+	}
+	if p, err := Load("go-backend"); err != nil || p.Name != "go-backend" {
+		t.Errorf("go-backend = %+v, %v; want the built-in", p, err)
+	}
+
+	infos, err := List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources := map[string]string{}
+	for _, info := range infos {
+		if _, dup := sources[info.Name]; dup {
+			t.Errorf("%s listed twice", info.Name)
+		}
+		sources[info.Name] = info.Source
+	}
+	for name, want := range map[string]string{
+		"general":              SourceProject,
+		"general-strict":       SourceProject,
+		"acme-payments":        SourceUser,
+		"acme-payments-strict": SourceUser,
+		"go-backend":           SourceBuiltin,
+	} {
+		if sources[name] != want {
+			t.Errorf("%s source = %q, want %q", name, sources[name], want)
+		}
+	}
+
+	write(ProjectDir, "broken.yaml", "checklist: []\n")
+	if _, err := Load("broken"); err == nil {
+		t.Error("Load of an invalid project profile succeeded")
+	}
+	write(user, "go-backend.yaml", "name: [unclosed\n")
+	infos, err = List()
+	if err != nil {
+		t.Fatalf("List with broken profile files: %v", err)
+	}
+	bad := map[string]Info{}
+	for _, info := range infos {
+		if info.Error != "" {
+			bad[info.Name] = info
+		}
+		if info.Name == "broken-strict" || info.Name == "go-backend-strict" {
+			t.Errorf("%s listed from a broken file: %+v", info.Name, info)
+		}
+	}
+	if len(bad) != 2 || bad["broken"].Source != SourceProject || bad["go-backend"].Source != SourceUser {
+		t.Errorf("broken entries = %+v, want broken (project) and go-backend (user)", bad)
+	}
+	names, err := Names()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(names, "broken") || slices.Contains(names, "go-backend") || !slices.Contains(names, "general") {
+		t.Errorf("Names = %v, want the broken profiles left out", names)
+	}
+}
+
+func TestExtends(t *testing.T) {
+	project := t.TempDir()
+	t.Chdir(project)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	base, err := LoadBuiltin("go-backend")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write(filepath.Join(ProjectDir, "acme-go.yaml"), `extends: go-backend
+description: Acme Go services.
+constraints:
+  database:
+    preferred: postgres16
+    rules:
+      - Every migration is reversible.
+  queue: sqs
+checklists:
+  - id: TEST_MAPPING
+    checks: ["Does every handler have a contract test?"]
+  - id: ACME
+    title: Acme
+    checks: ["Is the service registered in the catalog?"]
+heuristics:
+  ambiguity_triggers: ["TBD", "ask platform"]
+`)
+	p, err := Load("acme-go")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if p.Name != "acme-go" || p.Description != "Acme Go services." || p.Version != base.Version {
+		t.Errorf("scalars = %q %q %d", p.Name, p.Description, p.Version)
+	}
+	db := p.Constraints["database"].(map[string]interface{})
+	baseDB := base.Constraints["database"].(map[string]interface{})
+	if db["preferred"] != "postgres16" || len(db["rules"].([]interface{})) != len(baseDB["rules"].([]interface{}))+1 {
+		t.Errorf("database = %v", db)
+	}
+	if p.Constraints["queue"] != "sqs" || p.Constraints["language"] == nil {
+		t.Errorf("constraints = %v", p.Constraints)
+	}
+	if baseDB["preferred"] != "mysql8" {
+		t.Error("Extend modified the parent's constraints")
+	}
+	if len(p.Checklists) != len(base.Checklists)+1 || p.Checklists[len(p.Checklists)-1].ID != "ACME" {
+		t.Errorf("checklists = %+v", p.Checklists)
+	}
+	for i, cl := range p.Checklists {
+		if cl.ID == "TEST_MAPPING" && len(cl.Checks) != len(base.Checklists[i].Checks)+1 {
+			t.Errorf("TEST_MAPPING checks = %v", cl.Checks)
+		}
+	}
+	if n := len(p.Heuristics.AmbiguityTriggers); n != len(appendNew(base.Heuristics.AmbiguityTriggers, []string{"TBD", "ask platform"})) {
+		t.Errorf("ambiguity triggers = %v", p.Heuristics.AmbiguityTriggers)
+	}
+	if len(p.Heuristics.Contradictions) != len(base.Heuristics.Contradictions) {
+		t.Errorf("contradictions = %v", p.Heuristics.Contradictions)
+	}
+
+	// A project profile named like a user profile extends it.
+	user, err := UserDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(filepath.Join(user, "team.yaml"), "checklists:\n  - id: TEAM\n    checks: [\"Is the team named?\"]\n")
+	write(filepath.Join(ProjectDir, "team.yaml"), "extends: team\nchecklists:\n  - id: REPO\n    checks: [\"Is the repo named?\"]\n")
+	if p, err := Load("team"); err != nil || len(p.Checklists) != 2 {
+		t.Errorf("team = %+v, %v; want the project profile on the user one", p, err)
+	}
+
+	// Paths resolve against the extending file.
+	write(filepath.Join(project, "profiles", "base.yaml"), "checklists:\n  - id: BASE\n    checks: [\"Is there a base?\"]\n")
+	write(filepath.Join(project, "profiles", "child.yaml"), "extends: ./base.yaml\n")
+	if p, err := LoadFile(filepath.Join("profiles", "child.yaml")); err != nil || p.Name != "child" || len(p.Checklists) != 1 {
+		t.Errorf("child = %+v, %v", p, err)
+	}
+
+	write(filepath.Join(project, "a.yaml"), "extends: ./b.yaml\n")
+	write(filepath.Join(project, "b.yaml"), "extends: ./a.yaml\n")
+	if _, err := LoadFile("a.yaml"); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("cycle err = %v", err)
+	}
+	write(filepath.Join(project, "missing.yaml"), "extends: no-such-profile\n")
+	if _, err := LoadFile("missing.yaml"); err == nil {
+		t.Error("extends of a missing profile succeeded")
+	}
+	write(filepath.Join(project, "bad.yaml"), "extends: go-backend\nheuristics:\n  contradictions:\n    - trigger_a: x\n      severity: LOUD\n")
+	if _, err := LoadFile("bad.yaml"); err == nil {
+		t.Error("extends with an invalid contradiction succeeded")
+	}
+}
+
+func TestLint(t *testing.T) {
+	src := `name: team
+version: -1
+checklists:
+  - id: A
+    checks: ["Is it done?"]
+  - title: No ID
+    checks: ["Is it named?"]
+  - id: A
+    checks: []
+  - id: B
+    checks: ["", "Is B done?"]
+constraints:
+  language:
+    primary: go
+  queues:
+    rules: ["SQS only"]
+heuristics:
+  contradictions:
+    - trigger_a: x
+      severity: WARN
+    - trigger_a: x
+      trigger_b: y
+      severity: LOUD
+evidence:
+  min_citations:
+    BLOCKER: 2
+    WARN: -1
+colour: blue
+`
+	var got []string
+	for _, p := range Lint([]byte(src)) {
+		got = append(got, p.String())
+	}
+	want := []string{
+		"2:10: version -1: want a positive number",
+		"6:5: checklists[1]: missing id",
+		"8:9: checklists[2]: duplicate id \"A\" (first defined on line 4)",
+		"9:5: checklists[2]: no checks",
+		"11:14: checklist B: checks[0] is empty",
+		"15:3: unknown constraint type \"queues\" (valid: " + strings.Join(ConstraintTypes, ", ") + ")",
+		"19:7: heuristics.contradictions[0]: trigger_a and trigger_b are required",
+		"23:17: heuristics.contradictions[1]: invalid severity \"LOUD\" (valid: CRITICAL, WARN, INFO)",
+		"26:5: evidence.min_citations: invalid severity \"BLOCKER\" (valid: CRITICAL, WARN, INFO)",
+		"27:11: evidence.min_citations.WARN: -1 is negative",
+		"28: unknown key \"colour\"",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Lint =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if ps := Lint([]byte("name: [unclosed\n")); len(ps) != 1 || ps[0].Line == 0 {
+		t.Errorf("syntax error problems = %v", ps)
+	}
+	if ps := Lint(nil); len(ps) != 1 || ps[0].Message != "empty profile" {
+		t.Errorf("empty profile problems = %v", ps)
+	}
+	for _, name := range []string{"general", "go-backend", "python-backend", "react-frontend", "aws-deploy", "terraform", "data-migration", "security-review", "api-design", "davin-go"} {
+		data, err := builtinFS.ReadFile("builtin/" + name + ".yaml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ps := Lint(data); len(ps) > 0 {
+			t.Errorf("%s: %v", name, ps)
+		}
+	}
+}
+
+func TestStarter(t *testing.T) {
+	data := []byte(Starter("my-team"))
+	if ps := Lint(data); len(ps) > 0 {
+		t.Errorf("Lint(Starter) = %v", ps)
+	}
+	path := filepath.Join(t.TempDir(), "my-team.yaml")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile(Starter): %v", err)
+	}
+	general, err := LoadBuiltin("general")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "my-team" || len(p.Checklists) != len(general.Checklists)+1 || len(p.Heuristics.Contradictions) != 1 {
+		t.Errorf("starter = %+v", p)
+	}
+}
+
+func TestLoadRemote(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	content := []byte("extends: general\nchecklists:\n  - id: PAYMENTS\n    checks: [\"Is every charge idempotent?\"]\n")
+	digest := sha256Hex(content)
+	served := content
+	requests := 0
+	orig := remoteRead
+	remoteRead = func(rawURL string) ([]byte, error) {
+		requests++
+		if rawURL != "https://example.com/profiles/payments.yaml" {
+			t.Errorf("fetched %s", rawURL)
+		}
+		return served, nil
+	}
+	t.Cleanup(func() { remoteRead = orig })
+
+	name := "https://example.com/profiles/payments.yaml@sha256:" + digest
+	if !IsRemote(name) {
+		t.Fatalf("IsRemote(%s) = false", name)
+	}
+	p, err := Load(name)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	general, err := LoadBuiltin("general")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "payments" || len(p.Checklists) != len(general.Checklists)+1 {
+		t.Errorf("remote profile = %+v", p)
+	}
+
+	// The second load is served from the cache.
+	served = []byte("tampered")
+	if _, err := Load(name); err != nil || requests != 1 {
+		t.Errorf("cached Load: %v after %d requests", err, requests)
+	}
+
+	// Content that does not match its pin is refused.
+	other := "https://example.com/profiles/payments.yaml@sha256:" + strings.Repeat("0", 64)
+	if _, err := Load(other); err == nil || !strings.Contains(err.Error(), "refusing") {
+		t.Errorf("mismatched Load err = %v", err)
+	}
+	dir, err := CacheDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, strings.Repeat("0", 64)+".yaml")); err == nil {
+		t.Error("mismatched content was cached")
+	}
+
+	for _, bad := range []string{
+		"https://example.com/profiles/payments.yaml",
+		"http://example.com/profiles/payments.yaml@sha256:" + digest,
+		"https://example.com/profiles/payments.yaml@sha256:abc",
+	} {
+		if _, err := Load(bad); err == nil {
+			t.Errorf("Load(%s) succeeded", bad)
+		}
+	}
+}
+
+func TestApplyConstraints(t *testing.T) {
+	base, err := LoadBuiltin("go-backend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	child := Extend(base, &Profile{Name: "child"})
+	err = ApplyConstraints(child, []string{
+		"max_new_dependencies=0",
+		"database.preferred=postgres16",
+		"dependencies.allowed=[cobra, yaml]",
+		"strict_mode=true",
+		"owner=platform: payments",
+		"language=rust",
+		"language.primary=go",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := child.Constraints
+	if c["max_new_dependencies"] != 0 || c["strict_mode"] != true || c["owner"] != "platform: payments" {
+		t.Errorf("top-level overrides = %v %v %v", c["max_new_dependencies"], c["strict_mode"], c["owner"])
+	}
+	db := c["database"].(map[string]interface{})
+	if db["preferred"] != "postgres16" || db["rules"] == nil {
+		t.Errorf("database = %v", db)
+	}
+	if allowed, ok := c["dependencies"].(map[string]interface{})["allowed"].([]interface{}); !ok || len(allowed) != 2 {
+		t.Errorf("dependencies.allowed = %v", c["dependencies"])
+	}
+	if lang, ok := c["language"].(map[string]interface{}); !ok || lang["primary"] != "go" || len(lang) != 1 {
+		t.Errorf("language = %v, want a section replacing the scalar", c["language"])
+	}
+	if base.Constraints["database"].(map[string]interface{})["preferred"] != "mysql8" {
+		t.Error("override changed the extended profile")
+	}
+
+	for _, bad := range []string{"no-equals", "=1", "a..b=1", ".a=1"} {
+		if err := ApplyConstraints(child, []string{bad}); err == nil {
+			t.Errorf("ApplyConstraints(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestFocusCategories(t *testing.T) {
+	p, err := LoadBuiltin("security-review-strict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.FocusCategories) != 1 || p.FocusCategories[0] != "RISK_SECURITY" {
+		t.Fatalf("focus categories = %v", p.FocusCategories)
+	}
+	if out := FormatForPrompt(p); !strings.Contains(out, "### Focus Categories\n\nThis profile focuses on RISK_SECURITY findings.") {
+		t.Errorf("prompt lacks the focus categories:\n%s", out)
+	}
+
+	bad := []byte("focus_categories: [RISK_SECURITY, SECURITY]\nchecklists:\n  - id: A\n    checks: [\"Is it safe?\"]\n")
+	if _, err := Parse(bad); err == nil || !strings.Contains(err.Error(), `unknown category "SECURITY"`) {
+		t.Errorf("Parse err = %v", err)
+	}
+	if ps := Lint(bad); len(ps) != 1 || ps[0].Line != 1 || ps[0].Column != 35 {
+		t.Errorf("Lint = %v", ps)
+	}
+}
+
+func TestCustomCategories(t *testing.T) {
+	data := []byte(`name: privacy
+categories:
+  - id: PRIVACY_RISK
+    description: Personal data handled without a stated legal basis.
+    default_severity: WARN
+focus_categories: [PRIVACY_RISK]
+checklists:
+  - id: PRIVACY
+    checks: ["Is personal data minimized?"]
+`)
+	p, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := p.CategoryIDs(); len(ids) != 1 || ids[0] != "PRIVACY_RISK" {
+		t.Errorf("CategoryIDs = %v", ids)
+	}
+	if out := FormatForPrompt(p); !strings.Contains(out, "- PRIVACY_RISK: Personal data handled without a stated legal basis. (usually WARN)\n") {
+		t.Errorf("prompt lacks the custom category:\n%s", out)
+	}
+	if ps := Lint(data); len(ps) != 0 {
+		t.Errorf("Lint = %v", ps)
+	}
+
+	for _, tc := range []struct{ yaml, want string }{
+		{"categories:\n  - id: privacy\n    description: x\n", `id "privacy": want upper case`},
+		{"categories:\n  - id: RISK_DATA\n    description: x\n", "RISK_DATA is a built-in category"},
+		{"categories:\n  - id: COST\n    description: x\n  - id: COST\n    description: y\n", `duplicate id "COST"`},
+		{"categories:\n  - id: COST\n", "category COST: missing description"},
+		{"categories:\n  - id: COST\n    description: x\n    default_severity: HIGH\n", `invalid default_severity "HIGH"`},
+	} {
+		if _, err := Parse([]byte(tc.yaml)); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Parse(%q) err = %v, want %q", tc.yaml, err, tc.want)
+		}
+		if ps := Lint([]byte(tc.yaml)); len(ps) != 1 || !strings.Contains(ps[0].Message, tc.want) {
+			t.Errorf("Lint(%q) = %v, want %q", tc.yaml, ps, tc.want)
+		}
+	}
+
+	parent := &Profile{Name: "base", Categories: []Category{{ID: "COST", Description: "old"}, {ID: "PRIVACY_RISK", Description: "p"}}}
+	child := &Profile{Categories: []Category{{ID: "COST", Description: "new"}, {ID: "VENDOR_LOCK_IN", Description: "v"}}, FocusCategories: []string{"PRIVACY_RISK"}, Extends: "base"}
+	if err := child.Validate(); err != nil {
+		t.Errorf("child focusing on a parent category: %v", err)
+	}
+	merged := Extend(parent, child)
+	if err := merged.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range merged.Categories {
+		got = append(got, c.ID+"="+c.Description)
+	}
+	if strings.Join(got, " ") != "COST=new PRIVACY_RISK=p VENDOR_LOCK_IN=v" {
+		t.Errorf("merged categories = %v", got)
+	}
+	if parent.Categories[0].Description != "old" {
+		t.Error("Extend modified the parent")
+	}
+}
+
+func TestCategorySeverity(t *testing.T) {
+	data := []byte(`name: policy
+categories:
+  - id: PRIVACY_RISK
+    description: Personal data without a retention limit.
+category_severity:
+  RISK_DATA:
+    min_severity: CRITICAL
+  TEST_GAP:
+    max_severity: WARN
+  PRIVACY_RISK:
+    min_severity: WARN
+    max_severity: CRITICAL
+`)
+	p, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ps := Lint(data); len(ps) != 0 {
+		t.Errorf("Lint = %v", ps)
+	}
+	out := FormatForPrompt(p)
+	for _, want := range []string{"### Severity Policy", "- PRIVACY_RISK: at least WARN, at most CRITICAL\n- RISK_DATA: at least CRITICAL\n- TEST_GAP: at most WARN\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("prompt lacks %q:\n%s", want, out)
+		}
+	}
+
+	for _, tc := range []struct{ yaml, want string }{
+		{"category_severity:\n  DATA:\n    min_severity: WARN\n", `unknown category "DATA"`},
+		{"category_severity:\n  RISK_DATA:\n    min_severity: HIGH\n", `invalid min_severity "HIGH"`},
+		{"category_severity:\n  TEST_GAP:\n    min_severity: CRITICAL\n    max_severity: WARN\n", "min_severity CRITICAL is above max_severity WARN"},
+	} {
+		if _, err := Parse([]byte(tc.yaml)); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Parse(%q) err = %v, want %q", tc.yaml, err, tc.want)
+		}
+		if ps := Lint([]byte(tc.yaml)); len(ps) != 1 || !strings.Contains(ps[0].Message, tc.want) {
+			t.Errorf("Lint(%q) = %v, want %q", tc.yaml, ps, tc.want)
+		}
+	}
+
+	child := &Profile{CategorySeverity: map[string]SeverityBounds{"TEST_GAP": {MaxSeverity: "INFO"}}}
+	merged := Extend(p, child)
+	if got := merged.CategorySeverity["TEST_GAP"].MaxSeverity; got != "INFO" {
+		t.Errorf("merged TEST_GAP max = %q", got)
+	}
+	if got := merged.CategorySeverity["RISK_DATA"].MinSeverity; got != "CRITICAL" {
+		t.Errorf("merged RISK_DATA min = %q", got)
+	}
+	if got := p.CategorySeverity["TEST_GAP"].MaxSeverity; got != "WARN" {
+		t.Errorf("Extend modified the parent: TEST_GAP max = %q", got)
+	}
+}
+
+func TestDisabledCategories(t *testing.T) {
+	p, err := Parse([]byte("name: lean\ndisabled_categories: [SCOPE_CREEP_RISK]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := DisableCategories(p, []string{"test_gap", "SCOPE_CREEP_RISK"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(p.DisabledCategories, ","); got != "SCOPE_CREEP_RISK,TEST_GAP" {
+		t.Errorf("disabled categories = %s", got)
+	}
+	if out := FormatForPrompt(p); !strings.Contains(out, "Do not report SCOPE_CREEP_RISK, TEST_GAP findings.") {
+		t.Errorf("prompt lacks the disabled categories:\n%s", out)
+	}
+	if err := DisableCategories(p, []string{"SCOPE"}); err == nil || !strings.Contains(err.Error(), `unknown category "SCOPE"`) {
+		t.Errorf("DisableCategories err = %v", err)
+	}
+
+	bad := []byte("disabled_categories: [SCOPE]\n")
+	if _, err := Parse(bad); err == nil || !strings.Contains(err.Error(), `disabled_categories[0]: unknown category "SCOPE"`) {
+		t.Errorf("Parse err = %v", err)
+	}
+	if ps := Lint(bad); len(ps) != 1 || ps[0].Line != 1 || ps[0].Column != 23 {
+		t.Errorf("Lint = %v", ps)
+	}
+}
+
+func TestFabricationPhrases(t *testing.T) {
+	data := []byte("name: k8s\nheuristics:\n  fabrication_phrases:\n    - our kubernetes cluster\n")
+	p, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	child := &Profile{Heuristics: Heuristics{FabricationPhrases: []string{"Our Kubernetes cluster", "the existing terraform"}}}
+	if got := strings.Join(Extend(p, child).Heuristics.FabricationPhrases, ","); got != "our kubernetes cluster,the existing terraform" {
+		t.Errorf("merged fabrication phrases = %s", got)
+	}
+
+	bad := []byte("heuristics:\n  fabrication_phrases:\n    - \"\"\n")
+	if _, err := Parse(bad); err == nil || !strings.Contains(err.Error(), "heuristics.fabrication_phrases[0] is empty") {
+		t.Errorf("Parse err = %v", err)
+	}
+	if ps := Lint(bad); len(ps) != 1 || ps[0].Line != 3 {
+		t.Errorf("Lint = %v", ps)
+	}
+}
+
+func TestStructuredChecks(t *testing.T) {
+	data := []byte(`name: api
+checklists:
+  - id: API
+    title: API policy
+    checks:
+      - "Is every endpoint versioned?"
+      - id: API-02
+        text: "Are error responses documented?"
+        category: UNSPECIFIED_INTERFACE
+        reference: https://example.com/policy/api#errors
+        required: true
+`)
+	p, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checks := p.Checklists[0].Checks
+	if len(checks) != 2 || checks[0] != (Check{Text: "Is every endpoint versioned?"}) || checks[1].ID != "API-02" || !checks[1].Required {
+		t.Fatalf("checks = %+v", checks)
+	}
+	if ps := Lint(data); len(ps) != 0 {
+		t.Errorf("Lint = %v", ps)
+	}
+	out := FormatForPrompt(p)
+	for _, want := range []string{"- Is every endpoint versioned?\n", "- [API-02] Are error responses documented? (required; category UNSPECIFIED_INTERFACE; see https://example.com/policy/api#errors)\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("prompt lacks %q:\n%s", want, out)
+		}
+	}
+	enc, err := yaml.Marshal(p.Checklists[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(enc), "- Is every endpoint versioned?\n") || !strings.Contains(string(enc), "id: API-02\n") {
+		t.Errorf("marshaled checklist:\n%s", enc)
+	}
+
+	child := &Profile{Checklists: []Checklist{{ID: "API", Checks: []Check{{ID: "API-02", Text: "Are all error codes documented?"}, {Text: "is every endpoint versioned?"}}}}}
+	merged := Extend(p, child).Checklists[0].Checks
+	if len(merged) != 2 || merged[1].Text != "Are all error codes documented?" || merged[1].Required {
+		t.Errorf("merged checks = %+v", merged)
+	}
+
+	for _, tc := range []struct{ check, parseErr, want string }{
+		{"{id: A-1, text: x, url: y}", "field url not found", `unknown key "url"`},
+		{"{id: A-1, text: \"\"}", "checks[0] is empty", "checks[0] is empty"},
+		{"{id: C-1, text: x}", `duplicate check id "C-1"`, `duplicate check id "C-1"`},
+		{"{text: x, category: SECURITY}", `unknown category "SECURITY"`, `unknown category "SECURITY"`},
+		{"{text: x, reference: wiki/api}", "want an http or https URL", `reference "wiki/api": want an http or https URL`},
+	} {
+		yml := "checklists:\n  - id: C\n    checks:\n      - {id: C-1, text: y}\n  - id: B\n    checks:\n      - " + tc.check + "\n"
+		if _, err := Parse([]byte(yml)); err == nil || !strings.Contains(err.Error(), tc.parseErr) {
+			t.Errorf("Parse(%q) err = %v, want %q", tc.check, err, tc.parseErr)
+		}
+		if ps := Lint([]byte(yml)); len(ps) != 1 || ps[0].Line != 7 || !strings.Contains(ps[0].Message, tc.want) {
+			t.Errorf("Lint(%q) = %v, want %q", tc.check, ps, tc.want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package profile
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dshills/plancritic/internal/fetch"
+)
+
+// pinPattern matches the content pin at the end of a remote profile
+// name, capturing the hex SHA-256 digest.
+var pinPattern = regexp.MustCompile(`@sha256:([0-9a-fA-F]{64})$`)
+
+// remoteRead fetches a remote profile's content; tests replace it.
+var remoteRead = func(rawURL string) ([]byte, error) {
+	return fetch.Read(rawURL, fetch.Options{})
+}
+
+// IsRemote reports whether a --profile value names a profile by URL,
+// such as https://example.com/payments.yaml@sha256:<hex>.
+func IsRemote(name string) bool {
+	return fetch.IsURL(name)
+}
+
+// ParseRemote splits a remote profile name into its URL and the hex
+// SHA-256 digest its content must have. The URL must be https and the
+// digest is required, so a profile changed on the server, or by
+// anyone between it and plancritic, is refused rather than reviewed
+// with.
+func ParseRemote(name string) (rawURL, digest string, err error) {
+	m := pinPattern.FindStringSubmatchIndex(name)
+	if m == nil {
+		return "", "", fmt.Errorf("remote profile %s: pin its content with @sha256:<hex digest>", name)
+	}
+	rawURL, digest = name[:m[0]], strings.ToLower(name[m[2]:m[3]])
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", fmt.Errorf("remote profile %s: %w", rawURL, err)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return "", "", fmt.Errorf("remote profile %s: want an https URL", rawURL)
+	}
+	return rawURL, digest, nil
+}
+
+// CacheDir returns the directory remote profiles are cached in, by
+// digest, using os.UserCacheDir (which honors XDG_CACHE_HOME on Linux).
+func CacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("profile: user cache dir: %w", err)
+	}
+	return filepath.Join(dir, "plancritic", "profiles"), nil
+}
+
+// LoadRemote loads a profile by pinned URL (see ParseRemote). A copy
+// cached under its digest is used without a request; otherwise the
+// profile is fetched, refused unless its SHA-256 matches the pin, and
+// cached. A profile without a name is named after the URL's file. A
+// remote profile may extend a profile by name, resolved as Load
+// resolves it, but not by path.
+func LoadRemote(name string) (*Profile, error) {
+	rawURL, digest, err := ParseRemote(name)
+	if err != nil {
+		return nil, fmt.Errorf("profile.LoadRemote: %w", err)
+	}
+	data, err := remoteData(rawURL, digest)
+	if err != nil {
+		return nil, fmt.Errorf("profile.LoadRemote: %w", err)
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("profile.LoadRemote: %s: %w", rawURL, err)
+	}
+	if p.Name == "" {
+		u, _ := url.Parse(rawURL)
+		base := path.Base(u.Path)
+		p.Name = strings.TrimSuffix(base, path.Ext(base))
+	}
+	if p.Extends == "" {
+		return p, nil
+	}
+	if IsPath(p.Extends) || IsRemote(p.Extends) {
+		return nil, fmt.Errorf("profile.LoadRemote: %s: extends %q: a remote profile can extend only a profile name", rawURL, p.Extends)
+	}
+	parent, err := loadParent(p.Extends, "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("profile.LoadRemote: %s: extends %q: %w", rawURL, p.Extends, err)
+	}
+	merged := Extend(parent, p)
+	if err := merged.Validate(); err != nil {
+		return nil, fmt.Errorf("profile.LoadRemote: %s: %w", rawURL, err)
+	}
+	return merged, nil
+}
+
+// remoteData returns the content of the profile at rawURL with the
+// given digest, from the cache when it has it. The cache is only an
+// optimization: when it cannot be read or written the profile is
+// fetched, and a cached copy is checked against its digest like a
+// fetched one.
+func remoteData(rawURL, digest string) ([]byte, error) {
+	dir, dirErr := CacheDir()
+	cached := ""
+	if dirErr == nil {
+		cached = filepath.Join(dir, digest+".yaml")
+		if data, err := os.ReadFile(cached); err == nil && sha256Hex(data) == digest {
+			return data, nil
+		} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			cached = ""
+		}
+	}
+	data, err := remoteRead(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if got := sha256Hex(data); got != digest {
+		return nil, fmt.Errorf("remote profile %s: content has sha256:%s, not the pinned sha256:%s; refusing it", rawURL, got, digest)
+	}
+	if cached != "" {
+		if err := os.MkdirAll(dir, 0o755); err == nil {
+			tmp := cached + ".tmp"
+			if os.WriteFile(tmp, data, 0o644) == nil {
+				_ = os.Rename(tmp, cached)
+			}
+		}
+	}
+	return data, nil
+}
+
+// sha256Hex returns the hex SHA-256 digest of data.
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
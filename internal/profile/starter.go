@@ -0,0 +1,83 @@
+package profile
+
+import "fmt"
+
+// Starter returns a commented starter profile named name, for a team
+// to edit into its own: every section with one short example and a
+// comment on what it does. It extends general, so it reviews like the
+// general profile until it is changed, and it passes Lint.
+func Starter(name string) string {
+	return fmt.Sprintf(starterTemplate, name)
+}
+
+const starterTemplate = `# plancritic profile %[1]s.
+#
+# Review with: plancritic check plan.md --profile %[1]s
+# Check edits with: plancritic profiles validate <this file>
+# The built-in profiles are examples: plancritic profiles show go-backend
+
+name: %[1]s
+version: 1
+description: >
+  Review rules for %[1]s plans. Describe what this profile checks; the
+  description is shown by plancritic profiles list.
+
+# Build on a built-in or another profile. Checklists, triggers, and
+# contradiction pairs are added to its own; constraints are merged key
+# by key. Remove this line to start from nothing.
+extends: general
+
+# Constraints tell the model what the team expects of every plan. Each
+# section is a map; rules are plain sentences. Sections the built-in
+# profiles use: api_design, architecture, database, dependencies,
+# error_handling, infrastructure, language, observability, operations,
+# platform, security, testing.
+constraints:
+  dependencies:
+    policy: minimize
+    rules:
+      - Any new dependency must be named in the plan with a reason.
+  database:
+    rules:
+      - Every migration must say how it is rolled back.
+
+# Checklists are questions the model asks of the plan. Each needs a
+# unique id and at least one check. A checklist with the id of one in
+# the extended profile adds its checks to it. A check is its text, or
+# a mapping with an id that review results carry, the category of the
+# finding when it fails, a reference to the policy behind it, and
+# whether a checklist review fails the plan when the check fails.
+checklists:
+  - id: TEAM_RELEASE
+    title: Release process
+    checks:
+      - "Does the plan say who approves the release?"
+      - "Is the change behind a feature flag, or is it said why not?"
+      - id: REL-03
+        text: "Does the plan name the on-call owner for the first week?"
+        category: RISK_OPERATIONS
+        required: true
+
+heuristics:
+  # Pairs of phrases that contradict each other when a plan uses both.
+  # severity is CRITICAL, WARN, or INFO.
+  contradictions:
+    - trigger_a: "no downtime"
+      trigger_b: "maintenance window"
+      severity: WARN
+      note: "The plan promises no downtime but schedules a maintenance window."
+  # Vague phrases the model asks the author to make concrete.
+  ambiguity_triggers:
+    - "as needed"
+    - "ask the platform team"
+  # Phrases that, in a --strict review, mark a finding as claiming
+  # knowledge of your systems the plan does not give.
+  # fabrication_phrases:
+  #   - "our kubernetes cluster"
+
+# Evidence rules: the fewest evidence citations an issue of each
+# severity must have. Uncomment to require two for CRITICAL issues.
+# evidence:
+#   min_citations:
+#     CRITICAL: 2
+`
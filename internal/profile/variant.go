@@ -0,0 +1,156 @@
+package profile
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+)
+
+// Variant derives a profile from a built-in base profile. Variants are
+// generated when loaded rather than kept as YAML copies, so they follow
+// every change to their base.
+type Variant struct {
+	// Suffix is appended to the base name with a hyphen, e.g. "strict"
+	// for go-backend-strict.
+	Suffix string
+	// Description is appended to the base profile's description.
+	Description string
+	// Derive returns the variant of base. It must not modify base.
+	Derive func(base *Profile) *Profile
+}
+
+// Variants lists the variants generated for every built-in profile.
+var Variants = []Variant{
+	{
+		Suffix:      "strict",
+		Description: "Strict variant: unanswered checks are findings, vague phrases are flagged more widely, and contradictions are blocking.",
+		Derive:      Strict,
+	},
+}
+
+// Info describes an available profile and where it comes from.
+type Info struct {
+	Name string `json:"name"`
+	// Description is the profile's description, on one line.
+	Description string `json:"description,omitempty"`
+	Version     int    `json:"version,omitempty"`
+	// Source is where the profile is defined: SourceProject,
+	// SourceUser, or SourceBuiltin.
+	Source string `json:"source"`
+	// Base is the profile a variant was generated from; empty for a
+	// profile defined in YAML.
+	Base string `json:"base,omitempty"`
+	// Variant is the suffix of the Variant that generated the profile.
+	Variant string `json:"variant,omitempty"`
+	// Error is why a profile file could not be loaded; the other fields
+	// but Name and Source are then empty.
+	Error string `json:"error,omitempty"`
+}
+
+// strictChecklist is added to every strict profile.
+var strictChecklist = Checklist{
+	ID:    "STRICT_COMPLETENESS",
+	Title: "Completeness (strict)",
+	Checks: []Check{
+		{Text: "Does every step have a verifiable done condition?"},
+		{Text: "Are placeholders (TBD, TODO, open questions) resolved or called out as blockers?"},
+		{Text: "Does every risky or irreversible step say how it is rolled back?"},
+		{Text: "Are all external systems, owners, and environments the plan depends on named?"},
+	},
+}
+
+// strictTriggers are vague phrases flagged by every strict profile in
+// addition to the base profile's.
+var strictTriggers = []string{
+	"TBD",
+	"TODO",
+	"as needed",
+	"if needed",
+	"where possible",
+	"ideally",
+	"probably",
+	"should be fine",
+	"and so on",
+	"simple",
+}
+
+// strictCheckSuffix is appended to each checklist of a strict profile.
+const strictCheckSuffix = "Does the plan answer every check above explicitly? Treat a check it leaves unaddressed as a gap, not a pass."
+
+// Strict returns the strict variant of base: every checklist gains a
+// check that unanswered items are gaps, a completeness checklist is
+// added, extra vague phrases are flagged, and every contradiction pair
+// is CRITICAL.
+func Strict(base *Profile) *Profile {
+	p := clone(base)
+	for i := range p.Checklists {
+		p.Checklists[i].Checks = append(p.Checklists[i].Checks, Check{Text: strictCheckSuffix})
+	}
+	p.Checklists = append(p.Checklists, strictChecklist)
+	for i := range p.Heuristics.Contradictions {
+		p.Heuristics.Contradictions[i].Severity = "CRITICAL"
+	}
+	seen := map[string]bool{}
+	for _, t := range p.Heuristics.AmbiguityTriggers {
+		seen[strings.ToLower(t)] = true
+	}
+	for _, t := range strictTriggers {
+		if !seen[strings.ToLower(t)] {
+			p.Heuristics.AmbiguityTriggers = append(p.Heuristics.AmbiguityTriggers, t)
+		}
+	}
+	return p
+}
+
+// clone copies the parts of p a variant may change. Constraints are
+// shared.
+func clone(p *Profile) *Profile {
+	c := *p
+	c.Checklists = make([]Checklist, len(p.Checklists))
+	for i, cl := range p.Checklists {
+		cl.Checks = append([]Check(nil), cl.Checks...)
+		c.Checklists[i] = cl
+	}
+	c.Heuristics.Contradictions = append([]Contradiction(nil), p.Heuristics.Contradictions...)
+	c.Heuristics.AmbiguityTriggers = append([]string(nil), p.Heuristics.AmbiguityTriggers...)
+	c.Heuristics.FabricationPhrases = append([]string(nil), p.Heuristics.FabricationPhrases...)
+	c.FocusCategories = append([]string(nil), p.FocusCategories...)
+	c.Categories = append([]Category(nil), p.Categories...)
+	c.DisabledCategories = append([]string(nil), p.DisabledCategories...)
+	c.CategorySeverity = maps.Clone(p.CategorySeverity)
+	return &c
+}
+
+// loadVariant loads name as a variant of a built-in profile, reporting
+// false when name does not end in a variant suffix.
+func loadVariant(name string) (*Profile, bool, error) {
+	v, baseName, ok := splitVariant(name)
+	if !ok {
+		return nil, false, nil
+	}
+	base, err := loadFile(baseName)
+	if err != nil {
+		return nil, true, fmt.Errorf("profile.LoadBuiltin: unknown profile %q: %w", name, err)
+	}
+	return v.derive(name, baseName, base), true, nil
+}
+
+// splitVariant splits a variant name such as go-backend-strict into
+// its Variant and base name, reporting false for any other name.
+func splitVariant(name string) (Variant, string, bool) {
+	for _, v := range Variants {
+		if baseName, ok := strings.CutSuffix(name, "-"+v.Suffix); ok && baseName != "" {
+			return v, baseName, true
+		}
+	}
+	return Variant{}, "", false
+}
+
+// derive returns the variant of base named name.
+func (v Variant) derive(name, baseName string, base *Profile) *Profile {
+	p := v.Derive(base)
+	p.Name = name
+	p.Base = baseName
+	p.Description = strings.TrimSpace(strings.TrimSpace(base.Description) + " " + v.Description)
+	return p
+}
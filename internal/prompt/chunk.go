@@ -0,0 +1,287 @@
+package prompt
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dshills/plancritic/internal/llm"
+	"github.com/dshills/plancritic/internal/plan"
+	"github.com/dshills/plancritic/internal/review"
+)
+
+// Chunk is one part of a plan too large to review in a single prompt.
+// Its lines are reviewed on their own, numbered from L001, and the
+// findings are moved back to the full plan's line numbers with Shift.
+type Chunk struct {
+	// Index is the 1-based position of the chunk among Total chunks.
+	Index int
+	Total int
+	// Start and End are the first and last plan lines the chunk
+	// covers, 1-based and inclusive.
+	Start int
+	End   int
+	// Plan holds the chunk's lines as a plan of its own. It shares the
+	// full plan's path, hash, and front matter.
+	Plan *plan.Plan
+	// StepIDs are the inferred steps in the chunk, renumbered to its
+	// lines; they keep their IDs from the full plan.
+	StepIDs []plan.StepID
+	// Outline lists the steps in the other chunks, so the model knows
+	// what the rest of the plan covers.
+	Outline []plan.StepID
+}
+
+// lineOverhead approximates the tokens the "L0001: " prefix and newline
+// that line numbering adds to each plan line.
+const lineOverhead = 4
+
+// SplitPlan splits the body of p into chunks whose line-numbered text
+// fits in maxTokens estimated tokens (see llm.CountTokens), cutting only
+// at inferred step boundaries so a step is never split across chunks. A
+// step larger than maxTokens on its own is cut at line boundaries.
+// Front matter is not part of any chunk.
+func SplitPlan(p *plan.Plan, steps []plan.StepID, maxTokens int) []Chunk {
+	body := max(p.BodyStart, 1)
+	n := len(p.Lines)
+	if body > n {
+		return nil
+	}
+	// spans are the step-aligned runs of lines a chunk is built from.
+	var cuts []int
+	for _, s := range steps {
+		if s.LineStart > body && s.LineStart <= n {
+			cuts = append(cuts, s.LineStart)
+		}
+	}
+	type span struct{ start, end, size int }
+	var spans []span
+	start := body
+	for _, c := range append(cuts, n+1) {
+		if c <= start {
+			continue
+		}
+		sp := span{start: start, end: c - 1}
+		for i := sp.start; i <= sp.end; i++ {
+			sp.size += llm.CountTokens(p.Lines[i-1]) + lineOverhead
+		}
+		spans = append(spans, sp)
+		start = c
+	}
+
+	var ranges [][2]int
+	cur, size := 0, 0
+	flush := func(end int) {
+		if cur > 0 {
+			ranges = append(ranges, [2]int{cur, end})
+		}
+		cur, size = 0, 0
+	}
+	for _, sp := range spans {
+		if sp.size > maxTokens {
+			flush(sp.start - 1)
+			// Cut the oversized step at line boundaries.
+			for i := sp.start; i <= sp.end; i++ {
+				line := llm.CountTokens(p.Lines[i-1]) + lineOverhead
+				if cur > 0 && size+line > maxTokens {
+					flush(i - 1)
+				}
+				if cur == 0 {
+					cur = i
+				}
+				size += line
+			}
+			flush(sp.end)
+			continue
+		}
+		if cur > 0 && size+sp.size > maxTokens {
+			flush(sp.start - 1)
+		}
+		if cur == 0 {
+			cur = sp.start
+		}
+		size += sp.size
+	}
+	flush(n)
+
+	chunks := make([]Chunk, len(ranges))
+	for i, r := range ranges {
+		lines := p.Lines[r[0]-1 : r[1]]
+		ch := Chunk{
+			Index: i + 1,
+			Total: len(ranges),
+			Start: r[0],
+			End:   r[1],
+			Plan: &plan.Plan{
+				FilePath:  p.FilePath,
+				Raw:       strings.Join(lines, "\n"),
+				Lines:     lines,
+				Hash:      p.Hash,
+				Meta:      p.Meta,
+				BodyStart: 1,
+			},
+		}
+		for _, s := range steps {
+			if s.LineStart < r[0] || s.LineStart > r[1] {
+				ch.Outline = append(ch.Outline, s)
+				continue
+			}
+			s.LineStart -= r[0] - 1
+			s.LineEnd -= r[0] - 1
+			ch.StepIDs = append(ch.StepIDs, s)
+		}
+		chunks[i] = ch
+	}
+	return chunks
+}
+
+// hunkHeader matches the line numbers of a unified diff hunk header.
+var hunkHeader = regexp.MustCompile(`(?m)^@@ -(\d+)(,\d+)? \+(\d+)(,\d+)? @@`)
+
+// Shift moves the plan citations and patch hunks in a review of c from
+// the chunk's line numbers to the full plan's.
+func (c Chunk) Shift(r *review.Review) {
+	off := c.Start - 1
+	if off == 0 {
+		return
+	}
+	shift := func(ev []review.Evidence) {
+		for i := range ev {
+			if ev[i].Source == "plan" {
+				ev[i].LineStart += off
+				ev[i].LineEnd += off
+			}
+		}
+	}
+	for i := range r.Issues {
+		shift(r.Issues[i].Evidence)
+	}
+	for i := range r.Questions {
+		shift(r.Questions[i].Evidence)
+	}
+	for i := range r.Checklists {
+		for j := range r.Checklists[i].Checks {
+			shift(r.Checklists[i].Checks[j].Evidence)
+		}
+	}
+	add := func(s string) string {
+		n, _ := strconv.Atoi(s)
+		return strconv.Itoa(n + off)
+	}
+	for i := range r.Patches {
+		r.Patches[i].DiffUnified = hunkHeader.ReplaceAllStringFunc(r.Patches[i].DiffUnified, func(h string) string {
+			m := hunkHeader.FindStringSubmatch(h)
+			return fmt.Sprintf("@@ -%s%s +%s%s @@", add(m[1]), m[2], add(m[3]), m[4])
+		})
+	}
+}
+
+// MergeChunks combines the reviews of a plan's chunks, already moved
+// to the full plan's line numbers with Shift. Issues and questions
+// reported by more than one chunk are matched by fingerprint: the most
+// severe report is kept (the earliest chunk on ties), with the evidence
+// of every report, and is blocking if any report was. Checklist items
+// fail if any chunk failed them and pass if any passed them. IDs are
+// renumbered in sorted order.
+func MergeChunks(parts []review.Review) review.Review {
+	var out review.Review
+	if len(parts) > 0 {
+		out.Tool, out.Version = parts[0].Tool, parts[0].Version
+	}
+	issueIdx := map[string]int{}
+	questionIdx := map[string]int{}
+	checklistIdx := map[string]int{}
+	for _, part := range parts {
+		for _, iss := range part.Issues {
+			fp := review.Fingerprint(iss)
+			i, ok := issueIdx[fp]
+			if !ok {
+				issueIdx[fp] = len(out.Issues)
+				out.Issues = append(out.Issues, iss)
+				continue
+			}
+			kept := &out.Issues[i]
+			evidence := review.SortEvidence(append(append([]review.Evidence(nil), kept.Evidence...), iss.Evidence...))
+			blocking := kept.Blocking || iss.Blocking
+			if iss.Severity.Order() < kept.Severity.Order() {
+				*kept = iss
+			}
+			kept.Evidence, kept.Blocking = evidence, blocking
+		}
+		for _, q := range part.Questions {
+			fp := review.QuestionFingerprint(q)
+			i, ok := questionIdx[fp]
+			if !ok {
+				questionIdx[fp] = len(out.Questions)
+				out.Questions = append(out.Questions, q)
+				continue
+			}
+			kept := &out.Questions[i]
+			evidence := review.SortEvidence(append(append([]review.Evidence(nil), kept.Evidence...), q.Evidence...))
+			if q.Severity.Order() < kept.Severity.Order() {
+				*kept = q
+			}
+			kept.Evidence = evidence
+		}
+		out.Patches = append(out.Patches, part.Patches...)
+		for _, cl := range part.Checklists {
+			i, ok := checklistIdx[cl.ID]
+			if !ok {
+				checklistIdx[cl.ID] = len(out.Checklists)
+				cl.Checks = append([]review.CheckItem(nil), cl.Checks...)
+				out.Checklists = append(out.Checklists, cl)
+				continue
+			}
+			out.Checklists[i].Checks = mergeChecks(out.Checklists[i].Checks, cl.Checks)
+		}
+	}
+
+	review.NormalizeEvidence(&out)
+	review.SortIssues(out.Issues)
+	review.SortQuestions(out.Questions)
+	for i := range out.Issues {
+		out.Issues[i].ID = fmt.Sprintf("ISSUE-%04d", i+1)
+	}
+	for i := range out.Questions {
+		out.Questions[i].ID = fmt.Sprintf("Q-%04d", i+1)
+	}
+	for i := range out.Patches {
+		out.Patches[i].ID = fmt.Sprintf("PATCH-%04d", i+1)
+	}
+	if out.Issues == nil {
+		out.Issues = []review.Issue{}
+	}
+	if out.Questions == nil {
+		out.Questions = []review.Question{}
+	}
+	return out
+}
+
+// checkRank orders checklist results when chunks disagree: a check any
+// chunk failed fails, else one any chunk passed passes.
+var checkRank = map[review.CheckStatus]int{
+	review.CheckStatusFail: 2,
+	review.CheckStatusPass: 1,
+	review.CheckStatusNA:   0,
+}
+
+// mergeChecks folds the checks of one chunk's checklist into kept.
+func mergeChecks(kept, add []review.CheckItem) []review.CheckItem {
+	idx := make(map[string]int, len(kept))
+	for i, c := range kept {
+		idx[c.Check] = i
+	}
+	for _, c := range add {
+		i, ok := idx[c.Check]
+		if !ok {
+			idx[c.Check] = len(kept)
+			kept = append(kept, c)
+			continue
+		}
+		if checkRank[c.Status] > checkRank[kept[i].Status] {
+			kept[i] = c
+		}
+	}
+	return kept
+}
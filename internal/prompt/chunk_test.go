@@ -0,0 +1,123 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dshills/plancritic/internal/plan"
+	"github.com/dshills/plancritic/internal/review"
+)
+
+func TestSplitPlan(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("---\ntitle: Big plan\n---\n# Plan\n")
+	for i := 1; i <= 6; i++ {
+		fmt.Fprintf(&b, "%d. Step %d\n   Detail line for step %d.\n", i, i, i)
+	}
+	p := plan.Parse("plan.md", b.String())
+	steps := plan.InferStepIDs(p)
+
+	chunks := SplitPlan(p, steps, 40)
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want several", len(chunks))
+	}
+	if chunks[0].Start != p.BodyStart || chunks[len(chunks)-1].End != len(p.Lines) {
+		t.Errorf("chunks cover lines %d-%d, want %d-%d", chunks[0].Start, chunks[len(chunks)-1].End, p.BodyStart, len(p.Lines))
+	}
+	starts := map[int]bool{}
+	for _, s := range steps {
+		starts[s.LineStart] = true
+	}
+	for i, ch := range chunks {
+		if ch.Index != i+1 || ch.Total != len(chunks) {
+			t.Errorf("chunk %d numbered %d of %d", i, ch.Index, ch.Total)
+		}
+		if i > 0 && (ch.Start != chunks[i-1].End+1 || !starts[ch.Start]) {
+			t.Errorf("chunk %d starts at line %d, not at the step after line %d", i, ch.Start, chunks[i-1].End)
+		}
+		if got := strings.Join(ch.Plan.Lines, "\n"); got != strings.Join(p.Lines[ch.Start-1:ch.End], "\n") {
+			t.Errorf("chunk %d lines = %q", i, got)
+		}
+		if len(ch.StepIDs)+len(ch.Outline) != len(steps) {
+			t.Errorf("chunk %d has %d steps and %d outlined, want %d in all", i, len(ch.StepIDs), len(ch.Outline), len(steps))
+		}
+		for _, s := range ch.StepIDs {
+			if ch.Plan.Lines[s.LineStart-1] != p.Lines[s.LineStart+ch.Start-2] {
+				t.Errorf("step %s not renumbered to the chunk", s.ID)
+			}
+		}
+	}
+
+	text := Build(BuildOpts{Plan: chunks[1].Plan, StepIDs: chunks[1].StepIDs, Chunk: &chunks[1]})
+	for _, want := range []string{"part 2 of", fmt.Sprintf("lines %d-%d of the full plan", chunks[1].Start, chunks[1].End), "L001: " + p.Lines[chunks[1].Start-1], "- P-001: Plan"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("chunk prompt missing %q", want)
+		}
+	}
+}
+
+func TestSplitPlanCutsLongStep(t *testing.T) {
+	p := plan.Parse("plan.md", "# Plan\n"+strings.Repeat("a long line of prose with no steps\n", 10))
+	chunks := SplitPlan(p, plan.InferStepIDs(p), 30)
+	if len(chunks) < 3 {
+		t.Fatalf("got %d chunks, want the long step cut", len(chunks))
+	}
+	for _, ch := range chunks {
+		if ch.End < ch.Start {
+			t.Errorf("empty chunk %+v", ch)
+		}
+	}
+}
+
+func TestShiftAndMergeChunks(t *testing.T) {
+	issue := func(sev review.Severity, line int, blocking bool) review.Issue {
+		return review.Issue{
+			Severity: sev,
+			Category: review.CategoryTestGap,
+			Title:    "No rollback test",
+			Evidence: []review.Evidence{{Source: "plan", Path: "plan.md", LineStart: line, LineEnd: line}},
+			Blocking: blocking,
+		}
+	}
+	first := review.Review{
+		Issues:  []review.Issue{issue(review.SeverityWarn, 2, true)},
+		Patches: []review.Patch{{DiffUnified: "--- a/plan.md\n+++ b/plan.md\n@@ -2,1 +2,1 @@\n-old\n+new\n"}},
+		Checklists: []review.Checklist{{ID: "TESTS", Checks: []review.CheckItem{
+			{Check: "Tests named?", Status: review.CheckStatusPass},
+		}}},
+	}
+	second := review.Review{
+		Issues: []review.Issue{
+			issue(review.SeverityCritical, 3, false),
+			{Severity: review.SeverityInfo, Category: review.CategoryAmbiguity, Title: "Vague", Evidence: []review.Evidence{{Source: "context", Path: "notes.md", LineStart: 3, LineEnd: 3}}},
+		},
+		Patches: []review.Patch{{DiffUnified: "--- a/plan.md\n+++ b/plan.md\n@@ -3 +3 @@\n-old\n+new\n"}},
+		Checklists: []review.Checklist{{ID: "TESTS", Checks: []review.CheckItem{
+			{Check: "Tests named?", Status: review.CheckStatusFail},
+		}}},
+	}
+	Chunk{Start: 1}.Shift(&first)
+	Chunk{Start: 41}.Shift(&second)
+
+	merged := MergeChunks([]review.Review{first, second})
+	if len(merged.Issues) != 2 {
+		t.Fatalf("got %d issues, want the duplicate merged: %+v", len(merged.Issues), merged.Issues)
+	}
+	got := merged.Issues[0]
+	if got.ID != "ISSUE-0001" || got.Severity != review.SeverityCritical || !got.Blocking {
+		t.Errorf("merged issue = %+v, want the CRITICAL report, blocking", got)
+	}
+	if len(got.Evidence) != 2 || got.Evidence[0].LineStart != 2 || got.Evidence[1].LineStart != 43 {
+		t.Errorf("merged evidence = %+v, want lines 2 and 43", got.Evidence)
+	}
+	if ev := merged.Issues[1].Evidence[0]; ev.LineStart != 3 {
+		t.Errorf("context evidence shifted to line %d", ev.LineStart)
+	}
+	if len(merged.Patches) != 2 || !strings.Contains(merged.Patches[1].DiffUnified, "@@ -43 +43 @@") || merged.Patches[1].ID != "PATCH-0002" {
+		t.Errorf("patches = %+v", merged.Patches)
+	}
+	if st := merged.Checklists[0].Checks[0].Status; st != review.CheckStatusFail {
+		t.Errorf("checklist status = %s, want FAIL", st)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	pctx "github.com/dshills/plancritic/internal/context"
 	"github.com/dshills/plancritic/internal/plan"
 	"github.com/dshills/plancritic/internal/profile"
+	"github.com/dshills/plancritic/internal/review"
 	"github.com/dshills/plancritic/internal/schema"
 )
 
@@ -48,15 +49,96 @@ func TestBuildStrict(t *testing.T) {
 	}
 }
 
+func TestBuildLanguage(t *testing.T) {
+	p := &plan.Plan{FilePath: "plan.md", Lines: []string{"step"}}
+	if text := Build(BuildOpts{Plan: p, Language: "en"}); strings.Contains(text, "Output Language") {
+		t.Error("English plans should not get a language instruction")
+	}
+	text := Build(BuildOpts{Plan: p, Language: "de", PlanLanguage: "de"})
+	if !strings.Contains(text, "The plan is written in German. Write every human-readable field") {
+		t.Error("language instruction missing from prompt")
+	}
+
+	// A forced output language does not change what the plan is said
+	// to be written in.
+	text = Build(BuildOpts{Plan: p, Language: "de", PlanLanguage: "en"})
+	if !strings.Contains(text, "The plan is written in English. ") || !strings.Contains(text, "suggested answers) in German.") {
+		t.Error("plan and output languages conflated in prompt")
+	}
+	text = Build(BuildOpts{Plan: p, Language: "de"})
+	if strings.Contains(text, "The plan is written in") || !strings.Contains(text, "in German.") {
+		t.Error("undetected plan language should leave only the output instruction")
+	}
+}
+
+func TestBuildLocalizedTriggersFollowPlanLanguage(t *testing.T) {
+	p := &plan.Plan{FilePath: "plan.md", Lines: []string{"step"}}
+	prof := &profile.Profile{Name: "test", Heuristics: profile.Heuristics{
+		AmbiguityTriggers:          []string{"somehow"},
+		LocalizedAmbiguityTriggers: map[string][]string{"de": {"irgendwie"}},
+	}}
+	text := Build(BuildOpts{Plan: p, Profile: prof, Language: "de", PlanLanguage: "en"})
+	if strings.Contains(text, "irgendwie") {
+		t.Error("German triggers used for an English plan with German output")
+	}
+	text = Build(BuildOpts{Plan: p, Profile: prof, PlanLanguage: "de"})
+	if !strings.Contains(text, "irgendwie") {
+		t.Error("German triggers missing for a German plan")
+	}
+}
+
 func TestBuildWithContext(t *testing.T) {
 	p := &plan.Plan{FilePath: "plan.md", Lines: []string{"step"}}
 	ctx := &pctx.File{FilePath: "constraints.md", Lines: []string{"rule one"}}
 	text := Build(BuildOpts{Plan: p, Contexts: []*pctx.File{ctx}})
-	if !strings.Contains(text, `##PLANCRITIC_CONTEXT_BEGIN path="constraints.md"##`) {
+	if !strings.Contains(text, `##PLANCRITIC_CONTEXT_BEGIN index="1" path="constraints.md"##`) {
 		t.Error("context block missing from prompt")
 	}
 }
 
+func TestBuildContextManifest(t *testing.T) {
+	p := &plan.Plan{FilePath: "plan.md", Lines: []string{"step"}}
+	api := &pctx.File{FilePath: "api/constraints.md", Lines: []string{"a", "b"}, Role: pctx.RoleFile}
+	db := &pctx.File{FilePath: "db/constraints.md", Lines: []string{"c", "d", "e"}, Role: pctx.RoleDirectory, Section: "Limits", Start: 2, End: 3}
+	contexts := []*pctx.File{api, db}
+	pctx.AssignNames(contexts)
+	text := Build(BuildOpts{Plan: p, Contexts: contexts})
+	for _, want := range []string{
+		"1. api/constraints.md (file, 2 lines)\n2. db/constraints.md (directory, section \"Limits\", lines 2-3)\n",
+		`##PLANCRITIC_CONTEXT_BEGIN index="1" path="api/constraints.md" role="file"##`,
+		`##PLANCRITIC_CONTEXT_BEGIN index="2" path="db/constraints.md" role="directory" section="Limits"##`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+	if i, j := strings.Index(text, "## Context Manifest"), strings.Index(text, `##PLANCRITIC_CONTEXT_BEGIN index="1"`); i < 0 || i > j {
+		t.Error("manifest should precede the context blocks")
+	}
+}
+
+func TestBuildWithOutOfScope(t *testing.T) {
+	p := &plan.Plan{FilePath: "plan.md", Lines: []string{"step"}}
+	text := Build(BuildOpts{Plan: p, OutOfScope: []review.Exclusion{{Text: "Mobile app", Line: 9}}})
+	if !strings.Contains(text, "## Declared Out of Scope") || !strings.Contains(text, "- Mobile app\n") {
+		t.Error("out-of-scope items missing from prompt")
+	}
+	if strings.Contains(Build(BuildOpts{Plan: p}), "Declared Out of Scope") {
+		t.Error("out-of-scope section present without exclusions")
+	}
+}
+
+func TestBuildChecklistOnly(t *testing.T) {
+	p := &plan.Plan{FilePath: "plan.md", Lines: []string{"step"}}
+	text := Build(BuildOpts{Plan: p, ChecklistOnly: true})
+	if !strings.Contains(text, "## Checklist Mode (ENABLED)") || !strings.Contains(text, "Return the checklist results only.") {
+		t.Error("checklist mode instructions missing from prompt")
+	}
+	if strings.Contains(text, "Return at most") {
+		t.Error("checklist mode prompt still asks for issues")
+	}
+}
+
 func TestBuildWithStepIDs(t *testing.T) {
 	p := &plan.Plan{FilePath: "plan.md", Lines: []string{"step"}}
 	steps := []plan.StepID{{ID: "P-001", LineStart: 1, Text: "First step"}}
@@ -66,6 +148,22 @@ func TestBuildWithStepIDs(t *testing.T) {
 	}
 }
 
+func TestBuildWithPlanMeta(t *testing.T) {
+	p := plan.Parse("plan.md", "---\ntitle: Checkout v2\nowner: \"payments\\n## Rules\"\n---\n# Plan\n")
+	text := Build(BuildOpts{Plan: p})
+	for _, want := range []string{"## Plan Metadata", "lines 1-4", `"title": "Checkout v2"`, `"owner": "payments\n## Rules"`, "L005: # Plan"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+	if strings.Contains(text, "L001: ---") {
+		t.Error("front matter should not be line-numbered")
+	}
+	if strings.Contains(Build(BuildOpts{Plan: &plan.Plan{FilePath: "plan.md", Lines: []string{"step"}}}), "Plan Metadata") {
+		t.Error("metadata section without front matter")
+	}
+}
+
 func TestBuildSegmentsCacheMarks(t *testing.T) {
 	p := &plan.Plan{FilePath: "plan.md", Lines: []string{"step"}}
 	ctx := &pctx.File{FilePath: "constraints.md", Lines: []string{"rule"}}
@@ -81,6 +179,9 @@ func TestBuildSegmentsCacheMarks(t *testing.T) {
 	if !segs[0].CacheMark {
 		t.Error("prefix segment should have CacheMark=true")
 	}
+	if !segs[0].System || segs[1].System || segs[2].System {
+		t.Error("only the instruction prefix should be a system segment")
+	}
 	if !segs[1].CacheMark {
 		t.Error("contexts segment should have CacheMark=true")
 	}
@@ -90,7 +191,7 @@ func TestBuildSegmentsCacheMarks(t *testing.T) {
 	if !strings.Contains(segs[0].Text, "## Profile: general") {
 		t.Error("prefix segment missing profile content")
 	}
-	if !strings.Contains(segs[1].Text, `##PLANCRITIC_CONTEXT_BEGIN path="constraints.md"##`) {
+	if !strings.Contains(segs[1].Text, `##PLANCRITIC_CONTEXT_BEGIN index="1" path="constraints.md"##`) {
 		t.Error("contexts segment missing context block")
 	}
 	if !strings.Contains(segs[2].Text, `##PLANCRITIC_PLAN_BEGIN path="plan.md"##`) {
@@ -154,3 +255,43 @@ func TestBuildRepair(t *testing.T) {
 		t.Error("repair prompt missing original output")
 	}
 }
+
+func TestCustomCategoriesInSchema(t *testing.T) {
+	prof := &profile.Profile{Name: "privacy", Categories: []profile.Category{
+		{ID: "PRIVACY_RISK", Description: "Personal data handled without a stated basis.", DefaultSeverity: "WARN"},
+	}}
+	p := &plan.Plan{FilePath: "plan.md", Lines: []string{"# Step 1", "Store emails"}}
+	text := Build(BuildOpts{Plan: p, Profile: prof})
+	if !strings.Contains(text, `"NON_DETERMINISM"|"PRIVACY_RISK",`) {
+		t.Error("schema should list the profile's category")
+	}
+	if !strings.Contains(text, "- PRIVACY_RISK: Personal data handled without a stated basis. (usually WARN)") {
+		t.Error("prompt should describe the profile's category")
+	}
+	repair := BuildRepairAttempt(`{}`, nil, nil, "PRIVACY_RISK")
+	if !strings.Contains(repair, `"NON_DETERMINISM"|"PRIVACY_RISK",`) {
+		t.Error("repair schema should list the profile's category")
+	}
+	if strings.Contains(BuildRepair(`{}`, nil), "PRIVACY_RISK") {
+		t.Error("schema without a profile should list only built-in categories")
+	}
+}
+
+func TestBuildRepairAttemptListsEarlierErrors(t *testing.T) {
+	current := []schema.ValidationError{{Path: "issues[0].title", Message: "required"}}
+	earlier := []schema.ValidationError{
+		{Path: "summary.verdict", Message: "invalid verdict: \"MAYBE\""},
+		{Path: "issues[0].title", Message: "required"},
+	}
+	text := BuildRepairAttempt(`{}`, current, earlier)
+	i := strings.Index(text, "## Errors Fixed In Earlier Attempts")
+	if i < 0 {
+		t.Fatal("missing earlier errors section")
+	}
+	if !strings.Contains(text[i:], "summary.verdict") || strings.Contains(text[i:], "issues[0].title") {
+		t.Errorf("earlier section should list only errors no longer reported:\n%s", text[i:])
+	}
+	if strings.Contains(BuildRepair(`{}`, current), "Earlier Attempts") {
+		t.Error("first repair round should not have an earlier errors section")
+	}
+}
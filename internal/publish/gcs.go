@@ -0,0 +1,54 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/dshills/plancritic/internal/review"
+)
+
+func init() { Register(&GCS{Client: &http.Client{Timeout: 2 * time.Minute}}) }
+
+// GCS uploads the review JSON and Markdown report to a Google Cloud
+// Storage bucket through the XML API.
+//
+// Options:
+//
+//	url       gs://bucket/prefix/ (required)
+//	endpoint  API endpoint (default https://storage.googleapis.com)
+//
+// The OAuth access token is read from GOOGLE_OAUTH_ACCESS_TOKEN (e.g.
+// the output of `gcloud auth print-access-token`).
+type GCS struct {
+	Client *http.Client
+}
+
+func (g *GCS) Name() string { return "gcs" }
+
+func (g *GCS) Publish(ctx context.Context, r *review.Review, opts PublishOpts) error {
+	token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+	if !opts.DryRun && token == "" {
+		return fmt.Errorf("gcs: GOOGLE_OAUTH_ACCESS_TOKEN must be set")
+	}
+	endpoint := opts.Option("endpoint", "https://storage.googleapis.com")
+
+	put := func(ctx context.Context, bucket string, obj object, meta map[string]string) error {
+		objURL := endpoint + "/" + bucket + (&url.URL{Path: "/" + obj.Key}).EscapedPath()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, objURL, bytes.NewReader(obj.Body))
+		if err != nil {
+			return fmt.Errorf("gcs: create request: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", obj.ContentType)
+		for k, v := range meta {
+			req.Header.Set("X-Goog-Meta-"+k, v)
+		}
+		return doRequest(g.Client, req, "gcs")
+	}
+	return uploadArtifacts(ctx, "gcs", "gs", r, opts, put)
+}
@@ -0,0 +1,105 @@
+package publish
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/dshills/plancritic/internal/render"
+	"github.com/dshills/plancritic/internal/review"
+)
+
+// object is one file to store in a bucket.
+type object struct {
+	Key         string
+	Body        []byte
+	ContentType string
+}
+
+// objectPutter uploads a single object with metadata to a bucket.
+type objectPutter func(ctx context.Context, bucket string, obj object, meta map[string]string) error
+
+// artifactObjects builds the review JSON and Markdown report under
+// content-addressed keys: <prefix><sha256 of the JSON>.json and .md.
+// Re-uploading an identical review therefore overwrites itself rather
+// than accumulating copies, and a key identifies its exact content.
+func artifactObjects(r *review.Review, prefix string) ([]object, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal review: %w", err)
+	}
+	data = append(data, '\n')
+	sum := sha256.Sum256(data)
+	base := prefix + hex.EncodeToString(sum[:])
+	return []object{
+		{Key: base + ".json", Body: data, ContentType: "application/json"},
+		{Key: base + ".md", Body: []byte(render.Markdown(r)), ContentType: "text/markdown; charset=utf-8"},
+	}, nil
+}
+
+// artifactMetadata returns the object metadata tags stored alongside
+// each artifact.
+func artifactMetadata(r *review.Review) map[string]string {
+	meta := map[string]string{
+		"verdict":   string(r.Summary.Verdict),
+		"score":     strconv.Itoa(r.Summary.Score),
+		"plan-hash": r.Input.PlanHash,
+		"plan-file": r.Input.PlanFile,
+		"model":     r.Meta.Model,
+		"version":   r.Version,
+	}
+	for k, v := range meta {
+		if v == "" {
+			delete(meta, k)
+		}
+	}
+	return meta
+}
+
+// parseBucketURL splits "s3://bucket/prefix/" into bucket and key
+// prefix. A non-empty prefix always ends in "/".
+func parseBucketURL(raw, scheme string) (bucket, prefix string, err error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != scheme || u.Host == "" {
+		return "", "", fmt.Errorf("invalid %s URL %q: want %s://bucket/prefix/", scheme, raw, scheme)
+	}
+	prefix = strings.TrimPrefix(u.Path, "/")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return u.Host, prefix, nil
+}
+
+// uploadArtifacts is the shared Publish body for object-store targets.
+func uploadArtifacts(ctx context.Context, name, scheme string, r *review.Review, opts PublishOpts, put objectPutter) error {
+	raw, err := opts.Require(name, "url")
+	if err != nil {
+		return err
+	}
+	bucket, prefix, err := parseBucketURL(raw, scheme)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	objs, err := artifactObjects(r, prefix)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	meta := artifactMetadata(r)
+	for _, obj := range objs {
+		dest := scheme + "://" + bucket + "/" + obj.Key
+		if opts.DryRun {
+			opts.Logf("would upload %d bytes to %s", len(obj.Body), dest)
+			continue
+		}
+		if err := put(ctx, bucket, obj, meta); err != nil {
+			return err
+		}
+		opts.Logf("%s: uploaded %s", name, dest)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+// Package publish defines the interface shared by every destination a
+// review can be sent to (webhooks, chat, issue trackers, object
+// storage) together with a registry and the common retry and dry-run
+// handling, so individual targets only implement the delivery itself.
+package publish
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dshills/plancritic/internal/review"
+)
+
+// PublishOpts carries the settings common to all publishers plus
+// target-specific key/value options (e.g. "url" for the webhook).
+type PublishOpts struct {
+	// DryRun asks the publisher to describe what it would send on Log
+	// without contacting the target.
+	DryRun bool
+	// Retries is the number of additional attempts made after a
+	// retryable failure.
+	Retries int
+	// Options holds target-specific settings.
+	Options map[string]string
+	// Log receives progress and dry-run output. May be nil.
+	Log io.Writer
+}
+
+// Option returns a target-specific option, or fallback when unset.
+func (o PublishOpts) Option(key, fallback string) string {
+	if v, ok := o.Options[key]; ok && v != "" {
+		return v
+	}
+	return fallback
+}
+
+// Require returns a target-specific option or an error naming the
+// publisher and missing key.
+func (o PublishOpts) Require(publisher, key string) (string, error) {
+	v := o.Option(key, "")
+	if v == "" {
+		return "", fmt.Errorf("%s: missing required option %q", publisher, key)
+	}
+	return v, nil
+}
+
+// Logf writes a progress line to o.Log when one is configured.
+func (o PublishOpts) Logf(format string, args ...any) {
+	if o.Log != nil {
+		fmt.Fprintf(o.Log, format+"\n", args...)
+	}
+}
+
+// Publisher sends a review to one destination. Implementations honor
+// opts.DryRun by logging what would be sent and returning nil, and wrap
+// transient failures with Retryable so Publish can retry them.
+type Publisher interface {
+	Name() string
+	Publish(ctx context.Context, r *review.Review, opts PublishOpts) error
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Publisher)
+)
+
+// Register makes a publisher available by name. It panics on a
+// duplicate name, which is a programming error.
+func Register(p Publisher) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	name := strings.ToLower(p.Name())
+	if _, dup := registry[name]; dup {
+		panic("publish: duplicate publisher " + name)
+	}
+	registry[name] = p
+}
+
+// Lookup returns the publisher registered under name.
+func Lookup(name string) (Publisher, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	p, ok := registry[strings.ToLower(name)]
+	return p, ok
+}
+
+// Names returns the registered publisher names, sorted.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// retryableError marks an error as transient.
+type retryableError struct{ err error }
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// Retryable marks err as transient (network failure, 429, 5xx).
+func Retryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &retryableError{err: err}
+}
+
+// IsRetryable reports whether err was marked with Retryable.
+func IsRetryable(err error) bool {
+	var re *retryableError
+	return errors.As(err, &re)
+}
+
+// doRequest executes req and classifies the response: network errors,
+// 429 and 5xx are retryable; any other non-2xx status is permanent.
+func doRequest(client *http.Client, req *http.Request, name string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return Retryable(fmt.Errorf("%s: request failed: %w", name, err))
+	}
+	defer func() { _ = resp.Body.Close() }()
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return Retryable(fmt.Errorf("%s: %s %s returned %d: %s", name, req.Method, req.URL.Redacted(), resp.StatusCode, snippet))
+	default:
+		return fmt.Errorf("%s: %s %s returned %d: %s", name, req.Method, req.URL.Redacted(), resp.StatusCode, snippet)
+	}
+}
+
+// retryDelay is the base backoff between attempts; tests shorten it.
+var retryDelay = time.Second
+
+// Publish sends r through p, retrying retryable failures up to
+// opts.Retries times with exponential backoff.
+func Publish(ctx context.Context, p Publisher, r *review.Review, opts PublishOpts) error {
+	if opts.DryRun {
+		opts.Logf("[dry-run] %s", p.Name())
+	}
+	delay := retryDelay
+	for attempt := 0; ; attempt++ {
+		err := p.Publish(ctx, r, opts)
+		if err == nil || opts.DryRun || !IsRetryable(err) || attempt >= opts.Retries {
+			return err
+		}
+		opts.Logf("%s: attempt %d failed, retrying in %s: %v", p.Name(), attempt+1, delay, err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
@@ -0,0 +1,165 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dshills/plancritic/internal/review"
+)
+
+type flakyPublisher struct {
+	failures int
+	calls    int
+	err      error
+}
+
+func (f *flakyPublisher) Name() string { return "flaky" }
+
+func (f *flakyPublisher) Publish(ctx context.Context, r *review.Review, opts PublishOpts) error {
+	f.calls++
+	if f.calls <= f.failures {
+		return f.err
+	}
+	return nil
+}
+
+func TestPublishRetriesRetryable(t *testing.T) {
+	retryDelay = 0
+	p := &flakyPublisher{failures: 2, err: Retryable(errors.New("503"))}
+	if err := Publish(context.Background(), p, &review.Review{}, PublishOpts{Retries: 2}); err != nil {
+		t.Fatalf("expected success after retries, got %v", err)
+	}
+	if p.calls != 3 {
+		t.Errorf("expected 3 calls, got %d", p.calls)
+	}
+}
+
+func TestPublishStopsOnPermanentError(t *testing.T) {
+	retryDelay = 0
+	p := &flakyPublisher{failures: 5, err: errors.New("400")}
+	if err := Publish(context.Background(), p, &review.Review{}, PublishOpts{Retries: 3}); err == nil {
+		t.Fatal("expected error")
+	}
+	if p.calls != 1 {
+		t.Errorf("permanent errors should not be retried, got %d calls", p.calls)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	p, ok := Lookup("WEBHOOK")
+	if !ok || p.Name() != "webhook" {
+		t.Fatal("webhook publisher not registered")
+	}
+	if _, ok := Lookup("nope"); ok {
+		t.Error("unexpected publisher")
+	}
+}
+
+func TestWebhookPublish(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			t.Errorf("missing header, got %q", r.Header.Get("Authorization"))
+		}
+		var got review.Review
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil || got.Summary.Score != 80 {
+			t.Errorf("unexpected body: %+v, %v", got, err)
+		}
+	}))
+	defer srv.Close()
+
+	retryDelay = 0
+	wh := &Webhook{Client: srv.Client()}
+	opts := PublishOpts{Retries: 1, Options: map[string]string{"url": srv.URL, "header": "Authorization: Bearer tok"}}
+	if err := Publish(context.Background(), wh, &review.Review{Summary: review.Summary{Score: 80}}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if hits.Load() != 2 {
+		t.Errorf("expected 2 requests, got %d", hits.Load())
+	}
+}
+
+func TestWebhookDryRun(t *testing.T) {
+	var log bytes.Buffer
+	wh := &Webhook{Client: &http.Client{}}
+	opts := PublishOpts{DryRun: true, Log: &log, Options: map[string]string{"url": "http://127.0.0.1:1/never"}}
+	if err := Publish(context.Background(), wh, &review.Review{}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(log.String(), "would POST") {
+		t.Errorf("dry run output missing: %q", log.String())
+	}
+}
+
+func TestWebhookRequiresURL(t *testing.T) {
+	err := (&Webhook{Client: &http.Client{}}).Publish(context.Background(), &review.Review{}, PublishOpts{})
+	if err == nil || !strings.Contains(err.Error(), `"url"`) {
+		t.Errorf("expected missing url error, got %v", err)
+	}
+}
+
+func TestS3Upload(t *testing.T) {
+	var keys []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("unexpected method %s", r.Method)
+		}
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+			t.Error("request not signed")
+		}
+		if r.Header.Get("X-Amz-Meta-Verdict") != string(review.VerdictNotExecutable) {
+			t.Errorf("missing verdict metadata, got %q", r.Header.Get("X-Amz-Meta-Verdict"))
+		}
+		keys = append(keys, r.URL.Path)
+	}))
+	defer srv.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	rev := &review.Review{Summary: review.Summary{Verdict: review.VerdictNotExecutable, Score: 40}}
+	opts := PublishOpts{Options: map[string]string{"url": "s3://audit/plans/2026", "endpoint": srv.URL}}
+	if err := (&S3{Client: srv.Client()}).Publish(context.Background(), rev, opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 uploads, got %v", keys)
+	}
+	if !strings.HasPrefix(keys[0], "/audit/plans/2026/") || !strings.HasSuffix(keys[0], ".json") || !strings.HasSuffix(keys[1], ".md") {
+		t.Errorf("unexpected keys: %v", keys)
+	}
+	if strings.TrimSuffix(keys[0], ".json") != strings.TrimSuffix(keys[1], ".md") {
+		t.Errorf("JSON and report should share a content address: %v", keys)
+	}
+}
+
+func TestGCSDryRun(t *testing.T) {
+	var log bytes.Buffer
+	opts := PublishOpts{DryRun: true, Log: &log, Options: map[string]string{"url": "gs://audit/"}}
+	if err := (&GCS{Client: &http.Client{}}).Publish(context.Background(), &review.Review{}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(log.String(), "would upload") != 2 || !strings.Contains(log.String(), "gs://audit/") {
+		t.Errorf("unexpected dry-run output: %q", log.String())
+	}
+}
+
+func TestParseBucketURL(t *testing.T) {
+	bucket, prefix, err := parseBucketURL("s3://b/a/c", "s3")
+	if err != nil || bucket != "b" || prefix != "a/c/" {
+		t.Errorf("got %q %q %v", bucket, prefix, err)
+	}
+	if _, _, err := parseBucketURL("gs://b/", "s3"); err == nil {
+		t.Error("expected scheme mismatch error")
+	}
+}
@@ -0,0 +1,64 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dshills/plancritic/internal/awssig"
+	"github.com/dshills/plancritic/internal/review"
+)
+
+func init() { Register(&S3{Client: &http.Client{Timeout: 2 * time.Minute}}) }
+
+// S3 uploads the review JSON and Markdown report to an S3 bucket (or
+// any S3-compatible store) using SigV4-signed PUT requests.
+//
+// Options:
+//
+//	url       s3://bucket/prefix/ (required)
+//	region    bucket region (default $AWS_REGION, then us-east-1)
+//	endpoint  custom endpoint for S3-compatible stores, addressed
+//	          path-style (default $AWS_ENDPOINT_URL_S3 or $AWS_ENDPOINT_URL)
+//
+// Credentials come from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// optionally AWS_SESSION_TOKEN.
+type S3 struct {
+	Client *http.Client
+	now    func() time.Time
+}
+
+func (s *S3) Name() string { return "s3" }
+
+func (s *S3) Publish(ctx context.Context, r *review.Review, opts PublishOpts) error {
+	region := opts.Option("region", awssig.EnvRegion())
+	endpoint := opts.Option("endpoint", awssig.EnvEndpoint())
+	creds := awssig.EnvCredentials()
+	if !opts.DryRun && !creds.Valid() {
+		return fmt.Errorf("s3: AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+	}
+
+	put := func(ctx context.Context, bucket string, obj object, meta map[string]string) error {
+		objURL, err := awssig.ObjectURL(endpoint, region, bucket, obj.Key)
+		if err != nil {
+			return fmt.Errorf("s3: %w", err)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, objURL, bytes.NewReader(obj.Body))
+		if err != nil {
+			return fmt.Errorf("s3: create request: %w", err)
+		}
+		req.Header.Set("Content-Type", obj.ContentType)
+		for k, v := range meta {
+			req.Header.Set("X-Amz-Meta-"+k, v)
+		}
+		now := time.Now
+		if s.now != nil {
+			now = s.now
+		}
+		awssig.Sign(req, obj.Body, creds, region, now().UTC())
+		return doRequest(s.Client, req, "s3")
+	}
+	return uploadArtifacts(ctx, "s3", "s3", r, opts, put)
+}
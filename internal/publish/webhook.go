@@ -0,0 +1,67 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dshills/plancritic/internal/review"
+)
+
+func init() { Register(&Webhook{Client: &http.Client{Timeout: 30 * time.Second}}) }
+
+// Webhook POSTs the review JSON to an arbitrary URL.
+//
+// Options:
+//
+//	url     target URL (required)
+//	header  optional "Name: value" header, e.g. for an auth token
+type Webhook struct {
+	Client *http.Client
+}
+
+func (w *Webhook) Name() string { return "webhook" }
+
+func (w *Webhook) Publish(ctx context.Context, r *review.Review, opts PublishOpts) error {
+	url, err := opts.Require("webhook", "url")
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("webhook: marshal review: %w", err)
+	}
+	if opts.DryRun {
+		opts.Logf("would POST %d bytes to %s", len(body), url)
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook: create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h := opts.Option("header", ""); h != "" {
+		name, value, ok := cutHeader(h)
+		if !ok {
+			return fmt.Errorf("webhook: header option must be \"Name: value\", got %q", h)
+		}
+		req.Header.Set(name, value)
+	}
+
+	if err := doRequest(w.Client, req, "webhook"); err != nil {
+		return err
+	}
+	opts.Logf("webhook: posted review to %s", url)
+	return nil
+}
+
+func cutHeader(h string) (name, value string, ok bool) {
+	name, value, ok = strings.Cut(h, ":")
+	name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+	return name, value, ok && name != ""
+}
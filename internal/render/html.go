@@ -0,0 +1,348 @@
+package render
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/dshills/plancritic/internal/review"
+)
+
+// HTML renders a review as a self-contained HTML page: the findings on
+// one side and the plan on the other, with the plan lines each finding
+// cites highlighted and a marker in the margin that jumps to the
+// finding. Clicking a finding scrolls the plan to its evidence. The
+// page needs no server and loads nothing from the network.
+//
+// planText is the plan the review was run on. When it is empty the
+// plan embedded with --embed-inputs is used; without either the page
+// lists the findings alone.
+func HTML(r *review.Review, planText string, t Theme) (string, error) {
+	if planText == "" {
+		planText = embeddedPlan(r)
+	}
+	page := htmlPage{
+		Review:     r,
+		PlanFile:   r.Input.PlanFile,
+		Lines:      planLines(planText),
+		Critical:   t.Tag(review.SeverityCritical),
+		Warn:       t.Tag(review.SeverityWarn),
+		Info:       t.Tag(review.SeverityInfo),
+		Incomplete: r.Status == review.StatusIncomplete,
+	}
+	for _, sev := range []review.Severity{review.SeverityCritical, review.SeverityWarn, review.SeverityInfo} {
+		page.Colors = append(page.Colors, htmlColor{Class: severityClass(sev), Color: cssColor(t.Color(sev))})
+	}
+	issueLinks, questionLinks := review.Permalinks(r)
+	for i, iss := range r.Issues {
+		page.Findings = append(page.Findings, page.finding(iss.ID, iss.Severity, iss.Title, iss.Evidence, t, htmlFinding{
+			Kind:           "issue",
+			Anchor:         issueLinks[i],
+			Category:       string(iss.Category),
+			Description:    iss.Description,
+			Impact:         iss.Impact,
+			Recommendation: iss.Recommendation,
+		}))
+	}
+	for i, q := range r.Questions {
+		page.Findings = append(page.Findings, page.finding(q.ID, q.Severity, q.Question, q.Evidence, t, htmlFinding{
+			Kind:        "question",
+			Anchor:      questionLinks[i],
+			Category:    "QUESTION",
+			Description: q.WhyNeeded,
+			Answers:     q.SuggestedAnswers,
+		}))
+	}
+
+	var b strings.Builder
+	if err := htmlTemplate.Execute(&b, page); err != nil {
+		return "", fmt.Errorf("render.HTML: %w", err)
+	}
+	return b.String(), nil
+}
+
+type htmlPage struct {
+	Review     *review.Review
+	PlanFile   string
+	Lines      []htmlLine
+	Findings   []htmlFinding
+	Colors     []htmlColor
+	Critical   string
+	Warn       string
+	Info       string
+	Incomplete bool
+}
+
+// htmlLine is one plan line. Severity is the class of the most severe
+// finding citing it; Markers link to the findings whose evidence
+// starts on it.
+type htmlLine struct {
+	N        int
+	Text     string
+	Severity string
+	Markers  []htmlMarker
+}
+
+type htmlMarker struct {
+	Anchor   string
+	Severity string
+	Title    string
+}
+
+type htmlFinding struct {
+	Kind           string
+	Anchor         string
+	ID             string
+	Severity       string
+	Tag            string
+	Category       string
+	Title          string
+	Description    string
+	Impact         string
+	Recommendation string
+	Answers        []string
+	Evidence       []htmlEvidence
+	// Ranges lists the cited plan lines as "start-end" pairs for the
+	// page script.
+	Ranges string
+}
+
+type htmlEvidence struct {
+	Location string
+	Quote    string
+	InPlan   bool
+	Line     int
+}
+
+type htmlColor struct {
+	Class string
+	Color template.CSS
+}
+
+// finding builds the view of one issue or question and marks the plan
+// lines its evidence cites. f.Anchor is the finding's permalink.
+func (p *htmlPage) finding(id string, sev review.Severity, title string, evidence []review.Evidence, t Theme, f htmlFinding) htmlFinding {
+	f.ID = id
+	f.Severity = severityClass(sev)
+	f.Tag = t.Tag(sev)
+	f.Title = title
+	var ranges []string
+	for _, ev := range evidence {
+		e := htmlEvidence{Quote: ev.Quote, Location: evidenceLocation(p.Review.Input, ev)}
+		start, end, ok := p.clamp(ev)
+		if ok {
+			e.InPlan = true
+			e.Line = start
+			ranges = append(ranges, fmt.Sprintf("%d-%d", start, end))
+			for n := start; n <= end; n++ {
+				line := &p.Lines[n-1]
+				if line.Severity == "" || sev.Order() < severityOrder(line.Severity) {
+					line.Severity = f.Severity
+				}
+			}
+			line := &p.Lines[start-1]
+			if !hasMarker(line.Markers, f.Anchor) {
+				line.Markers = append(line.Markers, htmlMarker{Anchor: f.Anchor, Severity: f.Severity, Title: title})
+			}
+		}
+		f.Evidence = append(f.Evidence, e)
+	}
+	f.Ranges = strings.Join(ranges, ",")
+	return f
+}
+
+// clamp returns the plan lines ev cites, limited to the plan, or false
+// when it cites a context file or another plan of a joint review, or
+// lies outside the plan.
+func (p *htmlPage) clamp(ev review.Evidence) (int, int, bool) {
+	if ev.Source != "" && ev.Source != "plan" || p.Review.Input.CitesOtherPlan(ev) {
+		return 0, 0, false
+	}
+	start, end := ev.LineStart, ev.LineEnd
+	if end < start {
+		end = start
+	}
+	if start < 1 || start > len(p.Lines) {
+		return 0, 0, false
+	}
+	return start, min(end, len(p.Lines)), true
+}
+
+func hasMarker(markers []htmlMarker, anchor string) bool {
+	for _, m := range markers {
+		if m.Anchor == anchor {
+			return true
+		}
+	}
+	return false
+}
+
+// evidenceLocation names the lines ev cites, with the file's path for
+// a context file or for any plan of a joint review.
+func evidenceLocation(in review.Input, ev review.Evidence) string {
+	loc := fmt.Sprintf("L%d-%d", ev.LineStart, ev.LineEnd)
+	named := ev.Source != "" && ev.Source != "plan" || len(in.Plans) > 0
+	if named && ev.Path != "" {
+		loc = ev.Path + " " + loc
+	}
+	return loc
+}
+
+func severityClass(sev review.Severity) string {
+	switch sev {
+	case review.SeverityCritical:
+		return "critical"
+	case review.SeverityWarn:
+		return "warn"
+	default:
+		return "info"
+	}
+}
+
+func severityOrder(class string) int {
+	switch class {
+	case "critical":
+		return review.SeverityCritical.Order()
+	case "warn":
+		return review.SeverityWarn.Order()
+	default:
+		return review.SeverityInfo.Order()
+	}
+}
+
+// cssColor returns c for use in the page's stylesheet, or "" when it is
+// not a color NewTheme would accept.
+func cssColor(c string) template.CSS {
+	if !colorPattern.MatchString(c) {
+		return ""
+	}
+	return template.CSS(c)
+}
+
+func planLines(text string) []htmlLine {
+	if text == "" {
+		return nil
+	}
+	raw := strings.Split(strings.TrimSuffix(strings.ReplaceAll(text, "\r\n", "\n"), "\n"), "\n")
+	lines := make([]htmlLine, len(raw))
+	for i, l := range raw {
+		lines[i] = htmlLine{N: i + 1, Text: l}
+	}
+	return lines
+}
+
+// embeddedPlan returns the plan stored in r with --embed-inputs, or "".
+func embeddedPlan(r *review.Review) string {
+	for _, e := range r.Embedded {
+		if e.Role != review.EmbedRolePlan {
+			continue
+		}
+		text, err := e.Content()
+		if err != nil {
+			return ""
+		}
+		return text
+	}
+	return ""
+}
+
+var htmlTemplate = template.Must(template.New("review").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>PlanCritic Review{{if .PlanFile}}: {{.PlanFile}}{{end}}</title>
+<style>
+:root { --critical: #c62828; --warn: #ef6c00; --info: #1565c0; }
+{{range .Colors}}{{if .Color}}:root { --{{.Class}}: {{.Color}}; }
+{{end}}{{end}}* { box-sizing: border-box; }
+body { margin: 0; font: 14px/1.5 system-ui, sans-serif; color: #222; background: #fafafa; }
+header { padding: 12px 20px; border-bottom: 1px solid #ddd; background: #fff; }
+header h1 { margin: 0 0 4px; font-size: 18px; }
+.banner { margin: 8px 0 0; padding: 6px 10px; background: #fff3e0; border-left: 4px solid var(--warn); }
+main { display: flex; height: calc(100vh - 90px); }
+#findings { flex: 1 1 45%; overflow-y: auto; padding: 12px 20px; }
+#plan { flex: 1 1 55%; overflow: auto; border-left: 1px solid #ddd; background: #fff; font: 13px/1.6 ui-monospace, monospace; }
+.finding { margin: 0 0 12px; padding: 10px 12px; background: #fff; border: 1px solid #ddd; border-left: 4px solid var(--info); border-radius: 4px; cursor: pointer; }
+.finding.critical { border-left-color: var(--critical); }
+.finding.warn { border-left-color: var(--warn); }
+.finding.active { box-shadow: 0 0 0 2px #90caf9; }
+.finding h2 { margin: 0 0 6px; font-size: 15px; }
+.badge { display: inline-block; padding: 0 6px; margin-right: 6px; border-radius: 3px; color: #fff; font-size: 12px; background: var(--info); }
+.badge.critical { background: var(--critical); }
+.badge.warn { background: var(--warn); }
+.finding p { margin: 4px 0; }
+blockquote { margin: 4px 0; padding: 2px 8px; border-left: 3px solid #ccc; color: #555; }
+blockquote a { color: inherit; }
+.line { display: flex; white-space: pre-wrap; }
+.line .gutter { flex: 0 0 28px; text-align: center; }
+.line .n { flex: 0 0 44px; padding-right: 8px; text-align: right; color: #999; user-select: none; }
+.line .text { flex: 1; padding: 0 8px; }
+.line.critical .text { background: rgba(198, 40, 40, 0.12); }
+.line.warn .text { background: rgba(239, 108, 0, 0.12); }
+.line.info .text { background: rgba(21, 101, 192, 0.10); }
+.line.active .text { outline: 2px solid #90caf9; outline-offset: -1px; }
+.marker { display: inline-block; width: 10px; height: 10px; margin-top: 5px; border-radius: 50%; background: var(--info); }
+.marker.critical { background: var(--critical); }
+.marker.warn { background: var(--warn); }
+.empty { padding: 12px 20px; color: #777; }
+</style>
+</head>
+<body>
+<header>
+<h1>PlanCritic Review{{if .PlanFile}}: {{.PlanFile}}{{end}}</h1>
+<div><strong>Verdict:</strong> {{.Review.Summary.Verdict}}{{with .Review.Summary.Grounded}}; grounded-only verdict: {{.Verdict}}{{end}} &middot; <strong>Score:</strong> {{.Review.Summary.Score}} / 100 &middot; {{.Review.Summary.CriticalCount}} {{.Critical}}, {{.Review.Summary.WarnCount}} {{.Warn}}, {{.Review.Summary.InfoCount}} {{.Info}}</div>
+{{if .Incomplete}}<div class="banner"><strong>INCOMPLETE:</strong> the run budget expired before every model call finished. Only findings that validated in time are listed.</div>{{end}}
+</header>
+<main>
+<section id="findings">
+{{range .Findings}}<article class="finding {{.Severity}}" id="{{.Anchor}}" data-ranges="{{.Ranges}}">
+<h2><span class="badge {{.Severity}}">{{.Tag}}</span>{{.Title}}</h2>
+<div><small>{{.ID}} &middot; <a href="#{{.Anchor}}">{{.Anchor}}</a> &middot; {{.Category}}</small></div>
+{{if .Description}}<p>{{.Description}}</p>{{end}}
+{{range .Evidence}}<blockquote>{{if .InPlan}}<a href="#L{{.Line}}">{{.Location}}</a>{{else}}{{.Location}}{{end}}: {{.Quote}}</blockquote>
+{{end}}{{if .Impact}}<p><strong>Impact:</strong> {{.Impact}}</p>{{end}}
+{{if .Recommendation}}<p><strong>Recommendation:</strong> {{.Recommendation}}</p>{{end}}
+{{if .Answers}}<p><strong>Suggested answers:</strong></p><ul>{{range .Answers}}<li>{{.}}</li>{{end}}</ul>{{end}}
+</article>
+{{else}}<p class="empty">No issues found.</p>
+{{end}}</section>
+{{if .Lines}}<section id="plan">
+{{range .Lines}}<div class="line{{if .Severity}} {{.Severity}}{{end}}" id="L{{.N}}"><span class="gutter">{{range .Markers}}<a class="marker {{.Severity}}" href="#{{.Anchor}}" title="{{.Title}}"></a>{{end}}</span><span class="n">{{.N}}</span><span class="text">{{.Text}}</span></div>
+{{end}}</section>{{end}}
+</main>
+<script>
+(function () {
+  function select(card) {
+    document.querySelectorAll(".active").forEach(function (el) { el.classList.remove("active"); });
+    card.classList.add("active");
+    var first = null;
+    (card.dataset.ranges || "").split(",").forEach(function (r) {
+      if (!r) { return; }
+      var parts = r.split("-");
+      for (var n = +parts[0]; n <= +parts[1]; n++) {
+        var line = document.getElementById("L" + n);
+        if (!line) { continue; }
+        line.classList.add("active");
+        first = first || line;
+      }
+    });
+    if (first) { first.scrollIntoView({ block: "center", behavior: "smooth" }); }
+  }
+  document.querySelectorAll(".finding").forEach(function (card) {
+    card.addEventListener("click", function () { select(card); });
+  });
+  document.querySelectorAll(".marker").forEach(function (m) {
+    m.addEventListener("click", function (e) {
+      e.preventDefault();
+      var card = document.getElementById(m.getAttribute("href").slice(1));
+      if (!card) { return; }
+      card.scrollIntoView({ block: "start", behavior: "smooth" });
+      select(card);
+    });
+  });
+})();
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,72 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dshills/plancritic/internal/review"
+)
+
+func TestHTML(t *testing.T) {
+	r := sampleReview()
+	r.Input.PlanFile = "plan.md"
+	r.Issues[1].Description = "<script>alert(1)</script>"
+	var plan strings.Builder
+	for i := 1; i <= 25; i++ {
+		fmt.Fprintf(&plan, "line %d\n", i)
+	}
+
+	out, err := HTML(r, plan.String(), Theme{Colors: map[review.Severity]string{review.SeverityCritical: "#800000"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<title>PlanCritic Review: plan.md</title>`,
+		`--critical: #800000;`,
+		`<div class="line critical" id="L5">`,
+		`<div class="line critical" id="L7">`,
+		`<div class="line warn" id="L10">`,
+		`<div class="line" id="L8">`,
+		fmt.Sprintf(`<a class="marker critical" href="#%s" title="Dependency contradiction">`, review.Permalink(review.Fingerprint(r.Issues[0]))),
+		`data-ranges="5-7"`,
+		`<a href="#L5">L5-7</a>`,
+		`&lt;script&gt;alert(1)&lt;/script&gt;`,
+		`PostgreSQL`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %s", want)
+		}
+	}
+	// ISSUE-0003 cites line 30 of a 25-line plan: listed, not linked.
+	if !strings.Contains(out, `<blockquote>L30-30: test it</blockquote>`) {
+		t.Error("out-of-range evidence should be listed without a link")
+	}
+	if strings.Contains(out, "<script>alert") {
+		t.Error("finding text not escaped")
+	}
+}
+
+func TestHTMLEmbeddedPlan(t *testing.T) {
+	r := sampleReview()
+	out, err := HTML(r, "", DefaultTheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, `id="plan"`) {
+		t.Error("plan pane rendered without plan text")
+	}
+
+	e, err := review.EmbedFile(review.EmbedRolePlan, "plan.md", strings.Repeat("step\n", 8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Embedded = []review.EmbeddedFile{e}
+	out, err = HTML(r, "", DefaultTheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `<div class="line critical" id="L5">`) {
+		t.Error("embedded plan not used")
+	}
+}
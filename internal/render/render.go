@@ -1,4 +1,4 @@
-// Package render produces Markdown output from a review.
+// Package render produces Markdown and HTML output from a review.
 package render
 
 import (
@@ -10,53 +10,84 @@ import (
 
 // Markdown renders a review as a Markdown report.
 func Markdown(r *review.Review) string {
+	return MarkdownTheme(r, DefaultTheme)
+}
+
+// MarkdownTheme renders a review as a Markdown report using theme t
+// for severity labels and icons.
+func MarkdownTheme(r *review.Review, t Theme) string {
 	var b strings.Builder
 
 	// Summary
 	b.WriteString("# PlanCritic Review\n\n")
-	fmt.Fprintf(&b, "**Verdict:** %s\n", r.Summary.Verdict)
+	if r.Status == review.StatusIncomplete {
+		b.WriteString("> **INCOMPLETE:** the run budget expired before every model call finished. Only findings that validated in time are listed.\n\n")
+	}
+	if r.Meta.SuspiciouslyEmpty {
+		b.WriteString("> **SUSPICIOUSLY EMPTY:** the model reported fewer findings than expected for this plan, even when asked again. Treat a clean result with caution.\n\n")
+	}
+	if g := r.Summary.Grounded; g != nil {
+		fmt.Fprintf(&b, "**Verdict:** %s; grounded-only verdict: %s\n", r.Summary.Verdict, g.Verdict)
+	} else {
+		fmt.Fprintf(&b, "**Verdict:** %s\n", r.Summary.Verdict)
+	}
 	fmt.Fprintf(&b, "**Score:** %d / 100\n", r.Summary.Score)
-	fmt.Fprintf(&b, "**Issues:** %d critical, %d warnings, %d info\n\n",
-		r.Summary.CriticalCount, r.Summary.WarnCount, r.Summary.InfoCount)
-
-	// Issues by severity
-	criticals := filterIssues(r.Issues, review.SeverityCritical)
-	warns := filterIssues(r.Issues, review.SeverityWarn)
-	infos := filterIssues(r.Issues, review.SeverityInfo)
-
-	if len(criticals) > 0 {
-		b.WriteString("## Critical Issues\n\n")
-		for _, iss := range criticals {
-			renderIssue(&b, iss)
+	if len(t.Labels) == 0 && len(t.Icons) == 0 {
+		fmt.Fprintf(&b, "**Issues:** %d critical, %d warnings, %d info\n\n",
+			r.Summary.CriticalCount, r.Summary.WarnCount, r.Summary.InfoCount)
+	} else {
+		fmt.Fprintf(&b, "**Issues:** %d %s, %d %s, %d %s\n\n",
+			r.Summary.CriticalCount, t.Tag(review.SeverityCritical),
+			r.Summary.WarnCount, t.Tag(review.SeverityWarn),
+			r.Summary.InfoCount, t.Tag(review.SeverityInfo))
+	}
+	if m := r.Summary.PlanMetrics; m != nil {
+		fmt.Fprintf(&b, "**Plan:** %d lines, %d steps (avg %.1f words), estimates %.0f%%, acceptance criteria %.0f%%, %d context references\n\n",
+			m.LineCount, m.StepCount, m.AvgStepWords, m.EstimateCoverage, m.AcceptanceCoverage, m.ContextReferences)
+		if m.StepsWithoutAcceptance > 0 || m.VaguePhrases > 0 || m.Placeholders > 0 {
+			fmt.Fprintf(&b, "**Plan quality:** %d steps without acceptance criteria, %d vague phrases (%.1f per 100 words), %d TODO/TBD markers\n\n",
+				m.StepsWithoutAcceptance, m.VaguePhrases, m.VagueDensity, m.Placeholders)
 		}
 	}
 
-	if len(warns) > 0 {
-		b.WriteString("## Warnings\n\n")
-		for _, iss := range warns {
-			renderIssue(&b, iss)
+	if len(r.Input.Plans) > 0 {
+		docs := []string{r.Input.PlanFile}
+		for _, p := range r.Input.Plans {
+			docs = append(docs, p.Path)
 		}
+		fmt.Fprintf(&b, "**Joint review of:** %s\n\n", strings.Join(docs, ", "))
+	}
+	if g := r.Input.Git; g != nil {
+		fmt.Fprintf(&b, "**Plan revision:** %s\n\n", gitRevision(g))
 	}
 
-	if len(infos) > 0 {
-		b.WriteString("## Info\n\n")
-		for _, iss := range infos {
-			renderIssue(&b, iss)
+	// Issues by severity. Each finding is anchored at its permalink.
+	issueLinks, questionLinks := review.Permalinks(r)
+	for _, sev := range []review.Severity{review.SeverityCritical, review.SeverityWarn, review.SeverityInfo} {
+		issues := filterIssues(r.Issues, sev)
+		if len(issues) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "## %s\n\n", t.sectionTitle(sev))
+		for _, i := range issues {
+			renderIssue(&b, r.Input, r.Issues[i], issueLinks[i], t)
 		}
 	}
 
-	if len(r.Issues) == 0 {
+	if len(r.Issues) == 0 && r.Summary.FailedChecks == 0 {
 		b.WriteString("No issues found.\n\n")
 	}
 
 	// Questions
 	if len(r.Questions) > 0 {
 		b.WriteString("## Questions\n\n")
-		for _, q := range r.Questions {
-			fmt.Fprintf(&b, "### %s [%s]\n\n", q.Question, q.Severity)
+		for i, q := range r.Questions {
+			renderAnchor(&b, questionLinks[i])
+			fmt.Fprintf(&b, "### %s [%s]\n\n", q.Question, t.Tag(q.Severity))
 			fmt.Fprintf(&b, "%s\n\n", q.WhyNeeded)
+			renderAgreement(&b, q.Agreement)
 			for _, ev := range q.Evidence {
-				fmt.Fprintf(&b, "> %s (L%d-%d)\n", ev.Quote, ev.LineStart, ev.LineEnd)
+				renderEvidence(&b, r.Input, ev)
 			}
 			if len(q.SuggestedAnswers) > 0 {
 				b.WriteString("\n**Suggested answers:**\n")
@@ -68,6 +99,36 @@ func Markdown(r *review.Review) string {
 		}
 	}
 
+	// Checklists
+	if len(r.Checklists) > 0 {
+		b.WriteString("## Checklists\n\n")
+		for _, cl := range r.Checklists {
+			fmt.Fprintf(&b, "### %s (%s)\n\n", cl.Title, cl.ID)
+			for _, c := range cl.Checks {
+				fmt.Fprintf(&b, "- **%s** ", c.Status)
+				switch {
+				case c.ID != "" && c.Reference != "":
+					fmt.Fprintf(&b, "[%s](%s) ", c.ID, c.Reference)
+				case c.ID != "":
+					fmt.Fprintf(&b, "%s ", c.ID)
+				}
+				b.WriteString(c.Check)
+				if c.Justification != "" {
+					fmt.Fprintf(&b, " — %s", c.Justification)
+				}
+				var locs []string
+				for _, ev := range c.Evidence {
+					locs = append(locs, evidenceLocation(r.Input, ev))
+				}
+				if len(locs) > 0 {
+					fmt.Fprintf(&b, " (%s)", strings.Join(locs, ", "))
+				}
+				b.WriteString("\n")
+			}
+			b.WriteString("\n")
+		}
+	}
+
 	// Patches
 	if len(r.Patches) > 0 {
 		b.WriteString("## Suggested Patches\n\n")
@@ -83,31 +144,105 @@ func Markdown(r *review.Review) string {
 	if len(r.Input.ContextFiles) > 0 {
 		b.WriteString("## Context Used\n\n")
 		for _, cf := range r.Input.ContextFiles {
-			fmt.Fprintf(&b, "- %s\n", cf.Path)
+			if cf.Section != "" {
+				fmt.Fprintf(&b, "- %s (section: %s)\n", cf.Path, cf.Section)
+			} else {
+				fmt.Fprintf(&b, "- %s\n", cf.Path)
+			}
 		}
 		b.WriteString("\n")
 	}
 
+	// Suppressed findings
+	if len(r.Suppressed) > 0 {
+		b.WriteString("## Suppressed\n\n")
+		for _, s := range r.Suppressed {
+			fmt.Fprintf(&b, "- `%s` %s [%s]", s.Fingerprint, s.Title, t.Tag(s.Severity))
+			if s.Until != "" {
+				fmt.Fprintf(&b, " — snoozed until %s", s.Until)
+			}
+			if s.Reason != "" {
+				fmt.Fprintf(&b, ": %s", s.Reason)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	// Human sign-off
+	if r.Signoff != nil {
+		b.WriteString("## Sign-off\n\n")
+		fmt.Fprintf(&b, "**Decision:** %s\n", r.Signoff.Decision)
+		fmt.Fprintf(&b, "**Reviewer:** %s\n", r.Signoff.Reviewer)
+		fmt.Fprintf(&b, "**Date:** %s\n", r.Signoff.Timestamp.Format("2006-01-02 15:04 MST"))
+		fmt.Fprintf(&b, "**Artifact hash:** `%s`\n\n", r.Signoff.ArtifactHash)
+		if r.Signoff.Notes != "" {
+			fmt.Fprintf(&b, "%s\n\n", r.Signoff.Notes)
+		}
+	}
+
 	return b.String()
 }
 
-func filterIssues(issues []review.Issue, sev review.Severity) []review.Issue {
-	var result []review.Issue
-	for _, iss := range issues {
+// filterIssues returns the indexes of the issues with severity sev.
+func filterIssues(issues []review.Issue, sev review.Severity) []int {
+	var result []int
+	for i, iss := range issues {
 		if iss.Severity == sev {
-			result = append(result, iss)
+			result = append(result, i)
 		}
 	}
 	return result
 }
 
-func renderIssue(b *strings.Builder, iss review.Issue) {
-	fmt.Fprintf(b, "### %s [%s / %s]\n\n", iss.Title, iss.Severity, iss.Category)
+// renderAnchor writes the HTML anchor that lets links to a finding's
+// permalink land on its heading.
+func renderAnchor(b *strings.Builder, link string) {
+	fmt.Fprintf(b, "<a id=\"%s\"></a>\n\n", link)
+}
+
+func renderAgreement(b *strings.Builder, a *review.Agreement) {
+	if a == nil {
+		return
+	}
+	fmt.Fprintf(b, "**Agreement:** %d/%d (%s)\n\n", a.Count, a.Total, strings.Join(a.Models, ", "))
+}
+
+// renderEvidence writes ev as a quote. The plan document is named in a
+// joint review, where line numbers alone do not say which one is meant.
+func renderEvidence(b *strings.Builder, in review.Input, ev review.Evidence) {
+	loc := fmt.Sprintf("L%d-%d", ev.LineStart, ev.LineEnd)
+	if len(in.Plans) > 0 {
+		loc = evidenceLocation(in, ev)
+	}
+	fmt.Fprintf(b, "> %s (%s)\n", ev.Quote, loc)
+}
+
+func renderIssue(b *strings.Builder, in review.Input, iss review.Issue, link string, t Theme) {
+	renderAnchor(b, link)
+	fmt.Fprintf(b, "### %s [%s / %s]\n\n", iss.Title, t.Tag(iss.Severity), iss.Category)
 	fmt.Fprintf(b, "%s\n\n", iss.Description)
+	renderAgreement(b, iss.Agreement)
 	for _, ev := range iss.Evidence {
-		fmt.Fprintf(b, "> %s (L%d-%d)\n", ev.Quote, ev.LineStart, ev.LineEnd)
+		renderEvidence(b, in, ev)
 	}
 	b.WriteString("\n")
 	fmt.Fprintf(b, "**Impact:** %s\n\n", iss.Impact)
 	fmt.Fprintf(b, "**Recommendation:** %s\n\n", iss.Recommendation)
 }
+
+// gitRevision describes the git revision a plan was reviewed at, as
+// "`1a2b3c4d5e6f` on main (uncommitted changes)".
+func gitRevision(g *review.GitProvenance) string {
+	s := "no commit"
+	if g.Commit != "" {
+		s = "`" + g.Commit[:min(12, len(g.Commit))] + "`"
+	}
+	if g.Branch != "" {
+		s += " on " + g.Branch
+	}
+	if g.Dirty {
+		s += " (uncommitted changes)"
+	}
+	return s
+}
@@ -3,6 +3,7 @@ package render
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/dshills/plancritic/internal/review"
 )
@@ -95,6 +96,17 @@ func TestMarkdown(t *testing.T) {
 	}
 }
 
+func TestMarkdownPermalinks(t *testing.T) {
+	r := sampleReview()
+	md := Markdown(r)
+	issues, questions := review.Permalinks(r)
+	for _, link := range append(issues, questions...) {
+		if !strings.Contains(md, `<a id="`+link+`"></a>`) {
+			t.Errorf("markdown missing anchor for %s", link)
+		}
+	}
+}
+
 func TestMarkdownEmpty(t *testing.T) {
 	r := &review.Review{
 		Summary: review.Summary{Verdict: review.VerdictExecutable, Score: 100},
@@ -104,3 +116,104 @@ func TestMarkdownEmpty(t *testing.T) {
 		t.Error("expected 'No issues found' for empty review")
 	}
 }
+
+func TestMarkdownSignoff(t *testing.T) {
+	r := sampleReview()
+	if strings.Contains(Markdown(r), "## Sign-off") {
+		t.Error("unexpected sign-off section for unsigned review")
+	}
+	r.Signoff = &review.Signoff{
+		Reviewer:     "alice",
+		Decision:     review.SignoffApproved,
+		Notes:        "Ship it.",
+		Timestamp:    time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		ArtifactHash: "sha256:abc",
+	}
+	md := Markdown(r)
+	for _, want := range []string{"## Sign-off", "**Decision:** APPROVED", "**Reviewer:** alice", "Ship it.", "sha256:abc"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q", want)
+		}
+	}
+}
+
+func TestMarkdownPlanMetrics(t *testing.T) {
+	r := sampleReview()
+	r.Summary.PlanMetrics = &review.PlanMetrics{LineCount: 40, StepCount: 6, AvgStepWords: 12.5, EstimateCoverage: 50, AcceptanceCoverage: 33.3, ContextReferences: 2}
+	md := Markdown(r)
+	if !strings.Contains(md, "**Plan:** 40 lines, 6 steps (avg 12.5 words), estimates 50%, acceptance criteria 33%, 2 context references") {
+		t.Errorf("markdown missing plan metrics line:\n%s", md)
+	}
+	if strings.Contains(md, "**Plan quality:**") {
+		t.Errorf("markdown has a quality line with nothing to report:\n%s", md)
+	}
+	r.Summary.PlanMetrics.StepsWithoutAcceptance, r.Summary.PlanMetrics.VaguePhrases, r.Summary.PlanMetrics.VagueDensity = 4, 3, 1.5
+	md = Markdown(r)
+	if !strings.Contains(md, "**Plan quality:** 4 steps without acceptance criteria, 3 vague phrases (1.5 per 100 words), 0 TODO/TBD markers") {
+		t.Errorf("markdown missing plan quality line:\n%s", md)
+	}
+}
+
+func TestMarkdownTheme(t *testing.T) {
+	theme, err := NewTheme(
+		map[string]string{"CRITICAL": "Blocker", "warn": "Major"},
+		map[string]string{"critical": "🛑"},
+		nil,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := MarkdownTheme(sampleReview(), theme)
+	for _, want := range []string{"## 🛑 Blocker", "[🛑 Blocker / ", "## Major", "1 🛑 Blocker"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("themed markdown missing %q", want)
+		}
+	}
+	if strings.Contains(md, "## Critical Issues") {
+		t.Error("default heading should be replaced by the theme label")
+	}
+	if Markdown(sampleReview()) != MarkdownTheme(sampleReview(), Theme{}) {
+		t.Error("zero theme should render like the default")
+	}
+}
+
+func TestNewThemeValidation(t *testing.T) {
+	if _, err := NewTheme(map[string]string{"blocker": "x"}, nil, nil); err == nil {
+		t.Error("expected error for unknown severity")
+	}
+	if _, err := NewTheme(nil, nil, map[string]string{"warn": "red;display:none"}); err == nil {
+		t.Error("expected error for unsafe color")
+	}
+}
+
+func TestMarkdownGroundedVerdict(t *testing.T) {
+	r := sampleReview()
+	r.Summary.Verdict = review.VerdictNotExecutable
+	r.Summary.Grounded = &review.Summary{Verdict: review.VerdictWithClarifications}
+	md := Markdown(r)
+	if !strings.Contains(md, "**Verdict:** NOT_EXECUTABLE; grounded-only verdict: EXECUTABLE_WITH_CLARIFICATIONS\n") {
+		t.Errorf("markdown missing grounded-only verdict:\n%s", md)
+	}
+}
+
+func TestMarkdownChecklists(t *testing.T) {
+	r := &review.Review{
+		Summary: review.Summary{Verdict: review.VerdictWithClarifications, Score: 93, FailedChecks: 1},
+		Checklists: []review.Checklist{{ID: "CONTRACTS", Title: "Contracts", Checks: []review.CheckItem{
+			{Check: "Measurable acceptance criteria?", Status: review.CheckStatusFail, Justification: "None given.",
+				Evidence: []review.Evidence{{Source: "plan", LineStart: 3, LineEnd: 4}}},
+			{Check: "Interfaces specified?", Status: review.CheckStatusNA},
+			{Check: "Errors documented?", Status: review.CheckStatusPass, ID: "API-2", Reference: "https://example.com/api#errors"},
+			{Check: "Versioned?", Status: review.CheckStatusPass, ID: "API-3"},
+		}}},
+	}
+	md := Markdown(r)
+	for _, want := range []string{"## Checklists\n\n### Contracts (CONTRACTS)\n", "- **FAIL** Measurable acceptance criteria? — None given. (L3-4)\n", "- **N/A** Interfaces specified?\n", "- **PASS** [API-2](https://example.com/api#errors) Errors documented?\n", "- **PASS** API-3 Versioned?\n"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+	if strings.Contains(md, "No issues found") {
+		t.Error("a review with a failed check reported no issues")
+	}
+}
@@ -0,0 +1,110 @@
+package render
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dshills/plancritic/internal/review"
+)
+
+// Theme customizes how severities are presented: the label shown in
+// place of CRITICAL/WARN/INFO, an optional icon (e.g. an emoji), and a
+// color for renderers that support one. Unset entries fall back to the
+// defaults, so the zero Theme renders exactly like DefaultTheme.
+type Theme struct {
+	Labels map[review.Severity]string
+	Icons  map[review.Severity]string
+	Colors map[review.Severity]string
+}
+
+// DefaultTheme is the built-in presentation.
+var DefaultTheme = Theme{}
+
+// colorPattern accepts CSS hex colors and plain color names, which are
+// safe to interpolate into a style attribute.
+var colorPattern = regexp.MustCompile(`^(#[0-9a-fA-F]{3}|#[0-9a-fA-F]{6}|[a-zA-Z]+)$`)
+
+// NewTheme builds a Theme from maps keyed by severity name
+// (case-insensitive "critical", "warn", "info"), as found in config
+// files. Unknown severities and invalid colors are errors.
+func NewTheme(labels, icons, colors map[string]string) (Theme, error) {
+	var t Theme
+	var err error
+	if t.Labels, err = severityMap("label", labels); err != nil {
+		return Theme{}, err
+	}
+	if t.Icons, err = severityMap("icon", icons); err != nil {
+		return Theme{}, err
+	}
+	if t.Colors, err = severityMap("color", colors); err != nil {
+		return Theme{}, err
+	}
+	for sev, c := range t.Colors {
+		if !colorPattern.MatchString(c) {
+			return Theme{}, fmt.Errorf("theme: invalid color %q for %s: want #rgb, #rrggbb, or a color name", c, sev)
+		}
+	}
+	return t, nil
+}
+
+func severityMap(kind string, in map[string]string) (map[review.Severity]string, error) {
+	if len(in) == 0 {
+		return nil, nil
+	}
+	out := make(map[review.Severity]string, len(in))
+	for k, v := range in {
+		sev := review.Severity(strings.ToUpper(strings.TrimSpace(k)))
+		if !sev.Valid() {
+			return nil, fmt.Errorf("theme: unknown severity %q in %s map (valid: critical, warn, info)", k, kind)
+		}
+		out[sev] = strings.TrimSpace(v)
+	}
+	return out, nil
+}
+
+// Label returns the display label for sev.
+func (t Theme) Label(sev review.Severity) string {
+	if l := t.Labels[sev]; l != "" {
+		return l
+	}
+	return string(sev)
+}
+
+// Icon returns the icon for sev, or "" when none is configured.
+func (t Theme) Icon(sev review.Severity) string {
+	return t.Icons[sev]
+}
+
+// Color returns the configured color for sev, or "".
+func (t Theme) Color(sev review.Severity) string {
+	return t.Colors[sev]
+}
+
+// Tag returns the label prefixed with the icon, if any.
+func (t Theme) Tag(sev review.Severity) string {
+	if icon := t.Icon(sev); icon != "" {
+		return icon + " " + t.Label(sev)
+	}
+	return t.Label(sev)
+}
+
+// sectionTitle returns the Markdown heading for a severity group. The
+// default headings are kept unless the label has been customized.
+func (t Theme) sectionTitle(sev review.Severity) string {
+	title := t.Labels[sev]
+	if title == "" {
+		switch sev {
+		case review.SeverityCritical:
+			title = "Critical Issues"
+		case review.SeverityWarn:
+			title = "Warnings"
+		default:
+			title = "Info"
+		}
+	}
+	if icon := t.Icon(sev); icon != "" {
+		return icon + " " + title
+	}
+	return title
+}
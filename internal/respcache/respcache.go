@@ -0,0 +1,138 @@
+// Package respcache stores validated LLM responses on disk, keyed by a
+// hash of everything that determines the response (model, request
+// settings, and prompt), so re-reviewing an unchanged plan needs no
+// provider call.
+//
+// Each entry is its own storage key (a file for the default local
+// backend, written atomically). Concurrent runs that store the same
+// key race harmlessly: both write an equivalent response and the last
+// write wins.
+package respcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dshills/plancritic/internal/llm"
+	"github.com/dshills/plancritic/internal/storage"
+)
+
+const entryVersion = 1
+
+// Cache is a set of response entries in a storage backend, with a
+// maximum age.
+type Cache struct {
+	store  storage.Backend
+	prefix string
+	ttl    time.Duration
+}
+
+type entry struct {
+	Version   int       `json:"version"`
+	Model     string    `json:"model"`
+	CreatedAt time.Time `json:"created_at"`
+	Response  string    `json:"response"`
+	// Usage is what the provider reported for the calls that produced
+	// Response, so a hit reports the same usage as the original run.
+	Usage *usage `json:"usage,omitempty"`
+}
+
+type usage struct {
+	InputTokens      int `json:"input_tokens"`
+	OutputTokens     int `json:"output_tokens"`
+	CacheWriteTokens int `json:"cache_write_tokens,omitempty"`
+	CacheReadTokens  int `json:"cache_read_tokens,omitempty"`
+}
+
+// DefaultDir returns the standard on-disk location for cached
+// responses, using os.UserCacheDir (which honors XDG_CACHE_HOME on
+// Linux).
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("respcache: user cache dir: %w", err)
+	}
+	return filepath.Join(dir, "plancritic", "responses"), nil
+}
+
+// New returns a cache of files in dir whose entries expire ttl after
+// they were stored. The directory is created on the first Put.
+func New(dir string, ttl time.Duration) *Cache {
+	return &Cache{store: storage.NewFS(dir), ttl: ttl}
+}
+
+// NewBackend returns a cache kept under "responses/" in store, for
+// deployments that share state through a non-local backend.
+func NewBackend(store storage.Backend, ttl time.Duration) *Cache {
+	return &Cache{store: store, prefix: "responses/", ttl: ttl}
+}
+
+// Key hashes parts into a cache key. Parts are length-prefixed so
+// adjacent values cannot run together into the same key.
+func Key(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		fmt.Fprintf(h, "%d:", len(p))
+		h.Write([]byte(p))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get returns the cached response for key and the usage recorded with
+// it. A missing, expired, or unreadable entry is a miss; expired
+// entries are removed.
+func (c *Cache) Get(ctx context.Context, key string) (string, llm.Usage, bool) {
+	data, err := c.store.Get(ctx, c.key(key))
+	if err != nil {
+		return "", llm.Usage{}, false
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil || e.Version != entryVersion {
+		return "", llm.Usage{}, false
+	}
+	if !time.Now().Before(e.CreatedAt.Add(c.ttl)) {
+		_ = c.store.Delete(ctx, c.key(key))
+		return "", llm.Usage{}, false
+	}
+	var u llm.Usage
+	if e.Usage != nil {
+		u = llm.Usage{
+			InputTokens:              e.Usage.InputTokens,
+			OutputTokens:             e.Usage.OutputTokens,
+			CacheCreationInputTokens: e.Usage.CacheWriteTokens,
+			CacheReadInputTokens:     e.Usage.CacheReadTokens,
+		}
+	}
+	return e.Response, u, true
+}
+
+// Put stores response under key with the usage spent producing it.
+func (c *Cache) Put(ctx context.Context, key, model, response string, u llm.Usage) error {
+	e := entry{Version: entryVersion, Model: model, CreatedAt: time.Now(), Response: response}
+	if u != (llm.Usage{}) {
+		e.Usage = &usage{
+			InputTokens:      u.InputTokens,
+			OutputTokens:     u.OutputTokens,
+			CacheWriteTokens: u.CacheCreationInputTokens,
+			CacheReadTokens:  u.CacheReadInputTokens,
+		}
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("respcache: marshal: %w", err)
+	}
+	if err := c.store.Put(ctx, c.key(key), data); err != nil {
+		return fmt.Errorf("respcache: %w", err)
+	}
+	return nil
+}
+
+func (c *Cache) key(key string) string {
+	return c.prefix + key + ".json"
+}
@@ -0,0 +1,83 @@
+package respcache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dshills/plancritic/internal/llm"
+	"github.com/dshills/plancritic/internal/storage"
+)
+
+func TestPutGet(t *testing.T) {
+	ctx := context.Background()
+	c := New(filepath.Join(t.TempDir(), "responses"), time.Hour)
+	key := Key("anthropic/claude", "prompt")
+	if _, _, ok := c.Get(ctx, key); ok {
+		t.Fatal("hit on empty cache")
+	}
+	want := llm.Usage{InputTokens: 1200, OutputTokens: 300, CacheReadInputTokens: 800}
+	if err := c.Put(ctx, key, "anthropic/claude", `{"tool":"plancritic"}`, want); err != nil {
+		t.Fatal(err)
+	}
+	got, u, ok := c.Get(ctx, key)
+	if !ok || got != `{"tool":"plancritic"}` {
+		t.Fatalf("Get = %q, %v", got, ok)
+	}
+	if u != want {
+		t.Errorf("usage = %+v, want %+v", u, want)
+	}
+}
+
+func TestExpiredEntryIsRemoved(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	c := New(dir, time.Hour)
+	if err := c.Put(ctx, "k", "m", "r", llm.Usage{}); err != nil {
+		t.Fatal(err)
+	}
+	expired := New(dir, 0)
+	if _, _, ok := expired.Get(ctx, "k"); ok {
+		t.Fatal("expired entry returned")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "k.json")); !os.IsNotExist(err) {
+		t.Errorf("expired entry not removed: %v", err)
+	}
+}
+
+func TestCorruptEntryIsMiss(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "k.json"), []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := New(dir, time.Hour).Get(ctx, "k"); ok {
+		t.Fatal("corrupt entry returned")
+	}
+}
+
+func TestKeyIsUnambiguous(t *testing.T) {
+	if Key("ab", "c") == Key("a", "bc") {
+		t.Error("Key should separate parts")
+	}
+	if Key("a", "b") != Key("a", "b") {
+		t.Error("Key should be deterministic")
+	}
+}
+
+func TestBackendKeysAreNamespaced(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	c := NewBackend(storage.NewFS(dir), time.Hour)
+	if err := c.Put(ctx, "k", "m", "r", llm.Usage{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "responses", "k.json")); err != nil {
+		t.Errorf("entry not under responses/: %v", err)
+	}
+	if got, _, ok := c.Get(ctx, "k"); !ok || got != "r" {
+		t.Errorf("Get = %q, %v", got, ok)
+	}
+}
@@ -0,0 +1,85 @@
+package review
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+)
+
+// Roles of embedded input files.
+const (
+	EmbedRolePlan    = "plan"
+	EmbedRoleContext = "context"
+)
+
+// EmbedEncoding is the only encoding EmbedFile produces.
+const EmbedEncoding = "gzip+base64"
+
+// MaxEmbeddedBytes bounds the decoded size of one embedded file, so a
+// small artifact that decompresses to gigabytes cannot exhaust memory.
+// It is well above the largest input check accepts by default.
+const MaxEmbeddedBytes = 64 << 20
+
+// EmbeddedFile is a snapshot of a plan or context file stored in the
+// review with --embed-inputs, so the artifact stays self-contained after
+// the original files change or disappear. The content is what the model
+// saw, so it is redacted when redaction was enabled. Hash covers the
+// decoded content and may therefore differ from the input hash.
+type EmbeddedFile struct {
+	Role     string `json:"role"`
+	Path     string `json:"path"`
+	Hash     string `json:"hash"`
+	Encoding string `json:"encoding"`
+	Data     string `json:"data"`
+}
+
+// EmbedFile compresses content into an EmbeddedFile.
+func EmbedFile(role, path, content string) (EmbeddedFile, error) {
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return EmbeddedFile{}, fmt.Errorf("review.EmbedFile: %w", err)
+	}
+	if _, err := zw.Write([]byte(content)); err != nil {
+		return EmbeddedFile{}, fmt.Errorf("review.EmbedFile: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return EmbeddedFile{}, fmt.Errorf("review.EmbedFile: %w", err)
+	}
+	return EmbeddedFile{
+		Role:     role,
+		Path:     path,
+		Hash:     fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(content))),
+		Encoding: EmbedEncoding,
+		Data:     base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}, nil
+}
+
+// Content decodes the embedded file and checks it against Hash.
+func (e EmbeddedFile) Content() (string, error) {
+	if e.Encoding != EmbedEncoding {
+		return "", fmt.Errorf("review: embedded %s: unsupported encoding %q", e.Path, e.Encoding)
+	}
+	raw, err := base64.StdEncoding.DecodeString(e.Data)
+	if err != nil {
+		return "", fmt.Errorf("review: embedded %s: %w", e.Path, err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return "", fmt.Errorf("review: embedded %s: %w", e.Path, err)
+	}
+	data, err := io.ReadAll(io.LimitReader(zr, MaxEmbeddedBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("review: embedded %s: %w", e.Path, err)
+	}
+	if len(data) > MaxEmbeddedBytes {
+		return "", fmt.Errorf("review: embedded %s: decodes to more than %d bytes", e.Path, MaxEmbeddedBytes)
+	}
+	if h := fmt.Sprintf("sha256:%x", sha256.Sum256(data)); h != e.Hash {
+		return "", fmt.Errorf("review: embedded %s: hash mismatch (got %s, recorded %s)", e.Path, h, e.Hash)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,58 @@
+package review
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEmbedFileRoundtrip(t *testing.T) {
+	content := "# Plan\n\n1. Step one\n" + strings.Repeat("filler line\n", 200)
+	e, err := EmbedFile(EmbedRolePlan, "plan.md", content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Encoding != EmbedEncoding || e.Role != EmbedRolePlan || e.Path != "plan.md" {
+		t.Errorf("unexpected header: %+v", e)
+	}
+	if len(e.Data) >= len(content) {
+		t.Errorf("data is %d bytes, expected compression below %d", len(e.Data), len(content))
+	}
+	got, err := e.Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != content {
+		t.Error("content did not round-trip")
+	}
+}
+
+func TestEmbeddedFileContentRejectsTampering(t *testing.T) {
+	e, err := EmbedFile(EmbedRoleContext, "spec.md", "original")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := EmbedFile(EmbedRoleContext, "spec.md", "altered")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Data = other.Data
+	if _, err := e.Content(); err == nil || !strings.Contains(err.Error(), "hash mismatch") {
+		t.Errorf("expected hash mismatch, got %v", err)
+	}
+
+	e.Encoding = "zstd"
+	if _, err := e.Content(); err == nil {
+		t.Error("expected unsupported encoding error")
+	}
+}
+
+func TestEmbeddedFileContentRejectsOversizedData(t *testing.T) {
+	// Zeros compress about a thousandfold, as a decompression bomb does.
+	e, err := EmbedFile(EmbedRolePlan, "plan.md", strings.Repeat("\x00", MaxEmbeddedBytes+1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Content(); err == nil || !strings.Contains(err.Error(), "more than") {
+		t.Errorf("expected a size error, got %v", err)
+	}
+}
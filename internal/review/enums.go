@@ -19,6 +19,13 @@ func (v Verdict) Valid() bool {
 	return false
 }
 
+// Status marks a review that is not the full result of the run.
+type Status string
+
+// StatusIncomplete marks a review cut short by the run budget
+// (--max-duration): its findings are those that validated in time.
+const StatusIncomplete Status = "INCOMPLETE"
+
 // Severity indicates the importance of an issue or question.
 type Severity string
 
@@ -1,5 +1,7 @@
 package review
 
+import "slices"
+
 // FilterBySeverity returns issues at or above the given threshold.
 // Invalid severities are always included.
 func FilterBySeverity(issues []Issue, threshold string) []Issue {
@@ -25,3 +27,46 @@ func FilterQuestionsBySeverity(questions []Question, threshold string) []Questio
 	}
 	return result
 }
+
+// SeverityBounds is the range of severities a category's issues may
+// have. An empty Min or Max leaves that side open.
+type SeverityBounds struct {
+	Min Severity
+	Max Severity
+}
+
+// ClampSeverities raises each issue below its category's Min to it and
+// lowers each above its Max to it, returning how many issues changed.
+// Issues with an invalid severity are left alone.
+func ClampSeverities(issues []Issue, bounds map[Category]SeverityBounds) int {
+	changed := 0
+	for i := range issues {
+		b, ok := bounds[issues[i].Category]
+		if !ok || !issues[i].Severity.Valid() {
+			continue
+		}
+		s := issues[i].Severity
+		if b.Min.Valid() && s.Order() > b.Min.Order() {
+			s = b.Min
+		}
+		if b.Max.Valid() && s.Order() < b.Max.Order() {
+			s = b.Max
+		}
+		if s != issues[i].Severity {
+			issues[i].Severity = s
+			changed++
+		}
+	}
+	return changed
+}
+
+// DropCategories removes the issues in one of the disabled categories,
+// returning the rest.
+func DropCategories(issues []Issue, disabled []Category) []Issue {
+	if len(disabled) == 0 {
+		return issues
+	}
+	return slices.DeleteFunc(issues, func(iss Issue) bool {
+		return slices.Contains(disabled, iss.Category)
+	})
+}
@@ -0,0 +1,59 @@
+package review
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode"
+)
+
+// Fingerprint returns a short stable identifier for an issue, derived
+// from its category and normalized title. Model-assigned IDs like
+// ISSUE-0003 change between runs; the fingerprint survives re-runs and
+// plan edits that move the cited lines, so it is what suppressions
+// refer to.
+func Fingerprint(iss Issue) string {
+	return fingerprint(string(iss.Category), iss.Title)
+}
+
+// QuestionFingerprint is Fingerprint for questions.
+func QuestionFingerprint(q Question) string {
+	return fingerprint("QUESTION", q.Question)
+}
+
+func fingerprint(kind, text string) string {
+	sum := sha256.Sum256([]byte(kind + "\n" + normalizeText(text)))
+	return hex.EncodeToString(sum[:6])
+}
+
+// normalizeText lowercases text and collapses everything that is not a
+// letter or digit into single spaces, so punctuation and whitespace
+// differences don't change a fingerprint.
+func normalizeText(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+			space = false
+			continue
+		}
+		space = true
+	}
+	return b.String()
+}
+
+// AssignFingerprints sets Fingerprint, and the Permalink derived from
+// it, on every issue and question.
+func AssignFingerprints(r *Review) {
+	for i := range r.Issues {
+		r.Issues[i].Fingerprint = Fingerprint(r.Issues[i])
+	}
+	for i := range r.Questions {
+		r.Questions[i].Fingerprint = QuestionFingerprint(r.Questions[i])
+	}
+	AssignPermalinks(r)
+}
@@ -24,7 +24,15 @@ type GroundingViolation struct {
 }
 
 // CheckGrounding scans issue and question text fields for phrases suggesting fabricated repo knowledge.
-func CheckGrounding(r *Review) []GroundingViolation {
+// extra are further phrases to look for, such as a profile's; case is ignored.
+func CheckGrounding(r *Review, extra ...string) []GroundingViolation {
+	phrases := fabricationPhrases
+	if len(extra) > 0 {
+		phrases = append([]string(nil), fabricationPhrases...)
+		for _, p := range extra {
+			phrases = append(phrases, strings.ToLower(p))
+		}
+	}
 	var violations []GroundingViolation
 	for _, iss := range r.Issues {
 		for _, field := range []struct {
@@ -36,7 +44,7 @@ func CheckGrounding(r *Review) []GroundingViolation {
 			{"recommendation", iss.Recommendation},
 		} {
 			lower := strings.ToLower(field.text)
-			for _, phrase := range fabricationPhrases {
+			for _, phrase := range phrases {
 				if strings.Contains(lower, phrase) {
 					violations = append(violations, GroundingViolation{
 						IssueID: iss.ID,
@@ -56,7 +64,7 @@ func CheckGrounding(r *Review) []GroundingViolation {
 			{"why_needed", q.WhyNeeded},
 		} {
 			lower := strings.ToLower(field.text)
-			for _, phrase := range fabricationPhrases {
+			for _, phrase := range phrases {
 				if strings.Contains(lower, phrase) {
 					violations = append(violations, GroundingViolation{
 						IssueID: q.ID,
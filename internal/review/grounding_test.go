@@ -31,6 +31,28 @@ func TestCheckGrounding(t *testing.T) {
 	}
 }
 
+func TestCheckGroundingExtraPhrases(t *testing.T) {
+	r := &Review{
+		Issues: []Issue{
+			{ID: "I-1", Description: "Our Kubernetes cluster autoscales, so load is fine."},
+		},
+		Questions: []Question{
+			{ID: "Q-1", Question: "Which module of the existing Terraform owns the VPC?"},
+		},
+	}
+
+	if v := CheckGrounding(r); len(v) != 0 {
+		t.Fatalf("expected no violations without extra phrases, got %v", v)
+	}
+	violations := CheckGrounding(r, "our kubernetes cluster", "The existing Terraform")
+	if len(violations) != 2 {
+		t.Fatalf("expected 2 violations, got %v", violations)
+	}
+	if violations[0].IssueID != "I-1" || violations[1].Phrase != "the existing terraform" {
+		t.Errorf("violations = %v", violations)
+	}
+}
+
 func TestCheckGroundingQuestions(t *testing.T) {
 	r := &Review{
 		Questions: []Question{
@@ -0,0 +1,79 @@
+package review
+
+import "fmt"
+
+// permalinkPrefix starts every permalink, so the IDs are easy to search
+// for and valid as HTML ids and Markdown anchors.
+const permalinkPrefix = "pc-"
+
+// permalinkHexLen is how many fingerprint hex digits a permalink keeps.
+const permalinkHexLen = 8
+
+// Permalink returns the permalink of a finding with fingerprint fp: a
+// short hash that names the finding the same way in every output — the
+// JSON review, Markdown and HTML anchors, and escalation documents — and
+// across re-runs, because it derives from the fingerprint rather than
+// the model-assigned ID.
+func Permalink(fp string) string {
+	if len(fp) > permalinkHexLen {
+		fp = fp[:permalinkHexLen]
+	}
+	return permalinkPrefix + fp
+}
+
+// Permalinks returns the permalinks of r's issues and questions, in
+// order. A finding's Permalink field is used when set; otherwise it is
+// derived from the finding's fingerprint. Findings that share a
+// permalink within r get -2, -3, ... suffixes in order, so every
+// permalink is unique in the review and can serve as an anchor.
+func Permalinks(r *Review) (issues, questions []string) {
+	seen := map[string]int{}
+	unique := func(link string) string {
+		seen[link]++
+		if n := seen[link]; n > 1 {
+			return fmt.Sprintf("%s-%d", link, n)
+		}
+		return link
+	}
+	for _, iss := range r.Issues {
+		link := iss.Permalink
+		if link == "" {
+			fp := iss.Fingerprint
+			if fp == "" {
+				fp = Fingerprint(iss)
+			}
+			link = unique(Permalink(fp))
+		}
+		issues = append(issues, link)
+	}
+	for _, q := range r.Questions {
+		link := q.Permalink
+		if link == "" {
+			fp := q.Fingerprint
+			if fp == "" {
+				fp = QuestionFingerprint(q)
+			}
+			link = unique(Permalink(fp))
+		}
+		questions = append(questions, link)
+	}
+	return issues, questions
+}
+
+// AssignPermalinks recomputes Permalink on every issue and question
+// from their fingerprints.
+func AssignPermalinks(r *Review) {
+	for i := range r.Issues {
+		r.Issues[i].Permalink = ""
+	}
+	for i := range r.Questions {
+		r.Questions[i].Permalink = ""
+	}
+	issues, questions := Permalinks(r)
+	for i := range r.Issues {
+		r.Issues[i].Permalink = issues[i]
+	}
+	for i := range r.Questions {
+		r.Questions[i].Permalink = questions[i]
+	}
+}
@@ -1,29 +1,63 @@
 package review
 
 import (
+	"fmt"
 	"path"
+	"sort"
 	"strings"
 )
 
-// NormalizeContextPath canonicalizes a path used to key a context
-// file across the three sites that need to agree: where check.go
-// builds the map, where schema.Validate looks up line counts, and
+// NormalizeContextPath canonicalizes a context path the way the
+// three sites that key context files agree on: where the reviewer
+// builds its maps, where schema.Validate looks up line counts, and
 // where ReconstructQuotes resolves lines. Backslashes are replaced
 // with forward slashes explicitly (rather than filepath.ToSlash,
 // which only converts on Windows) so an LLM-emitted Windows-style
-// path resolves the same way regardless of the host OS. The result
-// is the final path component.
+// path resolves the same way regardless of the host OS, and leading
+// "./" and "/" are dropped.
 func NormalizeContextPath(p0 string) string {
-	return path.Base(strings.ReplaceAll(p0, "\\", "/"))
+	p := path.Clean(strings.ReplaceAll(p0, "\\", "/"))
+	return strings.TrimLeft(strings.TrimPrefix(p, "./"), "/")
+}
+
+// ResolveContextPath maps a cited context path to the key of byName,
+// whose keys are the names the prompt gave the context files. A
+// citation matches a name exactly, or when it is a shorter or longer
+// form of exactly one name: "constraints.md" or
+// "repo/docs/constraints.md" for "docs/constraints.md". It fails when
+// no file matches, or when several do and the citation cannot say
+// which was meant.
+func ResolveContextPath[V any](byName map[string]V, cited string) (string, error) {
+	c := NormalizeContextPath(cited)
+	if _, ok := byName[c]; ok {
+		return c, nil
+	}
+	var matches []string
+	for name := range byName {
+		if strings.HasSuffix(name, "/"+c) || strings.HasSuffix(c, "/"+name) {
+			matches = append(matches, name)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("context %q was not provided", c)
+	case 1:
+		return matches[0], nil
+	}
+	sort.Strings(matches)
+	return "", fmt.Errorf("context %q is ambiguous: cite one of %s", c, strings.Join(matches, ", "))
 }
 
 // QuoteSource supplies the line text that Evidence citations refer to.
 // PlanLines is the plan's lines, 0-indexed (line_start=1 maps to
-// PlanLines[0]). ContextsByBasename maps a context file's base name
-// (the same name used when embedding it into the prompt) to its lines.
+// PlanLines[0]). ContextsByName maps each context file's name (the
+// path the prompt shows for it) to its lines. In a joint review,
+// PlansByName maps every plan document's name to its lines, and plan
+// evidence is resolved by its path as context evidence is.
 type QuoteSource struct {
-	PlanLines          []string
-	ContextsByBasename map[string][]string
+	PlanLines      []string
+	ContextsByName map[string][]string
+	PlansByName    map[string][]string
 }
 
 // unavailableQuote marks evidence whose citation could not be resolved
@@ -53,38 +87,67 @@ func ReconstructQuotes(r *Review, src QuoteSource) int {
 			}
 		}
 	}
+	for i := range r.Checklists {
+		for j := range r.Checklists[i].Checks {
+			for k := range r.Checklists[i].Checks[j].Evidence {
+				if !fillQuote(&r.Checklists[i].Checks[j].Evidence[k], src) {
+					misses++
+				}
+			}
+		}
+	}
 	return misses
 }
 
 // fillQuote resolves ev against src and sets ev.Quote. Returns false
 // if the source/path couldn't be found or the line range was invalid.
 func fillQuote(ev *Evidence, src QuoteSource) bool {
-	lines, ok := resolveLines(ev, src)
+	text, ok := src.Text(*ev)
 	if !ok {
 		ev.Quote = unavailableQuote
 		return false
 	}
+	ev.Quote = text
+	return true
+}
+
+// Text returns the lines ev cites, joined with newlines. The second
+// return is false if the source/path couldn't be found or the line
+// range was invalid.
+func (src QuoteSource) Text(ev Evidence) (string, bool) {
+	lines, ok := resolveLines(&ev, src)
+	if !ok {
+		return "", false
+	}
 	// Evidence line numbers are 1-indexed and inclusive on both ends.
 	start := ev.LineStart - 1
 	end := ev.LineEnd
 	if start < 0 || start >= end || end > len(lines) {
-		ev.Quote = unavailableQuote
-		return false
+		return "", false
 	}
-	ev.Quote = strings.Join(lines[start:end], "\n")
-	return true
+	return strings.Join(lines[start:end], "\n"), true
 }
 
 func resolveLines(ev *Evidence, src QuoteSource) ([]string, bool) {
 	switch ev.Source {
 	case "plan":
+		if src.PlansByName != nil {
+			name, err := ResolveContextPath(src.PlansByName, ev.Path)
+			if err != nil {
+				return nil, false
+			}
+			return src.PlansByName[name], true
+		}
 		if src.PlanLines == nil {
 			return nil, false
 		}
 		return src.PlanLines, true
 	case "context":
-		lines, ok := src.ContextsByBasename[NormalizeContextPath(ev.Path)]
-		return lines, ok
+		name, err := ResolveContextPath(src.ContextsByName, ev.Path)
+		if err != nil {
+			return nil, false
+		}
+		return src.ContextsByName[name], true
 	default:
 		return nil, false
 	}
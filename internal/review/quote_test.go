@@ -1,6 +1,9 @@
 package review
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestReconstructQuotesPlanSource(t *testing.T) {
 	r := &Review{
@@ -35,7 +38,7 @@ func TestReconstructQuotesContextSource(t *testing.T) {
 		}},
 	}
 	src := QuoteSource{
-		ContextsByBasename: map[string][]string{
+		ContextsByName: map[string][]string{
 			"constraints.md": {"first rule", "second rule"},
 		},
 	}
@@ -74,7 +77,7 @@ func TestReconstructQuotesNormalizesFullPathFromLLM(t *testing.T) {
 		}},
 	}
 	src := QuoteSource{
-		ContextsByBasename: map[string][]string{"constraints.md": {"rule one"}},
+		ContextsByName: map[string][]string{"constraints.md": {"rule one"}},
 	}
 	if misses := ReconstructQuotes(r, src); misses != 0 {
 		t.Fatalf("unexpected misses: %d", misses)
@@ -163,8 +166,8 @@ func TestReconstructQuotesMultipleEvidence(t *testing.T) {
 		},
 	}
 	src := QuoteSource{
-		PlanLines:          []string{"plan-1", "plan-2"},
-		ContextsByBasename: map[string][]string{"a.md": {"ctx-1", "ctx-2"}},
+		PlanLines:      []string{"plan-1", "plan-2"},
+		ContextsByName: map[string][]string{"a.md": {"ctx-1", "ctx-2"}},
 	}
 	if misses := ReconstructQuotes(r, src); misses != 0 {
 		t.Fatalf("unexpected misses: %d", misses)
@@ -179,3 +182,29 @@ func TestReconstructQuotesMultipleEvidence(t *testing.T) {
 		t.Errorf("question ev[0] = %q, want plan-2", got)
 	}
 }
+
+func TestResolveContextPath(t *testing.T) {
+	byName := map[string]int{"api/constraints.md": 1, "db/constraints.md": 2, "notes.md": 3}
+	tests := []struct {
+		cited, want, err string
+	}{
+		{"api/constraints.md", "api/constraints.md", ""},
+		{"./db/constraints.md", "db/constraints.md", ""},
+		{"repo\\db\\constraints.md", "db/constraints.md", ""},
+		{"/home/me/notes.md", "notes.md", ""},
+		{"constraints.md", "", "ambiguous: cite one of api/constraints.md, db/constraints.md"},
+		{"web/constraints.md", "", "was not provided"},
+	}
+	for _, tt := range tests {
+		got, err := ResolveContextPath(byName, tt.cited)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ResolveContextPath(%q) error = %v, want %q", tt.cited, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ResolveContextPath(%q) = %q, %v; want %q", tt.cited, got, err, tt.want)
+		}
+	}
+}
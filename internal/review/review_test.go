@@ -1,6 +1,7 @@
 package review
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -123,6 +124,45 @@ func TestSortIssues(t *testing.T) {
 	}
 }
 
+func TestClampSeverities(t *testing.T) {
+	issues := []Issue{
+		{ID: "1", Severity: SeverityInfo, Category: CategoryRiskData},
+		{ID: "2", Severity: SeverityCritical, Category: CategoryTestGap},
+		{ID: "3", Severity: SeverityInfo, Category: CategoryTestGap},
+		{ID: "4", Severity: SeverityCritical, Category: CategoryAmbiguity},
+		{ID: "5", Severity: "BOGUS", Category: CategoryRiskData},
+	}
+	bounds := map[Category]SeverityBounds{
+		CategoryRiskData: {Min: SeverityCritical},
+		CategoryTestGap:  {Max: SeverityWarn},
+	}
+
+	if n := ClampSeverities(issues, bounds); n != 2 {
+		t.Errorf("changed %d issues, want 2", n)
+	}
+	expected := []Severity{SeverityCritical, SeverityWarn, SeverityInfo, SeverityCritical, "BOGUS"}
+	for i, sev := range expected {
+		if issues[i].Severity != sev {
+			t.Errorf("issue %s: got %s, want %s", issues[i].ID, issues[i].Severity, sev)
+		}
+	}
+}
+
+func TestDropCategories(t *testing.T) {
+	issues := []Issue{
+		{ID: "1", Category: CategoryScopeCreepRisk},
+		{ID: "2", Category: CategoryTestGap},
+		{ID: "3", Category: CategoryScopeCreepRisk},
+	}
+	got := DropCategories(issues, []Category{CategoryScopeCreepRisk})
+	if len(got) != 1 || got[0].ID != "2" {
+		t.Errorf("DropCategories = %+v", got)
+	}
+	if got := DropCategories([]Issue{{ID: "1"}}, nil); len(got) != 1 {
+		t.Errorf("no disabled categories dropped %+v", got)
+	}
+}
+
 func TestSortQuestions(t *testing.T) {
 	questions := []Question{
 		{ID: "Q1", Severity: SeverityInfo, Evidence: []Evidence{{LineStart: 10}}},
@@ -140,6 +180,70 @@ func TestSortQuestions(t *testing.T) {
 	}
 }
 
+func TestSortIssuesUsesLowestEvidenceLine(t *testing.T) {
+	issues := []Issue{
+		{ID: "1", Severity: SeverityWarn, Title: "B", Evidence: []Evidence{{LineStart: 30}, {LineStart: 2}}},
+		{ID: "2", Severity: SeverityWarn, Title: "A", Evidence: []Evidence{{LineStart: 10}}},
+		{ID: "3", Severity: SeverityWarn, Title: "C", Evidence: []Evidence{{LineStart: 10}}},
+		{ID: "4", Severity: SeverityWarn, Title: "A", Evidence: []Evidence{{LineStart: 2}}},
+	}
+
+	SortIssues(issues)
+
+	expected := []string{"4", "1", "2", "3"}
+	for i, id := range expected {
+		if issues[i].ID != id {
+			t.Errorf("position %d: got ID %s, want %s", i, issues[i].ID, id)
+		}
+	}
+}
+
+func TestSortEvidence(t *testing.T) {
+	ev := []Evidence{
+		{Source: "context", Path: "b.md", LineStart: 1, LineEnd: 1, Quote: "b"},
+		{Source: "plan", Path: "plan.md", LineStart: 9, LineEnd: 9, Quote: "nine"},
+		{Source: "context", Path: "a.md", LineStart: 4, LineEnd: 5, Quote: "a"},
+		{Source: "plan", Path: "plan.md", LineStart: 3, LineEnd: 4, Quote: "three"},
+		{Source: "plan", Path: "plan.md", LineStart: 9, LineEnd: 9, Quote: "nine again"},
+		{Source: "plan", Path: "plan.md", LineStart: 3, LineEnd: 3, Quote: "three only"},
+	}
+
+	got := SortEvidence(ev)
+
+	want := []string{"three only", "three", "nine", "a", "b"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d evidence entries, want %d: %+v", len(got), len(want), got)
+	}
+	for i, q := range want {
+		if got[i].Quote != q {
+			t.Errorf("position %d: got quote %q, want %q", i, got[i].Quote, q)
+		}
+	}
+}
+
+func TestNormalizeEvidence(t *testing.T) {
+	r := Review{
+		Issues: []Issue{{Evidence: []Evidence{
+			{Source: "plan", LineStart: 8, LineEnd: 8},
+			{Source: "plan", LineStart: 2, LineEnd: 2},
+			{Source: "plan", LineStart: 8, LineEnd: 8},
+		}}},
+		Questions: []Question{{Evidence: []Evidence{
+			{Source: "context", Path: "c.md", LineStart: 1, LineEnd: 1},
+			{Source: "plan", LineStart: 5, LineEnd: 5},
+		}}},
+	}
+
+	NormalizeEvidence(&r)
+
+	if ev := r.Issues[0].Evidence; len(ev) != 2 || ev[0].LineStart != 2 || ev[1].LineStart != 8 {
+		t.Errorf("issue evidence = %+v, want lines 2 then 8", ev)
+	}
+	if ev := r.Questions[0].Evidence; len(ev) != 2 || ev[0].Source != "plan" {
+		t.Errorf("question evidence = %+v, want plan evidence first", ev)
+	}
+}
+
 // --- Summary tests ---
 
 func TestComputeSummary(t *testing.T) {
@@ -163,6 +267,44 @@ func TestComputeSummary(t *testing.T) {
 	}
 }
 
+func TestGroundedSummary(t *testing.T) {
+	issues := []Issue{
+		{Severity: SeverityWarn, Blocking: true, Tags: []string{"UNVERIFIED"}},
+		{Severity: SeverityCritical, Blocking: true, Tags: []string{"Assumption"}},
+		{Severity: SeverityWarn, Tags: []string{"security"}},
+	}
+	if v := ComputeSummary(issues).Verdict; v != VerdictNotExecutable {
+		t.Errorf("verdict = %s, want %s", v, VerdictNotExecutable)
+	}
+	g := GroundedSummary(issues)
+	if g.Verdict != VerdictWithClarifications || g.WarnCount != 1 || g.CriticalCount != 0 {
+		t.Errorf("grounded summary = %+v, want one WARN and %s", g, VerdictWithClarifications)
+	}
+}
+
+func TestChecklistSummary(t *testing.T) {
+	checklists := []Checklist{{ID: "C", Checks: []CheckItem{
+		{Check: "a", Status: CheckStatusFail},
+		{Check: "b", Status: CheckStatusPass},
+		{Check: "c", Status: CheckStatusFail},
+	}}}
+	s := ChecklistSummary(nil, checklists)
+	if s.Verdict != VerdictWithClarifications || s.FailedChecks != 2 || s.Score != 86 {
+		t.Errorf("summary = %+v, want two failed checks", s)
+	}
+	blocking := []Issue{{Severity: SeverityCritical, Blocking: true}}
+	if s := ChecklistSummary(blocking, checklists); s.Verdict != VerdictNotExecutable || s.Score != 66 {
+		t.Errorf("summary = %+v, want the blocking issue to decide the verdict", s)
+	}
+	if s := ChecklistSummary(nil, checklists[:0]); s.Verdict != VerdictExecutable || s.Score != 100 {
+		t.Errorf("summary = %+v, want a clean review", s)
+	}
+	checklists[0].Checks[2].Required = true
+	if s := ChecklistSummary(nil, checklists); s.Verdict != VerdictNotExecutable || s.Score != 86 {
+		t.Errorf("summary = %+v, want a failed required check to block the plan", s)
+	}
+}
+
 // --- Truncate tests ---
 
 func TestTruncate(t *testing.T) {
@@ -194,3 +336,68 @@ func TestTruncate(t *testing.T) {
 		t.Errorf("expected truncation issue, got ID %s", last.ID)
 	}
 }
+
+func TestFingerprintStable(t *testing.T) {
+	a := Issue{ID: "ISSUE-0001", Category: CategoryAmbiguity, Title: "Unclear rollback plan."}
+	b := Issue{ID: "ISSUE-0007", Category: CategoryAmbiguity, Title: "  unclear   ROLLBACK plan"}
+	if Fingerprint(a) != Fingerprint(b) {
+		t.Error("fingerprint should ignore ID, case, punctuation, and spacing")
+	}
+	c := Issue{Category: CategoryTestGap, Title: a.Title}
+	if Fingerprint(a) == Fingerprint(c) {
+		t.Error("fingerprint should depend on category")
+	}
+	if len(Fingerprint(a)) != 12 {
+		t.Errorf("expected 12-char fingerprint, got %q", Fingerprint(a))
+	}
+}
+
+func TestPermalinks(t *testing.T) {
+	iss := Issue{ID: "ISSUE-0001", Category: CategoryAmbiguity, Title: "Unclear rollback plan"}
+	r := Review{
+		Issues:    []Issue{iss, {ID: "ISSUE-0002", Category: CategoryAmbiguity, Title: "unclear rollback plan."}},
+		Questions: []Question{{ID: "Q-0001", Question: "Which region?"}},
+	}
+	AssignFingerprints(&r)
+
+	want := Permalink(Fingerprint(iss))
+	if !strings.HasPrefix(want, "pc-") || len(want) != len("pc-")+8 {
+		t.Fatalf("Permalink = %q, want pc- and 8 hex digits", want)
+	}
+	if r.Issues[0].Permalink != want || r.Issues[1].Permalink != want+"-2" {
+		t.Errorf("issue permalinks = %q, %q; want %q and a -2 suffix", r.Issues[0].Permalink, r.Issues[1].Permalink, want)
+	}
+	if r.Questions[0].Permalink != Permalink(QuestionFingerprint(r.Questions[0])) {
+		t.Errorf("question permalink = %q", r.Questions[0].Permalink)
+	}
+
+	// A review read back from JSON keeps its recorded permalinks, and
+	// one without them derives the same ones.
+	issues, _ := Permalinks(&Review{Issues: []Issue{{Permalink: "pc-kept"}, iss}})
+	if issues[0] != "pc-kept" || issues[1] != want {
+		t.Errorf("Permalinks = %q", issues)
+	}
+}
+
+func TestApplyOutOfScope(t *testing.T) {
+	r := Review{Issues: []Issue{
+		{ID: "ISSUE-0001", Category: CategoryMissingPrerequisite, Title: "No mobile apps plan", Description: "iOS clients are not covered."},
+		{ID: "ISSUE-0002", Category: CategoryScopeCreepRisk, Title: "Billing rework", Evidence: []Evidence{{Source: "plan", LineStart: 12, LineEnd: 13}}},
+		{ID: "ISSUE-0003", Category: CategoryRiskData, Title: "Mobile app data loss"},
+		{ID: "ISSUE-0004", Category: CategoryMissingAcceptanceCriteria, Title: "No acceptance criteria for search"},
+	}}
+	AssignFingerprints(&r)
+	excl := []Exclusion{
+		{Text: "Mobile app — handled in the Q3 plan"},
+		{Text: "Billing", Line: 13},
+	}
+	if n := ApplyOutOfScope(&r, excl); n != 2 {
+		t.Fatalf("moved %d issues, want 2", n)
+	}
+	if len(r.Issues) != 2 || r.Issues[0].ID != "ISSUE-0003" || r.Issues[1].ID != "ISSUE-0004" {
+		t.Errorf("kept %+v; want the data risk and the unrelated gap", r.Issues)
+	}
+	if len(r.Suppressed) != 2 || r.Suppressed[0].Reason != "out of scope: Mobile app — handled in the Q3 plan" || r.Suppressed[1].ID != "ISSUE-0002" {
+		t.Errorf("suppressed = %+v", r.Suppressed)
+	}
+}
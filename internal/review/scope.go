@@ -0,0 +1,123 @@
+package review
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Exclusion is one item a plan declares out of scope, from an "Out of
+// scope" or "Non-goals" section or from front matter.
+type Exclusion struct {
+	Text string
+	// Line is the plan line the item is on, 0 for front matter.
+	Line int
+}
+
+// scopeStopWords are left out when matching a finding to an exclusion:
+// they say nothing about what was excluded.
+var scopeStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "any": true,
+	"all": true, "not": true, "out": true, "scope": true, "this": true,
+	"that": true, "from": true, "into": true, "are": true, "will": true,
+	"our": true, "its": true, "support": true, "plan": true, "work": true,
+	"changes": true, "change": true, "new": true, "existing": true,
+}
+
+// outOfScopeCategory reports whether c is a category a plan's
+// exclusions answer: scope creep, and anything the plan is said to be
+// missing.
+func outOfScopeCategory(c Category) bool {
+	return c == CategoryScopeCreepRisk || strings.HasPrefix(string(c), "MISSING_")
+}
+
+// ApplyOutOfScope moves SCOPE_CREEP_RISK and MISSING_* issues about
+// something the plan excludes into r.Suppressed, with the exclusion as
+// the reason. An issue matches an exclusion when it cites the
+// exclusion's line, or when every significant word of the item's
+// subject (the text before a dash, colon, or parenthesis) appears in
+// its title or description. Issues need fingerprints (see
+// AssignFingerprints). It returns the number of issues moved.
+func ApplyOutOfScope(r *Review, excl []Exclusion) int {
+	if len(excl) == 0 {
+		return 0
+	}
+	subjects := make([][]string, len(excl))
+	for i, e := range excl {
+		subjects[i] = scopeWords(scopeSubject(e.Text))
+	}
+	moved := 0
+	issues := r.Issues[:0]
+	for _, iss := range r.Issues {
+		if outOfScopeCategory(iss.Category) {
+			if i := matchExclusion(iss, excl, subjects); i >= 0 {
+				r.Suppressed = append(r.Suppressed, SuppressedFinding{
+					Fingerprint: iss.Fingerprint,
+					ID:          iss.ID,
+					Severity:    iss.Severity,
+					Title:       strings.TrimSpace(iss.Title),
+					Reason:      "out of scope: " + excl[i].Text,
+				})
+				moved++
+				continue
+			}
+		}
+		issues = append(issues, iss)
+	}
+	r.Issues = issues
+	return moved
+}
+
+// matchExclusion returns the index of the first exclusion iss is
+// about, or -1.
+func matchExclusion(iss Issue, excl []Exclusion, subjects [][]string) int {
+	words := map[string]bool{}
+	for _, w := range scopeWords(iss.Title + " " + iss.Description) {
+		words[w] = true
+	}
+	for i, e := range excl {
+		for _, ev := range iss.Evidence {
+			if ev.Source == "plan" && e.Line > 0 && ev.LineStart <= e.Line && e.Line <= ev.LineEnd {
+				return i
+			}
+		}
+		if len(subjects[i]) == 0 {
+			continue
+		}
+		all := true
+		for _, w := range subjects[i] {
+			all = all && words[w]
+		}
+		if all {
+			return i
+		}
+	}
+	return -1
+}
+
+// scopeSubject trims an exclusion to what it excludes, dropping a
+// trailing explanation: "Mobile app (handled in Q3)" is "Mobile app".
+func scopeSubject(text string) string {
+	for _, sep := range []string{" — ", " – ", " - ", ":", "(", ";"} {
+		if i := strings.Index(text, sep); i > 0 {
+			text = text[:i]
+		}
+	}
+	return text
+}
+
+// scopeWords splits text into lower-cased words of three or more
+// letters or digits, without stop words and with a plural "s" dropped.
+func scopeWords(text string) []string {
+	var out []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") {
+			w = w[:len(w)-1]
+		}
+		if len(w) >= 3 && !scopeStopWords[w] {
+			out = append(out, w)
+		}
+	}
+	return out
+}
@@ -0,0 +1,110 @@
+package review
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SignoffDecision records the human reviewer's decision on a plan.
+type SignoffDecision string
+
+const (
+	SignoffApproved SignoffDecision = "APPROVED"
+	SignoffRejected SignoffDecision = "REJECTED"
+)
+
+func (d SignoffDecision) Valid() bool {
+	return d == SignoffApproved || d == SignoffRejected
+}
+
+// Signoff is the human approval that follows the machine critique.
+// ArtifactHash covers every other field of the review, and SignoffHash
+// covers the signoff's own fields along with ArtifactHash, so any edit
+// to the critique or to the decision after sign-off is detectable with
+// VerifySignoff.
+type Signoff struct {
+	Reviewer     string          `json:"reviewer"`
+	Decision     SignoffDecision `json:"decision"`
+	Notes        string          `json:"notes,omitempty"`
+	Timestamp    time.Time       `json:"timestamp"`
+	ArtifactHash string          `json:"artifact_hash"`
+	SignoffHash  string          `json:"signoff_hash,omitempty"`
+}
+
+// ArtifactHash returns the sha256 of the review's canonical JSON
+// encoding with the signoff section removed.
+func ArtifactHash(r *Review) (string, error) {
+	clone := *r
+	clone.Signoff = nil
+	data, err := json.Marshal(&clone)
+	if err != nil {
+		return "", fmt.Errorf("review.ArtifactHash: %w", err)
+	}
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data)), nil
+}
+
+// signoffHash returns the sha256 of the signoff's JSON encoding without
+// SignoffHash, which includes ArtifactHash.
+func signoffHash(s Signoff) (string, error) {
+	s.SignoffHash = ""
+	data, err := json.Marshal(&s)
+	if err != nil {
+		return "", fmt.Errorf("review.signoffHash: %w", err)
+	}
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data)), nil
+}
+
+// Sign attaches a signoff to r, replacing any existing one.
+func Sign(r *Review, reviewer string, decision SignoffDecision, notes string, at time.Time) error {
+	if reviewer == "" {
+		return fmt.Errorf("review.Sign: reviewer is required")
+	}
+	if !decision.Valid() {
+		return fmt.Errorf("review.Sign: invalid decision %q", decision)
+	}
+	hash, err := ArtifactHash(r)
+	if err != nil {
+		return err
+	}
+	s := &Signoff{
+		Reviewer:     reviewer,
+		Decision:     decision,
+		Notes:        notes,
+		Timestamp:    at.UTC(),
+		ArtifactHash: hash,
+	}
+	if s.SignoffHash, err = signoffHash(*s); err != nil {
+		return err
+	}
+	r.Signoff = s
+	return nil
+}
+
+// VerifySignoff reports whether r carries a signoff whose hashes still
+// match the review content and the signoff itself. A signoff without a
+// SignoffHash is rejected, since its decision could have been edited.
+func VerifySignoff(r *Review) error {
+	if r.Signoff == nil {
+		return fmt.Errorf("review has no signoff")
+	}
+	hash, err := ArtifactHash(r)
+	if err != nil {
+		return err
+	}
+	if hash != r.Signoff.ArtifactHash {
+		return fmt.Errorf("artifact hash mismatch: signed %s, current %s", r.Signoff.ArtifactHash, hash)
+	}
+	if r.Signoff.SignoffHash == "" {
+		return fmt.Errorf("signoff has no signoff_hash; sign the review again")
+	}
+	hash, err = signoffHash(*r.Signoff)
+	if err != nil {
+		return err
+	}
+	if hash != r.Signoff.SignoffHash {
+		return fmt.Errorf("signoff hash mismatch: the reviewer, decision, notes, or timestamp changed after sign-off")
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package review
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	r := &Review{
+		Tool:    "plancritic",
+		Summary: Summary{Verdict: VerdictWithClarifications, Score: 93},
+		Issues:  []Issue{{ID: "ISSUE-0001", Severity: SeverityWarn, Title: "t"}},
+	}
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.FixedZone("x", 3600))
+	if err := Sign(r, "alice", SignoffApproved, "looks fine", at); err != nil {
+		t.Fatal(err)
+	}
+	if r.Signoff.Timestamp.Location() != time.UTC {
+		t.Error("expected UTC timestamp")
+	}
+	if err := VerifySignoff(r); err != nil {
+		t.Fatalf("VerifySignoff: %v", err)
+	}
+
+	// Re-signing is stable: the hash never includes the signoff itself.
+	first := r.Signoff.ArtifactHash
+	if err := Sign(r, "bob", SignoffRejected, "", at); err != nil {
+		t.Fatal(err)
+	}
+	if r.Signoff.ArtifactHash != first {
+		t.Error("artifact hash changed on re-sign")
+	}
+
+	r.Issues[0].Severity = SeverityInfo
+	if err := VerifySignoff(r); err == nil {
+		t.Error("expected mismatch after editing the review")
+	}
+}
+
+func TestVerifySignoffDetectsSignoffEdits(t *testing.T) {
+	for name, edit := range map[string]func(*Signoff){
+		"decision": func(s *Signoff) { s.Decision = SignoffApproved },
+		"reviewer": func(s *Signoff) { s.Reviewer = "mallory" },
+		"notes":    func(s *Signoff) { s.Notes = "" },
+		"missing":  func(s *Signoff) { s.SignoffHash = "" },
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := &Review{Tool: "plancritic", Summary: Summary{Verdict: VerdictNotExecutable, Score: 40}}
+			if err := Sign(r, "alice", SignoffRejected, "rollback is missing", time.Now()); err != nil {
+				t.Fatal(err)
+			}
+			edit(r.Signoff)
+			if err := VerifySignoff(r); err == nil {
+				t.Errorf("VerifySignoff passed after editing the signoff's %s", name)
+			}
+		})
+	}
+}
+
+func TestSignRejectsBadInput(t *testing.T) {
+	r := &Review{}
+	if err := Sign(r, "", SignoffApproved, "", time.Now()); err == nil {
+		t.Error("expected error for empty reviewer")
+	}
+	if err := Sign(r, "alice", SignoffDecision("MAYBE"), "", time.Now()); err == nil {
+		t.Error("expected error for invalid decision")
+	}
+	if err := VerifySignoff(r); err == nil {
+		t.Error("expected error for missing signoff")
+	}
+}
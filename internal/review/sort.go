@@ -1,35 +1,98 @@
 package review
 
-import "sort"
+import (
+	"cmp"
+	"slices"
+	"sort"
+)
 
-// SortIssues sorts issues by severity (CRITICAL > WARN > INFO),
-// then by first evidence line_start ascending.
+// SortIssues sorts issues by severity (CRITICAL > WARN > INFO), then by
+// the lowest line_start across all their evidence, then by category and
+// title so issues citing the same line keep a stable order across runs.
 func SortIssues(issues []Issue) {
 	sort.SliceStable(issues, func(i, j int) bool {
-		oi := issues[i].Severity.Order()
-		oj := issues[j].Severity.Order()
-		if oi != oj {
-			return oi < oj
+		a, b := issues[i], issues[j]
+		if c := cmp.Compare(a.Severity.Order(), b.Severity.Order()); c != 0 {
+			return c < 0
 		}
-		return firstLine(issues[i].Evidence) < firstLine(issues[j].Evidence)
+		if c := cmp.Compare(minLine(a.Evidence), minLine(b.Evidence)); c != 0 {
+			return c < 0
+		}
+		if c := cmp.Compare(a.Category, b.Category); c != 0 {
+			return c < 0
+		}
+		return a.Title < b.Title
 	})
 }
 
-// SortQuestions sorts questions by severity then by first evidence line_start.
+// SortQuestions sorts questions by severity, then by the lowest
+// line_start across all their evidence, then by question text.
 func SortQuestions(questions []Question) {
 	sort.SliceStable(questions, func(i, j int) bool {
-		oi := questions[i].Severity.Order()
-		oj := questions[j].Severity.Order()
-		if oi != oj {
-			return oi < oj
+		a, b := questions[i], questions[j]
+		if c := cmp.Compare(a.Severity.Order(), b.Severity.Order()); c != 0 {
+			return c < 0
+		}
+		if c := cmp.Compare(minLine(a.Evidence), minLine(b.Evidence)); c != 0 {
+			return c < 0
 		}
-		return firstLine(questions[i].Evidence) < firstLine(questions[j].Evidence)
+		return a.Question < b.Question
 	})
 }
 
-func firstLine(ev []Evidence) int {
+// minLine returns the lowest line_start in ev, or 0 when ev is empty.
+func minLine(ev []Evidence) int {
 	if len(ev) == 0 {
 		return 0
 	}
-	return ev[0].LineStart
+	m := ev[0].LineStart
+	for _, e := range ev[1:] {
+		m = min(m, e.LineStart)
+	}
+	return m
+}
+
+// SortEvidence orders ev in reading order — plan evidence first, then
+// context evidence by path, each by line range — and drops entries that
+// repeat the source, path, and range of an earlier one. The first quote
+// seen for a range is kept. ev is sorted in place; the deduplicated
+// slice is returned.
+func SortEvidence(ev []Evidence) []Evidence {
+	slices.SortStableFunc(ev, func(a, b Evidence) int {
+		if c := cmp.Compare(sourceRank(a.Source), sourceRank(b.Source)); c != 0 {
+			return c
+		}
+		return cmp.Or(
+			cmp.Compare(a.Path, b.Path),
+			cmp.Compare(a.LineStart, b.LineStart),
+			cmp.Compare(a.LineEnd, b.LineEnd),
+		)
+	})
+	return slices.CompactFunc(ev, func(a, b Evidence) bool {
+		return a.Source == b.Source && a.Path == b.Path && a.LineStart == b.LineStart && a.LineEnd == b.LineEnd
+	})
+}
+
+// sourceRank puts plan evidence before context evidence.
+func sourceRank(source string) int {
+	if source == "plan" {
+		return 0
+	}
+	return 1
+}
+
+// NormalizeEvidence applies SortEvidence to the evidence of every
+// issue, question, and checklist item in r.
+func NormalizeEvidence(r *Review) {
+	for i := range r.Issues {
+		r.Issues[i].Evidence = SortEvidence(r.Issues[i].Evidence)
+	}
+	for i := range r.Questions {
+		r.Questions[i].Evidence = SortEvidence(r.Questions[i].Evidence)
+	}
+	for i := range r.Checklists {
+		for j := range r.Checklists[i].Checks {
+			r.Checklists[i].Checks[j].Evidence = SortEvidence(r.Checklists[i].Checks[j].Evidence)
+		}
+	}
 }
@@ -1,5 +1,7 @@
 package review
 
+import "strings"
+
 // ComputeSummary derives the verdict, score, and severity counts from issues.
 func ComputeSummary(issues []Issue) Summary {
 	var crit, warn, info int
@@ -37,3 +39,53 @@ func ComputeSummary(issues []Issue) Summary {
 		InfoCount:     info,
 	}
 }
+
+// Ungrounded reports whether iss is tagged UNVERIFIED (see
+// ApplyGroundingDowngrades) or assumption, the tag the model gives an
+// uncertain inference.
+func Ungrounded(iss Issue) bool {
+	for _, tag := range iss.Tags {
+		if tag == "UNVERIFIED" || strings.EqualFold(tag, "assumption") {
+			return true
+		}
+	}
+	return false
+}
+
+// GroundedSummary is ComputeSummary over the issues that are not
+// Ungrounded: the verdict the plan gets on its evidence alone.
+func GroundedSummary(issues []Issue) Summary {
+	var grounded []Issue
+	for _, iss := range issues {
+		if !Ungrounded(iss) {
+			grounded = append(grounded, iss)
+		}
+	}
+	return ComputeSummary(grounded)
+}
+
+// ChecklistSummary is ComputeSummary for a checklist-mode review, which
+// has checklist results instead of free-form issues: each FAIL check
+// costs the score what a WARN issue would, and makes an otherwise
+// clean plan EXECUTABLE_WITH_CLARIFICATIONS. A FAIL of a required
+// check makes the plan NOT_EXECUTABLE, like a blocking CRITICAL issue.
+func ChecklistSummary(issues []Issue, checklists []Checklist) Summary {
+	s := ComputeSummary(issues)
+	requiredFailed := false
+	for _, cl := range checklists {
+		for _, c := range cl.Checks {
+			if c.Status == CheckStatusFail {
+				s.FailedChecks++
+				requiredFailed = requiredFailed || c.Required
+			}
+		}
+	}
+	s.Score = max(s.Score-7*s.FailedChecks, 0)
+	switch {
+	case requiredFailed:
+		s.Verdict = VerdictNotExecutable
+	case s.FailedChecks > 0 && s.Verdict == VerdictExecutable:
+		s.Verdict = VerdictWithClarifications
+	}
+	return s
+}
@@ -5,6 +5,7 @@ package review
 type Review struct {
 	Tool       string      `json:"tool"`
 	Version    string      `json:"version"`
+	Status     Status      `json:"status,omitempty"`
 	Input      Input       `json:"input"`
 	Summary    Summary     `json:"summary"`
 	Questions  []Question  `json:"questions"`
@@ -12,6 +13,25 @@ type Review struct {
 	Patches    []Patch     `json:"patches,omitempty"`
 	Checklists []Checklist `json:"checklists,omitempty"`
 	Meta       Meta        `json:"meta"`
+	Signoff    *Signoff    `json:"signoff,omitempty"`
+	// Suppressed lists findings hidden by active suppressions.
+	Suppressed []SuppressedFinding `json:"suppressed,omitempty"`
+	// Embedded holds snapshots of the plan and context files when the
+	// review was run with --embed-inputs.
+	Embedded []EmbeddedFile `json:"embedded_inputs,omitempty"`
+}
+
+// SuppressedFinding records a finding removed from the output by a
+// suppression entry, so the audit trail shows what was hidden and why.
+type SuppressedFinding struct {
+	Fingerprint string   `json:"fingerprint"`
+	ID          string   `json:"id"`
+	Severity    Severity `json:"severity"`
+	Title       string   `json:"title"`
+	Reason      string   `json:"reason,omitempty"`
+	// Until is the snooze expiry date (YYYY-MM-DD), empty for a
+	// permanent suppression.
+	Until string `json:"until,omitempty"`
 }
 
 // Input describes the files and settings used for the review.
@@ -21,12 +41,89 @@ type Input struct {
 	ContextFiles []ContextFile `json:"context_files,omitempty"`
 	Profile      string        `json:"profile,omitempty"`
 	Strict       bool          `json:"strict"`
+	// Language is the ISO 639-1 code detected from the plan text.
+	Language string `json:"language,omitempty"`
+	// PlanMeta is the plan's YAML front matter, if it has any.
+	PlanMeta *PlanMeta `json:"plan_meta,omitempty"`
+	// JiraIssue is the key of the Jira issue the plan was read from,
+	// when it was given as "jira:KEY"; PlanHash covers the issue and
+	// subtask text as reviewed.
+	JiraIssue string `json:"jira_issue,omitempty"`
+	// ConstraintOverrides are the key=value overrides of the profile's
+	// constraints the review was run with.
+	ConstraintOverrides []string `json:"constraint_overrides,omitempty"`
+	// DisabledCategories are the issue categories disabled for the
+	// review on the command line, besides those its profile disables.
+	DisabledCategories []string `json:"disabled_categories,omitempty"`
+	// Plans lists the other plan documents of a joint review, reviewed
+	// together with PlanFile; plan evidence names its document in Path.
+	Plans []PlanFile `json:"plans,omitempty"`
+	// Since is the git revision an incremental review compared the
+	// plan with; findings tagged "baseline" were carried forward from
+	// the review of that revision.
+	Since string `json:"since,omitempty"`
+	// Git records the git revision of the plan file, when it is in a
+	// git work tree, so the review can be traced to the exact text.
+	Git *GitProvenance `json:"git,omitempty"`
+}
+
+// GitProvenance is the git revision a plan file was reviewed at.
+type GitProvenance struct {
+	Commit string `json:"commit,omitempty"`
+	Branch string `json:"branch,omitempty"`
+	// Dirty reports that the plan file had uncommitted changes, or was
+	// untracked, so Commit does not hold the reviewed text.
+	Dirty bool `json:"dirty"`
+}
+
+// PlanMeta is the metadata a plan declares in YAML front matter.
+type PlanMeta struct {
+	Title      string   `json:"title,omitempty"`
+	Owner      string   `json:"owner,omitempty"`
+	TargetDate string   `json:"target_date,omitempty"`
+	Related    []string `json:"related,omitempty"`
+	// Classification is the plan's data classification, such as
+	// "confidential"; the config maps it to the providers allowed to
+	// see the plan.
+	Classification string `json:"classification,omitempty"`
+	// Providers restricts which providers may review the plan, as
+	// "provider" or "provider:model" entries (see reviewer policy).
+	Providers []string `json:"providers,omitempty"`
+	// OutOfScope lists what the plan declares it does not cover; see
+	// ApplyOutOfScope.
+	OutOfScope []string `json:"out_of_scope,omitempty"`
+	// Extra holds any other front matter keys.
+	Extra map[string]any `json:"extra,omitempty"`
+}
+
+// PlanFile records a plan document of a joint review and its hash.
+type PlanFile struct {
+	Path string `json:"path"`
+	Hash string `json:"hash"`
+}
+
+// CitesOtherPlan reports whether ev cites one of in.Plans rather than
+// the plan PlanFile names.
+func (in Input) CitesOtherPlan(ev Evidence) bool {
+	if ev.Source != "plan" || len(in.Plans) == 0 {
+		return false
+	}
+	names := make(map[string]bool, len(in.Plans)+1)
+	for _, p := range in.Plans {
+		names[p.Path] = true
+	}
+	names[in.PlanFile] = false
+	name, err := ResolveContextPath(names, ev.Path)
+	return err == nil && names[name]
 }
 
 // ContextFile records a context file path and its hash.
 type ContextFile struct {
 	Path string `json:"path"`
 	Hash string `json:"hash"`
+	// Section is the heading the context was pinned to with
+	// --context file.md#Heading; empty when the whole file was used.
+	Section string `json:"section,omitempty"`
 }
 
 // Summary holds the verdict, score, and severity counts.
@@ -36,20 +133,64 @@ type Summary struct {
 	CriticalCount int     `json:"critical_count"`
 	WarnCount     int     `json:"warn_count"`
 	InfoCount     int     `json:"info_count"`
+	// PlanMetrics is computed locally from the plan text, independent
+	// of the model's findings.
+	PlanMetrics *PlanMetrics `json:"plan_metrics,omitempty"`
+	// FailedChecks is the number of FAIL checklist items in a
+	// checklist-mode review (see ChecklistSummary).
+	FailedChecks int `json:"failed_checks,omitempty"`
+	// Grounded is the summary of the issues left when UNVERIFIED and
+	// assumption findings are excluded; set with --grounded-verdict.
+	Grounded *Summary `json:"grounded,omitempty"`
+}
+
+// PlanMetrics holds deterministic plan size and coverage metrics.
+// Coverage values are percentages of inferred steps.
+type PlanMetrics struct {
+	LineCount          int     `json:"line_count"`
+	StepCount          int     `json:"step_count"`
+	AvgStepWords       float64 `json:"avg_step_words"`
+	EstimateCoverage   float64 `json:"estimate_coverage_pct"`
+	AcceptanceCoverage float64 `json:"acceptance_coverage_pct"`
+	ContextReferences  int     `json:"context_references"`
+	// StepsWithoutAcceptance counts the steps whose sections have no
+	// acceptance criteria; Placeholders counts TODO, TBD, FIXME, and
+	// ??? markers in the plan body.
+	StepsWithoutAcceptance int `json:"steps_without_acceptance"`
+	Placeholders           int `json:"placeholders"`
+	// VaguePhrases counts the profile's ambiguity triggers in the plan
+	// body, and VagueDensity is that count per 100 body words.
+	VaguePhrases int     `json:"vague_phrases"`
+	VagueDensity float64 `json:"vague_per_100_words"`
 }
 
 // Issue represents a detected problem in the plan.
 type Issue struct {
-	ID             string   `json:"id"`
-	Severity       Severity `json:"severity"`
-	Category       Category `json:"category"`
-	Title          string   `json:"title"`
-	Description    string   `json:"description"`
+	ID             string     `json:"id"`
+	Severity       Severity   `json:"severity"`
+	Category       Category   `json:"category"`
+	Title          string     `json:"title"`
+	Description    string     `json:"description"`
 	Evidence       []Evidence `json:"evidence"`
-	Impact         string   `json:"impact"`
-	Recommendation string   `json:"recommendation"`
-	Blocking       bool     `json:"blocking"`
-	Tags           []string `json:"tags,omitempty"`
+	Impact         string     `json:"impact"`
+	Recommendation string     `json:"recommendation"`
+	Blocking       bool       `json:"blocking"`
+	Tags           []string   `json:"tags,omitempty"`
+	// Fingerprint is a stable identifier computed locally (see
+	// Fingerprint); it is not part of the model's output.
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// Permalink names the issue across output formats (see Permalink).
+	Permalink string `json:"permalink,omitempty"`
+	// Agreement is set on ensemble reviews.
+	Agreement *Agreement `json:"agreement,omitempty"`
+}
+
+// Agreement records which members of an ensemble review reported a
+// finding.
+type Agreement struct {
+	Count  int      `json:"count"`
+	Total  int      `json:"total"`
+	Models []string `json:"models"`
 }
 
 // Question represents an ambiguity that must be resolved.
@@ -61,6 +202,9 @@ type Question struct {
 	Blocks           []string   `json:"blocks,omitempty"`
 	Evidence         []Evidence `json:"evidence"`
 	SuggestedAnswers []string   `json:"suggested_answers,omitempty"`
+	Fingerprint      string     `json:"fingerprint,omitempty"`
+	Permalink        string     `json:"permalink,omitempty"`
+	Agreement        *Agreement `json:"agreement,omitempty"`
 }
 
 // Patch is an optional suggested edit to the plan text.
@@ -73,28 +217,65 @@ type Patch struct {
 
 // Checklist records the result of a profile checklist evaluation.
 type Checklist struct {
-	ID     string       `json:"id"`
-	Title  string       `json:"title"`
-	Checks []CheckItem  `json:"checks"`
+	ID     string      `json:"id"`
+	Title  string      `json:"title"`
+	Checks []CheckItem `json:"checks"`
 }
 
 // CheckItem is a single check within a checklist.
 type CheckItem struct {
 	Check  string      `json:"check"`
 	Status CheckStatus `json:"status"`
+	// Justification is the model's one-line reason for the status.
+	Justification string     `json:"justification,omitempty"`
+	Evidence      []Evidence `json:"evidence,omitempty"`
+	// ID, Reference, and Required are the profile check's, when it
+	// has them, so a failure can be traced to the policy behind it.
+	ID        string `json:"id,omitempty"`
+	Reference string `json:"reference,omitempty"`
+	Required  bool   `json:"required,omitempty"`
 }
 
 // Evidence references a specific location in the plan or context.
 type Evidence struct {
 	Source    string `json:"source"`
-	Path     string `json:"path"`
+	Path      string `json:"path"`
 	LineStart int    `json:"line_start"`
 	LineEnd   int    `json:"line_end"`
-	Quote    string `json:"quote"`
+	Quote     string `json:"quote"`
 }
 
 // Meta records the model and settings used for the review.
 type Meta struct {
 	Model       string  `json:"model"`
 	Temperature float64 `json:"temperature"`
+	Usage       *Usage  `json:"usage,omitempty"`
+	// SanityRetry is set when the first response had fewer findings
+	// than --min-findings-sanity and the model was asked again.
+	SanityRetry bool `json:"sanity_retry,omitempty"`
+	// SuspiciouslyEmpty is set when the review still has fewer findings
+	// than --min-findings-sanity after the retry: the model may have
+	// skimmed or quietly declined rather than found a sound plan.
+	SuspiciouslyEmpty bool `json:"suspiciously_empty,omitempty"`
+	// Chunks is the number of parts a plan too large for one prompt was
+	// reviewed in; zero when it was reviewed whole.
+	Chunks int `json:"chunks,omitempty"`
+	// Interrupted is set when the run received SIGINT or SIGTERM after
+	// the model had answered: the review was finished and written so
+	// the paid-for response is not lost.
+	Interrupted bool `json:"interrupted,omitempty"`
+	// Precheck is set when the plan was too thin to send to a model
+	// (empty, or no steps) and the review was made locally; see
+	// --force-review.
+	Precheck bool `json:"precheck,omitempty"`
+}
+
+// Usage records the tokens spent across every model call in a review.
+// EstimatedCostUSD is nil when the model has no known price.
+type Usage struct {
+	InputTokens      int      `json:"input_tokens"`
+	OutputTokens     int      `json:"output_tokens"`
+	CacheReadTokens  int      `json:"cache_read_tokens,omitempty"`
+	CacheWriteTokens int      `json:"cache_write_tokens,omitempty"`
+	EstimatedCostUSD *float64 `json:"estimated_cost_usd,omitempty"`
 }
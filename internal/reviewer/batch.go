@@ -0,0 +1,165 @@
+package reviewer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dshills/plancritic/internal/llm"
+	"github.com/dshills/plancritic/internal/review"
+)
+
+// BatchTicket records a submitted batch so a later run can collect it.
+type BatchTicket struct {
+	Provider  string      `json:"provider"`
+	Model     string      `json:"model,omitempty"`
+	BatchID   string      `json:"batch_id"`
+	Submitted time.Time   `json:"submitted"`
+	Plans     []BatchPlan `json:"plans"`
+}
+
+// BatchPlan is one plan in a batch. PlanHash is checked on collection,
+// since the findings cite lines of the plan as it was submitted.
+type BatchPlan struct {
+	ID       string `json:"id"`
+	Path     string `json:"path"`
+	PlanHash string `json:"plan_hash"`
+}
+
+// BatchReview is the review of one plan collected from a batch, or the
+// error that prevented it.
+type BatchReview struct {
+	Plan   BatchPlan
+	Review review.Review
+	Err    error
+}
+
+// batchProvider resolves the provider for a batch run and checks that
+// it has a batch API.
+func batchProvider(f Options) (llm.Provider, llm.BatchProvider, string, error) {
+	if len(f.Ensemble) > 0 {
+		return nil, nil, "", Errorf(3, "batch mode cannot be combined with --ensemble")
+	}
+	provider := f.Provider
+	if provider == nil {
+		var err error
+		provider, err = resolveProvider(f)
+		if err != nil {
+			return nil, nil, "", err
+		}
+	}
+	bp, model, ok := llm.AsBatchProvider(provider)
+	if !ok {
+		return nil, nil, "", Errorf(3, "provider %s has no batch API (use anthropic or openai)", provider.Name())
+	}
+	return provider, bp, model, nil
+}
+
+// SubmitBatch builds the review prompt for each plan, exactly as Run
+// would, and submits them together through the provider's batch API.
+// The returned ticket is what CollectBatch needs later.
+func SubmitBatch(ctx context.Context, planPaths []string, f Options) (*BatchTicket, error) {
+	f = expandAliases(f)
+	verbose := verboseLogger(f.Verbose)
+	provider, bp, model, err := batchProvider(f)
+	if err != nil {
+		return nil, err
+	}
+	f.Provider = provider
+
+	t := &BatchTicket{Provider: provider.Name(), Model: f.Model}
+	var reqs []llm.BatchRequest
+	for i, path := range planPaths {
+		r, err := prepare(path, f)
+		if err != nil {
+			return nil, err
+		}
+		if len(r.parts) > 0 {
+			return nil, Errorf(3, "%s must be reviewed in parts, which batch mode does not support; review it directly with --chunk", path)
+		}
+		// The settings a synchronous call would send (see call.run).
+		s := r.c.settings
+		segments := llm.AdaptPrompt(provider, r.c.segments)
+		s.System, _ = llm.SplitSystem(segments)
+		if model != "" {
+			s.Model = model
+		}
+		id := fmt.Sprintf("plan-%d", i+1)
+		reqs = append(reqs, llm.BatchRequest{ID: id, Segments: segments, Settings: s})
+		t.Plans = append(t.Plans, BatchPlan{ID: id, Path: path, PlanHash: r.p.Hash})
+	}
+
+	verbose("Submitting batch of %d plans to %s", len(reqs), provider.Name())
+	t.BatchID, err = bp.SubmitBatch(ctx, reqs)
+	if err != nil {
+		return nil, providerErrorf(err, "batch submission failed: %v", err)
+	}
+	t.Submitted = time.Now().UTC()
+	return t, nil
+}
+
+// CollectBatch fetches the results of a submitted batch and finishes
+// each plan's review as Run would; schema repairs are sent directly to
+// the provider. While the batch is still running it returns nil reviews
+// and the provider's state. The provider and model default to the
+// ticket's. A plan that fails to review is reported in its BatchReview
+// without failing the others.
+func CollectBatch(ctx context.Context, t *BatchTicket, f Options, version string) ([]BatchReview, string, error) {
+	f = expandAliases(f)
+	verbose := verboseLogger(f.Verbose)
+	if f.ProviderName == "" && f.Model == "" {
+		f.ProviderName, f.Model = t.Provider, t.Model
+	}
+	provider, bp, _, err := batchProvider(f)
+	if err != nil {
+		return nil, "", err
+	}
+	f.Provider = provider
+
+	st, err := bp.CollectBatch(ctx, t.BatchID)
+	if err != nil {
+		return nil, st.State, providerErrorf(err, "batch collection failed: %v", err)
+	}
+	if !st.Done {
+		verbose("Batch %s is %s", t.BatchID, st.State)
+		return nil, st.State, nil
+	}
+
+	reviews := make([]BatchReview, 0, len(t.Plans))
+	for _, bplan := range t.Plans {
+		rev, err := collectPlan(ctx, bplan, st.Results, f, version)
+		reviews = append(reviews, BatchReview{Plan: bplan, Review: rev, Err: err})
+	}
+	return reviews, st.State, nil
+}
+
+// collectPlan finishes the review of one plan from its batch result.
+func collectPlan(ctx context.Context, bplan BatchPlan, results map[string]llm.BatchResult, f Options, version string) (review.Review, error) {
+	r, err := prepare(bplan.Path, f)
+	if err != nil {
+		return review.Review{}, err
+	}
+	if r.p.Hash != bplan.PlanHash {
+		return review.Review{}, Errorf(3, "%s changed since the batch was submitted; submit it again", bplan.Path)
+	}
+	res, ok := results[bplan.ID]
+	switch {
+	case !ok:
+		return review.Review{}, Errorf(4, "batch has no result for %s", bplan.Path)
+	case errors.Is(res.Err, llm.ErrRefused):
+		return review.Review{}, providerErrorf(res.Err, "model refused to review the plan: %v", res.Err)
+	case res.Err != nil:
+		return review.Review{}, providerErrorf(res.Err, "batch request failed: %v", res.Err)
+	}
+	if r.modelProvider.Capabilities(r.c.settings.Model).Prefill {
+		res.Text = llm.RestorePrefill(res.Text, r.c.settings.Prefill)
+	}
+	r.c.prefetched = &res
+
+	out, err := r.c.run(ctx, r.modelProvider)
+	if err != nil {
+		return review.Review{}, err
+	}
+	return r.finish(ctx, f, outcome{rev: out.rev, res: out}, version)
+}
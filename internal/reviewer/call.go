@@ -0,0 +1,536 @@
+package reviewer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dshills/plancritic/internal/llm"
+	"github.com/dshills/plancritic/internal/prompt"
+	"github.com/dshills/plancritic/internal/respcache"
+	"github.com/dshills/plancritic/internal/review"
+	"github.com/dshills/plancritic/internal/schema"
+	"github.com/dshills/plancritic/internal/training"
+	"github.com/dshills/plancritic/internal/transcript"
+)
+
+// call holds what every provider request in a run shares: the prompt,
+// the request settings, and what validation needs to know about the
+// plan and context files.
+type call struct {
+	segments          []llm.Segment
+	settings          llm.Settings
+	timeout           time.Duration
+	planLines         int
+	contextLineCounts map[string]int
+	quoteSrc          review.QuoteSource
+	evidenceRules     schema.EvidenceRules
+	// planLineCounts is set for a joint review: every plan document's
+	// line count by name.
+	planLineCounts map[string]int
+	// categories are the custom issue categories of the profile.
+	categories []review.Category
+	// repairAttempts is Options.MaxRepairAttempts.
+	repairAttempts int
+	verbose        func(string, ...any)
+	// debugDir, when non-empty, receives a copy of each raw response.
+	debugDir string
+	// logDir, when non-empty, receives a transcript entry for every
+	// provider request.
+	logDir string
+	// cache, when non-nil, serves validated responses from disk and
+	// stores new ones.
+	cache *respcache.Cache
+	// model names the provider and model in cache keys and transcripts.
+	model string
+	// limiter bounds the requests in flight; it is shared with the
+	// ensemble members, the chunks, and other reviews of the process.
+	limiter *Limiter
+	// prefetched, when non-nil, is a response to the prompt already
+	// obtained through a provider's batch API. It replaces the first
+	// provider call; repairs are still sent directly.
+	prefetched *llm.BatchResult
+}
+
+// cacheKey hashes everything that determines the response: the model,
+// the request settings, and the prompt.
+func (c *call) cacheKey() string {
+	seed := "none"
+	if c.settings.Seed != nil {
+		seed = fmt.Sprint(*c.settings.Seed)
+	}
+	params := fmt.Sprintf("temperature=%g max_tokens=%d seed=%s", c.settings.Temperature, c.settings.MaxTokens, seed)
+	if c.settings.ReasoningEffort != "" {
+		params += " reasoning_effort=" + c.settings.ReasoningEffort
+	}
+	parts := []string{c.model, params}
+	for _, seg := range c.segments {
+		parts = append(parts, fmt.Sprintf("system=%t", seg.System), seg.Text)
+	}
+	return respcache.Key(parts...)
+}
+
+// cached returns the cached response for this call, if any.
+func (c *call) cached(ctx context.Context) (string, llm.Usage, bool) {
+	if c.cache == nil {
+		return "", llm.Usage{}, false
+	}
+	return c.cache.Get(ctx, c.cacheKey())
+}
+
+// errBudgetExpired is the cause of the run context's cancellation when
+// --max-duration expires. A call cut short by it returns the findings
+// that validated before the deadline alongside the error.
+var errBudgetExpired = errors.New("run budget (--max-duration) expired")
+
+// budgetExpired reports whether ctx was cancelled by the run budget.
+func budgetExpired(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errBudgetExpired)
+}
+
+// interrupted reports whether ctx was cancelled, rather than timed
+// out: the caller gave up on the run, as the CLI does on SIGINT or
+// SIGTERM.
+func interrupted(ctx context.Context) bool {
+	return errors.Is(ctx.Err(), context.Canceled)
+}
+
+// callResult is a validated review from one provider, with the raw
+// exchange kept for training data and the tokens spent on it.
+type callResult struct {
+	rev    review.Review
+	raw    string
+	repair *training.Exchange
+	usage  llm.Usage
+	cost   float64
+	priced bool
+}
+
+// addCost adds the estimated cost of r's usage on provider. The part
+// of the usage answered through a batch, batched, bills at
+// llm.BatchDiscount.
+func (r *callResult) addCost(provider llm.Provider, s llm.Settings, batched llm.Usage) {
+	r.cost, r.priced = llm.EstimateCost(provider, s, r.usage)
+	if batched != (llm.Usage{}) {
+		full, _ := llm.EstimateCost(provider, s, batched)
+		r.cost -= full * (1 - llm.BatchDiscount)
+	}
+}
+
+// validate checks rev against the schema and the profile's evidence
+// requirements.
+func (c *call) validate(rev *review.Review) []schema.ValidationError {
+	errs := c.validateSchema(rev)
+	return append(errs, schema.ValidateEvidenceRules(rev, c.evidenceRules, c.quoteSrc)...)
+}
+
+// validateSchema checks rev against the schema, with the profile's
+// custom categories, and the line counts of the plan and context files.
+func (c *call) validateSchema(rev *review.Review) []schema.ValidationError {
+	return schema.ValidateSources(rev, schema.Sources{PlanLines: c.planLines, Contexts: c.contextLineCounts, Plans: c.planLineCounts, Categories: c.categories})
+}
+
+// downgrade lowers the severity of the issues in rev that lack the
+// evidence the profile requires, when that is all that is wrong with
+// the latest response, and reports whether rev now validates. errs are
+// the latest validation errors and revErrs those of rev, which differ
+// when the latest response was not JSON.
+func (c *call) downgrade(rev *review.Review, errs, revErrs []schema.ValidationError) bool {
+	if !slices.Equal(errs, revErrs) || len(c.validateSchema(rev)) > 0 {
+		return false
+	}
+	n := schema.DowngradeUnsupported(rev, c.evidenceRules, c.quoteSrc)
+	if n == 0 || len(c.validate(rev)) > 0 {
+		return false
+	}
+	c.verbose("Lowered the severity of %d issues short of the profile's evidence minimum", n)
+	return true
+}
+
+// maxRepairs is the number of repair rounds allowed after a failed
+// validation.
+func (c *call) maxRepairs() int {
+	switch {
+	case c.repairAttempts < 0:
+		return 0
+	case c.repairAttempts == 0:
+		return 1
+	}
+	return c.repairAttempts
+}
+
+// appendNewErrors appends the errors in add that seen lacks.
+func appendNewErrors(seen, add []schema.ValidationError) []schema.ValidationError {
+	for _, e := range add {
+		if !slices.Contains(seen, e) {
+			seen = append(seen, e)
+		}
+	}
+	return seen
+}
+
+// run sends the prompt to provider, parses and validates the response,
+// makes repair attempts on schema errors, and reconstructs evidence
+// quotes. With a response cache, a cached response replaces the
+// provider call and a newly validated one is stored. Errors are *Error
+// values with the pipeline's exit codes.
+func (c *call) run(ctx context.Context, provider llm.Provider) (callResult, error) {
+	verbose := c.verbose
+
+	// The provider may adapt the prompt layout to its models (see
+	// llm.PromptAdapter). Instructions go in the system prompt and the
+	// plan/context in the user message. Segmented providers read the
+	// split from the segments; the rest get it through Settings.System.
+	// The repair call reuses the same system prompt.
+	settings := c.settings
+	segments := llm.AdaptPrompt(provider, c.segments)
+	systemText, userText := llm.SplitSystem(segments)
+	settings.System = systemText
+	var batched llm.Usage
+	// A hit reports the usage of the call that produced it, so an
+	// unchanged plan re-renders to the same review.
+	result, usage, hit := c.cached(ctx)
+	if hit {
+		verbose("Using cached response (%d bytes)", len(result))
+	} else if c.prefetched != nil {
+		result, usage = c.prefetched.Text, c.prefetched.Usage
+		batched = usage
+		verbose("Using batch response (%d bytes)", len(result))
+	} else {
+		var err error
+		result, usage, err = c.generate(ctx, provider, "review", settings, segments, userText)
+		if err != nil && budgetExpired(ctx) {
+			return callResult{}, errBudgetExpired
+		}
+		if errors.Is(err, llm.ErrRefused) {
+			return callResult{}, providerErrorf(err, "model refused to review the plan: %v", err)
+		}
+		if errors.Is(err, llm.ErrFiltered) {
+			return callResult{}, providerErrorf(err, "provider content filter blocked the review: %v", err)
+		}
+		if err != nil {
+			return callResult{}, providerErrorf(err, "LLM call failed: %v", timeoutHint(err, c.timeout))
+		}
+		verbose("Received LLM response (%d bytes)", len(result))
+		if usage.CacheReadInputTokens > 0 || usage.CacheCreationInputTokens > 0 {
+			verbose("Token usage: input=%d (cache read=%d, cache write=%d), output=%d",
+				usage.InputTokens, usage.CacheReadInputTokens, usage.CacheCreationInputTokens, usage.OutputTokens)
+		} else if usage.InputTokens > 0 {
+			verbose("Token usage: input=%d, output=%d", usage.InputTokens, usage.OutputTokens)
+		}
+	}
+	out := callResult{raw: result, usage: usage}
+
+	if c.debugDir != "" && !hit {
+		debugRespPath, err := writeDebugFile(c.debugDir, "plancritic-debug-response-*.txt", []byte(result))
+		if err != nil {
+			verbose("Warning: failed to write debug response: %v", err)
+		} else {
+			verbose("Wrote debug response to %s", debugRespPath)
+		}
+	}
+
+	// 9. Parse JSON
+	rev, result, err := parseReview(llm.ExtractJSON(result), verbose)
+	if err != nil && llm.LooksLikeRefusal(result) {
+		// Not a review at all, so neither a schema error nor anything
+		// a repair call could fix.
+		return callResult{}, &Error{Code: 4, Class: llm.ClassContentFilter, Msg: fmt.Sprintf("model refused to review the plan or returned no content: %q", excerpt(result, 200))}
+	}
+	if err != nil {
+		return callResult{}, Errorf(5, "failed to parse LLM response as JSON: %v", err)
+	}
+
+	// 10. Validate, then repair up to maxRepairs times. Each round
+	// sends the latest output with its errors, plus errors fixed in
+	// earlier rounds so the model does not reintroduce them.
+	validationErrs := c.validate(&rev)
+	partial, partialErrs := rev, validationErrs
+	var seen []schema.ValidationError
+	maxRepairs := c.maxRepairs()
+	for attempt := 1; len(validationErrs) > 0; attempt++ {
+		if attempt > maxRepairs && c.downgrade(&rev, validationErrs, partialErrs) {
+			fmt.Fprintf(os.Stderr, "plancritic: warning: issues still lacked the evidence the profile requires after %d repair attempts; lowered their severity\n", maxRepairs)
+			data, err := json.Marshal(rev)
+			if err != nil {
+				return callResult{}, Errorf(5, "failed to encode the downgraded review: %v", err)
+			}
+			result = string(data)
+			break
+		}
+		if attempt > maxRepairs {
+			fmt.Fprintf(os.Stderr, "Schema validation errors after %d repair attempts:\n", maxRepairs)
+			for _, e := range validationErrs {
+				fmt.Fprintf(os.Stderr, "  %s\n", e)
+			}
+			return callResult{}, Errorf(5, "LLM output failed schema validation after %d repair attempts", maxRepairs)
+		}
+		verbose("Validation failed (%d errors), repair attempt %d of %d...", len(validationErrs), attempt, maxRepairs)
+
+		repairPrompt := prompt.BuildRepairAttempt(result, validationErrs, seen, c.categories...)
+		repairResult, repairUsage, err := c.generate(ctx, provider, "repair", settings, nil, repairPrompt)
+		out.usage = out.usage.Add(repairUsage)
+		if err != nil && budgetExpired(ctx) {
+			// Keep the findings from the latest parsed response that
+			// passed validation on their own.
+			partial = validatedSubset(partial, partialErrs)
+			review.ReconstructQuotes(&partial, c.quoteSrc)
+			out.rev = partial
+			out.addCost(provider, settings, batched)
+			return out, errBudgetExpired
+		}
+		if err != nil {
+			return callResult{}, providerErrorf(err, "repair LLM call failed: %v", timeoutHint(err, c.timeout))
+		}
+		if repairUsage.InputTokens > 0 {
+			verbose("Repair token usage: input=%d, output=%d", repairUsage.InputTokens, repairUsage.OutputTokens)
+		}
+		out.repair = &training.Exchange{Prompt: repairPrompt, Response: repairResult}
+		seen = appendNewErrors(seen, validationErrs)
+		rev2, text, err := parseReview(llm.ExtractJSON(repairResult), verbose)
+		result = text
+		if err != nil {
+			validationErrs = []schema.ValidationError{{Path: "$", Message: fmt.Sprintf("not valid JSON: %v", err)}}
+			continue
+		}
+		rev = rev2
+		validationErrs = c.validate(&rev)
+		partial, partialErrs = rev, validationErrs
+	}
+	verbose("Validation passed")
+
+	// Cache the validated JSON rather than the raw response so a hit
+	// never needs a repair call.
+	if c.cache != nil && !hit {
+		if err := c.cache.Put(ctx, c.cacheKey(), c.model, result, out.usage); err != nil {
+			verbose("Warning: failed to cache response: %v", err)
+		}
+	}
+
+	// 10b. Reconstruct evidence quotes from cited line ranges. The LLM
+	// is instructed to omit the quote field to save output tokens; any
+	// quote it still emits is overwritten from the authoritative source.
+	if misses := review.ReconstructQuotes(&rev, c.quoteSrc); misses > 0 {
+		verbose("Quote reconstruction: %d evidence entries could not be resolved to a source", misses)
+	}
+
+	out.rev = rev
+	out.addCost(provider, settings, batched)
+	return out, nil
+}
+
+// maxContinuations is how many times a response cut off at the output
+// token limit is continued before the call fails.
+const maxContinuations = 3
+
+// generate sends one prompt to provider, as segments when they are
+// given and the provider takes them, and logs each exchange. A response
+// cut off at the output token limit is continued: when the request was
+// prefilled and the provider supports it, the partial text is prefilled
+// to resume from; otherwise (including under Options.NoPrefill) the
+// prompt is sent again with the partial response and the model is asked
+// for the remainder. The usage covers every request made.
+func (c *call) generate(ctx context.Context, provider llm.Provider, kind string, settings llm.Settings, segments []llm.Segment, userText string) (string, llm.Usage, error) {
+	result, usage, err := c.request(ctx, provider, kind, settings, segments, userText)
+	for n := 1; n <= maxContinuations && errors.Is(err, llm.ErrTruncated) && strings.TrimSpace(result) != ""; n++ {
+		if budgetExpired(ctx) {
+			break
+		}
+		c.verbose("Response truncated at %d bytes, continuation %d of %d...", len(result), n, maxContinuations)
+		cs, segs, text := settings, segments, userText
+		prefill := settings.Prefill != "" && provider.Capabilities(settings.Model).Prefill
+		if prefill {
+			// The provider returns the prefill with the new text.
+			// Anthropic rejects a prefill ending in whitespace.
+			cs.Prefill = strings.TrimRight(result, " \t\r\n")
+		} else {
+			// The prompt changes, so a provider-side context cache of
+			// the original prompt no longer applies.
+			cs.CachedContentName = ""
+			segs, text = nil, prompt.BuildContinuation(userText, result)
+		}
+		more, moreUsage, moreErr := c.request(ctx, provider, "continuation", cs, segs, text)
+		usage = usage.Add(moreUsage)
+		if moreErr != nil && !errors.Is(moreErr, llm.ErrTruncated) {
+			// Keep the truncation error: it says what went wrong first.
+			break
+		}
+		if prefill {
+			result = more
+		} else {
+			result = llm.StitchContinuation(result, more)
+		}
+		err = moreErr
+	}
+	return result, usage, err
+}
+
+// maxProviderRetries is how many times a request that failed with a
+// retryable error (rate limit, transient) is sent again.
+const maxProviderRetries = 2
+
+// providerRetryDelay is the base backoff between retries; it doubles
+// each time. Tests shorten it.
+var providerRetryDelay = 2 * time.Second
+
+// request sends one prompt to provider and logs the exchange. Requests
+// that fail with a retryable error (see llm.ErrorClass) are retried
+// with backoff while the run budget allows.
+func (c *call) request(ctx context.Context, provider llm.Provider, kind string, s llm.Settings, segments []llm.Segment, userText string) (string, llm.Usage, error) {
+	var usage llm.Usage
+	delay := providerRetryDelay
+	for n := 0; ; n++ {
+		// A request that cannot get a slot before the context ends
+		// still runs, and fails (and is logged) with the context's error.
+		release, err := c.limiter.acquire(ctx, provider)
+		if err != nil {
+			release = func() {}
+		}
+		start := time.Now()
+		result, u, err := request(ctx, provider, s, segments, userText)
+		release()
+		c.logExchange(kind, s, userText, result, u, start, err, interrupted(ctx))
+		usage = usage.Add(u)
+		class := llm.Classify(err)
+		if n == maxProviderRetries || !class.Retryable() || ctx.Err() != nil {
+			return result, usage, err
+		}
+		c.verbose("%s request failed (%s), retry %d of %d in %s: %v", kind, class, n+1, maxProviderRetries, delay, err)
+		select {
+		case <-ctx.Done():
+			return result, usage, err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// request sends one prompt to provider, as segments when they are given
+// and the provider takes them.
+func request(ctx context.Context, provider llm.Provider, s llm.Settings, segments []llm.Segment, userText string) (string, llm.Usage, error) {
+	if sp, ok := provider.(llm.SegmentedProvider); ok && segments != nil {
+		return sp.GenerateSegments(ctx, segments, s)
+	}
+	return provider.Generate(ctx, userText, s)
+}
+
+// parseReview unmarshals an LLM response. When that fails it retries
+// with invalid escape sequences sanitized (common with Gemini), then
+// with local syntax repairs (see llm.RepairJSON), so trivial slips do
+// not cost a repair call. It returns the text that parsed, or text
+// itself on error.
+func parseReview(text string, verbose func(string, ...any)) (review.Review, string, error) {
+	var rev review.Review
+	err := json.Unmarshal([]byte(text), &rev)
+	if err == nil {
+		return rev, text, nil
+	}
+	// Each retry gets a fresh Review so partial fields from a failed
+	// unmarshal don't bleed into its result.
+	sanitized := llm.SanitizeJSON(text)
+	var rev2 review.Review
+	if json.Unmarshal([]byte(sanitized), &rev2) == nil {
+		verbose("Sanitized invalid JSON escape sequences")
+		return rev2, sanitized, nil
+	}
+	repaired := llm.SanitizeJSON(llm.RepairJSON(text))
+	var rev3 review.Review
+	err3 := json.Unmarshal([]byte(repaired), &rev3)
+	if err3 == nil {
+		verbose("Repaired JSON syntax locally")
+		return rev3, repaired, nil
+	}
+	return review.Review{}, text, fmt.Errorf("%v (before local repair: %v)", err3, err)
+}
+
+// logExchange writes a transcript entry for one provider request when
+// --log-llm is set. A request that failed because the run was
+// interrupted is logged as cancelled. Failures to log are reported but
+// never fail the run.
+func (c *call) logExchange(kind string, s llm.Settings, prompt, response string, usage llm.Usage, start time.Time, err error, cancelled bool) {
+	if c.logDir == "" {
+		return
+	}
+	e := transcript.Entry{
+		Time:  start,
+		Kind:  kind,
+		Model: c.model,
+		Settings: transcript.Settings{
+			Temperature:       s.Temperature,
+			MaxTokens:         s.MaxTokens,
+			Seed:              s.Seed,
+			Timeout:           c.timeout.String(),
+			Prefill:           s.Prefill,
+			CachedContentName: s.CachedContentName,
+		},
+		System:    s.System,
+		Prompt:    prompt,
+		Response:  response,
+		LatencyMS: time.Since(start).Milliseconds(),
+		Status:    transcript.StatusOK,
+		Usage: transcript.Usage{
+			InputTokens:              usage.InputTokens,
+			OutputTokens:             usage.OutputTokens,
+			CacheCreationInputTokens: usage.CacheCreationInputTokens,
+			CacheReadInputTokens:     usage.CacheReadInputTokens,
+		},
+	}
+	if err != nil {
+		e.Status = transcript.StatusError
+		if cancelled {
+			e.Status = transcript.StatusCancelled
+		}
+		e.Error = err.Error()
+	}
+	path, werr := transcript.Write(c.logDir, e)
+	if werr != nil {
+		fmt.Fprintf(os.Stderr, "plancritic: warning: failed to write LLM transcript: %v\n", werr)
+		return
+	}
+	c.verbose("Logged %s exchange to %s", kind, path)
+}
+
+// validatedSubset returns rev without the issues, questions, and
+// patches that errs reports problems with.
+func validatedSubset(rev review.Review, errs []schema.ValidationError) review.Review {
+	bad := map[string]bool{}
+	for _, e := range errs {
+		if i := strings.Index(e.Path, "]"); i >= 0 {
+			bad[e.Path[:i+1]] = true
+		}
+	}
+	issues := []review.Issue{}
+	for i, iss := range rev.Issues {
+		if !bad[fmt.Sprintf("issues[%d]", i)] {
+			issues = append(issues, iss)
+		}
+	}
+	questions := []review.Question{}
+	for i, q := range rev.Questions {
+		if !bad[fmt.Sprintf("questions[%d]", i)] {
+			questions = append(questions, q)
+		}
+	}
+	var patches []review.Patch
+	for i, p := range rev.Patches {
+		if !bad[fmt.Sprintf("patches[%d]", i)] {
+			patches = append(patches, p)
+		}
+	}
+	rev.Issues, rev.Questions, rev.Patches = issues, questions, patches
+	return rev
+}
+
+// excerpt returns the first n bytes of text, trimmed, for messages.
+func excerpt(text string, n int) string {
+	text = strings.TrimSpace(text)
+	if len(text) <= n {
+		return text
+	}
+	return text[:n] + "..."
+}
@@ -0,0 +1,125 @@
+package reviewer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dshills/plancritic/internal/llm"
+	"github.com/dshills/plancritic/internal/review"
+)
+
+func runWithProvider(t *testing.T, p llm.Provider) error {
+	t.Helper()
+	planPath := filepath.Join(t.TempDir(), "plan.md")
+	if err := os.WriteFile(planPath, []byte("# Plan\n\n1. Ship it\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Run(context.Background(), planPath, Options{
+		ProfileName:       "general",
+		SeverityThreshold: "info",
+		RedactEnabled:     true,
+		NoCache:           true,
+		Provider:          p,
+	}, "test")
+	return err
+}
+
+// prefillMock is a mock that claims prefill support.
+type prefillMock struct {
+	*llm.MockProvider
+}
+
+func (prefillMock) Capabilities(string) llm.Capabilities {
+	return llm.Capabilities{Prefill: true}
+}
+
+func TestContinuationWithoutPrefill(t *testing.T) {
+	issues := []review.Issue{{
+		ID: "ISSUE-0001", Severity: review.SeverityWarn, Category: review.CategoryAmbiguity,
+		Title: "Test issue", Description: "d", Impact: "i", Recommendation: "r",
+		Evidence: []review.Evidence{{Source: "plan", Path: "plan.md", LineStart: 3, LineEnd: 3, Quote: "1. Ship it"}},
+	}}
+	data, err := json.Marshal(review.Review{Issues: issues, Questions: []review.Question{}, Summary: review.ComputeSummary(issues)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, cut := string(data), len(data)/2
+	mock := &llm.MockProvider{Steps: []llm.MockStep{
+		{Response: full[:cut], Err: fmt.Errorf("mock: %w", llm.ErrTruncated), Times: 1},
+		{Response: full[cut:], Times: 1},
+	}}
+	_, err = Run(context.Background(), "plan.md", Options{
+		ProfileName:       "general",
+		SeverityThreshold: "info",
+		NoCache:           true,
+		NoPrefill:         true,
+		PlanText:          "# Plan\n\n1. Ship it\n",
+		Provider:          prefillMock{mock},
+	}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prompts := mock.Prompts()
+	if len(prompts) != 2 || !strings.Contains(prompts[1], "## Partial Response") {
+		t.Errorf("calls = %d, want a re-prompt carrying the partial response", len(prompts))
+	}
+}
+
+func TestProviderErrorRetried(t *testing.T) {
+	defer func(d time.Duration) { providerRetryDelay = d }(providerRetryDelay)
+	providerRetryDelay = 0
+
+	mock := &llm.MockProvider{Err: &llm.APIError{Provider: "mock", StatusCode: http.StatusServiceUnavailable}}
+	err := runWithProvider(t, mock)
+	var re *Error
+	if !errors.As(err, &re) {
+		t.Fatalf("error = %v, want *Error", err)
+	}
+	if re.Code != 7 || re.Class != llm.ClassTransient {
+		t.Errorf("code, class = %d, %q, want 7, transient", re.Code, re.Class)
+	}
+	if n := len(mock.Prompts()); n != maxProviderRetries+1 {
+		t.Errorf("calls = %d, want %d", n, maxProviderRetries+1)
+	}
+}
+
+func TestProviderErrorNotRetried(t *testing.T) {
+	defer func(d time.Duration) { providerRetryDelay = d }(providerRetryDelay)
+	providerRetryDelay = 0
+
+	mock := &llm.MockProvider{Err: &llm.APIError{Provider: "mock", StatusCode: http.StatusUnauthorized}}
+	err := runWithProvider(t, mock)
+	var re *Error
+	if !errors.As(err, &re) {
+		t.Fatalf("error = %v, want *Error", err)
+	}
+	if re.Code != 4 || re.Class != llm.ClassAuth {
+		t.Errorf("code, class = %d, %q, want 4, auth", re.Code, re.Class)
+	}
+	if n := len(mock.Prompts()); n != 1 {
+		t.Errorf("calls = %d, want 1", n)
+	}
+}
+
+func TestProviderErrorRecovers(t *testing.T) {
+	defer func(d time.Duration) { providerRetryDelay = d }(providerRetryDelay)
+	providerRetryDelay = 0
+
+	mock := &llm.MockProvider{Steps: []llm.MockStep{
+		{Err: &llm.APIError{Provider: "mock", StatusCode: http.StatusTooManyRequests}, Times: 1},
+		{Response: "not json"},
+	}}
+	err := runWithProvider(t, mock)
+	var re *Error
+	if !errors.As(err, &re) || re.Code != 5 {
+		t.Fatalf("error = %v, want a schema error after the retry succeeds", err)
+	}
+}
@@ -0,0 +1,99 @@
+package reviewer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dshills/plancritic/internal/llm"
+	"github.com/dshills/plancritic/internal/plan"
+	"github.com/dshills/plancritic/internal/prompt"
+	"github.com/dshills/plancritic/internal/review"
+)
+
+// minChunkTokens is the least room for plan lines a chunk's prompt must
+// leave; below it the plan would be cut into uselessly small parts.
+const minChunkTokens = 500
+
+// splitPlan splits the plan in opts into chunks whose prompts fit in
+// maxTokens estimated tokens.
+func splitPlan(opts prompt.BuildOpts, maxTokens int) ([]prompt.Chunk, error) {
+	// The prompt with no plan lines but every step in the outline
+	// bounds what each chunk's prompt adds to its own lines.
+	bare := opts
+	bare.Plan = &plan.Plan{FilePath: opts.Plan.FilePath, Meta: opts.Plan.Meta, BodyStart: 1}
+	bare.StepIDs = nil
+	bare.Chunk = &prompt.Chunk{Index: 1, Total: 1, Outline: opts.StepIDs}
+	overhead := llm.CountTokens(prompt.Build(bare))
+	room := maxTokens - overhead
+	if room < minChunkTokens {
+		return nil, Errorf(3, "an input budget of %d tokens leaves no room to review the plan in parts: the instructions, context, and plan outline alone take ~%d tokens. Reduce context or raise --max-input-tokens",
+			maxTokens, overhead)
+	}
+	return prompt.SplitPlan(opts.Plan, opts.StepIDs, room), nil
+}
+
+// chunkCalls derives from c the call that reviews each chunk: the same
+// settings and context, with the chunk's prompt and plan lines.
+func chunkCalls(c *call, chunks []prompt.Chunk, opts prompt.BuildOpts, noCache bool) []*call {
+	var parts []*call
+	for i := range chunks {
+		ch := &chunks[i]
+		co := opts
+		co.Plan, co.StepIDs, co.Chunk = ch.Plan, ch.StepIDs, ch
+		segments := prompt.BuildSegments(co)
+		if noCache {
+			for j := range segments {
+				segments[j].CacheMark = false
+			}
+		}
+		pc := *c
+		pc.segments = segments
+		pc.planLines = len(ch.Plan.Lines)
+		pc.quoteSrc.PlanLines = ch.Plan.Lines
+		label := fmt.Sprintf("[part %d/%d] ", ch.Index, ch.Total)
+		pc.verbose = func(msg string, args ...any) { c.verbose(label+msg, args...) }
+		parts = append(parts, &pc)
+	}
+	return parts
+}
+
+// runChunks reviews each chunk of the plan in turn, with the single
+// provider or the ensemble, and merges the chunks' findings at the full
+// plan's line numbers. Usage and cost are summed over every chunk; the
+// raw exchange is the first chunk's. When the run budget expires, the
+// chunks reviewed so far are merged and errBudgetExpired is returned
+// alongside the review.
+func (r *prepared) runChunks(ctx context.Context, minAgreement int) (review.Review, callResult, error) {
+	var reviews []review.Review
+	var total callResult
+	total.priced = true
+	for i, pc := range r.parts {
+		ch := r.chunks[i]
+		r.verbose("Reviewing part %d of %d (plan lines %d-%d)", ch.Index, ch.Total, ch.Start, ch.End)
+		var rev review.Review
+		var res callResult
+		var err error
+		if len(r.members) > 0 {
+			rev, res, err = runEnsemble(ctx, pc, r.members, minAgreement)
+		} else {
+			res, err = pc.run(ctx, r.modelProvider)
+			rev = res.rev
+		}
+		total.usage = total.usage.Add(res.usage)
+		total.cost += res.cost
+		total.priced = total.priced && (res.priced || res.usage == llm.Usage{})
+		if i == 0 {
+			total.raw, total.repair = res.raw, res.repair
+		}
+		if err != nil && !errors.Is(err, errBudgetExpired) {
+			return review.Review{}, callResult{}, err
+		}
+		ch.Shift(&rev)
+		reviews = append(reviews, rev)
+		if err != nil {
+			return prompt.MergeChunks(reviews), total, err
+		}
+	}
+	return prompt.MergeChunks(reviews), total, nil
+}
@@ -0,0 +1,83 @@
+package reviewer
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/dshills/plancritic/internal/llm"
+)
+
+// hostedConcurrency is the default number of calls run at once against
+// a hosted provider: enough to overlap slow responses, few enough to
+// stay under per-key rate limits.
+const hostedConcurrency = 4
+
+// DefaultConcurrency returns how many calls to provider run at once
+// when Options.Concurrency is 0. A local server answers one request at
+// a time, so its calls are not overlapped.
+func DefaultConcurrency(provider string) int {
+	if strings.EqualFold(provider, "local") {
+		return 1
+	}
+	return hostedConcurrency
+}
+
+// Concurrency returns how many plans a run of f reviews at once:
+// f.Concurrency when set, else the default of the provider f selects.
+func Concurrency(f Options) int {
+	if f.Concurrency > 0 {
+		return f.Concurrency
+	}
+	f = expandAliases(f)
+	if len(f.Ensemble) > 0 {
+		return hostedConcurrency
+	}
+	provider := f.ProviderName
+	if provider == "" {
+		provider, _, _ = strings.Cut(f.Model, ":")
+	}
+	return DefaultConcurrency(provider)
+}
+
+// Limiter bounds the provider requests in flight across every review
+// that shares it: the plans of a multi-plan check, ensemble members, and
+// chunks. With a limit of n > 0 every request shares one bound of n;
+// with 0, each provider has its own bound of DefaultConcurrency.
+type Limiter struct {
+	n    int
+	mu   sync.Mutex
+	sems map[string]chan struct{}
+}
+
+// NewLimiter returns a Limiter for Options.Limiter; n is as for
+// Options.Concurrency. Create one per process and share it.
+func NewLimiter(n int) *Limiter {
+	return &Limiter{n: n, sems: map[string]chan struct{}{}}
+}
+
+// acquire waits for a slot for a request to p and returns the function
+// that frees it, or the context's error. A nil Limiter does not limit.
+func (l *Limiter) acquire(ctx context.Context, p llm.Provider) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	key, size := "", l.n
+	if size <= 0 {
+		key = llm.Unwrap(p).Name()
+		size = DefaultConcurrency(key)
+	}
+	l.mu.Lock()
+	sem, ok := l.sems[key]
+	if !ok {
+		sem = make(chan struct{}, size)
+		l.sems[key] = sem
+	}
+	l.mu.Unlock()
+	select {
+	case sem <- struct{}{}:
+		return func() { <-sem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
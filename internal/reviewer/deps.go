@@ -0,0 +1,132 @@
+package reviewer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dshills/plancritic/internal/plan"
+	"github.com/dshills/plancritic/internal/review"
+)
+
+// dependencyTag marks the issues dependencyIssues finds without the
+// model.
+const dependencyTag = "dependency-graph"
+
+// dependencyIssues checks the plan's "depends on step N" graph and
+// returns an ORDERING_DEPENDENCY issue for each dependency cycle
+// (CRITICAL: no order executes the steps), each dependency on a later
+// step, and each dependency on a step the plan does not have (WARN).
+// The issues are numbered ISSUE-DEP-001 on, in plan order.
+func dependencyIssues(p *plan.Plan) []review.Issue {
+	g := plan.Dependencies(p)
+	if len(g.Deps) == 0 {
+		return nil
+	}
+	quote := func(line int) review.Evidence {
+		return review.Evidence{Source: "plan", Path: filepath.Base(p.FilePath), LineStart: line, LineEnd: line, Quote: strings.TrimSpace(p.Lines[line-1])}
+	}
+	var issues []review.Issue
+	add := func(iss review.Issue) {
+		iss.ID = fmt.Sprintf("ISSUE-DEP-%03d", len(issues)+1)
+		iss.Category = review.CategoryOrderingDependency
+		iss.Tags = []string{dependencyTag}
+		issues = append(issues, iss)
+	}
+
+	for _, cycle := range g.Cycles() {
+		in := map[int]bool{}
+		names := make([]string, len(cycle))
+		for i, n := range cycle {
+			in[n] = true
+			names[i] = strconv.Itoa(n)
+		}
+		var ev []review.Evidence
+		for _, d := range g.Deps {
+			if in[d.Step] && in[d.On] {
+				ev = append(ev, quote(d.Line))
+			}
+		}
+		iss := review.Issue{
+			Severity:       review.SeverityCritical,
+			Evidence:       ev,
+			Impact:         "No order of execution satisfies these dependencies; an implementer has to guess which one to break.",
+			Recommendation: "Decide which step really comes first and remove or reword the dependency that contradicts it.",
+			Blocking:       true,
+		}
+		if len(cycle) == 1 {
+			iss.Title = fmt.Sprintf("Step %d depends on itself", cycle[0])
+			iss.Description = fmt.Sprintf("Step %d lists itself as a prerequisite.", cycle[0])
+		} else {
+			iss.Title = "Dependency cycle between steps " + joinSteps(names)
+			iss.Description = fmt.Sprintf("Steps %s each depend, directly or through one another, on the others, so none of them can start first.", joinSteps(names))
+		}
+		add(iss)
+	}
+
+	for _, d := range g.ForwardRefs() {
+		on, _ := g.Step(d.On)
+		add(review.Issue{
+			Severity:       review.SeverityWarn,
+			Title:          fmt.Sprintf("Step %d depends on later step %d", d.Step, d.On),
+			Description:    fmt.Sprintf("Step %d says %q, but step %d comes after it in the plan.", d.Step, d.Phrase, d.On),
+			Evidence:       []review.Evidence{quote(d.Line), quote(on.Line)},
+			Impact:         "Executing the plan top to bottom starts a step before its prerequisite is done.",
+			Recommendation: fmt.Sprintf("Move step %d before step %d, or correct the dependency.", d.On, d.Step),
+		})
+	}
+	for _, d := range g.MissingRefs() {
+		add(review.Issue{
+			Severity:       review.SeverityWarn,
+			Title:          fmt.Sprintf("Step %d depends on missing step %d", d.Step, d.On),
+			Description:    fmt.Sprintf("Step %d says %q, but the plan has no step %d.", d.Step, d.Phrase, d.On),
+			Evidence:       []review.Evidence{quote(d.Line)},
+			Impact:         "The prerequisite cannot be found, so it may be skipped or the steps were renumbered without updating the reference.",
+			Recommendation: "Point the dependency at the step that exists, or add the missing step.",
+		})
+	}
+	return issues
+}
+
+// joinSteps joins step numbers as "2, 4, and 5".
+func joinSteps(names []string) string {
+	switch len(names) {
+	case 0:
+		return ""
+	case 1:
+		return names[0]
+	case 2:
+		return names[0] + " and " + names[1]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + ", and " + names[len(names)-1]
+}
+
+// addDependencyIssues adds the dependency graph's issues to rev, except
+// where the model already raised an ORDERING_DEPENDENCY issue citing
+// the same plan lines.
+func addDependencyIssues(rev *review.Review, issues []review.Issue) int {
+	cited := map[int]bool{}
+	for _, iss := range rev.Issues {
+		if iss.Category != review.CategoryOrderingDependency {
+			continue
+		}
+		for _, e := range iss.Evidence {
+			if e.Source != "plan" {
+				continue
+			}
+			for l := e.LineStart; l <= e.LineEnd; l++ {
+				cited[l] = true
+			}
+		}
+	}
+	added := 0
+	for _, iss := range issues {
+		if cited[iss.Evidence[0].LineStart] {
+			continue
+		}
+		rev.Issues = append(rev.Issues, iss)
+		added++
+	}
+	return added
+}
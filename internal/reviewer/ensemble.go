@@ -0,0 +1,130 @@
+package reviewer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/dshills/plancritic/internal/ensemble"
+	"github.com/dshills/plancritic/internal/llm"
+	"github.com/dshills/plancritic/internal/review"
+)
+
+type ensembleMember struct {
+	name     string
+	provider llm.Provider
+}
+
+// resolveEnsemble resolves each --ensemble model spec to a provider.
+func resolveEnsemble(f Options) ([]ensembleMember, error) {
+	if len(f.Ensemble) < 2 {
+		return nil, Errorf(3, "--ensemble needs at least two models, got %d", len(f.Ensemble))
+	}
+	if f.MinAgreement > len(f.Ensemble) {
+		return nil, Errorf(3, "--min-agreement %d exceeds the %d ensemble models", f.MinAgreement, len(f.Ensemble))
+	}
+	transport, err := llm.NewTransport(llm.TransportOptions{Proxy: f.Proxy, CACertFile: f.CACertFile})
+	if err != nil {
+		return nil, Errorf(3, "invalid network settings: %v", err)
+	}
+	seen := map[string]bool{}
+	members := make([]ensembleMember, 0, len(f.Ensemble))
+	for _, spec := range f.Ensemble {
+		spec = strings.TrimSpace(spec)
+		if spec == "" || seen[spec] {
+			return nil, Errorf(3, "invalid --ensemble: empty or duplicate model %q", spec)
+		}
+		seen[spec] = true
+		p, err := llm.ResolveProviderWith("", spec, llm.ProviderOptions{
+			APIBase:   f.APIBase,
+			Transport: transport,
+			Keys:      f.APIKeys,
+		})
+		if err != nil {
+			return nil, Errorf(4, "model provider error for %s: %v", spec, err)
+		}
+		members = append(members, ensembleMember{name: spec, provider: p})
+	}
+	return members, nil
+}
+
+// runEnsemble sends the prompt to the members concurrently, as many
+// requests at a time as c's Limiter allows, and merges the validated
+// reviews. A failed member is reported on stderr and left out of the
+// agreement totals; the run fails only if every member fails. The
+// returned callResult is the first successful member's, for training
+// data, with usage and cost summed over every member. When the run
+// budget expires, members that answered in time are merged (with any
+// partial results) and errBudgetExpired is returned alongside the merged
+// review.
+func runEnsemble(ctx context.Context, c *call, members []ensembleMember, minAgreement int) (review.Review, callResult, error) {
+	results := make([]callResult, len(members))
+	errs := make([]error, len(members))
+	var wg sync.WaitGroup
+	for i, m := range members {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mc := *c
+			mc.settings.Model = ""
+			mc.model = m.name
+			mc.verbose = func(msg string, args ...any) { c.verbose("["+m.name+"] "+msg, args...) }
+			results[i], errs[i] = mc.run(ctx, m.provider)
+		}()
+	}
+	wg.Wait()
+
+	var merged []ensemble.Member
+	var first *callResult
+	var firstErr error
+	var total callResult
+	total.priced = true
+	expired := false
+	for i, m := range members {
+		total.usage = total.usage.Add(results[i].usage)
+		total.cost += results[i].cost
+		total.priced = total.priced && (results[i].priced || results[i].usage == llm.Usage{})
+		if errors.Is(errs[i], errBudgetExpired) {
+			expired = true
+			if results[i].raw != "" {
+				merged = append(merged, ensemble.Member{Name: m.name, Review: results[i].rev})
+			}
+			continue
+		}
+		if errs[i] != nil {
+			fmt.Fprintf(os.Stderr, "plancritic: warning: ensemble member %s failed: %v\n", m.name, errs[i])
+			if firstErr == nil {
+				firstErr = errs[i]
+			}
+			continue
+		}
+		if first == nil {
+			first = &results[i]
+		}
+		merged = append(merged, ensemble.Member{Name: m.name, Review: results[i].rev})
+	}
+	if len(merged) == 0 && expired {
+		return review.Review{Issues: []review.Issue{}, Questions: []review.Question{}}, total, errBudgetExpired
+	}
+	if len(merged) == 0 {
+		var re *Error
+		if errors.As(firstErr, &re) {
+			return review.Review{}, callResult{}, Errorf(re.Code, "all ensemble members failed; first error: %s", re.Msg)
+		}
+		return review.Review{}, callResult{}, providerErrorf(firstErr, "all ensemble members failed: %v", firstErr)
+	}
+
+	rev := ensemble.Merge(merged)
+	ensemble.FilterByAgreement(&rev, minAgreement)
+	res := total
+	if first != nil {
+		res.raw, res.repair = first.raw, first.repair
+	}
+	if expired {
+		return rev, res, errBudgetExpired
+	}
+	return rev, res, nil
+}
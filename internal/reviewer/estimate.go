@@ -0,0 +1,71 @@
+package reviewer
+
+import (
+	"github.com/dshills/plancritic/internal/llm"
+)
+
+// Estimate is what a review would send and cost, worked out without
+// calling any model.
+type Estimate struct {
+	// PromptChars and PromptTokens measure the prompt for the whole
+	// plan, or the sum over the parts of a chunked review.
+	PromptChars  int `json:"prompt_chars"`
+	PromptTokens int `json:"prompt_tokens"`
+	// Parts is the number of chunks the plan is reviewed in; 1 when it
+	// fits in a single prompt.
+	Parts int `json:"parts"`
+	// MaxOutputTokens is the response budget per request.
+	MaxOutputTokens int `json:"max_output_tokens"`
+	// Models has one entry per model the review calls: the single
+	// provider, or each ensemble member.
+	Models []ModelEstimate `json:"models"`
+}
+
+// ModelEstimate is the estimated cost of one model's share of a review.
+type ModelEstimate struct {
+	Model        string `json:"model"`
+	InputTokens  int    `json:"input_tokens"`
+	OutputTokens int    `json:"max_output_tokens"`
+	// CostUSD is the cost if every response uses its full budget, so an
+	// upper bound in practice; nil when the model has no known price.
+	CostUSD *float64 `json:"estimated_cost_usd,omitempty"`
+}
+
+// EstimateRun loads the inputs and builds the prompt for a review of
+// planPath as Run would, and estimates its size and cost per model
+// without calling the LLM. Prompt caching is not credited, since a
+// first run pays for it in full.
+func EstimateRun(planPath string, f Options) (Estimate, error) {
+	f = expandAliases(f)
+	r, err := prepare(planPath, f)
+	if err != nil {
+		return Estimate{}, err
+	}
+	calls := r.parts
+	if len(calls) == 0 {
+		calls = []*call{r.c}
+	}
+	est := Estimate{Parts: len(calls), MaxOutputTokens: r.c.settings.MaxTokens}
+	for _, c := range calls {
+		text := llm.ConcatSegments(c.segments)
+		est.PromptChars += len(text)
+		est.PromptTokens += llm.CountTokens(text)
+	}
+	u := llm.Usage{InputTokens: est.PromptTokens, OutputTokens: est.Parts * est.MaxOutputTokens}
+	add := func(name string, p llm.Provider, settings llm.Settings) {
+		m := ModelEstimate{Model: name, InputTokens: u.InputTokens, OutputTokens: u.OutputTokens}
+		if cost, ok := llm.EstimateCost(p, settings, u); ok {
+			m.CostUSD = &cost
+		}
+		est.Models = append(est.Models, m)
+	}
+	if len(r.members) == 0 {
+		add(providerLabel(r.modelProvider, f.Model), r.modelProvider, r.c.settings)
+	}
+	for _, m := range r.members {
+		settings := r.c.settings
+		settings.Model = ""
+		add(m.name, m.provider, settings)
+	}
+	return est, nil
+}
@@ -0,0 +1,68 @@
+package reviewer
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	pctx "github.com/dshills/plancritic/internal/context"
+	"github.com/dshills/plancritic/internal/fetch"
+)
+
+// DefaultMaxInputBytes bounds each plan and context document when
+// Options.MaxInputBytes is 0. Plans are a few KiB; a document this
+// large is a log, a dump, or the wrong file, and would not fit a
+// model's context anyway.
+const DefaultMaxInputBytes = 2 << 20
+
+// maxInputBytes returns the document size limit f sets.
+func maxInputBytes(f Options) int64 {
+	if f.MaxInputBytes > 0 {
+		return f.MaxInputBytes
+	}
+	return DefaultMaxInputBytes
+}
+
+// checkInput applies the checks fetch.Read makes of a file to a
+// document given as content.
+func checkInput(name, text string, f Options) error {
+	if limit := maxInputBytes(f); int64(len(text)) > limit {
+		return fmt.Errorf("%s is %d bytes, over the %d byte limit: %w", name, len(text), limit, fetch.ErrTooLarge)
+	}
+	return fetch.CheckText(name, []byte(text))
+}
+
+// inputHint suggests the way past a document the loader refused.
+func inputHint(err error) string {
+	if errors.Is(err, fetch.ErrTooLarge) {
+		return " (raise the limit with --max-input-bytes)"
+	}
+	return ""
+}
+
+// expandContextDirs appends the files found in dirs to paths, skipping
+// files already given.
+func expandContextDirs(paths, dirs []string, verbose func(string, ...any)) ([]string, error) {
+	if len(dirs) == 0 {
+		return paths, nil
+	}
+	seen := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		seen[filepath.Clean(p)] = true
+	}
+	out := append([]string(nil), paths...)
+	for _, dir := range dirs {
+		found, err := pctx.Dir(dir)
+		if err != nil {
+			return nil, Errorf(3, "failed to read context directory %s: %v", dir, err)
+		}
+		verbose("Found %d context files in %s", len(found), dir)
+		for _, p := range found {
+			if !seen[filepath.Clean(p)] {
+				seen[filepath.Clean(p)] = true
+				out = append(out, p)
+			}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,60 @@
+package reviewer
+
+import (
+	"path/filepath"
+
+	"github.com/dshills/plancritic/internal/fetch"
+	"github.com/dshills/plancritic/internal/plan"
+)
+
+// loadJointPlans loads the other plan documents of a joint review. The
+// prompt and evidence name every document by its base name, so two
+// documents may not share one.
+func loadJointPlans(p *plan.Plan, paths []string, o fetch.Options, verbose func(string, ...any)) ([]*plan.Plan, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	seen := map[string]string{filepath.Base(p.FilePath): p.FilePath}
+	var joint []*plan.Plan
+	for _, path := range paths {
+		verbose("Loading joint plan: %s", path)
+		jp, err := plan.LoadWith(path, o)
+		if err != nil {
+			return nil, Errorf(3, "failed to load plan %s: %v%s", path, err, inputHint(err))
+		}
+		name := filepath.Base(jp.FilePath)
+		if prev, ok := seen[name]; ok {
+			return nil, Errorf(3, "plans %s and %s are both named %s; rename one to review them together", prev, jp.FilePath, name)
+		}
+		seen[name] = jp.FilePath
+		joint = append(joint, jp)
+	}
+	verbose("Reviewing %d plan documents together", len(joint)+1)
+	return joint, nil
+}
+
+// jointLines maps every plan document of a joint review to its lines,
+// or is nil for a single plan.
+func jointLines(p *plan.Plan, joint []*plan.Plan) map[string][]string {
+	if len(joint) == 0 {
+		return nil
+	}
+	m := map[string][]string{filepath.Base(p.FilePath): p.Lines}
+	for _, jp := range joint {
+		m[filepath.Base(jp.FilePath)] = jp.Lines
+	}
+	return m
+}
+
+// jointLineCounts maps every plan document of a joint review to its
+// line count, or is nil for a single plan.
+func jointLineCounts(p *plan.Plan, joint []*plan.Plan) map[string]int {
+	if len(joint) == 0 {
+		return nil
+	}
+	m := map[string]int{filepath.Base(p.FilePath): len(p.Lines)}
+	for _, jp := range joint {
+		m[filepath.Base(jp.FilePath)] = len(jp.Lines)
+	}
+	return m
+}
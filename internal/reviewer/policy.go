@@ -0,0 +1,119 @@
+package reviewer
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	pctx "github.com/dshills/plancritic/internal/context"
+	"github.com/dshills/plancritic/internal/llm"
+	"github.com/dshills/plancritic/internal/plan"
+	"github.com/dshills/plancritic/internal/profile"
+	"github.com/dshills/plancritic/internal/review"
+)
+
+// providerRule is one source of a restriction on which providers may
+// see a plan, for error messages.
+type providerRule struct {
+	source  string
+	allowed []string
+}
+
+// providerRules collects the provider restrictions declared by the
+// front matter of the plan, each joint plan, and each context file, and
+// by the profile: an explicit provider list, or a data classification
+// that classifications maps to one. A classification with no entry in
+// classifications is an error, so a document marked confidential is
+// never sent anywhere because a policy is missing.
+func providerRules(p *plan.Plan, joint []*plan.Plan, contexts []*pctx.File, prof *profile.Profile, classifications map[string][]string) ([]providerRule, error) {
+	var rules []providerRule
+	classify := func(source, class string) error {
+		if class == "" {
+			return nil
+		}
+		allowed, ok := classifications[strings.ToLower(class)]
+		if !ok {
+			return Errorf(3, "%s declares data classification %q, but no config file defines it under classifications; add the providers it allows", source, class)
+		}
+		rules = append(rules, providerRule{source: fmt.Sprintf("%s (classification %s)", source, class), allowed: allowed})
+		return nil
+	}
+	declare := func(source string, m *review.PlanMeta) error {
+		if m == nil {
+			return nil
+		}
+		if err := classify(source, m.Classification); err != nil {
+			return err
+		}
+		if len(m.Providers) > 0 {
+			rules = append(rules, providerRule{source: source, allowed: m.Providers})
+		}
+		return nil
+	}
+	if err := declare("the plan", p.Meta); err != nil {
+		return nil, err
+	}
+	for _, jp := range joint {
+		if err := declare("joint plan "+jp.FilePath, jp.Meta); err != nil {
+			return nil, err
+		}
+	}
+	for _, cf := range contexts {
+		meta, _ := plan.ParseFrontMatter(cf.Raw)
+		if err := declare("context "+cf.FilePath, meta); err != nil {
+			return nil, err
+		}
+	}
+	profileName := fmt.Sprintf("profile %s", prof.Name)
+	if err := classify(profileName, prof.Classification); err != nil {
+		return nil, err
+	}
+	if len(prof.AllowedProviders) > 0 {
+		rules = append(rules, providerRule{source: profileName, allowed: prof.AllowedProviders})
+	}
+	return rules, nil
+}
+
+// checkProviders fails when a provider the review would call is not
+// allowed by every rule. label names the provider in the error; model
+// is the model requested of it.
+func checkProviders(rules []providerRule, label string, p llm.Provider, model string) error {
+	name := p.Name()
+	model = llm.EffectiveModel(p, model)
+	for _, r := range rules {
+		if !providerAllowed(r.allowed, name, model) {
+			return Errorf(3, "%s may only be reviewed with %s; %s is not allowed", r.source, strings.Join(r.allowed, ", "), label)
+		}
+	}
+	return nil
+}
+
+// providerAllowed reports whether any entry in allowed matches the
+// provider and model. An entry is a provider name, which allows all of
+// its models, or "provider:model" where model may use path.Match
+// wildcards, e.g. "anthropic:claude-haiku-*".
+func providerAllowed(allowed []string, name, model string) bool {
+	for _, entry := range allowed {
+		prov, pattern, hasModel := strings.Cut(strings.TrimSpace(entry), ":")
+		if !strings.EqualFold(prov, name) {
+			continue
+		}
+		if !hasModel {
+			return true
+		}
+		if ok, err := path.Match(strings.ToLower(pattern), strings.ToLower(model)); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// providerLabel names a provider and the model it serves for model, as
+// "provider/model", or the provider alone when the model is unknown.
+func providerLabel(p llm.Provider, model string) string {
+	name := p.Name()
+	if m := llm.EffectiveModel(p, model); m != "" {
+		name += "/" + m
+	}
+	return name
+}
@@ -0,0 +1,61 @@
+package reviewer
+
+import (
+	"strings"
+
+	"github.com/dshills/plancritic/internal/plan"
+	"github.com/dshills/plancritic/internal/review"
+)
+
+// minProseWords is the fewest words a plan with no list items needs to
+// be worth a model call: a short paragraph, not a title and a sentence.
+const minProseWords = 40
+
+// precheck looks for a plan too thin to review: one with no text
+// besides its headings, or with no list items and less than a short
+// paragraph of prose. It returns the CRITICAL issue that makes the
+// review NOT_EXECUTABLE without asking the model, or false when the
+// plan is worth reviewing.
+func precheck(p *plan.Plan) (review.Issue, bool) {
+	start := min(max(p.BodyStart, 1), len(p.Lines))
+	words, items := 0, 0
+	last := start
+	for _, s := range plan.InferStepIDs(p) {
+		if !strings.HasPrefix(strings.TrimSpace(p.Lines[s.LineStart-1]), "#") {
+			items++
+		}
+	}
+	for i := start - 1; i < len(p.Lines); i++ {
+		line := strings.TrimSpace(p.Lines[i])
+		if line == "" {
+			continue
+		}
+		last = i + 1
+		if !strings.HasPrefix(line, "#") {
+			words += len(strings.Fields(line))
+		}
+	}
+
+	iss := review.Issue{
+		ID:       "ISSUE-0001",
+		Severity: review.SeverityCritical,
+		Category: review.CategoryAmbiguity,
+		Evidence: []review.Evidence{{Source: "plan", LineStart: start, LineEnd: last}},
+		Impact:   "There is nothing concrete to execute or review; an implementer would have to invent the plan.",
+		Blocking: true,
+		Tags:     []string{"precheck"},
+	}
+	switch {
+	case words == 0:
+		iss.Title = "Plan is empty"
+		iss.Description = "The plan has no content besides its headings."
+		iss.Recommendation = "Write out the steps the work takes, with acceptance criteria, before requesting a review."
+	case items == 0 && words < minProseWords:
+		iss.Title = "Plan has no actionable steps"
+		iss.Description = "The plan is a short note with no list of steps."
+		iss.Recommendation = "List the steps the work takes, one per item, with what done looks like for each."
+	default:
+		return review.Issue{}, false
+	}
+	return iss, true
+}
@@ -0,0 +1,26 @@
+package reviewer
+
+import (
+	"errors"
+
+	"github.com/dshills/plancritic/internal/fetch"
+	"github.com/dshills/plancritic/internal/git"
+	"github.com/dshills/plancritic/internal/review"
+)
+
+// planProvenance returns the git revision of the plan file, or nil for
+// plan content, a remote plan, or a file outside a git work tree.
+func planProvenance(planPath string, f Options, verbose func(string, ...any)) *review.GitProvenance {
+	if f.PlanText != "" || fetch.IsRemote(planPath) {
+		return nil
+	}
+	pv, err := git.Describe(planPath)
+	if err != nil {
+		if !errors.Is(err, git.ErrNotRepo) {
+			// Provenance is informational; never fail the review for it.
+			verbose("Warning: git provenance not recorded: %v", err)
+		}
+		return nil
+	}
+	return &review.GitProvenance{Commit: pv.Commit, Branch: pv.Branch, Dirty: pv.Dirty}
+}
@@ -4,37 +4,126 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/dshills/plancritic/internal/cachestore"
 	pctx "github.com/dshills/plancritic/internal/context"
+	"github.com/dshills/plancritic/internal/fetch"
+	"github.com/dshills/plancritic/internal/git"
+	"github.com/dshills/plancritic/internal/history"
+	"github.com/dshills/plancritic/internal/hook"
 	"github.com/dshills/plancritic/internal/llm"
+	"github.com/dshills/plancritic/internal/patch"
 	"github.com/dshills/plancritic/internal/plan"
 	"github.com/dshills/plancritic/internal/profile"
 	"github.com/dshills/plancritic/internal/prompt"
 	"github.com/dshills/plancritic/internal/redact"
+	"github.com/dshills/plancritic/internal/respcache"
 	"github.com/dshills/plancritic/internal/review"
 	"github.com/dshills/plancritic/internal/schema"
+	"github.com/dshills/plancritic/internal/storage"
+	"github.com/dshills/plancritic/internal/suppress"
+	"github.com/dshills/plancritic/internal/training"
 )
 
 type Options struct {
-	Format            string
-	Out               string
-	ContextPaths      []string
-	ProfileName       string
-	Strict            bool
-	ProviderName      string
-	Model             string
-	MaxTokens         int
-	MaxIssues         int
-	MaxQuestions      int
-	MaxInputTokens    int
+	Format       string
+	Out          string
+	ContextPaths []string
+	// ContextDirs are walked for Markdown and text context files (see
+	// pctx.Dir), loaded after ContextPaths.
+	ContextDirs  []string
+	ProfileName  string
+	Strict       bool
+	ProviderName string
+	// APIBase is the server URL for the local provider
+	// (OpenAI-compatible, e.g. llama.cpp server or LM Studio).
+	APIBase string
+	// Endpoint replaces the API root of a hosted provider, e.g. an LLM
+	// gateway (see llm.ProviderOptions).
+	Endpoint string
+	// Proxy is an explicit outbound proxy URL for provider requests;
+	// HTTPS_PROXY is honored when empty.
+	Proxy string
+	// CACertFile is an extra PEM CA bundle for provider TLS.
+	CACertFile string
+	// APIKeys are read for provider API keys before the environment.
+	APIKeys llm.KeySources
+	// PlanText, when non-empty, is the plan content; the plan path Run
+	// is given then only names the plan and is not read.
+	PlanText string
+	// ContextDocuments are context files given as content, reviewed
+	// after those in ContextPaths.
+	ContextDocuments []ContextDocument
+	// JointPlans are further plan documents (a design doc next to a
+	// rollout plan) reviewed together with the plan as one joint
+	// review: the model is asked for the contradictions between them,
+	// and plan evidence names the document it cites. The plan keeps
+	// its role for the hash, metrics, stamp, and patches.
+	JointPlans []string
+	// Since makes the review incremental: the plan file is compared
+	// with its content at this git revision, and the model is asked to
+	// focus on what changed.
+	Since string
+	// BaselinePath is the review of the plan as of Since. Its findings
+	// whose plan evidence is unchanged are carried forward, tagged
+	// "baseline", and the model is asked not to repeat them.
+	BaselinePath string
+	// Constraints are key=value overrides of the profile's
+	// constraints (see profile.ParseConstraint).
+	Constraints []string
+	// DisableCategories are issue categories to drop, besides those
+	// the profile disables (see profile.DisableCategories).
+	DisableCategories []string
+	// URLHeaders are sent when the plan or a context path is an http(s)
+	// URL, e.g. Authorization for a private wiki.
+	URLHeaders http.Header
+	// GitHubLinked also reads the issues a GitHub issue or pull request
+	// plan or context closes (see fetch.GitHubIssue).
+	GitHubLinked bool
+	// MaxInputBytes bounds the size of each plan and context file or
+	// URL; 0 means DefaultMaxInputBytes.
+	MaxInputBytes int64
+	// ReadOnly forbids filesystem access beyond loading configuration:
+	// the plan and context must be given as content, options that
+	// write files (Debug, PatchOut, LogLLMDir, an fs response cache)
+	// are input errors, and the Gemini context cache, whose handles are
+	// kept on disk, is skipped. Only built-in profiles are loaded.
+	// For shared, locked-down deployments.
+	ReadOnly bool
+	// SuppressionsPath is the suppression file to apply; empty disables
+	// suppressions. A missing file is not an error.
+	SuppressionsPath string
+	// ExpireQuestionsAfter turns a CRITICAL question still unanswered
+	// this many plan revisions after it was first asked into a CRITICAL
+	// AMBIGUITY issue; 0 disables the policy. Revisions are tracked in
+	// QuestionHistoryPath.
+	ExpireQuestionsAfter int
+	// QuestionHistoryPath is the question history file read and
+	// updated when ExpireQuestionsAfter is set. With a shared
+	// StorageBackend the history is kept there instead.
+	QuestionHistoryPath string
+	// TrainingDataDir, when set, appends a redacted prompt/response/
+	// triage record for this run (see package training). It requires
+	// RedactEnabled. Callers are responsible for obtaining consent.
+	TrainingDataDir string
+	Model           string
+	MaxTokens       int
+	MaxIssues       int
+	MaxQuestions    int
+	MaxInputTokens  int
+	// Chunk reviews a plan whose prompt would exceed MaxInputTokens in
+	// step-aligned parts that fit (see prompt.SplitPlan) instead of
+	// failing, and merges their findings.
+	Chunk             bool
 	Timeout           string
 	Temperature       float64
 	Seed              int
@@ -49,61 +138,447 @@ type Options struct {
 	Debug             bool
 	DebugDir          string
 	Provider          llm.Provider
+	// Language selects the language of the model's findings: "" for
+	// the default (English), "auto" to follow the detected plan
+	// language, or an ISO 639-1 code.
+	Language string
+	// Ensemble lists model specs (as accepted by --model, e.g.
+	// "anthropic:claude-sonnet-4-6") to run concurrently instead of a
+	// single provider; findings are merged by fingerprint and carry an
+	// agreement record. Provider, ProviderName, and Model are ignored.
+	Ensemble []string
+	// Aliases maps short model names (e.g. "fast") to model specs, as
+	// the config's aliases section does; Model and each Ensemble entry
+	// are expanded before use.
+	Aliases map[string]string
+	// MinAgreement drops ensemble findings reported by fewer members.
+	MinAgreement int
+	// Concurrency bounds the provider calls and plans run at once; 0
+	// uses DefaultConcurrency per provider.
+	Concurrency int
+	// Limiter, when set, bounds this review's provider requests
+	// together with those of every other review given the same
+	// Limiter; Concurrency is then ignored. Nil limits this review
+	// alone, to Concurrency.
+	Limiter *Limiter
+	// PostProcessors run in order on the finished review; the summary
+	// is recomputed and the result re-validated afterwards.
+	PostProcessors []hook.PostProcessor
+	// ResponseCache serves validated responses from the on-disk
+	// response cache (see package respcache) and stores new ones, so an
+	// unchanged prompt needs no provider call. NoCache overrides it.
+	ResponseCache bool
+	// ResponseCacheTTL is the maximum age of a cached response; empty
+	// means 24h.
+	ResponseCacheTTL string
+	// ResponseCacheDir overrides respcache.DefaultDir.
+	ResponseCacheDir string
+	// LogLLMDir, when set, receives a timestamped JSON transcript of
+	// every provider request and response, including repairs (see
+	// package transcript).
+	LogLLMDir string
+	// MaxDuration bounds the whole run's model calls, including repairs
+	// (e.g. "2m"); empty means no limit. On expiry the review holds the
+	// findings that validated in time and has Status INCOMPLETE.
+	MaxDuration string
+	// MaxRepairAttempts is the number of repair rounds after a response
+	// fails validation. Zero means the default of one; negative
+	// disables repair.
+	MaxRepairAttempts int
+	// NoPrefill stops the pipeline from prefilling the model's turn
+	// with "{", for models that reject assistant prefill.
+	NoPrefill bool
+	// StorageBackend selects where persistent state (the response
+	// cache and the question history) lives: "fs" (the default, local
+	// files), "redis", "s3", or "sqlite" (see package storage).
+	StorageBackend string
+	// StorageURL locates a redis or s3 storage backend, or is the
+	// sqlite database file.
+	StorageURL string
+	// ReasoningEffort is passed to OpenAI reasoning models (see
+	// llm.Settings.ReasoningEffort); empty uses the model's default.
+	ReasoningEffort string
+	// MinFindingsSanity, when positive, treats a single-model review
+	// with fewer issues and questions than this, for a plan whose
+	// metrics show gaps, as suspicious: the model is asked once more
+	// with a second-look prompt, and a review still short of the
+	// minimum is flagged in Meta.SuspiciouslyEmpty.
+	MinFindingsSanity int
+	// ForceReview sends a plan to the model even when it fails the
+	// local pre-check for an empty or stub plan (see precheck).
+	ForceReview bool
+	// GroundedVerdict adds Summary.Grounded: the verdict with
+	// UNVERIFIED and assumption findings left out.
+	GroundedVerdict bool
+	// Mode is ModeFull (the default when empty) for a full critique,
+	// or ModeChecklist to evaluate only the profile checklists.
+	Mode string
+	// EmbedInputs stores the plan and context contents, as sent to the
+	// model, in the review (see review.EmbeddedFile).
+	EmbedInputs bool
+	// Classifications maps the data classifications plans and profiles
+	// may declare to the providers allowed to review them (see
+	// config.Config.Classifications).
+	Classifications map[string][]string
+}
+
+// Review modes (see Options.Mode).
+const (
+	ModeFull = "full"
+	// ModeChecklist asks the model only for the profile checklists,
+	// each check PASS, FAIL, or N/A with a justification and evidence:
+	// a cheaper, faster gate than the full critique. The verdict comes
+	// from the failed checks (see review.ChecklistSummary).
+	ModeChecklist = "checklist"
+)
+
+// prepared is a review set up to the point of the model call: the
+// loaded inputs, the resolved provider, and the call that carries the
+// prompt.
+type prepared struct {
+	planPath      string
+	p             *plan.Plan
+	joint         []*plan.Plan
+	since         *incremental
+	contexts      []*pctx.File
+	metrics       review.PlanMetrics
+	detectedLang  string
+	modelProvider llm.Provider
+	members       []ensembleMember
+	timeout       time.Duration
+	budget        time.Duration
+	maxIssues     int
+	maxQuestions  int
+	promptText    string
+	outOfScope    []review.Exclusion
+	// bounds are the profile's severity bounds by category.
+	bounds map[review.Category]review.SeverityBounds
+	// disabled are the categories whose findings are dropped.
+	disabled []review.Category
+	// fabrication are the profile's fabrication phrases, checked in
+	// strict mode with the built-in ones.
+	fabrication []string
+	// checklists are the profile's, which checklist results are
+	// traced to (see traceChecks).
+	checklists []profile.Checklist
+	// store is the shared storage backend, or nil when state is kept
+	// in local files.
+	store storage.Backend
+	// deps are the ORDERING_DEPENDENCY issues the plan's step
+	// dependency graph shows without the model.
+	deps []review.Issue
+	c    *call
+	// chunks and parts are set when the plan is reviewed in chunks:
+	// parts[i] is the call that reviews chunks[i].
+	chunks  []prompt.Chunk
+	parts   []*call
+	verbose func(string, ...any)
+}
+
+// outcome is what the model calls produced for a prepared review.
+type outcome struct {
+	rev         review.Review
+	res         callResult
+	incomplete  bool
+	sanityRetry bool
+	suspicious  bool
+	// precheck marks a review made locally for a stub plan; no model
+	// was called.
+	precheck bool
 }
 
 func Run(parentCtx context.Context, planPath string, f Options, version string) (review.Review, error) {
+	f = expandAliases(f)
+	r, err := prepare(planPath, f)
+	if err != nil {
+		return review.Review{}, err
+	}
+	c, members, modelProvider, verbose := r.c, r.members, r.modelProvider, r.verbose
+
+	ctx := parentCtx
+	// A stub plan gets a deterministic NOT_EXECUTABLE review rather than
+	// a paid model call. In a joint review the steps may all be in the
+	// other documents, so the plan alone is not judged.
+	if iss, stub := precheck(r.p); stub && !f.ForceReview && len(r.joint) == 0 {
+		fmt.Fprintf(os.Stderr, "plancritic: warning: %s; skipped the model call (--force-review reviews it anyway)\n", strings.ToLower(iss.Title))
+		rev := review.Review{Issues: []review.Issue{iss}, Questions: []review.Question{}}
+		review.ReconstructQuotes(&rev, c.quoteSrc)
+		return r.finish(ctx, f, outcome{rev: rev, precheck: true}, version)
+	}
+
+	// llmCtx carries the run budget. It covers every model call but not
+	// the local steps after them, so an expired budget still yields a
+	// written review.
+	llmCtx := ctx
+	if r.budget > 0 {
+		var cancel context.CancelFunc
+		llmCtx, cancel = context.WithTimeoutCause(ctx, r.budget, errBudgetExpired)
+		defer cancel()
+	}
+
+	// A cached response needs no provider-side context cache either.
+	// Read-only runs skip it: its handles are kept in a file.
+	if _, _, hit := c.cached(llmCtx); !f.NoCache && !f.ReadOnly && len(members) == 0 && len(r.parts) == 0 && !hit {
+		cacheCtx, cancel := context.WithTimeout(llmCtx, r.timeout)
+		name, err := ensureGeminiCache(cacheCtx, modelProvider, c.segments, f.Model, f.CacheTTL, verbose)
+		cancel()
+		if err != nil {
+			verbose("Cache orchestration error (falling back to uncached): %v", err)
+		} else if name != "" {
+			c.settings.CachedContentName = name
+		}
+	}
+
+	var rev review.Review
+	var res callResult
+	if len(r.parts) > 0 {
+		rev, res, err = r.runChunks(llmCtx, f.MinAgreement)
+	} else if len(members) > 0 {
+		rev, res, err = runEnsemble(llmCtx, c, members, f.MinAgreement)
+	} else {
+		res, err = c.run(llmCtx, modelProvider)
+		rev = res.rev
+	}
+	incomplete := errors.Is(err, errBudgetExpired)
+	if incomplete {
+		fmt.Fprintf(os.Stderr, "plancritic: warning: --max-duration %s expired; the review is incomplete\n", r.budget)
+		if rev.Issues == nil {
+			rev.Issues = []review.Issue{}
+		}
+		if rev.Questions == nil {
+			rev.Questions = []review.Question{}
+		}
+	} else if err != nil {
+		if interrupted(parentCtx) {
+			// 130 is the shell's code for a run ended by SIGINT.
+			return review.Review{}, Errorf(130, "interrupted before the model responded; no review was written")
+		}
+		return review.Review{}, err
+	}
+
+	// 10c. Sanity check: an empty review of a plan with obvious gaps
+	// is more likely a skim or a quiet refusal than a clean bill of
+	// health.
+	var sanityRetry, suspicious bool
+	// Checklist mode asks for no findings, so it has none to count.
+	if !incomplete && !interrupted(parentCtx) && len(members) == 0 && len(r.parts) == 0 && f.Mode != ModeChecklist && suspiciouslyEmpty(rev, r.metrics, f.MinFindingsSanity) {
+		sanityRetry = true
+		res = c.sanityRetry(llmCtx, modelProvider, res)
+		rev = res.rev
+		if suspiciouslyEmpty(rev, r.metrics, f.MinFindingsSanity) {
+			suspicious = true
+			fmt.Fprintf(os.Stderr, "plancritic: warning: review has %d findings, fewer than --min-findings-sanity %d; treat a clean result with caution\n", findings(rev), f.MinFindingsSanity)
+		}
+	}
+
+	// The response is paid for: an interrupt from here on still lets
+	// post-processing finish, and the review is marked instead.
+	rev, err = r.finish(context.WithoutCancel(ctx), f, outcome{rev: rev, res: res, incomplete: incomplete, sanityRetry: sanityRetry, suspicious: suspicious}, version)
+	if err == nil && interrupted(parentCtx) {
+		rev.Meta.Interrupted = true
+	}
+	return rev, err
+}
+
+// prepare loads the plan and context files, resolves the provider, and
+// builds the prompt and the call that sends it: steps 1 to 8 of a run.
+func prepare(planPath string, f Options) (*prepared, error) {
 	verbose := verboseLogger(f.Verbose)
 
+	if err := checkReadOnly(f); err != nil {
+		return nil, err
+	}
+	if f.ExpireQuestionsAfter < 0 {
+		return nil, Errorf(3, "invalid question expiry %d: want a number of plan revisions, or 0 to disable", f.ExpireQuestionsAfter)
+	}
+	if f.ExpireQuestionsAfter > 0 && f.QuestionHistoryPath == "" {
+		return nil, Errorf(3, "expiring questions needs a question history file")
+	}
+	if f.Mode != "" && f.Mode != ModeFull && f.Mode != ModeChecklist {
+		return nil, Errorf(3, "unknown mode %q (valid: %s, %s)", f.Mode, ModeFull, ModeChecklist)
+	}
+	if f.Language != "" && !strings.EqualFold(f.Language, "auto") && !plan.KnownLanguage(f.Language) {
+		return nil, Errorf(3, "unknown language %q (valid: auto, %s)", f.Language, strings.Join(plan.LanguageCodes(), ", "))
+	}
+	if f.Concurrency < 0 {
+		return nil, Errorf(3, "invalid concurrency %d: want a positive number, or 0 for the provider default", f.Concurrency)
+	}
+	if f.MaxInputBytes < 0 {
+		return nil, Errorf(3, "invalid max input bytes %d: want a positive number, or 0 for the default", f.MaxInputBytes)
+	}
+	if len(f.JointPlans) > 0 && f.Chunk {
+		return nil, Errorf(3, "a joint review cannot be chunked: its documents are compared as a whole")
+	}
+	if f.TrainingDataDir != "" && !f.RedactEnabled {
+		// The corpus would keep the plan's secrets on disk.
+		return nil, Errorf(3, "training data is only collected from redacted prompts; drop --redact=false or --collect-training-data")
+	}
+	fo := fetch.Options{Headers: f.URLHeaders, GitHubLinked: f.GitHubLinked, MaxBytes: maxInputBytes(f)}
+
 	// 1. Load plan
-	verbose("Loading plan: %s", planPath)
-	p, err := plan.Load(planPath)
+	var p *plan.Plan
+	if f.PlanText != "" {
+		verbose("Using plan content for %s", planPath)
+		if err := checkInput(planPath, f.PlanText, f); err != nil {
+			return nil, Errorf(3, "failed to load plan: %v%s", err, inputHint(err))
+		}
+		p = plan.Parse(planPath, f.PlanText)
+	} else {
+		verbose("Loading plan: %s", planPath)
+		var err error
+		if p, err = plan.LoadWith(planPath, fo); err != nil {
+			return nil, Errorf(3, "failed to load plan: %v%s", err, inputHint(err))
+		}
+	}
+
+	joint, err := loadJointPlans(p, f.JointPlans, fo, verbose)
 	if err != nil {
-		return review.Review{}, Errorf(3, "failed to load plan: %v", err)
+		return nil, err
+	}
+	since, err := loadIncremental(planPath, f, verbose)
+	if err != nil {
+		return nil, err
 	}
 
 	stepIDs := plan.InferStepIDs(p)
 	verbose("Inferred %d plan steps", len(stepIDs))
 
+	detectedLang := plan.DetectLanguage(p.Raw)
+	promptLang := f.Language
+	if strings.EqualFold(promptLang, "auto") {
+		promptLang = detectedLang
+	}
+	verbose("Detected plan language: %q (output language: %q)", detectedLang, promptLang)
+
 	// 2. Load context files
 	var contexts []*pctx.File
-	for _, cp := range f.ContextPaths {
+	var contextFiles []string
+	contextPaths, err := expandContextDirs(f.ContextPaths, f.ContextDirs, verbose)
+	if err != nil {
+		return nil, err
+	}
+	for i, cp := range contextPaths {
 		verbose("Loading context: %s", cp)
-		cf, err := pctx.Load(cp)
+		cf, err := pctx.LoadWith(cp, fo)
 		if err != nil {
-			return review.Review{}, Errorf(3, "failed to load context %s: %v", cp, err)
+			return nil, Errorf(3, "failed to load context %s: %v%s", cp, err, inputHint(err))
+		}
+		if cf.Section != "" {
+			verbose("Pinned context %s to section %q (lines %d-%d)", cf.FilePath, cf.Section, cf.Start, cf.End)
+		}
+		if i >= len(f.ContextPaths) {
+			cf.Role = pctx.RoleDirectory
 		}
 		contexts = append(contexts, cf)
+		contextFiles = append(contextFiles, cf.FilePath)
+	}
+	for _, doc := range f.ContextDocuments {
+		verbose("Using context content for %s", doc.Name)
+		if err := checkInput(doc.Name, doc.Text, f); err != nil {
+			return nil, Errorf(3, "failed to load context %s: %v%s", doc.Name, err, inputHint(err))
+		}
+		cf := pctx.Parse(doc.Name, doc.Text)
+		cf.Role = pctx.RoleDocument
+		contexts = append(contexts, cf)
+		contextFiles = append(contextFiles, cf.FilePath)
+	}
+	// Citations name a context by the path the prompt shows for it, so
+	// files that share a base name are told apart by their directories.
+	pctx.AssignNames(contexts)
+	for _, cf := range contexts {
+		if cf.Name != filepath.Base(cf.FilePath) {
+			verbose("Context %s is cited as %s", cf.FilePath, cf.Name)
+		}
 	}
 
+	metrics := plan.ComputeMetrics(p, stepIDs, contextFiles)
+
 	// 3. Redact
 	if f.RedactEnabled {
 		verbose("Redacting secrets")
 		p.Raw = redact.Redact(p.Raw)
 		p.Lines = strings.Split(p.Raw, "\n")
+		p.Meta, p.BodyStart = plan.ParseFrontMatter(p.Raw)
 		for _, cf := range contexts {
 			cf.Raw = redact.Redact(cf.Raw)
 			cf.Lines = strings.Split(cf.Raw, "\n")
 		}
+		for _, jp := range joint {
+			jp.Raw = redact.Redact(jp.Raw)
+			jp.Lines = strings.Split(jp.Raw, "\n")
+			jp.Meta, jp.BodyStart = plan.ParseFrontMatter(jp.Raw)
+		}
+		if since != nil {
+			since.old.Raw = redact.Redact(since.old.Raw)
+			since.old.Lines = strings.Split(since.old.Raw, "\n")
+		}
+	}
+	if since != nil {
+		since.compare(p)
+		verbose("Plan has %d changes since %s; carrying forward %d baseline findings", len(since.changes), f.Since, len(since.issues)+len(since.questions))
+	}
+
+	outOfScope := plan.OutOfScope(p)
+	if len(outOfScope) > 0 {
+		verbose("Plan declares %d out-of-scope items", len(outOfScope))
 	}
 
 	// 4. Load profile
 	verbose("Loading profile: %s", f.ProfileName)
-	prof, err := profile.LoadBuiltin(f.ProfileName)
+	loadProfile := profile.Load
+	if f.ReadOnly {
+		// Profile directories are not consulted either.
+		loadProfile = profile.LoadBuiltin
+	}
+	prof, err := loadProfile(f.ProfileName)
 	if err != nil {
-		return review.Review{}, Errorf(3, "failed to load profile: %v", err)
+		return nil, Errorf(3, "failed to load profile: %v", err)
+	}
+	if len(f.Constraints) > 0 {
+		verbose("Overriding %d profile constraints", len(f.Constraints))
+		if err := profile.ApplyConstraints(prof, f.Constraints); err != nil {
+			return nil, Errorf(3, "%v", err)
+		}
+	}
+	if err := profile.DisableCategories(prof, f.DisableCategories); err != nil {
+		return nil, Errorf(3, "--disable-category: %v", err)
 	}
+	if f.Mode == ModeChecklist && len(prof.Checklists) == 0 {
+		return nil, Errorf(3, "checklist mode needs a profile with checklists; %q has none", f.ProfileName)
+	}
+	plan.MeasureVagueness(&metrics, p, profile.AmbiguityTriggers(prof, detectedLang))
 
 	// 6. Resolve LLM provider
 	verbose("Resolving LLM provider")
+	rules, err := providerRules(p, joint, contexts, prof, f.Classifications)
+	if err != nil {
+		return nil, err
+	}
 	modelProvider := f.Provider
-	if modelProvider == nil {
-		var err error
-		modelProvider, err = llm.ResolveProvider(f.ProviderName, f.Model)
+	var members []ensembleMember
+	if len(f.Ensemble) > 0 {
+		members, err = resolveEnsemble(f)
 		if err != nil {
-			return review.Review{}, Errorf(4, "model provider error: %v", err)
+			return nil, err
+		}
+		for _, m := range members {
+			if err := checkProviders(rules, m.name, m.provider, ""); err != nil {
+				return nil, err
+			}
+		}
+		verbose("Using ensemble of %d models", len(members))
+	} else {
+		if modelProvider == nil {
+			modelProvider, err = resolveProvider(f)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if err := checkProviders(rules, providerLabel(modelProvider, f.Model), modelProvider, f.Model); err != nil {
+			return nil, err
 		}
+		verbose("Using provider: %s", modelProvider.Name())
 	}
-	verbose("Using provider: %s", modelProvider.Name())
 
 	// 6b. Parse timeout
 	requestTimeoutText := f.Timeout
@@ -112,7 +587,62 @@ func Run(parentCtx context.Context, planPath string, f Options, version string)
 	}
 	timeout, err := time.ParseDuration(requestTimeoutText)
 	if err != nil {
-		return review.Review{}, Errorf(3, "invalid --timeout value %q: %v", f.Timeout, err)
+		return nil, Errorf(3, "invalid --timeout value %q: %v", f.Timeout, err)
+	}
+	if timeout <= 0 {
+		return nil, Errorf(3, "invalid --timeout value %q: must be positive", f.Timeout)
+	}
+
+	// 6b2. Run budget
+	var budget time.Duration
+	if f.MaxDuration != "" {
+		budget, err = time.ParseDuration(f.MaxDuration)
+		if err != nil {
+			return nil, Errorf(3, "invalid --max-duration value %q: %v", f.MaxDuration, err)
+		}
+		if budget <= 0 {
+			return nil, Errorf(3, "invalid --max-duration value %q: must be positive", f.MaxDuration)
+		}
+	}
+
+	// 6c. Persistent state. A shared storage backend keeps the response
+	// cache and the question history; otherwise both are local files.
+	var store storage.Backend
+	backend := strings.ToLower(f.StorageBackend)
+	if backend != "" && backend != "fs" && ((f.ResponseCache && !f.NoCache) || f.ExpireQuestionsAfter > 0) {
+		store, err = storage.Open(storage.Config{Backend: backend, URL: f.StorageURL})
+		if err != nil {
+			return nil, Errorf(3, "%v", err)
+		}
+	}
+	var respCache *respcache.Cache
+	if f.ResponseCache && !f.NoCache {
+		ttlText := f.ResponseCacheTTL
+		if ttlText == "" {
+			ttlText = "24h"
+		}
+		ttl, err := time.ParseDuration(ttlText)
+		if err != nil {
+			return nil, Errorf(3, "invalid --response-cache-ttl value %q: %v", f.ResponseCacheTTL, err)
+		}
+		if ttl <= 0 {
+			return nil, Errorf(3, "invalid --response-cache-ttl value %q: must be positive", f.ResponseCacheTTL)
+		}
+		if store != nil {
+			verbose("Using response cache in %s storage (ttl=%s)", backend, ttl)
+			respCache = respcache.NewBackend(store, ttl)
+		} else {
+			dir := f.ResponseCacheDir
+			if dir == "" {
+				dir, err = respcache.DefaultDir()
+			}
+			if err != nil {
+				verbose("Response cache disabled: %v", err)
+			} else {
+				verbose("Using response cache: %s (ttl=%s)", dir, ttl)
+				respCache = respcache.New(dir, ttl)
+			}
+		}
 	}
 
 	// 7. Build prompt
@@ -125,13 +655,19 @@ func Run(parentCtx context.Context, planPath string, f Options, version string)
 		maxQuestions = review.DefaultMaxQuestions
 	}
 	promptOpts := prompt.BuildOpts{
-		Plan:         p,
-		Contexts:     contexts,
-		Profile:      prof,
-		Strict:       f.Strict,
-		StepIDs:      stepIDs,
-		MaxIssues:    maxIssues,
-		MaxQuestions: maxQuestions,
+		Plan:          p,
+		Contexts:      contexts,
+		Profile:       prof,
+		Strict:        f.Strict,
+		StepIDs:       stepIDs,
+		MaxIssues:     maxIssues,
+		MaxQuestions:  maxQuestions,
+		Language:      promptLang,
+		PlanLanguage:  detectedLang,
+		OutOfScope:    outOfScope,
+		ChecklistOnly: f.Mode == ModeChecklist,
+		Plans:         joint,
+		Since:         since.revision(),
 	}
 	promptSegments := prompt.BuildSegments(promptOpts)
 	if f.NoCache {
@@ -145,14 +681,27 @@ func Run(parentCtx context.Context, planPath string, f Options, version string)
 	promptText := llm.ConcatSegments(promptSegments)
 
 	// 7b. Prompt size check
-	estimatedTokens := len(promptText) / estimatedCharsPerToken
+	estimatedTokens := llm.CountTokens(promptText)
 	verbose("Prompt size: %d chars (~%d estimated tokens)", len(promptText), estimatedTokens)
 	if estimatedTokens > 100000 {
 		verbose("WARNING: prompt is very large (~%dk tokens), request may be slow or fail", estimatedTokens/1000)
 	}
-	if f.MaxInputTokens > 0 && estimatedTokens > f.MaxInputTokens {
-		return review.Review{}, Errorf(3, "estimated prompt size ~%d tokens exceeds --max-input-tokens=%d (plan: %d lines, context files: %d). Reduce context, lower --max-issues/--max-questions, or raise the limit",
-			estimatedTokens, f.MaxInputTokens, len(p.Lines), len(contexts))
+	inputLimit := f.MaxInputTokens
+	if inputLimit == 0 && f.Chunk {
+		// Without an explicit limit, chunking fits the model's window.
+		inputLimit = contextBudget(modelProvider, members, f.Model)
+	}
+	var chunks []prompt.Chunk
+	if inputLimit > 0 && estimatedTokens > inputLimit {
+		if !f.Chunk {
+			return nil, Errorf(3, "estimated prompt size ~%d tokens exceeds --max-input-tokens=%d (plan: %d lines, context files: %d). Reduce context, lower --max-issues/--max-questions, raise the limit, or review the plan in parts with --chunk",
+				estimatedTokens, inputLimit, len(p.Lines), len(contexts))
+		}
+		chunks, err = splitPlan(promptOpts, inputLimit)
+		if err != nil {
+			return nil, err
+		}
+		verbose("Prompt exceeds the input budget of %d tokens; reviewing the plan in %d parts", inputLimit, len(chunks))
 	}
 
 	// 8. Debug output
@@ -166,144 +715,160 @@ func Run(parentCtx context.Context, planPath string, f Options, version string)
 	}
 
 	// 9. Call LLM
-	verbose("Calling LLM (timeout: %s)...", timeout)
+	verbose("Calling LLM (timeout: %s per request)...", timeout)
+	if f.ReasoningEffort != "" && !slices.Contains(llm.ReasoningEfforts, f.ReasoningEffort) {
+		return nil, Errorf(3, "invalid --reasoning-effort value %q: want one of %s", f.ReasoningEffort, strings.Join(llm.ReasoningEfforts, ", "))
+	}
+	maxTokens := f.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = autoMaxTokens(len(p.Lines), maxIssues, maxQuestions, outputLimit(modelProvider, members, f.Model))
+		verbose("Max response tokens: %d (scaled to %d plan lines, %d issues, %d questions)", maxTokens, len(p.Lines), maxIssues, maxQuestions)
+	}
 	settings := llm.Settings{
-		Model:       f.Model,
-		Temperature: f.Temperature,
-		MaxTokens:   f.MaxTokens,
+		Model:           f.Model,
+		Temperature:     f.Temperature,
+		MaxTokens:       maxTokens,
+		Timeout:         timeout,
+		ReasoningEffort: f.ReasoningEffort,
 	}
 	if f.HasSeed {
 		settings.Seed = &f.Seed
 	}
+	if f.HasSeed && modelProvider != nil && !modelProvider.Capabilities(f.Model).Seed {
+		verbose("Provider %s ignores --seed", modelProvider.Name())
+	}
+	if !f.NoPrefill {
+		// Providers that support prefill (Anthropic) then cannot open
+		// the response with prose before the JSON.
+		settings.Prefill = "{"
+	}
 
-	ctx, cancel := context.WithTimeout(parentCtx, timeout)
-	defer cancel()
-
-	if !f.NoCache {
-		if name, err := ensureGeminiCache(ctx, modelProvider, promptSegments, f.Model, f.CacheTTL, verbose); err != nil {
-			verbose("Cache orchestration error (falling back to uncached): %v", err)
-		} else if name != "" {
-			settings.CachedContentName = name
+	// Build context lookup maps in a single pass, keyed by the name
+	// the prompt shows for each file (see prompt.BuildSegments).
+	// schema.Validate and review.ReconstructQuotes resolve
+	// Evidence.Path against these keys with
+	// review.ResolveContextPath. Sections pinned from one file share
+	// its name; the count covers the furthest section shown.
+	contextLineCounts := make(map[string]int, len(contexts))
+	contextLinesByName := make(map[string][]string, len(contexts))
+	for _, c := range contexts {
+		count := len(c.Lines)
+		if c.Section != "" {
+			// Lines past a pinned section were never shown to the model.
+			count = c.End
 		}
+		contextLineCounts[c.Name] = max(contextLineCounts[c.Name], count)
+		contextLinesByName[c.Name] = c.Lines
 	}
-
-	var result string
-	var usage llm.Usage
-	if sp, ok := modelProvider.(llm.SegmentedProvider); ok {
-		result, usage, err = sp.GenerateSegments(ctx, promptSegments, settings)
-	} else {
-		result, usage, err = modelProvider.Generate(ctx, promptText, settings)
+	c := &call{
+		segments:          promptSegments,
+		settings:          settings,
+		timeout:           timeout,
+		planLines:         len(p.Lines),
+		contextLineCounts: contextLineCounts,
+		quoteSrc: review.QuoteSource{
+			PlanLines:      p.Lines,
+			ContextsByName: contextLinesByName,
+			PlansByName:    jointLines(p, joint),
+		},
+		planLineCounts: jointLineCounts(p, joint),
+		evidenceRules:  evidenceRules(prof),
+		categories:     prof.CategoryIDs(),
+		repairAttempts: f.MaxRepairAttempts,
+		verbose:        verbose,
 	}
-	if err != nil {
-		return review.Review{}, Errorf(4, "LLM call failed: %v", err)
+	if f.Debug {
+		c.debugDir = f.DebugDir
 	}
-	verbose("Received LLM response (%d bytes)", len(result))
-	if usage.CacheReadInputTokens > 0 || usage.CacheCreationInputTokens > 0 {
-		verbose("Token usage: input=%d (cache read=%d, cache write=%d), output=%d",
-			usage.InputTokens, usage.CacheReadInputTokens, usage.CacheCreationInputTokens, usage.OutputTokens)
-	} else if usage.InputTokens > 0 {
-		verbose("Token usage: input=%d, output=%d", usage.InputTokens, usage.OutputTokens)
+	if modelProvider != nil {
+		c.model = modelProvider.Name() + "/" + f.Model
 	}
-
-	if f.Debug {
-		debugRespPath, err := writeDebugFile(f.DebugDir, "plancritic-debug-response-*.txt", []byte(result))
-		if err != nil {
-			verbose("Warning: failed to write debug response: %v", err)
-		} else {
-			verbose("Wrote debug response to %s", debugRespPath)
-		}
+	c.cache = respCache
+	c.logDir = f.LogLLMDir
+	c.limiter = f.Limiter
+	if c.limiter == nil {
+		c.limiter = NewLimiter(f.Concurrency)
 	}
+	parts := chunkCalls(c, chunks, promptOpts, f.NoCache)
 
-	// 9. Parse JSON
-	result = llm.ExtractJSON(result)
-	var rev review.Review
-	if err := json.Unmarshal([]byte(result), &rev); err != nil {
-		// Try sanitizing invalid escape sequences (common with Gemini).
-		// Use a fresh Review so partial fields from the failed unmarshal
-		// don't bleed into the retry result.
-		sanitized := llm.SanitizeJSON(result)
-		var rev2 review.Review
-		if err2 := json.Unmarshal([]byte(sanitized), &rev2); err2 != nil {
-			return review.Review{}, Errorf(5, "failed to parse LLM response as JSON: %v (pre-sanitize: %v)", err2, err)
-		}
-		rev = rev2
-		verbose("Sanitized invalid JSON escape sequences")
-		result = sanitized
-	}
-
-	// 10. Validate. Build context lookup maps in a single pass; both
-	// maps are keyed by basename, matching the identifier the prompt
-	// exposes to the LLM (see prompt.BuildSegments).
-	// Use review.NormalizeContextPath so the map keys match exactly
-	// what schema.Validate and review.ReconstructQuotes will compute
-	// from Evidence.Path, regardless of the host OS or whether the
-	// LLM emits back- or forward-slash paths.
-	contextLineCounts := make(map[string]int, len(contexts))
-	contextLinesByBase := make(map[string][]string, len(contexts))
-	for _, c := range contexts {
-		base := review.NormalizeContextPath(c.FilePath)
-		if _, dup := contextLinesByBase[base]; dup {
-			// Unconditional stderr: two context files with the same
-			// basename make the LLM's citations ambiguous and will
-			// silently resolve to whichever file we store last.
-			fmt.Fprintf(os.Stderr, "plancritic: warning: multiple context files share basename %q — citations may be ambiguous\n", base)
-		}
-		contextLineCounts[base] = len(c.Lines)
-		contextLinesByBase[base] = c.Lines
-	}
-	validationErrs := schema.Validate(&rev, len(p.Lines), contextLineCounts)
-	if len(validationErrs) > 0 {
-		verbose("Validation failed (%d errors), attempting repair...", len(validationErrs))
-
-		repairPrompt := prompt.BuildRepair(result, validationErrs)
-		repairResult, repairUsage, err := modelProvider.Generate(ctx, repairPrompt, settings)
-		if err != nil {
-			return review.Review{}, Errorf(4, "repair LLM call failed: %v", err)
-		}
-		if repairUsage.InputTokens > 0 {
-			verbose("Repair token usage: input=%d, output=%d", repairUsage.InputTokens, repairUsage.OutputTokens)
-		}
-		repairResult = llm.ExtractJSON(repairResult)
+	return &prepared{
+		planPath:      planPath,
+		p:             p,
+		joint:         joint,
+		since:         since,
+		contexts:      contexts,
+		metrics:       metrics,
+		detectedLang:  detectedLang,
+		modelProvider: modelProvider,
+		members:       members,
+		timeout:       timeout,
+		budget:        budget,
+		maxIssues:     maxIssues,
+		maxQuestions:  maxQuestions,
+		promptText:    promptText,
+		outOfScope:    outOfScope,
+		deps:          dependencyIssues(p),
+		c:             c,
+		chunks:        chunks,
+		parts:         parts,
+		bounds:        severityBounds(prof),
+		disabled:      disabledCategories(prof),
+		fabrication:   prof.Heuristics.FabricationPhrases,
+		checklists:    prof.Checklists,
+		store:         store,
+		verbose:       verbose,
+	}, nil
+}
 
-		var rev2 review.Review
-		if err := json.Unmarshal([]byte(repairResult), &rev2); err != nil {
-			sanitized := llm.SanitizeJSON(repairResult)
-			if err2 := json.Unmarshal([]byte(sanitized), &rev2); err2 != nil {
-				return review.Review{}, Errorf(5, "repair response is not valid JSON: %v (pre-sanitize: %v)", err2, err)
-			}
-		}
+// finish post-processes the model's findings into the final review:
+// grounding, suppressions, filtering, the summary, metadata, training
+// data, and post-process hooks.
+func (r *prepared) finish(ctx context.Context, f Options, o outcome, version string) (review.Review, error) {
+	rev, res := o.rev, o.res
+	p, contexts, metrics, verbose := r.p, r.contexts, r.metrics, r.verbose
+	planPath, detectedLang := r.planPath, r.detectedLang
+	members, modelProvider := r.members, r.modelProvider
+	maxIssues, maxQuestions := r.maxIssues, r.maxQuestions
+	promptText := r.promptText
 
-		validationErrs2 := schema.Validate(&rev2, len(p.Lines), contextLineCounts)
-		if len(validationErrs2) > 0 {
-			fmt.Fprintln(os.Stderr, "Schema validation errors after repair:")
-			for _, e := range validationErrs2 {
-				fmt.Fprintf(os.Stderr, "  %s\n", e)
+	// A checklist-mode review reports the checklists alone; findings
+	// the model volunteered anyway are dropped. A pre-checked stub plan
+	// keeps its local issue.
+	if f.Mode == ModeChecklist && !o.precheck {
+		rev.Issues, rev.Questions, rev.Patches = []review.Issue{}, []review.Question{}, nil
+	}
+
+	// Dependency cycles and forward references are found locally, so
+	// they are reported even when the model misses them.
+	if f.Mode != ModeChecklist && !o.precheck {
+		if n := addDependencyIssues(&rev, r.deps); n > 0 {
+			verbose("Added %d step dependency issues", n)
+		}
+		if r.since != nil {
+			if n := r.since.addCarried(&rev); n > 0 {
+				verbose("Carried forward %d findings from the baseline review", n)
 			}
-			return review.Review{}, Errorf(5, "LLM output failed schema validation after repair")
 		}
-
-		rev = rev2
 	}
-	verbose("Validation passed")
 
-	// 10b. Reconstruct evidence quotes from cited line ranges. The LLM
-	// is instructed to omit the quote field to save output tokens; any
-	// quote it still emits is overwritten from the authoritative source.
-	quoteSrc := review.QuoteSource{
-		PlanLines:          p.Lines,
-		ContextsByBasename: contextLinesByBase,
+	// 11. Post-process. Severity bounds and disabled categories are
+	// team policy, so they override the model before grounding
+	// downgrades and scoring.
+	review.NormalizeEvidence(&rev)
+	if n := review.ClampSeverities(rev.Issues, r.bounds); n > 0 {
+		verbose("Adjusted the severity of %d findings to the profile's category bounds", n)
 	}
-	if misses := review.ReconstructQuotes(&rev, quoteSrc); misses > 0 {
-		verbose("Quote reconstruction: %d evidence entries could not be resolved to a source", misses)
+	traceChecks(rev.Checklists, r.checklists)
+	if n := len(rev.Issues); len(r.disabled) > 0 {
+		rev.Issues = review.DropCategories(rev.Issues, r.disabled)
+		verbose("Dropped %d findings in disabled categories", n-len(rev.Issues))
 	}
-
-	// 11. Post-process
 	review.SortIssues(rev.Issues)
 	review.SortQuestions(rev.Questions)
 
 	// Strict grounding post-check
 	if f.Strict {
-		violations := review.CheckGrounding(&rev)
+		violations := review.CheckGrounding(&rev, r.fabrication...)
 		if len(violations) > 0 {
 			verbose("Grounding violations found: %d, applying downgrades", len(violations))
 			review.ApplyGroundingDowngrades(&rev, violations)
@@ -311,6 +876,42 @@ func Run(parentCtx context.Context, planPath string, f Options, version string)
 		}
 	}
 
+	// Patches are shown to users and written to --patch-out, so a diff
+	// that does not apply cleanly to the plan is dropped here.
+	rev.Patches = validPatches(rev.Patches, p.Lines, verbose)
+
+	// Suppressions apply before the severity filter and truncation so
+	// hidden findings never take up a slot in the capped output.
+	review.AssignFingerprints(&rev)
+	if f.SuppressionsPath != "" {
+		sup, err := suppress.Load(f.SuppressionsPath)
+		if err != nil {
+			return review.Review{}, Errorf(3, "%v", err)
+		}
+		res := sup.Apply(&rev, time.Now())
+		verbose("Suppressed %d findings from %s", len(rev.Suppressed), f.SuppressionsPath)
+		for _, e := range res.Expired {
+			// Unconditional: an expired snooze is exactly what the
+			// reader needs to notice.
+			fmt.Fprintf(os.Stderr, "plancritic: warning: suppression %s expired on %s; the finding is reported again\n", e.Fingerprint, e.Until)
+		}
+	}
+
+	// Findings about what the plan explicitly excludes are the most
+	// common false positive; the prompt asks the model not to raise
+	// them, and any that slip through are hidden here like suppressions.
+	if n := review.ApplyOutOfScope(&rev, r.outOfScope); n > 0 {
+		verbose("Hid %d findings about out-of-scope items", n)
+	}
+
+	// Chronic unanswered questions become issues before the filter so
+	// they are scored like any other CRITICAL finding.
+	if f.ExpireQuestionsAfter > 0 {
+		if err := expireQuestions(ctx, &rev, planPath, p.Hash, f, r.store, verbose); err != nil {
+			return review.Review{}, err
+		}
+	}
+
 	// Apply severity threshold filter before truncation so the cap applies
 	// to the user-visible set and the truncation notice is never filtered out.
 	rev.Issues = review.FilterBySeverity(rev.Issues, f.SeverityThreshold)
@@ -318,38 +919,325 @@ func Run(parentCtx context.Context, planPath string, f Options, version string)
 	review.Truncate(&rev, maxIssues, maxQuestions)
 
 	// Compute deterministic summary from final issue list
-	rev.Summary = review.ComputeSummary(rev.Issues)
+	summarize(&rev, &metrics, f)
 
 	// Fill metadata
 	rev.Tool = "plancritic"
 	rev.Version = version
 	rev.Input = review.Input{
-		PlanFile: filepath.Base(planPath),
+		PlanFile: planFileName(planPath),
 		PlanHash: p.Hash,
 		Profile:  f.ProfileName,
 		Strict:   f.Strict,
+		Language: detectedLang,
+		PlanMeta: p.Meta,
+	}
+	if key, ok := fetch.JiraKey(planPath); ok {
+		rev.Input.JiraIssue = key
+	}
+	if r.since != nil {
+		rev.Input.Since = r.since.rev
+	}
+	rev.Input.ConstraintOverrides = f.Constraints
+	rev.Input.DisabledCategories = f.DisableCategories
+	rev.Input.Git = planProvenance(planPath, f, verbose)
+	for _, jp := range r.joint {
+		rev.Input.Plans = append(rev.Input.Plans, review.PlanFile{Path: filepath.Base(jp.FilePath), Hash: jp.Hash})
 	}
 	for _, cf := range contexts {
 		rev.Input.ContextFiles = append(rev.Input.ContextFiles, review.ContextFile{
-			Path: filepath.Base(cf.FilePath),
-			Hash: cf.Hash,
+			Path:    cf.Name,
+			Hash:    cf.Hash,
+			Section: cf.Section,
 		})
 	}
+	if f.EmbedInputs {
+		if err := embedInputs(&rev, p, r.joint, contexts); err != nil {
+			return review.Review{}, Errorf(3, "%v", err)
+		}
+		verbose("Embedded %d input files", len(rev.Embedded))
+	}
 	modelName := f.Model
 	if modelName == "" {
 		modelName = "(default)"
 	}
 	rev.Meta = review.Meta{
-		Model:       modelProvider.Name() + "/" + modelName,
-		Temperature: f.Temperature,
+		Temperature:       f.Temperature,
+		SanityRetry:       o.sanityRetry,
+		SuspiciouslyEmpty: o.suspicious,
+		Precheck:          o.precheck,
+	}
+	if len(members) > 0 {
+		rev.Meta.Model = "ensemble(" + strings.Join(f.Ensemble, ",") + ")"
+	} else {
+		rev.Meta.Model = modelProvider.Name() + "/" + modelName
+	}
+	if u := res.usage; u != (llm.Usage{}) {
+		rev.Meta.Usage = &review.Usage{
+			InputTokens:      u.InputTokens,
+			OutputTokens:     u.OutputTokens,
+			CacheReadTokens:  u.CacheReadInputTokens,
+			CacheWriteTokens: u.CacheCreationInputTokens,
+		}
+		if res.priced {
+			cost := res.cost
+			rev.Meta.Usage.EstimatedCostUSD = &cost
+		}
+	}
+
+	rev.Meta.Chunks = len(r.chunks)
+
+	if o.incomplete {
+		rev.Status = review.StatusIncomplete
+	}
+
+	if f.TrainingDataDir != "" && o.incomplete {
+		verbose("Skipping training data for an incomplete review")
+	} else if f.TrainingDataDir != "" && o.precheck {
+		verbose("Skipping training data for a pre-checked stub plan")
+	} else if f.TrainingDataDir != "" && len(r.chunks) > 0 {
+		// The merged review answers no single prompt.
+		verbose("Skipping training data for a chunked review")
+	} else if f.TrainingDataDir != "" {
+		rec := training.NewRecord(rev, training.Exchange{Prompt: promptText, Response: res.raw}, res.repair, time.Now())
+		if err := training.Append(f.TrainingDataDir, rec); err != nil {
+			// Collection is a side channel; never fail the review for it.
+			fmt.Fprintf(os.Stderr, "plancritic: warning: training data not recorded: %v\n", err)
+		} else {
+			verbose("Recorded training example in %s", f.TrainingDataDir)
+		}
+	}
+
+	// 12. Post-process hooks
+	if len(f.PostProcessors) > 0 {
+		verbose("Running %d post-process hooks", len(f.PostProcessors))
+		if err := hook.Run(ctx, &rev, f.PostProcessors); err != nil {
+			return review.Review{}, Errorf(3, "%v", err)
+		}
+		if errs := r.c.validateSchema(&rev); len(errs) > 0 {
+			for _, e := range errs {
+				fmt.Fprintf(os.Stderr, "  %s\n", e)
+			}
+			return review.Review{}, Errorf(3, "post-process hooks produced an invalid review")
+		}
+		review.NormalizeEvidence(&rev)
+		review.SortIssues(rev.Issues)
+		review.SortQuestions(rev.Questions)
+		review.AssignFingerprints(&rev)
+		summarize(&rev, &metrics, f)
 	}
 
 	return rev, nil
 }
 
+// summarize sets rev's summary from its final issues, or in checklist
+// mode its checklists, with the grounded-only summary alongside when
+// asked for.
+func summarize(rev *review.Review, metrics *review.PlanMetrics, f Options) {
+	if f.Mode == ModeChecklist {
+		rev.Summary = review.ChecklistSummary(rev.Issues, rev.Checklists)
+	} else {
+		rev.Summary = review.ComputeSummary(rev.Issues)
+	}
+	rev.Summary.PlanMetrics = metrics
+	if f.GroundedVerdict {
+		g := review.GroundedSummary(rev.Issues)
+		rev.Summary.Grounded = &g
+	}
+}
+
+// planFileName is the name Input.PlanFile records: the base name of a
+// file or URL, or the whole reference for a tracker item such as
+// "gh:owner/repo#12".
+func planFileName(planPath string) string {
+	name := fetch.Name(planPath)
+	if fetch.IsRemote(planPath) && !fetch.IsURL(name) {
+		return name
+	}
+	return filepath.Base(name)
+}
+
+// expireQuestions records the review's CRITICAL questions in the
+// question history and converts those unanswered for
+// f.ExpireQuestionsAfter revisions into issues. The history is kept in
+// store, one object per plan (see historyID), when one is given, else
+// in f.QuestionHistoryPath.
+func expireQuestions(ctx context.Context, rev *review.Review, planPath, hash string, f Options, store storage.Backend, verbose func(string, ...any)) error {
+	var ph *history.Plan
+	if store != nil {
+		id := historyID(planPath)
+		key := history.PlanKey(id)
+		var err error
+		if ph, err = history.LoadPlan(ctx, store, key); err != nil {
+			return Errorf(3, "%v", err)
+		}
+		ph.ID = id
+		ph.Record(hash, rev)
+		if err := history.SavePlan(ctx, store, key, ph); err != nil {
+			return Errorf(3, "%v", err)
+		}
+	} else {
+		b, histKey := history.Local(f.QuestionHistoryPath)
+		hist, err := history.Load(ctx, b, histKey)
+		if err != nil {
+			return Errorf(3, "%v", err)
+		}
+		key := fetch.Name(planPath)
+		if !fetch.IsRemote(key) {
+			key = filepath.ToSlash(filepath.Clean(key))
+		}
+		ph = hist.Record(key, hash, rev)
+		if err := history.Save(ctx, b, histKey, hist); err != nil {
+			return Errorf(3, "%v", err)
+		}
+	}
+	expired := ph.Expire(rev, f.ExpireQuestionsAfter)
+	verbose("Plan revision %d: %d open CRITICAL questions, %d converted to issues", ph.Revision, len(ph.Questions), len(expired))
+	return nil
+}
+
+// historyID names the plan at planPath the same way for every client of
+// a shared question history: by its repository's origin remote and its
+// path in the work tree, or by its absolute path outside a repository.
+// A remote plan is named by its URL or reference.
+func historyID(planPath string) string {
+	if fetch.IsRemote(planPath) {
+		return fetch.Name(planPath)
+	}
+	if loc, err := git.Locate(planPath); err == nil {
+		return loc.Repo + "#" + loc.Path
+	}
+	if abs, err := filepath.Abs(planPath); err == nil {
+		return filepath.ToSlash(abs)
+	}
+	return filepath.ToSlash(filepath.Clean(planPath))
+}
+
+// validPatches returns the patches whose diffs parse and apply to the
+// plan lines.
+func validPatches(patches []review.Patch, lines []string, verbose func(string, ...any)) []review.Patch {
+	var valid []review.Patch
+	for _, pt := range patches {
+		if err := patch.Validate(pt.DiffUnified, lines); err != nil {
+			verbose("Dropping patch %s: %v", pt.ID, err)
+			continue
+		}
+		valid = append(valid, pt)
+	}
+	return valid
+}
+
+// embedInputs snapshots the plan and context files into rev. Contexts
+// pinned to a section are stored whole: the section is recorded in
+// rev.Input.
+func embedInputs(rev *review.Review, p *plan.Plan, joint []*plan.Plan, contexts []*pctx.File) error {
+	e, err := review.EmbedFile(review.EmbedRolePlan, rev.Input.PlanFile, p.Raw)
+	if err != nil {
+		return err
+	}
+	rev.Embedded = []review.EmbeddedFile{e}
+	for i, jp := range joint {
+		e, err := review.EmbedFile(review.EmbedRolePlan, rev.Input.Plans[i].Path, jp.Raw)
+		if err != nil {
+			return err
+		}
+		rev.Embedded = append(rev.Embedded, e)
+	}
+	for _, cf := range contexts {
+		e, err := review.EmbedFile(review.EmbedRoleContext, cf.Name, cf.Raw)
+		if err != nil {
+			return err
+		}
+		rev.Embedded = append(rev.Embedded, e)
+	}
+	return nil
+}
+
+// expandAliases returns f with its model and ensemble specs expanded
+// through f.Aliases.
+func expandAliases(f Options) Options {
+	if len(f.Aliases) == 0 {
+		return f
+	}
+	f.Model = llm.ExpandAlias(f.Aliases, f.Model)
+	if len(f.Ensemble) > 0 {
+		ensemble := make([]string, len(f.Ensemble))
+		for i, spec := range f.Ensemble {
+			ensemble[i] = llm.ExpandAlias(f.Aliases, strings.TrimSpace(spec))
+		}
+		f.Ensemble = ensemble
+	}
+	return f
+}
+
+// resolveProvider resolves the single model provider the options name.
+// f's aliases must already be expanded (see expandAliases).
+func resolveProvider(f Options) (llm.Provider, error) {
+	transport, err := llm.NewTransport(llm.TransportOptions{Proxy: f.Proxy, CACertFile: f.CACertFile})
+	if err != nil {
+		return nil, Errorf(3, "invalid network settings: %v", err)
+	}
+	p, err := llm.ResolveProviderWith(f.ProviderName, f.Model, llm.ProviderOptions{
+		APIBase:   f.APIBase,
+		Endpoint:  f.Endpoint,
+		Transport: transport,
+		Keys:      f.APIKeys,
+	})
+	if err != nil {
+		return nil, providerErrorf(err, "model provider error: %v", err)
+	}
+	return p, nil
+}
+
+// ContextDocument is a context file given as content rather than a
+// path.
+type ContextDocument struct {
+	Name string
+	Text string
+}
+
+// checkReadOnly rejects, in read-only mode, options that would read
+// inputs from or write anything to the filesystem.
+func checkReadOnly(f Options) error {
+	if !f.ReadOnly {
+		return nil
+	}
+	switch {
+	case f.PlanText == "":
+		return Errorf(3, "read-only mode takes the plan as content, not a path")
+	case len(f.ContextPaths) > 0 || len(f.ContextDirs) > 0:
+		return Errorf(3, "read-only mode takes context as content, not paths")
+	case len(f.JointPlans) > 0:
+		return Errorf(3, "read-only mode takes the plan as content, not paths")
+	case f.Since != "" || f.BaselinePath != "":
+		return Errorf(3, "read-only mode does not read earlier plan revisions or baselines")
+	case f.SuppressionsPath != "":
+		return Errorf(3, "read-only mode does not read a suppressions file")
+	case profile.IsRemote(f.ProfileName):
+		return Errorf(3, "read-only mode takes a built-in profile, not a remote profile")
+	case profile.IsPath(f.ProfileName):
+		return Errorf(3, "read-only mode takes a built-in profile, not a profile file")
+	case f.Debug:
+		return Errorf(3, "read-only mode does not write debug files")
+	case f.PatchOut != "":
+		return Errorf(3, "read-only mode does not write patch files")
+	case f.LogLLMDir != "":
+		return Errorf(3, "read-only mode does not write LLM transcripts")
+	case f.ExpireQuestionsAfter > 0:
+		return Errorf(3, "read-only mode does not keep a question history")
+	case f.TrainingDataDir != "":
+		return Errorf(3, "read-only mode does not write training data")
+	case f.ResponseCache && !f.NoCache && (f.StorageBackend == "" || f.StorageBackend == "fs" || strings.EqualFold(f.StorageBackend, "sqlite")):
+		return Errorf(3, "read-only mode cannot keep the response cache on disk; use the redis or s3 storage backend")
+	}
+	return nil
+}
+
 type Error struct {
 	Code int
 	Msg  string
+	// Class is set for provider failures (codes 4 and 7).
+	Class llm.ErrorClass
 }
 
 func (e *Error) Error() string { return e.Msg }
@@ -358,6 +1246,23 @@ func Errorf(code int, format string, args ...any) error {
 	return &Error{Code: code, Msg: fmt.Sprintf(format, args...)}
 }
 
+// providerErrorf returns the error for a failed provider call, classed
+// by llm.Classify. Retryable failures (rate limits, outages) exit 7
+// rather than 4 so scripts can tell them apart from auth, quota, and
+// request errors that need a fix first.
+func providerErrorf(err error, format string, args ...any) error {
+	class := llm.Classify(err)
+	var re *Error
+	if errors.As(err, &re) && re.Class != "" {
+		class = re.Class
+	}
+	code := 4
+	if class.Retryable() {
+		code = 7
+	}
+	return &Error{Code: code, Class: class, Msg: fmt.Sprintf(format, args...)}
+}
+
 func verboseLogger(enabled bool) func(string, ...any) {
 	logger := log.New(os.Stderr, "", 0)
 	return func(msg string, args ...any) {
@@ -469,6 +1374,91 @@ func ensureGeminiCache(ctx context.Context, provider llm.Provider, segments []ll
 	return handle.Name, nil
 }
 
-// estimatedCharsPerToken is a rough heuristic for converting prompt
-// character count to an approximate token count across LLM providers.
-const estimatedCharsPerToken = 4
+// evidenceRules converts a profile's evidence requirements for
+// schema.ValidateEvidenceRules.
+func evidenceRules(p *profile.Profile) schema.EvidenceRules {
+	var rules schema.EvidenceRules
+	for sev, n := range p.Evidence.MinCitations {
+		if rules.MinCitations == nil {
+			rules.MinCitations = map[review.Severity]int{}
+		}
+		rules.MinCitations[review.Severity(strings.ToUpper(sev))] = n
+	}
+	if p.Evidence.AllowContradictionPair {
+		for _, c := range p.Heuristics.Contradictions {
+			rules.ContradictionPairs = append(rules.ContradictionPairs, [2]string{c.TriggerA, c.TriggerB})
+		}
+	}
+	return rules
+}
+
+// severityBounds converts a profile's category severity bounds for
+// review.ClampSeverities.
+func severityBounds(p *profile.Profile) map[review.Category]review.SeverityBounds {
+	if len(p.CategorySeverity) == 0 {
+		return nil
+	}
+	bounds := make(map[review.Category]review.SeverityBounds, len(p.CategorySeverity))
+	for c, b := range p.CategorySeverity {
+		bounds[review.Category(c)] = review.SeverityBounds{Min: review.Severity(b.MinSeverity), Max: review.Severity(b.MaxSeverity)}
+	}
+	return bounds
+}
+
+// traceChecks sets the ID, Reference, and Required of each checklist
+// result from the profile check it answers, found by ID or else by
+// text, so a failure names the policy behind it whether or not the
+// model returned the ID. A result that answers no profile check keeps
+// the model's ID but is not required.
+func traceChecks(results []review.Checklist, checklists []profile.Checklist) {
+	for i := range results {
+		j := slices.IndexFunc(checklists, func(cl profile.Checklist) bool { return cl.ID == results[i].ID })
+		for k := range results[i].Checks {
+			item := &results[i].Checks[k]
+			// The model sometimes echoes the ID the prompt shows in
+			// brackets before the check text.
+			if id, text, ok := strings.Cut(item.Check, "] "); ok && strings.HasPrefix(id, "[") {
+				if item.ID == "" {
+					item.ID = id[1:]
+				}
+				item.Check = text
+			}
+			item.Reference, item.Required = "", false
+			if j < 0 {
+				continue
+			}
+			checks := checklists[j].Checks
+			n := -1
+			if item.ID != "" {
+				n = slices.IndexFunc(checks, func(c profile.Check) bool { return c.ID == item.ID })
+			}
+			if n < 0 {
+				n = slices.IndexFunc(checks, func(c profile.Check) bool {
+					return strings.EqualFold(strings.TrimSpace(c.Text), strings.TrimSpace(item.Check))
+				})
+			}
+			if n >= 0 {
+				c := checks[n]
+				item.ID, item.Reference, item.Required = c.ID, c.Reference, c.Required
+			}
+		}
+	}
+}
+
+// disabledCategories returns the categories a profile disables.
+func disabledCategories(p *profile.Profile) []review.Category {
+	var cats []review.Category
+	for _, c := range p.DisabledCategories {
+		cats = append(cats, review.Category(c))
+	}
+	return cats
+}
+
+// timeoutHint annotates a per-request deadline error with the
+// configured timeout so CI logs say how to fix it.
+func timeoutHint(err error, timeout time.Duration) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w (request exceeded --timeout %s)", err, timeout)
+	}
+	return err
+}
@@ -0,0 +1,53 @@
+package reviewer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/dshills/plancritic/internal/llm"
+	"github.com/dshills/plancritic/internal/prompt"
+	"github.com/dshills/plancritic/internal/review"
+)
+
+// findings counts a review's issues and questions.
+func findings(rev review.Review) int {
+	return len(rev.Issues) + len(rev.Questions)
+}
+
+// suspiciouslyEmpty reports whether rev has fewer than minFindings
+// findings for a plan whose metrics show gaps a careful reviewer would
+// mention: steps without acceptance criteria or estimates, or no
+// recognisable steps at all. A plan that covers both is allowed to
+// come back clean.
+func suspiciouslyEmpty(rev review.Review, m review.PlanMetrics, minFindings int) bool {
+	if minFindings <= 0 || findings(rev) >= minFindings {
+		return false
+	}
+	return m.StepCount == 0 || m.AcceptanceCoverage < 100 || m.EstimateCoverage < 100
+}
+
+// sanityRetry asks the model once more, with a nudge appended to the
+// prompt, after a suspiciously empty review. It returns the response
+// with more findings (the original on a tie or when the retry fails),
+// with usage and cost covering both calls.
+func (c *call) sanityRetry(ctx context.Context, provider llm.Provider, first callResult) callResult {
+	c.verbose("Only %d findings; retrying once with a second-look prompt", findings(first.rev))
+	rc := *c
+	rc.segments = append(slices.Clip(c.segments), llm.Segment{Text: prompt.BuildSanityNudge(findings(first.rev))})
+	retry, err := rc.run(ctx, provider)
+
+	best := first
+	if err == nil && findings(retry.rev) > findings(first.rev) {
+		best = retry
+	} else if err != nil {
+		// The first review validated; a failed second look must not
+		// throw it away.
+		fmt.Fprintf(os.Stderr, "plancritic: warning: sanity retry failed: %v\n", err)
+	}
+	best.usage = first.usage.Add(retry.usage)
+	best.cost = first.cost + retry.cost
+	best.priced = first.priced && (err != nil || retry.priced)
+	return best
+}
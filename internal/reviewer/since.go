@@ -0,0 +1,176 @@
+package reviewer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/dshills/plancritic/internal/fetch"
+	"github.com/dshills/plancritic/internal/git"
+	"github.com/dshills/plancritic/internal/plan"
+	"github.com/dshills/plancritic/internal/prompt"
+	"github.com/dshills/plancritic/internal/review"
+)
+
+// baselineTag marks the issues an incremental review carries forward
+// from the baseline review.
+const baselineTag = "baseline"
+
+// incremental is the earlier revision of the plan an incremental review
+// (Options.Since) compares it with, and the earlier review's findings
+// that still apply.
+type incremental struct {
+	rev      string
+	old      *plan.Plan
+	baseline *review.Review
+	changes  []plan.Change
+	// issues and questions are the baseline findings whose plan
+	// evidence is unchanged, renumbered to the plan's current lines.
+	issues    []review.Issue
+	questions []review.Question
+}
+
+// loadIncremental reads the plan as of f.Since from git and, with
+// f.BaselinePath, the review of that revision. It returns nil when the
+// review is not incremental.
+func loadIncremental(planPath string, f Options, verbose func(string, ...any)) (*incremental, error) {
+	if f.Since == "" {
+		if f.BaselinePath != "" {
+			return nil, Errorf(3, "a baseline review needs --since, the revision of the plan it reviewed")
+		}
+		return nil, nil
+	}
+	switch {
+	case f.PlanText != "" || fetch.IsRemote(planPath):
+		return nil, Errorf(3, "--since needs a plan file in a git repository")
+	case len(f.JointPlans) > 0:
+		return nil, Errorf(3, "--since cannot be combined with a joint review")
+	case f.Chunk:
+		return nil, Errorf(3, "--since cannot be combined with --chunk")
+	}
+
+	verbose("Reading plan as of %s", f.Since)
+	text, err := git.Show(planPath, f.Since)
+	if err != nil {
+		return nil, Errorf(3, "failed to read the plan as of %s: %v", f.Since, err)
+	}
+	if err := checkInput(planPath+"@"+f.Since, text, f); err != nil {
+		return nil, Errorf(3, "failed to load plan: %v%s", err, inputHint(err))
+	}
+	inc := &incremental{rev: f.Since, old: plan.Parse(planPath, text)}
+	if f.BaselinePath == "" {
+		return inc, nil
+	}
+
+	data, err := os.ReadFile(f.BaselinePath)
+	if err != nil {
+		return nil, Errorf(3, "failed to read baseline review: %v", err)
+	}
+	var base review.Review
+	if err := json.Unmarshal(data, &base); err != nil {
+		return nil, Errorf(3, "failed to parse baseline review %s: %v", f.BaselinePath, err)
+	}
+	if base.Input.PlanHash != inc.old.Hash {
+		return nil, Errorf(3, "baseline review %s is not of the plan as of %s (plan hash %s, want %s)", f.BaselinePath, f.Since, base.Input.PlanHash, inc.old.Hash)
+	}
+	inc.baseline = &base
+	return inc, nil
+}
+
+// compare diffs the earlier revision with p and keeps the baseline
+// findings whose plan evidence p still has unchanged. Locally found
+// dependency issues are not carried; they are found again.
+func (inc *incremental) compare(p *plan.Plan) {
+	inc.changes = plan.Diff(inc.old.Lines, p.Lines)
+	if inc.baseline == nil {
+		return
+	}
+	for _, iss := range inc.baseline.Issues {
+		if slices.Contains(iss.Tags, dependencyTag) {
+			continue
+		}
+		ev, ok := carryEvidence(iss.Evidence, inc.changes)
+		if !ok {
+			continue
+		}
+		iss.ID = fmt.Sprintf("ISSUE-PREV-%03d", len(inc.issues)+1)
+		iss.Evidence = ev
+		if !slices.Contains(iss.Tags, baselineTag) {
+			iss.Tags = append(slices.Clip(iss.Tags), baselineTag)
+		}
+		inc.issues = append(inc.issues, iss)
+	}
+	for _, q := range inc.baseline.Questions {
+		ev, ok := carryEvidence(q.Evidence, inc.changes)
+		if !ok {
+			continue
+		}
+		q.ID = fmt.Sprintf("Q-PREV-%03d", len(inc.questions)+1)
+		q.Evidence = ev
+		inc.questions = append(inc.questions, q)
+	}
+}
+
+// carryEvidence renumbers plan evidence to the new revision's lines. It
+// reports false when a cited line changed, so the finding is left to
+// the model's review of the change.
+func carryEvidence(evidence []review.Evidence, changes []plan.Change) ([]review.Evidence, bool) {
+	out := make([]review.Evidence, len(evidence))
+	for i, ev := range evidence {
+		if ev.Source == "plan" {
+			start, ok1 := plan.MapLine(changes, ev.LineStart)
+			end, ok2 := plan.MapLine(changes, ev.LineEnd)
+			// Lines inserted inside the range shift its end further than
+			// its start.
+			if !ok1 || !ok2 || end-start != ev.LineEnd-ev.LineStart {
+				return nil, false
+			}
+			ev.LineStart, ev.LineEnd = start, end
+		}
+		out[i] = ev
+	}
+	return out, true
+}
+
+// revision describes the earlier revision to the prompt; nil when the
+// review is not incremental.
+func (inc *incremental) revision() *prompt.Revision {
+	if inc == nil {
+		return nil
+	}
+	r := &prompt.Revision{Rev: inc.rev, Old: inc.old.Lines, Changes: inc.changes}
+	for _, iss := range inc.issues {
+		r.Carried = append(r.Carried, fmt.Sprintf("%s %s: %s", iss.Severity, iss.Category, iss.Title))
+	}
+	for _, q := range inc.questions {
+		r.Carried = append(r.Carried, "QUESTION: "+q.Question)
+	}
+	return r
+}
+
+// addCarried adds the carried baseline findings to rev, except those
+// the model reported again, and returns how many it added.
+func (inc *incremental) addCarried(rev *review.Review) int {
+	seen := map[string]bool{}
+	for _, iss := range rev.Issues {
+		seen[review.Fingerprint(iss)] = true
+	}
+	for _, q := range rev.Questions {
+		seen[review.QuestionFingerprint(q)] = true
+	}
+	added := 0
+	for _, iss := range inc.issues {
+		if !seen[review.Fingerprint(iss)] {
+			rev.Issues = append(rev.Issues, iss)
+			added++
+		}
+	}
+	for _, q := range inc.questions {
+		if !seen[review.QuestionFingerprint(q)] {
+			rev.Questions = append(rev.Questions, q)
+			added++
+		}
+	}
+	return added
+}
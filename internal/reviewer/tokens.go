@@ -0,0 +1,82 @@
+package reviewer
+
+import "github.com/dshills/plancritic/internal/llm"
+
+// Response size estimates for scaling max tokens to the plan. A finding
+// with evidence, a suggestion, and its JSON framing runs to a few
+// hundred tokens; a question to about half that.
+const (
+	autoTokensBase        = 1024 // summary, verdict, and JSON framing
+	autoTokensPerIssue    = 300
+	autoTokensPerQuestion = 120
+	autoTokensMin         = 2048
+	autoTokensMax         = 32768
+	// unknownOutputLimit bounds models with no known limit, such as
+	// local ones, which commonly serve 8k outputs.
+	unknownOutputLimit = 8192
+)
+
+// autoMaxTokens returns the response token budget for a plan of
+// planLines lines when --max-tokens is not set. The expected number of
+// findings grows with the plan and is capped by maxIssues and
+// maxQuestions; the budget is rounded up to a multiple of 1024 and
+// bounded by limit, the model's output limit (0 if unknown).
+func autoMaxTokens(planLines, maxIssues, maxQuestions, limit int) int {
+	issues := min(maxIssues, 5+planLines/10)
+	questions := min(maxQuestions, 3+planLines/25)
+	n := autoTokensBase + issues*autoTokensPerIssue + questions*autoTokensPerQuestion
+	n = (n + 1023) / 1024 * 1024
+	n = max(n, autoTokensMin)
+	if limit <= 0 {
+		limit = unknownOutputLimit
+	}
+	return min(n, autoTokensMax, limit)
+}
+
+// outputLimit returns the output token limit of the single provider,
+// or the smallest across ensemble members, which share one budget; 0
+// if unknown.
+func outputLimit(p llm.Provider, members []ensembleMember, model string) int {
+	if len(members) == 0 {
+		if p == nil {
+			return 0
+		}
+		return llm.MaxOutputTokens(p, model)
+	}
+	limit := 0
+	for _, m := range members {
+		l := llm.MaxOutputTokens(m.provider, "")
+		if l <= 0 {
+			l = unknownOutputLimit
+		}
+		if limit == 0 || l < limit {
+			limit = l
+		}
+	}
+	return limit
+}
+
+// contextBudget returns the input token budget a chunked review splits
+// the plan to fit when --max-input-tokens is not set: three quarters of
+// the smallest context window among the providers, leaving the rest for
+// the response; 0 if any window is unknown.
+func contextBudget(p llm.Provider, members []ensembleMember, model string) int {
+	var windows []int
+	if len(members) == 0 && p != nil {
+		windows = append(windows, p.Capabilities(model).MaxContextTokens)
+	}
+	for _, m := range members {
+		windows = append(windows, m.provider.Capabilities("").MaxContextTokens)
+	}
+	if len(windows) == 0 {
+		return 0
+	}
+	smallest := windows[0]
+	for _, w := range windows {
+		if w <= 0 {
+			return 0
+		}
+		smallest = min(smallest, w)
+	}
+	return smallest * 3 / 4
+}
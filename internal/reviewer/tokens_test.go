@@ -0,0 +1,46 @@
+package reviewer
+
+import (
+	"testing"
+
+	"github.com/dshills/plancritic/internal/llm"
+)
+
+func TestAutoMaxTokens(t *testing.T) {
+	cases := []struct {
+		name                     string
+		lines, issues, qs, limit int
+		want                     int
+	}{
+		{"tiny plan", 5, 50, 20, 64000, 3072},
+		{"short plan", 20, 50, 20, 64000, 4096},
+		{"long plan", 500, 50, 20, 64000, 18432},
+		{"few issues", 500, 10, 5, 64000, 5120},
+		{"huge plan", 5000, 200, 100, 128000, 32768},
+		{"model limit", 500, 50, 20, 16384, 16384},
+		{"unknown limit", 500, 50, 20, 0, 8192},
+		{"floor", 0, 1, 0, 64000, 2048},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := autoMaxTokens(tc.lines, tc.issues, tc.qs, tc.limit); got != tc.want {
+				t.Errorf("autoMaxTokens(%d, %d, %d, %d) = %d, want %d", tc.lines, tc.issues, tc.qs, tc.limit, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestContextBudget(t *testing.T) {
+	anthropic := &llm.AnthropicProvider{}
+	if got := contextBudget(anthropic, nil, "claude-sonnet-4-6"); got != 150000 {
+		t.Errorf("single provider budget = %d, want 150000", got)
+	}
+	members := []ensembleMember{{provider: anthropic}, {provider: &llm.GeminiProvider{}}}
+	if got := contextBudget(nil, members, ""); got != 150000 {
+		t.Errorf("ensemble budget = %d, want the smallest window's", got)
+	}
+	members = append(members, ensembleMember{provider: &llm.LocalProvider{}})
+	if got := contextBudget(nil, members, ""); got != 0 {
+		t.Errorf("budget with an unknown window = %d, want 0", got)
+	}
+}
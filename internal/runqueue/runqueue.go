@@ -0,0 +1,133 @@
+// Package runqueue coalesces bursts of review requests from interactive
+// front ends (watch mode, LSP diagnostics, editor integrations) into at
+// most one in-flight LLM call.
+//
+// Every keystroke or save submits the latest plan state. The queue
+// waits until submissions have been quiet for the debounce interval,
+// then runs the newest one. A submission that arrives while a run is in
+// flight cancels it, since its result would describe text that no
+// longer exists, and the newest state runs once the cancelled run has
+// returned. Run starts are also spaced at least MinInterval apart, so a
+// steady stream of edits cannot turn into a steady stream of API calls.
+//
+// Use one Queue per document when documents are reviewed independently.
+package runqueue
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Queue debounces submitted values and runs the latest one.
+type Queue[T any] struct {
+	debounce    time.Duration
+	minInterval time.Duration
+	run         func(ctx context.Context, v T)
+
+	mu         sync.Mutex
+	pending    T
+	hasPending bool
+	lastSubmit time.Time
+	lastStart  time.Time
+	timer      *time.Timer
+	running    bool
+	cancel     context.CancelFunc
+	closed     bool
+	idle       *sync.Cond
+}
+
+// New returns a queue that calls run with the newest value once
+// submissions have been quiet for debounce, with run starts at least
+// minInterval apart. run must return promptly when its context is
+// cancelled.
+func New[T any](debounce, minInterval time.Duration, run func(ctx context.Context, v T)) *Queue[T] {
+	q := &Queue[T]{debounce: debounce, minInterval: minInterval, run: run}
+	q.idle = sync.NewCond(&q.mu)
+	return q
+}
+
+// Submit replaces any pending value with v and cancels the in-flight
+// run, if any. Submitting to a closed queue does nothing.
+func (q *Queue[T]) Submit(v T) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.pending, q.hasPending = v, true
+	q.lastSubmit = time.Now()
+	if q.cancel != nil {
+		q.cancel()
+	}
+	q.scheduleLocked()
+}
+
+// Close drops any pending value, cancels the in-flight run, and waits
+// for it to return.
+func (q *Queue[T]) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.hasPending = false
+	if q.timer != nil {
+		q.timer.Stop()
+	}
+	if q.cancel != nil {
+		q.cancel()
+	}
+	for q.running {
+		q.idle.Wait()
+	}
+}
+
+// scheduleLocked arms the timer for the next start: after the debounce
+// interval since the last submission and the minimum interval since the
+// last start.
+func (q *Queue[T]) scheduleLocked() {
+	now := time.Now()
+	delay := q.lastSubmit.Add(q.debounce).Sub(now)
+	if !q.lastStart.IsZero() {
+		delay = max(delay, q.lastStart.Add(q.minInterval).Sub(now))
+	}
+	delay = max(delay, 0)
+	if q.timer == nil {
+		q.timer = time.AfterFunc(delay, q.fire)
+		return
+	}
+	q.timer.Reset(delay)
+}
+
+// fire starts the pending value unless a run is still in flight; that
+// run reschedules when it returns.
+func (q *Queue[T]) fire() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed || !q.hasPending || q.running {
+		return
+	}
+	// The timer may fire early if Submit reset it concurrently.
+	if time.Now().Before(q.lastSubmit.Add(q.debounce)) {
+		q.scheduleLocked()
+		return
+	}
+
+	v := q.pending
+	var zero T
+	q.pending, q.hasPending = zero, false
+	ctx, cancel := context.WithCancel(context.Background())
+	q.running, q.cancel = true, cancel
+	q.lastStart = time.Now()
+
+	go func() {
+		q.run(ctx, v)
+		cancel()
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		q.running, q.cancel = false, nil
+		q.idle.Broadcast()
+		if q.hasPending && !q.closed {
+			q.scheduleLocked()
+		}
+	}()
+}
@@ -0,0 +1,109 @@
+package runqueue
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder collects the values a queue ran and whether each run was
+// cancelled.
+type recorder struct {
+	mu        sync.Mutex
+	ran       []int
+	cancelled []int
+	started   chan int
+}
+
+func (r *recorder) run(hold time.Duration) func(context.Context, int) {
+	return func(ctx context.Context, v int) {
+		r.mu.Lock()
+		r.ran = append(r.ran, v)
+		r.mu.Unlock()
+		if r.started != nil {
+			r.started <- v
+		}
+		select {
+		case <-ctx.Done():
+			r.mu.Lock()
+			r.cancelled = append(r.cancelled, v)
+			r.mu.Unlock()
+		case <-time.After(hold):
+		}
+	}
+}
+
+func (r *recorder) snapshot() ([]int, []int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int(nil), r.ran...), append([]int(nil), r.cancelled...)
+}
+
+func TestBurstRunsLatestOnce(t *testing.T) {
+	var r recorder
+	q := New(20*time.Millisecond, 0, r.run(0))
+	for i := 1; i <= 10; i++ {
+		q.Submit(i)
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	q.Close()
+
+	ran, _ := r.snapshot()
+	if len(ran) != 1 || ran[0] != 10 {
+		t.Errorf("ran %v, want [10]", ran)
+	}
+}
+
+func TestSubmitCancelsInFlightRun(t *testing.T) {
+	r := recorder{started: make(chan int, 4)}
+	q := New(5*time.Millisecond, 0, r.run(time.Second))
+	q.Submit(1)
+	<-r.started
+	q.Submit(2)
+	if v := <-r.started; v != 2 {
+		t.Fatalf("second run got %d, want 2", v)
+	}
+	q.Close()
+
+	ran, cancelled := r.snapshot()
+	if len(ran) != 2 || len(cancelled) != 2 || cancelled[0] != 1 {
+		t.Errorf("ran %v cancelled %v; want run 1 superseded by 2, then 2 cancelled by Close", ran, cancelled)
+	}
+}
+
+func TestMinIntervalSpacesStarts(t *testing.T) {
+	var mu sync.Mutex
+	var starts []time.Time
+	q := New(0, 60*time.Millisecond, func(ctx context.Context, v int) {
+		mu.Lock()
+		starts = append(starts, time.Now())
+		mu.Unlock()
+	})
+	q.Submit(1)
+	time.Sleep(10 * time.Millisecond)
+	q.Submit(2)
+	time.Sleep(120 * time.Millisecond)
+	q.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(starts) != 2 {
+		t.Fatalf("starts = %d, want 2", len(starts))
+	}
+	if gap := starts[1].Sub(starts[0]); gap < 60*time.Millisecond {
+		t.Errorf("starts %v apart, want >= 60ms", gap)
+	}
+}
+
+func TestSubmitAfterCloseIsIgnored(t *testing.T) {
+	var r recorder
+	q := New(0, 0, r.run(0))
+	q.Close()
+	q.Submit(1)
+	time.Sleep(20 * time.Millisecond)
+	if ran, _ := r.snapshot(); len(ran) != 0 {
+		t.Errorf("ran %v after Close", ran)
+	}
+}
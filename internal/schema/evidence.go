@@ -0,0 +1,92 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dshills/plancritic/internal/review"
+)
+
+// EvidenceRules raises the number of evidence citations an issue must
+// carry, by severity. The zero value requires nothing beyond Validate's
+// one citation.
+type EvidenceRules struct {
+	// MinCitations maps a severity to the fewest evidence entries an
+	// issue of that severity must cite.
+	MinCitations map[review.Severity]int
+	// ContradictionPairs, when set, let an issue meet its minimum with
+	// a single citation whose lines contain both phrases of a pair
+	// (case-insensitive): the citation then shows the contradiction on
+	// its own.
+	ContradictionPairs [][2]string
+}
+
+// ValidateEvidenceRules checks every issue against rules. src resolves
+// citations to line text for the contradiction-pair exception; a
+// citation that cannot be resolved never satisfies it.
+func ValidateEvidenceRules(r *review.Review, rules EvidenceRules, src review.QuoteSource) []ValidationError {
+	var errs []ValidationError
+	for i, iss := range r.Issues {
+		if meetsEvidenceRules(iss, rules, src) {
+			continue
+		}
+		min := rules.MinCitations[iss.Severity]
+		msg := fmt.Sprintf("%s issues need at least %d evidence entries, got %d", iss.Severity, min, len(iss.Evidence))
+		if len(rules.ContradictionPairs) > 0 {
+			msg += " (or one citation containing both sides of a profile contradiction pair)"
+		}
+		errs = append(errs, ValidationError{fmt.Sprintf("issues[%d].evidence", i), msg})
+	}
+	return errs
+}
+
+// DowngradeUnsupported lowers the severity of each issue that fails
+// rules, CRITICAL to WARN and WARN to INFO, until it meets them, and
+// returns how many issues it lowered. A lowered issue no longer blocks.
+func DowngradeUnsupported(r *review.Review, rules EvidenceRules, src review.QuoteSource) int {
+	n := 0
+	for i := range r.Issues {
+		iss := &r.Issues[i]
+		lowered := false
+		for !meetsEvidenceRules(*iss, rules, src) && iss.Severity != review.SeverityInfo {
+			if iss.Severity == review.SeverityCritical {
+				iss.Severity = review.SeverityWarn
+			} else {
+				iss.Severity = review.SeverityInfo
+			}
+			lowered = true
+		}
+		if lowered {
+			iss.Blocking = false
+			n++
+		}
+	}
+	return n
+}
+
+// meetsEvidenceRules reports whether iss cites enough evidence for its
+// severity under rules.
+func meetsEvidenceRules(iss review.Issue, rules EvidenceRules, src review.QuoteSource) bool {
+	if len(iss.Evidence) >= rules.MinCitations[iss.Severity] || len(iss.Evidence) == 0 {
+		// No evidence at all is already reported by Validate.
+		return true
+	}
+	return len(iss.Evidence) == 1 && citesContradictionPair(iss.Evidence[0], rules.ContradictionPairs, src)
+}
+
+func citesContradictionPair(ev review.Evidence, pairs [][2]string, src review.QuoteSource) bool {
+	if len(pairs) == 0 {
+		return false
+	}
+	text, ok := src.Text(ev)
+	if !ok {
+		return false
+	}
+	text = strings.ToLower(text)
+	for _, p := range pairs {
+		if strings.Contains(text, strings.ToLower(p[0])) && strings.Contains(text, strings.ToLower(p[1])) {
+			return true
+		}
+	}
+	return false
+}
@@ -3,6 +3,9 @@ package schema
 
 import (
 	"fmt"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/dshills/plancritic/internal/review"
 )
@@ -20,11 +23,30 @@ func (v ValidationError) Error() string {
 // Validate checks a Review for structural validity.
 // planLineCount is the total number of lines in the plan file (0 to
 // skip plan line-range checks). contextLineCounts maps a context
-// file's basename (the identifier used in the prompt, matching
-// Evidence.Path) to its total line count; pass nil to skip context
+// file's name (the path the prompt shows for it, which Evidence.Path
+// cites; see review.ResolveContextPath) to its total line count; pass nil to skip context
 // line-range checks. Range checks are only enforced when a positive
 // count is supplied for the cited source.
 func Validate(r *review.Review, planLineCount int, contextLineCounts map[string]int) []ValidationError {
+	return ValidateSources(r, Sources{PlanLines: planLineCount, Contexts: contextLineCounts})
+}
+
+// Sources gives the line counts Validate checks evidence ranges
+// against.
+type Sources struct {
+	PlanLines int
+	Contexts  map[string]int
+	// Plans, set for a joint review, maps every plan document's name to
+	// its line count. Plan evidence must then name one of them in its
+	// path, and PlanLines is not used.
+	Plans map[string]int
+	// Categories are issue categories accepted besides the built-in
+	// ones: those the review's profile defines.
+	Categories []review.Category
+}
+
+// ValidateSources is Validate with the line counts of a joint review.
+func ValidateSources(r *review.Review, src Sources) []ValidationError {
 	var errs []ValidationError
 
 	// Note: tool, version, score, and severity counts are NOT validated here
@@ -50,7 +72,7 @@ func Validate(r *review.Review, planLineCount int, contextLineCounts map[string]
 		if !iss.Severity.Valid() {
 			errs = append(errs, ValidationError{prefix + ".severity", fmt.Sprintf("invalid: %q", iss.Severity)})
 		}
-		if !iss.Category.Valid() {
+		if !iss.Category.Valid() && !slices.Contains(src.Categories, iss.Category) {
 			errs = append(errs, ValidationError{prefix + ".category", fmt.Sprintf("invalid: %q", iss.Category)})
 		}
 		if iss.Title == "" {
@@ -63,7 +85,7 @@ func Validate(r *review.Review, planLineCount int, contextLineCounts map[string]
 			errs = append(errs, ValidationError{prefix + ".evidence", "at least one evidence entry required"})
 		}
 		for j, ev := range iss.Evidence {
-			errs = append(errs, validateEvidence(fmt.Sprintf("%s.evidence[%d]", prefix, j), ev, planLineCount, contextLineCounts)...)
+			errs = append(errs, validateEvidence(fmt.Sprintf("%s.evidence[%d]", prefix, j), ev, src)...)
 		}
 	}
 
@@ -91,7 +113,20 @@ func Validate(r *review.Review, planLineCount int, contextLineCounts map[string]
 			errs = append(errs, ValidationError{prefix + ".evidence", "at least one evidence entry required"})
 		}
 		for j, ev := range q.Evidence {
-			errs = append(errs, validateEvidence(fmt.Sprintf("%s.evidence[%d]", prefix, j), ev, planLineCount, contextLineCounts)...)
+			errs = append(errs, validateEvidence(fmt.Sprintf("%s.evidence[%d]", prefix, j), ev, src)...)
+		}
+	}
+
+	// Validate checklists
+	for i, cl := range r.Checklists {
+		for j, c := range cl.Checks {
+			prefix := fmt.Sprintf("checklists[%d].checks[%d]", i, j)
+			if !c.Status.Valid() {
+				errs = append(errs, ValidationError{prefix + ".status", fmt.Sprintf("invalid: %q", c.Status)})
+			}
+			for k, ev := range c.Evidence {
+				errs = append(errs, validateEvidence(fmt.Sprintf("%s.evidence[%d]", prefix, k), ev, src)...)
+			}
 		}
 	}
 
@@ -115,7 +150,8 @@ func Validate(r *review.Review, planLineCount int, contextLineCounts map[string]
 	return errs
 }
 
-func validateEvidence(prefix string, ev review.Evidence, planLineCount int, contextLineCounts map[string]int) []ValidationError {
+func validateEvidence(prefix string, ev review.Evidence, src Sources) []ValidationError {
+	planLineCount, contextLineCounts := src.PlanLines, src.Contexts
 	var errs []ValidationError
 	if ev.Source != "plan" && ev.Source != "context" {
 		errs = append(errs, ValidationError{prefix + ".source", fmt.Sprintf("must be 'plan' or 'context', got %q", ev.Source)})
@@ -129,7 +165,19 @@ func validateEvidence(prefix string, ev review.Evidence, planLineCount int, cont
 	if ev.LineEnd < ev.LineStart {
 		errs = append(errs, ValidationError{prefix + ".line_end", "must be >= line_start"})
 	}
-	if planLineCount > 0 && ev.Source == "plan" && ev.LineEnd > planLineCount {
+	if ev.Source == "plan" && src.Plans != nil {
+		key, err := review.ResolveContextPath(src.Plans, ev.Path)
+		if err != nil {
+			names := make([]string, 0, len(src.Plans))
+			for name := range src.Plans {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			errs = append(errs, ValidationError{prefix + ".path", fmt.Sprintf("plan %q was not provided: cite one of %s", ev.Path, strings.Join(names, ", "))})
+		} else if count := src.Plans[key]; ev.LineEnd > count {
+			errs = append(errs, ValidationError{prefix + ".line_end", fmt.Sprintf("exceeds plan %q line count (%d)", key, count)})
+		}
+	} else if planLineCount > 0 && ev.Source == "plan" && ev.LineEnd > planLineCount {
 		errs = append(errs, ValidationError{prefix + ".line_end", fmt.Sprintf("exceeds plan line count (%d)", planLineCount)})
 	}
 	// Callers pass nil to skip context-side validation (used by tests
@@ -137,11 +185,10 @@ func validateEvidence(prefix string, ev review.Evidence, planLineCount int, cont
 	// non-nil map means "no context files were provided" and any
 	// "context" citation from the LLM is therefore invalid.
 	if ev.Source == "context" && contextLineCounts != nil && ev.Path != "" {
-		key := review.NormalizeContextPath(ev.Path)
-		count, ok := contextLineCounts[key]
-		if !ok {
-			errs = append(errs, ValidationError{prefix + ".path", fmt.Sprintf("context %q was not provided", key)})
-		} else if ev.LineEnd > count {
+		key, err := review.ResolveContextPath(contextLineCounts, ev.Path)
+		if err != nil {
+			errs = append(errs, ValidationError{prefix + ".path", err.Error()})
+		} else if count := contextLineCounts[key]; ev.LineEnd > count {
 			errs = append(errs, ValidationError{prefix + ".line_end", fmt.Sprintf("exceeds context %q line count (%d)", key, count)})
 		}
 	}
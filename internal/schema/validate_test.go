@@ -100,6 +100,22 @@ func TestValidateLineExceedsPlan(t *testing.T) {
 	}
 }
 
+func TestValidateSourcesJointPlans(t *testing.T) {
+	r := validReview()
+	r.Issues[0].Evidence = append(r.Issues[0].Evidence, review.Evidence{Source: "plan", Path: "rollout.md", LineStart: 40, LineEnd: 41})
+	src := Sources{PlanLines: 10, Plans: map[string]int{"plan.md": 10, "rollout.md": 50}}
+	if errs := ValidateSources(r, src); len(errs) != 0 {
+		t.Errorf("expected no errors, got %v", errs)
+	}
+
+	r.Issues[0].Evidence[1].Path = "design.md"
+	r.Questions[0].Evidence[0].LineEnd = 12
+	errs := ValidateSources(r, src)
+	if len(errs) != 2 || !strings.Contains(errs[0].Message, "cite one of plan.md, rollout.md") || !strings.Contains(errs[1].Message, `plan "plan.md" line count (10)`) {
+		t.Errorf("errors = %v, want an unknown plan and a line past plan.md", errs)
+	}
+}
+
 func TestValidatePatches(t *testing.T) {
 	r := validReview()
 	r.Patches = []review.Patch{
@@ -134,6 +150,17 @@ func TestValidateIssueInvalidCategory(t *testing.T) {
 	assertHasError(t, errs, "issues[0].category", "invalid")
 }
 
+func TestValidateSourcesCustomCategory(t *testing.T) {
+	r := validReview()
+	r.Issues[0].Category = "PRIVACY_RISK"
+	errs := ValidateSources(r, Sources{Categories: []review.Category{"PRIVACY_RISK"}})
+	if len(errs) != 0 {
+		t.Errorf("expected no errors, got %v", errs)
+	}
+	errs = ValidateSources(r, Sources{Categories: []review.Category{"COST_RISK"}})
+	assertHasError(t, errs, "issues[0].category", "invalid")
+}
+
 func TestValidateIssueEmptyTitle(t *testing.T) {
 	r := validReview()
 	r.Issues[0].Title = ""
@@ -276,6 +303,23 @@ func TestValidateEvidenceContextWithinBounds(t *testing.T) {
 	}
 }
 
+func TestValidateEvidenceContextAmbiguous(t *testing.T) {
+	// Two context files share a base name; citing only the base name
+	// cannot say which was meant.
+	r := validReview()
+	r.Issues[0].Evidence[0].Source = "context"
+	r.Issues[0].Evidence[0].Path = "constraints.md"
+	errs := Validate(r, 0, map[string]int{"api/constraints.md": 10, "db/constraints.md": 10})
+	assertHasError(t, errs, "issues[0].evidence[0].path", "ambiguous: cite one of api/constraints.md, db/constraints.md")
+
+	r.Issues[0].Evidence[0].Path = "db/constraints.md"
+	for _, e := range Validate(r, 0, map[string]int{"api/constraints.md": 10, "db/constraints.md": 10}) {
+		if strings.Contains(e.Path, "issues[0].evidence[0]") {
+			t.Errorf("unexpected evidence error: %s", e)
+		}
+	}
+}
+
 func TestValidateEvidenceInvalidSource(t *testing.T) {
 	r := validReview()
 	r.Issues[0].Evidence[0].Source = "filesystem"
@@ -303,3 +347,57 @@ func assertHasError(t *testing.T, errs []ValidationError, path, msgSubstring str
 	}
 	t.Errorf("expected validation error at path %q containing %q, got errors: %v", path, msgSubstring, errs)
 }
+
+func TestValidateEvidenceRules(t *testing.T) {
+	rules := EvidenceRules{MinCitations: map[review.Severity]int{review.SeverityCritical: 2}}
+	src := review.QuoteSource{PlanLines: []string{"Keep it dependency-free.", "Then add dependency on redis.", "x", "y"}}
+
+	r := validReview()
+	errs := ValidateEvidenceRules(r, rules, src)
+	if len(errs) != 1 || errs[0].Path != "issues[0].evidence" {
+		t.Fatalf("errs = %v, want one evidence error", errs)
+	}
+
+	// A second citation meets the minimum.
+	r.Issues[0].Evidence = append(r.Issues[0].Evidence, review.Evidence{Source: "plan", Path: "plan.md", LineStart: 3, LineEnd: 3})
+	if errs := ValidateEvidenceRules(r, rules, src); len(errs) != 0 {
+		t.Errorf("errs = %v, want none", errs)
+	}
+
+	// One citation covering both sides of a contradiction pair also does.
+	r = validReview()
+	rules.ContradictionPairs = [][2]string{{"Dependency-Free", "add dependency"}}
+	if errs := ValidateEvidenceRules(r, rules, src); len(errs) != 0 {
+		t.Errorf("errs = %v, want none", errs)
+	}
+	r.Issues[0].Evidence[0].LineEnd = 1
+	if errs := ValidateEvidenceRules(r, rules, src); len(errs) != 1 || !strings.Contains(errs[0].Message, "contradiction pair") {
+		t.Errorf("errs = %v, want the pair hint", errs)
+	}
+
+	// WARN issues are not affected.
+	r.Issues[0].Severity = review.SeverityWarn
+	if errs := ValidateEvidenceRules(r, rules, src); len(errs) != 0 {
+		t.Errorf("errs = %v, want none for WARN", errs)
+	}
+}
+
+func TestDowngradeUnsupported(t *testing.T) {
+	rules := EvidenceRules{MinCitations: map[review.Severity]int{review.SeverityCritical: 2, review.SeverityWarn: 2}}
+	r := validReview()
+	r.Issues[0].Blocking = true
+	r.Issues = append(r.Issues, r.Issues[0])
+	r.Issues[1].Evidence = append(r.Issues[1].Evidence, r.Issues[1].Evidence[0])
+	if n := DowngradeUnsupported(r, rules, review.QuoteSource{}); n != 1 {
+		t.Fatalf("lowered %d issues, want 1", n)
+	}
+	if iss := r.Issues[0]; iss.Severity != review.SeverityInfo || iss.Blocking {
+		t.Errorf("unsupported issue = %s (blocking %v), want a non-blocking INFO", iss.Severity, iss.Blocking)
+	}
+	if r.Issues[1].Severity != review.SeverityCritical {
+		t.Errorf("supported issue lowered to %s", r.Issues[1].Severity)
+	}
+	if errs := ValidateEvidenceRules(r, rules, review.QuoteSource{}); len(errs) != 0 {
+		t.Errorf("errs = %v, want none after downgrading", errs)
+	}
+}
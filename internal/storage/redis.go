@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// redisKeyPrefix namespaces plancritic's keys in a shared database.
+const redisKeyPrefix = "plancritic:"
+
+// redisDialTimeout bounds connecting when the context has no deadline.
+const redisDialTimeout = 10 * time.Second
+
+// Redis stores keys in a Redis database. It speaks the RESP protocol
+// directly and opens one connection per operation, which suits the
+// handful of requests a review makes.
+type Redis struct {
+	addr     string
+	tls      bool
+	username string
+	password string
+	db       int
+}
+
+// NewRedis parses a redis://[user:password@]host[:port][/db] URL
+// (rediss:// for TLS).
+func NewRedis(rawURL string) (*Redis, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") || u.Hostname() == "" {
+		return nil, fmt.Errorf("storage: invalid redis URL %q: want redis://[:password@]host:port[/db]", rawURL)
+	}
+	r := &Redis{addr: u.Host, tls: u.Scheme == "rediss"}
+	if u.Port() == "" {
+		r.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		r.username = u.User.Username()
+		r.password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if r.db, err = strconv.Atoi(db); err != nil || r.db < 0 {
+			return nil, fmt.Errorf("storage: invalid redis database %q", db)
+		}
+	}
+	return r, nil
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	v, err := r.do(ctx, "GET", redisKeyPrefix+key)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, ErrNotFound
+	}
+	return v, nil
+}
+
+func (r *Redis) Put(ctx context.Context, key string, data []byte) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	_, err := r.do(ctx, "SET", redisKeyPrefix+key, string(data))
+	return err
+}
+
+func (r *Redis) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	_, err := r.do(ctx, "DEL", redisKeyPrefix+key)
+	return err
+}
+
+// do runs one command on a fresh connection, authenticating and
+// selecting the database first. A nil reply (missing key) is a nil
+// slice with a nil error.
+func (r *Redis) do(ctx context.Context, args ...string) ([]byte, error) {
+	d := net.Dialer{Timeout: redisDialTimeout}
+	var conn net.Conn
+	var err error
+	if r.tls {
+		host, _, _ := net.SplitHostPort(r.addr)
+		conn, err = (&tls.Dialer{NetDialer: &d, Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", r.addr)
+	} else {
+		conn, err = d.DialContext(ctx, "tcp", r.addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("storage: redis: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	rd := bufio.NewReader(conn)
+	var cmds [][]string
+	if r.password != "" {
+		if r.username != "" {
+			cmds = append(cmds, []string{"AUTH", r.username, r.password})
+		} else {
+			cmds = append(cmds, []string{"AUTH", r.password})
+		}
+	}
+	if r.db != 0 {
+		cmds = append(cmds, []string{"SELECT", strconv.Itoa(r.db)})
+	}
+	cmds = append(cmds, args)
+
+	var reply []byte
+	for _, cmd := range cmds {
+		if _, err := conn.Write(encodeRESP(cmd)); err != nil {
+			return nil, fmt.Errorf("storage: redis %s: %w", cmd[0], err)
+		}
+		if reply, err = readRESP(rd); err != nil {
+			return nil, fmt.Errorf("storage: redis %s: %w", cmd[0], err)
+		}
+	}
+	return reply, nil
+}
+
+// encodeRESP encodes a command as a RESP array of bulk strings.
+func encodeRESP(args []string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	return []byte(b.String())
+}
+
+// readRESP reads one reply. Simple strings and integers are returned as
+// text, bulk strings as their bytes, a nil bulk string as nil, and an
+// error reply as an error. Array replies are not used by this client.
+func readRESP(rd *bufio.Reader) ([]byte, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty reply")
+	}
+	switch line[0] {
+	case '+', ':':
+		return []byte(line[1:]), nil
+	case '-':
+		return nil, errors.New(line[1:])
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("bad bulk length %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		if n > maxValueBytes {
+			return nil, fmt.Errorf("bulk reply of %d bytes exceeds %d", n, maxValueBytes)
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(rd, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	default:
+		return nil, fmt.Errorf("unsupported reply %q", line)
+	}
+}
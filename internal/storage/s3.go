@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dshills/plancritic/internal/awssig"
+)
+
+// S3 stores keys as objects under a bucket prefix, using SigV4-signed
+// requests. Region, endpoint, and credentials come from the standard
+// AWS environment variables (see package awssig).
+type S3 struct {
+	bucket   string
+	prefix   string
+	region   string
+	endpoint string
+	creds    awssig.Credentials
+	client   *http.Client
+}
+
+// NewS3 parses an s3://bucket/prefix/ URL.
+func NewS3(rawURL string) (*S3, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "s3" || u.Host == "" {
+		return nil, fmt.Errorf("storage: invalid s3 URL %q: want s3://bucket/prefix/", rawURL)
+	}
+	creds := awssig.EnvCredentials()
+	if !creds.Valid() {
+		return nil, fmt.Errorf("storage: s3: AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+	}
+	prefix := strings.TrimPrefix(u.Path, "/")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &S3{
+		bucket:   u.Host,
+		prefix:   prefix,
+		region:   awssig.EnvRegion(),
+		endpoint: awssig.EnvEndpoint(),
+		creds:    creds,
+		client:   &http.Client{Timeout: 2 * time.Minute},
+	}, nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.request(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if err := s3Status(resp, "GET"); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxValueBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("storage: s3 GET: %w", err)
+	}
+	if len(data) > maxValueBytes {
+		return nil, fmt.Errorf("storage: s3 GET %s: object exceeds %d bytes", key, maxValueBytes)
+	}
+	return data, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := s.request(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	return s3Status(resp, "PUT")
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	resp, err := s.request(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return s3Status(resp, "DELETE")
+}
+
+func (s *S3) request(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	objURL, err := awssig.ObjectURL(s.endpoint, s.region, s.bucket, s.prefix+key)
+	if err != nil {
+		return nil, fmt.Errorf("storage: s3: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, objURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("storage: s3: create request: %w", err)
+	}
+	awssig.Sign(req, body, s.creds, s.region, time.Now().UTC())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("storage: s3 %s: %w", method, err)
+	}
+	return resp, nil
+}
+
+func s3Status(resp *http.Response, method string) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("storage: s3 %s returned %d: %s", method, resp.StatusCode, strings.TrimSpace(string(body)))
+}
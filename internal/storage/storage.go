@@ -0,0 +1,78 @@
+// Package storage defines the key/value backend behind plancritic's
+// persistent state (the response cache and the question history), so a
+// shared server can keep that state in durable storage while a laptop
+// uses local files.
+//
+// Keys are slash-separated paths such as "responses/<hash>.json".
+// Backends store values as opaque bytes; callers own the encoding and
+// any expiry.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// maxValueBytes bounds a value read from a remote backend. Cached
+// reviews and question histories are a few KiB; the limit only stops a
+// misbehaving or hostile server from exhausting memory.
+const maxValueBytes = 16 << 20
+
+// ErrNotFound is returned by Get for a key with no value.
+var ErrNotFound = errors.New("storage: not found")
+
+// Backend is a key/value store. Implementations are safe for
+// concurrent use.
+type Backend interface {
+	// Get returns the value for key, or ErrNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Put stores data under key, replacing any previous value.
+	Put(ctx context.Context, key string, data []byte) error
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Config selects a backend.
+type Config struct {
+	// Backend is "fs" (the default), "redis", "s3", or "sqlite".
+	Backend string
+	// Dir is the root directory of the fs backend.
+	Dir string
+	// URL locates the redis (redis://[:password@]host:port[/db]) or
+	// s3 (s3://bucket/prefix/) backend, or is the sqlite database file.
+	URL string
+}
+
+// Open returns the backend cfg selects.
+func Open(cfg Config) (Backend, error) {
+	switch strings.ToLower(cfg.Backend) {
+	case "", "fs":
+		if cfg.Dir == "" {
+			return nil, errors.New("storage: fs backend needs a directory")
+		}
+		return NewFS(cfg.Dir), nil
+	case "redis":
+		return NewRedis(cfg.URL)
+	case "s3":
+		return NewS3(cfg.URL)
+	case "sqlite":
+		return NewSQLite(cfg.URL)
+	default:
+		return nil, fmt.Errorf("storage: unknown backend %q (want fs, redis, s3, or sqlite)", cfg.Backend)
+	}
+}
+
+// checkKey rejects keys that could escape a backend's namespace.
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("storage: invalid key %q", key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("storage: invalid key %q", key)
+		}
+	}
+	return nil
+}
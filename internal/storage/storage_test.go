@@ -0,0 +1,248 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// exercise runs the Backend contract against b.
+func exercise(t *testing.T, b Backend) {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := b.Get(ctx, "responses/a.json"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get missing = %v, want ErrNotFound", err)
+	}
+	if err := b.Put(ctx, "responses/a.json", []byte("one\r\ntwo")); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Put(ctx, "responses/a.json", []byte("three")); err != nil {
+		t.Fatal(err)
+	}
+	got, err := b.Get(ctx, "responses/a.json")
+	if err != nil || string(got) != "three" {
+		t.Fatalf("Get = %q, %v; want three", got, err)
+	}
+	if err := b.Delete(ctx, "responses/a.json"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Delete(ctx, "responses/a.json"); err != nil {
+		t.Fatalf("Delete missing = %v", err)
+	}
+	if _, err := b.Get(ctx, "responses/a.json"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get deleted = %v, want ErrNotFound", err)
+	}
+	for _, key := range []string{"", "../escape", "/abs", "a//b"} {
+		if err := b.Put(ctx, key, nil); err == nil {
+			t.Errorf("Put(%q) should fail", key)
+		}
+	}
+}
+
+func TestFS(t *testing.T) {
+	exercise(t, NewFS(t.TempDir()))
+}
+
+func TestSQLite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "plancritic.db")
+	b, err := NewSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exercise(t, b)
+	if err := b.Put(context.Background(), "responses/b.json", []byte("kept")); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err = NewSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	if got, err := b.Get(context.Background(), "responses/b.json"); err != nil || string(got) != "kept" {
+		t.Errorf("Get after reopen = %q, %v; want kept", got, err)
+	}
+}
+
+func TestRedis(t *testing.T) {
+	addr := fakeRedis(t, "secret")
+	b, err := NewRedis("redis://:secret@" + addr + "/2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exercise(t, b)
+	if err := b.Put(context.Background(), "huge", make([]byte, maxValueBytes+1)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Get(context.Background(), "huge"); err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Errorf("Get of an oversized value = %v, want a size error", err)
+	}
+
+	bad, _ := NewRedis("redis://:wrong@" + addr)
+	if _, err := bad.Get(context.Background(), "k"); err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Errorf("Get with bad password = %v", err)
+	}
+}
+
+func TestS3(t *testing.T) {
+	var mu sync.Mutex
+	objects := map[string][]byte{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+			http.Error(w, "unsigned", http.StatusForbidden)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write(data)
+		case http.MethodPut:
+			objects[r.URL.Path], _ = io.ReadAll(r.Body)
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_ENDPOINT_URL_S3", srv.URL)
+
+	b, err := NewS3("s3://bucket/state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exercise(t, b)
+	if err := b.Put(context.Background(), "k", []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := objects["/bucket/state/k"]; !ok {
+		t.Errorf("object not stored under the prefix: %v", objects)
+	}
+	mu.Lock()
+	objects["/bucket/state/huge"] = make([]byte, maxValueBytes+1)
+	mu.Unlock()
+	if _, err := b.Get(context.Background(), "huge"); err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Errorf("Get of an oversized object = %v, want a size error", err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	if b, err := Open(Config{Dir: t.TempDir()}); err != nil || b == nil {
+		t.Errorf("Open default = %v, %v", b, err)
+	}
+	for _, cfg := range []Config{
+		{Backend: "bolt", URL: "x"},
+		{Backend: "sqlite"},
+		{Backend: "fs"},
+		{Backend: "redis", URL: "http://host"},
+		{Backend: "s3", URL: "gs://bucket"},
+	} {
+		if _, err := Open(cfg); err == nil {
+			t.Errorf("Open(%+v) should fail", cfg)
+		}
+	}
+}
+
+// fakeRedis serves GET, SET, DEL, AUTH, and SELECT from memory and
+// returns its address.
+func fakeRedis(t *testing.T, password string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	var mu sync.Mutex
+	data := map[string]string{}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+				rd := bufio.NewReader(conn)
+				authed := false
+				for {
+					args, err := readCommand(rd)
+					if err != nil {
+						return
+					}
+					mu.Lock()
+					var reply string
+					switch cmd := strings.ToUpper(args[0]); {
+					case cmd == "AUTH":
+						authed = args[len(args)-1] == password
+						reply = "+OK\r\n"
+						if !authed {
+							reply = "-WRONGPASS invalid password\r\n"
+						}
+					case !authed:
+						reply = "-NOAUTH Authentication required.\r\n"
+					case cmd == "SELECT":
+						reply = "+OK\r\n"
+					case cmd == "GET":
+						if v, ok := data[args[1]]; ok {
+							reply = "$" + strconv.Itoa(len(v)) + "\r\n" + v + "\r\n"
+						} else {
+							reply = "$-1\r\n"
+						}
+					case cmd == "SET":
+						data[args[1]] = args[2]
+						reply = "+OK\r\n"
+					case cmd == "DEL":
+						delete(data, args[1])
+						reply = ":1\r\n"
+					}
+					mu.Unlock()
+					if _, err := conn.Write([]byte(reply)); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func readCommand(rd *bufio.Reader) ([]string, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, n)
+	for i := range args {
+		line, err := rd.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(rd, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
@@ -0,0 +1,185 @@
+// Package suppress implements the suppression file that hides
+// acknowledged findings from review output, either permanently or
+// until an expiry date (a snooze).
+package suppress
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dshills/plancritic/internal/review"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPath is the project suppression file, relative to the working
+// directory.
+const DefaultPath = ".plancritic/suppressions.yaml"
+
+// dateLayout is the format of Entry.Until.
+const dateLayout = "2006-01-02"
+
+// File is the on-disk suppression list.
+type File struct {
+	Suppressions []Entry `yaml:"suppressions"`
+}
+
+// Entry suppresses the finding with the given fingerprint. When Until
+// is set the suppression is a snooze: from that date on the finding is
+// reported (and can fail CI) again.
+type Entry struct {
+	Fingerprint string `yaml:"fingerprint"`
+	Title       string `yaml:"title,omitempty"`
+	Reason      string `yaml:"reason,omitempty"`
+	Until       string `yaml:"until,omitempty"`
+}
+
+// Expiry returns the end of the snooze, or the zero time for a
+// permanent suppression. A snooze "until 2025-09-01" covers that whole
+// day in local time.
+func (e Entry) Expiry() (time.Time, error) {
+	if e.Until == "" {
+		return time.Time{}, nil
+	}
+	d, err := time.ParseInLocation(dateLayout, e.Until, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("suppression %s: invalid until date %q (want YYYY-MM-DD)", e.Fingerprint, e.Until)
+	}
+	return d.AddDate(0, 0, 1), nil
+}
+
+// Load reads a suppression file. A missing file yields an empty File.
+func Load(path string) (*File, error) {
+	f := &File{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return f, nil
+		}
+		return nil, fmt.Errorf("suppress: read %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("suppress: parse %s: %w", path, err)
+	}
+	for _, e := range f.Suppressions {
+		if e.Fingerprint == "" {
+			return nil, fmt.Errorf("suppress: %s: entry without fingerprint", path)
+		}
+		if _, err := e.Expiry(); err != nil {
+			return nil, fmt.Errorf("suppress: %s: %w", path, err)
+		}
+	}
+	return f, nil
+}
+
+// Save writes f to path, creating parent directories as needed.
+func Save(path string, f *File) error {
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("suppress: marshal: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("suppress: mkdir: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("suppress: write %s: %w", path, err)
+	}
+	return nil
+}
+
+// Add inserts or replaces the entry for e.Fingerprint.
+func (f *File) Add(e Entry) {
+	for i := range f.Suppressions {
+		if f.Suppressions[i].Fingerprint == e.Fingerprint {
+			f.Suppressions[i] = e
+			return
+		}
+	}
+	f.Suppressions = append(f.Suppressions, e)
+}
+
+// Result reports what Apply did.
+type Result struct {
+	// Expired lists snoozes whose date has passed; their findings were
+	// kept in the review and tagged "suppression-expired".
+	Expired []Entry
+}
+
+// ExpiredTag marks a finding whose snooze has lapsed.
+const ExpiredTag = "suppression-expired"
+
+// Apply removes issues and questions matched by an active suppression
+// from r, recording them in r.Suppressed. Findings whose snooze expired
+// before now stay in the review and are tagged with ExpiredTag.
+// Fingerprints must already be assigned. The caller recomputes the
+// summary afterwards.
+func (f *File) Apply(r *review.Review, now time.Time) Result {
+	var res Result
+	if f == nil || len(f.Suppressions) == 0 {
+		return res
+	}
+	active := make(map[string]Entry)
+	expired := make(map[string]Entry)
+	for _, e := range f.Suppressions {
+		exp, _ := e.Expiry() // validated by Load
+		if !exp.IsZero() && !now.Before(exp) {
+			expired[e.Fingerprint] = e
+			continue
+		}
+		active[e.Fingerprint] = e
+	}
+
+	seenExpired := make(map[string]bool)
+	markExpired := func(fp string, tags []string) []string {
+		e, ok := expired[fp]
+		if !ok {
+			return tags
+		}
+		if !seenExpired[fp] {
+			seenExpired[fp] = true
+			res.Expired = append(res.Expired, e)
+		}
+		for _, t := range tags {
+			if t == ExpiredTag {
+				return tags
+			}
+		}
+		return append(tags, ExpiredTag)
+	}
+
+	issues := r.Issues[:0]
+	for _, iss := range r.Issues {
+		if e, ok := active[iss.Fingerprint]; ok {
+			r.Suppressed = append(r.Suppressed, suppressed(e, iss.ID, iss.Severity, iss.Title))
+			continue
+		}
+		iss.Tags = markExpired(iss.Fingerprint, iss.Tags)
+		issues = append(issues, iss)
+	}
+	r.Issues = issues
+
+	questions := r.Questions[:0]
+	for _, q := range r.Questions {
+		if e, ok := active[q.Fingerprint]; ok {
+			r.Suppressed = append(r.Suppressed, suppressed(e, q.ID, q.Severity, q.Question))
+			continue
+		}
+		_ = markExpired(q.Fingerprint, nil)
+		questions = append(questions, q)
+	}
+	r.Questions = questions
+	return res
+}
+
+func suppressed(e Entry, id string, sev review.Severity, title string) review.SuppressedFinding {
+	return review.SuppressedFinding{
+		Fingerprint: e.Fingerprint,
+		ID:          id,
+		Severity:    sev,
+		Title:       strings.TrimSpace(title),
+		Reason:      e.Reason,
+		Until:       e.Until,
+	}
+}
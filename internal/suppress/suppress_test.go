@@ -0,0 +1,79 @@
+package suppress
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dshills/plancritic/internal/review"
+)
+
+func sample() *review.Review {
+	r := &review.Review{
+		Issues: []review.Issue{
+			{ID: "ISSUE-0001", Severity: review.SeverityCritical, Category: review.CategoryAmbiguity, Title: "No rollback plan"},
+			{ID: "ISSUE-0002", Severity: review.SeverityWarn, Category: review.CategoryTestGap, Title: "Missing load tests"},
+		},
+		Questions: []review.Question{{ID: "Q-0001", Severity: review.SeverityInfo, Question: "Which region?"}},
+	}
+	review.AssignFingerprints(r)
+	return r
+}
+
+func TestApplyActiveAndExpired(t *testing.T) {
+	r := sample()
+	now := time.Date(2025, 9, 1, 12, 0, 0, 0, time.Local)
+	f := &File{Suppressions: []Entry{
+		{Fingerprint: r.Issues[0].Fingerprint, Reason: "tracked", Until: "2025-09-01"}, // last day, still active
+		{Fingerprint: r.Issues[1].Fingerprint, Reason: "debt", Until: "2025-08-31"},    // expired
+		{Fingerprint: r.Questions[0].Fingerprint, Reason: "known"},                     // permanent
+	}}
+
+	res := f.Apply(r, now)
+
+	if len(r.Issues) != 1 || r.Issues[0].ID != "ISSUE-0002" {
+		t.Fatalf("expected only the expired snooze to remain, got %+v", r.Issues)
+	}
+	if len(r.Issues[0].Tags) != 1 || r.Issues[0].Tags[0] != ExpiredTag {
+		t.Errorf("expired finding should be tagged, got %v", r.Issues[0].Tags)
+	}
+	if len(r.Questions) != 0 {
+		t.Errorf("question should be suppressed")
+	}
+	if len(r.Suppressed) != 2 || r.Suppressed[0].Until != "2025-09-01" {
+		t.Errorf("unexpected suppressed list: %+v", r.Suppressed)
+	}
+	if len(res.Expired) != 1 || res.Expired[0].Until != "2025-08-31" {
+		t.Errorf("unexpected expired list: %+v", res.Expired)
+	}
+}
+
+func TestLoadSaveRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "suppressions.yaml")
+	f, err := Load(path)
+	if err != nil || len(f.Suppressions) != 0 {
+		t.Fatalf("missing file should load empty: %v", err)
+	}
+	f.Add(Entry{Fingerprint: "abc", Reason: "one"})
+	f.Add(Entry{Fingerprint: "abc", Reason: "two", Until: "2030-01-01"})
+	if err := Save(path, f); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Suppressions) != 1 || got.Suppressions[0].Reason != "two" {
+		t.Errorf("Add should replace by fingerprint, got %+v", got.Suppressions)
+	}
+}
+
+func TestLoadRejectsBadDate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.yaml")
+	if err := Save(path, &File{Suppressions: []Entry{{Fingerprint: "x", Until: "next week"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("expected error for invalid until date")
+	}
+}
@@ -0,0 +1,32 @@
+// Package textnorm puts plan and context text in one canonical form, so
+// the line numbers and quotes the model cites match the text plancritic
+// checks them against whatever editor or OS wrote the file.
+package textnorm
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// bom is the byte order mark some Windows editors write at the start of
+// a UTF-8 file.
+const bom = "\ufeff"
+
+// Normalize strips a leading byte order mark, turns CRLF and lone CR
+// line endings into LF, and composes the text to NFC (see NFC).
+func Normalize(s string) string {
+	s = strings.TrimPrefix(s, bom)
+	if strings.Contains(s, "\r") {
+		s = strings.ReplaceAll(s, "\r\n", "\n")
+		s = strings.ReplaceAll(s, "\r", "\n")
+	}
+	return NFC(s)
+}
+
+// NFC composes decomposed characters, as macOS and some copy-paste
+// paths produce them, to their precomposed forms: "e" followed by a
+// combining acute accent becomes "é", and Hangul jamo become syllables.
+func NFC(s string) string {
+	return norm.NFC.String(s)
+}
@@ -0,0 +1,40 @@
+package textnorm
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"":                                   "",
+		"# Plan\n1. Ship it\n":               "# Plan\n1. Ship it\n",
+		"\ufeff# Plan\r\n\r\n1. Ship it\r\n": "# Plan\n\n1. Ship it\n",
+		"old\rmac\r":                         "old\nmac\n",
+		"\ufeff":                             "",
+		"a\ufeffb":                           "a\ufeffb",
+	}
+	for in, want := range cases {
+		if got := Normalize(in); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNFC(t *testing.T) {
+	cases := map[string]string{
+		"cafe\u0301":         "caf\u00e9",
+		"caf\u00e9":          "caf\u00e9",
+		"A\u030a":            "\u00c5",
+		"a\u0302\u0301":      "\u1ea5",
+		"a\u0301\u0323":      "\u1ea1\u0301", // marks reordered: dot below (220) before acute (230)
+		"e\u0301\u0301":      "\u00e9\u0301",
+		"\u1112\u1161\u11ab": "\ud55c",
+		"\u1100\u1161":       "\uac00",
+		"x\u0301":            "x\u0301",
+		"\u65e5\u672c\u8a9e": "\u65e5\u672c\u8a9e",
+		"\u2126":             "\u03a9", // the Ohm sign is a singleton for capital omega
+	}
+	for in, want := range cases {
+		if got := NFC(in); got != want {
+			t.Errorf("NFC(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// Package training records redacted prompt/response/triage triples as
+// JSONL for later fine-tuning or few-shot mining. Collection is opt-in
+// and gated on explicit consent in the config file (see the CLI).
+package training
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dshills/plancritic/internal/review"
+	"github.com/dshills/plancritic/internal/suppress"
+)
+
+// FileName is the JSONL file written inside the collection directory.
+const FileName = "plancritic-training.jsonl"
+
+// Exchange is one prompt and the model's raw reply.
+type Exchange struct {
+	Prompt   string `json:"prompt"`
+	Response string `json:"response"`
+}
+
+// Outcome is the human triage state of one finding at the time of the
+// run: "reported", "suppressed", "snoozed", or "suppression-expired".
+type Outcome struct {
+	Fingerprint string          `json:"fingerprint"`
+	Kind        string          `json:"kind"`
+	Severity    review.Severity `json:"severity"`
+	Category    review.Category `json:"category,omitempty"`
+	Outcome     string          `json:"outcome"`
+}
+
+// Record is one JSONL line.
+type Record struct {
+	Timestamp time.Time     `json:"timestamp"`
+	PlanHash  string        `json:"plan_hash"`
+	Profile   string        `json:"profile,omitempty"`
+	Model     string        `json:"model"`
+	Review    Exchange      `json:"review"`
+	Repair    *Exchange     `json:"repair,omitempty"`
+	Result    review.Review `json:"result"`
+	Triage    []Outcome     `json:"triage"`
+}
+
+// NewRecord builds a record from a finished review. Prompts are
+// expected to be redacted already (the pipeline redacts inputs before
+// building them); file names are dropped from the stored result so
+// records carry content hashes only.
+func NewRecord(rev review.Review, initial Exchange, repair *Exchange, at time.Time) Record {
+	return Record{
+		Timestamp: at.UTC(),
+		PlanHash:  rev.Input.PlanHash,
+		Profile:   rev.Input.Profile,
+		Model:     rev.Meta.Model,
+		Review:    initial,
+		Repair:    repair,
+		Result:    anonymize(rev),
+		Triage:    outcomes(&rev),
+	}
+}
+
+func anonymize(rev review.Review) review.Review {
+	rev.Input.PlanFile = ""
+	ctx := make([]review.ContextFile, len(rev.Input.ContextFiles))
+	for i, cf := range rev.Input.ContextFiles {
+		ctx[i] = review.ContextFile{Hash: cf.Hash}
+	}
+	rev.Input.ContextFiles = ctx
+	rev.Signoff = nil
+	return rev
+}
+
+func outcomes(rev *review.Review) []Outcome {
+	out := make([]Outcome, 0, len(rev.Issues)+len(rev.Questions)+len(rev.Suppressed))
+	for _, iss := range rev.Issues {
+		o := "reported"
+		for _, t := range iss.Tags {
+			if t == suppress.ExpiredTag {
+				o = t
+			}
+		}
+		out = append(out, Outcome{Fingerprint: iss.Fingerprint, Kind: "issue", Severity: iss.Severity, Category: iss.Category, Outcome: o})
+	}
+	for _, q := range rev.Questions {
+		out = append(out, Outcome{Fingerprint: q.Fingerprint, Kind: "question", Severity: q.Severity, Outcome: "reported"})
+	}
+	for _, s := range rev.Suppressed {
+		o := "suppressed"
+		if s.Until != "" {
+			o = "snoozed"
+		}
+		out = append(out, Outcome{Fingerprint: s.Fingerprint, Kind: "suppressed", Severity: s.Severity, Outcome: o})
+	}
+	return out
+}
+
+// Append writes rec as one line to dir/FileName, creating the
+// directory if needed. The file is created owner-only since it holds
+// plan content.
+func Append(dir string, rec Record) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("training: mkdir: %w", err)
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("training: marshal: %w", err)
+	}
+	f, err := os.OpenFile(filepath.Join(dir, FileName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("training: open: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("training: write: %w", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,65 @@
+package training
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dshills/plancritic/internal/review"
+)
+
+func TestAppendAnonymizedRecord(t *testing.T) {
+	rev := review.Review{
+		Input: review.Input{
+			PlanFile:     "secret-project-plan.md",
+			PlanHash:     "sha256:abc",
+			ContextFiles: []review.ContextFile{{Path: "acme-internal.md", Hash: "sha256:def"}},
+		},
+		Meta:       review.Meta{Model: "mock/(default)"},
+		Issues:     []review.Issue{{Fingerprint: "f1", Severity: review.SeverityWarn, Tags: []string{"suppression-expired"}}},
+		Questions:  []review.Question{{Fingerprint: "f2", Severity: review.SeverityInfo}},
+		Suppressed: []review.SuppressedFinding{{Fingerprint: "f3", Until: "2030-01-01"}, {Fingerprint: "f4"}},
+	}
+	dir := filepath.Join(t.TempDir(), "data")
+	rec := NewRecord(rev, Exchange{Prompt: "p", Response: "r"}, nil, time.Unix(0, 0))
+	if err := Append(dir, rec); err != nil {
+		t.Fatal(err)
+	}
+	if err := Append(dir, rec); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(filepath.Join(dir, FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	var lines []Record
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var r Record
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, r)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 JSONL lines, got %d", len(lines))
+	}
+	got := lines[0]
+	if got.Result.Input.PlanFile != "" || got.Result.Input.ContextFiles[0].Path != "" || got.Result.Input.ContextFiles[0].Hash != "sha256:def" {
+		t.Errorf("file names should be dropped, hashes kept: %+v", got.Result.Input)
+	}
+	want := map[string]string{"f1": "suppression-expired", "f2": "reported", "f3": "snoozed", "f4": "suppressed"}
+	for _, o := range got.Triage {
+		if want[o.Fingerprint] != o.Outcome {
+			t.Errorf("%s: outcome %q, want %q", o.Fingerprint, o.Outcome, want[o.Fingerprint])
+		}
+	}
+	if rev.Input.PlanFile == "" {
+		t.Error("NewRecord must not modify the caller's review")
+	}
+}
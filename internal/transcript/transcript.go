@@ -0,0 +1,82 @@
+// Package transcript writes one JSON file per LLM request and response
+// for debugging and auditing what was sent to the model.
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Status values for Entry.Status.
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+	// StatusCancelled marks a request abandoned because the run was
+	// interrupted before the provider answered.
+	StatusCancelled = "cancelled"
+)
+
+// Settings are the request settings recorded with an entry.
+type Settings struct {
+	Temperature       float64 `json:"temperature"`
+	MaxTokens         int     `json:"max_tokens,omitempty"`
+	Seed              *int    `json:"seed,omitempty"`
+	Timeout           string  `json:"timeout,omitempty"`
+	Prefill           string  `json:"prefill,omitempty"`
+	CachedContentName string  `json:"cached_content_name,omitempty"`
+}
+
+// Usage is the token usage the provider reported.
+type Usage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
+}
+
+// Entry is one request/response round-trip.
+type Entry struct {
+	Time time.Time `json:"time"`
+	// Kind is "review" for the initial request and "repair" for the
+	// schema repair request.
+	Kind      string   `json:"kind"`
+	Model     string   `json:"model"`
+	Settings  Settings `json:"settings"`
+	System    string   `json:"system,omitempty"`
+	Prompt    string   `json:"prompt"`
+	Response  string   `json:"response"`
+	LatencyMS int64    `json:"latency_ms"`
+	Status    string   `json:"status"`
+	Error     string   `json:"error,omitempty"`
+	Usage     Usage    `json:"usage"`
+}
+
+// Write stores e in dir as <timestamp>-<kind>-<random>.json, creating
+// dir as needed, and returns the file path. Timestamped names sort in
+// request order.
+func Write(dir string, e Entry) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("transcript: mkdir: %w", err)
+	}
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("transcript: marshal: %w", err)
+	}
+	stamp := strings.ReplaceAll(e.Time.UTC().Format("20060102T150405.000000000Z"), ".", "")
+	f, err := os.CreateTemp(dir, stamp+"-"+e.Kind+"-*.json")
+	if err != nil {
+		return "", fmt.Errorf("transcript: create: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return "", fmt.Errorf("transcript: write: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("transcript: close: %w", err)
+	}
+	return filepath.Clean(f.Name()), nil
+}
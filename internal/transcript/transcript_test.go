@@ -0,0 +1,40 @@
+package transcript
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "log")
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	first, err := Write(dir, Entry{Time: at, Kind: "review", Model: "mock/", Prompt: "p", Response: "r", Status: StatusOK})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Write(dir, Entry{Time: at.Add(time.Second), Kind: "repair", Status: StatusError, Error: "boom"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{filepath.Base(second), filepath.Base(first)}
+	sort.Strings(names)
+	if names[0] != filepath.Base(first) {
+		t.Errorf("file names should sort in request order: %v", names)
+	}
+
+	data, err := os.ReadFile(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Entry
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Kind != "review" || got.Prompt != "p" || got.Response != "r" || !got.Time.Equal(at) {
+		t.Errorf("entry = %+v", got)
+	}
+}
@@ -0,0 +1,214 @@
+// Package corpus exposes the plans, context files, and golden reviews
+// plancritic tests itself against, so integrations (editor plugins,
+// library callers) can exercise their code on known-good fixtures
+// without copying files out of this repository.
+//
+// Each Fixture pairs a plan and its context files with the review a
+// model returned for them. Replay that review through the mock provider
+// to get a deterministic plancritic.Check result:
+//
+//	f, _ := corpus.Load("simple")
+//	opts, cleanup, _ := f.MockCheckOptions()
+//	defer cleanup()
+//	res, err := plancritic.Check(ctx, opts)
+package corpus
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/dshills/plancritic/pkg/plancritic"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed fixtures
+var fixturesFS embed.FS
+
+// manifest is the fixture list, fixtures/corpus.yaml.
+const manifest = "corpus.yaml"
+
+// Document is one fixture file.
+type Document struct {
+	// Path is the file's path within FS, as in "plans/simple.md".
+	Path string
+	Text string
+}
+
+// Name is the file's base name, as a review records it.
+func (d Document) Name() string {
+	return path.Base(d.Path)
+}
+
+// Fixture is a plan, its context files, and the review a model returned
+// for them under Profile.
+type Fixture struct {
+	Name        string
+	Description string
+	Profile     string
+	Plan        Document
+	Contexts    []Document
+	// Golden is the model's review JSON, before plancritic's
+	// normalization; Check recomputes its summary and ordering.
+	Golden []byte
+}
+
+type manifestFile struct {
+	Fixtures []struct {
+		Name        string   `yaml:"name"`
+		Description string   `yaml:"description"`
+		Profile     string   `yaml:"profile"`
+		Plan        string   `yaml:"plan"`
+		Contexts    []string `yaml:"contexts"`
+		Golden      string   `yaml:"golden"`
+	} `yaml:"fixtures"`
+}
+
+// FS returns the fixture files, rooted at the directory holding
+// corpus.yaml, plans/, contexts/, and golden/.
+func FS() fs.FS {
+	sub, err := fs.Sub(fixturesFS, "fixtures")
+	if err != nil {
+		panic(err) // the directory is embedded above
+	}
+	return sub
+}
+
+// All returns every fixture, in manifest order.
+func All() ([]Fixture, error) {
+	fsys := FS()
+	data, err := fs.ReadFile(fsys, manifest)
+	if err != nil {
+		return nil, err
+	}
+	var m manifestFile
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("corpus: parse %s: %w", manifest, err)
+	}
+	fixtures := make([]Fixture, 0, len(m.Fixtures))
+	for _, e := range m.Fixtures {
+		f := Fixture{Name: e.Name, Description: e.Description, Profile: e.Profile}
+		if f.Plan, err = readDocument(fsys, e.Plan); err != nil {
+			return nil, fmt.Errorf("corpus: fixture %s: %w", e.Name, err)
+		}
+		for _, c := range e.Contexts {
+			doc, err := readDocument(fsys, c)
+			if err != nil {
+				return nil, fmt.Errorf("corpus: fixture %s: %w", e.Name, err)
+			}
+			f.Contexts = append(f.Contexts, doc)
+		}
+		if e.Golden != "" {
+			if f.Golden, err = fs.ReadFile(fsys, e.Golden); err != nil {
+				return nil, fmt.Errorf("corpus: fixture %s: %w", e.Name, err)
+			}
+		}
+		fixtures = append(fixtures, f)
+	}
+	return fixtures, nil
+}
+
+// Load returns the fixture called name.
+func Load(name string) (Fixture, error) {
+	fixtures, err := All()
+	if err != nil {
+		return Fixture{}, err
+	}
+	for _, f := range fixtures {
+		if f.Name == name {
+			return f, nil
+		}
+	}
+	return Fixture{}, fmt.Errorf("corpus: unknown fixture %q", name)
+}
+
+func readDocument(fsys fs.FS, name string) (Document, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return Document{}, err
+	}
+	return Document{Path: name, Text: string(data)}, nil
+}
+
+// Review decodes the golden review.
+func (f Fixture) Review() (*plancritic.Review, error) {
+	if len(f.Golden) == 0 {
+		return nil, fmt.Errorf("corpus: fixture %s has no golden review", f.Name)
+	}
+	var rev plancritic.Review
+	if err := json.Unmarshal(f.Golden, &rev); err != nil {
+		return nil, fmt.Errorf("corpus: fixture %s: parse golden review: %w", f.Name, err)
+	}
+	return &rev, nil
+}
+
+// CheckOptions returns plancritic.DefaultCheckOptions set to review the
+// fixture's plan and contexts under its profile, passed as text.
+// Callers choose the provider and model.
+func (f Fixture) CheckOptions() plancritic.CheckOptions {
+	opts := plancritic.DefaultCheckOptions()
+	opts.PlanName = f.Plan.Name()
+	opts.PlanText = f.Plan.Text
+	for _, c := range f.Contexts {
+		opts.ContextDocuments = append(opts.ContextDocuments, plancritic.ContextDocument{Name: c.Name(), Text: c.Text})
+	}
+	if f.Profile != "" {
+		opts.ProfileName = f.Profile
+	}
+	return opts
+}
+
+// MockCheckOptions returns options that review the fixture with the
+// mock provider replaying the golden review, so plancritic.Check runs
+// without network access or an API key and returns the same review
+// every time. The plan and contexts are written to a temporary
+// directory under their own names, which the golden evidence cites;
+// cleanup removes it.
+func (f Fixture) MockCheckOptions() (plancritic.CheckOptions, func(), error) {
+	if len(f.Golden) == 0 {
+		return plancritic.CheckOptions{}, func() {}, fmt.Errorf("corpus: fixture %s has no golden review", f.Name)
+	}
+	dir, err := os.MkdirTemp("", "plancritic-corpus-*")
+	if err != nil {
+		return plancritic.CheckOptions{}, func() {}, err
+	}
+	cleanup := func() { _ = os.RemoveAll(dir) }
+	fail := func(err error) (plancritic.CheckOptions, func(), error) {
+		cleanup()
+		return plancritic.CheckOptions{}, func() {}, err
+	}
+
+	opts := plancritic.DefaultCheckOptions()
+	if f.Profile != "" {
+		opts.ProfileName = f.Profile
+	}
+	write := func(name string, data []byte) (string, error) {
+		p := filepath.Join(dir, name)
+		return p, os.WriteFile(p, data, 0o600)
+	}
+	if opts.PlanPath, err = write(f.Plan.Name(), []byte(f.Plan.Text)); err != nil {
+		return fail(err)
+	}
+	for _, c := range f.Contexts {
+		p, err := write(c.Name(), []byte(c.Text))
+		if err != nil {
+			return fail(err)
+		}
+		opts.ContextPaths = append(opts.ContextPaths, p)
+	}
+	scenario, err := yaml.Marshal(map[string]string{"response": string(f.Golden)})
+	if err != nil {
+		return fail(err)
+	}
+	p, err := write("scenario.yaml", scenario)
+	if err != nil {
+		return fail(err)
+	}
+	opts.Model = "mock:" + p
+	opts.NoCache = true
+	return opts, cleanup, nil
+}
@@ -0,0 +1,80 @@
+package corpus
+
+import (
+	"context"
+	"io/fs"
+	"testing"
+
+	"github.com/dshills/plancritic/pkg/plancritic"
+)
+
+func TestAll(t *testing.T) {
+	fixtures, err := All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatal("corpus has no fixtures")
+	}
+	for _, f := range fixtures {
+		if f.Name == "" || f.Plan.Text == "" {
+			t.Errorf("fixture %+v has no name or plan", f)
+		}
+		if _, err := f.Review(); err != nil {
+			t.Error(err)
+		}
+		if _, err := fs.Stat(FS(), f.Plan.Path); err != nil {
+			t.Error(err)
+		}
+	}
+	if _, err := Load("missing"); err == nil {
+		t.Error("Load(missing) should fail")
+	}
+}
+
+func TestReplayGolden(t *testing.T) {
+	f, err := Load("simple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden, err := f.Review()
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts, cleanup, err := f.MockCheckOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	if opts.ProfileName != "go-backend" || len(opts.ContextPaths) != 1 {
+		t.Errorf("options = %+v", opts)
+	}
+
+	res, err := plancritic.Check(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Review.Summary.Verdict != golden.Summary.Verdict {
+		t.Errorf("verdict = %s, want %s", res.Review.Summary.Verdict, golden.Summary.Verdict)
+	}
+	if len(res.Review.Issues) != len(golden.Issues) {
+		t.Errorf("issues = %d, want %d", len(res.Review.Issues), len(golden.Issues))
+	}
+	if res.Review.Input.PlanFile != "simple.md" {
+		t.Errorf("plan_file = %q", res.Review.Input.PlanFile)
+	}
+}
+
+func TestCheckOptions(t *testing.T) {
+	f, err := Load("simple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := f.CheckOptions()
+	if opts.PlanName != "simple.md" || opts.PlanText != f.Plan.Text || opts.ProfileName != "go-backend" {
+		t.Errorf("plan options = %q, %q", opts.PlanName, opts.ProfileName)
+	}
+	if len(opts.ContextDocuments) != 1 || opts.ContextDocuments[0].Name != "constraints.md" {
+		t.Errorf("contexts = %+v", opts.ContextDocuments)
+	}
+}
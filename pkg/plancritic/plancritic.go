@@ -9,6 +9,8 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/dshills/plancritic/internal/config"
+	"github.com/dshills/plancritic/internal/hook"
 	"github.com/dshills/plancritic/internal/llm"
 	"github.com/dshills/plancritic/internal/profile"
 	"github.com/dshills/plancritic/internal/render"
@@ -26,28 +28,91 @@ type Patch = review.Patch
 type Checklist = review.Checklist
 type Evidence = review.Evidence
 type Meta = review.Meta
+type PlanMetrics = review.PlanMetrics
+type SuppressedFinding = review.SuppressedFinding
+type Agreement = review.Agreement
 type Severity = review.Severity
 type Verdict = review.Verdict
 type ModelInfo = llm.ModelInfo
 
+// PostProcessor modifies a finished review before it is returned; see
+// CheckOptions.PostProcessors.
+type PostProcessor = hook.PostProcessor
+
+// PostProcessFunc adapts a function to PostProcessor.
+type PostProcessFunc = hook.Func
+
+// ExecPostProcessor returns a PostProcessor that pipes the review JSON
+// through command and reads the modified review from its stdout.
+func ExecPostProcessor(command string) (PostProcessor, error) {
+	return hook.NewExec(command)
+}
+
 type Error = reviewer.Error
 
+// Limiter bounds the provider requests in flight across the Check calls
+// that share it; see CheckOptions.Limiter.
+type Limiter = reviewer.Limiter
+
+// NewLimiter returns a Limiter allowing n requests at once, or with n
+// of 0 the provider default per provider. Create one per process.
+func NewLimiter(n int) *Limiter {
+	return reviewer.NewLimiter(n)
+}
+
+// ErrorClass categorizes a provider failure; see Error.Class.
+type ErrorClass = llm.ErrorClass
+
+const (
+	ClassAuth           = llm.ClassAuth
+	ClassQuota          = llm.ClassQuota
+	ClassRateLimit      = llm.ClassRateLimit
+	ClassTransient      = llm.ClassTransient
+	ClassInvalidRequest = llm.ClassInvalidRequest
+	ClassContentFilter  = llm.ClassContentFilter
+	ClassUnknown        = llm.ClassUnknown
+)
+
 type ContextDocument struct {
 	Name string `json:"name"`
 	Text string `json:"text"`
 }
 
 type CheckOptions struct {
-	Version           string
-	PlanPath          string
-	PlanName          string
-	PlanText          string
-	ContextPaths      []string
-	ContextDocuments  []ContextDocument
-	ProfileName       string
-	Strict            bool
-	ProviderName      string
-	Model             string
+	Version          string
+	PlanPath         string
+	PlanName         string
+	PlanText         string
+	ContextPaths     []string
+	ContextDocuments []ContextDocument
+	// JointPlanPaths are further plan documents reviewed together with
+	// the plan, as --joint does.
+	JointPlanPaths []string
+	// Constraints are key=value overrides of the profile's
+	// constraints, as --constraint gives them.
+	Constraints []string
+	// DisableCategories are issue categories whose findings are
+	// dropped, as --disable-category gives them.
+	DisableCategories []string
+	// Since and BaselinePath make the review incremental, as --since
+	// and --baseline do.
+	Since            string
+	BaselinePath     string
+	ProfileName      string
+	Strict           bool
+	ProviderName     string
+	APIBase          string
+	Endpoint         string
+	Proxy            string
+	CACertFile       string
+	APIKeyFile       string
+	SuppressionsPath string
+	Model            string
+	// Aliases maps short model names (e.g. "fast") to model specs for
+	// Model and Ensemble. Nil reads the aliases of the user and project
+	// config files, as the CLI does.
+	Aliases map[string]string
+	// MaxTokens caps each response; 0 scales it with the plan size.
 	MaxTokens         int
 	MaxIssues         int
 	MaxQuestions      int
@@ -63,6 +128,43 @@ type CheckOptions struct {
 	Verbose           bool
 	Debug             bool
 	DebugDir          string
+	Language          string
+	PostProcessors    []PostProcessor
+	Ensemble          []string
+	MinAgreement      int
+	// Concurrency bounds the ensemble calls run at once; 0 uses the
+	// provider default.
+	Concurrency int
+	// Limiter, when set, bounds the provider requests of every Check
+	// given the same Limiter; Concurrency is then ignored.
+	Limiter *Limiter
+	// MaxInputBytes bounds each plan and context document; 0 uses the
+	// 2 MiB default.
+	MaxInputBytes     int64
+	ResponseCache     bool
+	ResponseCacheTTL  string
+	ResponseCacheDir  string
+	NoPrefill         bool
+	LogLLMDir         string
+	MaxDuration       string
+	MaxRepairAttempts int
+	StorageBackend    string
+	StorageURL        string
+	ReasoningEffort   string
+	MinFindingsSanity int
+	// ForceReview sends empty or stub plans to the model instead of
+	// failing them locally.
+	ForceReview bool
+	// GroundedVerdict also computes the verdict without UNVERIFIED and
+	// assumption findings, in Summary.Grounded.
+	GroundedVerdict bool
+	// Mode is "full" (the default) or "checklist", which evaluates only
+	// the profile checklists.
+	Mode        string
+	EmbedInputs bool
+	// Classifications maps data classifications that plans and
+	// profiles declare to the providers allowed to review them.
+	Classifications map[string][]string
 }
 
 type CheckResult struct {
@@ -79,7 +181,6 @@ func DefaultCheckOptions() CheckOptions {
 	return CheckOptions{
 		Version:           "api",
 		ProfileName:       "general",
-		MaxTokens:         4096,
 		MaxIssues:         50,
 		MaxQuestions:      20,
 		Timeout:           "5m",
@@ -94,6 +195,14 @@ func Check(ctx context.Context, opts CheckOptions) (*CheckResult, error) {
 	if opts.Version == "" {
 		opts.Version = "api"
 	}
+	aliases := opts.Aliases
+	if aliases == nil {
+		cfg, err := config.Load()
+		if err != nil {
+			return nil, reviewer.Errorf(3, "%v", err)
+		}
+		aliases = cfg.Aliases()
+	}
 	planPath, contextPaths, cleanup, err := materializeInputs(opts)
 	if err != nil {
 		return nil, err
@@ -102,9 +211,20 @@ func Check(ctx context.Context, opts CheckOptions) (*CheckResult, error) {
 
 	rev, err := reviewer.Run(ctx, planPath, reviewer.Options{
 		ContextPaths:      contextPaths,
+		JointPlans:        opts.JointPlanPaths,
+		Since:             opts.Since,
+		BaselinePath:      opts.BaselinePath,
 		ProfileName:       opts.ProfileName,
+		Constraints:       opts.Constraints,
+		DisableCategories: opts.DisableCategories,
 		Strict:            opts.Strict,
 		ProviderName:      opts.ProviderName,
+		APIBase:           opts.APIBase,
+		Endpoint:          opts.Endpoint,
+		Proxy:             opts.Proxy,
+		CACertFile:        opts.CACertFile,
+		APIKeys:           llm.KeySources{File: opts.APIKeyFile},
+		SuppressionsPath:  opts.SuppressionsPath,
 		Model:             opts.Model,
 		MaxTokens:         opts.MaxTokens,
 		MaxIssues:         opts.MaxIssues,
@@ -121,6 +241,30 @@ func Check(ctx context.Context, opts CheckOptions) (*CheckResult, error) {
 		Verbose:           opts.Verbose,
 		Debug:             opts.Debug,
 		DebugDir:          opts.DebugDir,
+		Language:          opts.Language,
+		PostProcessors:    opts.PostProcessors,
+		Ensemble:          opts.Ensemble,
+		Aliases:           aliases,
+		MinAgreement:      opts.MinAgreement,
+		Concurrency:       opts.Concurrency,
+		Limiter:           opts.Limiter,
+		MaxInputBytes:     opts.MaxInputBytes,
+		ResponseCache:     opts.ResponseCache,
+		ResponseCacheTTL:  opts.ResponseCacheTTL,
+		ResponseCacheDir:  opts.ResponseCacheDir,
+		NoPrefill:         opts.NoPrefill,
+		LogLLMDir:         opts.LogLLMDir,
+		MaxDuration:       opts.MaxDuration,
+		MaxRepairAttempts: opts.MaxRepairAttempts,
+		StorageBackend:    opts.StorageBackend,
+		StorageURL:        opts.StorageURL,
+		ReasoningEffort:   opts.ReasoningEffort,
+		MinFindingsSanity: opts.MinFindingsSanity,
+		ForceReview:       opts.ForceReview,
+		GroundedVerdict:   opts.GroundedVerdict,
+		Mode:              opts.Mode,
+		EmbedInputs:       opts.EmbedInputs,
+		Classifications:   opts.Classifications,
 	}, opts.Version)
 	if err != nil {
 		return nil, err
@@ -134,6 +278,10 @@ func RenderReview(review *Review, format string) ([]byte, error) {
 		return json.MarshalIndent(review, "", "  ")
 	case "md":
 		return []byte(render.Markdown(review)), nil
+	case "html":
+		// The plan text comes from the review's embedded inputs, if any.
+		html, err := render.HTML(review, "", render.DefaultTheme)
+		return []byte(html), err
 	default:
 		return nil, fmt.Errorf("unknown format: %s", format)
 	}
@@ -160,7 +308,16 @@ func FilterReviewBySeverity(input *Review, threshold string) *Review {
 	filtered := cloneReview(input)
 	filtered.Issues = review.FilterBySeverity(filtered.Issues, threshold)
 	filtered.Questions = review.FilterQuestionsBySeverity(filtered.Questions, threshold)
-	filtered.Summary = review.ComputeSummary(filtered.Issues)
+	if input.Summary.FailedChecks > 0 {
+		filtered.Summary = review.ChecklistSummary(filtered.Issues, filtered.Checklists)
+	} else {
+		filtered.Summary = review.ComputeSummary(filtered.Issues)
+	}
+	filtered.Summary.PlanMetrics = input.Summary.PlanMetrics
+	if input.Summary.Grounded != nil {
+		g := review.GroundedSummary(filtered.Issues)
+		filtered.Summary.Grounded = &g
+	}
 	return filtered
 }
 
@@ -227,7 +384,7 @@ func DefaultModelForProvider(provider string) string {
 }
 
 func ProfileNames() []string {
-	names, err := profile.List()
+	names, err := profile.Names()
 	if err != nil {
 		return nil
 	}
@@ -301,5 +458,6 @@ func cloneReview(input *Review) *Review {
 	clone.Issues = append([]Issue(nil), input.Issues...)
 	clone.Patches = append([]Patch(nil), input.Patches...)
 	clone.Checklists = append([]Checklist(nil), input.Checklists...)
+	clone.Suppressed = append([]SuppressedFinding(nil), input.Suppressed...)
 	return &clone
 }